@@ -12,7 +12,7 @@ import (
 )
 
 var addCmd = &cobra.Command{
-	Use:                "add [--description <desc>] [--] <command> [args...]",
+	Use:                "add [options] [--] <command> [args...]",
 	Short:              "Create and start a new background job",
 	DisableFlagParsing: true,
 	Long: `Create and start a new background job that continues running after the CLI exits.
@@ -20,6 +20,38 @@ var addCmd = &cobra.Command{
 The job is started as a detached process and assigned a unique job ID.
 Use this ID with other commands to manage the job.
 
+Options (must come before the command):
+  -d, --description <desc>    Human-readable description of the job
+  -p, --priority <level>      Scheduling priority: low, normal or high
+      --memory-limit <size>   Memory limit per run, e.g. 512M or 2G (Linux cgroup v2); off removes it
+      --cpu-limit <cores>     CPU limit per run in cores, e.g. 1.5 (Linux cgroup v2); off removes it
+      --on-start <cmd>        Shell command to run after each run starts
+      --on-success <cmd>      Shell command to run after a run exits with code 0
+      --on-failure <cmd>      Shell command to run after a run exits with a non-zero code
+      --on-slow <cmd>         Shell command to run when a run passes the job's slow threshold
+                              (off removes a hook)
+      --stdin <mode>          null (default, reads get EOF) or open (stdin stays open)
+      --stdin-from <file>     Feed the file to the command's stdin
+      --log-format <format>   text (default) or json (stdout is JSON lines with a level)
+      --timestamps            Record when each output line was written (see gob logs --timestamps)
+      --no-timestamps         Stop recording when output lines were written
+      --combine-output        Write stderr into the stdout log, keeping the order of lines
+      --no-combine-output     Write stderr to its own log again
+      --keep-head <size>      Only keep the first part of each log of a run, e.g. 64K
+      --keep-tail <size>      Only keep the last part of each log of a run, e.g. 1M
+      --log-dir <dir>         Write the job's logs to this directory instead of gob's
+      --in-container <image>  Run the command in a container of the image (docker or podman)
+      --dev-env <env>         Run the command in the project's nix (flake.nix) or direnv (.envrc) environment
+      --sandbox <mode>        Only let the command write in the project (project) or nowhere (readonly),
+                              besides a scratch TMPDIR; off removes it (default $GOB_SANDBOX)
+      --workdir <dir>         Run the command in dir, keeping the job in the current directory
+      --reload-signal <sig>   Signal sent by 'gob reload' (default HUP)
+      --env <KEY=VALUE>       Set a variable in every run of the job (repeatable, replaces the job's)
+      --shell                 Run the command as one script with $GOB_SHELL -c (default sh)
+  -t, --tag <tag>             Tag the job (repeatable, replaces the job's tags)
+  -l, --label <label>         Label the started run, to group it with others (see 'gob summary')
+      --color <when>          Color output: auto (default), always or never
+
 Examples:
   # Add a long-running sleep
   gob add sleep 3600
@@ -33,6 +65,9 @@ Examples:
   # Quoted command strings also work
   gob add "make test"
 
+  # Run a pipeline through a shell
+  gob add --shell "tail -f app.log | grep ERROR"
+
   # Optional -- separator is also supported
   gob add -- npm run --flag
 
@@ -40,6 +75,35 @@ Examples:
   gob add --description "Dev server" npm run dev
   gob add -d "Build watcher" -- npm run build:watch
 
+  # Add with a scheduling priority (low, normal or high)
+  # Low runs niced (+10) with idle-ish I/O; high asks for a negative nice value,
+  # which usually requires privileges and is otherwise ignored
+  gob add --priority low make build
+
+  # Limit memory and CPU (each run gets its own cgroup)
+  gob add --memory-limit 2G --cpu-limit 1.5 npm run dev
+
+  # Run a hook when the build fails (hooks get GOB_JOB_ID, GOB_RUN_ID,
+  # GOB_HOOK_EVENT and GOB_EXIT_CODE in their environment)
+  gob add --on-failure "make fmt" make build
+
+  # Tag jobs to filter and control them together
+  gob add --tag backend --tag slow make server
+
+  # Keep the first 64K and the last 1M of a chatty job's output; the rest
+  # is replaced by a marker when the run ends
+  gob add --keep-head 64K --keep-tail 1M npm run dev
+
+  # Keep stdin open for commands that exit on EOF
+  gob add --stdin open -- npx some-repl
+
+  # Run in the nix develop shell of the project's flake, also when the job
+  # is restarted from the TUI
+  gob add --dev-env nix npm run dev
+
+  # Let the command write only in the current directory and its TMPDIR
+  gob add --sandbox project -- npm install
+
 Output:
   Added job <job_id> running: <command>
 
@@ -52,44 +116,23 @@ Exit codes:
 			return fmt.Errorf("requires at least 1 arg(s)")
 		}
 
-		// Parse --description / -d flag manually (before --)
-		var description string
-		var commandArgs []string
-		for i := 0; i < len(args); i++ {
-			arg := args[i]
-			if arg == "--" {
-				// Everything after -- is the command
-				commandArgs = args[i+1:]
-				break
-			}
-			if arg == "--description" || arg == "-d" {
-				if i+1 >= len(args) {
-					return fmt.Errorf("--description requires a value")
-				}
-				description = args[i+1]
-				i++ // skip the value
-				continue
-			}
-			if strings.HasPrefix(arg, "--description=") {
-				description = strings.TrimPrefix(arg, "--description=")
-				continue
-			}
-			if strings.HasPrefix(arg, "-d=") {
-				description = strings.TrimPrefix(arg, "-d=")
-				continue
-			}
-			// Not a flag we recognize, treat rest as command
-			commandArgs = args[i:]
-			break
+		// Parse job flags manually (before --)
+		parsed, err := parseJobArgs(args)
+		if err != nil {
+			return err
 		}
+		commandArgs := parsed.command
 
-		if len(commandArgs) == 0 {
-			return fmt.Errorf("requires at least 1 arg(s)")
+		if parsed.followRestarts {
+			return fmt.Errorf("--follow-restarts can only be used with gob run")
+		}
+		if parsed.silent {
+			return fmt.Errorf("--silent can only be used with gob run")
 		}
 
-		// Handle quoted command string: "echo hello world" -> ["echo", "hello", "world"]
-		if len(commandArgs) == 1 && strings.Contains(commandArgs[0], " ") {
-			commandArgs = strings.Fields(commandArgs[0])
+		opts, err := parsed.options()
+		if err != nil {
+			return err
 		}
 
 		// Connect to daemon
@@ -121,7 +164,7 @@ Exit codes:
 		env := os.Environ()
 
 		// Add job via daemon (blocked=false since CLI doesn't set blocked status)
-		result, err := client.Add(commandArgs, cwd, env, description, false)
+		result, err := client.Add(commandArgs, cwd, env, opts)
 		if err != nil {
 			return fmt.Errorf("failed to add job: %w", err)
 		}
@@ -139,6 +182,9 @@ Exit codes:
 		} else {
 			// Job was created or started
 			fmt.Printf("Added job %s running: %s\n", result.Job.ID, commandStr)
+			if result.Job.RunDir != "" {
+				fmt.Printf("  in %s\n", result.Job.RunDir)
+			}
 
 			// Show stats if job has previous runs
 			if result.Job.RunCount > 0 {
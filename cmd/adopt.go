@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var adoptCommand string
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <pid>",
+	Short: "Manage a process started outside gob as a job",
+	Long: `Register a running process as a job in the current directory.
+
+The process is not restarted: gob watches it until it exits, so stop, signal,
+ports and stats work as for other jobs, and restart starts the job's command.
+Its output is not captured, it keeps writing where it did, and its exit code
+is only known on Linux (read before the process is reaped).
+
+The job's command is the process's command line, or --command to give the
+command that restart should run (split on whitespace). If a job with that
+command exists in the current directory and is not running, the process
+becomes its new run.
+
+Examples:
+  # Adopt a dev server started in another terminal
+  gob adopt 12345
+
+  # Adopt it as the job restart should run
+  gob adopt 12345 --command "npm run dev"
+
+Output:
+  Adopted PID <pid> as job <job_id>: <command>
+
+Exit codes:
+  0: Process adopted
+  1: Error (process not found, already managed, or job already running)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pid, err := strconv.Atoi(args[0])
+		if err != nil || pid <= 0 {
+			return fmt.Errorf("invalid pid: %s", args[0])
+		}
+
+		command := strings.Fields(adoptCommand)
+		if cmd.Flags().Changed("command") && len(command) == 0 {
+			return fmt.Errorf("--command must not be empty")
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		job, err := client.Adopt(pid, command, cwd)
+		if err != nil {
+			return fmt.Errorf("failed to adopt process: %w", err)
+		}
+
+		fmt.Printf("Adopted PID %d as job %s: %s\n", pid, job.ID, strings.Join(job.Command, " "))
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(adoptCmd)
+	adoptCmd.Flags().StringVar(&adoptCommand, "command", "",
+		"Command of the job, run by restart (default: the process's command line)")
+}
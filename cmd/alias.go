@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var aliasClear bool
+
+var aliasCmd = &cobra.Command{
+	Use:               "alias <job_id> [alias]",
+	Short:             "Give a job a human-friendly name",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Assign an alias to a job.
+
+The alias is accepted anywhere a job ID is expected and is shown in listings.
+Aliases may contain letters, digits, '.', '_' and '-', and must be unique.
+
+Examples:
+  # Name job abc "web-server"
+  gob alias abc web-server
+
+  # Use the alias instead of the ID
+  gob stop web-server
+  gob logs web-server
+
+  # Rename it
+  gob alias web-server web
+
+  # Remove the alias
+  gob alias web --clear
+
+Output:
+  Job abc is now aliased web-server
+
+Exit codes:
+  0: Alias set
+  1: Error (job not found, invalid or duplicate alias)`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+
+		var alias string
+		switch {
+		case aliasClear && len(args) == 2:
+			return fmt.Errorf("--clear does not take an alias")
+		case !aliasClear && len(args) == 1:
+			return fmt.Errorf("requires an alias (or --clear to remove it)")
+		case !aliasClear:
+			alias = args[1]
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		job, err := client.SetAlias(jobID, alias)
+		if err != nil {
+			return err
+		}
+
+		if alias == "" {
+			fmt.Printf("Removed alias from job %s\n", job.ID)
+		} else {
+			fmt.Printf("Job %s is now aliased %s\n", job.ID, job.Alias)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(aliasCmd)
+	aliasCmd.Flags().BoolVar(&aliasClear, "clear", false,
+		"Remove the job's alias")
+}
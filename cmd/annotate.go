@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var annotateClear bool
+
+var annotateCmd = &cobra.Command{
+	Use:               "annotate <run_id> [note...]",
+	Short:             "Attach a note to a run",
+	ValidArgsFunction: completeRunIDs,
+	Long: `Attach a note to a run, to remember what it was about.
+
+The note is stored with the run and shown in 'gob runs', 'gob history', the
+job detail and the runs panel of the TUI. It replaces the run's current note.
+The words after the run ID are joined with spaces, so quoting is optional.
+Notes are a single line of at most 1024 characters.
+
+Examples:
+  # Note why run abc-4 failed
+  gob annotate abc-4 broke after upgrading node
+
+  # Remove the note
+  gob annotate abc-4 --clear
+
+Output:
+  Run abc-4: broke after upgrading node
+
+Exit codes:
+  0: Note set
+  1: Error (run not found, invalid note)`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runID := args[0]
+
+		var note string
+		switch {
+		case annotateClear && len(args) > 1:
+			return fmt.Errorf("--clear does not take a note")
+		case !annotateClear && len(args) == 1:
+			return fmt.Errorf("requires a note (or --clear to remove it)")
+		case !annotateClear:
+			note = strings.Join(args[1:], " ")
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		run, err := client.AnnotateRun(runID, note)
+		if err != nil {
+			return err
+		}
+
+		if run.Note == "" {
+			fmt.Printf("Removed note from run %s\n", run.ID)
+		} else {
+			fmt.Printf("Run %s: %s\n", run.ID, run.Note)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(annotateCmd)
+	annotateCmd.Flags().BoolVar(&annotateClear, "clear", false,
+		"Remove the run's note")
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var approvalsJSON bool
+
+var approvalsCmd = &cobra.Command{
+	Use:   "approvals",
+	Short: "Show the requests waiting for approval",
+	Long: `Show the requests held by the approval policy until someone approves them.
+
+The policy lives in $XDG_CONFIG_HOME/gob/policy.toml and holds the
+destructive requests of some clients, typically agents:
+
+  clients = ["claude*", "codex"]           # client names the policy applies to
+  require = ["remove", "stop_all", "kill"] # actions needing approval
+  commands = ["rm -rf *", "git push*"]     # add and run commands needing approval
+  timeout = "5m"                           # denied when nobody answers (default 5m)
+
+stop_all covers 'gob shutdown' too, as it stops every job. kill covers
+SIGKILL, including 'gob stop --force' and 'gob kill-orphans --force'.
+Clients are told apart by $GOB_CLIENT or the name of the process running gob
+(see 'gob audit'), and on Linux and macOS by the names of the processes gob
+runs under. The daemon reads the policy when it starts.
+
+Held requests wait until they are approved with 'gob approve' or in the TUI,
+denied with 'gob deny', or the timeout passes. Clients the policy applies to
+can't approve requests. Decisions are recorded in 'gob audit'.
+
+Output:
+  One line per request with its ID, time, client, type, target and reason:
+    #3  2026-01-05 14:03:12  claude  remove  V3x0QqI  (remove)
+
+Exit codes:
+  0: Success
+  1: Error (connection failed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		approvals, err := client.Approvals()
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if approvalsJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(approvals)
+		}
+
+		if len(approvals) == 0 {
+			fmt.Fprintln(out, "No requests waiting for approval")
+			return nil
+		}
+		for _, a := range approvals {
+			fmt.Fprintln(out, formatApproval(a))
+		}
+		return nil
+	},
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <approval_id>",
+	Short: "Approve a request waiting for approval",
+	Long: `Approve a request held by the approval policy (see 'gob approvals'). The
+request is carried out and its client gets the result.
+
+Output:
+  Approved #3: claude remove V3x0QqI
+
+Exit codes:
+  0: Request approved
+  1: Error (not waiting for approval, client not allowed to approve)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return decideApproval(args[0], true)
+	},
+}
+
+var denyCmd = &cobra.Command{
+	Use:   "deny <approval_id>",
+	Short: "Deny a request waiting for approval",
+	Long: `Deny a request held by the approval policy (see 'gob approvals'). The
+request fails without being carried out.
+
+Output:
+  Denied #3: claude remove V3x0QqI
+
+Exit codes:
+  0: Request denied
+  1: Error (not waiting for approval, client not allowed to deny)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return decideApproval(args[0], false)
+	},
+}
+
+// decideApproval approves or denies a request waiting for approval and
+// prints the outcome
+func decideApproval(arg string, approve bool) error {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid approval ID: %s", arg)
+	}
+
+	// Connect to daemon
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	approval, err := client.Approve(id, approve)
+	if err != nil {
+		return err
+	}
+
+	verb := "Denied"
+	if approve {
+		verb = "Approved"
+	}
+	fmt.Printf("%s #%d: %s %s %s\n", verb, approval.ID, approval.Client, approval.Type, approval.Target)
+	return nil
+}
+
+// formatApproval formats a request waiting for approval as a line of text
+func formatApproval(a daemon.Approval) string {
+	when := a.Time
+	if t, err := time.Parse(time.RFC3339, a.Time); err == nil {
+		when = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	line := fmt.Sprintf("#%d  %s  %s  %s", a.ID, when, a.Client, a.Type)
+	if a.Target != "" {
+		line += "  " + a.Target
+	}
+	return line + "  (" + a.Reason + ")"
+}
+
+func init() {
+	RootCmd.AddCommand(approvalsCmd)
+	RootCmd.AddCommand(approveCmd)
+	RootCmd.AddCommand(denyCmd)
+	approvalsCmd.Flags().BoolVar(&approvalsJSON, "json", false,
+		"Output in JSON format")
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince  string
+	auditClient string
+	auditLimit  int
+	auditJSON   bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the requests that changed jobs, and who sent them",
+	Long: `Show the audit log: every request that changed state (add, run, start,
+stop, restart, remove, signal, ...), the client that sent it, when, and
+whether it succeeded. The daemon keeps the log for 30 days.
+
+The client is $GOB_CLIENT if set, or the name of the process running gob
+(the shell, editor or agent). Set GOB_CLIENT to tell agents apart.
+
+Each connection to the daemon may send 30 state-changing requests at once
+and 2 more every second after that; requests over the limit fail and are
+recorded as such. This stops an agent restarting a job in a loop over one
+connection from taking the machine down. The limit doesn't go by client
+name, which clients choose: shells of the same name don't share it.
+Reads (list, logs, stats, ...) are neither limited nor recorded.
+
+Output:
+  One line per request with its time, client, type, target, duration and result:
+    2026-01-05 14:03:12  claude  restart  V3x0QqI  12ms  ok
+
+Examples:
+  # The last 20 requests
+  gob audit --limit 20
+
+  # What an agent did in the last hour
+  gob audit --client claude --since 1h
+
+  # As JSON, one entry per line
+  gob audit --json
+
+Exit codes:
+  0: Success
+  1: Error (invalid duration, connection failed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if auditLimit < 0 {
+			return fmt.Errorf("--limit must not be negative")
+		}
+
+		filter := daemon.AuditFilter{Client: auditClient, Limit: auditLimit}
+		if auditSince != "" {
+			since, err := parseAge(auditSince)
+			if err != nil {
+				return err
+			}
+			filter.Since = time.Now().Add(-since)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		entries, err := client.Audit(filter)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if auditJSON {
+			enc := json.NewEncoder(out)
+			for _, entry := range entries {
+				if err := enc.Encode(entry); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if len(entries) == 0 {
+			fmt.Fprintln(out, "No requests recorded")
+			return nil
+		}
+		for _, entry := range entries {
+			fmt.Fprintln(out, formatAuditEntry(entry))
+		}
+		return nil
+	},
+}
+
+// formatAuditEntry formats an audit log entry as a line of text
+func formatAuditEntry(e daemon.AuditEntry) string {
+	when := e.Time
+	if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+		when = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	line := fmt.Sprintf("%s  %s  %s", when, e.Client, e.Type)
+	if e.Target != "" {
+		line += "  " + e.Target
+	}
+	if e.Approval != "" {
+		line += "  [" + e.Approval + "]"
+	}
+	line += fmt.Sprintf("  %dms", e.DurationMs)
+	if e.Success {
+		return line + "  ok"
+	}
+	return line + "  error: " + e.Error
+}
+
+func init() {
+	RootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().StringVarP(&auditSince, "since", "s", "",
+		"Show the requests recorded within this long (e.g. 30m, 12h, 2d)")
+	auditCmd.Flags().StringVarP(&auditClient, "client", "c", "",
+		"Show only the requests of this client")
+	auditCmd.Flags().IntVarP(&auditLimit, "limit", "n", 0,
+		"Show only the most recent requests")
+	auditCmd.Flags().BoolVar(&auditJSON, "json", false,
+		"Print entries as JSON, one per line")
+}
@@ -7,9 +7,13 @@ import (
 	"time"
 
 	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
 	"github.com/spf13/cobra"
 )
 
+var awaitFollowRestarts bool
+
 var awaitCmd = &cobra.Command{
 	Use:               "await <job_id>",
 	Short:             "Wait for a job to complete and show its output",
@@ -27,13 +31,25 @@ For stopped jobs:
 
 The job continues running in the background if you press Ctrl+C.
 
+With --follow-restarts, a run that is replaced by a new one (for example by
+'gob restart') does not end the wait: the output of the new run is followed
+instead. The job is complete when a run exits and no new run starts within
+2 seconds.
+
 Examples:
   # Wait for job abc to complete
   gob await abc
 
+  # Keep following abc when it is restarted
+  gob await --follow-restarts abc
+
 Output:
   Shows the job's stdout and stderr, followed by a summary.
 
+  For jobs with at least 3 successful runs, the wait starts with the typical
+  (average) duration and reports progress against it at each quarter:
+    [gob] ~75% of typical duration, ETA 12.0s
+
 Exit codes:
   Exits with the job's exit code (0 if successful, non-zero otherwise).
   Exits with 1 if there's an error (job not found, connection failed).`,
@@ -70,10 +86,25 @@ Exit codes:
 			stuckTimeout := CalculateStuckTimeout(avgDurationMs)
 
 			fmt.Printf("Awaiting job %s: %s\n", job.ID, commandStr)
+			if avgDurationMs > 0 {
+				typical := time.Duration(avgDurationMs) * time.Millisecond
+				fmt.Printf("  Typical duration: %s (average of %d successful runs)\n", formatDuration(typical), statsJob.SuccessCount)
+				fmt.Printf("  Progress: %s\n", formatProgressEstimate(time.Since(parseRunStart(job.StartedAt)), typical))
+			}
 			fmt.Printf("  Stuck detection: timeout after %s\n", formatDuration(stuckTimeout))
 
+			var restarts <-chan daemon.RunResponse
+			if awaitFollowRestarts {
+				var stop func()
+				restarts, stop, err = watchRestarts(job.ID, job.Workdir)
+				if err != nil {
+					return err
+				}
+				defer stop()
+			}
+
 			// Follow the output until completion
-			followResult, err := followJob(job.ID, job.PID, job.StdoutPath, avgDurationMs)
+			followResult, err := followJob(job.ID, job.PID, job.StdoutPath, job.StartedAt, avgDurationMs, restarts)
 			if err != nil {
 				return err
 			}
@@ -120,8 +151,8 @@ Exit codes:
 // printJobOutput prints the stdout and stderr of a stopped job
 func printJobOutput(job *daemon.JobResponse) error {
 	// Print stdout
-	if _, err := os.Stat(job.StdoutPath); err == nil {
-		content, err := os.ReadFile(job.StdoutPath)
+	if _, err := logfile.Stat(job.StdoutPath); err == nil {
+		content, err := logfile.ReadFile(job.StdoutPath)
 		if err != nil {
 			return fmt.Errorf("failed to read stdout: %w", err)
 		}
@@ -132,14 +163,14 @@ func printJobOutput(job *daemon.JobResponse) error {
 
 	// Print stderr
 	stderrPath := strings.Replace(job.StdoutPath, ".stdout.log", ".stderr.log", 1)
-	if _, err := os.Stat(stderrPath); err == nil {
-		content, err := os.ReadFile(stderrPath)
+	if _, err := logfile.Stat(stderrPath); err == nil {
+		content, err := logfile.ReadFile(stderrPath)
 		if err != nil {
 			return fmt.Errorf("failed to read stderr: %w", err)
 		}
 		if len(content) > 0 {
 			// Print stderr with yellow color
-			fmt.Fprintf(os.Stderr, "\033[33m%s\033[0m", string(content))
+			fmt.Fprint(os.Stderr, output.Colorize(os.Stderr, output.Yellow, string(content)))
 		}
 	}
 
@@ -164,9 +195,13 @@ func printJobSummary(job *daemon.JobResponse) {
 
 	// Show exit code
 	if job.ExitCode != nil {
-		fmt.Printf("  Exit code: %d\n", *job.ExitCode)
+		color := output.Green
+		if *job.ExitCode != 0 {
+			color = output.Red
+		}
+		fmt.Printf("  Exit code: %s\n", output.Colorize(os.Stdout, color, fmt.Sprint(*job.ExitCode)))
 	} else {
-		fmt.Printf("  Exit code: unknown (killed by signal)\n")
+		fmt.Printf("  Exit code: %s\n", output.Colorize(os.Stdout, output.Red, "unknown (killed by signal)"))
 	}
 }
 
@@ -189,5 +224,6 @@ func formatDuration(d time.Duration) string {
 }
 
 func init() {
+	awaitCmd.Flags().BoolVar(&awaitFollowRestarts, "follow-restarts", false, "Keep following the job when it starts a new run")
 	RootCmd.AddCommand(awaitCmd)
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	awaitPortPort    int
+	awaitPortTimeout int
+)
+
+// awaitPortInterval is how often the job's ports are checked
+const awaitPortInterval = 500 * time.Millisecond
+
+var awaitPortCmd = &cobra.Command{
+	Use:               "await-port <job_id>",
+	Short:             "Wait until a job is listening on a port",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Wait until a job's process tree is listening on a port.
+
+Without --port, waits for the first listening port of the job.
+With --port, waits until that specific port is open.
+
+Ports opened by child processes spawned by the job count as well.
+
+Examples:
+  # Start a dev server and wait until it accepts connections
+  gob add npm run dev
+  gob await-port abc
+
+  # Wait for port 3000, giving up after 2 minutes
+  gob await-port abc --port 3000 --timeout 120
+
+Output:
+  Job abc listening on port 3000 (tcp, pid 1234)
+
+Exit codes:
+  0: The job is listening on the port
+  1: Timeout, the job stopped, or an error occurred`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		timeout := time.Duration(awaitPortTimeout) * time.Second
+		port, err := awaitPort(client, jobID, awaitPortPort, timeout)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Job %s listening on port %d (%s, pid %d)\n", jobID, port.Port, port.Protocol, port.PID)
+		return nil
+	},
+}
+
+// awaitPort polls the job's ports until the wanted port (or any port if
+// wanted is 0) is listening. Fails if the job stops or the timeout expires.
+func awaitPort(client *daemon.Client, jobID string, wanted int, timeout time.Duration) (*daemon.PortInfo, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ports, err := client.Ports(jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ports.Status == "stopped" {
+			return nil, fmt.Errorf("job %s is not running", jobID)
+		}
+
+		for _, p := range ports.Ports {
+			if wanted == 0 || int(p.Port) == wanted {
+				return &p, nil
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			if wanted == 0 {
+				return nil, fmt.Errorf("timed out after %s waiting for job %s to listen on a port", timeout, jobID)
+			}
+			return nil, fmt.Errorf("timed out after %s waiting for job %s to listen on port %d", timeout, jobID, wanted)
+		}
+		time.Sleep(awaitPortInterval)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(awaitPortCmd)
+	awaitPortCmd.Flags().IntVarP(&awaitPortPort, "port", "p", 0,
+		"Port to wait for (default: any port)")
+	awaitPortCmd.Flags().IntVarP(&awaitPortTimeout, "timeout", "t", 60,
+		"Seconds to wait before giving up")
+	awaitPortCmd.RegisterFlagCompletionFunc("port", completeJobPorts)
+}
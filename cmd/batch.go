@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+// batchSelector holds the --match and --tag flags of commands that can act
+// on several jobs at once, and the --older-than and --failed-only filters of
+// those that register them
+type batchSelector struct {
+	match      string
+	tag        string
+	olderThan  string
+	failedOnly bool
+}
+
+// isSet reports whether jobs are selected by pattern, tag or filter
+func (s *batchSelector) isSet() bool {
+	return s.match != "" || s.tag != "" || s.filtered()
+}
+
+// filtered reports whether jobs are selected by age or outcome
+func (s *batchSelector) filtered() bool {
+	return s.olderThan != "" || s.failedOnly
+}
+
+// addBatchFlags registers the selection flags on a command
+func addBatchFlags(cmd *cobra.Command, s *batchSelector, verb string) {
+	cmd.Flags().StringVarP(&s.match, "match", "m", "",
+		fmt.Sprintf("%s jobs in the current directory whose command matches a glob pattern", verb))
+	cmd.Flags().StringVarP(&s.tag, "tag", "t", "",
+		fmt.Sprintf("%s jobs in the current directory with this tag", verb))
+	cmd.RegisterFlagCompletionFunc("tag", completeTags)
+}
+
+// addBatchFilterFlags registers the age and outcome filters on a command
+func addBatchFilterFlags(cmd *cobra.Command, s *batchSelector, verb string) {
+	cmd.Flags().StringVar(&s.olderThan, "older-than", "",
+		fmt.Sprintf("%s jobs in the current directory last active longer ago than this (e.g. 12h, 7d)", verb))
+	cmd.Flags().BoolVar(&s.failedOnly, "failed-only", false,
+		fmt.Sprintf("%s jobs in the current directory whose latest run failed", verb))
+}
+
+// batchArgs validates positional arguments: at least min job IDs, or any
+// number when jobs are selected by pattern or tag. extra is the number of
+// trailing non-ID arguments (e.g. the signal).
+func batchArgs(s *batchSelector, extra int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if s.isSet() {
+			return cobra.MinimumNArgs(extra)(cmd, args)
+		}
+		return cobra.MinimumNArgs(extra+1)(cmd, args)
+	}
+}
+
+// runBatch applies an action to the jobs given by ID and selected by the
+// flags in one daemon request, calling report for each successful job.
+// Failures are printed to stderr and make the command fail.
+func runBatch(client *daemon.Client, s *batchSelector, b daemon.BatchRequest, report func(daemon.BatchResult)) error {
+	b.Match = s.match
+	b.Tag = s.tag
+	b.FailedOnly = s.failedOnly
+	if s.filtered() && len(b.JobIDs) > 0 {
+		return fmt.Errorf("--older-than and --failed-only select jobs, they cannot be used with job IDs")
+	}
+	if s.olderThan != "" {
+		age, err := parseAge(s.olderThan)
+		if err != nil {
+			return err
+		}
+		b.Before = time.Now().Add(-age)
+	}
+	if s.isSet() {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		b.Workdir = cwd
+	}
+
+	results, err := client.Batch(b)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", result.JobID, result.Error)
+			failed++
+			continue
+		}
+		report(result)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d jobs", b.Action, failed, len(results))
+	}
+	return nil
+}
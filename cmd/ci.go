@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciJobs      int
+	ciKeepGoing bool
+	ciLabel     string
+	ciReport    string
+)
+
+// ciReportData is the machine-readable report of a ci pipeline
+type ciReportData struct {
+	Status     string        `json:"status"` // passed or failed
+	DurationMs int64         `json:"duration_ms"`
+	Jobs       []ciJobReport `json:"jobs"` // in gobfile order
+}
+
+// ciJobReport is the outcome of one job of a ci pipeline
+type ciJobReport struct {
+	Name       string   `json:"name"` // name of the gobfile job, or its command
+	Command    []string `json:"command"`
+	Workdir    string   `json:"workdir"`
+	Needs      []string `json:"needs,omitempty"`
+	JobID      string   `json:"job_id,omitempty"`
+	Status     string   `json:"status"` // succeeded, failed, running, skipped or error
+	ExitCode   *int     `json:"exit_code,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+	Error      string   `json:"error,omitempty"`
+}
+
+var ciCmd = &cobra.Command{
+	Use:   "ci [name...]",
+	Short: "Run the gobfile's jobs once, in dependency order, like a CI pipeline",
+	Long: `Run the jobs of the gobfile once, as a pipeline: a job starts when the
+jobs in its needs have succeeded, at most --jobs at a time, and their output
+is streamed prefixed with the job ID. Autostarted jobs (usually servers that
+never exit) and blocked jobs are not run.
+
+With names, only those jobs and the jobs they need are run. Jobs are
+referred to by their name in the gobfile, or by their command if they have
+none:
+
+  [[job]]
+  name = "build"
+  command = "make build"
+
+  [[job]]
+  command = "make test"
+  needs = ["build"]
+
+Like make, no new job is started once one fails, unless --keep-going is
+given; the jobs that need a failed job are skipped either way. The summary
+is followed by a JSON report with --report, e.g. for a pre-push hook or an
+editor.
+
+Examples:
+  # Run the whole pipeline before pushing
+  gob ci
+
+  # Run the tests and what they need, writing a report
+  gob ci --report ci.json "make test"
+
+  # Run every job that can run, even after a failure
+  gob ci -k
+
+Output:
+  Prefixed output of each job, then one line per job:
+  ✓ <job_id>  <duration>  <command>
+  ✗ <job_id>  <duration>  <command> (exit <code>)
+  - <command>  (a skipped job)
+
+Exit codes:
+  0: All jobs succeeded
+  N: The exit code of the first failed job, in gobfile order
+  1: Error (no gobfile, unknown or cyclic needs, a job could not be started)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ciJobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1")
+		}
+		if ciLabel != "" {
+			if err := daemon.ValidateRunLabel(ciLabel); err != nil {
+				return err
+			}
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		config, err := tui.ReadGobfile(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to read gobfile: %w", err)
+		}
+		if config == nil {
+			return fmt.Errorf("no gobfile found")
+		}
+		jobs, err := config.Pipeline(args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return fmt.Errorf("no jobs to run")
+		}
+
+		// Positions of the jobs each job needs
+		index := make(map[string]int, len(jobs))
+		for i, job := range jobs {
+			index[job.Key()] = i
+		}
+		needs := make([][]int, len(jobs))
+		entries := make([]runAllEntry, len(jobs))
+		for i, job := range jobs {
+			for _, need := range job.Needs {
+				needs[i] = append(needs[i], index[need])
+			}
+			entries[i] = runAllEntry{command: job.CommandArgs(), shell: job.Shell, workdir: job.JobWorkdir(cwd), label: ciLabel}
+		}
+
+		// Start the daemon before the workers connect to it
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+		client.Close()
+
+		start := time.Now()
+		results := runPipeline(entries, needs, cwd, os.Environ())
+		duration := time.Since(start)
+
+		fmt.Println()
+		exitCode := printRunAllResults(results)
+
+		if ciReport != "" {
+			if err := writeCIReport(ciReport, jobs, entries, results, duration, exitCode); err != nil {
+				return err
+			}
+		}
+
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// runPipeline runs the entries once the entries they need succeeded, at most
+// --jobs at a time, and returns their results. Entries that need a failed
+// one, or that were not started when one failed without --keep-going, are
+// skipped.
+func runPipeline(entries []runAllEntry, needs [][]int, cwd string, env []string) []runAllResult {
+	type finishedEntry struct {
+		i      int
+		result runAllResult
+	}
+
+	results := make([]runAllResult, len(entries))
+	started := make([]bool, len(entries))
+	done := make([]bool, len(entries))
+	finished := make(chan finishedEntry)
+	running := 0
+	failed := false
+
+	// state returns whether an entry can start, or must be skipped
+	state := func(i int) (ready, skip bool) {
+		if failed && !ciKeepGoing {
+			return false, true
+		}
+		ready = true
+		for _, need := range needs[i] {
+			switch {
+			case !done[need]:
+				ready = false
+			case results[need].status != "succeeded":
+				return false, true
+			}
+		}
+		return ready, false
+	}
+
+	for {
+		// Skipping an entry can skip the entries that need it
+		for progress := true; progress; {
+			progress = false
+			for i, entry := range entries {
+				if started[i] {
+					continue
+				}
+				ready, skip := state(i)
+				switch {
+				case skip:
+					started[i], done[i] = true, true
+					results[i] = runAllResult{command: entry.command, status: "skipped"}
+					progress = true
+				case ready && running < ciJobs:
+					started[i] = true
+					running++
+					go func() {
+						finished <- finishedEntry{i, runAllCommand(entry, cwd, env)}
+					}()
+				}
+			}
+		}
+
+		if running == 0 {
+			return results
+		}
+		f := <-finished
+		running--
+		results[f.i], done[f.i] = f.result, true
+		if f.result.status != "succeeded" {
+			failed = true
+		}
+	}
+}
+
+// writeCIReport writes the JSON report of a pipeline to path, or to stdout
+// for "-"
+func writeCIReport(path string, jobs []tui.GobfileJob, entries []runAllEntry, results []runAllResult, duration time.Duration, exitCode int) error {
+	report := ciReportData{Status: "passed", DurationMs: duration.Milliseconds()}
+	if exitCode != 0 {
+		report.Status = "failed"
+	}
+	for i, result := range results {
+		job := ciJobReport{
+			Name:       jobs[i].Key(),
+			Command:    result.command,
+			Workdir:    entries[i].workdir,
+			Needs:      jobs[i].Needs,
+			JobID:      result.jobID,
+			Status:     result.status,
+			ExitCode:   result.exitCode,
+			DurationMs: result.duration.Milliseconds(),
+		}
+		if result.err != nil {
+			job.Error = result.err.Error()
+		}
+		report.Jobs = append(report.Jobs, job)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		fmt.Println()
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Printf("Report written to %s\n", path)
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(ciCmd)
+	ciCmd.Flags().IntVarP(&ciJobs, "jobs", "j", runtime.NumCPU(),
+		"Maximum number of jobs running at once")
+	ciCmd.Flags().BoolVarP(&ciKeepGoing, "keep-going", "k", false,
+		"Keep starting jobs after one fails")
+	ciCmd.Flags().StringVarP(&ciLabel, "label", "l", "",
+		"Label the runs, to check them later with 'gob summary'")
+	ciCmd.Flags().StringVar(&ciReport, "report", "",
+		"Write a JSON report to this file ('-' for stdout)")
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupLogs bool
+	cleanupAll  bool
+	cleanupJSON bool
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Reconcile stored runs with the filesystem",
+	Long: `Reconcile the runs the daemon stores with the filesystem.
+
+With --logs, the stopped runs of the jobs in the current directory whose log
+files were deleted outside of gob are removed, with what is left of their
+logs. The statistics of their jobs no longer count them.
+
+The daemon looks for missing log files when it starts and every 10 minutes,
+and flags the runs it finds: 'gob runs' shows them as (logs missing) and the
+TUI with a ⌀ next to their ID.
+
+Examples:
+  # Remove the runs of jobs in the current directory whose logs are gone
+  gob cleanup --logs
+
+  # Across all directories
+  gob cleanup --logs --all
+
+Example output:
+  Removed 3 runs with missing logs
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cleanupLogs {
+			return fmt.Errorf("nothing to clean up: pass --logs")
+		}
+
+		var workdir string
+		if !cleanupAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			workdir = cwd
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		removed, err := client.CleanupLogs(workdir)
+		if err != nil {
+			return err
+		}
+
+		if cleanupJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(daemon.CleanupLogsData{RunsRemoved: removed})
+		}
+
+		if removed == 0 {
+			fmt.Println("No runs with missing logs")
+			return nil
+		}
+		fmt.Printf("Removed %s with missing logs\n", pluralRuns(removed))
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(cleanupCmd)
+	cleanupCmd.Flags().BoolVar(&cleanupLogs, "logs", false,
+		"Remove the stopped runs whose log files are missing")
+	cleanupCmd.Flags().BoolVarP(&cleanupAll, "all", "a", false,
+		"Include jobs from all directories")
+	cleanupCmd.Flags().BoolVar(&cleanupJSON, "json", false,
+		"Output in JSON format")
+}
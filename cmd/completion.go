@@ -1,13 +1,39 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juanibiapina/gob/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
+// completionTimeout bounds each daemon request made while completing, so a
+// busy or stuck daemon never freezes the shell
+const completionTimeout = time.Second
+
+// completionSignals are the signal names offered when completing 'gob signal'
+var completionSignals = []string{"HUP", "INT", "QUIT", "KILL", "TERM", "USR1", "USR2", "STOP", "CONT"}
+
+// completionClient connects to the daemon for shell completion
+func completionClient() (*daemon.Client, error) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	client.SetTimeout(completionTimeout)
+
+	if err := client.Connect(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
 // completeJobIDs provides completion for job IDs
 func completeJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 	// Only complete first argument for most commands
@@ -15,23 +41,48 @@ func completeJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]str
 		return nil, cobra.ShellCompDirectiveNoFileComp
 	}
 
+	return jobCompletions(toComplete, nil)
+}
+
+// completeManyJobIDs provides completion for commands taking several job IDs,
+// leaving out the ones already given
+func completeManyJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return jobCompletions(toComplete, args)
+}
+
+// completeSignalArgs completes job IDs and, once a job is given, signal names
+func completeSignalArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	completions, directive := jobCompletions(toComplete, args)
+	if len(args) == 0 && !signalSelector.isSet() {
+		return completions, directive
+	}
+
+	for _, name := range completionSignals {
+		if _, ok := signalNames[name]; !ok {
+			continue // not available on this platform
+		}
+		if strings.HasPrefix(name, strings.ToUpper(toComplete)) {
+			completions = append(completions, name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// jobCompletions lists the jobs of the current directory as "id\tstatus: command",
+// including aliases, and skipping jobs whose ID or alias is in exclude
+func jobCompletions(toComplete string, exclude []string) ([]string, cobra.ShellCompDirective) {
 	// Get current workdir for filtering
 	workdir, err := os.Getwd()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
 
-	// Connect to daemon
-	client, err := daemon.NewClient()
+	client, err := completionClient()
 	if err != nil {
 		return nil, cobra.ShellCompDirectiveError
 	}
 	defer client.Close()
 
-	if err := client.Connect(); err != nil {
-		return nil, cobra.ShellCompDirectiveError
-	}
-
 	// List jobs for current workdir
 	jobs, err := client.List(workdir)
 	if err != nil {
@@ -40,12 +91,139 @@ func completeJobIDs(cmd *cobra.Command, args []string, toComplete string) ([]str
 
 	var completions []string
 	for _, job := range jobs {
+		if slices.Contains(exclude, job.ID) || (job.Alias != "" && slices.Contains(exclude, job.Alias)) {
+			continue
+		}
+
+		// Format: jobID\tstatus: command (tab-separated for description)
+		description := job.Status + ": " + strings.Join(job.Command, " ")
 		if strings.HasPrefix(job.ID, toComplete) {
-			// Format: jobID\tcommand (tab-separated for description)
-			commandStr := strings.Join(job.Command, " ")
-			completions = append(completions, job.ID+"\t"+commandStr)
+			completions = append(completions, job.ID+"\t"+description)
+		}
+		if job.Alias != "" && strings.HasPrefix(job.Alias, toComplete) {
+			completions = append(completions, job.Alias+"\t"+description)
 		}
 	}
 
 	return completions, cobra.ShellCompDirectiveNoFileComp
 }
+
+// completeRunIDs provides completion for IDs of stopped runs in the current
+// directory, the ones that can be deleted
+func completeRunIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	client, err := completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer client.Close()
+
+	runs, err := client.RunsInWorkdir(workdir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, run := range runs {
+		if run.Status == "running" || !strings.HasPrefix(run.ID, toComplete) {
+			continue
+		}
+
+		// Format: runID\tstatus, started (tab-separated for description)
+		status := run.Status
+		if run.ExitCode != nil {
+			status = fmt.Sprintf("exit %d", *run.ExitCode)
+		}
+		startedAt, _ := time.Parse("2006-01-02T15:04:05Z07:00", run.StartedAt)
+		completions = append(completions, run.ID+"\t"+status+", started "+formatRelativeTime(startedAt))
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTags provides completion for the tags of jobs in the current directory
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	client, err := completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer client.Close()
+
+	jobs, err := client.List(workdir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	// Count jobs per tag for the description
+	counts := make(map[string]int)
+	var tags []string
+	for _, job := range jobs {
+		for _, tag := range job.Tags {
+			if counts[tag] == 0 {
+				tags = append(tags, tag)
+			}
+			counts[tag]++
+		}
+	}
+	slices.Sort(tags)
+
+	var completions []string
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, toComplete) {
+			description := fmt.Sprintf("%d jobs", counts[tag])
+			if counts[tag] == 1 {
+				description = "1 job"
+			}
+			completions = append(completions, tag+"\t"+description)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeJobPorts provides completion for the ports the job given as first
+// argument is listening on
+func completeJobPorts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	client, err := completionClient()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer client.Close()
+
+	ports, err := client.Ports(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, p := range ports.Ports {
+		port := strconv.Itoa(int(p.Port))
+		if strings.HasPrefix(port, toComplete) {
+			// Format: port\tprotocol address (tab-separated for description)
+			completions = append(completions, port+"\t"+p.Protocol+" "+p.Address)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeEnvSources completes the values of --env-from
+var completeEnvSources = cobra.FixedCompletions(
+	[]string{daemon.EnvSourceClient, daemon.EnvSourcePrevious, daemon.EnvSourceStored}, cobra.ShellCompDirectiveNoFileComp)
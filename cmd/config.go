@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change settings",
+	Long:  `Show or change settings of jobs.`,
+}
+
+var configJobCmd = &cobra.Command{
+	Use:               "job <job_id> <setting> [value]",
+	Short:             "Show or change a setting of a job",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Show or change a setting of a job. Without a value the current one is
+printed.
+
+Settings:
+  slow-threshold  When a run counts as slow: a duration like 5m, or a factor
+                  of the 90th percentile of the job's successful runs like 2x
+                  (needs 5 of them). A run passing it gets a slow marker, a
+                  job_slow event and runs the job's --on-slow hook. "off"
+                  removes the threshold.
+
+Examples:
+  # Alert when a run of job abc takes more than 5 minutes
+  gob config job abc slow-threshold 5m
+
+  # Alert when it takes more than twice as long as usual
+  gob config job abc slow-threshold 2x
+
+  # Show the threshold
+  gob config job abc slow-threshold
+
+Output:
+  Job abc: slow-threshold 5m
+
+Exit codes:
+  0: Setting shown or changed
+  1: Error (job not found, unknown setting, invalid value)`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID, setting := args[0], args[1]
+		if setting != "slow-threshold" {
+			return fmt.Errorf("unknown setting %q (available: slow-threshold)", setting)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		var job *daemon.JobResponse
+		if len(args) == 3 {
+			job, err = client.SetSlowThreshold(jobID, args[2])
+		} else {
+			job, err = client.GetJob(jobID)
+		}
+		if err != nil {
+			return err
+		}
+
+		threshold := job.SlowThreshold
+		if threshold == "" {
+			threshold = "off"
+		}
+		fmt.Printf("Job %s: %s %s\n", job.ID, setting, threshold)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configJobCmd)
+}
@@ -1,37 +1,59 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/juanibiapina/gob/internal/daemon"
-	godaemon "github.com/sevlyar/go-daemon"
 	"github.com/spf13/cobra"
 )
 
+var (
+	daemonForeground bool
+	daemonLogLevel   string
+)
+
 var daemonCmd = &cobra.Command{
-	Use:    "daemon",
-	Hidden: true, // Hidden from help - only used internally for auto-start
-	Short:  "Run the gob daemon (internal use only)",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Use go-daemon to properly daemonize with PPID=1
-		// Don't use LogFileName - let InitLogger handle logging after daemonization
-		ctx := &godaemon.Context{}
+	Use:   "daemon",
+	Short: "Run the gob daemon, or install it as a user service",
+	Long: `Run the gob daemon. Clients start it automatically when needed, so it is
+rarely run by hand.
 
-		child, err := ctx.Reborn()
+To keep the daemon running across reboots, for example for jobs that must
+keep running while you are away, install it as a user service with
+'gob daemon install' (systemd on Linux, launchd on macOS).
+
+The daemon logs what it does (port scans, database errors, hooks) at the
+level given with --log-level or GOB_LOG_LEVEL: debug, info (default), warn
+or error. See them with 'gob daemon logs'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("log-level") {
+			daemonLogLevel = os.Getenv("GOB_LOG_LEVEL")
+		}
+		level, err := daemon.ParseDaemonLogLevel(daemonLogLevel)
 		if err != nil {
 			return err
 		}
-		if child != nil {
-			// Parent process - exit immediately
-			return nil
+
+		// A service manager runs the daemon in the foreground
+		if !daemonForeground {
+			parent, release, err := daemonize()
+			if err != nil {
+				return err
+			}
+			if parent {
+				// Parent process - exit immediately
+				return nil
+			}
+			// Child process continues as daemon
+			defer release()
 		}
-		// Child process continues as daemon
-		defer ctx.Release()
 
 		// Initialize logging
 		logPath, err := daemon.GetLogPath()
 		if err != nil {
 			return err
 		}
-		if err := daemon.InitLogger(logPath); err != nil {
+		if err := daemon.InitLogger(logPath, level); err != nil {
 			return err
 		}
 
@@ -46,4 +68,8 @@ var daemonCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().BoolVar(&daemonForeground, "foreground", false,
+		"Run in the foreground instead of detaching (for service managers)")
+	daemonCmd.Flags().StringVar(&daemonLogLevel, "log-level", "info",
+		"Level of the daemon log: debug, info, warn or error (default from $GOB_LOG_LEVEL)")
 }
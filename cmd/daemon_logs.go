@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonLogsFollow bool
+	daemonLogsTail   int
+	daemonLogsLevel  string
+	daemonLogsPath   bool
+)
+
+var daemonLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the daemon's own log",
+	Long: `Show the log of the daemon itself, to debug problems with the daemon
+such as port scan errors or database failures. Job output is shown by
+'gob logs' instead.
+
+The daemon logs at the level given to 'gob daemon' with --log-level or
+GOB_LOG_LEVEL (info by default); --level here only shows the lines at a
+level and above. The log is rotated when the daemon starts and when it
+grows past 10 MB, keeping 3 older logs next to it (daemon.log.1 is the
+newest). Following keeps going across rotations.
+
+Examples:
+  # The daemon log
+  gob daemon logs
+
+  # The last 50 lines, then follow
+  gob daemon logs -n 50 -f
+
+  # Only warnings and errors
+  gob daemon logs --level warn
+
+  # Where the log is
+  gob daemon logs --path
+
+Exit codes:
+  0: Log displayed
+  1: Error (no log yet, invalid level)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if daemonLogsTail < 0 {
+			return fmt.Errorf("--tail must not be negative")
+		}
+		path, err := daemon.GetLogPath()
+		if err != nil {
+			return err
+		}
+		if daemonLogsPath {
+			fmt.Println(path)
+			return nil
+		}
+
+		keep := func([]byte) bool { return true }
+		if daemonLogsLevel != "" {
+			minLevel, err := daemon.ParseDaemonLogLevel(daemonLogsLevel)
+			if err != nil {
+				return err
+			}
+			keep = func(line []byte) bool {
+				level, ok := daemonLogLineLevel(line)
+				return !ok || level >= minLevel
+			}
+		}
+
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("daemon log not found: %s (has the daemon started?)", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read daemon log: %w", err)
+		}
+
+		var lines [][]byte
+		for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+			if len(line) > 0 && keep(line) {
+				lines = append(lines, line)
+			}
+		}
+		if daemonLogsTail > 0 && len(lines) > daemonLogsTail {
+			lines = lines[len(lines)-daemonLogsTail:]
+		}
+		for _, line := range lines {
+			os.Stdout.Write(line)
+		}
+
+		if daemonLogsFollow {
+			return followDaemonLog(path, int64(len(content)), keep, os.Stdout)
+		}
+		return nil
+	},
+}
+
+// daemonLogLineLevel returns the level of a line of the daemon log, from
+// its level= field
+func daemonLogLineLevel(line []byte) (slog.Level, bool) {
+	for _, field := range strings.Fields(string(line)) {
+		if value, ok := strings.CutPrefix(field, "level="); ok {
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(value)); err != nil {
+				return 0, false
+			}
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// followDaemonLog writes the lines appended to the daemon log after offset
+// that keep accepts, until an error occurs. When the daemon rotates the log,
+// the rest of the old one is written, then the new one from the start.
+func followDaemonLog(path string, offset int64, keep func([]byte) bool, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { file.Close() }()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	var partial []byte
+	for {
+		line, err := reader.ReadBytes('\n')
+		partial = append(partial, line...)
+		if err == nil {
+			if keep(partial) {
+				w.Write(partial)
+			}
+			partial = nil
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+
+		// Nothing new: reopen the log if it was rotated
+		time.Sleep(100 * time.Millisecond)
+		opened, statErr := file.Stat()
+		current, err := os.Stat(path)
+		if statErr != nil || err != nil || os.SameFile(opened, current) {
+			continue
+		}
+		if rest, _ := io.ReadAll(reader); len(rest) > 0 {
+			partial = append(partial, rest...)
+		}
+		if len(partial) > 0 && keep(partial) {
+			w.Write(partial)
+		}
+		partial = nil
+
+		next, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		file.Close()
+		file = next
+		reader = bufio.NewReader(file)
+	}
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonLogsCmd)
+	daemonLogsCmd.Flags().BoolVarP(&daemonLogsFollow, "follow", "f", false, "Follow the log in real-time")
+	daemonLogsCmd.Flags().IntVarP(&daemonLogsTail, "tail", "n", 0, "Only show the last N lines")
+	daemonLogsCmd.Flags().StringVar(&daemonLogsLevel, "level", "", "Only show lines at this level and above: debug, info, warn or error")
+	daemonLogsCmd.Flags().BoolVar(&daemonLogsPath, "path", false, "Print the path of the daemon log")
+}
@@ -0,0 +1,376 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonInstallPrint bool
+	daemonStatusJSON   bool
+)
+
+// serviceFile is a file defining the daemon's user service
+type serviceFile struct {
+	path    string
+	content string
+}
+
+// serviceManager installs the daemon as a user service of the platform's
+// service manager (see daemon_service_linux.go and daemon_service_darwin.go)
+type serviceManager interface {
+	// name describes the service, e.g. "systemd user service"
+	name() string
+	// files returns the service definition for the gob executable at exe,
+	// running jobs with the given PATH
+	files(exe, path string) ([]serviceFile, error)
+	// paths returns the files of the service, installed or not
+	paths() []string
+	// enable loads the installed files and starts the service, unless it
+	// is already running
+	enable() error
+	// disable stops the service and unloads it
+	disable() error
+	// state describes the service as reported by the service manager
+	state() string
+	// running reports whether the service's daemon is running
+	running() bool
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the daemon as a user service that starts at login",
+	Long: `Install the daemon as a user service, so it runs without a client
+starting it and survives reboots.
+
+On Linux, a systemd user service and socket are installed in
+~/.config/systemd/user. systemd listens on the daemon's socket and starts the
+daemon on the first connection (socket activation), and at login. To also
+start it at boot, before you log in, enable lingering:
+  loginctl enable-linger $USER
+
+On macOS, a launchd agent is installed in ~/Library/LaunchAgents and the
+daemon starts at login.
+
+The service runs this gob executable, with the current PATH for the jobs'
+commands. A daemon already running hands its running jobs over to the
+service's. Run install again after moving gob to update the service.
+
+Examples:
+  # Install and start the service
+  gob daemon install
+
+  # Show the service files without installing them
+  gob daemon install --print
+
+Exit codes:
+  0: Installed
+  1: Error (unsupported platform, isolated project, service manager error)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if daemon.ProjectDir() != "" {
+			return fmt.Errorf("the daemon of an isolated project cannot be installed as a service (run it outside the project, or with GOB_ISOLATED=0)")
+		}
+
+		m, err := newServiceManager()
+		if err != nil {
+			return err
+		}
+
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get executable path: %w", err)
+		}
+		files, err := m.files(exe, os.Getenv("PATH"))
+		if err != nil {
+			return err
+		}
+
+		if daemonInstallPrint {
+			for i, f := range files {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("# %s\n%s", f.path, f.content)
+			}
+			return nil
+		}
+
+		for _, f := range files {
+			if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", filepath.Dir(f.path), err)
+			}
+			if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", f.path, err)
+			}
+		}
+
+		// A daemon not started by the service hands its jobs over to it
+		if !m.running() {
+			if err := handOverRunningDaemon(); err != nil {
+				return err
+			}
+		}
+
+		if err := m.enable(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Installed gob daemon as a %s\n", m.name())
+		for _, f := range files {
+			fmt.Printf("  %s\n", f.path)
+		}
+		return nil
+	},
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the daemon's user service",
+	Long: `Stop and remove the user service installed by 'gob daemon install'.
+
+Running jobs keep running: the service's daemon hands them over, and the
+next gob command starts a daemon that takes them over, as before the
+service was installed.
+
+Exit codes:
+  0: Uninstalled
+  1: Error (not installed, service manager error)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := newServiceManager()
+		if err != nil {
+			return err
+		}
+
+		installed := slices.ContainsFunc(m.paths(), fileExists)
+		if !installed {
+			return fmt.Errorf("the daemon is not installed as a %s", m.name())
+		}
+
+		if m.running() {
+			if err := handOverRunningDaemon(); err != nil {
+				return err
+			}
+		}
+
+		if err := m.disable(); err != nil {
+			return err
+		}
+		for _, path := range m.paths() {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
+
+		fmt.Printf("Uninstalled the gob daemon's %s\n", m.name())
+		return nil
+	},
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is installed, running and healthy",
+	Long: `Show whether the daemon is installed as a user service, the service's
+state, whether a daemon is running and, if it is, its health: uptime,
+database, clients subscribed to events, jobs and runs, the last error it
+logged and the state of its background tasks.
+
+Example output:
+  Service:  systemd user service, installed
+  Files:    /home/me/.config/systemd/user/gob.service
+            /home/me/.config/systemd/user/gob.socket
+  State:    active (running)
+  Daemon:   running, pid 1234, version 1.2.3, up 2h5m
+  Socket:   /run/user/1000/gob/daemon.sock
+  Database: /home/me/.local/state/gob/state.db (1.2 MB)
+  Log:      /run/user/1000/gob/daemon.log
+  Clients:  2 subscribed
+  Jobs:     5 (2 running), 48 runs
+  Error:    none
+  Tasks:    presence watcher running (shells: 1, projects: 1)
+            supervisor idle
+            gateway stopped
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		socketPath, err := daemon.GetSocketPath()
+		if err != nil {
+			return err
+		}
+
+		status := daemonStatus{Socket: socketPath}
+		if m, err := newServiceManager(); err != nil {
+			status.ServiceError = err.Error()
+		} else {
+			status.Service = &serviceStatus{Name: m.name(), Files: m.paths()}
+			status.Service.Installed = slices.ContainsFunc(m.paths(), fileExists)
+			if status.Service.Installed {
+				status.Service.State = m.state()
+			}
+		}
+		status.Running, status.PID, status.Version, status.Health = daemonHealth()
+
+		if daemonStatusJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+		printDaemonStatus(status)
+		return nil
+	},
+}
+
+// daemonStatus is the output of 'gob daemon status --json'
+type daemonStatus struct {
+	Service      *serviceStatus     `json:"service,omitempty"`       // nil without a supported service manager
+	ServiceError string             `json:"service_error,omitempty"` // why there is no service manager
+	Running      bool               `json:"running"`
+	PID          int                `json:"pid,omitempty"`
+	Version      string             `json:"version,omitempty"`
+	Socket       string             `json:"socket"`
+	Health       *daemon.HealthData `json:"health,omitempty"` // nil if not running, or too old to report it
+}
+
+// serviceStatus describes the daemon's user service
+type serviceStatus struct {
+	Name      string   `json:"name"`
+	Installed bool     `json:"installed"`
+	Files     []string `json:"files"`
+	State     string   `json:"state,omitempty"`
+}
+
+// printDaemonStatus prints the status of the service and daemon
+func printDaemonStatus(status daemonStatus) {
+	if s := status.Service; s != nil {
+		if s.Installed {
+			fmt.Printf("Service:  %s, installed\n", s.Name)
+		} else {
+			fmt.Printf("Service:  %s, not installed (gob daemon install)\n", s.Name)
+		}
+		printDaemonStatusList("Files:", s.Files)
+		if s.Installed {
+			fmt.Printf("State:    %s\n", s.State)
+		}
+	} else {
+		fmt.Printf("Service:  %s\n", status.ServiceError)
+	}
+
+	h := status.Health
+	switch {
+	case !status.Running:
+		fmt.Println("Daemon:   not running")
+	case h == nil:
+		fmt.Printf("Daemon:   running, pid %d, version %s\n", status.PID, status.Version)
+	default:
+		fmt.Printf("Daemon:   running, pid %d, version %s, up %s\n", h.PID, h.Version, formatDuration(time.Duration(h.UptimeMs)*time.Millisecond))
+	}
+	fmt.Printf("Socket:   %s\n", status.Socket)
+	if h == nil {
+		return
+	}
+
+	fmt.Printf("Database: %s (%s)\n", h.DatabasePath, daemon.FormatBytes(h.DatabaseBytes))
+	fmt.Printf("Log:      %s\n", h.LogPath)
+	fmt.Printf("Clients:  %d subscribed\n", h.Subscribers)
+	fmt.Printf("Jobs:     %d (%d running), %s\n", h.Jobs, h.RunningJobs, pluralRuns(h.Runs))
+	if h.LastError != nil {
+		fmt.Printf("Error:    %s %s\n", h.LastError.Time, h.LastError.Message)
+	} else {
+		fmt.Println("Error:    none")
+	}
+	var tasks []string
+	for _, c := range h.Components {
+		task := c.Name + " " + c.State
+		if c.Detail != "" {
+			task += " (" + c.Detail + ")"
+		}
+		tasks = append(tasks, task)
+	}
+	printDaemonStatusList("Tasks:", tasks)
+}
+
+// printDaemonStatusList prints a labeled list, one item per line
+func printDaemonStatusList(label string, items []string) {
+	for i, item := range items {
+		if i > 0 {
+			label = ""
+		}
+		fmt.Printf("%-10s%s\n", label, item)
+	}
+}
+
+// daemonHealth describes the running daemon, without starting one: whether
+// it runs, its PID and version, and its health if it reports it
+func daemonHealth() (bool, int, string, *daemon.HealthData) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return false, 0, "", nil
+	}
+	defer client.Close()
+	client.SetTimeout(time.Second)
+
+	info, err := client.GetDaemonVersion()
+	if err != nil {
+		return false, 0, "", nil
+	}
+	if info.Supports(string(daemon.RequestTypeHealth)) {
+		if health, err := client.Health(); err == nil {
+			return true, health.PID, health.Version, health
+		}
+	}
+
+	pid := 0
+	if pidPath, err := daemon.GetPIDPath(); err == nil {
+		if data, err := os.ReadFile(pidPath); err == nil {
+			pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+	}
+	return true, pid, info.Version, nil
+}
+
+// handOverRunningDaemon makes a running daemon hand its jobs over to the
+// next one and exit. Does nothing if no daemon is running.
+func handOverRunningDaemon() error {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.ConnectRunning(); err != nil {
+		return nil
+	}
+	if err := client.HandOver(); err != nil {
+		return fmt.Errorf("failed to hand over running jobs: %w", err)
+	}
+	return nil
+}
+
+// fileExists reports whether a file exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonCmd.AddCommand(daemonUninstallCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonInstallCmd.Flags().BoolVar(&daemonInstallPrint, "print", false,
+		"Print the service files instead of installing them")
+	daemonStatusCmd.Flags().BoolVar(&daemonStatusJSON, "json", false,
+		"Output in JSON format")
+}
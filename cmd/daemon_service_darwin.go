@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+// launchdLabel is the label of the daemon's launchd agent
+const launchdLabel = "io.github.juanibiapina.gob"
+
+// launchdManager installs the daemon as a launchd agent. launchd socket
+// activation needs the launch_activate_socket API, so the agent starts the
+// daemon at login instead.
+type launchdManager struct {
+	plist  string // path of the agent's property list
+	domain string // the user's GUI domain, e.g. gui/501
+}
+
+func newServiceManager() (serviceManager, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return launchdManager{
+		plist:  filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"),
+		domain: fmt.Sprintf("gui/%d", os.Getuid()),
+	}, nil
+}
+
+func (m launchdManager) name() string {
+	return "launchd agent"
+}
+
+func (m launchdManager) paths() []string {
+	return []string{m.plist}
+}
+
+func (m launchdManager) files(exe, path string) ([]serviceFile, error) {
+	stateDir, err := daemon.GetStateDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only restarted after a crash: a daemon that exits on its own (gob
+	// shutdown, or handing over to a new version) is started again by the
+	// next gob command
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+  <key>Label</key>
+  <string>%s</string>
+  <key>ProgramArguments</key>
+  <array>
+    <string>%s</string>
+    <string>daemon</string>
+    <string>--foreground</string>
+  </array>
+  <key>EnvironmentVariables</key>
+  <dict>
+    <key>PATH</key>
+    <string>%s</string>
+  </dict>
+  <key>RunAtLoad</key>
+  <true/>
+  <key>KeepAlive</key>
+  <dict>
+    <key>SuccessfulExit</key>
+    <false/>
+  </dict>
+  <key>StandardErrorPath</key>
+  <string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, xmlEscape(exe), xmlEscape(path), xmlEscape(filepath.Join(stateDir, "launchd.log")))
+
+	return []serviceFile{{m.plist, plist}}, nil
+}
+
+func (m launchdManager) enable() error {
+	if m.running() {
+		return nil
+	}
+	// Reload an agent left loaded with an older property list
+	exec.Command("launchctl", "bootout", m.domain+"/"+launchdLabel).Run()
+	return launchctl("bootstrap", m.domain, m.plist)
+}
+
+func (m launchdManager) disable() error {
+	return launchctl("bootout", m.domain+"/"+launchdLabel)
+}
+
+func (m launchdManager) state() string {
+	out, err := exec.Command("launchctl", "print", m.domain+"/"+launchdLabel).Output()
+	if err != nil {
+		return "not loaded"
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if state, ok := strings.CutPrefix(strings.TrimSpace(line), "state = "); ok {
+			return state
+		}
+	}
+	return "loaded"
+}
+
+func (m launchdManager) running() bool {
+	return m.state() == "running"
+}
+
+// launchctl runs a launchctl command
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// xmlEscape escapes text for a property list
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
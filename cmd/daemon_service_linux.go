@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+// systemdUnits are the units of the daemon's systemd user service
+var systemdUnits = []string{"gob.socket", "gob.service"}
+
+// systemdManager installs the daemon as a socket-activated systemd user
+// service
+type systemdManager struct {
+	dir string // directory of the user units
+}
+
+func newServiceManager() (serviceManager, error) {
+	return systemdManager{dir: filepath.Join(xdg.ConfigHome, "systemd", "user")}, nil
+}
+
+func (m systemdManager) name() string {
+	return "systemd user service"
+}
+
+func (m systemdManager) paths() []string {
+	paths := make([]string, len(systemdUnits))
+	for i, unit := range systemdUnits {
+		paths[i] = filepath.Join(m.dir, unit)
+	}
+	return paths
+}
+
+func (m systemdManager) files(exe, path string) ([]serviceFile, error) {
+	socketPath, err := daemon.GetSocketPath()
+	if err != nil {
+		return nil, err
+	}
+
+	socket := fmt.Sprintf(`[Unit]
+Description=gob daemon socket
+
+[Socket]
+ListenStream=%s
+SocketMode=0600
+DirectoryMode=0700
+
+[Install]
+WantedBy=sockets.target
+`, strings.ReplaceAll(socketPath, "%", "%%"))
+
+	// Jobs outlive the daemon when it hands over to a new one, so only the
+	// daemon is killed when the service stops (it stops its jobs itself).
+	// Delegate lets it create the cgroups of jobs with resource limits.
+	service := fmt.Sprintf(`[Unit]
+Description=gob daemon
+Documentation=https://github.com/juanibiapina/gob
+Requires=gob.socket
+After=gob.socket
+
+[Service]
+ExecStart=%s daemon --foreground
+Environment=%s
+Restart=on-failure
+KillMode=process
+Delegate=yes
+
+[Install]
+WantedBy=default.target
+`, systemdQuote(exe), systemdQuote("PATH="+path))
+
+	paths := m.paths()
+	return []serviceFile{{paths[0], socket}, {paths[1], service}}, nil
+}
+
+func (m systemdManager) enable() error {
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctl(append([]string{"enable"}, systemdUnits...)...); err != nil {
+		return err
+	}
+	return systemctl(append([]string{"start"}, systemdUnits...)...)
+}
+
+func (m systemdManager) disable() error {
+	if err := systemctl(append([]string{"disable", "--now"}, systemdUnits...)...); err != nil {
+		return err
+	}
+	return systemctl("daemon-reload")
+}
+
+func (m systemdManager) state() string {
+	out, err := exec.Command("systemctl", "--user", "show", "gob.service",
+		"--property=ActiveState", "--property=SubState", "--property=UnitFileState").Output()
+	if err != nil {
+		return "unknown"
+	}
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			props[key] = value
+		}
+	}
+	return fmt.Sprintf("%s (%s), %s", props["ActiveState"], props["SubState"], props["UnitFileState"])
+}
+
+func (m systemdManager) running() bool {
+	return exec.Command("systemctl", "--user", "--quiet", "is-active", "gob.service").Run() == nil
+}
+
+// systemctl runs a systemctl command for the user's service manager
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdQuote quotes a word of a unit file line, escaping the characters
+// systemd would otherwise interpret
+func systemdQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func newServiceManager() (serviceManager, error) {
+	return nil, fmt.Errorf("installing the daemon as a service is not supported on %s (only with systemd on Linux and launchd on macOS)", runtime.GOOS)
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package cmd
+
+import godaemon "github.com/sevlyar/go-daemon"
+
+// daemonize uses go-daemon to properly daemonize with PPID=1. It returns
+// parent=true in the original process, which should exit immediately.
+func daemonize() (parent bool, release func(), err error) {
+	// Don't use LogFileName - let InitLogger handle logging after daemonization
+	ctx := &godaemon.Context{}
+
+	child, err := ctx.Reborn()
+	if err != nil {
+		return false, nil, err
+	}
+	if child != nil {
+		return true, nil, nil
+	}
+	return false, func() { ctx.Release() }, nil
+}
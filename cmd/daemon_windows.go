@@ -0,0 +1,7 @@
+package cmd
+
+// daemonize is a no-op on Windows: the client already starts the daemon as
+// a detached process (see daemon.StartDaemon), so it runs in place
+func daemonize() (parent bool, release func(), err error) {
+	return false, func() {}, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var describeClear bool
+
+var describeCmd = &cobra.Command{
+	Use:               "describe <job_id> [text...]",
+	Short:             "Set the description of a job",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Set the human-readable description of a job.
+
+The description is shown under the job in 'gob list', next to it in the TUI
+and in 'gob list --compact'. It replaces the description given with
+--description to add or run, or by the gobfile. The words after the job ID
+are joined with spaces, so quoting is optional.
+
+Examples:
+  # Describe job abc
+  gob describe abc Dev server for the frontend app
+
+  # Remove the description
+  gob describe abc --clear
+
+Output:
+  Job abc: Dev server for the frontend app
+
+Exit codes:
+  0: Description set
+  1: Error (job not found)`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+
+		var description string
+		switch {
+		case describeClear && len(args) > 1:
+			return fmt.Errorf("--clear does not take a description")
+		case !describeClear && len(args) == 1:
+			return fmt.Errorf("requires a description (or --clear to remove it)")
+		case !describeClear:
+			description = strings.Join(args[1:], " ")
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		job, err := client.SetDescription(jobID, description)
+		if err != nil {
+			return err
+		}
+
+		if description == "" {
+			fmt.Printf("Removed description from job %s\n", job.ID)
+		} else {
+			fmt.Printf("Job %s: %s\n", job.ID, job.Description)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(describeCmd)
+	describeCmd.Flags().BoolVar(&describeClear, "clear", false,
+		"Remove the job's description")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffRun     string
+	diffAgainst string
+	diffContext int
+	diffStderr  bool
+	diffClean   bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:               "diff <job_id>",
+	Short:             "Compare a run's output with the last successful run",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Show how the stdout of a job's current or latest run differs from the
+stdout of its last successful run, as a unified diff.
+
+Useful when a build or test suite starts failing: the diff shows what it
+printed differently from the last time it passed. The last successful run
+is the latest stopped run before the compared one that exited with 0.
+
+Examples:
+  # What did the failing build print differently?
+  gob diff build
+
+  # Compare two given runs
+  gob diff build --run build-7 --against build-4
+
+  # Compare stderr, with more context, without progress bar redraws
+  gob diff build --stderr -U 10 --clean
+
+Output format:
+  --- <run_id>  <started>  <status>   (the successful run)
+  +++ <run_id>  <started>  <status>   (the compared run)
+  @@ -<line>,<count> +<line>,<count> @@
+   <line in both>
+  -<line only in the successful run>
+  +<line only in the compared run>
+
+Exit codes:
+  0: Compared (with or without differences)
+  1: Error (job or run not found, no successful run to compare with)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diffContext < 0 {
+			return fmt.Errorf("--context must not be negative")
+		}
+
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		// Newest first
+		runs, err := client.Runs(args[0])
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("job %s has no runs", args[0])
+		}
+
+		target := 0
+		if diffRun != "" {
+			if target = runIndex(runs, diffRun); target < 0 {
+				return fmt.Errorf("run not found: %s", diffRun)
+			}
+		}
+
+		baseline := -1
+		if diffAgainst != "" {
+			if baseline = runIndex(runs, diffAgainst); baseline < 0 {
+				return fmt.Errorf("run not found: %s", diffAgainst)
+			}
+		} else {
+			for i := target + 1; i < len(runs); i++ {
+				if runs[i].Status != "running" && runs[i].ExitCode != nil && *runs[i].ExitCode == 0 {
+					baseline = i
+					break
+				}
+			}
+			if baseline < 0 {
+				return fmt.Errorf("no successful run before %s to compare with (use --against <run_id>)", runs[target].ID)
+			}
+		}
+
+		stream := "stdout"
+		if diffStderr {
+			stream = "stderr"
+		}
+		oldText, err := readRunStream(runs[baseline], stream)
+		if err != nil {
+			return err
+		}
+		newText, err := readRunStream(runs[target], stream)
+		if err != nil {
+			return err
+		}
+
+		// A line diff of binary output means nothing
+		if logfile.IsBinary(oldText) || logfile.IsBinary(newText) {
+			if string(oldText) == string(newText) {
+				fmt.Println("No differences")
+			} else {
+				fmt.Printf("Binary %s of %s and %s differ\n", stream, runs[baseline].ID, runs[target].ID)
+			}
+			return nil
+		}
+
+		a, b := string(oldText), string(newText)
+		if diffClean {
+			a, b = daemon.CleanOutput(a), daemon.CleanOutput(b)
+		}
+		hunks := daemon.DiffOutput(daemon.SplitLines(a), daemon.SplitLines(b), diffContext)
+		if len(hunks) == 0 {
+			fmt.Println("No differences")
+			return nil
+		}
+
+		fmt.Println(formatDiffHeader("---", runs[baseline]))
+		fmt.Println(formatDiffHeader("+++", runs[target]))
+		for _, h := range hunks {
+			fmt.Println(output.Colorize(os.Stdout, output.Cyan,
+				fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)))
+			for _, line := range h.Lines {
+				text := string(line.Kind) + line.Text
+				switch line.Kind {
+				case '-':
+					text = output.Colorize(os.Stdout, output.Red, text)
+				case '+':
+					text = output.Colorize(os.Stdout, output.Green, text)
+				}
+				fmt.Println(text)
+			}
+		}
+		return nil
+	},
+}
+
+// runIndex returns the position of a run in a list, -1 if it is not there
+func runIndex(runs []daemon.RunResponse, runID string) int {
+	for i := range runs {
+		if runs[i].ID == runID {
+			return i
+		}
+	}
+	return -1
+}
+
+// readRunStream reads one stream ("stdout" or "stderr") of a run
+func readRunStream(run daemon.RunResponse, stream string) ([]byte, error) {
+	path := run.StdoutPath
+	if stream == "stderr" {
+		path = run.StderrPath
+	}
+	content, err := logfile.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s log of %s not found: %s", stream, run.ID, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s log of %s: %w", stream, run.ID, err)
+	}
+	return content, nil
+}
+
+// formatDiffHeader formats the line naming one side of a diff
+func formatDiffHeader(marker string, run daemon.RunResponse) string {
+	started, _, status := formatRunColumns(run)
+	return fmt.Sprintf("%s %s  %s  %s", marker, run.ID, started, status)
+}
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&diffRun, "run", "",
+		"Compare this run (e.g. abc-7) instead of the current or latest one")
+	diffCmd.Flags().StringVar(&diffAgainst, "against", "",
+		"Compare with this run instead of the last successful one")
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 3,
+		"Lines of context around each change")
+	diffCmd.Flags().BoolVar(&diffStderr, "stderr", false,
+		"Compare stderr instead of stdout")
+	diffCmd.Flags().BoolVar(&diffClean, "clean", false,
+		"Remove cursor movement and keep the last state of progress bars")
+	diffCmd.RegisterFlagCompletionFunc("run", completeRunIDs)
+	diffCmd.RegisterFlagCompletionFunc("against", completeRunIDs)
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diskUsageAll   bool
+	diskUsagePrune bool
+	diskUsageKeep  int
+	diskUsageJSON  bool
+	diskUsageForce bool
+)
+
+var diskUsageCmd = &cobra.Command{
+	Use:   "disk-usage",
+	Short: "Show the disk space used by job logs",
+	Long: `Show the size of the stored logs of each job in the current directory,
+largest first, and their total.
+
+The daemon records the size of a run's logs when it stops; the logs of
+running jobs are measured when asked.
+
+With --prune, the stopped runs of each job are removed with their logs,
+except the --keep most recent ones, like 'gob runs delete' for each of them.
+Pinned jobs (see 'gob pin') are skipped unless --force is given.
+
+Output format:
+  <job_id>  <runs>  <size>  <command>
+
+Examples:
+  # Log sizes of the jobs in the current directory
+  gob disk-usage
+
+  # Across all directories
+  gob disk-usage --all
+
+  # Keep only the latest run of each job
+  gob disk-usage --prune
+
+  # Keep the last 5 runs of each job
+  gob disk-usage --prune --keep 5
+
+Example output:
+  abc  12 runs   48.2 MB    npm run dev
+  def  3 runs    1.1 KB     make test
+
+  Total: 48.2 MB
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diskUsageKeep < 0 {
+			return fmt.Errorf("--keep must not be negative")
+		}
+		if cmd.Flags().Changed("keep") && !diskUsagePrune {
+			return fmt.Errorf("--keep requires --prune")
+		}
+		if diskUsageForce && !diskUsagePrune {
+			return fmt.Errorf("--force requires --prune")
+		}
+
+		var workdir string
+		if !diskUsageAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			workdir = cwd
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		if diskUsagePrune {
+			removed, freed, err := client.PruneLogs(workdir, diskUsageKeep, diskUsageForce)
+			if err != nil {
+				return err
+			}
+			if diskUsageJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(daemon.PruneLogsData{RunsRemoved: removed, BytesFreed: freed})
+			}
+			fmt.Printf("Removed %d runs, freed %s\n", removed, daemon.FormatBytes(freed))
+			return nil
+		}
+
+		jobs, total, err := client.DiskUsage(workdir)
+		if err != nil {
+			return err
+		}
+
+		if diskUsageJSON {
+			if jobs == nil {
+				jobs = []daemon.JobDiskUsage{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(daemon.DiskUsageData{Jobs: jobs, TotalBytes: total})
+		}
+
+		if len(jobs) == 0 {
+			fmt.Println("No jobs found")
+			return nil
+		}
+
+		for _, job := range jobs {
+			runs := fmt.Sprintf("%d runs", job.Runs)
+			if job.Runs == 1 {
+				runs = "1 run"
+			}
+			line := fmt.Sprintf("%s  %-8s  %-9s  %s", job.JobID, runs, daemon.FormatBytes(job.Bytes), strings.Join(job.Command, " "))
+			if diskUsageAll {
+				line += "  (" + job.Workdir + ")"
+			}
+			if job.LogDir != "" {
+				line += "  [" + job.LogDir + "]"
+			}
+			fmt.Println(line)
+		}
+
+		fmt.Printf("\nTotal: %s\n", daemon.FormatBytes(total))
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(diskUsageCmd)
+	diskUsageCmd.Flags().BoolVarP(&diskUsageAll, "all", "a", false,
+		"Include jobs from all directories")
+	diskUsageCmd.Flags().BoolVar(&diskUsagePrune, "prune", false,
+		"Remove the logs of old stopped runs")
+	diskUsageCmd.Flags().IntVar(&diskUsageKeep, "keep", 1,
+		"Stopped runs kept per job by --prune, newest first")
+	diskUsageCmd.Flags().BoolVar(&diskUsageForce, "force", false,
+		"Also prune pinned jobs")
+	diskUsageCmd.Flags().BoolVar(&diskUsageJSON, "json", false,
+		"Output in JSON format")
+}
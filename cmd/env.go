@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var envJSON bool
+
+// envReport is the JSON output of gob env
+type envReport struct {
+	Filter    daemon.EnvFilter       `json:"filter"`
+	Variables int                    `json:"variables"` // captured
+	Bytes     int64                  `json:"bytes"`     // size of the captured variables
+	Dropped   []daemon.DroppedEnvVar `json:"dropped"`
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show which environment variables jobs get",
+	Long: `Show how much of the current environment is captured for jobs, and
+which variables are dropped and why.
+
+Commands starting jobs (add, run, start, restart) send their environment to
+the daemon, which starts the job with it. Before sending it, they filter it:
+
+  GOB_ENV_ALLOW     Comma-separated name patterns (e.g. AWS_*,NODE_*). If
+                    set, only matching variables are captured
+  GOB_ENV_DENY      Name patterns of variables dropped. Defaults to
+                    BASH_FUNC_*,LS_COLORS,OLDPWD,_; empty denies nothing
+  GOB_ENV_MAX_SIZE  Total size of the captured variables, e.g. 512K
+                    (256K by default, 0 for no limit). The largest
+                    variables are dropped first
+
+Denying wins over allowing, and PATH and HOME are always captured. The
+daemon filters the environments it receives again, with the settings it was
+started with.
+
+Examples:
+  # What the current environment loses
+  gob env
+
+  # Only pass the variables a project needs
+  GOB_ENV_ALLOW='AWS_*,DATABASE_URL' gob env
+
+Example output:
+  Captured 48 variables (3.2 KB of 256.0 KB)
+  Denied: BASH_FUNC_*, LS_COLORS, OLDPWD, _
+
+  Dropped 2 variables:
+    LS_COLORS  1.5 KB  denied by LS_COLORS
+    OLDPWD     24 B    denied by OLDPWD
+
+Exit codes:
+  0: Success
+  1: Invalid settings`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := daemon.EnvFilterFromEnv()
+		if err != nil {
+			return err
+		}
+		env, dropped := filter.Apply(os.Environ())
+
+		if envJSON {
+			if dropped == nil {
+				dropped = []daemon.DroppedEnvVar{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(envReport{Filter: filter, Variables: len(env), Bytes: daemon.EnvBytes(env), Dropped: dropped})
+		}
+
+		size := daemon.FormatBytes(daemon.EnvBytes(env))
+		if filter.MaxBytes > 0 {
+			size += " of " + daemon.FormatBytes(filter.MaxBytes)
+		}
+		fmt.Printf("Captured %s (%s)\n", pluralVariables(len(env)), size)
+
+		if len(filter.Allow) > 0 {
+			fmt.Printf("Allowed: %s\n", strings.Join(filter.Allow, ", "))
+		}
+		if len(filter.Deny) > 0 {
+			fmt.Printf("Denied: %s\n", strings.Join(filter.Deny, ", "))
+		}
+
+		if len(dropped) == 0 {
+			fmt.Println("\nNothing dropped")
+			return nil
+		}
+
+		width := 0
+		for _, d := range dropped {
+			width = max(width, len(d.Name))
+		}
+		fmt.Printf("\nDropped %s:\n", pluralVariables(len(dropped)))
+		for _, d := range dropped {
+			fmt.Printf("  %-*s  %-8s  %s\n", width, d.Name, daemon.FormatBytes(int64(d.Bytes)), d.Reason)
+		}
+		return nil
+	},
+}
+
+// pluralVariables formats a number of environment variables
+func pluralVariables(n int) string {
+	if n == 1 {
+		return "1 variable"
+	}
+	return fmt.Sprintf("%d variables", n)
+}
+
+func init() {
+	RootCmd.AddCommand(envCmd)
+	envCmd.Flags().BoolVar(&envJSON, "json", false,
+		"Output in JSON format")
+}
@@ -4,34 +4,94 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/juanibiapina/gob/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
-var eventsAll bool
+var (
+	eventsAll      bool
+	eventsSnapshot bool
+	eventsSince    string
+	eventsAfter    uint64
+	eventsFollow   bool
+	eventsJSON     bool
+	eventsTypes    []string
+	eventsJobs     []string
+	eventsTags     []string
+)
 
 var eventsCmd = &cobra.Command{
 	Use:   "events",
-	Short: "Subscribe to daemon events",
-	Long: `Subscribe to daemon events and print them as JSON.
+	Short: "Show and follow daemon events",
+	Long: `Show the events recorded by the daemon and follow new ones.
+
+The daemon records every job and run change (added, started, stopped,
+removed, ports updated) with an increasing sequence number, and keeps
+them for 30 days.
 
 By default, only shows events for jobs in the current directory.
-Use --all to see events from all directories.
+Use --all to see events from all directories. --type, --job and --tag
+narrow them down further (each can be repeated, or take a comma-separated
+list); the daemon then only sends the matching events.
+
+Without --since or --after, follows new events until Ctrl+C. With them,
+prints the recorded events and exits, unless --follow is also given: then
+it keeps following without missing any event in between.
 
-Events are printed as JSON objects, one per line:
-  {"type":"job_added","job_id":"V3x0QqI","job":{...}}
-  {"type":"job_stopped","job_id":"V3x0QqI","job":{...}}
+Output:
+  One line per event with its time, sequence number, type, job and command:
+    2026-01-05 14:03:12  #41  job_started  V3x0QqI  npm run dev
+
+  With --json, events are printed as JSON objects, one per line:
+    {"seq":41,"time":"2026-01-05T13:03:12Z","type":"job_started","job_id":"V3x0QqI","job":{...}}
 
 Event types:
-  job_added   - A new job was created
-  job_started - A stopped job was started
-  job_stopped - A running job was stopped
-  job_removed - A job was removed
+  job_added     - A new job was created
+  job_started   - A stopped job was started
+  job_stopped   - A running job was stopped
+  job_removed   - A job was removed
+  job_updated   - A job's settings changed
+  run_started   - A run started
+  run_stopped   - A run stopped
+  run_removed   - A run was deleted
+  run_updated   - A run's note or markers changed
+  ports_updated - A job's listening ports changed
+  snapshot      - The current jobs and runs (with --snapshot)
+
+With --snapshot, the first event is a snapshot whose seq is that of the last
+event it includes; later events may repeat changes it already shows.
 
-This is useful for testing and debugging event subscriptions.
-Press Ctrl+C to stop.`,
+Examples:
+  # Follow events of jobs in the current directory
+  gob events
+
+  # Events of the last hour in all directories, as JSON
+  gob events --all --since 1h --json
+
+  # Catch up from sequence number 120, then keep following
+  gob events --after 120 --follow
+
+  # Only the starts and stops of the web server
+  gob events --job web --type job_started,job_stopped
+
+Exit codes:
+  0: Success (or interrupted while following)
+  1: Error (invalid duration, unknown event type or job, connection failed)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter daemon.EventFilter
+		if eventsSince != "" {
+			since, err := parseAge(eventsSince)
+			if err != nil {
+				return err
+			}
+			filter.Since = time.Now().Add(-since)
+		}
+		filter.AfterID = eventsAfter
+		recorded := eventsSince != "" || cmd.Flags().Changed("after")
+
 		// Connect to daemon
 		client, err := daemon.NewClient()
 		if err != nil {
@@ -43,23 +103,62 @@ Press Ctrl+C to stop.`,
 			return fmt.Errorf("failed to connect to daemon: %w", err)
 		}
 
+		match := daemon.EventMatch{Tags: eventsTags}
+		for _, t := range eventsTypes {
+			match.Types = append(match.Types, daemon.EventType(t))
+		}
+		if err := match.Validate(); err != nil {
+			return err
+		}
+		for _, jobID := range eventsJobs {
+			job, err := client.GetJob(jobID)
+			if err != nil {
+				return err
+			}
+			match.JobIDs = append(match.JobIDs, job.ID)
+		}
+
 		// Determine workdir filter
-		var workdir string
 		if !eventsAll {
 			cwd, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("failed to get current directory: %w", err)
 			}
-			workdir = cwd
+			filter.Workdir = cwd
 		}
 
-		// Subscribe to events
-		encoder := json.NewEncoder(cmd.OutOrStdout())
-		err = client.Subscribe(workdir, func(event daemon.Event) error {
-			return encoder.Encode(event)
-		})
+		out := cmd.OutOrStdout()
+		printEvent := func(event daemon.Event) error {
+			if eventsJSON {
+				return json.NewEncoder(out).Encode(event)
+			}
+			_, err := fmt.Fprintln(out, formatEvent(event))
+			return err
+		}
 
-		if err != nil {
+		// Print recorded events, and continue following after the last one
+		opts := daemon.SubscribeOptions{Snapshot: eventsSnapshot, Match: match, Reconnect: true}
+		if recorded {
+			events, seq, err := client.Events(filter)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if !match.Matches(event) {
+					continue
+				}
+				if err := printEvent(event); err != nil {
+					return err
+				}
+			}
+			if !eventsFollow {
+				return nil
+			}
+			opts.Replay = true
+			opts.Since = seq
+		}
+
+		if err := client.SubscribeWithOptions(filter.Workdir, opts, printEvent); err != nil {
 			return fmt.Errorf("subscription error: %w", err)
 		}
 
@@ -67,8 +166,70 @@ Press Ctrl+C to stop.`,
 	},
 }
 
+// formatEvent formats an event as a line of text
+func formatEvent(event daemon.Event) string {
+	when := event.Time
+	if t, err := time.Parse(time.RFC3339, event.Time); err == nil {
+		when = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	if event.Type == daemon.EventTypeSnapshot {
+		return fmt.Sprintf("%s  #%d  %s  %d jobs, %d runs", when, event.Seq, event.Type, len(event.Jobs), len(event.Runs))
+	}
+	if a := event.Approval; a != nil {
+		line := fmt.Sprintf("%s  #%d  %s  #%d  %s %s %s  (%s)", when, event.Seq, event.Type, a.ID, a.Client, a.Type, a.Target, a.Reason)
+		if a.Decision != "" {
+			line += "  " + a.Decision
+		}
+		return line
+	}
+
+	line := fmt.Sprintf("%s  #%d  %s  %s  %s", when, event.Seq, event.Type, event.JobID, strings.Join(event.Job.Command, " "))
+	switch event.Type {
+	case daemon.EventTypeJobStopped, daemon.EventTypeRunStopped:
+		if event.Job.ExitCode != nil {
+			line += fmt.Sprintf("  (exit %d)", *event.Job.ExitCode)
+		} else {
+			line += "  (killed)"
+		}
+	case daemon.EventTypePortsUpdated:
+		var ports []string
+		for _, p := range event.Ports {
+			ports = append(ports, fmt.Sprintf("%d", p.Port))
+		}
+		line += fmt.Sprintf("  (ports: %s)", strings.Join(ports, ", "))
+	case daemon.EventTypeRunUpdated:
+		if event.Run != nil && event.Run.Note != "" {
+			line += fmt.Sprintf("  (%s: %s)", event.Run.ID, event.Run.Note)
+		}
+	case daemon.EventTypeJobSlow:
+		if event.Run != nil {
+			line += fmt.Sprintf("  (%s slower than %s)", event.Run.ID, event.Job.SlowThreshold)
+		}
+	}
+	return line
+}
+
 func init() {
 	RootCmd.AddCommand(eventsCmd)
 	eventsCmd.Flags().BoolVarP(&eventsAll, "all", "a", false,
 		"Show events from all directories")
+	eventsCmd.Flags().StringVarP(&eventsSince, "since", "s", "",
+		"Show the events recorded within this long (e.g. 30m, 12h, 2d)")
+	eventsCmd.Flags().Uint64Var(&eventsAfter, "after", 0,
+		"Show the events recorded after this sequence number")
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false,
+		"Keep following new events after the recorded ones")
+	eventsCmd.Flags().BoolVar(&eventsJSON, "json", false,
+		"Print events as JSON, one per line")
+	eventsCmd.Flags().StringSliceVarP(&eventsTypes, "type", "T", nil,
+		"Only show events of these types")
+	eventsCmd.Flags().StringSliceVarP(&eventsJobs, "job", "j", nil,
+		"Only show events of these jobs (IDs or aliases)")
+	eventsCmd.Flags().StringSliceVarP(&eventsTags, "tag", "t", nil,
+		"Only show events of jobs with one of these tags")
+	eventsCmd.RegisterFlagCompletionFunc("job", completeJobIDs)
+	eventsCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	eventsCmd.Flags().BoolVar(&eventsSnapshot, "snapshot", false,
+		"Start following with a snapshot of the current jobs and runs")
 }
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportJob      string
+	exportFormat   string
+	exportSince    string
+	exportAll      bool
+	exportLogPaths bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export run history as CSV or JSON lines",
+	Long: `Export the run history of the jobs in the current directory, oldest
+first, for analysis in other tools (spreadsheets, jq, pandas, ...).
+
+Each run is written with its job, command, status, exit code, start and stop
+times, duration, log size and note. The daemon reads runs from its database
+a page at a time, so long histories export without loading every run.
+
+Formats:
+  jsonl  One JSON object per line (default)
+  csv    A header line, then one row per run
+
+Fields:
+  run_id, job_id, command, workdir, status, exit_code, started_at,
+  stopped_at, duration_ms, log_bytes, note
+  and stdout_path, stderr_path with --log-paths
+
+Missing values (no exit code for a killed run, no stop time for a running
+one) are empty in CSV and left out in JSON lines.
+
+Examples:
+  # Runs of job abc in the last week, as JSON lines
+  gob export --job abc --since 7d
+
+  # Every run of every directory, as CSV
+  gob export --all --format csv > runs.csv
+
+  # Durations of successful runs with jq
+  gob export --job abc | jq 'select(.exit_code == 0) | .duration_ms'
+
+Exit codes:
+  0: Success
+  1: Error (job not found, invalid format or duration)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != "jsonl" && exportFormat != "csv" {
+			return fmt.Errorf("invalid format: %s (expected jsonl or csv)", exportFormat)
+		}
+		if exportJob != "" && exportAll {
+			return fmt.Errorf("--all cannot be used with --job")
+		}
+
+		filter := daemon.RunFilter{JobID: exportJob}
+		if exportJob == "" && !exportAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			filter.Workdir = cwd
+		}
+		if exportSince != "" {
+			since, err := parseAge(exportSince)
+			if err != nil {
+				return err
+			}
+			filter.Since = time.Now().Add(-since)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		out := bufio.NewWriter(cmd.OutOrStdout())
+		defer out.Flush()
+
+		write := writeExportJSONL(out, exportLogPaths)
+		if exportFormat == "csv" {
+			w := csv.NewWriter(out)
+			defer w.Flush()
+			if err := w.Write(exportFields(exportLogPaths)); err != nil {
+				return err
+			}
+			write = func(run daemon.HistoryEntry) error {
+				return w.Write(exportRow(run, exportLogPaths))
+			}
+		}
+
+		// Write each page as it comes
+		for {
+			runs, next, err := client.ExportRuns(filter)
+			if err != nil {
+				return err
+			}
+			for _, run := range runs {
+				if err := write(run); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			filter.After = next
+		}
+	},
+}
+
+// exportRecord is a run as written by gob export --format jsonl
+type exportRecord struct {
+	RunID      string   `json:"run_id"`
+	JobID      string   `json:"job_id"`
+	Command    []string `json:"command"`
+	Workdir    string   `json:"workdir"`
+	Status     string   `json:"status"`
+	ExitCode   *int     `json:"exit_code,omitempty"`
+	StartedAt  string   `json:"started_at"`
+	StoppedAt  string   `json:"stopped_at,omitempty"`
+	DurationMs *int64   `json:"duration_ms,omitempty"`
+	LogBytes   *int64   `json:"log_bytes,omitempty"`
+	Note       string   `json:"note,omitempty"`
+	StdoutPath string   `json:"stdout_path,omitempty"`
+	StderrPath string   `json:"stderr_path,omitempty"`
+}
+
+// newExportRecord converts a run for export, with its log paths if asked
+func newExportRecord(run daemon.HistoryEntry, logPaths bool) exportRecord {
+	r := exportRecord{
+		RunID:     run.ID,
+		JobID:     run.JobID,
+		Command:   run.Command,
+		Workdir:   run.Workdir,
+		Status:    run.Status,
+		ExitCode:  run.ExitCode,
+		StartedAt: run.StartedAt,
+		StoppedAt: run.StoppedAt,
+		LogBytes:  run.LogBytes,
+		Note:      run.Note,
+	}
+	// Running runs have no duration yet
+	if run.Status != "running" {
+		r.DurationMs = &run.DurationMs
+	}
+	if logPaths {
+		r.StdoutPath = run.StdoutPath
+		r.StderrPath = run.StderrPath
+	}
+	return r
+}
+
+// writeExportJSONL returns a function writing runs as JSON lines
+func writeExportJSONL(w io.Writer, logPaths bool) func(daemon.HistoryEntry) error {
+	enc := json.NewEncoder(w)
+	return func(run daemon.HistoryEntry) error {
+		return enc.Encode(newExportRecord(run, logPaths))
+	}
+}
+
+// exportFields returns the CSV header of gob export
+func exportFields(logPaths bool) []string {
+	fields := []string{"run_id", "job_id", "command", "workdir", "status", "exit_code", "started_at",
+		"stopped_at", "duration_ms", "log_bytes", "note"}
+	if logPaths {
+		fields = append(fields, "stdout_path", "stderr_path")
+	}
+	return fields
+}
+
+// exportRow returns the CSV row of a run, in the order of exportFields
+func exportRow(run daemon.HistoryEntry, logPaths bool) []string {
+	r := newExportRecord(run, logPaths)
+	optional := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	}
+	exitCode := ""
+	if r.ExitCode != nil {
+		exitCode = fmt.Sprint(*r.ExitCode)
+	}
+
+	row := []string{r.RunID, r.JobID, strings.Join(r.Command, " "), r.Workdir, r.Status, exitCode, r.StartedAt,
+		r.StoppedAt, optional(r.DurationMs), optional(r.LogBytes), r.Note}
+	if logPaths {
+		row = append(row, r.StdoutPath, r.StderrPath)
+	}
+	return row
+}
+
+func init() {
+	RootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportJob, "job", "j", "",
+		"Only export the runs of this job")
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "jsonl",
+		"Output format: jsonl or csv")
+	exportCmd.Flags().StringVarP(&exportSince, "since", "s", "",
+		"Only export runs started within this long (e.g. 30m, 12h, 7d)")
+	exportCmd.Flags().BoolVarP(&exportAll, "all", "a", false,
+		"Export runs from all directories")
+	exportCmd.Flags().BoolVar(&exportLogPaths, "log-paths", false,
+		"Include the paths of each run's stdout and stderr logs")
+	exportCmd.RegisterFlagCompletionFunc("job", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return jobCompletions(toComplete, nil)
+	})
+	exportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"jsonl", "csv"}, cobra.ShellCompDirectiveNoFileComp))
+}
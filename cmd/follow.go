@@ -8,6 +8,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
 	"github.com/juanibiapina/gob/internal/process"
 	"github.com/juanibiapina/gob/internal/tail"
 )
@@ -18,23 +21,116 @@ const DefaultStuckTimeoutMs int64 = 5 * 60 * 1000
 // NoOutputWindowMs is the constant "no output" window (1 minute)
 const NoOutputWindowMs int64 = 60 * 1000
 
+// RestartGracePeriod is how long followers of restarts wait after a run exits
+// for the job to start a new one before they consider it completed
+const RestartGracePeriod = 2 * time.Second
+
 // FollowResult represents the result of following a job
 type FollowResult struct {
 	Completed     bool // job finished running
 	PossiblyStuck bool // job may be stuck (timed out without output)
 }
 
+// watchRestarts subscribes to the daemon events of a directory and returns
+// the runs started for the job from then on. The channel is closed when the
+// subscription ends. Call stop to unsubscribe.
+func watchRestarts(jobID string, workdir string) (restarts <-chan daemon.RunResponse, stop func(), err error) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create event client: %w", err)
+	}
+	if err := client.Connect(); err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("failed to connect event client: %w", err)
+	}
+
+	runs := make(chan daemon.RunResponse, 10)
+	eventCh, _ := client.SubscribeChanWithOptions(workdir, daemon.SubscribeOptions{
+		Match:     daemon.EventMatch{Types: []daemon.EventType{daemon.EventTypeRunStarted}, JobIDs: []string{jobID}},
+		Reconnect: true,
+	})
+	go func() {
+		defer close(runs)
+		for event := range eventCh {
+			if event.Type == daemon.EventTypeRunStarted && event.JobID == jobID && event.Run != nil {
+				runs <- *event.Run
+			}
+		}
+	}()
+
+	return runs, func() { client.Close() }, nil
+}
+
+// recordStuck marks the job's current run as possibly stuck, so that the
+// moment shows up in its logs and in the TUI. It is best effort: the job may
+// have stopped meanwhile.
+func recordStuck(jobID string) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		return
+	}
+	client.MarkStuck(jobID)
+}
+
+// nextRun returns the run started next from restarts. When the current run
+// has exited, it waits up to RestartGracePeriod for one. A nil restarts
+// channel never yields a run.
+func nextRun(restarts <-chan daemon.RunResponse, exited bool) (daemon.RunResponse, bool) {
+	if restarts == nil {
+		return daemon.RunResponse{}, false
+	}
+	if exited {
+		select {
+		case run, ok := <-restarts:
+			return run, ok
+		case <-time.After(RestartGracePeriod):
+			return daemon.RunResponse{}, false
+		}
+	}
+	select {
+	case run, ok := <-restarts:
+		return run, ok
+	default:
+		return daemon.RunResponse{}, false
+	}
+}
+
+// parseRunStart parses the start time of a run, or returns now if unknown
+func parseRunStart(startedAt string) time.Time {
+	if t, err := time.Parse(time.RFC3339, startedAt); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// formatProgressEstimate describes how far a run is into the typical
+// duration of its job
+func formatProgressEstimate(elapsed, typical time.Duration) string {
+	if elapsed >= typical {
+		return fmt.Sprintf("over typical duration (%s)", formatDuration(typical))
+	}
+	return fmt.Sprintf("~%d%% of typical duration, ETA %s", int(elapsed*100/typical), formatDuration(typical-elapsed))
+}
+
 // followJob follows a job's output until it completes, is interrupted, or is detected as possibly stuck
-// avgDurationMs is the average duration of successful runs (0 if no history)
+// avgDurationMs is the average duration of successful runs (0 if no history).
+// When known, the progress of the run (started at startedAt, RFC3339) against
+// it is logged at each quarter.
 // stdoutPath is the full path to the stdout log file
-func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (FollowResult, error) {
+// restarts, if not nil, yields new runs of the job: the follower switches to
+// them instead of completing (see watchRestarts)
+func followJob(jobID string, pid int, stdoutPath, startedAt string, avgDurationMs int64, restarts <-chan daemon.RunResponse) (FollowResult, error) {
 	// Derive stderr path from stdout path
 	stderrPath := strings.Replace(stdoutPath, ".stdout.log", ".stderr.log", 1)
 
 	// Wait for log files to exist
 	for i := 0; i < 50; i++ {
-		_, errStdout := os.Stat(stdoutPath)
-		_, errStderr := os.Stat(stderrPath)
+		_, errStdout := logfile.Stat(stdoutPath)
+		_, errStderr := logfile.Stat(stderrPath)
 		if errStdout == nil && errStderr == nil {
 			break
 		}
@@ -42,10 +138,10 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 	}
 
 	// Check if log files exist
-	if _, err := os.Stat(stdoutPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(stdoutPath); os.IsNotExist(err) {
 		return FollowResult{}, fmt.Errorf("stdout log file not found: %s", stdoutPath)
 	}
-	if _, err := os.Stat(stderrPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(stderrPath); os.IsNotExist(err) {
 		return FollowResult{}, fmt.Errorf("stderr log file not found: %s", stderrPath)
 	}
 
@@ -66,8 +162,8 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 	// Create follower
 	follower := tail.NewFollower(os.Stdout)
 
-	// Yellow ANSI color for stderr prefix (uses terminal theme)
-	stderrPrefix := fmt.Sprintf("\033[33m[%s]\033[0m ", jobID)
+	// Yellow stderr prefix (uses terminal theme)
+	stderrPrefix := output.Colorize(os.Stdout, output.Yellow, "["+jobID+"]") + " "
 	stdoutPrefix := fmt.Sprintf("[%s] ", jobID)
 
 	follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix})
@@ -80,6 +176,13 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 	// Track completion status
 	result := FollowResult{}
 	startTime := time.Now()
+	typical := time.Duration(avgDurationMs) * time.Millisecond
+	runStart := parseRunStart(startedAt)
+	nextQuarter := 1
+	if typical > 0 {
+		// Quarters already passed are not reported
+		nextQuarter = int(time.Since(runStart)*4/typical) + 1
+	}
 
 	// Monitor for process completion, signal, or stuck condition
 	done := make(chan struct{})
@@ -89,8 +192,23 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 			case <-done:
 				return
 			default:
+				// Switch to the new run when the job restarts
+				exited := !process.IsProcessRunning(pid)
+				if run, ok := nextRun(restarts, exited); ok {
+					follower.RemoveSource(stdoutPath)
+					follower.RemoveSource(stderrPath)
+					pid, stdoutPath, stderrPath = run.PID, run.StdoutPath, run.StderrPath
+					follower.SystemLog("job %s restarted (pid:%d run:%s)", jobID, run.PID, run.ID)
+					follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix})
+					follower.AddSource(tail.FileSource{Path: stderrPath, Prefix: stderrPrefix})
+					startTime = time.Now()
+					runStart = parseRunStart(run.StartedAt)
+					nextQuarter = 1
+					continue
+				}
+
 				// Check if process completed
-				if !process.IsProcessRunning(pid) {
+				if exited {
 					// Give a moment for any final output to be written
 					time.Sleep(200 * time.Millisecond)
 					result.Completed = true
@@ -103,8 +221,15 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 				elapsed := time.Since(startTime)
 				timeSinceOutput := time.Since(follower.LastOutputTime())
 
+				// Report progress at 25%, 50%, 75% and 100% of the typical duration
+				if runElapsed := time.Since(runStart); typical > 0 && nextQuarter <= 4 && runElapsed*4 >= typical*time.Duration(nextQuarter) {
+					follower.SystemLog("%s", formatProgressEstimate(runElapsed, typical))
+					nextQuarter = int(runElapsed*4/typical) + 1
+				}
+
 				if elapsed > stuckTimeout && timeSinceOutput > noOutputWindow {
 					result.PossiblyStuck = true
+					recordStuck(jobID)
 					follower.Stop()
 					return
 				}
@@ -128,15 +253,16 @@ func followJob(jobID string, pid int, stdoutPath string, avgDurationMs int64) (F
 
 // waitForJob waits for a job to complete without streaming output.
 // It monitors for completion, stuck condition, or interruption using file mod times
-// for stuck detection instead of a Follower.
-func waitForJob(pid int, stdoutPath string, avgDurationMs int64) (FollowResult, error) {
+// for stuck detection instead of a Follower. Like followJob, it waits for the
+// runs yielded by restarts if that is not nil.
+func waitForJob(pid int, stdoutPath string, avgDurationMs int64, restarts <-chan daemon.RunResponse) (FollowResult, error) {
 	// Derive stderr path from stdout path
 	stderrPath := strings.Replace(stdoutPath, ".stdout.log", ".stderr.log", 1)
 
 	// Wait for log files to exist
 	for i := 0; i < 50; i++ {
-		_, errStdout := os.Stat(stdoutPath)
-		_, errStderr := os.Stat(stderrPath)
+		_, errStdout := logfile.Stat(stdoutPath)
+		_, errStderr := logfile.Stat(stderrPath)
 		if errStdout == nil && errStderr == nil {
 			break
 		}
@@ -144,10 +270,10 @@ func waitForJob(pid int, stdoutPath string, avgDurationMs int64) (FollowResult,
 	}
 
 	// Check if log files exist
-	if _, err := os.Stat(stdoutPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(stdoutPath); os.IsNotExist(err) {
 		return FollowResult{}, fmt.Errorf("stdout log file not found: %s", stdoutPath)
 	}
-	if _, err := os.Stat(stderrPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(stderrPath); os.IsNotExist(err) {
 		return FollowResult{}, fmt.Errorf("stderr log file not found: %s", stderrPath)
 	}
 
@@ -179,8 +305,16 @@ func waitForJob(pid int, stdoutPath string, avgDurationMs int64) (FollowResult,
 			// Interrupted — job continues in background
 			return result, nil
 		case <-ticker.C:
+			// Wait for the new run when the job restarts
+			exited := !process.IsProcessRunning(pid)
+			if run, ok := nextRun(restarts, exited); ok {
+				pid, stdoutPath, stderrPath = run.PID, run.StdoutPath, run.StderrPath
+				startTime = time.Now()
+				continue
+			}
+
 			// Check if process completed
-			if !process.IsProcessRunning(pid) {
+			if exited {
 				result.Completed = true
 				return result, nil
 			}
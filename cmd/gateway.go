@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var gatewayAddr string
+
+var gatewayCmd = &cobra.Command{
+	Use:   "gateway",
+	Short: "Manage the daemon's HTTP gateway",
+	Long: `Manage the daemon's HTTP gateway.
+
+The gateway exposes the daemon to editor extensions and web dashboards over
+HTTP, without speaking the Unix socket protocol:
+
+  POST /api/<type>     Run a request (list, add, stop, runs, ports, ...).
+                       The JSON body is the request payload; the response
+                       is the daemon's response.
+  GET  /api/subscribe  WebSocket streaming one event per message, like
+                       gob events --json. Query parameters: workdir,
+                       snapshot=true, since=<seq>.
+  GET  /api/logs       WebSocket streaming a run's output, one
+                       {"stream":"stdout","line":"..."} per message.
+                       Query parameters: job_id, run_id (default: the
+                       latest run).
+  GET  /               A read-only dashboard (see gob web).
+
+Every request needs the gateway's token, as an "Authorization: Bearer"
+header or a token query parameter. A new token is made each time the
+gateway starts and written, with the address, to a file only you can read
+(shown by gob gateway status). An X-Gob-Client header names the client in
+the audit log and on the jobs and runs it starts (default: http).
+
+Once started, the gateway starts again with the daemon until it is stopped.
+
+Examples:
+  # Start the gateway on the default address (127.0.0.1:7070)
+  gob gateway start
+
+  # List jobs in a directory
+  curl -H "Authorization: Bearer $TOKEN" \
+    -d '{"workdir":"/path/to/project"}' http://127.0.0.1:7070/api/list
+
+  # Stop the gateway
+  gob gateway stop`,
+}
+
+var gatewayStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the HTTP gateway",
+	Long: `Start the daemon's HTTP gateway.
+
+The gateway listens on 127.0.0.1:7070 unless --addr is given. Listening on
+other interfaces than loopback makes the daemon reachable by anyone with
+the token.
+
+Output:
+  Gateway listening on http://127.0.0.1:7070
+  Token in /run/user/1000/gob/gateway.json
+
+Exit codes:
+  0: Gateway running
+  1: Error (address in use, connection failed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connectGateway()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		info, err := client.GatewayStart(gatewayAddr)
+		if err != nil {
+			return err
+		}
+
+		return printGatewayInfo(info)
+	},
+}
+
+var gatewayStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the HTTP gateway",
+	Long: `Stop the daemon's HTTP gateway. It no longer starts with the daemon.
+
+Exit codes:
+  0: Gateway stopped (or was not running)
+  1: Error (connection failed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connectGateway()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		if err := client.GatewayStop(); err != nil {
+			return err
+		}
+
+		fmt.Println("Gateway stopped")
+		return nil
+	},
+}
+
+var gatewayStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the HTTP gateway's address",
+	Long: `Show where the daemon's HTTP gateway listens and where its token is.
+
+Exit codes:
+  0: Gateway running
+  1: Gateway not running, or error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connectGateway()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		info, err := client.GatewayStatus()
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return fmt.Errorf("gateway is not running")
+		}
+
+		return printGatewayInfo(info)
+	},
+}
+
+// connectGateway connects to the daemon for a gateway command
+func connectGateway() (*daemon.Client, error) {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if err := client.Connect(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	return client, nil
+}
+
+// printGatewayInfo prints the gateway's URL and the file holding its token
+func printGatewayInfo(info *daemon.GatewayInfo) error {
+	path, err := daemon.GetGatewayInfoPath()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Gateway listening on %s\n", info.URL)
+	fmt.Printf("Token in %s\n", path)
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(gatewayCmd)
+	gatewayCmd.AddCommand(gatewayStartCmd)
+	gatewayCmd.AddCommand(gatewayStopCmd)
+	gatewayCmd.AddCommand(gatewayStatusCmd)
+	gatewayStartCmd.Flags().StringVar(&gatewayAddr, "addr", "",
+		"Address to listen on (default 127.0.0.1:7070)")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	grepJob        string
+	grepSince      string
+	grepAll        bool
+	grepIgnoreCase bool
+	grepMax        int
+	grepJSON       bool
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <pattern>",
+	Short: "Search the logs of past and current runs",
+	Long: `Search the stored stdout and stderr of runs for lines matching a pattern.
+
+The pattern is a regular expression (Go syntax). The search runs in the
+daemon over every run whose logs are still stored, oldest run first, so the
+first match shows when a line first appeared.
+
+By default, searches the jobs of the current directory.
+
+Examples:
+  # When did this error first appear?
+  gob grep "connection refused"
+
+  # Only one job, in the last two days
+  gob grep --job abc --since 2d "panic:"
+
+  # Case-insensitive, across all directories
+  gob grep -i --all "timeout"
+
+Output format:
+  <run_id>  <started>  <stream>:<line>: <text>
+
+Where:
+  run_id:  Run whose logs contain the line (e.g., abc-3)
+  started: When that run started
+  stream:  stdout or stderr
+  line:    Line number within the stream
+
+Exit codes:
+  0: Search completed (with or without matches)
+  1: Error (invalid pattern, job not found)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := daemon.GrepRequest{
+			Pattern:    args[0],
+			IgnoreCase: grepIgnoreCase,
+			JobID:      grepJob,
+			MaxMatches: grepMax,
+		}
+
+		if grepSince != "" {
+			since, err := parseAge(grepSince)
+			if err != nil {
+				return err
+			}
+			g.Since = time.Now().Add(-since)
+		}
+
+		if !grepAll && grepJob == "" {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			g.Workdir = cwd
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		matches, truncated, err := client.Grep(g)
+		if err != nil {
+			return err
+		}
+
+		if grepJSON {
+			if matches == nil {
+				matches = []daemon.GrepMatch{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(matches)
+		}
+
+		if len(matches) == 0 {
+			fmt.Println("No matches found")
+			return nil
+		}
+
+		for _, m := range matches {
+			startedAt, _ := time.Parse("2006-01-02T15:04:05Z07:00", m.StartedAt)
+			text := m.Text
+			if m.Truncated {
+				text += " [truncated]"
+			}
+			fmt.Printf("%s  %s  %s:%d: %s\n", m.RunID, startedAt.Local().Format("2006-01-02 15:04:05"), m.Stream, m.Line, text)
+		}
+
+		if truncated {
+			fmt.Fprintf(os.Stderr, "Stopped after %d matches (use --max to see more)\n", len(matches))
+		}
+
+		return nil
+	},
+}
+
+// parseAge parses a duration such as "90s", "30m", "12h" or "2d"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+	return d, nil
+}
+
+func init() {
+	RootCmd.AddCommand(grepCmd)
+	grepCmd.Flags().StringVarP(&grepJob, "job", "j", "",
+		"Only search the runs of this job")
+	grepCmd.Flags().StringVarP(&grepSince, "since", "s", "",
+		"Only search runs active within this long (e.g. 30m, 12h, 2d)")
+	grepCmd.Flags().BoolVarP(&grepAll, "all", "a", false,
+		"Search jobs from all directories")
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false,
+		"Match case-insensitively")
+	grepCmd.Flags().IntVarP(&grepMax, "max", "m", 0,
+		"Maximum number of matches (default 1000)")
+	grepCmd.Flags().BoolVar(&grepJSON, "json", false,
+		"Output in JSON format")
+	grepCmd.RegisterFlagCompletionFunc("job", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return jobCompletions(toComplete, nil)
+	})
+}
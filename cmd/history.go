@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyAll    bool
+	historyLimit  int
+	historyOffset int
+	historyJSON   bool
+	historyLabel  string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent runs across all jobs",
+	Long: `Show recent runs of all jobs in the current directory, newest first.
+
+Unlike 'gob runs', which shows the runs of one job, history interleaves the
+runs of every job so you can see what happened recently.
+
+Output format:
+  <run_id>  <started>  <duration>  <status>  <command>  [# <note>]
+
+Where:
+  run_id:   Run identifier (e.g., abc-1, abc-2)
+  started:  When the run started (relative time)
+  duration: How long the run took (or "running" if still active)
+  status:   Exit status: ◉ (running), ✓ (0) for success, ✗ (N) for failure
+  command:  The job's command
+  note:     The run's note, if any (see 'gob annotate')
+
+Examples:
+  # Last 20 runs in the current directory
+  gob history
+
+  # Last 50 runs across all directories
+  gob history --all --limit 50
+
+  # The next page
+  gob history --offset 20
+
+  # Runs started with 'gob run --label pr-1234' (see 'gob summary')
+  gob history --label pr-1234
+
+Example output:
+  abc-5  2 min ago    running   ◉      npm run dev
+  def-2  1 hour ago   12s       ✓ (0)  make test
+  def-1  2 hours ago  10s       ✗ (2)  make test
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var workdir string
+		if !historyAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			workdir = cwd
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		entries, total, err := client.History(workdir, historyLabel, historyOffset, historyLimit)
+		if err != nil {
+			return err
+		}
+
+		if historyJSON {
+			if entries == nil {
+				entries = []daemon.HistoryEntry{}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println("No runs found")
+			return nil
+		}
+
+		for _, entry := range entries {
+			started, duration, status := formatRunColumns(entry.RunResponse)
+			line := fmt.Sprintf("%s  %-12s  %-10s  %-6s  %s", entry.ID, started, duration, status, strings.Join(entry.Command, " "))
+			if historyAll {
+				line += "  (" + entry.Workdir + ")"
+			}
+			if entry.Dir != "" {
+				line += "  (in " + entry.Dir + ")"
+			}
+			if entry.LogsMissing {
+				line += "  (logs missing)"
+			}
+			if entry.Note != "" {
+				line += "  # " + entry.Note
+			}
+			fmt.Println(line)
+		}
+
+		if shown := historyOffset + len(entries); shown < total {
+			fmt.Printf("\nShowing %d-%d of %d runs (use --offset %d for more)\n", historyOffset+1, shown, total, shown)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().BoolVarP(&historyAll, "all", "a", false,
+		"Show runs from all directories")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20,
+		"Maximum number of runs to show (0 for all)")
+	historyCmd.Flags().IntVar(&historyOffset, "offset", 0,
+		"Number of most recent runs to skip")
+	historyCmd.Flags().StringVarP(&historyLabel, "label", "l", "",
+		"Only show runs with this label")
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false,
+		"Output in JSON format")
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var hookPID int
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Hooks called by the shell integration",
+	Long: `Hooks called by the shell integration printed by 'gob shell-init'.
+They are not meant to be run by hand.`,
+}
+
+var hookCdCmd = &cobra.Command{
+	Use:   "cd",
+	Short: "Start and stop gobfile jobs as shells enter and leave projects",
+	Long: `Tell the daemon which project the shell is in, starting the project's
+on_enter jobs when the shell enters it.
+
+A project is the nearest directory with a .config/gobfile.toml, from the
+current directory up. Jobs with on_enter = true are started when a shell
+enters the project. If the gobfile sets stop_on_leave to a duration, the
+jobs this hook started are stopped once no shell has been in the project
+for that long. Shells count as in their last reported project until they
+exit.
+
+The shell integration calls it when the shell starts and on every change of
+directory ('gob shell-init <shell> --cd').
+
+Gobfile:
+  stop_on_leave = "10m"
+
+  [[job]]
+  command = "npm run dev"
+  on_enter = true
+
+Output (stderr, when jobs start):
+  gob: started npm run dev
+
+Exit codes:
+  0: Success
+  1: Error (invalid gobfile, daemon error)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pid := hookPID
+		if pid == 0 {
+			pid = os.Getppid()
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		var config *tui.GobfileConfig
+		project := daemon.GobfileDir(cwd)
+		if project != "" {
+			if config, err = tui.ReadGobfile(project); err != nil {
+				return fmt.Errorf("invalid gobfile: %w", err)
+			}
+		}
+
+		var stopAfter time.Duration
+		if config != nil && config.StopOnLeave != "" {
+			if stopAfter, err = time.ParseDuration(config.StopOnLeave); err != nil || stopAfter < 0 {
+				return fmt.Errorf("invalid stop_on_leave: %s (expected a duration like 10m)", config.StopOnLeave)
+			}
+		}
+
+		// Only projects with jobs to start need the daemon started
+		onEnter := config != nil && slices.ContainsFunc(config.Jobs, func(job tui.GobfileJob) bool {
+			return job.OnEnter && !job.IsBlocked()
+		})
+
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if onEnter {
+			err = client.Connect()
+		} else if err = client.ConnectRunning(); err != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		entered, err := client.ShellPresence(pid, project, nil, 0)
+		if err != nil {
+			return err
+		}
+		if !entered || !onEnter {
+			return nil
+		}
+
+		stderr := cmd.ErrOrStderr()
+		var started, startedIDs []string
+		for _, job := range config.Jobs {
+			if !job.OnEnter || job.IsBlocked() || len(job.CommandArgs()) == 0 {
+				continue
+			}
+
+			opts, err := job.Options(project, config.LogDir)
+			if err != nil {
+				fmt.Fprintf(stderr, "gob: ignoring invalid settings for '%s': %v\n", job.Command, err)
+			}
+
+			result, err := client.Add(job.CommandArgs(), job.JobWorkdir(project), os.Environ(), opts)
+			if err != nil {
+				fmt.Fprintf(stderr, "gob: failed to start '%s': %v\n", job.Command, err)
+				continue
+			}
+			if result.Action != "already_running" {
+				started = append(started, job.Command)
+				startedIDs = append(startedIDs, result.Job.ID)
+			}
+		}
+
+		if len(started) > 0 {
+			fmt.Fprintf(stderr, "gob: started %s\n", strings.Join(started, ", "))
+		}
+
+		// Jobs that were already running are left alone when leaving
+		if config.StopOnLeave != "" && len(startedIDs) > 0 {
+			if _, err := client.ShellPresence(pid, project, startedIDs, stopAfter); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookCdCmd)
+	hookCdCmd.Flags().IntVar(&hookPID, "pid", 0,
+		"PID of the shell (defaults to the parent process)")
+}
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var ipcStdio bool
+
+// ipcCapabilities lists what an ipc session supports, for clients to check
+// in the handshake
+var ipcCapabilities = []string{
+	"requests",           // every daemon request type
+	"subscribe",          // event streams, several at a time
+	"subscribe.snapshot", // subscribe with "snapshot": true
+	"subscribe.replay",   // subscribe with "since": <seq>
+	"unsubscribe",        // end an event stream
+}
+
+// ipcMessage is a line read from or written to an ipc session
+type ipcMessage struct {
+	ID      json.RawMessage `json:"id,omitempty"` // chosen by the client, echoed back
+	Type    string          `json:"type"`
+	Payload map[string]any  `json:"payload,omitempty"`
+
+	// Set in messages written by gob
+	Response *daemon.Response `json:"response,omitempty"`
+	Event    *daemon.Event    `json:"event,omitempty"`
+}
+
+// ipcHandshake is the first line written by an ipc session
+type ipcHandshake struct {
+	Type            string   `json:"type"` // always "handshake"
+	ProtocolVersion int      `json:"protocol_version"`
+	Version         string   `json:"version"`
+	Capabilities    []string `json:"capabilities"`
+}
+
+var ipcCmd = &cobra.Command{
+	Use:   "ipc --stdio",
+	Short: "Speak the daemon protocol over stdin and stdout",
+	Long: `Speak the daemon protocol over stdin and stdout, for editor extensions
+that embed gob without finding the daemon's socket themselves.
+
+Messages are JSON objects, one per line. The first line written is a
+handshake:
+  {"type":"handshake","protocol_version":1,"version":"1.2.3","capabilities":[...]}
+
+Each line read is a request with an id of your choosing, a type and a
+payload, as in the daemon protocol. Requests run concurrently; every
+message written for one carries its id:
+  > {"id":1,"type":"list","payload":{"workdir":"/path/to/project"}}
+  < {"id":1,"type":"response","response":{"success":true,"data":{"jobs":[...]}}}
+
+A subscribe request is answered with a response, then one message per
+event until it is ended with unsubscribe (or the daemon stops), then an end
+message:
+  > {"id":2,"type":"subscribe","payload":{"snapshot":true}}
+  < {"id":2,"type":"response","response":{"success":true,"data":{"seq":41}}}
+  < {"id":2,"type":"event","event":{"seq":42,"type":"job_added",...}}
+  > {"id":3,"type":"unsubscribe","payload":{"id":2}}
+  < {"id":2,"type":"end"}
+  < {"id":3,"type":"response","response":{"success":true}}
+
+Lines that are not valid requests are answered with an error response.
+The session ends when stdin is closed.
+
+Exit codes:
+  0: stdin closed
+  1: Error (connection failed, stdout closed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !ipcStdio {
+			return fmt.Errorf("--stdio is required")
+		}
+
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		session := newIPCSession(client, os.Stdout)
+		return session.serve(os.Stdin)
+	},
+}
+
+// ipcSession runs the requests read from an ipc client
+type ipcSession struct {
+	client *daemon.Client
+
+	writeMu sync.Mutex
+	encoder *json.Encoder
+
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]*ipcSubscription // event streams, by request id
+	closed          bool                        // stdin ended, no new streams
+
+	wg sync.WaitGroup
+}
+
+// ipcSubscription is an event stream of an ipc session
+type ipcSubscription struct {
+	client *daemon.Client // the stream's own connection
+	ended  chan struct{}  // closed once the end message is written
+}
+
+func newIPCSession(client *daemon.Client, w io.Writer) *ipcSession {
+	return &ipcSession{
+		client:        client,
+		encoder:       json.NewEncoder(w),
+		subscriptions: make(map[string]*ipcSubscription),
+	}
+}
+
+// write writes a message as a line
+func (s *ipcSession) write(v any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.encoder.Encode(v)
+}
+
+// respond writes the response to the request with the given id
+func (s *ipcSession) respond(id json.RawMessage, resp *daemon.Response) {
+	s.write(ipcMessage{ID: id, Type: "response", Response: resp})
+}
+
+// serve writes the handshake and runs requests read from r until it ends,
+// then ends the event streams
+func (s *ipcSession) serve(r io.Reader) error {
+	err := s.write(ipcHandshake{
+		Type:            "handshake",
+		ProtocolVersion: daemon.ProtocolVersion,
+		Version:         version.Version,
+		Capabilities:    ipcCapabilities,
+	})
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var msg ipcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			s.respond(nil, daemon.NewErrorResponse(fmt.Errorf("invalid request: %w", err)))
+			continue
+		}
+		if msg.Payload == nil {
+			msg.Payload = make(map[string]any)
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(msg)
+		}()
+	}
+
+	s.subscriptionsMu.Lock()
+	s.closed = true
+	for _, sub := range s.subscriptions {
+		sub.client.Close()
+	}
+	s.subscriptionsMu.Unlock()
+	s.wg.Wait()
+
+	return scanner.Err()
+}
+
+// handle runs one request
+func (s *ipcSession) handle(msg ipcMessage) {
+	switch daemon.RequestType(msg.Type) {
+	case "unsubscribe":
+		s.unsubscribe(msg)
+	case daemon.RequestTypeSubscribe:
+		s.subscribe(msg)
+	default:
+		req := &daemon.Request{Version: daemon.ProtocolVersion, Type: daemon.RequestType(msg.Type), Payload: msg.Payload}
+		resp, err := s.client.SendRequest(req)
+		if err != nil {
+			resp = daemon.NewErrorResponse(err)
+		}
+		s.respond(msg.ID, resp)
+	}
+}
+
+// ipcKey returns the id of a request in a canonical form, so that 2 and
+// 2.0 name the same subscription
+func ipcKey(id any) string {
+	if raw, ok := id.(json.RawMessage); ok {
+		if err := json.Unmarshal(raw, &id); err != nil {
+			return ""
+		}
+	}
+	if id == nil {
+		return ""
+	}
+	key, _ := json.Marshal(id)
+	return string(key)
+}
+
+// subscribe streams events on a connection of its own until unsubscribed,
+// then writes an end message
+func (s *ipcSession) subscribe(msg ipcMessage) {
+	key := ipcKey(msg.ID)
+
+	client, err := daemon.NewClient()
+	if err == nil {
+		err = client.ConnectSkipVersionCheck()
+	}
+	if err != nil {
+		s.respond(msg.ID, daemon.NewErrorResponse(err))
+		return
+	}
+	defer client.Close()
+	sub := &ipcSubscription{client: client, ended: make(chan struct{})}
+	defer close(sub.ended)
+
+	s.subscriptionsMu.Lock()
+	if s.closed {
+		s.subscriptionsMu.Unlock()
+		return
+	}
+	if _, taken := s.subscriptions[key]; taken || key == "" {
+		s.subscriptionsMu.Unlock()
+		s.respond(msg.ID, daemon.NewErrorResponse(fmt.Errorf("subscribe needs an id not used by another subscription")))
+		return
+	}
+	s.subscriptions[key] = sub
+	s.subscriptionsMu.Unlock()
+
+	defer func() {
+		s.subscriptionsMu.Lock()
+		delete(s.subscriptions, key)
+		s.subscriptionsMu.Unlock()
+	}()
+
+	req := &daemon.Request{Version: daemon.ProtocolVersion, Type: daemon.RequestTypeSubscribe, Payload: msg.Payload}
+	subscribed := false
+	client.Stream(req, func(resp *daemon.Response) error {
+		subscribed = resp.Success
+		s.respond(msg.ID, resp)
+		return nil
+	}, func(event daemon.Event) error {
+		return s.write(ipcMessage{ID: msg.ID, Type: "event", Event: &event})
+	})
+	if subscribed {
+		s.write(ipcMessage{ID: msg.ID, Type: "end"})
+	}
+}
+
+// unsubscribe ends the event stream of the subscribe request with the id
+// in the payload
+func (s *ipcSession) unsubscribe(msg ipcMessage) {
+	key := ipcKey(msg.Payload["id"])
+
+	s.subscriptionsMu.Lock()
+	sub, ok := s.subscriptions[key]
+	s.subscriptionsMu.Unlock()
+	if !ok {
+		s.respond(msg.ID, daemon.NewErrorResponse(fmt.Errorf("no subscription with id %s", key)))
+		return
+	}
+
+	sub.client.Close()
+	<-sub.ended
+	s.respond(msg.ID, daemon.NewSuccessResponse())
+}
+
+func init() {
+	RootCmd.AddCommand(ipcCmd)
+	ipcCmd.Flags().BoolVar(&ipcStdio, "stdio", false,
+		"Read requests from stdin and write responses and events to stdout")
+}
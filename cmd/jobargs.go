@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/output"
+)
+
+// jobArgs holds the options and command parsed from add/run arguments
+type jobArgs struct {
+	description string
+	priority    string
+	memoryLimit string
+	cpuLimit    string
+	hooks       daemon.JobHooks
+	stdin       string
+	stdinFrom   string
+	logFormat   string
+	timestamps  bool
+	combine     bool
+	noTimestamp bool
+	noCombine   bool
+	keepHead    string
+	keepTail    string
+	logDir      string
+	container   string
+	devEnv      string
+	sandbox     string
+	runDir      string
+	reloadSig   string
+	env         []string
+	shell       bool
+	tags        []string
+	label       string
+	command     []string
+
+	// followRestarts is only accepted by run: it keeps waiting when the
+	// job is restarted
+	followRestarts bool
+
+	// silent is only accepted by run: it prints nothing unless the job
+	// fails
+	silent bool
+}
+
+// jobArgFlag describes a flag accepted before the command. Flags with
+// values set can be repeated and collect every value; flags with enabled
+// set are switches that take no value.
+type jobArgFlag struct {
+	long    string
+	short   string
+	value   *string
+	values  *[]string
+	enabled *bool
+}
+
+// set stores a value for the flag
+func (f jobArgFlag) set(v string) {
+	if f.values != nil {
+		*f.values = append(*f.values, v)
+		return
+	}
+	*f.value = v
+}
+
+// parseJobArgs parses the leading flags of add/run manually. Those commands
+// disable cobra flag parsing so that the job command's own flags are passed
+// through untouched.
+func parseJobArgs(args []string) (*jobArgs, error) {
+	parsed := &jobArgs{}
+	var color string
+	flags := []jobArgFlag{
+		{long: "--description", short: "-d", value: &parsed.description},
+		{long: "--priority", short: "-p", value: &parsed.priority},
+		{long: "--memory-limit", value: &parsed.memoryLimit},
+		{long: "--cpu-limit", value: &parsed.cpuLimit},
+		{long: "--on-start", value: &parsed.hooks.OnStart},
+		{long: "--on-success", value: &parsed.hooks.OnSuccess},
+		{long: "--on-failure", value: &parsed.hooks.OnFailure},
+		{long: "--on-slow", value: &parsed.hooks.OnSlow},
+		{long: "--stdin", value: &parsed.stdin},
+		{long: "--stdin-from", value: &parsed.stdinFrom},
+		{long: "--log-format", value: &parsed.logFormat},
+		{long: "--timestamps", enabled: &parsed.timestamps},
+		{long: "--combine-output", enabled: &parsed.combine},
+		{long: "--no-timestamps", enabled: &parsed.noTimestamp},
+		{long: "--no-combine-output", enabled: &parsed.noCombine},
+		{long: "--keep-head", value: &parsed.keepHead},
+		{long: "--keep-tail", value: &parsed.keepTail},
+		{long: "--log-dir", value: &parsed.logDir},
+		{long: "--in-container", value: &parsed.container},
+		{long: "--dev-env", value: &parsed.devEnv},
+		{long: "--sandbox", value: &parsed.sandbox},
+		{long: "--workdir", value: &parsed.runDir},
+		{long: "--reload-signal", value: &parsed.reloadSig},
+		{long: "--env", values: &parsed.env},
+		{long: "--shell", enabled: &parsed.shell},
+		{long: "--follow-restarts", enabled: &parsed.followRestarts},
+		{long: "--silent", enabled: &parsed.silent},
+		{long: "--tag", short: "-t", values: &parsed.tags},
+		{long: "--label", short: "-l", value: &parsed.label},
+		{long: "--color", value: &color},
+	}
+
+	var commandArgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			// Everything after -- is the command
+			commandArgs = args[i+1:]
+			break
+		}
+
+		matched := false
+		for _, flag := range flags {
+			if flag.enabled != nil {
+				if arg == flag.long {
+					*flag.enabled = true
+					matched = true
+					break
+				}
+				continue
+			}
+			if arg == flag.long || (flag.short != "" && arg == flag.short) {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%s requires a value", flag.long)
+				}
+				flag.set(args[i+1])
+				i++ // skip the value
+				matched = true
+				break
+			}
+			if strings.HasPrefix(arg, flag.long+"=") {
+				flag.set(strings.TrimPrefix(arg, flag.long+"="))
+				matched = true
+				break
+			}
+			if flag.short != "" && strings.HasPrefix(arg, flag.short+"=") {
+				flag.set(strings.TrimPrefix(arg, flag.short+"="))
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		// Not a flag we recognize, treat rest as command
+		commandArgs = args[i:]
+		break
+	}
+
+	if len(commandArgs) == 0 {
+		return nil, fmt.Errorf("requires at least 1 arg(s)")
+	}
+
+	// --color is global, but cobra does not parse the flags of add and run
+	if color != "" {
+		if err := output.SetMode(color); err != nil {
+			return nil, err
+		}
+	}
+
+	if parsed.shell {
+		// A shell command is one script, kept exactly as given
+		commandArgs = []string{strings.Join(commandArgs, " ")}
+	} else if len(commandArgs) == 1 && strings.Contains(commandArgs[0], " ") {
+		// Handle quoted command string: "echo hello world" -> ["echo", "hello", "world"]
+		commandArgs = strings.Fields(commandArgs[0])
+	}
+
+	parsed.command = commandArgs
+	return parsed, nil
+}
+
+// options converts the parsed flags into job options. Unset flags are left
+// empty so that re-adding an existing job keeps its stored settings.
+func (a *jobArgs) options() (daemon.JobOptions, error) {
+	opts := daemon.JobOptions{Description: a.description, Hooks: a.hooks, Container: a.container}
+
+	timestamps, err := switchOption("timestamps", a.timestamps, a.noTimestamp)
+	if err != nil {
+		return opts, err
+	}
+	opts.Timestamps = timestamps
+
+	combine, err := switchOption("combine-output", a.combine, a.noCombine)
+	if err != nil {
+		return opts, err
+	}
+	opts.CombineOutput = combine
+
+	if a.shell {
+		opts.Shell = commandShell()
+	}
+
+	if a.priority != "" {
+		priority, err := daemon.ParsePriority(a.priority)
+		if err != nil {
+			return opts, err
+		}
+		opts.Priority = priority
+	}
+
+	if a.memoryLimit != "" {
+		memoryLimit, err := daemon.ParseMemoryLimit(a.memoryLimit)
+		if err != nil {
+			return opts, err
+		}
+		opts.MemoryLimit = memoryLimit
+	}
+
+	if a.cpuLimit != "" {
+		cpuLimit, err := daemon.ParseCPULimit(a.cpuLimit)
+		if err != nil {
+			return opts, err
+		}
+		opts.CPULimit = cpuLimit
+	}
+
+	if a.keepHead != "" {
+		size, err := daemon.ParseOutputSize(a.keepHead)
+		if err != nil {
+			return opts, err
+		}
+		opts.OutputHead = size
+	}
+
+	if a.keepTail != "" {
+		size, err := daemon.ParseOutputSize(a.keepTail)
+		if err != nil {
+			return opts, err
+		}
+		opts.OutputTail = size
+	}
+
+	if len(a.tags) > 0 {
+		tags, err := daemon.NormalizeTags(a.tags)
+		if err != nil {
+			return opts, err
+		}
+		opts.Tags = tags
+	}
+
+	if a.label != "" {
+		if err := daemon.ValidateRunLabel(a.label); err != nil {
+			return opts, err
+		}
+		opts.RunLabel = a.label
+	}
+
+	switch {
+	case a.stdin != "" && a.stdinFrom != "":
+		return opts, fmt.Errorf("--stdin and --stdin-from cannot be used together")
+	case a.stdinFrom != "":
+		// The daemon opens the file, so resolve it against our directory
+		path, err := filepath.Abs(a.stdinFrom)
+		if err != nil {
+			return opts, fmt.Errorf("failed to resolve stdin file: %w", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return opts, fmt.Errorf("stdin file: %w", err)
+		}
+		opts.Stdin = path
+	case a.stdin != "":
+		if a.stdin != daemon.StdinNull && a.stdin != daemon.StdinOpen {
+			return opts, fmt.Errorf("invalid stdin %q (expected null or open)", a.stdin)
+		}
+		opts.Stdin = a.stdin
+	}
+
+	if a.logFormat != "" {
+		if err := daemon.ValidateLogFormat(a.logFormat); err != nil {
+			return opts, err
+		}
+		opts.LogFormat = a.logFormat
+	}
+
+	if len(a.env) > 0 {
+		if err := daemon.ValidateEnvOverrides(a.env); err != nil {
+			return opts, err
+		}
+		opts.EnvOverrides = a.env
+	}
+
+	if a.devEnv != "" {
+		if err := daemon.ValidateDevEnv(a.devEnv); err != nil {
+			return opts, err
+		}
+		opts.DevEnv = a.devEnv
+	}
+
+	// Agents set GOB_SANDBOX to sandbox every job they start
+	sandbox := a.sandbox
+	if sandbox == "" {
+		sandbox = os.Getenv("GOB_SANDBOX")
+	}
+	if sandbox != "" {
+		if err := daemon.ValidateSandbox(sandbox); err != nil {
+			return opts, err
+		}
+		opts.Sandbox = sandbox
+	}
+
+	if a.reloadSig != "" {
+		sig, err := parseSignal(a.reloadSig)
+		if err != nil {
+			return opts, err
+		}
+		if sig <= 0 {
+			return opts, fmt.Errorf("invalid reload signal: %s", a.reloadSig)
+		}
+		opts.ReloadSignal = int(sig)
+	}
+
+	if a.logDir != "" {
+		// The daemon writes the logs, so resolve the directory against ours
+		path, err := filepath.Abs(a.logDir)
+		if err != nil {
+			return opts, fmt.Errorf("failed to resolve log directory: %w", err)
+		}
+		opts.LogDir = path
+	}
+
+	if a.runDir != "" {
+		// Runs execute there, but the job stays in the current directory
+		path, err := filepath.Abs(a.runDir)
+		if err != nil {
+			return opts, fmt.Errorf("failed to resolve workdir: %w", err)
+		}
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return opts, fmt.Errorf("workdir is not a directory: %s", a.runDir)
+		}
+		opts.RunDir = path
+	}
+
+	return opts, nil
+}
+
+// switchOption returns the setting given by a --<name> and --no-<name> pair
+// of switches: nil when neither is set, to keep the job's current one
+func switchOption(name string, on, off bool) (*bool, error) {
+	if on && off {
+		return nil, fmt.Errorf("--%s and --no-%s cannot be used together", name, name)
+	}
+	if !on && !off {
+		return nil, nil
+	}
+	return &on, nil
+}
+
+// commandShell returns the shell running --shell commands: $GOB_SHELL, or sh
+func commandShell() string {
+	if shell := os.Getenv("GOB_SHELL"); shell != "" {
+		return shell
+	}
+	return "sh"
+}
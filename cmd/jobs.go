@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/tui"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cobra"
+)
+
+var jobsImportStart bool
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Share job definitions",
+	Long: `Export and import job definitions, to share a project's background jobs
+with teammates working in their own clone of it.
+
+Definitions are written in the gobfile format (see .config/gobfile.toml):
+the command, the directory relative to the project, the description, tags,
+and settings such as priority, resource limits, hooks, stdin and logging.`,
+}
+
+var jobsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the jobs of this project as a gobfile",
+	Long: `Write the definitions of the jobs in the current directory and the
+directories below it, in the gobfile format.
+
+Directories, stdin files and log directories inside the project are written
+relative to it, so the file works in another clone. Aliases, runs and
+statistics are not exported. Commands whose arguments contain spaces are
+written as a script for sh, so they split the same way when imported.
+
+Examples:
+  # Share the jobs of this project
+  gob jobs export > jobs.toml
+
+  # Make them the project's gobfile
+  gob jobs export > .config/gobfile.toml
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		jobs, err := client.List("")
+		if err != nil {
+			return err
+		}
+
+		var config tui.GobfileConfig
+		for _, job := range jobs {
+			if _, ok := projectPath(cwd, job.Workdir); ok {
+				config.Jobs = append(config.Jobs, gobfileJob(cwd, job))
+			}
+		}
+		slices.SortStableFunc(config.Jobs, func(a, b tui.GobfileJob) int {
+			return strings.Compare(a.Workdir+"\x00"+a.Command, b.Workdir+"\x00"+b.Command)
+		})
+
+		data, err := toml.Marshal(config)
+		if err != nil {
+			return fmt.Errorf("failed to write jobs: %w", err)
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, "# Jobs exported with gob jobs export; load them with gob jobs import")
+		_, err = out.Write(data)
+		return err
+	},
+}
+
+var jobsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create the jobs of a gobfile",
+	Long: `Create the jobs defined in a file written by 'gob jobs export' (or any
+gobfile), relative to the current directory. Use '-' to read from stdin.
+
+Jobs that already exist get the file's settings. Jobs are created stopped;
+with --start, those marked autostart = true are started too.
+
+Examples:
+  # Recreate a teammate's jobs in this clone
+  gob jobs import jobs.toml
+
+  # And start the autostart ones
+  gob jobs import --start jobs.toml
+
+Output:
+  Imported abc: npm run dev
+  Imported def: make test (web)
+
+Exit codes:
+  0: All jobs imported
+  1: Error (invalid file, invalid settings, daemon error)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var data []byte
+		var err error
+		if args[0] == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read jobs: %w", err)
+		}
+
+		var config tui.GobfileConfig
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("invalid jobs file: %w", err)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// Check every job before creating any
+		opts := make([]daemon.JobOptions, len(config.Jobs))
+		for i, job := range config.Jobs {
+			if len(job.CommandArgs()) == 0 {
+				return fmt.Errorf("job %d has no command", i+1)
+			}
+			if opts[i], err = job.Options(cwd, config.LogDir); err != nil {
+				return fmt.Errorf("invalid settings for '%s': %w", job.Command, err)
+			}
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		for i, job := range config.Jobs {
+			workdir := job.JobWorkdir(cwd)
+
+			var imported *daemon.JobResponse
+			if jobsImportStart && job.ShouldAutostart() && !job.IsBlocked() {
+				added, err := client.Add(job.CommandArgs(), workdir, os.Environ(), opts[i])
+				if err != nil {
+					return fmt.Errorf("failed to import '%s': %w", job.Command, err)
+				}
+				imported = &added.Job
+			} else {
+				imported, err = client.Create(job.CommandArgs(), workdir, opts[i])
+				if err != nil {
+					return fmt.Errorf("failed to import '%s': %w", job.Command, err)
+				}
+			}
+
+			line := fmt.Sprintf("Imported %s: %s", imported.ID, job.Command)
+			if job.Workdir != "" {
+				line += " (" + job.Workdir + ")"
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), line)
+		}
+
+		return nil
+	},
+}
+
+// envMap converts KEY=VALUE variables to the gobfile's env table, nil if
+// there are none
+func envMap(env []string) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		m[name] = value
+	}
+	return m
+}
+
+// gobfileJob converts a job to its gobfile definition, with paths inside the
+// project root written relative to it
+func gobfileJob(root string, job daemon.JobResponse) tui.GobfileJob {
+	g := tui.GobfileJob{
+		Command:       strings.Join(job.Command, " "),
+		Description:   job.Description,
+		Shell:         job.Shell,
+		CPULimit:      job.CPULimit,
+		Timestamps:    job.Timestamps,
+		CombineOutput: job.CombineOutput,
+		Container:     job.Container,
+		DevEnv:        job.DevEnv,
+		Sandbox:       job.Sandbox,
+		Env:           envMap(job.EnvOverrides),
+		Tags:          job.Tags,
+	}
+
+	// Arguments with spaces would be split apart on import
+	if job.Shell == "" && slices.ContainsFunc(job.Command, func(arg string) bool { return arg == "" || strings.ContainsAny(arg, " \t\n") }) {
+		quoted := make([]string, len(job.Command))
+		for i, arg := range job.Command {
+			quoted[i] = shellQuote(arg)
+		}
+		g.Command = strings.Join(quoted, " ")
+		g.Shell = "sh"
+	}
+
+	if rel, _ := projectPath(root, job.Workdir); rel != "." {
+		g.Workdir = rel
+	}
+	if job.Blocked {
+		blocked := true
+		g.Blocked = &blocked
+	}
+	if job.Priority != "" && job.Priority != daemon.PriorityNormal {
+		g.Priority = string(job.Priority)
+	}
+	if job.LogFormat != "" && job.LogFormat != daemon.LogFormatText {
+		g.LogFormat = job.LogFormat
+	}
+	if job.MemoryLimit > 0 {
+		g.MemoryLimit = formatSize(job.MemoryLimit)
+	}
+	if job.OutputHead > 0 {
+		g.KeepHead = formatSize(job.OutputHead)
+	}
+	if job.OutputTail > 0 {
+		g.KeepTail = formatSize(job.OutputTail)
+	}
+	if job.Hooks != nil {
+		g.OnStart = job.Hooks.OnStart
+		g.OnSuccess = job.Hooks.OnSuccess
+		g.OnFailure = job.Hooks.OnFailure
+		g.OnSlow = job.Hooks.OnSlow
+	}
+	switch job.Stdin {
+	case "", daemon.StdinNull:
+	case daemon.StdinOpen:
+		g.Stdin = job.Stdin
+	default:
+		g.Stdin, _ = projectPath(root, job.Stdin)
+	}
+	if job.LogDir != "" {
+		g.LogDir, _ = projectPath(root, job.LogDir)
+	}
+	return g
+}
+
+// projectPath returns path relative to the project root if it is inside it,
+// or path unchanged and false
+func projectPath(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return rel, true
+}
+
+// formatSize formats a byte count the way memory and output limits are
+// given, e.g. "512M", in the largest unit that divides it
+func formatSize(n int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}} {
+		if n%unit.size == 0 {
+			return fmt.Sprintf("%d%s", n/unit.size, unit.suffix)
+		}
+	}
+	return fmt.Sprint(n)
+}
+
+// shellQuote quotes an argument for sh
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func init() {
+	RootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsExportCmd)
+	jobsCmd.AddCommand(jobsImportCmd)
+	jobsImportCmd.Flags().BoolVar(&jobsImportStart, "start", false,
+		"Start the jobs marked autostart = true")
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	killOrphansKill  bool
+	killOrphansAdopt bool
+	killOrphansForce bool
+	killOrphansJSON  bool
+)
+
+var killOrphansCmd = &cobra.Command{
+	Use:   "kill-orphans",
+	Short: "Find processes that outlived their run, and kill or adopt them",
+	Long: `Find processes that escaped their run and offer to kill or adopt them.
+
+Every run sets GOB_RUN_ID and GOB_DAEMON_ID in its environment, and its
+children inherit them. A process carrying the ID of one of the daemon's runs
+that is not running anymore is an orphan: usually a child that was
+re-parented while the daemon was down or crashed. Processes of running runs,
+including adopted ones, are never orphans, and neither are processes of the
+runs of other daemons (e.g. of other projects' isolated daemons).
+
+In a terminal, gob asks what to do with each orphaned run: kill its
+processes (SIGTERM, escalating to SIGKILL, as gob stop), adopt its main
+process as a run of its job (see gob adopt), or leave it. Otherwise it only
+lists them, unless --kill or --adopt says what to do with all of them.
+
+Orphans of removed jobs are adopted in the directory the process runs in.
+
+Output:
+  One block per orphaned run, its job and its processes:
+    Run V3x0QqI-3 (job V3x0QqI: npm run dev) in /home/user/project
+      12345  node server.js
+
+Examples:
+  # Decide for each orphaned run
+  gob kill-orphans
+
+  # Kill every orphaned process right away
+  gob kill-orphans --kill --force
+
+Exit codes:
+  0: Success (also when there are no orphans)
+  1: Error (connection failed, processes survived SIGKILL)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if killOrphansKill && killOrphansAdopt {
+			return fmt.Errorf("--kill cannot be used with --adopt")
+		}
+		if killOrphansForce && killOrphansAdopt {
+			return fmt.Errorf("--force cannot be used with --adopt")
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		orphans, err := client.Orphans()
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		if killOrphansJSON {
+			if orphans == nil {
+				orphans = []daemon.Orphan{}
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(orphans)
+		}
+
+		if len(orphans) == 0 {
+			fmt.Fprintln(out, "No orphaned processes found")
+			return nil
+		}
+
+		interactive := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+		in := bufio.NewReader(os.Stdin)
+		for _, o := range orphans {
+			printOrphan(out, o)
+
+			action := ""
+			switch {
+			case killOrphansKill:
+				action = "k"
+			case killOrphansAdopt:
+				action = "a"
+			case interactive:
+				fmt.Fprint(os.Stderr, "[k]ill, [a]dopt or [s]kip? ")
+				answer, _ := in.ReadString('\n')
+				action = strings.ToLower(strings.TrimSpace(answer))
+			}
+
+			switch action {
+			case "k", "kill":
+				if _, err := client.KillOrphans([]string{o.RunID}, killOrphansForce); err != nil {
+					return fmt.Errorf("failed to kill run %s: %w", o.RunID, err)
+				}
+				pids := make([]string, len(o.Processes))
+				for i, p := range o.Processes {
+					pids[i] = strconv.Itoa(p.PID)
+				}
+				fmt.Fprintf(out, "Killed run %s (PID %s)\n", o.RunID, strings.Join(pids, ", "))
+			case "a", "adopt":
+				if err := adoptOrphan(out, client, o); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !interactive && !killOrphansKill && !killOrphansAdopt {
+			fmt.Fprintln(out, "\nUse --kill or --adopt to act on them")
+		}
+		return nil
+	},
+}
+
+// adoptOrphan adopts the main process of an orphaned run as a run of its
+// job, or of a new job where the process runs if the job was removed
+func adoptOrphan(out io.Writer, client *daemon.Client, o daemon.Orphan) error {
+	workdir := o.Workdir
+	if workdir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		workdir = cwd
+	}
+
+	pid := o.MainPID()
+	job, err := client.Adopt(pid, o.Command, workdir)
+	if err != nil {
+		return fmt.Errorf("failed to adopt process %d: %w", pid, err)
+	}
+	fmt.Fprintf(out, "Adopted PID %d as job %s: %s\n", pid, job.ID, strings.Join(job.Command, " "))
+	return nil
+}
+
+// printOrphan prints an orphaned run and its processes
+func printOrphan(out io.Writer, o daemon.Orphan) {
+	line := "Run " + o.RunID
+	if o.JobID != "" {
+		line += fmt.Sprintf(" (job %s: %s)", o.JobID, strings.Join(o.Command, " "))
+	} else {
+		line += " (job removed)"
+	}
+	if o.Workdir != "" {
+		line += " in " + o.Workdir
+	}
+	fmt.Fprintln(out, line)
+	for _, p := range o.Processes {
+		fmt.Fprintf(out, "  %d  %s\n", p.PID, p.Command)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(killOrphansCmd)
+	killOrphansCmd.Flags().BoolVar(&killOrphansKill, "kill", false,
+		"Kill every orphaned process without asking")
+	killOrphansCmd.Flags().BoolVar(&killOrphansAdopt, "adopt", false,
+		"Adopt every orphaned run without asking")
+	killOrphansCmd.Flags().BoolVarP(&killOrphansForce, "force", "f", false,
+		"Kill with SIGKILL right away")
+	killOrphansCmd.Flags().BoolVar(&killOrphansJSON, "json", false,
+		"List the orphans in JSON format")
+}
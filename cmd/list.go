@@ -4,19 +4,50 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/output"
 	"github.com/spf13/cobra"
 )
 
 var (
-	listAll     bool
-	showWorkdir bool
-	listJSON    bool
+	listAll       bool
+	showWorkdir   bool
+	listJSON      bool
+	listCompact   bool
+	listPorcelain bool
+	listTag       string
+	listStartedBy string
+	listSort      string
+	listReverse   bool
+	listColumns   string
+	listPorts     bool
 )
 
+// compactLines and compactLineLength bound the output tails of --compact
+const (
+	compactLines      = 5
+	compactLineLength = 200
+)
+
+// compactJob is a job in --compact output: what an agent needs to decide
+// what to do next, in few tokens
+type compactJob struct {
+	ID          string   `json:"id"`
+	Status      string   `json:"status"`
+	ExitCode    *int     `json:"exit_code,omitempty"`
+	Command     string   `json:"command"`
+	Description string   `json:"description,omitempty"`
+	Ports       string   `json:"ports,omitempty"`
+	StdoutTail  []string `json:"stdout_tail,omitempty"`
+	StderrTail  []string `json:"stderr_tail,omitempty"`
+}
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List background jobs",
@@ -24,9 +55,14 @@ var listCmd = &cobra.Command{
 
 By default, only shows jobs started in the current directory.
 Use --all to see jobs from all directories.
+Use --tag to only show jobs with a given tag.
+Use --started-by to only show jobs whose current or latest run was started
+by a given client: a name or glob pattern matching the client's name (see
+'gob audit') or type (cli, tui or api).
 
 Shows job ID, PID, status (running/stopped), and the original command.
-If a job has a description, it is shown on a second indented line.
+If a job has a description, it is shown on a second indented line
+(see 'gob describe' to change it).
 Use --workdir to also display the working directory for each job.
 Jobs are sorted by start time (newest first).
 
@@ -37,6 +73,13 @@ the status shows an estimated progress percentage based on average duration:
 Output format:
   <job_id>: [<pid>] <status>: <command>
            <description>   (if present)
+           tags: <tags>    (if present)
+           started by <client>  (if not this client)
+
+Jobs with an alias (see 'gob alias') show it after the ID, and pinned jobs
+(see 'gob pin') are marked the same way:
+  <job_id> (<alias>): [<pid>] <status>: <command>
+  <job_id> (<alias>, pinned): [<pid>] <status>: <command>
 
 With --workdir:
   <job_id>: [<pid>] <status> (<workdir>): <command>
@@ -57,13 +100,54 @@ Example output:
 Example with --workdir:
   V3x0QqI: [12345] running (/home/user/project): sleep 3600
 
-If no jobs exist:
-  No jobs found
+With --compact, jobs are printed as JSON on one line with only their ID,
+status, exit code, command, description and the last lines of their output, for agents
+that pay for every token:
+  [{"id":"V3x0QqI","status":"stopped","exit_code":1,"command":"make test","stderr_tail":["FAIL"]}]
+
+With --porcelain, each job is one line of tab-separated fields, with no
+headers or colors, for shell scripts. The fields never change between
+versions (new ones may be appended); missing values are "-":
+  <job_id>\t<status>\t<exit_code>\t<pid>\t<workdir>\t<command>
+
+Use --sort to order jobs by started (the default, newest first), duration
+(longest current or last run first), status (running, then failed, then
+stopped) or command, and --reverse to flip the order. The TUI cycles
+through the same orders with 'o'.
+
+With --columns, jobs are printed as a table with the given columns, in
+that order. Available columns: id, alias, pid, status, exit, duration,
+started, ports, workdir, command, description, tags, started_by
+  gob list --columns id,status,ports,duration
+  ID       STATUS   PORTS   DURATION
+  V3x0QqI  running  :3000   12m4s
+
+
+With --ports, the listening ports of running jobs are scanned when listing
+and shown after the command (and as "ports" in --compact output).
+The scan walks each job's process tree, so it is off by default:
+  V3x0QqI: [12345] running: npm run dev (:3000,:5432)
+
+  No jobs found (nothing with --porcelain)
 
 Exit codes:
   0: Success
   1: Error reading jobs`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if listPorcelain && (listJSON || listCompact) {
+			return fmt.Errorf("--porcelain cannot be used with --json or --compact")
+		}
+		order, err := daemon.ParseJobOrder(listSort)
+		if err != nil {
+			return err
+		}
+		var columns []listColumn
+		if listColumns != "" {
+			if columns, err = parseListColumns(listColumns); err != nil {
+				return err
+			}
+		}
+
 		// Connect to daemon
 		client, err := daemon.NewClient()
 		if err != nil {
@@ -88,10 +172,32 @@ Exit codes:
 		}
 
 		// Get jobs from daemon
-		jobs, err := client.List(workdirFilter)
+		var jobs []daemon.JobResponse
+		if listPorts {
+			jobs, err = client.ListWithPorts(workdirFilter, listTag)
+		} else {
+			jobs, err = client.ListByTag(workdirFilter, listTag)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to list jobs: %w", err)
 		}
+		if listStartedBy != "" {
+			jobs = slices.DeleteFunc(jobs, func(job daemon.JobResponse) bool {
+				return !startedBy(job, listStartedBy)
+			})
+		}
+		daemon.SortJobs(jobs, order, listReverse, daemon.JobResponse.SortKey)
+
+		if listCompact {
+			return printCompactJobs(cmd, jobs)
+		}
+
+		if listPorcelain {
+			for _, job := range jobs {
+				printPorcelainJob(cmd.OutOrStdout(), job)
+			}
+			return nil
+		}
 
 		// If no jobs, print message (unless JSON output)
 		if len(jobs) == 0 {
@@ -110,43 +216,49 @@ Exit codes:
 			return enc.Encode(jobs)
 		}
 
+		if columns != nil {
+			return printJobColumns(cmd.OutOrStdout(), jobs, columns)
+		}
+
 		// Print each job in human-readable format
+		self := daemon.ClientName()
 		for _, job := range jobs {
 			commandStr := strings.Join(job.Command, " ")
-
-			// Format status with exit code or progress if available
-			status := job.Status
-			if job.Status == "running" && job.AvgDurationMs > 0 && job.StartedAt != "" {
-				startedAt, err := time.Parse(time.RFC3339, job.StartedAt)
-				if err == nil {
-					elapsed := time.Since(startedAt)
-					avgDuration := time.Duration(job.AvgDurationMs) * time.Millisecond
-					progress := float64(elapsed) / float64(avgDuration) * 100
-					if progress > 100 {
-						progress = 100
-					}
-					status = fmt.Sprintf("running (%.0f%%)", progress)
-				}
-			} else if job.ExitCode != nil {
-				status = fmt.Sprintf("%s (%d)", job.Status, *job.ExitCode)
+			if job.PortsSummary != "" {
+				commandStr += " " + output.Colorize(os.Stdout, output.Cyan, "("+job.PortsSummary+")")
 			}
 
+			status := output.Colorize(os.Stdout, statusColor(job), formatJobStatus(job))
+
 			// Format PID (show "-" for stopped jobs with no PID)
 			pidStr := fmt.Sprintf("%d", job.PID)
 			if job.PID == 0 {
 				pidStr = "-"
 			}
 
+			// Show the alias and the pin next to the ID
+			var marks []string
+			if job.Alias != "" {
+				marks = append(marks, job.Alias)
+			}
+			if job.Pinned {
+				marks = append(marks, "pinned")
+			}
+			id := job.ID
+			if len(marks) > 0 {
+				id = fmt.Sprintf("%s (%s)", job.ID, strings.Join(marks, ", "))
+			}
+
 			if showWorkdir {
 				workdir := job.Workdir
 				if workdir == "" {
 					workdir = "<unknown>"
 				}
 				fmt.Printf("%s: [%s] %s (%s): %s\n",
-					job.ID, pidStr, status, workdir, commandStr)
+					id, pidStr, status, workdir, commandStr)
 			} else {
 				fmt.Printf("%s: [%s] %s: %s\n",
-					job.ID, pidStr, status, commandStr)
+					id, pidStr, status, commandStr)
 			}
 
 			// Print description on second line if present
@@ -154,12 +266,96 @@ Exit codes:
 				// Indent to align with command (9 spaces for "XXX: [-] ")
 				fmt.Printf("         %s\n", job.Description)
 			}
+
+			// Print tags on their own line if present
+			if len(job.Tags) > 0 {
+				fmt.Printf("         tags: %s\n", strings.Join(job.Tags, ", "))
+			}
+
+			// Tell apart the jobs other clients, such as agents, started
+			if job.StartedBy != "" && job.StartedBy != self {
+				fmt.Printf("         started by %s\n", daemon.FormatOrigin(job.StartedBy, job.StartedVia))
+			}
 		}
 
 		return nil
 	},
 }
 
+// startedBy reports whether the current or latest run of a job was started
+// by a client whose name or type matches a glob pattern
+func startedBy(job daemon.JobResponse, pattern string) bool {
+	for _, s := range []string{job.StartedBy, job.StartedVia} {
+		if ok, _ := path.Match(pattern, s); ok && s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatJobStatus formats a job's status with its exit code, or with the
+// progress of a running job when its usual duration is known
+func formatJobStatus(job daemon.JobResponse) string {
+	if job.Status == "running" && job.AvgDurationMs > 0 && job.StartedAt != "" {
+		startedAt, err := time.Parse(time.RFC3339, job.StartedAt)
+		if err == nil {
+			elapsed := time.Since(startedAt)
+			avgDuration := time.Duration(job.AvgDurationMs) * time.Millisecond
+			progress := float64(elapsed) / float64(avgDuration) * 100
+			if progress > 100 {
+				progress = 100
+			}
+			return fmt.Sprintf("running (%.0f%%)", progress)
+		}
+	} else if job.ExitCode != nil {
+		return fmt.Sprintf("%s (%d)", job.Status, *job.ExitCode)
+	}
+	return job.Status
+}
+
+// statusColor returns the color of a job's status in the list: green while
+// running, red when its last run failed
+func statusColor(job daemon.JobResponse) output.Color {
+	if job.Status == "running" {
+		return output.Green
+	}
+	if job.ExitCode != nil && *job.ExitCode != 0 {
+		return output.Red
+	}
+	return ""
+}
+
+// printCompactJobs prints jobs as one line of JSON with their output tails
+func printCompactJobs(cmd *cobra.Command, jobs []daemon.JobResponse) error {
+	compact := make([]compactJob, 0, len(jobs))
+	for _, job := range jobs {
+		c := compactJob{ID: job.ID, Status: job.Status, ExitCode: job.ExitCode, Command: strings.Join(job.Command, " "), Description: job.Description, Ports: job.PortsSummary}
+		if job.StdoutPath != "" {
+			c.StdoutTail = truncateLines(lastLines(job.StdoutPath, compactLines))
+			stderrPath := strings.Replace(job.StdoutPath, ".stdout.log", ".stderr.log", 1)
+			c.StderrTail = truncateLines(lastLines(stderrPath, compactLines))
+		}
+		compact = append(compact, c)
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetEscapeHTML(false) // keep commands readable: && and > are common
+	return enc.Encode(compact)
+}
+
+// truncateLines shortens long lines to compactLineLength bytes
+func truncateLines(lines []string) []string {
+	for i, line := range lines {
+		if len(line) > compactLineLength {
+			end := compactLineLength
+			for end > 0 && !utf8.RuneStart(line[end]) {
+				end--
+			}
+			lines[i] = line[:end] + "…"
+		}
+	}
+	return lines
+}
+
 func init() {
 	RootCmd.AddCommand(listCmd)
 	listCmd.Flags().BoolVarP(&listAll, "all", "a", false,
@@ -168,4 +364,28 @@ func init() {
 		"Show working directory for each job")
 	listCmd.Flags().BoolVar(&listJSON, "json", false,
 		"Output in JSON format")
+	listCmd.Flags().BoolVar(&listCompact, "compact", false,
+		"Output minimal JSON with the tail of each job's output, for agents")
+	listCmd.Flags().BoolVar(&listPorcelain, "porcelain", false,
+		"Output stable tab-separated fields for scripts")
+	listCmd.Flags().StringVarP(&listTag, "tag", "t", "",
+		"Only show jobs with this tag")
+	listCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	listCmd.Flags().StringVar(&listStartedBy, "started-by", "",
+		"Only show jobs whose current or latest run was started by this client (name, type or glob pattern)")
+	listCmd.Flags().StringVar(&listSort, "sort", string(daemon.JobOrderStarted),
+		"Sort jobs by started, duration, status or command")
+	listCmd.Flags().BoolVar(&listReverse, "reverse", false,
+		"Reverse the sort order")
+	listCmd.Flags().StringVar(&listColumns, "columns", "",
+		"Print a table with these columns, e.g. id,status,ports,duration")
+	listCmd.Flags().BoolVar(&listPorts, "ports", false,
+		"Scan and show the listening ports of running jobs")
+	listCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		orders := make([]string, len(daemon.JobOrders))
+		for i, o := range daemon.JobOrders {
+			orders[i] = string(o)
+		}
+		return orders, cobra.ShellCompDirectiveNoFileComp
+	})
 }
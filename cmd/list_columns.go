@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+// listColumn is a column of gob list --columns
+type listColumn struct {
+	name   string
+	format func(job daemon.JobResponse, now time.Time) string
+}
+
+// jobColumns are the columns gob list --columns can show. Empty values are
+// printed as "-".
+var jobColumns = []listColumn{
+	{"id", func(job daemon.JobResponse, now time.Time) string { return job.ID }},
+	{"alias", func(job daemon.JobResponse, now time.Time) string { return job.Alias }},
+	{"pid", func(job daemon.JobResponse, now time.Time) string {
+		if job.PID == 0 {
+			return ""
+		}
+		return fmt.Sprint(job.PID)
+	}},
+	{"status", func(job daemon.JobResponse, now time.Time) string { return formatJobStatus(job) }},
+	{"exit", func(job daemon.JobResponse, now time.Time) string {
+		if job.ExitCode == nil {
+			return ""
+		}
+		return fmt.Sprint(*job.ExitCode)
+	}},
+	{"duration", func(job daemon.JobResponse, now time.Time) string {
+		key := job.SortKey()
+		if key.StartedAt.IsZero() {
+			return ""
+		}
+		return formatDuration(key.Duration(now))
+	}},
+	{"started", func(job daemon.JobResponse, now time.Time) string {
+		startedAt, err := time.Parse(time.RFC3339, job.StartedAt)
+		if err != nil {
+			return ""
+		}
+		return formatRelativeTime(startedAt)
+	}},
+	{"ports", func(job daemon.JobResponse, now time.Time) string { return daemon.FormatPorts(job.Ports) }},
+	{"workdir", func(job daemon.JobResponse, now time.Time) string { return job.Workdir }},
+	{"command", func(job daemon.JobResponse, now time.Time) string { return strings.Join(job.Command, " ") }},
+	{"description", func(job daemon.JobResponse, now time.Time) string { return job.Description }},
+	{"tags", func(job daemon.JobResponse, now time.Time) string { return strings.Join(job.Tags, ",") }},
+	{"started_by", func(job daemon.JobResponse, now time.Time) string {
+		return daemon.FormatOrigin(job.StartedBy, job.StartedVia)
+	}},
+}
+
+// parseListColumns parses a comma-separated list of column names
+func parseListColumns(s string) ([]listColumn, error) {
+	var columns []listColumn
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, c := range jobColumns {
+			if c.name == name {
+				columns = append(columns, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(jobColumns))
+			for i, c := range jobColumns {
+				names[i] = c.name
+			}
+			return nil, fmt.Errorf("unknown column %q: must be one of %s", name, strings.Join(names, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// printJobColumns prints jobs as a table with a header row
+func printJobColumns(w io.Writer, jobs []daemon.JobResponse, columns []listColumn) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = strings.ToUpper(c.name)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	now := time.Now()
+	for _, job := range jobs {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = c.format(job, now)
+			if values[i] == "" {
+				values[i] = "-"
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
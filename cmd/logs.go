@@ -5,21 +5,37 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
 	"github.com/juanibiapina/gob/internal/tail"
 	"github.com/spf13/cobra"
 )
 
-var followLogs bool
+var (
+	followLogs     bool
+	logsLevel      string
+	logsTimestamps bool
+	logsRestarts   bool
+	logsIDs        string
+	logsClean      bool
+	logsMarkers    bool
+)
+
+// jobPrefixColors color the stdout prefixes of jobs followed together with
+// --ids. Yellow marks stderr and cyan gob's own lines, so neither is used.
+var jobPrefixColors = []output.Color{output.Green, output.Blue, output.Magenta, output.Red}
 
 var logsCmd = &cobra.Command{
-	Use:   "logs [job_id]",
+	Use:   "logs [job_id | --ids id1,id2,...]",
 	Short: "Display stdout and stderr for jobs",
 	Long: `Display both stdout and stderr output for background jobs.
 
 Without arguments, shows output for all jobs in the current directory.
-With a job ID, shows output for that specific job.
+With a job ID, shows output for that specific job. With --ids, shows output
+for the listed jobs, from any directory.
 
 In dump mode (default), reads existing log content and exits.
 In follow mode (-f), streams output in real-time.
@@ -49,27 +65,112 @@ FOLLOW MODE (-f):
   # Follow a specific job
   gob logs -f V3x0QqI
 
+  # Follow a specific job across restarts
+  gob logs -f --follow-restarts V3x0QqI
+
+  # Follow frontend, backend and migrations together
+  gob logs -f --ids web,api,V3x0QqI
+
+  Following a single job stays on its current run unless --follow-restarts
+  is given: then the output of each new run of the job is followed as it
+  starts. Following all jobs always picks up new runs.
+
+  With --ids, the output of the listed jobs is interleaved as it is
+  written, each job's stdout prefix in its own color, and new runs of the
+  listed jobs are picked up as they start.
+
+LEVEL FILTER (--level):
+  For jobs added with --log-format json, shows only stdout lines whose level
+  is at least the given one (trace, debug, info, warn, error, fatal). In dump
+  mode the daemon does the filtering. Jobs without JSON logs are skipped.
+
+  # Only errors of a JSON-logging server
+  gob logs --level error V3x0QqI
+
+  # Follow warnings and errors of all JSON-logging jobs
+  gob logs -f --level warn
+
+TIMESTAMPS (--timestamps):
+  For jobs added with --timestamps, prefixes each line with the time it was
+  written (RFC3339, local time). The times come from an index the daemon
+  keeps next to the log files, which themselves hold the raw output. Only
+  available in dump mode.
+
+  gob logs --timestamps V3x0QqI
+
+CLEAN OUTPUT (--clean):
+  Removes cursor movement and screen clearing sequences, and keeps only the
+  last state of lines redrawn with carriage returns, so the output of jobs
+  with progress bars and spinners reads well outside a terminal. Colors are
+  kept. Works in dump and follow mode.
+
+  gob logs --clean V3x0QqI
+
+MARKERS (--markers):
+  Inserts a [gob] line where something happened to the run, e.g. when it
+  was sent a signal or its reload signal, or looked stuck to 'gob await'.
+  Only the stdout log of the job's latest run is marked. In follow mode,
+  these lines are always printed as the markers are recorded.
+
+  gob logs --markers V3x0QqI
+
 Exit codes:
   0: Success
   1: Error (job not found, log files not available)`,
 	ValidArgsFunction: completeJobIDs,
 	Args:              cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		var ids []string
+		if logsIDs != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--ids cannot be used with a job ID argument")
+			}
+			for _, id := range strings.Split(logsIDs, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) == 0 {
+				return fmt.Errorf("--ids requires at least one job ID")
+			}
+		}
+
+		if logsLevel != "" {
+			level, err := daemon.ParseLevelName(logsLevel)
+			if err != nil {
+				return err
+			}
+			logsLevel = level
+		}
+
 		if followLogs {
+			if logsTimestamps {
+				return fmt.Errorf("--timestamps cannot be used with --follow")
+			}
+			if ids != nil {
+				return logsFollowJobs(ids)
+			}
 			return logsFollow(args)
 		}
+		if logsRestarts {
+			return fmt.Errorf("--follow-restarts requires --follow")
+		}
+		if ids != nil {
+			return logsDumpJobs(ids)
+		}
 		return logsDump(args)
 	},
 }
 
 func logsDump(args []string) error {
 	if len(args) == 1 {
-		return logsDumpJob(args[0])
+		return logsDumpJobs(args)
 	}
 	return logsDumpAll()
 }
 
-func logsDumpJob(jobID string) error {
+// logsDumpJobs dumps the logs of the given jobs, one after the other
+func logsDumpJobs(jobIDs []string) error {
 	client, err := daemon.NewClient()
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
@@ -79,13 +180,32 @@ func logsDumpJob(jobID string) error {
 	if err := client.Connect(); err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
+	if err := client.StartSession(); err != nil {
+		return err
+	}
+
+	for _, jobID := range jobIDs {
+		if err := dumpJob(client, jobID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func dumpJob(client *daemon.Client, jobID string) error {
 	job, err := client.GetJob(jobID)
 	if err != nil {
 		return err
 	}
 
-	return dumpJobLogs(job)
+	if logsLevel != "" {
+		if job.LogFormat != daemon.LogFormatJSON {
+			return fmt.Errorf("job %s does not have JSON logs (add it with --log-format json)", job.ID)
+		}
+		return dumpJobLevel(client, job)
+	}
+
+	return dumpJobLogs(client, job)
 }
 
 func logsDumpAll() error {
@@ -103,6 +223,9 @@ func logsDumpAll() error {
 	if err := client.Connect(); err != nil {
 		return fmt.Errorf("failed to connect to daemon: %w", err)
 	}
+	if err := client.StartSession(); err != nil {
+		return err
+	}
 
 	jobs, err := client.List(cwd)
 	if err != nil {
@@ -110,7 +233,16 @@ func logsDumpAll() error {
 	}
 
 	for _, job := range jobs {
-		if err := dumpJobLogs(&job); err != nil {
+		if logsLevel != "" {
+			if job.LogFormat != daemon.LogFormatJSON {
+				continue
+			}
+			if err := dumpJobLevel(client, &job); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := dumpJobLogs(client, &job); err != nil {
 			return err
 		}
 	}
@@ -118,26 +250,158 @@ func logsDumpAll() error {
 	return nil
 }
 
-func dumpJobLogs(job *daemon.JobResponse) error {
-	if _, err := os.Stat(job.StdoutPath); err == nil {
-		content, err := os.ReadFile(job.StdoutPath)
+// dumpJobLevel writes the stdout lines of a JSON-logging job at or above
+// the --level filter, as selected by the daemon
+func dumpJobLevel(client *daemon.Client, job *daemon.JobResponse) error {
+	lines, skipped, err := client.LogLines(job.ID, logsLevel)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if logsClean {
+			line = daemon.CleanOutput(line)
+		}
+		fmt.Println(line)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d lines too long to read their level\n", skipped)
+	}
+	return nil
+}
+
+// cleanTransform returns a tail transform cleaning lines when --clean is set
+// (nil otherwise)
+func cleanTransform() func([]byte) []byte {
+	if !logsClean {
+		return nil
+	}
+	return daemon.CleanLine
+}
+
+// levelFilter returns a tail filter keeping lines at or above the --level
+// filter (nil when not filtering)
+func levelFilter() func([]byte) bool {
+	if logsLevel == "" {
+		return nil
+	}
+	return func(line []byte) bool {
+		return daemon.LevelAtLeast(daemon.ParseLogLevel(string(line)), logsLevel)
+	}
+}
+
+func dumpJobLogs(client *daemon.Client, job *daemon.JobResponse) error {
+	if _, err := logfile.Stat(job.StdoutPath); err == nil {
+		var markers []daemon.RunMarker
+		if logsMarkers {
+			if markers, err = runMarkers(client, job); err != nil {
+				return err
+			}
+		}
+		content, err := readLogContent(job.StdoutPath, markers)
 		if err != nil {
 			return fmt.Errorf("failed to read stdout log: %w", err)
 		}
-		os.Stdout.Write(content)
+		os.Stdout.Write(textOrPlaceholder(content, "stdout"))
 	}
 
-	if _, err := os.Stat(job.StderrPath); err == nil {
-		content, err := os.ReadFile(job.StderrPath)
+	if _, err := logfile.Stat(job.StderrPath); err == nil {
+		content, err := readLogContent(job.StderrPath, nil)
 		if err != nil {
 			return fmt.Errorf("failed to read stderr log: %w", err)
 		}
-		os.Stderr.Write(content)
+		os.Stderr.Write(textOrPlaceholder(content, "stderr"))
 	}
 
 	return nil
 }
 
+// textOrPlaceholder returns log content, or a line giving its size if it is
+// binary, pointing to the command ("stdout" or "stderr") that prints it
+func textOrPlaceholder(content []byte, stream string) []byte {
+	if !logfile.IsBinary(content) {
+		return content
+	}
+	return []byte(fmt.Sprintf("%s (use gob %s --raw to print it)\n", logfile.BinaryPlaceholder(len(content)), stream))
+}
+
+// runMarkers returns the markers of the run whose stdout log the job points
+// to
+func runMarkers(client *daemon.Client, job *daemon.JobResponse) ([]daemon.RunMarker, error) {
+	runs, err := client.Runs(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.StdoutPath == job.StdoutPath {
+			return run.Markers, nil
+		}
+	}
+	return nil, nil
+}
+
+// readLogContent reads a log file, prefixing its lines with their times
+// when --timestamps is set and the log has a timestamp index, cleaning
+// them when --clean is set, and inserting a line for each marker
+func readLogContent(path string, markers []daemon.RunMarker) ([]byte, error) {
+	raw, err := logfile.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := raw
+	if logsClean {
+		content = []byte(daemon.CleanOutput(string(content)))
+	}
+	if logsTimestamps {
+		stamps, err := daemon.ReadTimestampIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(daemon.PrefixTimestamps(string(content), stamps, func(t time.Time) string {
+			return t.Local().Format(daemon.TimestampFormat)
+		}))
+	}
+	if len(markers) == 0 {
+		return content, nil
+	}
+
+	prefix := output.Colorize(os.Stdout, output.Cyan, "["+tail.SystemLogTag+"]")
+	return []byte(daemon.InsertMarkers(string(raw), string(content), markers, func(m daemon.RunMarker) string {
+		return fmt.Sprintf("%s %s %s", prefix, m.At.Local().Format(daemon.TimestampFormat), m)
+	})), nil
+}
+
+// markerPrinter prints the markers recorded on runs from its creation on as
+// system lines of a follower. Started markers are left out, as followers
+// report new runs themselves.
+type markerPrinter struct {
+	follower *tail.Follower
+	since    time.Time
+	last     map[string]time.Time // time of the last marker printed, per run
+}
+
+func newMarkerPrinter(follower *tail.Follower) *markerPrinter {
+	return &markerPrinter{follower: follower, since: time.Now(), last: make(map[string]time.Time)}
+}
+
+// print prints the new markers of the run of a run_updated event
+func (p *markerPrinter) print(event daemon.Event) {
+	if event.Type != daemon.EventTypeRunUpdated || event.Run == nil {
+		return
+	}
+	last, ok := p.last[event.Run.ID]
+	if !ok {
+		last = p.since
+	}
+	for _, marker := range event.Run.Markers {
+		if marker.Kind == daemon.MarkerStarted || !marker.At.After(last) {
+			continue
+		}
+		p.follower.SystemLog("job %s %s", event.JobID, marker)
+		last = marker.At
+	}
+	p.last[event.Run.ID] = last
+}
+
 func logsFollow(args []string) error {
 	if len(args) == 1 {
 		return logsFollowJob(args[0])
@@ -161,20 +425,192 @@ func logsFollowJob(jobID string) error {
 		return err
 	}
 
-	if _, err := os.Stat(job.StdoutPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(job.StdoutPath); os.IsNotExist(err) {
 		return fmt.Errorf("stdout log file not found: %s", job.StdoutPath)
 	}
-	if _, err := os.Stat(job.StderrPath); os.IsNotExist(err) {
+	if _, err := logfile.Stat(job.StderrPath); os.IsNotExist(err) {
 		return fmt.Errorf("stderr log file not found: %s", job.StderrPath)
 	}
 
-	stderrPrefix := fmt.Sprintf("\033[33m[%s]\033[0m ", job.ID)
+	if logsLevel != "" && job.LogFormat != daemon.LogFormatJSON {
+		return fmt.Errorf("job %s does not have JSON logs (add it with --log-format json)", job.ID)
+	}
+
+	stderrPrefix := output.Colorize(os.Stdout, output.Yellow, "["+job.ID+"]") + " "
 	stdoutPrefix := fmt.Sprintf("[%s] ", job.ID)
 
-	return tail.FollowMultiple([]tail.FileSource{
-		{Path: job.StdoutPath, Prefix: stdoutPrefix},
-		{Path: job.StderrPath, Prefix: stderrPrefix},
-	}, os.Stdout)
+	follower := tail.NewFollower(os.Stdout)
+	addRunSources := func(stdoutPath, stderrPath string) {
+		if logsLevel != "" {
+			follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix, Filter: levelFilter(), Transform: cleanTransform()})
+			return
+		}
+		follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix, Transform: cleanTransform()})
+		follower.AddSource(tail.FileSource{Path: stderrPath, Prefix: stderrPrefix, Transform: cleanTransform()})
+	}
+	addRunSources(job.StdoutPath, job.StderrPath)
+
+	// Print the markers recorded on the job's runs as they come
+	eventClient, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create event client: %w", err)
+	}
+	defer eventClient.Close()
+
+	if err := eventClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect event client: %w", err)
+	}
+	eventCh, _ := eventClient.SubscribeChanWithOptions(job.Workdir, daemon.SubscribeOptions{
+		Match:     daemon.EventMatch{Types: []daemon.EventType{daemon.EventTypeRunUpdated}, JobIDs: []string{job.ID}},
+		Reconnect: true,
+	})
+	markers := newMarkerPrinter(follower)
+	go func() {
+		for event := range eventCh {
+			if event.JobID == job.ID {
+				markers.print(event)
+			}
+		}
+	}()
+
+	if logsRestarts {
+		restarts, stop, err := watchRestarts(job.ID, job.Workdir)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		go func() {
+			stdoutPath, stderrPath := job.StdoutPath, job.StderrPath
+			for run := range restarts {
+				follower.RemoveSource(stdoutPath)
+				follower.RemoveSource(stderrPath)
+				stdoutPath, stderrPath = run.StdoutPath, run.StderrPath
+				follower.SystemLog("job %s restarted (pid:%d run:%s)", job.ID, run.PID, run.ID)
+				addRunSources(stdoutPath, stderrPath)
+			}
+		}()
+	}
+
+	return follower.Wait()
+}
+
+// logsFollowJobs follows the given jobs together, across restarts, with a
+// colored prefix per job
+func logsFollowJobs(jobIDs []string) error {
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	if err := client.StartSession(); err != nil {
+		return err
+	}
+
+	type followedJob struct {
+		stdoutPath, stderrPath string
+		stdoutPrefix           string
+		stderrPrefix           string
+	}
+	followed := make(map[string]*followedJob)
+
+	var jobs []*daemon.JobResponse
+	for _, jobID := range jobIDs {
+		job, err := client.GetJob(jobID)
+		if err != nil {
+			return err
+		}
+		if _, seen := followed[job.ID]; seen {
+			continue
+		}
+		if logsLevel != "" && job.LogFormat != daemon.LogFormatJSON {
+			return fmt.Errorf("job %s does not have JSON logs (add it with --log-format json)", job.ID)
+		}
+		color := jobPrefixColors[len(jobs)%len(jobPrefixColors)]
+		followed[job.ID] = &followedJob{
+			stdoutPrefix: output.Colorize(os.Stdout, color, "["+job.ID+"]") + " ",
+			stderrPrefix: output.Colorize(os.Stdout, output.Yellow, "["+job.ID+"]") + " ",
+		}
+		jobs = append(jobs, job)
+	}
+
+	follower := tail.NewFollower(os.Stdout)
+	var mu sync.Mutex
+	followRun := func(jobID, stdoutPath, stderrPath string) {
+		f := followed[jobID]
+		if f.stdoutPath != "" {
+			follower.RemoveSource(f.stdoutPath)
+			follower.RemoveSource(f.stderrPath)
+		}
+		f.stdoutPath, f.stderrPath = stdoutPath, stderrPath
+		if logsLevel != "" {
+			follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: f.stdoutPrefix, Filter: levelFilter(), Transform: cleanTransform()})
+			return
+		}
+		follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: f.stdoutPrefix, Transform: cleanTransform()})
+		follower.AddSource(tail.FileSource{Path: stderrPath, Prefix: f.stderrPrefix, Transform: cleanTransform()})
+	}
+
+	// Subscribe before adding the sources so no run start is missed
+	eventClient, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create event client: %w", err)
+	}
+	defer eventClient.Close()
+
+	if err := eventClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect event client: %w", err)
+	}
+	eventCh, errCh := eventClient.SubscribeChanWithOptions("", daemon.SubscribeOptions{Reconnect: true})
+	markers := newMarkerPrinter(follower)
+
+	mu.Lock()
+	for _, job := range jobs {
+		if job.StdoutPath == "" {
+			follower.SystemLog("job %s has not run yet", job.ID)
+			continue
+		}
+		followRun(job.ID, job.StdoutPath, job.StderrPath)
+	}
+	mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				if event.Run == nil || followed[event.JobID] == nil {
+					continue
+				}
+				if event.Type == daemon.EventTypeRunUpdated {
+					markers.print(event)
+					continue
+				}
+				if event.Type != daemon.EventTypeRunStarted {
+					continue
+				}
+				mu.Lock()
+				if followed[event.JobID].stdoutPath != event.Run.StdoutPath {
+					follower.SystemLog("job %s started (pid:%d run:%s)", event.JobID, event.Run.PID, event.Run.ID)
+					followRun(event.JobID, event.Run.StdoutPath, event.Run.StderrPath)
+				}
+				mu.Unlock()
+			case err, ok := <-errCh:
+				if ok && err != nil {
+					follower.SystemLog("event subscription error: %v", err)
+				}
+				return
+			}
+		}
+	}()
+
+	return follower.Wait()
 }
 
 func logsFollowAll() error {
@@ -205,18 +641,22 @@ func logsFollowAll() error {
 		stdoutPath := job.StdoutPath
 		stderrPath := job.StderrPath
 
-		if _, err := os.Stat(stdoutPath); os.IsNotExist(err) {
+		if _, err := logfile.Stat(stdoutPath); os.IsNotExist(err) {
 			return fmt.Errorf("stdout log file not found: %s", stdoutPath)
 		}
-		if _, err := os.Stat(stderrPath); os.IsNotExist(err) {
+		if _, err := logfile.Stat(stderrPath); os.IsNotExist(err) {
 			return fmt.Errorf("stderr log file not found: %s", stderrPath)
 		}
 
-		stderrPrefix := fmt.Sprintf("\033[33m[%s]\033[0m ", job.ID)
+		stderrPrefix := output.Colorize(os.Stdout, output.Yellow, "["+job.ID+"]") + " "
 		stdoutPrefix := fmt.Sprintf("[%s] ", job.ID)
 
-		follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix})
-		follower.AddSource(tail.FileSource{Path: stderrPath, Prefix: stderrPrefix})
+		if logsLevel == "" {
+			follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix, Transform: cleanTransform()})
+			follower.AddSource(tail.FileSource{Path: stderrPath, Prefix: stderrPrefix, Transform: cleanTransform()})
+		} else if job.LogFormat == daemon.LogFormatJSON {
+			follower.AddSource(tail.FileSource{Path: stdoutPath, Prefix: stdoutPrefix, Filter: levelFilter(), Transform: cleanTransform()})
+		}
 
 		runningJobs[job.ID] = runningJob{pid: job.PID, command: strings.Join(job.Command, " ")}
 		return nil
@@ -247,7 +687,8 @@ func logsFollowAll() error {
 		return fmt.Errorf("failed to connect event client: %w", err)
 	}
 
-	eventCh, errCh := eventClient.SubscribeChan(cwd)
+	eventCh, errCh := eventClient.SubscribeChanWithOptions(cwd, daemon.SubscribeOptions{Reconnect: true})
+	markers := newMarkerPrinter(follower)
 
 	go func() {
 		for {
@@ -280,6 +721,8 @@ func logsFollowAll() error {
 							follower.SystemLog("all processes stopped")
 						}
 					}
+				case daemon.EventTypeRunUpdated:
+					markers.print(event)
 				}
 				mu.Unlock()
 			case err, ok := <-errCh:
@@ -301,4 +744,10 @@ func logsFollowAll() error {
 func init() {
 	RootCmd.AddCommand(logsCmd)
 	logsCmd.Flags().BoolVarP(&followLogs, "follow", "f", false, "Follow log output in real-time")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "Only show lines of JSON logs at or above this level")
+	logsCmd.Flags().BoolVar(&logsRestarts, "follow-restarts", false, "With --follow, switch to each new run of the job as it starts")
+	logsCmd.Flags().StringVar(&logsIDs, "ids", "", "Show output for these jobs (comma-separated IDs or aliases), interleaved when following")
+	logsCmd.Flags().BoolVar(&logsClean, "clean", false, "Remove cursor movement and keep the last state of progress bars")
+	logsCmd.Flags().BoolVar(&logsMarkers, "markers", false, "Insert a [gob] line for each marker of the run (signals, reloads, stuck detection)")
+	logsCmd.Flags().BoolVarP(&logsTimestamps, "timestamps", "t", false, "Prefix lines with the time they were written (jobs added with --timestamps)")
 }
@@ -38,6 +38,7 @@ CLEANUP
 INTERACTIVE
   gob tui                 Launch interactive TUI
   gob list                List jobs with IDs and status
+  gob status              Running and failed jobs, ports, next steps
 
 Job IDs are 3 characters (e.g. abc, x7f).
 Use 'gob <command> --help' for details.`)
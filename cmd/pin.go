@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:               "pin <job_id>",
+	Short:             "Protect a job from bulk removal and log pruning",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Pin a job so that it is kept when stopped jobs and old runs are cleaned up.
+
+Pinned jobs are skipped by 'gob remove --match/--tag', 'gob disk-usage
+--prune', and by deleting and cleaning up in the TUI. Removing a pinned job
+by ID fails. Each of these takes --force to include pinned jobs anyway
+(except the TUI, where the job must be unpinned first).
+
+Pinned jobs are marked in 'gob list' and in the TUI, and the pin is kept
+across daemon restarts. Use 'gob unpin' to remove it.
+
+Examples:
+  # Keep the database job and its run history
+  gob pin abc
+
+Output:
+  Pinned job abc
+
+Exit codes:
+  0: Job pinned
+  1: Error (job not found)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], true)
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <job_id>",
+	Short:             "Remove the pin of a job",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Remove the pin set with 'gob pin', so that the job is removed and pruned
+like any other.
+
+Output:
+  Unpinned job abc
+
+Exit codes:
+  0: Job unpinned
+  1: Error (job not found)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setPinned(args[0], false)
+	},
+}
+
+// setPinned pins or unpins a job and prints the outcome
+func setPinned(jobID string, pinned bool) error {
+	// Connect to daemon
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	job, err := client.SetPinned(jobID, pinned)
+	if err != nil {
+		return err
+	}
+
+	if pinned {
+		fmt.Printf("Pinned job %s\n", job.ID)
+	} else {
+		fmt.Printf("Unpinned job %s\n", job.ID)
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(pinCmd)
+	RootCmd.AddCommand(unpinCmd)
+}
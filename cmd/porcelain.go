@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+// --porcelain output is for shell scripts: one record per line, fields
+// separated by tabs, no headers and no colors. The fields of each command
+// only ever get new ones appended, so scripts can rely on their positions:
+//
+//	list:  <job_id> <status> <exit_code> <pid> <workdir> <command>
+//	runs:  <run_id> <status> <exit_code> <started_at> <duration_ms> <note>
+//	ports: <job_id> <port> <protocol> <address> <pid>
+//
+// Missing values (no exit code, no PID, no note) are written as "-".
+
+// porcelainNone stands for a missing value in --porcelain output
+const porcelainNone = "-"
+
+// printPorcelain writes one record of --porcelain output. Tabs and newlines
+// inside fields are replaced by spaces so records stay one line.
+func printPorcelain(w io.Writer, fields ...any) {
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(fmt.Sprint(f))
+	}
+	fmt.Fprintln(w, strings.Join(values, "\t"))
+}
+
+// porcelainExitCode formats an exit code for --porcelain output
+func porcelainExitCode(exitCode *int) string {
+	if exitCode == nil {
+		return porcelainNone
+	}
+	return fmt.Sprint(*exitCode)
+}
+
+// printPorcelainJob writes a job as a --porcelain record of gob list
+func printPorcelainJob(w io.Writer, job daemon.JobResponse) {
+	pid := porcelainNone
+	if job.PID != 0 {
+		pid = fmt.Sprint(job.PID)
+	}
+	printPorcelain(w, job.ID, job.Status, porcelainExitCode(job.ExitCode), pid, job.Workdir, strings.Join(job.Command, " "))
+}
+
+// printPorcelainRun writes a run as a --porcelain record of gob runs
+func printPorcelainRun(w io.Writer, run daemon.RunResponse) {
+	duration := porcelainNone
+	if run.Status != "running" {
+		duration = fmt.Sprint(run.DurationMs)
+	}
+	note := porcelainNone
+	if run.Note != "" {
+		note = run.Note
+	}
+	printPorcelain(w, run.ID, run.Status, porcelainExitCode(run.ExitCode), run.StartedAt, duration, note)
+}
+
+// printPorcelainPort writes a listening port as a --porcelain record of gob ports
+func printPorcelainPort(w io.Writer, jobID string, p daemon.PortInfo) {
+	printPorcelain(w, jobID, p.Port, p.Protocol, p.Address, p.PID)
+}
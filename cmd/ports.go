@@ -10,8 +10,9 @@ import (
 )
 
 var (
-	portsAll  bool
-	portsJSON bool
+	portsAll       bool
+	portsJSON      bool
+	portsPorcelain bool
 )
 
 var portsCmd = &cobra.Command{
@@ -36,11 +37,20 @@ Output format (multiple jobs):
   abc    8080   tcp    0.0.0.0      1234
   def    3000   tcp    0.0.0.0      5678
 
+With --porcelain, each port is one line of tab-separated fields, with no
+headers, for shell scripts; nothing is printed when there are none. The
+fields never change between versions (new ones may be appended):
+  <job_id>\t<port>\t<protocol>\t<address>\t<pid>
+
 Exit codes:
   0: Success
   1: Error (job not found)`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if portsPorcelain && portsJSON {
+			return fmt.Errorf("--porcelain cannot be used with --json")
+		}
+
 		// Connect to daemon
 		client, err := daemon.NewClient()
 		if err != nil {
@@ -69,6 +79,13 @@ func showJobPorts(cmd *cobra.Command, client *daemon.Client, jobID string) error
 		return err
 	}
 
+	if portsPorcelain {
+		for _, p := range ports.Ports {
+			printPorcelainPort(cmd.OutOrStdout(), ports.JobID, p)
+		}
+		return nil
+	}
+
 	// Check if job is stopped
 	if ports.Status == "stopped" {
 		fmt.Printf("Job %s is not running\n", jobID)
@@ -119,6 +136,15 @@ func showAllPorts(cmd *cobra.Command, client *daemon.Client) error {
 		return enc.Encode(allPorts)
 	}
 
+	if portsPorcelain {
+		for _, jp := range allPorts {
+			for _, p := range jp.Ports {
+				printPorcelainPort(cmd.OutOrStdout(), jp.JobID, p)
+			}
+		}
+		return nil
+	}
+
 	// Check if any ports
 	totalPorts := 0
 	for _, jp := range allPorts {
@@ -147,4 +173,6 @@ func init() {
 		"Show ports from all directories")
 	portsCmd.Flags().BoolVar(&portsJSON, "json", false,
 		"Output in JSON format")
+	portsCmd.Flags().BoolVar(&portsPorcelain, "porcelain", false,
+		"Output stable tab-separated fields for scripts")
 }
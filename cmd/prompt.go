@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+// promptTimeout bounds the daemon request made for the prompt, so a busy
+// daemon never delays it noticeably
+const promptTimeout = 300 * time.Millisecond
+
+var promptAll bool
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print job counts for the shell prompt",
+	Long: `Print the number of running jobs and of jobs whose last run failed in
+the current directory, separated by a space, for use in a shell prompt.
+
+The hooks installed by 'gob shell-init' call it before each prompt and store
+the counts in GOB_RUNNING and GOB_FAILED.
+
+It never starts the daemon: when it is not running (or does not answer
+quickly), nothing is printed.
+
+Examples:
+  gob prompt
+  gob prompt --all
+
+Output:
+  2 1
+
+Exit codes:
+  0: Always`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workdir := ""
+		if !promptAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return nil
+			}
+			workdir = cwd
+		}
+
+		client, err := daemon.NewClient()
+		if err != nil {
+			return nil
+		}
+		defer client.Close()
+		client.SetTimeout(promptTimeout)
+
+		if err := client.ConnectRunning(); err != nil {
+			return nil
+		}
+
+		jobs, err := client.List(workdir)
+		if err != nil {
+			return nil
+		}
+
+		running, failed := promptCounts(jobs)
+		fmt.Fprintf(cmd.OutOrStdout(), "%d %d\n", running, failed)
+		return nil
+	},
+}
+
+// promptCounts counts the running jobs and the stopped jobs whose last run
+// exited with a non-zero code, the same way 'gob status' sorts them
+func promptCounts(jobs []daemon.JobResponse) (running, failed int) {
+	for _, job := range jobs {
+		switch {
+		case job.Status == "running":
+			running++
+		case job.ExitCode != nil && *job.ExitCode != 0:
+			failed++
+		}
+	}
+	return running, failed
+}
+
+func init() {
+	RootCmd.AddCommand(promptCmd)
+	promptCmd.Flags().BoolVarP(&promptAll, "all", "a", false,
+		"Count jobs in all directories")
+}
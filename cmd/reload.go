@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var reloadCmd = &cobra.Command{
+	Use:               "reload <job_id>",
+	Short:             "Ask a running job to reload, keeping its run",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Send a running job its reload signal, for servers that reload their
+configuration on a signal instead of restarting.
+
+The signal is HUP unless the job was added with --reload-signal. Unlike
+'gob restart', the run keeps going: the reload is recorded as a marker on
+the run, with the time it was sent.
+
+Examples:
+  # Reload nginx's configuration
+  gob reload abc
+
+  # A server that reloads on USR2
+  gob add --reload-signal USR2 ./server
+  gob reload def
+
+Output:
+  Reloaded job <job_id> (sent <signal> to PID <pid>)
+
+Exit codes:
+  0: Reload signal sent
+  1: Error (job not found, job not running, failed to send)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		data, err := client.Reload(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Reloaded job %s (sent %s to PID %d)\n", data.JobID, signalName(syscall.Signal(data.Signal)), data.PID)
+		return nil
+	},
+}
+
+// signalName returns the name of a signal without the SIG prefix, or its
+// number if it has no common name
+func signalName(sig syscall.Signal) string {
+	for name, s := range signalNames {
+		if s == sig {
+			return name
+		}
+	}
+	return fmt.Sprint(int(sig))
+}
+
+func init() {
+	RootCmd.AddCommand(reloadCmd)
+}
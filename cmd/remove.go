@@ -7,21 +7,51 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	removeSelector batchSelector
+	removeForce    bool
+	removeDryRun   bool
+)
+
 var removeCmd = &cobra.Command{
-	Use:               "remove <job_id>",
+	Use:               "remove <job_id>... | --match <pattern> | --tag <tag> | --older-than <age> | --failed-only",
 	Short:             "Remove a stopped job",
-	ValidArgsFunction: completeJobIDs,
+	ValidArgsFunction: completeManyJobIDs,
 	Long: `Remove a single stopped job and all its run history.
 
 Only works on stopped jobs - returns an error if the job is still running.
 Use 'gob stop' first if needed.
 
-Example:
+Several jobs can be removed at once by passing multiple IDs, or by selecting
+stopped jobs in the current directory with --match (a glob pattern matched
+against the command, '*' matches anything) or --tag. --older-than only selects
+jobs whose latest run stopped longer ago than the given age (or that were
+created that long ago and never ran), and --failed-only jobs whose latest run
+failed. They narrow --match and --tag, or select among every stopped job in
+the current directory on their own.
+
+--dry-run lists the jobs that would be removed with their log files and the
+disk space removing them frees, without removing anything.
+
+Pinned jobs (see 'gob pin') are skipped by --match and --tag, and removing
+one by ID fails. Use --force to remove them anyway.
+
+Examples:
   # Remove a specific stopped job
   gob remove V3x0QqI
 
+  # Remove several stopped jobs
+  gob remove abc def
+
+  # Remove every stopped npm script in the current directory
+  gob remove --match "npm run *"
+
+  # See which failed jobs not run for a week would be removed
+  gob remove --older-than 7d --failed-only --dry-run
+
 Output:
   Removed job <job_id> (PID <pid>)
+  Freed <size>            (when removing several jobs)
 
 Notes:
   - Only works on stopped jobs (use 'gob stop' first if needed)
@@ -30,11 +60,9 @@ Notes:
 
 Exit codes:
   0: Job removed successfully
-  1: Error (job not found, job still running, failed to remove)`,
-	Args: cobra.ExactArgs(1),
+  1: Error (job not found, job still running, job pinned, failed to remove)`,
+	Args: batchArgs(&removeSelector, 0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
-
 		// Connect to daemon
 		client, err := daemon.NewClient()
 		if err != nil {
@@ -46,8 +74,35 @@ Exit codes:
 			return fmt.Errorf("failed to connect to daemon: %w", err)
 		}
 
+		if len(args) > 1 || removeSelector.isSet() || removeDryRun {
+			b := daemon.BatchRequest{Action: daemon.BatchRemove, JobIDs: args, Force: removeForce, DryRun: removeDryRun}
+			var freed int64
+			err := runBatch(client, &removeSelector, b, func(r daemon.BatchResult) {
+				freed += r.LogBytes
+				if !removeDryRun {
+					fmt.Printf("Removed job %s (PID %d)\n", r.JobID, r.PID)
+					return
+				}
+				fmt.Printf("Would remove job %s (%d log files, %s)\n", r.JobID, len(r.LogFiles), daemon.FormatBytes(r.LogBytes))
+				for _, file := range r.LogFiles {
+					fmt.Printf("  %s\n", file)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			if removeDryRun {
+				fmt.Printf("Would free %s\n", daemon.FormatBytes(freed))
+			} else {
+				fmt.Printf("Freed %s\n", daemon.FormatBytes(freed))
+			}
+			return nil
+		}
+
+		jobID := args[0]
+
 		// Remove via daemon
-		pid, err := client.Remove(jobID)
+		pid, err := client.Remove(jobID, removeForce)
 		if err != nil {
 			return err
 		}
@@ -61,4 +116,10 @@ Exit codes:
 
 func init() {
 	RootCmd.AddCommand(removeCmd)
+	addBatchFlags(removeCmd, &removeSelector, "Remove stopped")
+	addBatchFilterFlags(removeCmd, &removeSelector, "Remove stopped")
+	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false,
+		"Also remove pinned jobs")
+	removeCmd.Flags().BoolVar(&removeDryRun, "dry-run", false,
+		"List the jobs and log files that would be removed, and the space freed, without removing them")
 }
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	replaySpeed     float64
+	replayIdleLimit time.Duration
+)
+
+var replayCmd = &cobra.Command{
+	Use:               "replay <run_id>",
+	Short:             "Replay the output of a run with its original timing",
+	ValidArgsFunction: completeRunIDs,
+	Long: `Replay the stdout and stderr of a run, writing each line after the same
+pause as when the run wrote it, for demos and post-mortems of flaky tools.
+
+Only runs of jobs added with --timestamps can be replayed: the timing comes
+from the index recorded next to their logs. Lines are replayed whole, so
+progress bars and prompts written without newlines appear at once.
+
+Examples:
+  # Replay at the original speed
+  gob replay abc-3
+
+  # Four times faster
+  gob replay --speed 4 abc-3
+
+  # Skip pauses longer than 2 seconds
+  gob replay --idle-limit 2s abc-3
+
+Exit codes:
+  0: Success
+  1: Error (run not found, run without timestamps)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runID := args[0]
+		if replaySpeed <= 0 {
+			return fmt.Errorf("--speed must be positive")
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		run, err := findRun(client, runID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(daemon.TimestampIndexPath(run.StdoutPath)); err != nil {
+			return fmt.Errorf("run %s has no timestamps (add the job with --timestamps to record them)", runID)
+		}
+		stdoutLines, err := readTimedLines(run.StdoutPath)
+		if err != nil {
+			return err
+		}
+
+		// Jobs with --combine-output log stderr into stdout
+		type replayLine struct {
+			daemon.TimedLine
+			stderr bool
+		}
+		var lines []replayLine
+		for _, line := range stdoutLines {
+			lines = append(lines, replayLine{TimedLine: line})
+		}
+		if run.StderrPath != run.StdoutPath {
+			stderrLines, err := readTimedLines(run.StderrPath)
+			if err != nil {
+				return err
+			}
+			for _, line := range stderrLines {
+				lines = append(lines, replayLine{TimedLine: line, stderr: true})
+			}
+		}
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].Time.Before(lines[j].Time)
+		})
+
+		// Pauses are measured from the start of the run
+		last, _ := time.Parse(time.RFC3339, run.StartedAt)
+		if len(lines) > 0 && (last.IsZero() || lines[0].Time.Before(last)) {
+			last = lines[0].Time
+		}
+		for _, line := range lines {
+			pause := time.Duration(float64(line.Time.Sub(last)) / replaySpeed)
+			if replayIdleLimit > 0 && pause > replayIdleLimit {
+				pause = replayIdleLimit
+			}
+			time.Sleep(pause)
+			last = line.Time
+
+			if line.stderr {
+				fmt.Fprint(os.Stderr, output.Colorize(os.Stderr, output.Yellow, line.Text))
+			} else {
+				fmt.Print(line.Text)
+			}
+		}
+
+		return nil
+	},
+}
+
+// readTimedLines reads a log file split into lines with the time each was
+// written. Returns nil if the log has no timestamp index or is empty.
+func readTimedLines(path string) ([]daemon.TimedLine, error) {
+	stamps, err := daemon.ReadTimestampIndex(path)
+	if err != nil || stamps == nil {
+		return nil, err
+	}
+	content, err := logfile.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return daemon.TimedLines(string(content), stamps), nil
+}
+
+func init() {
+	RootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().Float64Var(&replaySpeed, "speed", 1,
+		"Playback speed, e.g. 2 for twice as fast")
+	replayCmd.Flags().DurationVar(&replayIdleLimit, "idle-limit", 0,
+		"Longest pause between lines (0 for no limit)")
+}
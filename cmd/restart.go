@@ -9,12 +9,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var restartFollow bool
+var (
+	restartFollow   bool
+	restartEnvFrom  string
+	restartSelector batchSelector
+)
 
 var restartCmd = &cobra.Command{
-	Use:               "restart <job_id>",
+	Use:               "restart <job_id>... | --match <pattern> | --tag <tag>",
 	Short:             "Restart a job (stop + start)",
-	ValidArgsFunction: completeJobIDs,
+	ValidArgsFunction: completeManyJobIDs,
 	Long: `Restart a job by stopping it (if running) and starting it again.
 
 If the job is running, sends SIGTERM to stop it first.
@@ -22,6 +26,19 @@ If the job is already stopped, simply starts it.
 
 The job ID remains the same, but a new PID is assigned.
 
+The new run gets the current environment, unless --env-from picks another:
+  client    the environment of this command (default)
+  previous  the environment of the job's previous run (falls back to client
+            when the daemon has restarted since)
+  stored    the daemon's environment with only the job's --env variables
+The job's --env variables are set in every case, and the source used is
+recorded on the run (see 'gob runs --json').
+
+Several jobs can be restarted at once by passing multiple IDs, or by
+selecting jobs in the current directory with --match (a glob pattern matched
+against the command, '*' matches anything) or --tag. Blocked jobs are skipped
+when selected by pattern or tag.
+
 Examples:
   # Restart a running job
   gob restart V3x0QqI
@@ -32,6 +49,18 @@ Examples:
   # Restart and follow output until completion
   gob restart -f V3x0QqI
 
+  # Restart with the environment the job ran with, e.g. from another shell
+  gob restart --env-from previous V3x0QqI
+
+  # Restart several jobs
+  gob restart abc def
+
+  # Restart every npm script in the current directory
+  gob restart --match "npm run *"
+
+  # Restart every job tagged "backend" in the current directory
+  gob restart --tag backend
+
 Output:
   Restarted job <job_id> with new PID <pid> running: <command>
 
@@ -44,9 +73,15 @@ Notes:
 Exit codes:
   0: Job restarted successfully
   1: Error (job not found, failed to stop/start)`,
-	Args: cobra.ExactArgs(1),
+	Args: batchArgs(&restartSelector, 0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
+		batch := len(args) > 1 || restartSelector.isSet()
+		if batch && restartFollow {
+			return fmt.Errorf("--follow can only be used with a single job")
+		}
+		if err := daemon.ValidateEnvSource(restartEnvFrom); err != nil {
+			return err
+		}
 
 		// Connect to daemon
 		client, err := daemon.NewClient()
@@ -62,8 +97,17 @@ Exit codes:
 		// Capture current environment
 		env := os.Environ()
 
+		if batch {
+			b := daemon.BatchRequest{Action: daemon.BatchRestart, JobIDs: args, Env: env, EnvFrom: restartEnvFrom}
+			return runBatch(client, &restartSelector, b, func(r daemon.BatchResult) {
+				fmt.Printf("Restarted job %s with new PID %d running: %s\n", r.JobID, r.PID, strings.Join(r.Job.Command, " "))
+			})
+		}
+
+		jobID := args[0]
+
 		// Restart via daemon
-		job, err := client.Restart(jobID, env)
+		job, err := client.Restart(jobID, env, restartEnvFrom)
 		if err != nil {
 			return err
 		}
@@ -83,7 +127,7 @@ Exit codes:
 			stuckTimeout := CalculateStuckTimeout(avgDurationMs)
 			fmt.Printf("  Stuck detection: timeout after %s\n", formatDuration(stuckTimeout))
 
-			followResult, err := followJob(jobID, job.PID, job.StdoutPath, avgDurationMs)
+			followResult, err := followJob(jobID, job.PID, job.StdoutPath, job.StartedAt, avgDurationMs, nil)
 			if err != nil {
 				return err
 			}
@@ -105,5 +149,9 @@ Exit codes:
 
 func init() {
 	restartCmd.Flags().BoolVarP(&restartFollow, "follow", "f", false, "Follow output until job completes")
+	restartCmd.Flags().StringVar(&restartEnvFrom, "env-from", daemon.EnvSourceClient,
+		"Environment of the new runs: client, previous or stored")
+	restartCmd.RegisterFlagCompletionFunc("env-from", completeEnvSources)
+	addBatchFlags(restartCmd, &restartSelector, "Restart")
 	RootCmd.AddCommand(restartCmd)
 }
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// retryTailLines is the number of stderr lines shown from the failed run
+const retryTailLines = 10
+
+var retryFollow bool
+
+var retryCmd = &cobra.Command{
+	Use:               "retry [job_id]",
+	Short:             "Re-run the last failed run",
+	ValidArgsFunction: completeJobIDs,
+	Long: `Start a new run of the job whose most recent run failed.
+
+With a job ID, the job's most recent run must have failed. Without one, the
+most recent failed run of any job in the current directory is retried.
+
+Before starting, a summary of the failed run is printed: its exit code,
+duration and the last lines of its stderr.
+
+Examples:
+  # Retry whatever failed last in this directory
+  gob retry
+
+  # Retry a specific job
+  gob retry V3x0QqI
+
+  # Retry and follow output until completion
+  gob retry -f
+
+Output:
+  Run <run_id> failed <when> after <duration>: <command>
+    Exit code: <code>
+    <last stderr lines>
+
+  Started job <job_id> with PID <pid> running: <command>
+
+Exit codes:
+  0: Job started successfully
+  1: Error (no failed run, job already running, failed to start)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		var failed *daemon.RunResponse
+		var command []string
+		if len(args) == 1 {
+			job, err := client.GetJob(args[0])
+			if err != nil {
+				return err
+			}
+			runs, _, err := client.RunsPage(daemon.RunsPayload{JobID: job.ID, Limit: 1})
+			if err != nil {
+				return err
+			}
+			if len(runs) == 0 || !runFailed(runs[0]) {
+				return fmt.Errorf("the last run of job %s did not fail", job.ID)
+			}
+			failed, command = &runs[0], job.Command
+		} else {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			entries, _, err := client.History(cwd, "", 0, 0)
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if runFailed(entry.RunResponse) {
+					failed, command = &entry.RunResponse, entry.Command
+					break
+				}
+			}
+			if failed == nil {
+				return fmt.Errorf("no failed runs in %s", cwd)
+			}
+		}
+
+		printFailureSummary(failed, command)
+
+		job, err := client.Start(failed.JobID, os.Environ(), daemon.EnvSourceClient)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nStarted job %s with PID %d running: %s\n", job.ID, job.PID, strings.Join(job.Command, " "))
+
+		if retryFollow {
+			var avgDurationMs int64
+			statsJob, statsErr := client.Stats(job.ID)
+			if statsErr == nil && statsJob != nil && statsJob.SuccessCount >= 3 {
+				avgDurationMs = statsJob.AvgDurationMs
+			}
+
+			followResult, err := followJob(job.ID, job.PID, job.StdoutPath, job.StartedAt, avgDurationMs, nil)
+			if err != nil {
+				return err
+			}
+			if followResult.Completed {
+				fmt.Printf("\nJob %s completed\n", job.ID)
+			} else {
+				fmt.Printf("\nJob %s continues running in background\n", job.ID)
+			}
+		}
+
+		return nil
+	},
+}
+
+// runFailed reports whether a run stopped with a non-zero exit code
+func runFailed(run daemon.RunResponse) bool {
+	return run.Status != "running" && run.ExitCode != nil && *run.ExitCode != 0
+}
+
+// printFailureSummary prints the exit code, duration and last stderr lines
+// of a failed run
+func printFailureSummary(run *daemon.RunResponse, command []string) {
+	started, duration, _ := formatRunColumns(*run)
+	fmt.Printf("Run %s failed %s after %s: %s\n", run.ID, started, duration, strings.Join(command, " "))
+	fmt.Printf("  Exit code: %d\n", *run.ExitCode)
+
+	for _, line := range lastLines(run.StderrPath, retryTailLines) {
+		fmt.Printf("  %s\n", output.Colorize(os.Stdout, output.Yellow, line))
+	}
+}
+
+// lastLines returns the last n lines of a file, or none if it cannot be read
+func lastLines(path string, n int) []string {
+	content, err := logfile.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	text := strings.TrimRight(string(content), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+func init() {
+	retryCmd.Flags().BoolVarP(&retryFollow, "follow", "f", false, "Follow output until job completes")
+	RootCmd.AddCommand(retryCmd)
+}
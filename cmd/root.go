@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 
+	"github.com/juanibiapina/gob/internal/output"
 	"github.com/juanibiapina/gob/internal/telemetry"
 	"github.com/juanibiapina/gob/internal/version"
 	"github.com/spf13/cobra"
@@ -13,8 +14,13 @@ var skipTelemetry = map[string]bool{
 	"tui":        true, // has own telemetry
 	"completion": true, // shell completion
 	"__complete": true, // internal completion
+	"prompt":     true, // runs before every shell prompt
+	"cd":         true, // gob hook cd runs on every change of directory
 }
 
+// colorMode is the value of the --color flag
+var colorMode string
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "gob",
@@ -23,16 +29,24 @@ var RootCmd = &cobra.Command{
 
 Start a dev server with Claude Code, check its logs yourself. Or vice-versa.
 Everyone has the same view. No more copy-pasting logs through chat.`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := output.SetMode(colorMode); err != nil {
+			return err
+		}
+
+		askTelemetryConsent(cmd)
+		telemetry.Init()
+
 		// Track CLI command usage (skip commands with own telemetry or completion)
 		name := cmd.Name()
 		if skipTelemetry[name] {
-			return
+			return nil
 		}
 		if parent := cmd.Parent(); parent != nil && parent.Name() == "completion" {
-			return
+			return nil
 		}
 		telemetry.CLICommandStart(name)
+		return nil
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		telemetry.CLICommandEnd()
@@ -46,7 +60,6 @@ Everyone has the same view. No more copy-pasting logs through chat.`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the RootCmd.
 func Execute() {
-	telemetry.Init()
 	defer telemetry.Flush()
 
 	err := RootCmd.Execute()
@@ -62,6 +75,9 @@ func init() {
 	// Don't show usage on errors - only show it when explicitly requested
 	RootCmd.SilenceUsage = true
 
+	RootCmd.PersistentFlags().StringVar(&colorMode, "color", string(output.ModeAuto),
+		"When to color output: auto (terminals, unless $NO_COLOR is set), always or never")
+
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
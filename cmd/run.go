@@ -12,7 +12,7 @@ import (
 )
 
 var runCmd = &cobra.Command{
-	Use:                "run [--description <desc>] [--] <command> [args...]",
+	Use:                "run [options] [--] <command> [args...]",
 	Short:              "Add a job and wait for it to complete",
 	DisableFlagParsing: true,
 	Long: `Add a new background job and wait for it to complete.
@@ -23,6 +23,40 @@ and dumped on failure. Use 'gob add' + 'gob await' for real-time streaming.
 On success: prints a summary with helper commands to inspect output.
 On failure: prints the full stdout and stderr, then a summary.
 
+Options (must come before the command):
+  -d, --description <desc>    Human-readable description of the job
+  -p, --priority <level>      Scheduling priority: low, normal or high
+      --memory-limit <size>   Memory limit per run, e.g. 512M or 2G (Linux cgroup v2); off removes it
+      --cpu-limit <cores>     CPU limit per run in cores, e.g. 1.5 (Linux cgroup v2); off removes it
+      --on-start <cmd>        Shell command to run after each run starts
+      --on-success <cmd>      Shell command to run after a run exits with code 0
+      --on-failure <cmd>      Shell command to run after a run exits with a non-zero code
+      --on-slow <cmd>         Shell command to run when a run passes the job's slow threshold
+                              (off removes a hook)
+      --stdin <mode>          null (default, reads get EOF) or open (stdin stays open)
+      --stdin-from <file>     Feed the file to the command's stdin
+      --log-format <format>   text (default) or json (stdout is JSON lines with a level)
+      --timestamps            Record when each output line was written (see gob logs --timestamps)
+      --no-timestamps         Stop recording when output lines were written
+      --combine-output        Write stderr into the stdout log, keeping the order of lines
+      --no-combine-output     Write stderr to its own log again
+      --keep-head <size>      Only keep the first part of each log of a run, e.g. 64K
+      --keep-tail <size>      Only keep the last part of each log of a run, e.g. 1M
+      --log-dir <dir>         Write the job's logs to this directory instead of gob's
+      --in-container <image>  Run the command in a container of the image (docker or podman)
+      --dev-env <env>         Run the command in the project's nix (flake.nix) or direnv (.envrc) environment
+      --sandbox <mode>        Only let the command write in the project (project) or nowhere (readonly),
+                              besides a scratch TMPDIR; off removes it (default $GOB_SANDBOX)
+      --workdir <dir>         Run the command in dir, keeping the job in the current directory
+      --reload-signal <sig>   Signal sent by 'gob reload' (default HUP)
+      --env <KEY=VALUE>       Set a variable in every run of the job (repeatable, replaces the job's)
+      --shell                 Run the command as one script with $GOB_SHELL -c (default sh)
+      --follow-restarts       Keep waiting when the job is restarted, and report the last run
+      --silent                Print nothing unless the job fails, then one line to stderr
+  -t, --tag <tag>             Tag the job (repeatable, replaces the job's tags)
+  -l, --label <label>         Label the started run, to group it with others (see 'gob summary')
+      --color <when>          Color output: auto (default), always or never
+
 Examples:
   # Run a build and wait for it
   gob run make build
@@ -40,10 +74,37 @@ Examples:
   gob run --description "Build project" make build
   gob run -d "Run tests" -- npm test
 
+  # Run with low scheduling priority (low, normal or high)
+  gob run --priority low make build
+
+  # Feed a file to the command's stdin
+  gob run --stdin-from input.sql -- sqlite3 app.db
+
+  # Run a pipeline or && chain through a shell
+  gob run --shell "npm run build && npm run test"
+
+  # Run the tests in a container (docker or podman, or $GOB_CONTAINER_RUNTIME)
+  gob run --in-container node:20 npm test
+
+  # Label the checks of a pull request, for one verdict from 'gob summary'
+  gob run --label pr-1234 make test
+  gob run --label pr-1234 make lint
+
+  # Report the result of the last run if the job is restarted meanwhile
+  gob run --follow-restarts make test
+
+  # Run make at the repository root, keeping the job with this package
+  gob run --workdir ../.. make test
+
+  # Run in the shell's background, only hearing back on failure
+  # (what 'make test &g' does with gob shell-init)
+  gob run --silent make test &
+
 Output:
   Shows job statistics (if available), then waits silently.
   On success: summary with commands to view output.
   On failure: full stdout/stderr followed by summary.
+  With --silent: nothing on success; on failure, one line to stderr.
 
 Exit codes:
   Exits with the job's exit code (0 if successful, non-zero otherwise).
@@ -54,44 +115,16 @@ Exit codes:
 			return fmt.Errorf("requires at least 1 arg(s)")
 		}
 
-		// Parse --description / -d flag manually (before --)
-		var description string
-		var commandArgs []string
-		for i := 0; i < len(args); i++ {
-			arg := args[i]
-			if arg == "--" {
-				// Everything after -- is the command
-				commandArgs = args[i+1:]
-				break
-			}
-			if arg == "--description" || arg == "-d" {
-				if i+1 >= len(args) {
-					return fmt.Errorf("--description requires a value")
-				}
-				description = args[i+1]
-				i++ // skip the value
-				continue
-			}
-			if strings.HasPrefix(arg, "--description=") {
-				description = strings.TrimPrefix(arg, "--description=")
-				continue
-			}
-			if strings.HasPrefix(arg, "-d=") {
-				description = strings.TrimPrefix(arg, "-d=")
-				continue
-			}
-			// Not a flag we recognize, treat rest as command
-			commandArgs = args[i:]
-			break
-		}
-
-		if len(commandArgs) == 0 {
-			return fmt.Errorf("requires at least 1 arg(s)")
+		// Parse job flags manually (before --)
+		parsed, err := parseJobArgs(args)
+		if err != nil {
+			return err
 		}
+		commandArgs := parsed.command
 
-		// Handle quoted command string: "echo hello world" -> ["echo", "hello", "world"]
-		if len(commandArgs) == 1 && strings.Contains(commandArgs[0], " ") {
-			commandArgs = strings.Fields(commandArgs[0])
+		opts, err := parsed.options()
+		if err != nil {
+			return err
 		}
 
 		// Connect to daemon
@@ -122,8 +155,9 @@ Exit codes:
 		// Capture current environment
 		env := os.Environ()
 
-		// Add job via daemon (blocked=false since CLI doesn't set blocked status)
-		result, err := client.Add(commandArgs, cwd, env, description, false)
+		// Run job via daemon (blocked=false since CLI doesn't set blocked status).
+		// If another client just ran the same command, its run is reused.
+		result, err := client.Run(commandArgs, cwd, env, opts)
 		if err != nil {
 			return fmt.Errorf("failed to add job: %w", err)
 		}
@@ -138,7 +172,14 @@ Exit codes:
 		stuckTimeout := CalculateStuckTimeout(avgDurationMs)
 
 		// Print message based on action
-		if result.Action == "already_running" {
+		switch {
+		case parsed.silent:
+			// Nothing to say until the job fails
+		case result.Action == "deduplicated":
+			startedAt, _ := time.Parse(time.RFC3339, result.Run.StartedAt)
+			fmt.Printf("Job %s was run by another client %s ago, reusing its result\n",
+				result.Job.ID, formatDuration(time.Since(startedAt)))
+		case result.Action == "already_running":
 			startedAt, _ := time.Parse(time.RFC3339, result.Job.StartedAt)
 			duration := formatDuration(time.Since(startedAt))
 			fmt.Printf("Job %s already running (since %s ago), attaching...\n", result.Job.ID, duration)
@@ -146,8 +187,11 @@ Exit codes:
 				fmt.Printf("  %s\n", result.Job.Description)
 			}
 			fmt.Printf("  Stuck detection: timeout after %s\n", formatDuration(stuckTimeout))
-		} else {
+		default:
 			fmt.Printf("Running job %s: %s\n", result.Job.ID, commandStr)
+			if result.Job.RunDir != "" {
+				fmt.Printf("  in %s\n", result.Job.RunDir)
+			}
 			if result.Job.Description != "" {
 				fmt.Printf("  %s\n", result.Job.Description)
 			}
@@ -168,25 +212,52 @@ Exit codes:
 			fmt.Printf("  Stuck detection: timeout after %s\n", formatDuration(stuckTimeout))
 		}
 
-		// Wait for job to complete (without streaming output)
-		waitResult, err := waitForJob(result.Job.PID, result.Job.StdoutPath, avgDurationMs)
-		if err != nil {
-			return err
-		}
+		// Wait for job to complete (without streaming output). A reused run
+		// that already finished has nothing to wait for.
+		if result.Action != "deduplicated" {
+			var restarts <-chan daemon.RunResponse
+			if parsed.followRestarts {
+				var stop func()
+				restarts, stop, err = watchRestarts(result.Job.ID, cwd)
+				if err != nil {
+					return err
+				}
+				defer stop()
+			}
 
-		if waitResult.PossiblyStuck {
-			fmt.Printf("\nJob %s possibly stuck (no output for 1m)\n", result.Job.ID)
-			fmt.Printf("  gob stdout %s   # check current output\n", result.Job.ID)
-			fmt.Printf("  gob await %s    # continue waiting with output\n", result.Job.ID)
-			fmt.Printf("  gob stop %s     # stop the job\n", result.Job.ID)
-			return nil
-		}
+			waitResult, err := waitForJob(result.Job.PID, result.Job.StdoutPath, avgDurationMs, restarts)
+			if err != nil {
+				return err
+			}
 
-		if !waitResult.Completed {
-			fmt.Printf("\nJob %s continues running in background\n", result.Job.ID)
-			fmt.Printf("  gob await %s   # wait for completion with live output\n", result.Job.ID)
-			fmt.Printf("  gob stop %s    # stop the job\n", result.Job.ID)
-			return nil
+			if waitResult.PossiblyStuck {
+				recordStuck(result.Job.ID)
+			}
+
+			if waitResult.PossiblyStuck && parsed.silent {
+				fmt.Fprintf(os.Stderr, "gob: job %s possibly stuck (no output for 1m): %s\n  gob stdout %s   # check current output\n",
+					result.Job.ID, commandStr, result.Job.ID)
+				return nil
+			}
+
+			if waitResult.PossiblyStuck {
+				fmt.Printf("\nJob %s possibly stuck (no output for 1m)\n", result.Job.ID)
+				fmt.Printf("  gob stdout %s   # check current output\n", result.Job.ID)
+				fmt.Printf("  gob await %s    # continue waiting with output\n", result.Job.ID)
+				fmt.Printf("  gob stop %s     # stop the job\n", result.Job.ID)
+				return nil
+			}
+
+			if !waitResult.Completed && parsed.silent {
+				return nil
+			}
+
+			if !waitResult.Completed {
+				fmt.Printf("\nJob %s continues running in background\n", result.Job.ID)
+				fmt.Printf("  gob await %s   # wait for completion with live output\n", result.Job.ID)
+				fmt.Printf("  gob stop %s    # stop the job\n", result.Job.ID)
+				return nil
+			}
 		}
 
 		// Re-fetch job to get final state
@@ -195,6 +266,21 @@ Exit codes:
 			return err
 		}
 
+		// Silent runs only report failures, in one line
+		if parsed.silent {
+			if job.ExitCode == nil || *job.ExitCode != 0 {
+				reason := "was killed by a signal"
+				if job.ExitCode != nil {
+					reason = fmt.Sprintf("failed with exit code %d", *job.ExitCode)
+				}
+				fmt.Fprintf(os.Stderr, "gob: job %s %s: %s\n  gob logs %s   # view output\n", job.ID, reason, commandStr, job.ID)
+			}
+			if job.ExitCode != nil && *job.ExitCode != 0 {
+				os.Exit(*job.ExitCode)
+			}
+			return nil
+		}
+
 		// On failure (non-zero or killed by signal), dump stdout/stderr
 		if job.ExitCode == nil || *job.ExitCode != 0 {
 			if err := printJobOutput(job); err != nil {
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/juanibiapina/gob/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runAllJobs      int
+	runAllKeepGoing bool
+	runAllShell     bool
+)
+
+// runAllEntry is a command of run-all, run by shell if not empty
+type runAllEntry struct {
+	command []string
+	shell   string
+	workdir string // empty for the current directory
+	label   string // label of the run, empty for none
+}
+
+// runAllResult is the outcome of one command of run-all
+type runAllResult struct {
+	command  []string
+	jobID    string
+	status   string // "succeeded", "failed", "running", "skipped" or "error"
+	exitCode *int
+	duration time.Duration
+	err      error
+}
+
+var runAllCmd = &cobra.Command{
+	Use:   "run-all [file|-]",
+	Short: "Run several commands concurrently and wait for all of them",
+	Long: `Run a list of commands as jobs in the current directory, at most --jobs
+at a time, streaming their output prefixed with the job ID, then print a
+summary of all of them.
+
+The commands are read one per line from the file, or from stdin with '-'.
+Empty lines and lines starting with # are skipped, and commands are split
+on whitespace like the gobfile's, unless --shell runs each line as a
+script with $GOB_SHELL -c (default sh). Without an argument, the jobs of
+the gobfile are run, except autostarted jobs (usually servers that never
+exit) and blocked jobs.
+
+Like make, no new command is started once one fails, unless --keep-going
+is given. Commands already running are waited for.
+
+Examples:
+  # Run the gobfile's jobs, as many at a time as there are CPUs
+  gob run-all
+
+  # Run the commands of a file, two at a time
+  gob run-all -j 2 checks.txt
+
+  # Run commands from stdin, all of them even if some fail
+  printf 'make lint\nmake test\n' | gob run-all -k -
+
+  # Lines with pipelines or && chains
+  gob run-all --shell checks.txt
+
+Output:
+  Prefixed output of each job, then one line per command:
+  ✓ <job_id>  <duration>  <command>
+  ✗ <job_id>  <duration>  <command> (exit <code>)
+
+Exit codes:
+  0: All commands succeeded
+  N: The exit code of the first failed command, in list order
+  1: Error (no commands, a command could not be started or was not waited for)`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if runAllJobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1")
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		var shell string
+		if runAllShell {
+			shell = commandShell()
+		}
+
+		var commands []runAllEntry
+		switch {
+		case len(args) == 1 && args[0] == "-":
+			commands, err = readCommandList(os.Stdin, shell)
+		case len(args) == 1:
+			var f *os.File
+			f, err = os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			commands, err = readCommandList(f, shell)
+		default:
+			commands, err = gobfileCommands(cwd)
+		}
+		if err != nil {
+			return err
+		}
+		if len(commands) == 0 {
+			return fmt.Errorf("no commands to run")
+		}
+
+		// Start the daemon before the workers connect to it
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+		client.Close()
+
+		results := make([]runAllResult, len(commands))
+		env := os.Environ()
+
+		var (
+			mu     sync.Mutex
+			failed bool
+			wg     sync.WaitGroup
+		)
+		slots := make(chan struct{}, runAllJobs)
+		for i, entry := range commands {
+			slots <- struct{}{}
+
+			mu.Lock()
+			stop := failed && !runAllKeepGoing
+			mu.Unlock()
+			if stop {
+				<-slots
+				results[i] = runAllResult{command: entry.command, status: "skipped"}
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-slots }()
+
+				result := runAllCommand(entry, cwd, env)
+				results[i] = result
+				if result.status != "succeeded" {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		fmt.Println()
+		exitCode := printRunAllResults(results)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// printRunAllResults prints one line per result and how many succeeded, and
+// returns the exit code of the first failed command (1 if it has none, 0 if
+// all succeeded)
+func printRunAllResults(results []runAllResult) int {
+	okMark := output.Colorize(os.Stdout, output.Green, "✓")
+	failMark := output.Colorize(os.Stdout, output.Red, "✗")
+	succeeded := 0
+	exitCode := 0
+	for _, result := range results {
+		commandStr := strings.Join(result.command, " ")
+		switch result.status {
+		case "succeeded":
+			succeeded++
+			fmt.Printf("%s %s  %-8s  %s\n", okMark, result.jobID, formatDuration(result.duration), commandStr)
+			continue
+		case "failed":
+			if result.exitCode != nil {
+				fmt.Printf("%s %s  %-8s  %s (exit %d)\n", failMark, result.jobID, formatDuration(result.duration), commandStr, *result.exitCode)
+			} else {
+				fmt.Printf("%s %s  %-8s  %s (killed)\n", failMark, result.jobID, formatDuration(result.duration), commandStr)
+			}
+		case "running":
+			fmt.Printf("◉ %s  %-8s  %s (still running)\n", result.jobID, "running", commandStr)
+		case "skipped":
+			fmt.Printf("- %s\n", commandStr)
+		default:
+			fmt.Printf("%s %s (%v)\n", failMark, commandStr, result.err)
+		}
+		if exitCode == 0 {
+			exitCode = 1
+			if result.exitCode != nil {
+				exitCode = *result.exitCode
+			}
+		}
+	}
+	fmt.Printf("\n%d of %d commands succeeded\n", succeeded, len(results))
+	return exitCode
+}
+
+// runAllCommand runs one command as a job, streaming its output, and waits
+// for it. Each call uses its own daemon connection.
+func runAllCommand(entry runAllEntry, cwd string, env []string) runAllResult {
+	command := entry.command
+	result := runAllResult{command: command, status: "error"}
+
+	if blockedJob := tui.FindBlockedJob(cwd, command); blockedJob != nil {
+		result.err = fmt.Errorf("job is blocked")
+		return result
+	}
+
+	client, err := daemon.NewClient()
+	if err != nil {
+		result.err = fmt.Errorf("failed to create client: %w", err)
+		return result
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		result.err = fmt.Errorf("failed to connect to daemon: %w", err)
+		return result
+	}
+
+	workdir := cwd
+	if entry.workdir != "" {
+		workdir = entry.workdir
+	}
+	added, err := client.Run(command, workdir, env, daemon.JobOptions{Shell: entry.shell, RunLabel: entry.label})
+	if err != nil {
+		result.err = fmt.Errorf("failed to add job: %w", err)
+		return result
+	}
+	result.jobID = added.Job.ID
+	fmt.Printf("Running job %s: %s\n", added.Job.ID, strings.Join(command, " "))
+
+	var avgDurationMs int64
+	if added.Job.SuccessCount >= 3 {
+		avgDurationMs = added.Job.AvgDurationMs
+	}
+
+	pid, stdoutPath, startedAt := added.Job.PID, added.Job.StdoutPath, added.Job.StartedAt
+	if added.Run != nil {
+		// A reused run that already finished: show its output
+		pid, stdoutPath, startedAt = added.Run.PID, added.Run.StdoutPath, added.Run.StartedAt
+	}
+	followResult, err := followJob(added.Job.ID, pid, stdoutPath, startedAt, avgDurationMs, nil)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if !followResult.Completed {
+		result.status = "running"
+		return result
+	}
+
+	job, err := client.GetJob(added.Job.ID)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.exitCode = job.ExitCode
+	if runs, _, err := client.RunsPage(daemon.RunsPayload{JobID: job.ID, Limit: 1}); err == nil && len(runs) > 0 {
+		result.duration = time.Duration(runs[0].DurationMs) * time.Millisecond
+	}
+	if job.ExitCode != nil && *job.ExitCode == 0 {
+		result.status = "succeeded"
+	} else {
+		result.status = "failed"
+	}
+	return result
+}
+
+// readCommandList reads one command per line, skipping empty lines and
+// lines starting with #. Lines are scripts run by shell if it is not empty.
+func readCommandList(r io.Reader, shell string) ([]runAllEntry, error) {
+	var commands []runAllEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if shell != "" {
+			commands = append(commands, runAllEntry{command: []string{line}, shell: shell})
+		} else {
+			commands = append(commands, runAllEntry{command: strings.Fields(line)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read commands: %w", err)
+	}
+	return commands, nil
+}
+
+// gobfileCommands returns the commands of the gobfile jobs that are neither
+// autostarted nor blocked
+func gobfileCommands(cwd string) ([]runAllEntry, error) {
+	config, err := tui.ReadGobfile(cwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gobfile: %w", err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("no gobfile found; pass a file of commands or '-' for stdin")
+	}
+
+	var commands []runAllEntry
+	for _, job := range config.Jobs {
+		parts := job.CommandArgs()
+		if len(parts) == 0 || job.ShouldAutostart() || job.IsBlocked() {
+			continue
+		}
+		commands = append(commands, runAllEntry{command: parts, shell: job.Shell, workdir: job.JobWorkdir(cwd)})
+	}
+	return commands, nil
+}
+
+func init() {
+	RootCmd.AddCommand(runAllCmd)
+	runAllCmd.Flags().IntVarP(&runAllJobs, "jobs", "j", runtime.NumCPU(),
+		"Maximum number of commands running at once")
+	runAllCmd.Flags().BoolVarP(&runAllKeepGoing, "keep-going", "k", false,
+		"Keep starting commands after one fails")
+	runAllCmd.Flags().BoolVar(&runAllShell, "shell", false,
+		"Run each line as a script with $GOB_SHELL -c (default sh)")
+}
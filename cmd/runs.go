@@ -3,13 +3,23 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/juanibiapina/gob/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
-var runsJSON bool
+var (
+	runsJSON      bool
+	runsPorcelain bool
+	runsLimit     int
+	runsOffset    int
+	runsArchived  bool
+
+	runsArchiveAll       bool
+	runsArchiveOlderThan string
+)
 
 var runsCmd = &cobra.Command{
 	Use:               "runs <job_id>",
@@ -17,25 +27,46 @@ var runsCmd = &cobra.Command{
 	ValidArgsFunction: completeJobIDs,
 	Long: `Show the run history for a job.
 
-Displays all runs for the specified job, sorted by start time (newest first).
-Each run shows its ID, when it started, duration, and exit status.
+Displays the runs for the specified job, sorted by start time (newest first).
+Use --limit and --offset to page through a long history, and
+--include-archived to also show the runs archived with 'gob runs archive',
+after the others. Each run shows its ID, when it started, duration, and exit status,
+how much it printed once it stopped, and its note if it has one (see 'gob annotate').
 
 Output format:
-  <run_id>  <started>  <duration>  <status>
+  <run_id>  <started>  <duration>  <status>  [<output>]  [# <note>]
 
 Where:
   run_id:   Internal run identifier (e.g., abc-1, abc-2)
   started:  When the run started (relative time or timestamp)
   duration: How long the run took (or "running" if still active)
   status:   Exit status: ◉ (running), ✓ (0) for success, ✗ (N) for failure
+  output:   Lines and bytes of stdout and stderr together
 
 Example output:
   abc-5  2 min ago   running   ◉
-  abc-4  1 hour ago  2m15s     ✓ (0)
-  abc-3  2 hours ago 2m45s     ✗ (1)
+  abc-4  1 hour ago  2m15s     ✓ (0)  1204 lines, 38.2 KB
+  abc-3  2 hours ago 2m45s     ✗ (1)  310 lines, 9.6 KB  # broke after upgrading node
+
+Examples:
+  # All runs of job abc
+  gob runs abc
+
+  # The 20 most recent runs, then the next 20
+  gob runs abc --limit 20
+  gob runs abc --limit 20 --offset 20
+
+  # Including the archived runs
+  gob runs abc --include-archived
+
+With --porcelain, each run is one line of tab-separated fields, with no
+headers or symbols, for shell scripts. The fields never change between
+versions (new ones may be appended); missing values are "-":
+  <run_id>\t<status>\t<exit_code>\t<started_at>\t<duration_ms>\t<note>
 
 Subcommands:
   runs delete <run_id>  Delete a stopped run and its log files
+  runs archive          Archive old runs, gzipping their logs
 
 Exit codes:
   0: Success
@@ -43,6 +74,9 @@ Exit codes:
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jobID := args[0]
+		if runsPorcelain && runsJSON {
+			return fmt.Errorf("--porcelain cannot be used with --json")
+		}
 
 		// Connect to daemon
 		client, err := daemon.NewClient()
@@ -56,11 +90,18 @@ Exit codes:
 		}
 
 		// Get runs from daemon
-		runs, err := client.Runs(jobID)
+		runs, total, err := client.RunsPage(daemon.RunsPayload{JobID: jobID, Offset: runsOffset, Limit: runsLimit, IncludeArchived: runsArchived})
 		if err != nil {
 			return err
 		}
 
+		if runsPorcelain {
+			for _, run := range runs {
+				printPorcelainRun(cmd.OutOrStdout(), run)
+			}
+			return nil
+		}
+
 		// If no runs, print message (unless JSON output)
 		if len(runs) == 0 {
 			if runsJSON {
@@ -80,38 +121,49 @@ Exit codes:
 
 		// Print each run in human-readable format
 		for _, run := range runs {
-			startedAt, _ := time.Parse("2006-01-02T15:04:05Z07:00", run.StartedAt)
-			started := formatRelativeTime(startedAt)
-
-			var duration string
-			var status string
-
-			if run.Status == "running" {
-				duration = "running"
-				status = "◉"
-			} else {
-				duration = formatDuration(time.Duration(run.DurationMs) * time.Millisecond)
-				if run.ExitCode != nil {
-					if *run.ExitCode == 0 {
-						status = fmt.Sprintf("✓ (%d)", *run.ExitCode)
-					} else {
-						status = fmt.Sprintf("✗ (%d)", *run.ExitCode)
-					}
-				} else {
-					status = "✗ (killed)"
-				}
+			started, duration, status := formatRunColumns(run)
+			line := fmt.Sprintf("%s  %-12s  %-10s  %s", run.ID, started, duration, status)
+			if run.Output != nil {
+				line += "  " + formatOutputStats(run.Output)
+			}
+			if run.Dir != "" {
+				line += "  (in " + run.Dir + ")"
+			}
+			if run.ArchivedAt != "" {
+				line += "  (archived)"
 			}
+			if run.LogsMissing {
+				line += "  (logs missing)"
+			}
+			if run.Note != "" {
+				line += "  # " + run.Note
+			}
+			fmt.Println(line)
+		}
 
-			fmt.Printf("%s  %-12s  %-10s  %s\n", run.ID, started, duration, status)
+		if shown := runsOffset + len(runs); shown < total {
+			fmt.Printf("\nShowing %d-%d of %d runs (use --offset %d for more)\n", runsOffset+1, shown, total, shown)
 		}
 
 		return nil
 	},
 }
 
+// formatOutputStats formats how much a run printed, stdout and stderr
+// together, e.g. "1204 lines, 38.2 KB"
+func formatOutputStats(o *daemon.OutputStats) string {
+	lines := o.StdoutLines + o.StderrLines
+	unit := "lines"
+	if lines == 1 {
+		unit = "line"
+	}
+	return fmt.Sprintf("%d %s, %s", lines, unit, daemon.FormatBytes(o.StdoutBytes+o.StderrBytes))
+}
+
 var runsDeleteCmd = &cobra.Command{
-	Use:   "delete <run_id>",
-	Short: "Delete a stopped run and its log files",
+	Use:               "delete <run_id>",
+	Short:             "Delete a stopped run and its log files",
+	ValidArgsFunction: completeRunIDs,
 	Long: `Delete a stopped run and its associated log files.
 
 The run must be stopped (not currently running). To delete a running run,
@@ -149,6 +201,88 @@ Exit codes:
 	},
 }
 
+var runsArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive old runs, gzipping their logs",
+	Long: `Archive the stopped runs of the jobs in the current directory that started
+longer ago than --older-than (30 days by default).
+
+Archived runs move to cold storage: their logs are gzipped and the daemon no
+longer keeps them in memory, so 'gob runs' and the TUI list only the recent
+ones. They still count in the job's statistics ('gob stats'), and
+'gob runs --include-archived' lists them, with the paths of their gzipped
+logs in --json output. The latest run of each job is never archived.
+
+The daemon also archives runs older than 90 days when it starts.
+
+Examples:
+  # Archive runs older than 30 days in the current directory
+  gob runs archive
+
+  # Archive runs older than a week in all directories
+  gob runs archive --older-than 7d --all
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		age, err := parseAge(runsArchiveOlderThan)
+		if err != nil {
+			return err
+		}
+
+		var workdir string
+		if !runsArchiveAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			workdir = cwd
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		archived, saved, err := client.ArchiveRuns(workdir, time.Now().Add(-age))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archived %d runs, saved %s\n", archived, daemon.FormatBytes(saved))
+		return nil
+	},
+}
+
+// formatRunColumns returns the started, duration and status columns of a run
+func formatRunColumns(run daemon.RunResponse) (started, duration, status string) {
+	startedAt, _ := time.Parse("2006-01-02T15:04:05Z07:00", run.StartedAt)
+	started = formatRelativeTime(startedAt)
+
+	if run.Status == "running" {
+		return started, "running", "◉"
+	}
+
+	duration = formatDuration(time.Duration(run.DurationMs) * time.Millisecond)
+	if run.ExitCode != nil {
+		if *run.ExitCode == 0 {
+			status = fmt.Sprintf("✓ (%d)", *run.ExitCode)
+		} else {
+			status = fmt.Sprintf("✗ (%d)", *run.ExitCode)
+		}
+	} else {
+		status = "✗ (killed)"
+	}
+	return started, duration, status
+}
+
 // formatRelativeTime formats a time as a human-readable relative string
 func formatRelativeTime(t time.Time) string {
 	d := time.Since(t)
@@ -179,5 +313,12 @@ func formatRelativeTime(t time.Time) string {
 func init() {
 	RootCmd.AddCommand(runsCmd)
 	runsCmd.Flags().BoolVar(&runsJSON, "json", false, "Output in JSON format")
+	runsCmd.Flags().BoolVar(&runsPorcelain, "porcelain", false, "Output stable tab-separated fields for scripts")
+	runsCmd.Flags().IntVarP(&runsLimit, "limit", "n", 0, "Maximum number of runs to show (0 for all)")
+	runsCmd.Flags().IntVar(&runsOffset, "offset", 0, "Number of most recent runs to skip")
+	runsCmd.Flags().BoolVar(&runsArchived, "include-archived", false, "Also show archived runs")
 	runsCmd.AddCommand(runsDeleteCmd)
+	runsCmd.AddCommand(runsArchiveCmd)
+	runsArchiveCmd.Flags().StringVar(&runsArchiveOlderThan, "older-than", "30d", "Archive runs started longer ago than this (e.g. 12h, 30d)")
+	runsArchiveCmd.Flags().BoolVarP(&runsArchiveAll, "all", "a", false, "Archive runs of jobs in all directories")
 }
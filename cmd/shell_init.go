@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	shellInitSuffix string
+	shellInitAlias  string
+	shellInitPrompt bool
+	shellInitCd     bool
+)
+
+// shellAliasPattern matches the function names accepted by --alias
+var shellAliasPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// shellScript is the integration of one shell, in parts that are left out
+// when disabled. {{suffix}} and {{alias}} are replaced by the flag values.
+type shellScript struct {
+	suffix string
+	alias  string
+	prompt string
+	cd     string
+}
+
+var shellScripts = map[string]shellScript{
+	"zsh": {
+		suffix: `# Run lines ending in {{suffix}} in the background through gob
+__gob_accept_line() {
+  local suffix='{{suffix}}'
+  if [[ "$BUFFER" == *[[:space:]]"$suffix" ]]; then
+    BUFFER="gob run --silent -- ${BUFFER%$suffix}&"
+  fi
+  zle .accept-line
+}
+zle -N accept-line __gob_accept_line
+`,
+		alias: `# {{alias}} <command> runs the command in the background through gob
+{{alias}}() {
+  command gob run --silent -- "$@" &
+}
+`,
+		prompt: `# Job counts of the current directory for the prompt
+__gob_precmd() {
+  local counts
+  counts=$(command gob prompt 2>/dev/null)
+  GOB_RUNNING=${counts% *}
+  GOB_FAILED=${counts#* }
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook precmd __gob_precmd
+
+# Prints e.g. "gob: 2 running, 1 failed" (setopt prompt_subst; RPROMPT='$(gob_prompt_info)')
+gob_prompt_info() {
+  local info=
+  [ "${GOB_RUNNING:-0}" -gt 0 ] && info="$GOB_RUNNING running"
+  [ "${GOB_FAILED:-0}" -gt 0 ] && info="${info:+$info, }$GOB_FAILED failed"
+  if [ -n "$info" ]; then
+    printf 'gob: %s' "$info"
+  fi
+}
+`,
+		cd: `# Start and stop gobfile jobs when entering and leaving projects
+__gob_chpwd() {
+  command gob hook cd --pid $$
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd __gob_chpwd
+__gob_chpwd
+`,
+	},
+	"bash": {
+		suffix: `# Run lines ending in {{suffix}} in the background through gob
+__gob_rewrite_line() {
+  local suffix='{{suffix}}'
+  if [[ "$READLINE_LINE" == *[[:space:]]"$suffix" ]]; then
+    READLINE_LINE="gob run --silent -- ${READLINE_LINE%"$suffix"}&"
+    READLINE_POINT=${#READLINE_LINE}
+  fi
+}
+if [[ $- == *i* ]]; then
+  for __gob_keymap in emacs vi-insert; do
+    bind -m "$__gob_keymap" -x '"\e[9999~": __gob_rewrite_line'
+    bind -m "$__gob_keymap" '"\C-m": "\e[9999~\C-j"'
+  done
+  unset __gob_keymap
+fi
+`,
+		alias: `# {{alias}} <command> runs the command in the background through gob
+{{alias}}() {
+  command gob run --silent -- "$@" &
+}
+`,
+		prompt: `# Job counts of the current directory for the prompt
+__gob_prompt_command() {
+  local status=$? counts
+  counts=$(command gob prompt 2>/dev/null)
+  GOB_RUNNING=${counts% *}
+  GOB_FAILED=${counts#* }
+  return $status
+}
+PROMPT_COMMAND="__gob_prompt_command${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+
+# Prints e.g. "gob: 2 running, 1 failed" (PS1='$(gob_prompt_info) \$ ')
+gob_prompt_info() {
+  local info=
+  [ "${GOB_RUNNING:-0}" -gt 0 ] && info="$GOB_RUNNING running"
+  [ "${GOB_FAILED:-0}" -gt 0 ] && info="${info:+$info, }$GOB_FAILED failed"
+  if [ -n "$info" ]; then
+    printf 'gob: %s' "$info"
+  fi
+}
+`,
+		cd: `# Start and stop gobfile jobs when entering and leaving projects
+__gob_cd_hook() {
+  local status=$?
+  if [[ "$PWD" != "$__gob_last_pwd" ]]; then
+    __gob_last_pwd=$PWD
+    command gob hook cd --pid $$
+  fi
+  return $status
+}
+PROMPT_COMMAND="__gob_cd_hook${PROMPT_COMMAND:+; $PROMPT_COMMAND}"
+`,
+	},
+	"fish": {
+		suffix: `# Run lines ending in {{suffix}} in the background through gob
+function __gob_accept_line
+    set -l pattern '\s'(string escape --style=regex -- '{{suffix}}')'$'
+    set -l line (commandline | string collect)
+    if string match -qr -- $pattern $line
+        commandline -r -- "gob run --silent -- "(string replace -r -- $pattern ' ' $line)"&"
+    end
+    commandline -f execute
+end
+bind \r __gob_accept_line
+bind -M insert \r __gob_accept_line
+`,
+		alias: `# {{alias}} <command> runs the command in the background through gob
+function {{alias}}
+    command gob run --silent -- $argv &
+end
+`,
+		prompt: `# Job counts of the current directory for the prompt
+function __gob_prompt --on-event fish_prompt
+    set -l counts (command gob prompt 2>/dev/null | string split ' ')
+    set -g GOB_RUNNING $counts[1]
+    set -g GOB_FAILED $counts[2]
+end
+
+# Prints e.g. "gob: 2 running, 1 failed" (call it from fish_prompt)
+function gob_prompt_info
+    set -l info
+    test "$GOB_RUNNING" -gt 0 2>/dev/null; and set -a info "$GOB_RUNNING running"
+    test "$GOB_FAILED" -gt 0 2>/dev/null; and set -a info "$GOB_FAILED failed"
+    set -q info[1]; and echo -n "gob: "(string join ', ' $info)
+end
+`,
+		cd: `# Start and stop gobfile jobs when entering and leaving projects
+function __gob_cd_hook --on-variable PWD
+    command gob hook cd --pid $fish_pid
+end
+__gob_cd_hook
+`,
+	},
+}
+
+var shellInitCmd = &cobra.Command{
+	Use:       "shell-init <zsh|bash|fish>",
+	Short:     "Print shell integration for running commands through gob",
+	ValidArgs: []string{"zsh", "bash", "fish"},
+	Long: `Print a script that integrates gob with your shell. Load it from your
+shell's startup file.
+
+With it, a command line ending in &g runs in the background through
+'gob run --silent' instead of as a shell job: 'make test &g' becomes
+'gob run --silent -- make test &'. The command is tracked by gob like any
+other job, and you only hear back from it when it fails. Use --suffix to
+pick another ending, or --suffix '' to turn this off. --alias defines a
+function that does the same for its arguments ('gj make test').
+
+Before each prompt, the number of running jobs and of jobs whose last run
+failed in the current directory are stored in GOB_RUNNING and GOB_FAILED
+(see 'gob prompt'), and gob_prompt_info prints them as
+"gob: 2 running, 1 failed", or nothing when both are zero. Use
+--prompt=false to leave the prompt alone.
+
+With --cd, entering a directory with a gobfile starts its jobs marked
+on_enter = true, and they are stopped once no shell has been in the project
+for its stop_on_leave period (see 'gob hook cd').
+
+Setup:
+  # ~/.zshrc
+  eval "$(gob shell-init zsh)"
+
+  # ~/.bashrc (bash 4 or later)
+  eval "$(gob shell-init bash)"
+
+  # ~/.config/fish/config.fish
+  gob shell-init fish | source
+
+Examples:
+  # Use 'cmd &gob' and a gj function
+  eval "$(gob shell-init zsh --suffix '&gob' --alias gj)"
+
+  # Also start the project's jobs when entering it
+  eval "$(gob shell-init bash --cd)"
+
+  # Show job counts on the right of the zsh prompt
+  setopt prompt_subst
+  RPROMPT='$(gob_prompt_info)'
+
+Exit codes:
+  0: Success
+  1: Error (unsupported shell, invalid suffix or alias)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		script, ok := shellScripts[args[0]]
+		if !ok {
+			return fmt.Errorf("unsupported shell: %s (expected zsh, bash or fish)", args[0])
+		}
+		if strings.ContainsAny(shellInitSuffix, " \t\n'\"\\") {
+			return fmt.Errorf("invalid suffix: %q (must not contain spaces, quotes or backslashes)", shellInitSuffix)
+		}
+		if shellInitAlias != "" && !shellAliasPattern.MatchString(shellInitAlias) {
+			return fmt.Errorf("invalid alias: %q (must be a function name)", shellInitAlias)
+		}
+
+		var parts []string
+		if shellInitSuffix != "" {
+			parts = append(parts, script.suffix)
+		}
+		if shellInitAlias != "" {
+			parts = append(parts, script.alias)
+		}
+		if shellInitPrompt {
+			parts = append(parts, script.prompt)
+		}
+		if shellInitCd {
+			parts = append(parts, script.cd)
+		}
+
+		r := strings.NewReplacer("{{suffix}}", shellInitSuffix, "{{alias}}", shellInitAlias)
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "# gob shell integration for %s\n", args[0])
+		for _, part := range parts {
+			fmt.Fprint(out, "\n"+r.Replace(part))
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(shellInitCmd)
+	shellInitCmd.Flags().StringVar(&shellInitSuffix, "suffix", "&g",
+		"Ending that runs a command line through gob ('' to disable)")
+	shellInitCmd.Flags().StringVar(&shellInitAlias, "alias", "",
+		"Also define a function with this name that runs its arguments through gob")
+	shellInitCmd.Flags().BoolVar(&shellInitPrompt, "prompt", true,
+		"Set GOB_RUNNING and GOB_FAILED before each prompt")
+	shellInitCmd.Flags().BoolVar(&shellInitCd, "cd", false,
+		"Start and stop gobfile jobs when entering and leaving projects (see gob hook cd)")
+}
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juanibiapina/gob/internal/daemon"
 	"github.com/spf13/cobra"
 )
 
+var shutdownDryRun bool
+
 var shutdownCmd = &cobra.Command{
 	Use:   "shutdown",
 	Short: "Stop all running jobs and shutdown daemon",
@@ -20,6 +23,9 @@ Workflow:
 Example:
   gob shutdown
 
+  # List the running jobs that would be stopped
+  gob shutdown --dry-run
+
 Output:
   Stopped <n> running job(s)
   Daemon shut down
@@ -28,6 +34,9 @@ Notes:
   - Uses SIGTERM (graceful) not SIGKILL initially
   - Job history is preserved (jobs are not removed)
   - Daemon will restart automatically on next gob command
+  - --dry-run stops nothing and leaves the daemon running
+  - To also free disk space, remove stopped jobs with 'gob remove'
+    (--older-than, --failed-only and --dry-run select and preview them)
 
 Exit codes:
   0: Shutdown completed successfully
@@ -44,6 +53,22 @@ Exit codes:
 			return fmt.Errorf("failed to connect to daemon: %w", err)
 		}
 
+		if shutdownDryRun {
+			jobs, err := client.List("")
+			if err != nil {
+				return err
+			}
+			running := 0
+			for _, job := range jobs {
+				if job.Status == "running" {
+					fmt.Printf("Would stop job %s (PID %d): %s\n", job.ID, job.PID, strings.Join(job.Command, " "))
+					running++
+				}
+			}
+			fmt.Printf("Would stop %d running job(s) and shut down the daemon (no logs are removed)\n", running)
+			return nil
+		}
+
 		// Stop all running jobs
 		stopped, err := client.StopAll()
 		if err != nil {
@@ -65,4 +90,6 @@ Exit codes:
 
 func init() {
 	RootCmd.AddCommand(shutdownCmd)
+	shutdownCmd.Flags().BoolVar(&shutdownDryRun, "dry-run", false,
+		"List the running jobs that would be stopped, without stopping them")
 }
@@ -22,35 +22,19 @@ func parseSignal(signalStr string) (syscall.Signal, error) {
 	upperStr := strings.ToUpper(signalStr)
 	normalizedStr := strings.TrimPrefix(upperStr, "SIG")
 
-	// Map common signal names to syscall constants
-	signalMap := map[string]syscall.Signal{
-		"HUP":  syscall.SIGHUP,
-		"INT":  syscall.SIGINT,
-		"QUIT": syscall.SIGQUIT,
-		"KILL": syscall.SIGKILL,
-		"TERM": syscall.SIGTERM,
-		"USR1": syscall.SIGUSR1,
-		"USR2": syscall.SIGUSR2,
-		"STOP": syscall.SIGSTOP,
-		"CONT": syscall.SIGCONT,
-		"ALRM": syscall.SIGALRM,
-		"PIPE": syscall.SIGPIPE,
-		"CHLD": syscall.SIGCHLD,
-		"ABRT": syscall.SIGABRT,
-		"TRAP": syscall.SIGTRAP,
-	}
-
-	if sig, ok := signalMap[normalizedStr]; ok {
+	if sig, ok := signalNames[normalizedStr]; ok {
 		return sig, nil
 	}
 
 	return 0, fmt.Errorf("invalid signal: %s", signalStr)
 }
 
+var signalSelector batchSelector
+
 var signalCmd = &cobra.Command{
-	Use:               "signal <job_id> <signal>",
+	Use:               "signal <job_id>... <signal> | --match <pattern> <signal> | --tag <tag> <signal>",
 	Short:             "Send a signal to a background job",
-	ValidArgsFunction: completeJobIDs,
+	ValidArgsFunction: completeSignalArgs,
 	Long: `Send a specific signal to a background job.
 
 More flexible than 'job stop' - useful for custom signals like HUP (reload)
@@ -61,6 +45,10 @@ Signal format:
   - Names: TERM, SIGTERM, HUP, SIGHUP, INT, SIGINT, KILL, SIGKILL, etc.
   - Numbers: 1 (HUP), 2 (INT), 9 (KILL), 15 (TERM), etc.
 
+Several jobs can be signaled at once by passing multiple IDs before the
+signal, or by selecting running jobs in the current directory with --match
+(a glob pattern matched against the command) or --tag.
+
 Examples:
   # Reload configuration (common for servers)
   gob signal V3x0QqI HUP
@@ -74,6 +62,12 @@ Examples:
   # Forcefully kill
   gob signal V3x0QqI KILL
 
+  # Reload several jobs
+  gob signal abc def HUP
+
+  # Reload every job tagged "web" in the current directory
+  gob signal --tag web HUP
+
 Output:
   Sent signal <signal> to job <job_id> (PID <pid>)
 
@@ -92,10 +86,10 @@ Notes:
 Exit codes:
   0: Signal sent successfully (or job already stopped)
   1: Error (job not found, invalid signal, failed to send)`,
-	Args: cobra.ExactArgs(2),
+	Args: batchArgs(&signalSelector, 1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
-		signalStr := args[1]
+		jobIDs := args[:len(args)-1]
+		signalStr := args[len(args)-1]
 
 		// Parse the signal
 		sig, err := parseSignal(signalStr)
@@ -114,6 +108,15 @@ Exit codes:
 			return fmt.Errorf("failed to connect to daemon: %w", err)
 		}
 
+		if len(jobIDs) > 1 || signalSelector.isSet() {
+			b := daemon.BatchRequest{Action: daemon.BatchSignal, JobIDs: jobIDs, Signal: int(sig)}
+			return runBatch(client, &signalSelector, b, func(r daemon.BatchResult) {
+				fmt.Printf("Sent signal %s to job %s (PID %d)\n", signalStr, r.JobID, r.PID)
+			})
+		}
+
+		jobID := jobIDs[0]
+
 		// Send signal via daemon
 		pid, err := client.Signal(jobID, sig)
 		if err != nil {
@@ -129,4 +132,5 @@ Exit codes:
 
 func init() {
 	RootCmd.AddCommand(signalCmd)
+	addBatchFlags(signalCmd, &signalSelector, "Signal running")
 }
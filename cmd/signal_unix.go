@@ -0,0 +1,23 @@
+//go:build !windows
+
+package cmd
+
+import "syscall"
+
+// signalNames maps common signal names to syscall constants
+var signalNames = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"STOP": syscall.SIGSTOP,
+	"CONT": syscall.SIGCONT,
+	"ALRM": syscall.SIGALRM,
+	"PIPE": syscall.SIGPIPE,
+	"CHLD": syscall.SIGCHLD,
+	"ABRT": syscall.SIGABRT,
+	"TRAP": syscall.SIGTRAP,
+}
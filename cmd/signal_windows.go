@@ -0,0 +1,17 @@
+package cmd
+
+import "syscall"
+
+// signalNames maps the signal names gob can emulate on Windows to syscall
+// constants. KILL force-terminates the job; the others ask it to close.
+var signalNames = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"ALRM": syscall.SIGALRM,
+	"PIPE": syscall.SIGPIPE,
+	"ABRT": syscall.SIGABRT,
+	"TRAP": syscall.SIGTRAP,
+}
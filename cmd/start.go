@@ -9,7 +9,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var startFollow bool
+var (
+	startFollow  bool
+	startEnvFrom string
+)
 
 var startCmd = &cobra.Command{
 	Use:               "start <job_id>",
@@ -20,6 +23,14 @@ var startCmd = &cobra.Command{
 The job must be stopped (not running). If you want to restart a running job,
 use 'gob restart' instead.
 
+The new run gets the current environment, unless --env-from picks another:
+  client    the environment of this command (default)
+  previous  the environment of the job's previous run (falls back to client
+            when the daemon has restarted since)
+  stored    the daemon's environment with only the job's --env variables
+The job's --env variables are set in every case, and the source used is
+recorded on the run (see 'gob runs --json').
+
 Examples:
   # Start a stopped job
   gob start V3x0QqI
@@ -27,6 +38,9 @@ Examples:
   # Start and follow output until completion
   gob start -f V3x0QqI
 
+  # Start with the environment of the previous run
+  gob start --env-from previous V3x0QqI
+
 Output:
   Started job <job_id> with PID <pid> running: <command>
 
@@ -36,6 +50,9 @@ Exit codes:
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		jobID := args[0]
+		if err := daemon.ValidateEnvSource(startEnvFrom); err != nil {
+			return err
+		}
 
 		// Connect to daemon
 		client, err := daemon.NewClient()
@@ -52,7 +69,7 @@ Exit codes:
 		env := os.Environ()
 
 		// Start via daemon
-		job, err := client.Start(jobID, env)
+		job, err := client.Start(jobID, env, startEnvFrom)
 		if err != nil {
 			return err
 		}
@@ -72,7 +89,7 @@ Exit codes:
 			stuckTimeout := CalculateStuckTimeout(avgDurationMs)
 			fmt.Printf("  Stuck detection: timeout after %s\n", formatDuration(stuckTimeout))
 
-			followResult, err := followJob(jobID, job.PID, job.StdoutPath, avgDurationMs)
+			followResult, err := followJob(jobID, job.PID, job.StdoutPath, job.StartedAt, avgDurationMs, nil)
 			if err != nil {
 				return err
 			}
@@ -94,5 +111,8 @@ Exit codes:
 
 func init() {
 	startCmd.Flags().BoolVarP(&startFollow, "follow", "f", false, "Follow output until job completes")
+	startCmd.Flags().StringVar(&startEnvFrom, "env-from", daemon.EnvSourceClient,
+		"Environment of the new run: client, previous or stored")
+	startCmd.RegisterFlagCompletionFunc("env-from", completeEnvSources)
 	RootCmd.AddCommand(startCmd)
 }
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,44 +13,113 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var statsJSON bool
+var (
+	statsJSON  bool
+	statsAll   bool
+	statsSince string
+	statsTop   int
+	statsWhen  []string
+)
 
 var statsCmd = &cobra.Command{
 	Use:               "stats <job_id>",
-	Short:             "Show statistics for a job",
+	Short:             "Show statistics for a job, or for all jobs with --all",
 	ValidArgsFunction: completeJobIDs,
 	Long: `Show statistics for a job.
 
 Displays aggregated statistics across all runs of the specified job, including:
 - Total number of runs (successes, failures, and killed processes)
 - Success rate (percentage of runs with exit code 0)
-- Duration statistics for successes (average, minimum, maximum)
-- Duration statistics for failures (average)
+- Duration statistics for successes (average, fastest, slowest), used for
+  progress estimates and stuck detection
+- Duration statistics for failures (average, fastest, slowest), kept apart
+  so that quick failures do not skew the estimates
+- The fastest and slowest of all completed runs
+- Duration percentiles of successful runs (p50, p90, p99)
+- The last run's duration, flagged when it is at least 2x slower than the
+  median of the previous successful runs (once there are 5 of them)
+- How much the latest run printed, once it stopped
 
 Example output:
   Job: abc (make test)
   Total runs: 10
   Success rate: 70% (7/10)
-  Avg success duration: 2m30s
-  Avg failure duration: 15s
-  Fastest: 2m15s
-  Slowest: 2m45s
+  Avg success duration: 2m30s (fastest 2m15s, slowest 8m30s)
+  Avg failure duration: 15s (fastest 5s, slowest 40s)
+  Fastest: 5s
+  Slowest: 8m30s
+  Percentiles: p50 2m30s, p90 2m45s, p99 8m30s
+  Last run: 8m30s (3.4x slower than p50)
+  Last run output: 1204 lines (38.2 KB) on stdout, 3 lines (120 B) on stderr
+  By conditions:
+    ac       p50 2m20s, p90 2m40s (5 runs)
+    battery  p50 3m10s, p90 3m30s (2 runs)
+    idle     p50 2m25s, p90 2m45s (7 runs)
+
+Each run records the conditions of the machine when it started: on AC
+power or on battery (Linux laptops), and idle or busy (a 1-minute load
+average of at least 0.7 per CPU, on Linux). Successful runs are grouped by
+them under "By conditions", and --when only counts the runs started in all
+the given conditions, so the numbers are not skewed by runs on a busy or
+unplugged machine. A slow last run is compared to the previous runs
+started in the same conditions once there are 5 of them.
 
 With --json, outputs the full job response including statistics fields
 (run_count, success_count, failure_count, success_rate, avg_duration_ms,
-failure_avg_duration_ms, min_duration_ms, max_duration_ms) along with
-all standard job fields (id, status, command, etc.).
+failure_avg_duration_ms, min_duration_ms, max_duration_ms, p50_duration_ms,
+p90_duration_ms, p99_duration_ms, success_min_duration_ms,
+success_max_duration_ms, failure_min_duration_ms, failure_max_duration_ms,
+failure_p50_duration_ms, last_duration_ms, by_condition, output) along with all standard
+job fields (id, status, command, etc.). A slow last run sets "slowdown" to
+how many times slower than usual it was, so scripts can detect slow builds.
 
-Note: Statistics are calculated from completed runs only.
+Note: Statistics are calculated from completed runs only. Averages and
+counts include archived runs (see 'gob runs archive'); the fastest and
+slowest runs by outcome and the percentiles cover the runs not archived.
 Running jobs and killed processes are excluded from duration averages.
 Killed processes (sent SIGTERM/SIGKILL) still count toward total runs but
 not toward success/failure counts or duration statistics.
 
+With --all instead of a job ID, shows where the time goes across the jobs
+of the current directory: the time all their runs took in the last week
+(--since to change it), the jobs that took the most of it, the slowest
+jobs by average successful run and the jobs that failed most. Each list
+has the top 5 jobs (--top to change it).
+
+Example output:
+  Time spent in the last 7d: 1h42m across 58 runs
+
+  Most time spent:
+    abc  1h10m    12 runs   make test
+    def  25m      40 runs   npm run lint
+
+  Slowest:
+    abc  5m50s    make test
+    def  38s      npm run lint
+
+  Most failing:
+    def  14 failures (65% success)  npm run lint
+
+With --all --json, outputs the jobs (job_id, command, run_count,
+failure_count, success_rate, avg_duration_ms, recent_runs,
+recent_duration_ms) and total_duration_ms.
+
 Exit codes:
   0: Success
   1: Error (job not found)`,
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if statsAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsAll {
+			if len(statsWhen) > 0 {
+				return fmt.Errorf("--when cannot be used with --all")
+			}
+			return runStatsAll(cmd)
+		}
 		jobID := args[0]
 
 		// Connect to daemon
@@ -62,7 +134,7 @@ Exit codes:
 		}
 
 		// Get stats from daemon
-		job, err := client.Stats(jobID)
+		job, err := client.StatsIn(jobID, statsWhen)
 		if err != nil {
 			return err
 		}
@@ -77,6 +149,9 @@ Exit codes:
 		// Print stats in human-readable format
 		commandStr := strings.Join(job.Command, " ")
 		fmt.Printf("Job: %s (%s)\n", job.ID, commandStr)
+		if len(statsWhen) > 0 {
+			fmt.Printf("Runs started: %s\n", strings.Join(statsWhen, ", "))
+		}
 
 		if job.RunCount == 0 {
 			fmt.Println("No completed runs yet")
@@ -86,19 +161,165 @@ Exit codes:
 		fmt.Printf("Total runs: %d\n", job.RunCount)
 		fmt.Printf("Success rate: %.0f%% (%d/%d)\n", job.SuccessRate, job.SuccessCount, job.RunCount)
 		if job.SuccessCount > 0 {
-			fmt.Printf("Avg success duration: %s\n", formatDuration(time.Duration(job.AvgDurationMs)*time.Millisecond))
+			fmt.Printf("Avg success duration: %s%s\n", formatDuration(time.Duration(job.AvgDurationMs)*time.Millisecond),
+				formatDurationRange(job.SuccessMinDurationMs, job.SuccessMaxDurationMs))
 		}
 		if job.FailureCount > 0 {
-			fmt.Printf("Avg failure duration: %s\n", formatDuration(time.Duration(job.FailureAvgDurationMs)*time.Millisecond))
+			fmt.Printf("Avg failure duration: %s%s\n", formatDuration(time.Duration(job.FailureAvgDurationMs)*time.Millisecond),
+				formatDurationRange(job.FailureMinDurationMs, job.FailureMaxDurationMs))
 		}
 		fmt.Printf("Fastest: %s\n", formatDuration(time.Duration(job.MinDurationMs)*time.Millisecond))
 		fmt.Printf("Slowest: %s\n", formatDuration(time.Duration(job.MaxDurationMs)*time.Millisecond))
+		if job.SuccessCount > 0 {
+			fmt.Printf("Percentiles: p50 %s, p90 %s, p99 %s\n",
+				formatDuration(time.Duration(job.P50DurationMs)*time.Millisecond),
+				formatDuration(time.Duration(job.P90DurationMs)*time.Millisecond),
+				formatDuration(time.Duration(job.P99DurationMs)*time.Millisecond))
+		}
+		last := formatDuration(time.Duration(job.LastDurationMs) * time.Millisecond)
+		if job.Slowdown > 0 {
+			fmt.Printf("Last run: %s (%.1fx slower than p50)\n", last, job.Slowdown)
+		} else {
+			fmt.Printf("Last run: %s\n", last)
+		}
+		if job.Output != nil {
+			fmt.Printf("Last run output: %d lines (%s) on stdout, %d lines (%s) on stderr\n",
+				job.Output.StdoutLines, daemon.FormatBytes(job.Output.StdoutBytes),
+				job.Output.StderrLines, daemon.FormatBytes(job.Output.StderrBytes))
+		}
+		if len(job.ByCondition) > 0 {
+			fmt.Println("By conditions:")
+			for _, c := range job.ByCondition {
+				fmt.Printf("  %-7s  p50 %s, p90 %s (%s)\n", c.Condition,
+					formatDuration(time.Duration(c.P50DurationMs)*time.Millisecond),
+					formatDuration(time.Duration(c.P90DurationMs)*time.Millisecond), pluralRuns(c.RunCount))
+			}
+		}
 
 		return nil
 	},
 }
 
+// runStatsAll prints where the time goes across the jobs of the current
+// directory
+func runStatsAll(cmd *cobra.Command) error {
+	age, err := parseAge(statsSince)
+	if err != nil {
+		return err
+	}
+	if statsTop < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	// Connect to daemon
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	jobs, total, err := client.StatsAll(cwd, time.Now().Add(-age))
+	if err != nil {
+		return err
+	}
+
+	if statsJSON {
+		if jobs == nil {
+			jobs = []daemon.JobTimeSpent{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(daemon.StatsAllData{Jobs: jobs, TotalDurationMs: total})
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No jobs found")
+		return nil
+	}
+
+	recentRuns := 0
+	for _, job := range jobs {
+		recentRuns += job.RecentRuns
+	}
+	fmt.Printf("Time spent in the last %s: %s across %s\n", statsSince,
+		formatDuration(time.Duration(total)*time.Millisecond), pluralRuns(recentRuns))
+
+	// The daemon sorts by time spent
+	var spent []daemon.JobTimeSpent
+	for _, job := range jobs {
+		if job.RecentRuns > 0 {
+			spent = append(spent, job)
+		}
+	}
+	if len(spent) > 0 {
+		fmt.Println("\nMost time spent:")
+		for _, job := range spent[:min(statsTop, len(spent))] {
+			fmt.Printf("  %s  %-7s  %-8s  %s\n", job.JobID, formatDuration(time.Duration(job.RecentDurationMs)*time.Millisecond),
+				pluralRuns(job.RecentRuns), strings.Join(job.Command, " "))
+		}
+	}
+
+	slowest := slices.DeleteFunc(slices.Clone(jobs), func(job daemon.JobTimeSpent) bool { return job.AvgDurationMs == 0 })
+	slices.SortStableFunc(slowest, func(a, b daemon.JobTimeSpent) int { return cmp.Compare(b.AvgDurationMs, a.AvgDurationMs) })
+	if len(slowest) > 0 {
+		fmt.Println("\nSlowest:")
+		for _, job := range slowest[:min(statsTop, len(slowest))] {
+			fmt.Printf("  %s  %-7s  %s\n", job.JobID, formatDuration(time.Duration(job.AvgDurationMs)*time.Millisecond), strings.Join(job.Command, " "))
+		}
+	}
+
+	failing := slices.DeleteFunc(slices.Clone(jobs), func(job daemon.JobTimeSpent) bool { return job.FailureCount == 0 })
+	slices.SortStableFunc(failing, func(a, b daemon.JobTimeSpent) int { return cmp.Compare(b.FailureCount, a.FailureCount) })
+	if len(failing) > 0 {
+		fmt.Println("\nMost failing:")
+		for _, job := range failing[:min(statsTop, len(failing))] {
+			failures := fmt.Sprintf("%d failures", job.FailureCount)
+			if job.FailureCount == 1 {
+				failures = "1 failure"
+			}
+			fmt.Printf("  %s  %s (%.0f%% success)  %s\n", job.JobID, failures, job.SuccessRate, strings.Join(job.Command, " "))
+		}
+	}
+	return nil
+}
+
+// pluralRuns formats a number of runs, e.g. "1 run" or "12 runs"
+func pluralRuns(n int) string {
+	if n == 1 {
+		return "1 run"
+	}
+	return fmt.Sprintf("%d runs", n)
+}
+
+// formatDurationRange formats the fastest and slowest of some runs, or
+// returns "" if there are none
+func formatDurationRange(minMs, maxMs int64) string {
+	if maxMs == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (fastest %s, slowest %s)",
+		formatDuration(time.Duration(minMs)*time.Millisecond),
+		formatDuration(time.Duration(maxMs)*time.Millisecond))
+}
+
 func init() {
 	RootCmd.AddCommand(statsCmd)
 	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Output in JSON format")
+	statsCmd.Flags().BoolVarP(&statsAll, "all", "a", false,
+		"Show where the time goes across all jobs of the current directory")
+	statsCmd.Flags().StringVarP(&statsSince, "since", "s", "7d",
+		"With --all, count the time spent within this long (e.g. 24h, 30d)")
+	statsCmd.Flags().IntVar(&statsTop, "top", 5,
+		"With --all, the number of jobs in each list")
+	statsCmd.Flags().StringSliceVarP(&statsWhen, "when", "w", nil,
+		"Only count runs started in these conditions: ac, battery, idle or busy")
+	statsCmd.RegisterFlagCompletionFunc("when", cobra.FixedCompletions(daemon.RunConditions, cobra.ShellCompDirectiveNoFileComp))
 }
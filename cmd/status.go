@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// statusTailLines is the number of stderr lines shown for failed jobs
+const statusTailLines = 3
+
+var statusJSON bool
+
+// statusJob is a job in the status snapshot
+type statusJob struct {
+	ID          string   `json:"id"`
+	Command     string   `json:"command"`
+	Description string   `json:"description,omitempty"`
+	Since       string   `json:"since"` // start of running jobs, end of stopped ones
+	Progress    string   `json:"progress,omitempty"`
+	Ports       []uint16 `json:"ports,omitempty"`
+	ExitCode    *int     `json:"exit_code,omitempty"`
+	StderrTail  []string `json:"stderr_tail,omitempty"`
+}
+
+// statusData is the status snapshot of a directory
+type statusData struct {
+	Workdir     string      `json:"workdir"`
+	Running     []statusJob `json:"running"`
+	Failed      []statusJob `json:"failed"`
+	Stopped     int         `json:"stopped"` // stopped jobs whose last run succeeded or never ran
+	Suggestions []string    `json:"suggestions"`
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a snapshot of the jobs in the current directory",
+	Long: `Show what is going on in the current directory in one call: running jobs
+with their listening ports and progress, jobs whose last run failed with
+the end of their stderr, and suggested next commands.
+
+It is meant for agents orienting themselves at the start of a task,
+instead of chaining 'gob list', 'gob stats' and 'gob ports'.
+
+Example output:
+  Running (2)
+    abc  npm run dev  since 2 hours ago  ports: 3000
+    def  make watch   since just now  ~40% of typical duration, ETA 12s
+
+  Failed (1)
+    ghi  make test  exit 2, 5 min ago
+      FAIL src/app.test.ts
+
+  1 other stopped job
+
+  Suggested
+    gob logs ghi    # see why make test failed
+    gob retry ghi   # run make test again
+    gob await def   # wait for make watch to finish
+
+Exit codes:
+  0: Success
+  1: Error`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		jobs, err := client.List(cwd)
+		if err != nil {
+			return fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		// Ports are looked up now rather than taken from the daemon's cache
+		ports := make(map[string][]uint16)
+		if allPorts, err := client.AllPorts(cwd); err == nil {
+			for _, jp := range allPorts {
+				for _, p := range jp.Ports {
+					ports[jp.JobID] = append(ports[jp.JobID], p.Port)
+				}
+			}
+		}
+
+		status := buildStatus(cwd, jobs, ports)
+
+		if statusJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+
+		printStatus(status)
+		return nil
+	},
+}
+
+// buildStatus sorts the jobs of a directory into the status snapshot.
+// ports are the listening ports of running jobs by job ID.
+func buildStatus(workdir string, jobs []daemon.JobResponse, ports map[string][]uint16) statusData {
+	status := statusData{Workdir: workdir, Running: []statusJob{}, Failed: []statusJob{}, Suggestions: []string{}}
+
+	for _, job := range jobs {
+		s := statusJob{ID: job.ID, Command: strings.Join(job.Command, " "), Description: job.Description}
+
+		switch {
+		case job.Status == "running":
+			started := parseRunStart(job.StartedAt)
+			s.Since = formatRelativeTime(started)
+			s.Ports = ports[job.ID]
+			if job.SuccessCount >= 3 && job.AvgDurationMs > 0 {
+				s.Progress = formatProgressEstimate(time.Since(started), time.Duration(job.AvgDurationMs)*time.Millisecond)
+			}
+			status.Running = append(status.Running, s)
+
+			// Jobs that finish on their own are worth waiting for; servers are not
+			if len(s.Ports) == 0 && job.SuccessCount > 0 {
+				status.Suggestions = append(status.Suggestions,
+					fmt.Sprintf("gob await %s   # wait for %s to finish", job.ID, s.Command))
+			}
+
+		case job.ExitCode != nil && *job.ExitCode != 0:
+			if stopped, err := time.Parse(time.RFC3339, job.StoppedAt); err == nil {
+				s.Since = formatRelativeTime(stopped)
+			}
+			s.ExitCode = job.ExitCode
+			if job.StdoutPath != "" {
+				stderrPath := strings.Replace(job.StdoutPath, ".stdout.log", ".stderr.log", 1)
+				s.StderrTail = lastLines(stderrPath, statusTailLines)
+			}
+			status.Failed = append(status.Failed, s)
+			status.Suggestions = append(status.Suggestions,
+				fmt.Sprintf("gob logs %s    # see why %s failed", job.ID, s.Command),
+				fmt.Sprintf("gob retry %s   # run %s again", job.ID, s.Command))
+
+		default:
+			status.Stopped++
+		}
+	}
+
+	if len(jobs) == 0 {
+		status.Suggestions = append(status.Suggestions,
+			"gob add <command>   # start a server or watcher in the background",
+			"gob run <command>   # run a build or test and wait for it")
+	}
+
+	return status
+}
+
+// printStatus prints the status snapshot
+func printStatus(status statusData) {
+	if len(status.Running) == 0 && len(status.Failed) == 0 && status.Stopped == 0 {
+		fmt.Printf("No jobs in %s\n\n", status.Workdir)
+	}
+
+	if len(status.Running) > 0 {
+		fmt.Printf("Running (%d)\n", len(status.Running))
+		for _, job := range status.Running {
+			line := fmt.Sprintf("  %s  %s  since %s", job.ID, job.Command, job.Since)
+			if len(job.Ports) > 0 {
+				ports := make([]string, len(job.Ports))
+				for i, p := range job.Ports {
+					ports[i] = fmt.Sprintf("%d", p)
+				}
+				line += "  ports: " + strings.Join(ports, ", ")
+			}
+			if job.Progress != "" {
+				line += "  " + job.Progress
+			}
+			fmt.Println(line)
+			if job.Description != "" {
+				fmt.Printf("       %s\n", job.Description)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(status.Failed) > 0 {
+		fmt.Printf("Failed (%d)\n", len(status.Failed))
+		for _, job := range status.Failed {
+			fmt.Printf("  %s  %s  exit %d, %s\n", job.ID, job.Command, *job.ExitCode, job.Since)
+			for _, line := range job.StderrTail {
+				fmt.Printf("    %s\n", output.Colorize(os.Stdout, output.Yellow, line))
+			}
+		}
+		fmt.Println()
+	}
+
+	switch status.Stopped {
+	case 0:
+	case 1:
+		fmt.Print("1 other stopped job\n\n")
+	default:
+		fmt.Printf("%d other stopped jobs\n\n", status.Stopped)
+	}
+
+	if len(status.Suggestions) > 0 {
+		fmt.Println("Suggested")
+		for _, s := range status.Suggestions {
+			fmt.Printf("  %s\n", s)
+		}
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false,
+		"Output in JSON format")
+}
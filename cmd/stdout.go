@@ -1,18 +1,13 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	"github.com/juanibiapina/gob/internal/daemon"
-	"github.com/juanibiapina/gob/internal/tail"
 	"github.com/spf13/cobra"
 )
 
-var followStdout bool
+var stdoutOpts streamOptions
 
 var stdoutCmd = &cobra.Command{
-	Use:               "stdout <job_id>",
+	Use:               "stdout [job_id | --run <run_id>]",
 	Short:             "Display stdout output for a job",
 	ValidArgsFunction: completeJobIDs,
 	Long: `Display the raw stdout output for a background job.
@@ -28,61 +23,36 @@ Example:
   # Follow stdout in real-time
   gob stdout -f V3x0QqI
 
+  # The last 20 lines, then follow
+  gob stdout -n 20 -f V3x0QqI
+
+  # Stdout of an earlier run (see gob runs)
+  gob stdout --run V3x0QqI-2
+
 Notes:
   - Output is raw with no prefixes (unlike the logs command)
-  - Shows the complete output from the beginning
-  - Use -f/--follow to stream output in real-time
+  - Shows the complete output from the beginning, or the last N lines with
+    -n/--tail. Printing more than 10000 lines of a stopped run without
+    --tail warns on stderr first
+  - Use -f/--follow to stream output in real-time. A run that has finished
+    is printed without following.
+  - Use --run to read a specific run instead of the current or latest one
+  - Use --clean to drop cursor movement and progress bar redraws, for
+    output read outside a terminal
+  - Binary output (tarballs, protobuf dumps) is replaced with a line giving
+    its size, e.g. [binary output: 10240 bytes]. Use --raw to print it as
+    it is, e.g. to redirect it to a file
 
 Exit codes:
   0: Output displayed successfully
-  1: Error (job not found, log file not available)`,
-	Args: cobra.ExactArgs(1),
+  1: Error (job or run not found, log file not available)`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
-
-		// Connect to daemon
-		client, err := daemon.NewClient()
-		if err != nil {
-			return fmt.Errorf("failed to create client: %w", err)
-		}
-		defer client.Close()
-
-		if err := client.Connect(); err != nil {
-			return fmt.Errorf("failed to connect to daemon: %w", err)
-		}
-
-		// Get job from daemon
-		job, err := client.GetJob(jobID)
-		if err != nil {
-			return err
-		}
-
-		stdoutPath := job.StdoutPath
-
-		// Check if stdout file exists
-		if _, err := os.Stat(stdoutPath); os.IsNotExist(err) {
-			return fmt.Errorf("stdout log file not found: %s", stdoutPath)
-		}
-
-		// If follow flag is set, follow the log file in real-time
-		if followStdout {
-			return tail.Follow(stdoutPath, os.Stdout)
-		}
-
-		// Read and display the stdout file
-		content, err := os.ReadFile(stdoutPath)
-		if err != nil {
-			return fmt.Errorf("failed to read stdout log: %w", err)
-		}
-
-		// Print the content (could be empty if no output yet)
-		fmt.Print(string(content))
-
-		return nil
+		return showStream(args, "stdout", stdoutOpts)
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(stdoutCmd)
-	stdoutCmd.Flags().BoolVarP(&followStdout, "follow", "f", false, "Follow log output in real-time")
+	addStreamFlags(stdoutCmd, &stdoutOpts)
 }
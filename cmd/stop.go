@@ -7,17 +7,25 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var forceStop bool
+var (
+	forceStop    bool
+	stopSelector batchSelector
+)
 
 var stopCmd = &cobra.Command{
-	Use:               "stop <job_id>",
+	Use:               "stop <job_id>... | --match <pattern> | --tag <tag>",
 	Short:             "Stop a background job",
-	ValidArgsFunction: completeJobIDs,
-	Long: `Stop a background job by sending a signal to terminate it.
+	ValidArgsFunction: completeManyJobIDs,
+	Long: `Stop background jobs by sending a signal to terminate them.
 
 By default, sends SIGTERM for graceful shutdown.
 Use --force to send SIGKILL for immediate termination.
 
+Several jobs can be stopped at once by passing multiple IDs, or by selecting
+running jobs in the current directory with --match (a glob pattern matched
+against the command, '*' matches anything) or --tag. All jobs are stopped
+in a single request to the daemon.
+
 Use 'job list' to find job IDs.
 
 Examples:
@@ -27,6 +35,15 @@ Examples:
   # Forcefully kill a stubborn job
   gob stop V3x0QqI --force
 
+  # Stop several jobs
+  gob stop abc def
+
+  # Stop every running npm script in the current directory
+  gob stop --match "npm run *"
+
+  # Stop every running job tagged "backend" in the current directory
+  gob stop --tag backend
+
 Output:
   Stopped job <job_id> (PID <pid>)
 
@@ -41,10 +58,8 @@ Notes:
 Exit codes:
   0: Job stopped successfully (or already stopped)
   1: Error (job not found, failed to send signal)`,
-	Args: cobra.ExactArgs(1),
+	Args: batchArgs(&stopSelector, 0),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
-
 		// Connect to daemon
 		client, err := daemon.NewClient()
 		if err != nil {
@@ -56,24 +71,37 @@ Exit codes:
 			return fmt.Errorf("failed to connect to daemon: %w", err)
 		}
 
+		if len(args) > 1 || stopSelector.isSet() {
+			b := daemon.BatchRequest{Action: daemon.BatchStop, JobIDs: args, Force: forceStop}
+			return runBatch(client, &stopSelector, b, func(r daemon.BatchResult) {
+				printStopped(r.JobID, r.PID)
+			})
+		}
+
+		jobID := args[0]
+
 		// Stop via daemon
 		pid, err := client.Stop(jobID, forceStop)
 		if err != nil {
 			return fmt.Errorf("job not found: %s", jobID)
 		}
 
-		// Print confirmation
-		if forceStop {
-			fmt.Printf("Force stopped job %s (PID %d)\n", jobID, pid)
-		} else {
-			fmt.Printf("Stopped job %s (PID %d)\n", jobID, pid)
-		}
-
+		printStopped(jobID, pid)
 		return nil
 	},
 }
 
+// printStopped prints the confirmation for a stopped job
+func printStopped(jobID string, pid int) {
+	if forceStop {
+		fmt.Printf("Force stopped job %s (PID %d)\n", jobID, pid)
+	} else {
+		fmt.Printf("Stopped job %s (PID %d)\n", jobID, pid)
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(stopCmd)
 	stopCmd.Flags().BoolVarP(&forceStop, "force", "f", false, "Send SIGKILL instead of SIGTERM for forceful termination")
+	addBatchFlags(stopCmd, &stopSelector, "Stop running")
 }
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/tail"
+	"github.com/spf13/cobra"
+)
+
+// streamOptions are the flags of gob stdout and gob stderr
+type streamOptions struct {
+	follow bool
+	run    string // a run ID, instead of the job's current or latest run
+	tail   int    // only the last lines, 0 for all
+	clean  bool   // remove cursor movement and progress bar redraws
+	raw    bool   // print binary output instead of a placeholder
+}
+
+// addStreamFlags registers the flags of gob stdout and gob stderr
+func addStreamFlags(cmd *cobra.Command, opts *streamOptions) {
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "Follow log output in real-time")
+	cmd.Flags().StringVar(&opts.run, "run", "", "Show the output of this run (e.g. abc-2) instead of the latest one")
+	cmd.Flags().IntVarP(&opts.tail, "tail", "n", 0, "Only show the last N lines")
+	cmd.Flags().BoolVar(&opts.clean, "clean", false, "Remove cursor movement and keep the last state of progress bars")
+	cmd.Flags().BoolVar(&opts.raw, "raw", false, "Print binary output as it is instead of its size")
+	cmd.RegisterFlagCompletionFunc("run", completeRunIDs)
+}
+
+// showStream prints one stream ("stdout" or "stderr") of a job's current or
+// latest run, or of the run given with --run
+func showStream(args []string, stream string, opts streamOptions) error {
+	if opts.tail < 0 {
+		return fmt.Errorf("--tail must not be negative")
+	}
+	if (len(args) == 1) == (opts.run != "") {
+		return fmt.Errorf("give either a job ID or --run")
+	}
+
+	client, err := daemon.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+
+	var path string
+	var output *daemon.OutputStats // counted once the run stopped
+	running := true
+	if opts.run != "" {
+		run, err := findRun(client, opts.run)
+		if err != nil {
+			return err
+		}
+		path = run.StdoutPath
+		if stream == "stderr" {
+			path = run.StderrPath
+		}
+		running = run.Status == "running"
+		output = run.Output
+	} else {
+		job, err := client.GetJob(args[0])
+		if err != nil {
+			return err
+		}
+		path = job.StdoutPath
+		if stream == "stderr" {
+			path = job.StderrPath
+		}
+		output = job.Output
+	}
+
+	// Warn before dumping a huge output, e.g. into the context of an agent
+	if output != nil && opts.tail == 0 {
+		lines, size := output.StdoutLines, output.StdoutBytes
+		if stream == "stderr" {
+			lines, size = output.StderrLines, output.StderrBytes
+		}
+		if lines >= daemon.HugeOutputLines {
+			fmt.Fprintf(os.Stderr, "gob: printing %d lines (%s) of %s, use --tail N for the last lines\n", lines, daemon.FormatBytes(size), stream)
+		}
+	}
+
+	content, err := logfile.ReadFile(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s log file not found: %s", stream, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s log: %w", stream, err)
+	}
+
+	start := 0
+	if opts.tail > 0 {
+		start = lastLinesOffset(content, opts.tail)
+	}
+
+	// A finished run has no more output to follow
+	if opts.follow && running {
+		if opts.clean {
+			return tail.FollowFrom(path, int64(start), daemon.NewCleanWriter(os.Stdout))
+		}
+		return tail.FollowFrom(path, int64(start), os.Stdout)
+	}
+
+	// Binary output would garble the terminal
+	if !opts.raw && logfile.IsBinary(content[start:]) {
+		fmt.Println(logfile.BinaryPlaceholder(len(content)-start) + " (use --raw to print it)")
+		return nil
+	}
+
+	// Print the content (could be empty if no output yet)
+	if opts.clean {
+		fmt.Print(daemon.CleanOutput(string(content[start:])))
+		return nil
+	}
+	os.Stdout.Write(content[start:])
+	return nil
+}
+
+// lastLinesOffset returns the offset where the last n lines of data start.
+// A last line without a trailing newline counts as a line.
+func lastLinesOffset(data []byte, n int) int {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for ; n > 0; n-- {
+		i := bytes.LastIndexByte(data[:end], '\n')
+		if i < 0 {
+			return 0
+		}
+		end = i
+	}
+	return end + 1
+}
+
+// findRun looks up a run by its ID, which is its job ID and run number
+// (e.g. abc-2)
+func findRun(client *daemon.Client, runID string) (*daemon.RunResponse, error) {
+	sep := strings.LastIndex(runID, "-")
+	if sep <= 0 {
+		return nil, fmt.Errorf("invalid run ID: %s", runID)
+	}
+
+	runs, err := client.Runs(runID[:sep])
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		if runs[i].ID == runID {
+			return &runs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("run not found: %s", runID)
+}
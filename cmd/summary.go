@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var (
+	summaryLabel string
+	summaryJSON  bool
+)
+
+// Verdicts of a label's runs
+const (
+	verdictPassed  = "passed"
+	verdictFailed  = "failed"
+	verdictRunning = "running"
+)
+
+// summaryData is the outcome of the runs with a label
+type summaryData struct {
+	Label     string                `json:"label"`
+	Verdict   string                `json:"verdict"` // passed, failed or running
+	Passed    int                   `json:"passed"`
+	Failed    int                   `json:"failed"`
+	Running   int                   `json:"running"`
+	ElapsedMs int64                 `json:"elapsed_ms"` // from the first start to the last stop (or now)
+	Runs      []daemon.HistoryEntry `json:"runs"`       // latest run of each job, oldest first
+}
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary --label <label>",
+	Short: "Show one verdict for the runs started with a label",
+	Long: `Aggregate the runs started with 'gob run --label' or 'gob add --label'
+across jobs and directories, like the checks of a CI pipeline.
+
+Only the latest labelled run of each job counts, so a check that failed and
+was run again with the same label is judged by its last attempt (see
+'gob history --label' for every attempt). The verdict is failed if any run
+failed, running if none failed but some are still running, and passed
+otherwise.
+
+Examples:
+  # Fan out the checks of a pull request, then get one verdict
+  gob add --label pr-1234 make test
+  gob add --label pr-1234 make lint
+  gob summary --label pr-1234
+
+Example output:
+  ✓ abc-3  12s       make test
+  ✗ def-1  45s       make lint (exit 2)
+  ◉ ghi-2  running   make e2e
+
+  pr-1234: failed (1 passed, 1 failed, 1 running) in 57s
+
+Exit codes:
+  0: All runs passed
+  1: A run failed, or error (no runs with the label)
+  2: No run failed, but some are still running`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if summaryLabel == "" {
+			return fmt.Errorf("--label is required")
+		}
+
+		// Connect to daemon
+		client, err := daemon.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		defer client.Close()
+
+		if err := client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+
+		entries, _, err := client.History("", summaryLabel, 0, 0)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("no runs with label %s", summaryLabel)
+		}
+
+		summary := summarizeRuns(summaryLabel, entries, time.Now())
+
+		if summaryJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(summary); err != nil {
+				return err
+			}
+		} else {
+			printSummary(summary)
+		}
+
+		switch summary.Verdict {
+		case verdictFailed:
+			os.Exit(1)
+		case verdictRunning:
+			os.Exit(2)
+		}
+		return nil
+	},
+}
+
+// summarizeRuns aggregates the latest run of each job among entries, which
+// are newest first
+func summarizeRuns(label string, entries []daemon.HistoryEntry, now time.Time) summaryData {
+	summary := summaryData{Label: label}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if seen[entry.JobID] {
+			continue
+		}
+		seen[entry.JobID] = true
+		summary.Runs = append(summary.Runs, entry)
+	}
+	sort.SliceStable(summary.Runs, func(i, j int) bool {
+		return summary.Runs[i].StartedAt < summary.Runs[j].StartedAt
+	})
+
+	var first, last time.Time
+	for _, run := range summary.Runs {
+		switch {
+		case run.Status == "running":
+			summary.Running++
+		case run.ExitCode != nil && *run.ExitCode == 0:
+			summary.Passed++
+		default:
+			summary.Failed++
+		}
+
+		started, _ := time.Parse(time.RFC3339, run.StartedAt)
+		end := now
+		if run.Status != "running" {
+			end = started.Add(time.Duration(run.DurationMs) * time.Millisecond)
+		}
+		if first.IsZero() || started.Before(first) {
+			first = started
+		}
+		if end.After(last) {
+			last = end
+		}
+	}
+	summary.ElapsedMs = last.Sub(first).Milliseconds()
+
+	switch {
+	case summary.Failed > 0:
+		summary.Verdict = verdictFailed
+	case summary.Running > 0:
+		summary.Verdict = verdictRunning
+	default:
+		summary.Verdict = verdictPassed
+	}
+	return summary
+}
+
+// printSummary prints the runs of a summary and its verdict, like run-all
+func printSummary(summary summaryData) {
+	okMark := output.Colorize(os.Stdout, output.Green, "✓")
+	failMark := output.Colorize(os.Stdout, output.Red, "✗")
+
+	for _, run := range summary.Runs {
+		commandStr := strings.Join(run.Command, " ")
+		duration := formatDuration(time.Duration(run.DurationMs) * time.Millisecond)
+		switch {
+		case run.Status == "running":
+			fmt.Printf("◉ %s  %-8s  %s\n", run.ID, "running", commandStr)
+		case run.ExitCode != nil && *run.ExitCode == 0:
+			fmt.Printf("%s %s  %-8s  %s\n", okMark, run.ID, duration, commandStr)
+		case run.ExitCode != nil:
+			fmt.Printf("%s %s  %-8s  %s (exit %d)\n", failMark, run.ID, duration, commandStr, *run.ExitCode)
+		default:
+			fmt.Printf("%s %s  %-8s  %s (killed)\n", failMark, run.ID, duration, commandStr)
+		}
+	}
+
+	fmt.Printf("\n%s: %s (%d passed, %d failed, %d running) in %s\n", summary.Label, summary.Verdict,
+		summary.Passed, summary.Failed, summary.Running, formatDuration(time.Duration(summary.ElapsedMs)*time.Millisecond))
+}
+
+func init() {
+	RootCmd.AddCommand(summaryCmd)
+	summaryCmd.Flags().StringVarP(&summaryLabel, "label", "l", "",
+		"Label of the runs to summarize")
+	summaryCmd.Flags().BoolVar(&summaryJSON, "json", false,
+		"Output in JSON format")
+}
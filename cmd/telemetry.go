@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juanibiapina/gob/internal/telemetry"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Show or change whether usage statistics are sent",
+	Long: `Show or change whether gob sends anonymous usage statistics.
+
+gob asks once, the first time a command runs in a terminal. Until then no
+statistics are recorded. The answer is saved in
+$XDG_CONFIG_HOME/gob/telemetry.toml.
+
+Modes:
+  on     Send usage statistics
+  off    Record nothing
+  local  Write the events to $XDG_STATE_HOME/gob/telemetry.jsonl instead of
+         sending them, to see what would be sent
+
+The environment overrides the saved mode: GOB_TELEMETRY=on|off|local, and
+GOB_TELEMETRY_DISABLED=1 or DO_NOT_TRACK=1 turn telemetry off.
+
+See docs/telemetry.md for what is collected.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return telemetryStatusCmd.RunE(cmd, args)
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage statistics are sent",
+	Long: `Show the telemetry mode in effect and where it comes from.
+
+Example output:
+  Telemetry: local (from config)
+  Events are written to /home/me/.local/state/gob/telemetry.jsonl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, source, err := telemetry.Mode()
+		if err != nil {
+			return err
+		}
+
+		switch source {
+		case telemetry.SourceUnset:
+			fmt.Printf("Telemetry: %s (not chosen yet)\n", mode)
+		default:
+			fmt.Printf("Telemetry: %s (from %s)\n", mode, source)
+		}
+		if mode == telemetry.ModeLocal {
+			fmt.Printf("Events are written to %s\n", telemetry.LocalPath())
+		}
+		return nil
+	},
+}
+
+// newTelemetryModeCmd returns the command that saves a telemetry mode
+func newTelemetryModeCmd(mode, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   mode,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := telemetry.SaveMode(mode); err != nil {
+				return fmt.Errorf("failed to save telemetry mode: %w", err)
+			}
+			fmt.Printf("Telemetry: %s\n", mode)
+			if _, source, _ := telemetry.Mode(); source == telemetry.SourceEnv {
+				fmt.Fprintln(os.Stderr, "Note: the environment overrides it (GOB_TELEMETRY, GOB_TELEMETRY_DISABLED or DO_NOT_TRACK)")
+			}
+			return nil
+		},
+	}
+}
+
+// askTelemetryConsent asks whether to send usage statistics the first time
+// a command runs in a terminal. Commands run by scripts, shells and
+// completion never ask.
+func askTelemetryConsent(cmd *cobra.Command) {
+	if skipTelemetry[cmd.Name()] && cmd.Name() != "tui" {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == telemetryCmd || c.Name() == "completion" {
+			return
+		}
+	}
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stderr.Fd()) {
+		return
+	}
+	if _, source, err := telemetry.Mode(); err != nil || source != telemetry.SourceUnset {
+		return
+	}
+
+	if _, err := telemetry.AskConsent(os.Stdin, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to save telemetry mode: %v\n", err)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+	telemetryCmd.AddCommand(newTelemetryModeCmd(telemetry.ModeOn, "Send usage statistics"))
+	telemetryCmd.AddCommand(newTelemetryModeCmd(telemetry.ModeOff, "Record no usage statistics"))
+	telemetryCmd.AddCommand(newTelemetryModeCmd(telemetry.ModeLocal, "Write usage statistics to a local file instead of sending them"))
+}
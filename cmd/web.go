@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	webAll  bool
+	webAddr string
+)
+
+var webCmd = &cobra.Command{
+	Use:   "web",
+	Short: "Show jobs in a web dashboard",
+	Long: `Print the address of a read-only web dashboard for the daemon's jobs.
+
+The dashboard lists jobs, updating as they change, and shows the run history
+and live logs of the selected job. It is served by the daemon's HTTP
+gateway, which is started if it is not running (see gob gateway).
+
+By default, only shows jobs in the current directory.
+Use --all to see jobs from all directories.
+
+The printed address includes the gateway's token: anyone with it can use
+the gateway, so only share it with people you would let run commands.
+
+Output:
+  Dashboard at http://127.0.0.1:7070/?token=...&workdir=/path/to/project
+
+Exit codes:
+  0: Dashboard available
+  1: Error (address in use, connection failed)`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := connectGateway()
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		info, err := client.GatewayStatus()
+		if err != nil {
+			return err
+		}
+		if info == nil || (webAddr != "" && webAddr != info.Addr) {
+			info, err = client.GatewayStart(webAddr)
+			if err != nil {
+				return err
+			}
+		}
+
+		query := url.Values{"token": {info.Token}}
+		if !webAll {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+			query.Set("workdir", cwd)
+		}
+
+		fmt.Printf("Dashboard at %s/?%s\n", info.URL, query.Encode())
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(webCmd)
+	webCmd.Flags().BoolVarP(&webAll, "all", "a", false,
+		"Show jobs from all directories")
+	webCmd.Flags().StringVar(&webAddr, "addr", "",
+		"Address of the gateway (default: the running gateway, or 127.0.0.1:7070)")
+}
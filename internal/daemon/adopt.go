@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// AdoptProcess registers a process started outside gob as a run of the job
+// for command in workdir (the process's command line if command is empty),
+// creating the job if needed. The process keeps writing its output where it
+// did, the run's log files stay empty. Its exit is noticed by the supervisor.
+func (jm *JobManager) AdoptProcess(pid int, command []string, workdir string) (*Job, error) {
+	if pid <= 0 || pid == os.Getpid() {
+		return nil, fmt.Errorf("invalid pid %d", pid)
+	}
+	info, err := getProcessInfo(pid)
+	if err != nil || !processExists(pid) {
+		return nil, fmt.Errorf("process %d not found", pid)
+	}
+	if len(command) == 0 {
+		command = info.Command
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("command of process %d is unknown, give one with --command", pid)
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	if jm.handedOver {
+		return nil, errHandedOver
+	}
+	for _, run := range jm.runs {
+		if run.PID == pid && run.IsRunning() {
+			return nil, fmt.Errorf("process %d is already run by job %s", pid, run.JobID)
+		}
+	}
+
+	job, err := jm.createJobLocked(command, workdir, JobOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if job.IsRunning() {
+		return nil, fmt.Errorf("job %s is already running", job.ID)
+	}
+
+	runID := fmt.Sprintf("%s-%d", job.ID, job.NextRunSeq)
+	stdoutPath, stderrPath, err := jm.runLogPaths(job, runID)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range []string{stdoutPath, stderrPath} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			return nil, fmt.Errorf("failed to create log file: %w", err)
+		}
+	}
+	job.NextRunSeq++
+
+	run := &Run{
+		ID:         runID,
+		JobID:      job.ID,
+		PID:        pid,
+		Status:     "running",
+		StdoutPath: stdoutPath,
+		StderrPath: stderrPath,
+		StartedAt:  info.StartTime,
+		process: &adoptedProcessHandle{
+			pid:    pid,
+			runID:  runID,
+			exited: jm.supervisor.watch(pid),
+		},
+	}
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now()
+	}
+
+	jm.addRunLocked(run)
+	job.CurrentRunID = &runID
+
+	// Persist run to database
+	if jm.store != nil {
+		if err := jm.store.InsertRun(run); err != nil {
+			Logger.Warn("failed to persist run", "id", run.ID, "error", err)
+		}
+		if err := jm.store.UpdateJob(job); err != nil {
+			Logger.Warn("failed to update job", "id", job.ID, "error", err)
+		}
+	}
+
+	jm.watchRun(job, run)
+	jm.schedulePortPolling(job, run)
+
+	Logger.Info("adopted process", "job", job.ID, "pid", pid)
+
+	jm.emitEvent(Event{
+		Type:            EventTypeJobStarted,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+	runResp := runToResponse(run)
+	jm.emitEvent(Event{
+		Type:            EventTypeRunStarted,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return job, nil
+}
+
+// handleAdopt handles an adopt request
+func (d *Daemon) handleAdopt(req *Request) *Response {
+	var p AdoptPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.PID == 0 {
+		return NewErrorResponse(fmt.Errorf("missing pid"))
+	}
+	if p.Workdir == "" {
+		return NewErrorResponse(fmt.Errorf("missing workdir"))
+	}
+
+	job, err := d.jobManager.AdoptProcess(p.PID, p.Command, p.Workdir)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
+}
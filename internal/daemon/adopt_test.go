@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJobManager_AdoptProcess(t *testing.T) {
+	var events []EventType
+	jm := NewJobManagerWithExecutor(t.TempDir(), func(e Event) { events = append(events, e.Type) }, NewFakeProcessExecutor(), nil)
+	jm.supervisor = newSupervisor(10 * time.Millisecond)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	pid := cmd.Process.Pid
+
+	job, err := jm.AdoptProcess(pid, nil, "/workdir")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(job.Command, []string{"sleep", "30"}) || job.Workdir != "/workdir" {
+		t.Errorf("expected a job for the process's command line, got %v in %s", job.Command, job.Workdir)
+	}
+	run := jm.GetCurrentRun(job.ID)
+	if run == nil || run.PID != pid || !job.IsRunning() {
+		t.Fatalf("expected a running run of PID %d, got %+v", pid, run)
+	}
+	if time.Since(run.StartedAt) > 5*time.Second {
+		t.Errorf("expected the process's start time, got %v", run.StartedAt)
+	}
+	if _, err := os.Stat(run.StdoutPath); err != nil {
+		t.Errorf("expected an empty stdout log, got %v", err)
+	}
+	if !reflect.DeepEqual(events, []EventType{EventTypeJobAdded, EventTypeJobStarted, EventTypeRunStarted}) {
+		t.Errorf("unexpected events: %v", events)
+	}
+
+	if _, err := jm.AdoptProcess(pid, []string{"other"}, "/workdir"); err == nil {
+		t.Error("expected an error adopting a process that is already a run")
+	}
+
+	cmd.Process.Kill()
+	cmd.Wait()
+	deadline := time.Now().Add(5 * time.Second)
+	for jm.GetCurrentRun(job.ID) != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if jm.GetCurrentRun(job.ID) != nil {
+		t.Fatal("expected the job to stop with its process")
+	}
+}
+
+func TestJobManager_AdoptProcessCommand(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	// An existing stopped job gets the process as its next run
+	existing, err := jm.CreateJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := jm.AdoptProcess(cmd.Process.Pid, []string{"npm", "run", "dev"}, "/workdir")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if job.ID != existing.ID || jm.GetCurrentRun(job.ID).ID != job.ID+"-1" {
+		t.Errorf("expected the process to become the first run of job %s", existing.ID)
+	}
+
+	// A running job is not given a second process
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		other.Process.Kill()
+		other.Wait()
+	})
+	if _, err := jm.AdoptProcess(other.Process.Pid, []string{"npm", "run", "dev"}, "/workdir"); err == nil {
+		t.Error("expected an error adopting a process for a running job")
+	}
+}
+
+func TestJobManager_AdoptProcessErrors(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pid := range []int{-1, os.Getpid(), exited.Process.Pid} {
+		if _, err := jm.AdoptProcess(pid, nil, "/workdir"); err == nil {
+			t.Errorf("expected an error adopting PID %d", pid)
+		}
+	}
+	if len(jm.jobs) != 0 {
+		t.Errorf("expected no job, got %d", len(jm.jobs))
+	}
+}
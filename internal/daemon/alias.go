@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// aliasPattern restricts aliases to names that are easy to type in a shell
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// maxAliasLength is the maximum length of a job alias
+const maxAliasLength = 64
+
+// ValidateAlias checks that an alias is a usable job name
+func ValidateAlias(alias string) error {
+	if len(alias) > maxAliasLength {
+		return fmt.Errorf("alias too long (max %d characters)", maxAliasLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("invalid alias %q (use letters, digits, '.', '_' and '-')", alias)
+	}
+	return nil
+}
+
+// ResolveJobID returns the ID of the job identified by an ID or alias.
+// IDs take precedence over aliases. Unknown values are returned unchanged
+// so that callers report them as "job not found".
+func (jm *JobManager) ResolveJobID(idOrAlias string) string {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	return jm.resolveJobIDLocked(idOrAlias)
+}
+
+// resolveJobIDLocked resolves an ID or alias (caller must hold lock)
+func (jm *JobManager) resolveJobIDLocked(idOrAlias string) string {
+	if _, ok := jm.jobs[idOrAlias]; ok {
+		return idOrAlias
+	}
+	for _, job := range jm.jobs {
+		if job.Alias != "" && job.Alias == idOrAlias {
+			return job.ID
+		}
+	}
+	return idOrAlias
+}
+
+// SetAlias assigns an alias to a job. An empty alias removes the current one.
+func (jm *JobManager) SetAlias(jobID string, alias string) (*Job, error) {
+	if alias != "" {
+		if err := ValidateAlias(alias); err != nil {
+			return nil, err
+		}
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jm.resolveJobIDLocked(jobID)]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if alias != "" {
+		if _, ok := jm.jobs[alias]; ok && alias != job.ID {
+			return nil, fmt.Errorf("alias %q conflicts with an existing job ID", alias)
+		}
+		for _, other := range jm.jobs {
+			if other != job && other.Alias == alias {
+				return nil, fmt.Errorf("alias %q is already used by job %s", alias, other.ID)
+			}
+		}
+	}
+
+	if job.Alias == alias {
+		return job, nil
+	}
+
+	previous := job.Alias
+	job.Alias = alias
+
+	if jm.store != nil {
+		if err := jm.store.UpdateJob(job); err != nil {
+			job.Alias = previous
+			return nil, fmt.Errorf("failed to persist alias: %w", err)
+		}
+	}
+
+	jm.emitEvent(Event{
+		Type:            EventTypeJobUpdated,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return job, nil
+}
@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAlias(t *testing.T) {
+	valid := []string{"web", "web-server", "api_v2", "build.watch", "3000"}
+	for _, alias := range valid {
+		if err := ValidateAlias(alias); err != nil {
+			t.Errorf("ValidateAlias(%q) failed: %v", alias, err)
+		}
+	}
+
+	invalid := []string{"", "-web", "web server", "web/api", strings.Repeat("a", maxAliasLength+1)}
+	for _, alias := range invalid {
+		if err := ValidateAlias(alias); err == nil {
+			t.Errorf("ValidateAlias(%q) expected error", alias)
+		}
+	}
+}
+
+func TestJobManager_SetAlias_ResolvesAlias(t *testing.T) {
+	tmpDir := t.TempDir()
+	var events []Event
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, NewFakeProcessExecutor(), nil)
+
+	job, _, err := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	events = nil
+
+	if _, err := jm.SetAlias(job.ID, "web"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+
+	if got := jm.ResolveJobID("web"); got != job.ID {
+		t.Errorf("expected alias to resolve to %s, got %s", job.ID, got)
+	}
+	if got := jm.ResolveJobID(job.ID); got != job.ID {
+		t.Errorf("expected ID to resolve to itself, got %s", got)
+	}
+	if got := jm.ResolveJobID("unknown"); got != "unknown" {
+		t.Errorf("expected unknown value to be returned unchanged, got %s", got)
+	}
+	if resp := jm.jobToResponse(job); resp.Alias != "web" {
+		t.Errorf("expected alias in response, got %q", resp.Alias)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeJobUpdated {
+		t.Errorf("expected one job_updated event, got %v", events)
+	}
+
+	// Renaming through the alias itself
+	if _, err := jm.SetAlias("web", "frontend"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	if got := jm.ResolveJobID("web"); got != "web" {
+		t.Errorf("expected old alias to stop resolving, got %s", got)
+	}
+
+	// Clearing
+	if _, err := jm.SetAlias(job.ID, ""); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	if job.Alias != "" {
+		t.Errorf("expected alias to be cleared, got %q", job.Alias)
+	}
+}
+
+func TestJobManager_SetAlias_RejectsDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), nil)
+
+	job1, _, _ := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	job2, _, _ := jm.AddJob([]string{"npm", "test"}, "/workdir", JobOptions{}, nil)
+
+	if _, err := jm.SetAlias(job1.ID, "web"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	if _, err := jm.SetAlias(job2.ID, "web"); err == nil {
+		t.Error("expected error for alias used by another job")
+	}
+	if _, err := jm.SetAlias(job2.ID, job1.ID); err == nil {
+		t.Error("expected error for alias matching another job's ID")
+	}
+	if _, err := jm.SetAlias("missing", "api"); err == nil {
+		t.Error("expected error for unknown job")
+	}
+}
+
+func TestDaemon_ResolvesAliasInRequests(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, _ := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+
+	d := &Daemon{jobManager: jm}
+	resp := d.handleRequest(&Request{
+		Type:    RequestTypeSetAlias,
+		Payload: map[string]interface{}{"job_id": job.ID, "alias": "web"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	resp = d.handleRequest(&Request{
+		Type:    RequestTypeGetJob,
+		Payload: map[string]interface{}{"job_id": "web"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	if respJob := resp.Data["job"].(JobResponse); respJob.ID != job.ID {
+		t.Errorf("expected job %s, got %s", job.ID, respJob.ID)
+	}
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxNoteLength is the maximum length of a run note
+const maxNoteLength = 1024
+
+// AnnotateRun replaces the note of a run. An empty note removes the current
+// one. Notes are single lines: surrounding whitespace is trimmed and line
+// breaks are rejected.
+func (jm *JobManager) AnnotateRun(runID string, note string) (*Run, error) {
+	note = strings.TrimSpace(note)
+	if strings.ContainsAny(note, "\r\n") {
+		return nil, fmt.Errorf("note must be a single line")
+	}
+	if len(note) > maxNoteLength {
+		return nil, fmt.Errorf("note too long (max %d characters)", maxNoteLength)
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	run, ok := jm.runs[runID]
+	if !ok {
+		return nil, fmt.Errorf("run not found: %s", runID)
+	}
+
+	if run.Note == note {
+		return run, nil
+	}
+
+	previous := run.Note
+	run.Note = note
+
+	if jm.store != nil {
+		if err := jm.store.UpdateRun(run); err != nil {
+			run.Note = previous
+			return nil, fmt.Errorf("failed to persist note: %w", err)
+		}
+	}
+
+	runResp := runToResponse(run)
+	var jobResp JobResponse
+	if job, ok := jm.jobs[run.JobID]; ok {
+		jobResp = jm.jobToResponse(job)
+	}
+	jm.emitEvent(Event{
+		Type:            EventTypeRunUpdated,
+		JobID:           run.JobID,
+		Job:             jobResp,
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return run, nil
+}
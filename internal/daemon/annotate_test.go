@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobManager_AnnotateRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	var events []Event
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, NewFakeProcessExecutor(), store)
+
+	job, _, err := jm.AddJob([]string{"npm", "test"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	runID := jm.GetCurrentRun(job.ID).ID
+	events = nil
+
+	run, err := jm.AnnotateRun(runID, "  broke after upgrading node ")
+	if err != nil {
+		t.Fatalf("AnnotateRun failed: %v", err)
+	}
+	if run.Note != "broke after upgrading node" {
+		t.Errorf("expected a trimmed note, got %q", run.Note)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeRunUpdated || events[0].Run.Note != run.Note {
+		t.Errorf("expected one run_updated event with the note, got %v", events)
+	}
+
+	// Same note: no event
+	if _, err := jm.AnnotateRun(runID, "broke after upgrading node"); err != nil {
+		t.Fatalf("AnnotateRun failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected no event for an unchanged note, got %d", len(events))
+	}
+
+	// Notes survive a restart
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Note != "broke after upgrading node" {
+		t.Errorf("expected the note stored, got %+v", runs)
+	}
+
+	if _, err := jm.AnnotateRun(runID, "two\nlines"); err == nil {
+		t.Error("expected an error for a multi-line note")
+	}
+	if _, err := jm.AnnotateRun(runID, strings.Repeat("a", maxNoteLength+1)); err == nil {
+		t.Error("expected an error for a note that is too long")
+	}
+	if _, err := jm.AnnotateRun("unknown-1", "x"); err == nil {
+		t.Error("expected an error for an unknown run")
+	}
+
+	if _, err := jm.AnnotateRun(runID, ""); err != nil {
+		t.Fatalf("AnnotateRun failed: %v", err)
+	}
+	if resp := runToResponse(run); resp.Note != "" {
+		t.Errorf("expected the note to be removed, got %q", resp.Note)
+	}
+}
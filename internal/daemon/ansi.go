@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// cursorSequenceRegex matches ANSI cursor movement and screen control sequences.
+// These are used by progress bars and spinners to update in place, but they
+// turn into garbage once the output is read back from the logs.
+var cursorSequenceRegex = regexp.MustCompile(
+	`\x1b\[` + // CSI (Control Sequence Introducer)
+		`(?:` +
+		`\d*[ABCDEFGH]` + // Cursor movement (up/down/forward/back/next line/prev line/column/position)
+		`|\d*;\d*[Hf]` + // Cursor position (row;col)
+		`|[suKJ]` + // Save/restore cursor, erase line/screen
+		`|\d*[KJ]` + // Erase with count
+		`|\?(?:25[hl]|\d+[hl])` + // Show/hide cursor, other private modes
+		`)`,
+)
+
+// StripCursorSequences removes ANSI cursor movement and line erasing sequences
+// while preserving color codes
+func StripCursorSequences(s string) string {
+	return cursorSequenceRegex.ReplaceAllString(s, "")
+}
+
+// CleanOutput makes captured output safe to print or show outside a
+// terminal: it removes cursor movement and screen clearing sequences, and
+// keeps only what follows the last carriage return of each line, the final
+// state of a progress bar redrawn in place. Colors are kept.
+func CleanOutput(s string) string {
+	s = StripCursorSequences(s)
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r") // CRLF line endings
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CleanLine is CleanOutput for a line of output, as a tail.FileSource
+// transform
+func CleanLine(line []byte) []byte {
+	return []byte(CleanOutput(string(line)))
+}
+
+// CleanWriter writes output cleaned with CleanOutput, one complete line at a
+// time so sequences split across writes are still removed. Call Flush to
+// write a last line without a newline.
+type CleanWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewCleanWriter returns a CleanWriter writing to w
+func NewCleanWriter(w io.Writer) *CleanWriter {
+	return &CleanWriter{w: w}
+}
+
+func (c *CleanWriter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	end := bytes.LastIndexByte(c.buf, '\n') + 1
+	if end == 0 {
+		return len(p), nil
+	}
+	if _, err := io.WriteString(c.w, CleanOutput(string(c.buf[:end]))); err != nil {
+		return 0, err
+	}
+	c.buf = c.buf[end:]
+	return len(p), nil
+}
+
+// Flush writes the buffered partial line
+func (c *CleanWriter) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(c.w, CleanOutput(string(c.buf)))
+	c.buf = nil
+	return err
+}
@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCleanOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "plain text unchanged",
+			input:    "one\ntwo\n",
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "cursor sequences removed, colors kept",
+			input:    "\x1b[1A\x1b[2K\x1b[32mok\x1b[0m\n",
+			expected: "\x1b[32mok\x1b[0m\n",
+		},
+		{
+			name:     "progress bar keeps its last state",
+			input:    "10%\r50%\r100%\ndone\n",
+			expected: "100%\ndone\n",
+		},
+		{
+			name:     "CRLF line endings",
+			input:    "one\r\ntwo\r\n",
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "spinner cleared with erase line",
+			input:    "⠋ building\r\x1b[Kbuilt\n",
+			expected: "built\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanOutput(tt.input); got != tt.expected {
+				t.Errorf("CleanOutput(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanWriter_SplitWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCleanWriter(&buf)
+
+	// A sequence and a progress line split across writes
+	w.Write([]byte("a\x1b["))
+	w.Write([]byte("2Kb\n10%\r"))
+	w.Write([]byte("100%\npartial"))
+	if got := buf.String(); got != "ab\n100%\n" {
+		t.Errorf("after writes = %q, want %q", got, "ab\n100%\n")
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "ab\n100%\npartial" {
+		t.Errorf("after flush = %q, want %q", got, "ab\n100%\npartial")
+	}
+}
@@ -0,0 +1,385 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Approvals.
+//
+// The policy in $XDG_CONFIG_HOME/gob/policy.toml makes the destructive
+// requests of some clients, typically agents, wait until someone approves
+// them in the TUI or with 'gob approve':
+//
+//	clients = ["claude*", "codex"]           # client names the policy applies to
+//	require = ["remove", "stop_all", "kill"] # actions needing approval
+//	commands = ["rm -rf *", "git push*"]     # add and run commands needing approval
+//	timeout = "5m"                           # denied when nobody answers (default 5m)
+//
+// The actions are remove (jobs and runs), stop_all (including shutdown), and
+// kill (SIGKILL, including stop --force and kill-orphans --force). Clients are told apart
+// by name: the one a request carries (see ClientName), which the client
+// chooses, and, where peer credentials report the process that connected,
+// the names of that process and those it runs under (see peerAncestry), so
+// an agent can't get out of the policy by renaming itself. It still can by
+// running gob outside its process tree, and through the HTTP gateway or
+// without peer credentials only reported names count: the policy guards
+// against mistakes, not against a client set on getting around it.
+// Clients the policy applies to can't approve requests. Every decision is
+// recorded with the request in the audit log.
+
+// defaultApprovalTimeout is how long a request waits for approval unless the
+// policy says otherwise
+const defaultApprovalTimeout = 5 * time.Minute
+
+// Actions a policy can require approval for
+const (
+	ApprovalRemove  = "remove"
+	ApprovalStopAll = "stop_all"
+	ApprovalKill    = "kill"
+)
+
+// Policy selects the requests that need approval. The zero value approves
+// everything.
+type Policy struct {
+	Clients  []string `toml:"clients" json:"clients,omitempty"`   // glob patterns of client names
+	Require  []string `toml:"require" json:"require,omitempty"`   // remove, stop_all or kill
+	Commands []string `toml:"commands" json:"commands,omitempty"` // glob patterns of commands added or run
+	Timeout  string   `toml:"timeout" json:"timeout,omitempty"`   // e.g. 5m
+
+	clients  []*regexp.Regexp
+	commands []*regexp.Regexp
+	timeout  time.Duration
+}
+
+// Approval is a request waiting for, or given, approval
+type Approval struct {
+	ID       int64       `json:"id"`
+	Time     string      `json:"time"` // RFC3339, when the request was received
+	Client   string      `json:"client"`
+	Type     RequestType `json:"type"`
+	Target   string      `json:"target,omitempty"`   // job IDs, command or selection, as in the audit log
+	Reason   string      `json:"reason"`             // remove, stop_all, kill or the command pattern matched
+	Decision string      `json:"decision,omitempty"` // approved by <client>, denied by <client> or timed out; empty while pending
+}
+
+// PolicyPath returns the path of the approval policy file
+func PolicyPath() string {
+	return filepath.Join(xdg.ConfigHome, "gob", "policy.toml")
+}
+
+// LoadPolicy reads an approval policy file. A missing file is the zero
+// policy.
+func LoadPolicy(path string) (Policy, error) {
+	var p Policy
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := toml.Unmarshal(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if err := p.compile(); err != nil {
+		return Policy{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// compile validates the policy and compiles its patterns
+func (p *Policy) compile() error {
+	for _, action := range p.Require {
+		if action != ApprovalRemove && action != ApprovalStopAll && action != ApprovalKill {
+			return fmt.Errorf("unknown action %q in require (expected remove, stop_all or kill)", action)
+		}
+	}
+
+	p.clients, p.commands = nil, nil
+	for _, pattern := range p.Clients {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid client pattern %q: %w", pattern, err)
+		}
+		p.clients = append(p.clients, re)
+	}
+	for _, pattern := range p.Commands {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid command pattern %q: %w", pattern, err)
+		}
+		p.commands = append(p.commands, re)
+	}
+
+	p.timeout = defaultApprovalTimeout
+	if p.Timeout != "" {
+		timeout, err := time.ParseDuration(p.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", p.Timeout)
+		}
+		p.timeout = timeout
+	}
+	return nil
+}
+
+// appliesTo reports whether the policy applies to a client
+func (p Policy) appliesTo(client string) bool {
+	return slices.ContainsFunc(p.clients, func(re *regexp.Regexp) bool { return re.MatchString(client) })
+}
+
+// heldClient returns the name the policy applies to a request by: the name
+// of its client, or of a process the client runs under. It returns "" if the
+// policy doesn't apply to the request.
+func (p Policy) heldClient(req *Request) string {
+	if len(p.clients) == 0 {
+		return ""
+	}
+	if client := requestOrigin(req).Name; p.appliesTo(client) {
+		return client
+	}
+	if req.peerPID > 0 {
+		for _, name := range peerAncestry(req.peerPID) {
+			if p.appliesTo(name) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// needsApproval returns why a request needs approval, or "" if it doesn't
+func (p Policy) needsApproval(req *Request) string {
+	if p.heldClient(req) == "" {
+		return ""
+	}
+	action, command := requestAction(req)
+	if action != "" && slices.Contains(p.Require, action) {
+		return action
+	}
+	if command != "" {
+		for i, re := range p.commands {
+			if re.MatchString(command) {
+				return "command matches " + p.Commands[i]
+			}
+		}
+	}
+	return ""
+}
+
+// requestAction returns the destructive action a request takes, if any, and
+// the command it adds or runs, if any. Payloads are decoded as their
+// handlers decode them; one that doesn't decode is rejected by its handler.
+func requestAction(req *Request) (string, string) {
+	kills := func(signal *int) bool { return signal != nil && *signal == int(syscall.SIGKILL) }
+
+	switch req.Type {
+	case RequestTypeRemove, RequestTypeRemoveRun:
+		return ApprovalRemove, ""
+	case RequestTypeStopAll, RequestTypeShutdown:
+		// Shutting down stops every job (a handover is its own request)
+		return ApprovalStopAll, ""
+	case RequestTypeKillOrphans:
+		var p KillOrphansPayload
+		if decodePayload(req, &p) == nil && p.Force {
+			return ApprovalKill, ""
+		}
+	case RequestTypeStop:
+		var p StopPayload
+		if decodePayload(req, &p) == nil && p.Force {
+			return ApprovalKill, ""
+		}
+	case RequestTypeSignal:
+		var p SignalPayload
+		if decodePayload(req, &p) == nil && kills(p.Signal) {
+			return ApprovalKill, ""
+		}
+	case RequestTypeBatch:
+		var p BatchPayload
+		if decodePayload(req, &p) != nil {
+			break
+		}
+		switch {
+		case p.Action == BatchRemove:
+			return ApprovalRemove, ""
+		case p.Action == BatchStop && p.Force, p.Action == BatchSignal && kills(p.Signal):
+			return ApprovalKill, ""
+		}
+	case RequestTypeAdd, RequestTypeRun, RequestTypeCreate:
+		var p AddPayload
+		if decodePayload(req, &p) == nil {
+			return "", strings.Join(p.Command, " ")
+		}
+	}
+	return "", ""
+}
+
+// approvalDecision is the answer to an approval request
+type approvalDecision struct {
+	approved bool
+	text     string // approved by <client> or denied by <client>
+}
+
+// pendingApproval is a request waiting for approval
+type pendingApproval struct {
+	Approval
+	workdir string // of the job the request acts on, for subscribers
+	decided chan approvalDecision
+}
+
+// approvals keeps the requests waiting for approval
+type approvals struct {
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]*pendingApproval
+}
+
+// add registers a request waiting for approval
+func (a *approvals) add(approval Approval, workdir string) *pendingApproval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.pending == nil {
+		a.pending = make(map[int64]*pendingApproval)
+	}
+	a.nextID++
+	approval.ID = a.nextID
+	p := &pendingApproval{Approval: approval, workdir: workdir, decided: make(chan approvalDecision, 1)}
+	a.pending[p.ID] = p
+	return p
+}
+
+// take removes a request from the pending ones, returning nil if it is not
+// pending anymore
+func (a *approvals) take(id int64) *pendingApproval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := a.pending[id]
+	delete(a.pending, id)
+	return p
+}
+
+// list returns the requests waiting for approval, oldest first
+func (a *approvals) list() []Approval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]Approval, 0, len(a.pending))
+	for _, p := range a.pending {
+		list = append(list, p.Approval)
+	}
+	slices.SortFunc(list, func(x, y Approval) int { return int(x.ID - y.ID) })
+	return list
+}
+
+// awaitApproval holds a request until it is approved or denied, or the
+// policy's timeout passes. It returns the decision, for the audit log, and
+// an error unless the request was approved.
+func (d *Daemon) awaitApproval(req *Request, client, reason string) (string, error) {
+	p := d.approvals.add(Approval{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Client: client,
+		Type:   req.Type,
+		Target: auditTarget(req),
+		Reason: reason,
+	}, d.requestWorkdir(req))
+	Logger.Info("request waiting for approval", "id", p.ID, "client", client, "type", req.Type, "reason", reason)
+	d.emitApprovalEvent(EventTypeApprovalRequested, p)
+
+	timer := time.NewTimer(d.policy.timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case decision := <-p.decided:
+		p.Decision = decision.text
+		if !decision.approved {
+			err = fmt.Errorf("request %s", p.Decision)
+		}
+	case <-timer.C:
+		d.approvals.take(p.ID)
+		p.Decision = "timed out"
+		err = fmt.Errorf("request not approved within %s", d.policy.timeout)
+	case <-d.ctx.Done():
+		d.approvals.take(p.ID)
+		p.Decision = "timed out"
+		err = fmt.Errorf("daemon shutting down")
+	}
+
+	Logger.Info("approval decided", "id", p.ID, "decision", p.Decision)
+	d.emitApprovalEvent(EventTypeApprovalDecided, p)
+	return p.Decision, err
+}
+
+// requestWorkdir returns the directory of the job a request acts on, or the
+// one in its payload
+func (d *Daemon) requestWorkdir(req *Request) string {
+	if id, ok := req.Payload["job_id"].(string); ok && id != "" {
+		if job, err := d.jobManager.GetJob(id); err == nil {
+			return job.Workdir
+		}
+	}
+	workdir, _ := req.Payload["workdir"].(string)
+	return workdir
+}
+
+// emitApprovalEvent sends an approval event to subscribers
+func (d *Daemon) emitApprovalEvent(eventType EventType, p *pendingApproval) {
+	approval := p.Approval
+	d.handleEvent(Event{
+		Type:            eventType,
+		Job:             JobResponse{Workdir: p.workdir},
+		Approval:        &approval,
+		JobCount:        d.jobManager.JobCount(),
+		RunningJobCount: d.jobManager.RunningJobCount(),
+	})
+}
+
+// handleApprovals handles an approvals request: the requests waiting for
+// approval
+func (d *Daemon) handleApprovals(req *Request) *Response {
+	return NewDataResponse(ApprovalsData{Approvals: d.approvals.list()})
+}
+
+// handleApprove handles an approve request, approving or denying a request
+// waiting for approval
+func (d *Daemon) handleApprove(req *Request) *Response {
+	var p ApprovePayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.Action != "approve" && p.Action != "deny" {
+		return NewErrorResponse(fmt.Errorf("invalid action %q (expected approve or deny)", p.Action))
+	}
+
+	client := req.Client
+	if client == "" {
+		client = "unknown"
+	}
+	if held := d.policy.heldClient(req); held == client {
+		return NewErrorResponse(fmt.Errorf("client %s can't approve requests: the approval policy applies to it", client))
+	} else if held != "" {
+		return NewErrorResponse(fmt.Errorf("client %s can't approve requests: it runs under %s, which the approval policy applies to", client, held))
+	}
+
+	pending := d.approvals.take(p.ApprovalID)
+	if pending == nil {
+		return NewErrorResponse(fmt.Errorf("no request waiting for approval: %d", p.ApprovalID))
+	}
+	approval := pending.Approval
+	approval.Decision = "denied by " + client
+	if p.Action == "approve" {
+		approval.Decision = "approved by " + client
+	}
+	pending.decided <- approvalDecision{approved: p.Action == "approve", text: approval.Decision}
+	return NewDataResponse(ApproveData{Approval: approval})
+}
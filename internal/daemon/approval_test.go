@@ -0,0 +1,213 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.toml")
+
+	p, err := LoadPolicy(path)
+	if err != nil || p.appliesTo("claude") {
+		t.Fatalf("expected a missing file to apply to nobody, got %+v, %v", p, err)
+	}
+
+	os.WriteFile(path, []byte(`clients = ["claude*"]
+require = ["remove", "kill"]
+commands = ["rm -rf *"]
+timeout = "30s"
+`), 0644)
+	p, err = LoadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.appliesTo("claude-code") || p.appliesTo("zsh") || p.timeout != 30*time.Second {
+		t.Errorf("unexpected policy %+v", p)
+	}
+
+	os.WriteFile(path, []byte(`require = ["nuke"]`), 0644)
+	if _, err := LoadPolicy(path); err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Errorf("expected an error for an unknown action, got %v", err)
+	}
+}
+
+func TestPolicy_NeedsApproval(t *testing.T) {
+	p := Policy{Clients: []string{"claude"}, Require: []string{ApprovalRemove, ApprovalKill}, Commands: []string{"rm -rf *"}}
+	if err := p.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{"remove", Request{Type: RequestTypeRemove, Client: "claude", Payload: map[string]any{"job_id": "abc"}}, "remove"},
+		{"other client", Request{Type: RequestTypeRemove, Client: "zsh", Payload: map[string]any{"job_id": "abc"}}, ""},
+		{"stop", Request{Type: RequestTypeStop, Client: "claude", Payload: map[string]any{"job_id": "abc"}}, ""},
+		{"stop force", Request{Type: RequestTypeStop, Client: "claude", Payload: map[string]any{"job_id": "abc", "force": true}}, "kill"},
+		{"signal KILL", Request{Type: RequestTypeSignal, Client: "claude", Payload: map[string]any{"job_id": "abc", "signal": float64(9)}}, "kill"},
+		{"signal TERM", Request{Type: RequestTypeSignal, Client: "claude", Payload: map[string]any{"job_id": "abc", "signal": float64(15)}}, ""},
+		{"batch remove", Request{Type: RequestTypeBatch, Client: "claude", Payload: map[string]any{"action": "remove", "match": "*"}}, "remove"},
+		{"stop_all not required", Request{Type: RequestTypeStopAll, Client: "claude", Payload: map[string]any{}}, ""},
+		{"signal KILL as a Go int", Request{Type: RequestTypeSignal, Client: "claude", Payload: map[string]any{"job_id": "abc", "signal": 9}}, "kill"},
+		{"batch signal KILL", Request{Type: RequestTypeBatch, Client: "claude", Payload: map[string]any{"action": "signal", "match": "*", "signal": 9}}, "kill"},
+		{"command", Request{Type: RequestTypeAdd, Client: "claude", Payload: map[string]any{"command": []any{"rm", "-rf", "/tmp/x"}}}, "command matches rm -rf *"},
+		{"safe command", Request{Type: RequestTypeRun, Client: "claude", Payload: map[string]any{"command": []any{"make"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.needsApproval(&tt.req); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPolicy_HeldClient(t *testing.T) {
+	self, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := self.Name()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Policy{Clients: []string{name}, Require: []string{ApprovalRemove}}
+	if err := p.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The process that connected counts, whatever name it reports
+	spoofed := &Request{Type: RequestTypeRemove, Client: "tui", ClientType: ClientTypeTUI, peerPID: os.Getpid()}
+	if got := p.heldClient(spoofed); got != name {
+		t.Errorf("expected the request held as %q, got %q", name, got)
+	}
+	if got := p.needsApproval(spoofed); got != ApprovalRemove {
+		t.Errorf("expected the request to need approval, got %q", got)
+	}
+	if got := p.heldClient(&Request{Type: RequestTypeRemove, Client: "tui"}); got != "" {
+		t.Errorf("expected a request without its process to go by its name, got %q", got)
+	}
+
+	d := &Daemon{policy: p}
+	resp := d.handleApprove(&Request{Type: RequestTypeApprove, Client: "tui", peerPID: os.Getpid(), Payload: map[string]any{"approval_id": float64(1), "action": "approve"}})
+	if resp.Success || !strings.Contains(resp.Error, "runs under "+name) {
+		t.Errorf("expected a client running under the policy's client not to approve, got %+v", resp)
+	}
+}
+
+func TestDaemon_ApprovalShutdown(t *testing.T) {
+	d := &Daemon{jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil), ctx: context.Background()}
+	d.policy = Policy{Clients: []string{"claude"}, Require: []string{ApprovalStopAll}, Timeout: "50ms"}
+	if err := d.policy.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shutting down stops every job, so it waits like stop_all
+	resp := d.serveRequest(&Request{Type: RequestTypeShutdown, Client: "claude"})
+	if resp.Success || !strings.Contains(resp.Error, "not approved within 50ms") {
+		t.Errorf("expected the shutdown to wait for approval, got %+v", resp)
+	}
+}
+
+// waitForApproval waits until a request is waiting for approval
+func waitForApproval(t *testing.T, d *Daemon) Approval {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if pending := d.approvals.list(); len(pending) > 0 {
+			return pending[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("no request waiting for approval")
+	return Approval{}
+}
+
+func TestDaemon_Approval(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	d := &Daemon{store: store, jobManager: jm, ctx: context.Background()}
+	d.policy = Policy{Clients: []string{"claude"}, Require: []string{ApprovalRemove}, Timeout: "50ms"}
+	if err := d.policy.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	job, _, err := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopAndWait(t, jm, executor, job.ID)
+
+	// Nobody answers in time
+	resp := d.serveRequest(&Request{Type: RequestTypeRemove, Client: "claude", Payload: map[string]any{"job_id": job.ID}})
+	if resp.Success || !strings.Contains(resp.Error, "not approved within 50ms") {
+		t.Fatalf("expected the request to time out, got %+v", resp)
+	}
+
+	d.policy.timeout = 2 * time.Second
+	done := make(chan *Response)
+	go func() {
+		done <- d.serveRequest(&Request{Type: RequestTypeRemove, Client: "claude", Payload: map[string]any{"job_id": job.ID}})
+	}()
+	pending := waitForApproval(t, d)
+	if pending.Client != "claude" || pending.Reason != ApprovalRemove || pending.Target != job.ID {
+		t.Errorf("unexpected approval %+v", pending)
+	}
+
+	// The policy's clients can't approve their own requests
+	resp = d.serveRequest(&Request{Type: RequestTypeApprove, Client: "claude", Payload: map[string]any{"approval_id": float64(pending.ID), "action": "approve"}})
+	if resp.Success {
+		t.Fatal("expected the client under the policy not to be able to approve")
+	}
+	resp = d.serveRequest(&Request{Type: RequestTypeApprove, Client: "gob", Payload: map[string]any{"approval_id": float64(pending.ID), "action": "deny"}})
+	if !resp.Success {
+		t.Fatalf("deny failed: %s", resp.Error)
+	}
+	if resp := <-done; resp.Success || resp.Error != "request denied by gob" {
+		t.Errorf("expected the request denied, got %+v", resp)
+	}
+	if _, err := jm.GetJob(job.ID); err != nil {
+		t.Errorf("expected the job to be kept: %v", err)
+	}
+
+	go func() {
+		done <- d.serveRequest(&Request{Type: RequestTypeRemove, Client: "claude", Payload: map[string]any{"job_id": job.ID}})
+	}()
+	pending = waitForApproval(t, d)
+	d.serveRequest(&Request{Type: RequestTypeApprove, Client: "gob", Payload: map[string]any{"approval_id": float64(pending.ID), "action": "approve"}})
+	if resp := <-done; !resp.Success {
+		t.Errorf("expected the approved request to succeed, got %s", resp.Error)
+	}
+
+	// Decisions are in the audit log
+	entries, err := store.ListAuditEntries(AuditFilter{Client: "claude"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decisions []string
+	for _, e := range entries {
+		if e.Type == RequestTypeRemove {
+			decisions = append(decisions, e.Approval)
+		}
+	}
+	if want := "timed out,denied by gob,approved by gob"; strings.Join(decisions, ",") != want {
+		t.Errorf("expected decisions %q, got %q", want, strings.Join(decisions, ","))
+	}
+}
@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// archiveSuffix is appended to the log files of archived runs, which are
+// gzipped
+const archiveSuffix = ".gz"
+
+// runArchiveAge is how long ago stopped runs must have started for the
+// daemon to archive them when it starts
+const runArchiveAge = 90 * 24 * time.Hour
+
+// ArchiveRuns archives the stopped runs of jobs in workdir (all directories
+// if empty) started before cutoff. Their logs are gzipped and they are only
+// kept in the database, where 'gob runs --include-archived' finds them; the
+// statistics of their jobs still count them. The latest run of each job is
+// kept, so the job's status and logs stay at hand. Returns the number of
+// runs archived and the bytes saved.
+func (jm *JobManager) ArchiveRuns(workdir string, cutoff time.Time) (int, int64, error) {
+	if jm.store == nil {
+		return 0, 0, fmt.Errorf("archiving runs needs a database")
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	var candidates []*Run
+	for _, job := range jm.jobs {
+		if workdir != "" && job.Workdir != workdir {
+			continue
+		}
+		runs := jm.jobRunsLocked(job.ID)
+		for _, run := range runs[:max(len(runs)-1, 0)] {
+			if run.Status != "running" && run.StartedAt.Before(cutoff) {
+				candidates = append(candidates, run)
+			}
+		}
+	}
+
+	archived := 0
+	var saved int64
+	for _, run := range candidates {
+		size := jm.logBytesLocked(run)
+		compressed, err := jm.archiveRunLocked(run)
+		if err != nil {
+			return archived, saved, fmt.Errorf("failed to archive run %s: %w", run.ID, err)
+		}
+		archived++
+		saved += size - compressed
+	}
+	return archived, saved, nil
+}
+
+// archiveRunLocked gzips the logs of a stopped run that were not compressed
+// already, records it as archived and drops it from memory, leaving the
+// statistics of its job alone. Returns the size of the compressed logs. Caller must hold the write lock.
+func (jm *JobManager) archiveRunLocked(run *Run) (int64, error) {
+	files := runLogFiles(run)
+	var compressed int64
+	var written []string
+	for _, file := range files {
+		size, err := gzipFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			// Compressed logs stay as they are
+			if info, err := os.Stat(file + logfile.CompressedSuffix); err == nil {
+				compressed += info.Size()
+			}
+			continue
+		}
+		if err != nil {
+			removeFiles(written)
+			return 0, err
+		}
+		written = append(written, file+archiveSuffix)
+		compressed += size
+	}
+
+	now := time.Now()
+	logBytes := run.LogBytes
+	run.ArchivedAt, run.LogBytes = &now, &compressed
+	if err := jm.store.ArchiveRun(run); err != nil {
+		run.ArchivedAt, run.LogBytes = nil, logBytes
+		removeFiles(written)
+		return 0, err
+	}
+	removeFiles(files)
+
+	runResp := runToResponse(run)
+	jm.deleteRunLocked(run)
+
+	var jobResp JobResponse
+	if job, ok := jm.jobs[run.JobID]; ok {
+		jobResp = jm.jobToResponse(job)
+	}
+	jm.emitEvent(Event{
+		Type:            EventTypeRunRemoved,
+		JobID:           run.JobID,
+		Job:             jobResp,
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+	return compressed, nil
+}
+
+// ListArchivedRuns returns the archived runs of a job, newest first
+func (jm *JobManager) ListArchivedRuns(jobID string) ([]*Run, error) {
+	if jm.store == nil {
+		return nil, nil
+	}
+	runs, err := jm.store.LoadArchivedRuns(jobID)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(runs, func(a, b *Run) int {
+		return compareRuns(b, a)
+	})
+	return runs, nil
+}
+
+// runLogFiles returns the log files of a run and their timestamp indexes,
+// each once
+func runLogFiles(run *Run) []string {
+	var files []string
+	for _, path := range []string{run.StdoutPath, run.StderrPath} {
+		for _, file := range []string{path, TimestampIndexPath(path)} {
+			if !slices.Contains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// gzipFile writes a gzipped copy of a file next to it, and returns the size
+// of the copy
+func gzipFile(path string) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+archiveSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + archiveSuffix)
+		return 0, err
+	}
+
+	info, err := os.Stat(path + archiveSuffix)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// archivedLogPath returns the path of a log of an archived run: gzipped, or
+// compressed if it was compressed before the run was archived
+func archivedLogPath(path string) string {
+	if _, err := os.Stat(path + logfile.CompressedSuffix); err == nil {
+		return path + logfile.CompressedSuffix
+	}
+	return path + archiveSuffix
+}
+
+// removeFiles removes files, ignoring the missing ones
+func removeFiles(files []string) {
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// handleArchiveRuns handles an archive_runs request
+func (d *Daemon) handleArchiveRuns(req *Request) *Response {
+	var p ArchiveRunsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	cutoff, err := time.Parse(time.RFC3339, p.Before)
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("invalid before: %w", err))
+	}
+
+	archived, saved, err := d.jobManager.ArchiveRuns(p.Workdir, cutoff)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewDataResponse(ArchiveRunsData{RunsArchived: archived, BytesSaved: saved})
+}
@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobManager_ArchiveRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, store)
+
+	job, _, _ := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, nil)
+	var runs []*Run
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			time.Sleep(5 * time.Millisecond) // distinct start times
+			if err := jm.StartJob(job.ID, nil, EnvSourceClient, Origin{}); err != nil {
+				t.Fatalf("StartJob failed: %v", err)
+			}
+		}
+		run := jm.GetCurrentRun(job.ID)
+		os.WriteFile(run.StdoutPath, []byte(strings.Repeat("building\n", 100)), 0644)
+		runs = append(runs, run)
+		stopAndWait(t, jm, executor, job.ID)
+	}
+	before, _ := jm.JobStats(job.ID)
+
+	// Other directories are left alone
+	if archived, _, _ := jm.ArchiveRuns("/other", time.Now().Add(time.Hour)); archived != 0 {
+		t.Errorf("expected nothing archived in /other, got %d", archived)
+	}
+
+	archived, saved, err := jm.ArchiveRuns("/workdir", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ArchiveRuns failed: %v", err)
+	}
+	if archived != 2 || saved <= 0 {
+		t.Errorf("expected 2 runs archived saving space, got %d and %d bytes", archived, saved)
+	}
+
+	// The latest run stays, the statistics still count the archived ones
+	left, _ := jm.ListRunsForJob(job.ID)
+	if len(left) != 1 || left[0].ID != runs[2].ID {
+		t.Fatalf("expected only the latest run kept, got %v", left)
+	}
+	if after, _ := jm.JobStats(job.ID); after.RunCount != before.RunCount || after.AvgDurationMs != before.AvgDurationMs {
+		t.Errorf("expected the stats unchanged, got %d runs (was %d)", after.RunCount, before.RunCount)
+	}
+
+	// The logs are gzipped
+	if _, err := os.Stat(runs[0].StdoutPath); !os.IsNotExist(err) {
+		t.Error("expected the plain log of an archived run to be removed")
+	}
+	f, err := os.Open(runs[0].StdoutPath + archiveSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(zr)
+	f.Close()
+	if strings.Count(string(content), "building") != 100 {
+		t.Errorf("expected the gzipped log to hold the output, got %d bytes", len(content))
+	}
+
+	// Archived runs are not loaded on restart, but can be listed
+	loaded, _ := store.LoadRuns()
+	if len(loaded) != 1 {
+		t.Errorf("expected only the latest run loaded, got %d", len(loaded))
+	}
+	d := &Daemon{jobManager: jm}
+	resp := d.handleRequest(NewTypedRequest(RequestTypeRuns, RunsPayload{JobID: job.ID, IncludeArchived: true}))
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	all := resp.Data["runs"].([]RunResponse)
+	if len(all) != 3 || all[1].ID != runs[1].ID || all[2].ArchivedAt == "" || all[2].StdoutPath != runs[0].StdoutPath+archiveSuffix {
+		t.Errorf("expected the archived runs after the latest, with gzipped logs, got %+v", all)
+	}
+
+	// Removing the job removes the archived logs
+	if err := jm.RemoveJob(job.ID, false); err != nil {
+		t.Fatalf("RemoveJob failed: %v", err)
+	}
+	if _, err := os.Stat(runs[0].StdoutPath + archiveSuffix); !os.IsNotExist(err) {
+		t.Error("expected the archived log to be removed with the job")
+	}
+}
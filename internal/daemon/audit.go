@@ -0,0 +1,290 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Each connection may send rateLimitBurst state-changing requests at once,
+// and rateLimitPerSecond more every second after that. A person never gets
+// close; an agent restarting a job in a loop over a session does. Client
+// names are reported by clients, so they don't key the limit: processes
+// named alike, such as shells, would share it, and a client could dodge it
+// by changing its name. Gateway requests have no connection of their own
+// and are limited by client name.
+const (
+	rateLimitBurst     = 30
+	rateLimitPerSecond = 2.0
+)
+
+// auditRetention is how long audit log entries are kept
+const auditRetention = 30 * 24 * time.Hour
+
+// auditedRequests are the requests that change state. They are rate limited
+// per client and recorded in the audit log; reads are neither.
+var auditedRequests = map[RequestType]bool{
+	RequestTypeShutdown:         true,
+	RequestTypeAdd:              true,
+	RequestTypeRun:              true,
+	RequestTypeCreate:           true,
+	RequestTypeStop:             true,
+	RequestTypeStart:            true,
+	RequestTypeRestart:          true,
+	RequestTypeRemove:           true,
+	RequestTypeStopAll:          true,
+	RequestTypeSignal:           true,
+	RequestTypeRemoveRun:        true,
+	RequestTypeSetAlias:         true,
+	RequestTypeSetDescription:   true,
+	RequestTypeAnnotateRun:      true,
+	RequestTypeSetPinned:        true,
+	RequestTypeReload:           true,
+	RequestTypeMarkStuck:        true,
+	RequestTypeSetSlowThreshold: true,
+	RequestTypeBatch:            true,
+	RequestTypeAdopt:            true,
+	RequestTypePruneLogs:        true,
+	RequestTypeArchiveRuns:      true,
+	RequestTypeCleanupLogs:      true,
+	RequestTypeApprove:          true,
+	RequestTypeKillOrphans:      true,
+}
+
+// AuditEntry is a state-changing request recorded in the audit log
+type AuditEntry struct {
+	ID         int64       `json:"id"`
+	Time       string      `json:"time"` // RFC3339
+	Client     string      `json:"client"`
+	Type       RequestType `json:"type"`
+	Target     string      `json:"target,omitempty"` // job IDs, command or selection
+	Success    bool        `json:"success"`
+	Error      string      `json:"error,omitempty"`
+	Approval   string      `json:"approval,omitempty"` // approved by <client>, denied by <client> or timed out, for requests that needed approval
+	DurationMs int64       `json:"duration_ms"`
+}
+
+// AuditFilter selects audit log entries
+type AuditFilter struct {
+	Since  time.Time // only entries at or after this time (all if zero)
+	Client string    // only entries of this client (all if empty)
+	Limit  int       // at most this many entries, the most recent (all if 0)
+}
+
+// ClientName identifies this process in its requests: $GOB_CLIENT, or the
+// name of the parent process (the shell, editor or agent running gob). The
+// daemon takes it as reported; the approval policy also checks the process
+// that connected (see Policy).
+func ClientName() string {
+	if name := os.Getenv("GOB_CLIENT"); name != "" {
+		return name
+	}
+	if parent, err := process.NewProcess(int32(os.Getppid())); err == nil {
+		if name, err := parent.Name(); err == nil && name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// Client types, telling apart the interfaces requests come through
+const (
+	ClientTypeCLI = "cli"
+	ClientTypeTUI = "tui"
+	ClientTypeAPI = "api" // the HTTP gateway
+)
+
+// Origin is the client that created a job or started a run
+type Origin struct {
+	Name string // client name, see ClientName
+	Type string // cli, tui or api
+}
+
+// requestOrigin returns the client that sent a request. Clients predating
+// client types are taken for the CLI.
+func requestOrigin(req *Request) Origin {
+	o := Origin{Name: req.Client, Type: req.ClientType}
+	if o.Name == "" {
+		o.Name = "unknown"
+	}
+	if o.Type == "" {
+		o.Type = ClientTypeCLI
+	}
+	return o
+}
+
+// FormatOrigin describes the client that created a job or started a run,
+// e.g. "claude" or "zsh via tui", or returns "" if it is unknown
+func FormatOrigin(name, clientType string) string {
+	if name == "" {
+		return ""
+	}
+	if clientType == "" || clientType == ClientTypeCLI {
+		return name
+	}
+	return name + " via " + clientType
+}
+
+// rateLimitKey returns the bucket of a request: its connection's, or its
+// client's when it has none
+func rateLimitKey(req *Request) string {
+	if req.connID != 0 {
+		return fmt.Sprintf("connection %d", req.connID)
+	}
+	return "client " + requestOrigin(req).Name
+}
+
+// rateLimiter keeps a token bucket per connection
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token from a bucket. If it is empty, it returns false and
+// how long until a token is available.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: rateLimitBurst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(rateLimitBurst, b.tokens+now.Sub(b.last).Seconds()*rateLimitPerSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rateLimitPerSecond * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// forget drops a bucket, once its connection is closed
+func (l *rateLimiter) forget(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.buckets, key)
+}
+
+// serveRequest handles a request from a client. State-changing requests are
+// rate limited per connection and recorded in the audit log.
+func (d *Daemon) serveRequest(req *Request) *Response {
+	if !auditedRequests[req.Type] {
+		return d.handleRequest(req)
+	}
+
+	client := req.Client
+	if client == "" {
+		client = "unknown"
+	}
+
+	start := time.Now()
+	var resp *Response
+	var approval string
+	if ok, wait := d.limiter.allow(rateLimitKey(req), start); ok {
+		if reason := d.policy.needsApproval(req); reason != "" {
+			var err error
+			if approval, err = d.awaitApproval(req, client, reason); err != nil {
+				resp = NewErrorResponse(err)
+			}
+		}
+		if resp == nil {
+			resp = d.handleRequest(req)
+		}
+	} else {
+		resp = NewErrorResponse(fmt.Errorf("rate limit exceeded for client %s: retry in %s", client, wait.Round(100*time.Millisecond)))
+		Logger.Warn("rate limited request", "client", client, "type", req.Type)
+	}
+
+	if d.store != nil {
+		entry := AuditEntry{
+			Time:       start.UTC().Format(time.RFC3339),
+			Client:     client,
+			Type:       req.Type,
+			Target:     auditTarget(req),
+			Success:    resp.Success,
+			Error:      resp.Error,
+			Approval:   approval,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if err := d.store.InsertAuditEntry(entry); err != nil {
+			Logger.Warn("failed to record audit entry", "type", req.Type, "error", err)
+		}
+	}
+	return resp
+}
+
+// auditTarget describes what a request acts on: job IDs, a command or a
+// selection, from the fields its payload has
+func auditTarget(req *Request) string {
+	p := req.Payload
+	var parts []string
+	if action, ok := p["action"].(string); ok && action != "" {
+		parts = append(parts, action)
+	}
+	for _, key := range []string{"job_id", "run_id"} {
+		if id, ok := p[key].(string); ok && id != "" {
+			parts = append(parts, id)
+		}
+	}
+	for _, key := range []string{"job_ids", "run_ids", "command"} {
+		if values, ok := p[key].([]any); ok {
+			for _, v := range values {
+				parts = append(parts, fmt.Sprint(v))
+			}
+		}
+	}
+	if match, ok := p["match"].(string); ok && match != "" {
+		parts = append(parts, "--match "+match)
+	}
+	if tag, ok := p["tag"].(string); ok && tag != "" {
+		parts = append(parts, "--tag "+tag)
+	}
+	if id, ok := p["approval_id"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("#%d", int64(id)))
+	}
+	if pid, ok := p["pid"].(float64); ok {
+		parts = append(parts, fmt.Sprintf("pid %d", int(pid)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// handleAudit handles an audit request
+func (d *Daemon) handleAudit(req *Request) *Response {
+	var p AuditPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	f := AuditFilter{Client: p.Client, Limit: p.Limit}
+	if p.Since != "" {
+		since, err := time.Parse(time.RFC3339, p.Since)
+		if err != nil {
+			return NewErrorResponse(fmt.Errorf("invalid since: %w", err))
+		}
+		f.Since = since
+	}
+
+	if d.store == nil {
+		return NewDataResponse(AuditData{})
+	}
+	entries, err := d.store.ListAuditEntries(f)
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("failed to list audit log: %w", err))
+	}
+	return NewDataResponse(AuditData{Entries: entries})
+}
@@ -0,0 +1,200 @@
+package daemon
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Burst(t *testing.T) {
+	var l rateLimiter
+	now := time.Now()
+
+	for i := 0; i < rateLimitBurst; i++ {
+		if ok, _ := l.allow("claude", now); !ok {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+	ok, wait := l.allow("claude", now)
+	if ok {
+		t.Fatal("expected the request after the burst to be limited")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("expected a wait of at most a second, got %s", wait)
+	}
+
+	// Other clients have their own bucket
+	if ok, _ := l.allow("zsh", now); !ok {
+		t.Error("expected another client to be allowed")
+	}
+
+	// Tokens refill over time
+	if ok, _ := l.allow("claude", now.Add(time.Second)); !ok {
+		t.Error("expected a request to be allowed after a second")
+	}
+}
+
+func TestDaemon_RateLimitPerConnection(t *testing.T) {
+	d := &Daemon{jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)}
+	request := func(connID uint64) *Response {
+		return d.serveRequest(&Request{Type: RequestTypeSetPinned, Client: "bash", connID: connID, Payload: map[string]any{"job_id": "abc"}})
+	}
+
+	for i := 0; i < rateLimitBurst; i++ {
+		request(1)
+	}
+	if resp := request(1); !strings.Contains(resp.Error, "rate limit exceeded") {
+		t.Fatalf("expected the connection to be rate limited, got %+v", resp)
+	}
+
+	// Another connection of a client with the same name has its own bucket
+	if resp := request(2); strings.Contains(resp.Error, "rate limit exceeded") {
+		t.Errorf("expected another connection not to be rate limited, got %+v", resp)
+	}
+}
+
+func TestAuditTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]any
+		want    string
+	}{
+		{"job", map[string]any{"job_id": "abc"}, "abc"},
+		{"command", map[string]any{"command": []any{"npm", "run", "dev"}, "workdir": "/w"}, "npm run dev"},
+		{"batch", map[string]any{"action": "stop", "match": "npm"}, "stop --match npm"},
+		{"adopt", map[string]any{"pid": float64(1234)}, "pid 1234"},
+		{"none", map[string]any{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := auditTarget(&Request{Payload: tt.payload}); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDaemon_ServeRequestRecordsAudit(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+	d := &Daemon{store: store, jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)}
+
+	d.serveRequest(&Request{Type: RequestTypeStop, Client: "claude", Payload: map[string]any{"job_id": "missing"}})
+	d.serveRequest(&Request{Type: RequestTypeList, Client: "claude", Payload: map[string]any{}})
+
+	entries, err := store.ListAuditEntries(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the stop request to be recorded, got %d entries", len(entries))
+	}
+	e := entries[0]
+	if e.Client != "claude" || e.Type != RequestTypeStop || e.Target != "missing" || e.Success {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if !strings.Contains(e.Error, "missing") {
+		t.Errorf("expected the error to be recorded, got %q", e.Error)
+	}
+}
+
+func TestDaemon_RecordsOrigin(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, store)
+	d := &Daemon{store: store, jobManager: jm}
+
+	resp := d.handleRequest(&Request{Type: RequestTypeAdd, Client: "claude", ClientType: ClientTypeAPI,
+		Payload: map[string]any{"command": []any{"make"}, "workdir": "/workdir"}})
+	if !resp.Success {
+		t.Fatalf("add failed: %s", resp.Error)
+	}
+	job := jm.ListJobs("")[0]
+	stopAndWait(t, jm, executor, job.ID)
+
+	// Clients predating client types are taken for the CLI
+	resp = d.handleRequest(&Request{Type: RequestTypeStart, Client: "zsh", Payload: map[string]any{"job_id": job.ID}})
+	if !resp.Success {
+		t.Fatalf("start failed: %s", resp.Error)
+	}
+	got := jm.jobToResponse(job)
+	if got.CreatedBy != "claude" || got.CreatedVia != ClientTypeAPI || got.StartedBy != "zsh" || got.StartedVia != ClientTypeCLI {
+		t.Errorf("unexpected origins: created by %s via %s, started by %s via %s", got.CreatedBy, got.CreatedVia, got.StartedBy, got.StartedVia)
+	}
+
+	// Origins are persisted
+	jobs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jobs[0].CreatedBy != "claude" || jobs[0].CreatedVia != ClientTypeAPI {
+		t.Errorf("unexpected stored job origin %s via %s", jobs[0].CreatedBy, jobs[0].CreatedVia)
+	}
+	var startedBy []string
+	for _, run := range runs {
+		startedBy = append(startedBy, FormatOrigin(run.StartedBy, run.StartedVia))
+	}
+	slices.Sort(startedBy)
+	if !slices.Equal(startedBy, []string{"claude via api", "zsh"}) {
+		t.Errorf("unexpected stored run origins %v", startedBy)
+	}
+}
+
+func TestStore_AuditEntries(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	now := time.Now().UTC().Format(time.RFC3339)
+	store.InsertAuditEntry(AuditEntry{Time: old, Client: "claude", Type: RequestTypeRun, Target: "make", Success: true})
+	store.InsertAuditEntry(AuditEntry{Time: now, Client: "zsh", Type: RequestTypeStop, Target: "abc", Success: true})
+	store.InsertAuditEntry(AuditEntry{Time: now, Client: "claude", Type: RequestTypeRestart, Target: "abc", Error: "rate limit exceeded"})
+
+	entries, _ := store.ListAuditEntries(AuditFilter{})
+	if len(entries) != 3 || entries[0].Type != RequestTypeRun || entries[2].Error != "rate limit exceeded" {
+		t.Errorf("expected all entries oldest first, got %+v", entries)
+	}
+
+	entries, _ = store.ListAuditEntries(AuditFilter{Client: "claude"})
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries of claude, got %d", len(entries))
+	}
+
+	entries, _ = store.ListAuditEntries(AuditFilter{Since: time.Now().Add(-time.Hour)})
+	if len(entries) != 2 {
+		t.Errorf("expected 2 recent entries, got %d", len(entries))
+	}
+
+	entries, _ = store.ListAuditEntries(AuditFilter{Limit: 1})
+	if len(entries) != 1 || entries[0].Type != RequestTypeRestart {
+		t.Errorf("expected the most recent entry, got %+v", entries)
+	}
+
+	if err := store.DeleteAuditEntriesBefore(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ = store.ListAuditEntries(AuditFilter{})
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries after deleting old ones, got %d", len(entries))
+	}
+}
@@ -0,0 +1,339 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batch actions
+const (
+	BatchStop    = "stop"
+	BatchRestart = "restart"
+	BatchRemove  = "remove"
+	BatchSignal  = "signal"
+)
+
+// BatchRequest describes an action applied to several jobs in one request.
+// Jobs are selected by ID (or alias), by a glob matched against the command,
+// by tag, and/or by when they were last active and how their latest run
+// ended. Everything but IDs only considers jobs in Workdir (all if empty).
+type BatchRequest struct {
+	Action     string
+	JobIDs     []string
+	Match      string    // glob pattern, '*' matches any text and '?' one character
+	Tag        string    // only jobs with this tag
+	Before     time.Time // only jobs last active before this time
+	FailedOnly bool      // only jobs whose latest run failed
+	Workdir    string    // scope of Match, Tag, Before and FailedOnly
+	DryRun     bool      // report the jobs the action applies to without applying it
+	Force      bool      // stop: send SIGKILL; remove: include pinned jobs
+	Signal     int       // signal: signal number
+	Env        []string  // restart: environment for the new runs
+	EnvFrom    string    // restart: source of the new runs' environment (see StartPayload)
+}
+
+// selects reports whether the request selects jobs other than by ID
+func (b BatchRequest) selects() bool {
+	return b.Match != "" || b.Tag != "" || !b.Before.IsZero() || b.FailedOnly
+}
+
+// BatchResult is the outcome of a batch action for one job
+type BatchResult struct {
+	JobID   string       `json:"job_id"`
+	Success bool         `json:"success"`
+	Error   string       `json:"error,omitempty"`
+	PID     int          `json:"pid,omitempty"`
+	Job     *JobResponse `json:"job,omitempty"` // restart: the restarted job
+
+	// remove: the log files deleted with the job and their size
+	LogFiles []string `json:"log_files,omitempty"`
+	LogBytes int64    `json:"log_bytes,omitempty"`
+}
+
+// globToRegexp compiles a glob pattern matching a whole string
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	quoted := regexp.QuoteMeta(pattern)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.Compile("^" + quoted + "$")
+}
+
+// batchApplies reports whether a job selected by pattern or tag is a
+// sensible target for the request's action. Jobs named explicitly are always
+// used, so that errors are reported for them.
+func batchApplies(b BatchRequest, job *Job) bool {
+	switch b.Action {
+	case BatchStop, BatchSignal:
+		return job.IsRunning()
+	case BatchRestart:
+		return !job.Blocked
+	case BatchRemove:
+		return !job.IsRunning() && (!job.Pinned || b.Force)
+	}
+	return false
+}
+
+// selectBatchJobs returns the IDs targeted by a batch request in a single
+// snapshot. Explicit IDs come first (unknown ones are kept so that they are
+// reported as not found), followed by pattern and tag matches.
+func (jm *JobManager) selectBatchJobs(b BatchRequest) ([]string, error) {
+	var re *regexp.Regexp
+	if b.Match != "" {
+		var err error
+		re, err = globToRegexp(b.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", b.Match, err)
+		}
+	}
+
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, idOrAlias := range b.JobIDs {
+		id := jm.resolveJobIDLocked(idOrAlias)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if !b.selects() {
+		return ids, nil
+	}
+
+	var matched []*Job
+	for _, job := range jm.jobs {
+		if seen[job.ID] || (b.Workdir != "" && job.Workdir != b.Workdir) {
+			continue
+		}
+		if re != nil && !re.MatchString(strings.Join(job.Command, " ")) {
+			continue
+		}
+		if b.Tag != "" && !job.HasTag(b.Tag) {
+			continue
+		}
+		if !jm.batchFiltersLocked(b, job) {
+			continue
+		}
+		if batchApplies(b, job) {
+			matched = append(matched, job)
+		}
+	}
+
+	if len(ids) == 0 && len(matched) == 0 {
+		return nil, fmt.Errorf("no jobs matched")
+	}
+
+	// Oldest first, so results are stable
+	sortJobsByCreation(matched)
+	for _, job := range matched {
+		ids = append(ids, job.ID)
+	}
+	return ids, nil
+}
+
+// batchFiltersLocked reports whether a job was last active before the
+// request's Before time and its latest run failed, when asked (caller must
+// hold lock). A job is last active when its latest run stopped, or when it
+// was created if it never ran; running jobs are active now.
+func (jm *JobManager) batchFiltersLocked(b BatchRequest, job *Job) bool {
+	var latest *Run
+	if runs := jm.jobRunsLocked(job.ID); len(runs) > 0 {
+		latest = runs[len(runs)-1]
+	}
+	if b.FailedOnly && (latest == nil || latest.Status == "running" || latest.ExitCode == nil || *latest.ExitCode == 0) {
+		return false
+	}
+	if !b.Before.IsZero() {
+		lastActive := job.CreatedAt
+		switch {
+		case latest == nil:
+		case latest.Status == "running":
+			lastActive = time.Now()
+		case latest.StoppedAt != nil:
+			lastActive = *latest.StoppedAt
+		default:
+			lastActive = latest.StartedAt
+		}
+		if !lastActive.Before(b.Before) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortJobsByCreation sorts jobs by creation time, oldest first
+func sortJobsByCreation(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+}
+
+// validateBatchJobs checks in a single snapshot that the action of a batch
+// request applies to every selected job, returning the reason for each job
+// it does not apply to
+func (jm *JobManager) validateBatchJobs(b BatchRequest, ids []string) map[string]error {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	errs := make(map[string]error)
+	for _, id := range ids {
+		job, ok := jm.jobs[id]
+		if !ok {
+			errs[id] = fmt.Errorf("job not found: %s", id)
+			continue
+		}
+		switch b.Action {
+		case BatchSignal:
+			if !job.IsRunning() {
+				errs[id] = fmt.Errorf("job %s is not running", id)
+			}
+		case BatchRestart:
+			if job.Blocked {
+				errs[id] = &ErrJobBlocked{Description: job.Description}
+			}
+		case BatchRemove:
+			if job.IsRunning() {
+				errs[id] = fmt.Errorf("cannot remove running job: %s (use 'stop' first)", id)
+			} else if job.Pinned && !b.Force {
+				errs[id] = fmt.Errorf("cannot remove pinned job: %s (use --force, or 'gob unpin' first)", id)
+			}
+		}
+	}
+	return errs
+}
+
+// handleBatch handles a batch request: one action applied to several jobs.
+// Every job is validated before any action is applied, and if the action
+// does not apply to one of them none is touched. Jobs are then processed
+// concurrently and results are returned in selection order. Failures while
+// applying the action (e.g. a command that no longer starts) are reported
+// for their job and do not undo the action on the others. A dry run stops
+// after validation, reporting the jobs the action would apply to.
+func (d *Daemon) handleBatch(req *Request) *Response {
+	var p BatchPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	b := BatchRequest{
+		Action:     p.Action,
+		JobIDs:     p.JobIDs,
+		Match:      p.Match,
+		Tag:        p.Tag,
+		FailedOnly: p.FailedOnly,
+		Workdir:    p.Workdir,
+		DryRun:     p.DryRun,
+		Force:      p.Force,
+		Env:        p.Env,
+		EnvFrom:    p.EnvSource,
+	}
+	if p.Before != "" {
+		before, err := time.Parse(time.RFC3339, p.Before)
+		if err != nil {
+			return NewErrorResponse(fmt.Errorf("invalid before: %w", err))
+		}
+		b.Before = before
+	}
+	switch b.Action {
+	case BatchStop, BatchRestart, BatchRemove, BatchSignal:
+	default:
+		return NewErrorResponse(fmt.Errorf("invalid batch action: %q", b.Action))
+	}
+
+	if len(b.JobIDs) == 0 && !b.selects() {
+		return NewErrorResponse(fmt.Errorf("no jobs selected"))
+	}
+
+	if b.Action == BatchSignal {
+		if p.Signal == nil {
+			return NewErrorResponse(fmt.Errorf("missing signal"))
+		}
+		b.Signal = *p.Signal
+	}
+
+	ids, err := d.jobManager.selectBatchJobs(b)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	results := make([]BatchResult, len(ids))
+	if errs := d.jobManager.validateBatchJobs(b, ids); len(errs) > 0 {
+		for i, id := range ids {
+			results[i] = BatchResult{JobID: id, Error: "not applied: the batch has invalid jobs"}
+			if err, ok := errs[id]; ok {
+				results[i].Error = err.Error()
+			}
+		}
+		return NewDataResponse(BatchData{Results: results})
+	}
+
+	// Log files are gone once removed, so they are accounted for first
+	var usage []jobLogUsage
+	if b.Action == BatchRemove {
+		usage = make([]jobLogUsage, len(ids))
+		for i, id := range ids {
+			usage[i] = d.jobManager.jobLogUsage(id)
+		}
+	}
+
+	if b.DryRun {
+		for i, id := range ids {
+			results[i] = BatchResult{JobID: id, Success: true}
+			if usage != nil {
+				results[i].LogFiles, results[i].LogBytes = usage[i].files, usage[i].bytes
+			}
+		}
+		return NewDataResponse(BatchData{Results: results})
+	}
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+
+			var payload any
+			switch b.Action {
+			case BatchStop:
+				payload = StopPayload{JobID: id, Force: b.Force}
+			case BatchSignal:
+				payload = SignalPayload{JobID: id, Signal: &b.Signal}
+			case BatchRestart:
+				payload = StartPayload{JobID: id, Env: b.Env, EnvSource: b.EnvFrom}
+			case BatchRemove:
+				payload = RemovePayload{JobID: id, Force: b.Force}
+			default:
+				payload = JobPayload{JobID: id}
+			}
+			sub := NewTypedRequest(RequestType(b.Action), payload)
+			sub.Client, sub.ClientType = req.Client, req.ClientType
+
+			results[i] = batchResult(id, d.handleRequest(sub))
+			if usage != nil && results[i].Success {
+				results[i].LogFiles, results[i].LogBytes = usage[i].files, usage[i].bytes
+			}
+		}(i, id)
+	}
+	wg.Wait()
+
+	return NewDataResponse(BatchData{Results: results})
+}
+
+// batchResult converts the response of a single-job request into a result
+func batchResult(jobID string, resp *Response) BatchResult {
+	result := BatchResult{JobID: jobID, Success: resp.Success, Error: resp.Error}
+	if pid, ok := resp.Data["pid"].(int); ok {
+		result.PID = pid
+	}
+	if job, ok := resp.Data["job"].(JobResponse); ok {
+		result.Job = &job
+		result.PID = job.PID
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package daemon
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern string
+		input   string
+		want    bool
+	}{
+		{"npm run *", "npm run dev", true},
+		{"npm run *", "npm test", false},
+		{"*server*", "make server", true},
+		{"sleep ?", "sleep 5", true},
+		{"sleep ?", "sleep 50", false},
+		{"a.b", "axb", false},
+	}
+
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.pattern)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q) failed: %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.input); got != tt.want {
+			t.Errorf("globToRegexp(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestJobManager_selectBatchJobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	dev, _, _ := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{Tags: []string{"web"}}, nil)
+	time.Sleep(10 * time.Millisecond)
+	lint, _, _ := jm.AddJob([]string{"npm", "run", "lint"}, "/workdir", JobOptions{}, nil)
+	time.Sleep(10 * time.Millisecond)
+	jm.AddJob([]string{"npm", "run", "dev"}, "/other", JobOptions{}, nil)
+	jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Tags: []string{"web"}}, nil)
+	jm.SetAlias(lint.ID, "lint")
+
+	// Explicit IDs and aliases, deduplicated
+	ids, err := jm.selectBatchJobs(BatchRequest{Action: BatchStop, JobIDs: []string{dev.ID, "lint", dev.ID, "missing"}})
+	if err != nil {
+		t.Fatalf("selectBatchJobs failed: %v", err)
+	}
+	if len(ids) != 3 || ids[0] != dev.ID || ids[1] != lint.ID || ids[2] != "missing" {
+		t.Errorf("unexpected ids: %v", ids)
+	}
+
+	// Pattern, scoped to the workdir, oldest first
+	ids, err = jm.selectBatchJobs(BatchRequest{Action: BatchStop, Match: "npm run *", Workdir: "/workdir"})
+	if err != nil {
+		t.Fatalf("selectBatchJobs failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != dev.ID || ids[1] != lint.ID {
+		t.Errorf("expected [%s %s], got %v", dev.ID, lint.ID, ids)
+	}
+
+	// Pattern and tag together
+	ids, err = jm.selectBatchJobs(BatchRequest{Action: BatchStop, Match: "npm *", Tag: "web", Workdir: "/workdir"})
+	if err != nil {
+		t.Fatalf("selectBatchJobs failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != dev.ID {
+		t.Errorf("expected [%s], got %v", dev.ID, ids)
+	}
+
+	// Remove only selects stopped jobs
+	if _, err := jm.selectBatchJobs(BatchRequest{Action: BatchRemove, Match: "npm run *", Workdir: "/workdir"}); err == nil {
+		t.Error("expected error when no stopped jobs match")
+	}
+
+	if _, err := jm.selectBatchJobs(BatchRequest{Action: BatchStop, Tag: "nope"}); err == nil {
+		t.Error("expected error for unknown tag")
+	}
+}
+
+func TestDaemon_handleBatch_Signal(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	first, _, _ := jm.AddJob([]string{"sleep", "1"}, "/workdir", JobOptions{}, nil)
+	firstHandle := executor.LastHandle()
+	second, _, _ := jm.AddJob([]string{"sleep", "2"}, "/workdir", JobOptions{}, nil)
+
+	d := &Daemon{jobManager: jm}
+	req := NewRequest(RequestTypeBatch)
+	req.Payload["action"] = BatchSignal
+	req.Payload["job_ids"] = []interface{}{first.ID, second.ID}
+	// Signal 0 only checks the process group, fake PIDs are not real processes
+	req.Payload["signal"] = float64(0)
+
+	resp := d.handleRequest(req)
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	results, ok := resp.Data["results"].([]BatchResult)
+	if !ok || len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v", resp.Data["results"])
+	}
+	if !results[0].Success || results[0].JobID != first.ID || results[0].PID != firstHandle.Pid() {
+		t.Errorf("unexpected result for first job: %+v", results[0])
+	}
+	if !results[1].Success || results[1].JobID != second.ID {
+		t.Errorf("unexpected result for second job: %+v", results[1])
+	}
+}
+
+func TestDaemon_handleBatch_AllOrNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	running, _, _ := jm.AddJob([]string{"sleep", "1"}, "/workdir", JobOptions{}, nil)
+	stopped, _, _ := jm.AddJob([]string{"sleep", "2"}, "/workdir", JobOptions{}, nil)
+	stopAndWait(t, jm, executor, stopped.ID)
+
+	d := &Daemon{jobManager: jm}
+	req := NewRequest(RequestTypeBatch)
+	req.Payload["action"] = BatchStop
+	req.Payload["job_ids"] = []interface{}{running.ID, "missing"}
+
+	resp := d.handleRequest(req)
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	results := resp.Data["results"].([]BatchResult)
+	if len(results) != 2 || results[0].Success || results[1].Success || results[1].Error != "job not found: missing" {
+		t.Errorf("expected the whole batch to fail, got %+v", results)
+	}
+	if job, _ := jm.GetJob(running.ID); !job.IsRunning() {
+		t.Error("expected the valid job not to be stopped")
+	}
+
+	// Removing a running job refuses the whole batch too
+	req = NewRequest(RequestTypeBatch)
+	req.Payload["action"] = BatchRemove
+	req.Payload["job_ids"] = []interface{}{stopped.ID, running.ID}
+	resp = d.handleRequest(req)
+	results = resp.Data["results"].([]BatchResult)
+	if len(results) != 2 || results[0].Success || results[1].Success {
+		t.Errorf("expected the whole batch to fail, got %+v", results)
+	}
+	if _, err := jm.GetJob(stopped.ID); err != nil {
+		t.Error("expected the stopped job not to be removed")
+	}
+}
+
+func TestDaemon_handleBatch_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+	d := &Daemon{jobManager: jm}
+
+	req := NewRequest(RequestTypeBatch)
+	req.Payload["action"] = "explode"
+	req.Payload["job_ids"] = []interface{}{"abc"}
+	if resp := d.handleRequest(req); resp.Success {
+		t.Error("expected error for invalid action")
+	}
+
+	req = NewRequest(RequestTypeBatch)
+	req.Payload["action"] = BatchStop
+	if resp := d.handleRequest(req); resp.Success {
+		t.Error("expected error when no jobs are selected")
+	}
+
+	req = NewRequest(RequestTypeBatch)
+	req.Payload["action"] = BatchSignal
+	req.Payload["job_ids"] = []interface{}{"abc"}
+	if resp := d.handleRequest(req); resp.Success {
+		t.Error("expected error when signal is missing")
+	}
+}
+
+func TestJobManager_selectBatchJobs_Filters(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	failed, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	stopAndWait(t, jm, executor, failed.ID)
+	passed, _, _ := jm.AddJob([]string{"make", "lint"}, "/workdir", JobOptions{}, nil)
+	stopAndWait(t, jm, executor, passed.ID)
+	jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+
+	// The fake executor does not report exit codes
+	exitCode := 1
+	jm.mu.Lock()
+	jm.getLatestRunForJobLocked(failed.ID).ExitCode = &exitCode
+	jm.mu.Unlock()
+
+	ids, err := jm.selectBatchJobs(BatchRequest{Action: BatchRemove, FailedOnly: true, Workdir: "/workdir"})
+	if err != nil || len(ids) != 1 || ids[0] != failed.ID {
+		t.Errorf("expected only the failed job, got %v (%v)", ids, err)
+	}
+
+	// Jobs are last active when their latest run stopped
+	stoppedAt := time.Now().Add(-48 * time.Hour)
+	jm.mu.Lock()
+	jm.getLatestRunForJobLocked(passed.ID).StoppedAt = &stoppedAt
+	jm.mu.Unlock()
+	ids, err = jm.selectBatchJobs(BatchRequest{Action: BatchRemove, Before: time.Now().Add(-24 * time.Hour), Workdir: "/workdir"})
+	if err != nil || len(ids) != 1 || ids[0] != passed.ID {
+		t.Errorf("expected only the job stopped two days ago, got %v (%v)", ids, err)
+	}
+
+	if _, err := jm.selectBatchJobs(BatchRequest{Action: BatchRemove, FailedOnly: true, Before: time.Now().Add(-24 * time.Hour), Workdir: "/workdir"}); err == nil {
+		t.Error("expected no job to pass both filters")
+	}
+}
+
+func TestDaemon_handleBatch_DryRun(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	d := &Daemon{jobManager: jm}
+
+	job, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	run := jm.GetCurrentRun(job.ID)
+	os.WriteFile(run.StdoutPath, []byte("0123456789"), 0644)
+	stopAndWait(t, jm, executor, job.ID)
+
+	req := NewTypedRequest(RequestTypeBatch, BatchPayload{Action: BatchRemove, JobIDs: []string{job.ID}, DryRun: true})
+	resp := d.handleRequest(req)
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	results := resp.Data["results"].([]BatchResult)
+	if len(results) != 1 || !results[0].Success || results[0].LogBytes != 10 || !slices.Contains(results[0].LogFiles, run.StdoutPath) {
+		t.Errorf("expected the job's logs reported, got %+v", results)
+	}
+	if _, err := jm.GetJob(job.ID); err != nil {
+		t.Error("expected a dry run not to remove the job")
+	}
+
+	// Removing reports the space freed
+	req = NewTypedRequest(RequestTypeBatch, BatchPayload{Action: BatchRemove, JobIDs: []string{job.ID}})
+	results = d.handleRequest(req).Data["results"].([]BatchResult)
+	if len(results) != 1 || !results[0].Success || results[0].LogBytes != 10 {
+		t.Errorf("expected the job removed with its logs, got %+v", results)
+	}
+	if _, err := os.Stat(run.StdoutPath); !os.IsNotExist(err) {
+		t.Error("expected the logs removed")
+	}
+}
@@ -0,0 +1,160 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// OutputLimit bounds how much of a run's output is stored per log file: the
+// first Head bytes and the last Tail bytes, with a marker where the rest was
+// dropped. Zero keeps everything on that side (both zero means no limit).
+type OutputLimit struct {
+	Head int64
+	Tail int64
+}
+
+// ParseOutputSize parses the size of an output limit, such as "64K" or
+// "1M" (see ParseMemoryLimit)
+func ParseOutputSize(s string) (int64, error) {
+	size, ok := parseSize(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid output size %q (expected e.g. 64K or 1M)", s)
+	}
+	return size, nil
+}
+
+// IsZero reports whether the whole output is kept
+func (l OutputLimit) IsZero() bool {
+	return l.Head == 0 && l.Tail == 0
+}
+
+// headTailWriter keeps the first head bytes of output and the last tail
+// bytes. The head is written as it comes; the tail, after a marker telling
+// how much was elided, when the writer is flushed at the end of the run.
+type headTailWriter struct {
+	w       io.Writer
+	limit   OutputLimit
+	written int64  // head bytes written
+	last    byte   // last head byte written
+	past    int64  // bytes received after the head
+	ring    []byte // the most recent bytes after the head, up to twice the tail
+	cut     byte   // the byte before the ring, once it was trimmed
+}
+
+func newHeadTailWriter(w io.Writer, limit OutputLimit) *headTailWriter {
+	return &headTailWriter{w: w, limit: limit}
+}
+
+func (h *headTailWriter) Write(p []byte) (int, error) {
+	total := len(p)
+
+	// With only a tail limit, there is no head: everything waits for Flush
+	if room := h.limit.Head - h.written; room > 0 && len(p) > 0 {
+		n := int(min(int64(len(p)), room))
+		if _, err := h.w.Write(p[:n]); err != nil {
+			return 0, err
+		}
+		h.written += int64(n)
+		h.last = p[n-1]
+		p = p[n:]
+	}
+
+	h.past += int64(len(p))
+	if h.limit.Tail > 0 {
+		h.ring = append(h.ring, p...)
+		// Trim in bulk so each write does not copy the whole tail
+		if int64(len(h.ring)) > 2*h.limit.Tail {
+			start := int64(len(h.ring)) - h.limit.Tail
+			h.cut = h.ring[start-1]
+			h.ring = append(h.ring[:0:0], h.ring[start:]...)
+		}
+	}
+	return total, nil
+}
+
+// Flush writes the elision marker and the tail
+func (h *headTailWriter) Flush() error {
+	tail := h.ring
+	if int64(len(tail)) > h.limit.Tail {
+		tail = tail[int64(len(tail))-h.limit.Tail:]
+	}
+
+	elided := h.past - int64(len(tail))
+	if elided > 0 && len(tail) > 0 {
+		before := h.cut
+		if len(h.ring) > len(tail) {
+			before = h.ring[len(h.ring)-len(tail)-1]
+		}
+		// Start the tail at a line, unless it is a single line
+		if i := bytes.IndexByte(tail, '\n'); before != '\n' && i >= 0 && i < len(tail)-1 {
+			elided += int64(i + 1)
+			tail = tail[i+1:]
+		}
+	}
+
+	if elided > 0 {
+		marker := fmt.Sprintf("[gob: %s of output elided]\n", FormatBytes(elided))
+		if h.written > 0 && h.last != '\n' {
+			marker = "\n" + marker
+		}
+		if _, err := io.WriteString(h.w, marker); err != nil {
+			return err
+		}
+	}
+	_, err := h.w.Write(tail)
+	return err
+}
+
+// captureOutput takes ownership of an open log file and returns the write
+// end of a pipe to give to the process instead. Output read from the pipe is
+// copied to the log file, indexing when each line was written with
+// timestamps, and keeping only its head and tail with an output limit. The
+// returned channel is closed once the pipe reaches EOF and the files are closed.
+func captureOutput(logFile *os.File, logPath string, timestamps bool, limit OutputLimit) (*os.File, <-chan struct{}, error) {
+	var w io.Writer = logFile
+
+	var index *os.File
+	if timestamps {
+		var err error
+		index, err = os.OpenFile(TimestampIndexPath(logPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open timestamp index: %w", err)
+		}
+		w = &timestampWriter{log: logFile, index: index}
+	}
+
+	// The limit applies before the index, so the index matches the log
+	var limited *headTailWriter
+	if !limit.IsZero() {
+		limited = newHeadTailWriter(w, limit)
+		w = limited
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		if index != nil {
+			index.Close()
+		}
+		return nil, nil, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(w, reader)
+		if limited != nil {
+			if err := limited.Flush(); err != nil {
+				Logger.Debug("failed to write output tail", "path", logPath, "error", err)
+			}
+		}
+		reader.Close()
+		logFile.Close()
+		if index != nil {
+			index.Close()
+		}
+	}()
+
+	return writer, done, nil
+}
@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseOutputSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"100", 100},
+		{"64K", 64 << 10},
+		{"1M", 1 << 20},
+	}
+	for _, tt := range tests {
+		size, err := ParseOutputSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseOutputSize(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if size != tt.expected {
+			t.Errorf("ParseOutputSize(%q) = %d, expected %d", tt.input, size, tt.expected)
+		}
+	}
+
+	if _, err := ParseOutputSize("lots"); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
+
+func TestHeadTailWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    OutputLimit
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "small output kept whole",
+			limit:    OutputLimit{Head: 100, Tail: 100},
+			writes:   []string{"one\n", "two\n"},
+			expected: "one\ntwo\n",
+		},
+		{
+			name:     "head and tail around a marker",
+			limit:    OutputLimit{Head: 8, Tail: 9},
+			writes:   []string{"one\ntwo\n", "three\nfour\n", "five\nsix\n"},
+			expected: "one\ntwo\n[gob: 11 B of output elided]\nfive\nsix\n",
+		},
+		{
+			name:     "partial line dropped from the tail",
+			limit:    OutputLimit{Head: 8, Tail: 8},
+			writes:   []string{"one\ntwo\n", "three\nfour\n", "five\nsix\n"},
+			expected: "one\ntwo\n[gob: 16 B of output elided]\nsix\n",
+		},
+		{
+			name:     "tail starts at a line",
+			limit:    OutputLimit{Tail: 6},
+			writes:   []string{"one\ntwo\nthree\n"},
+			expected: "[gob: 8 B of output elided]\nthree\n",
+		},
+		{
+			name:     "head only",
+			limit:    OutputLimit{Head: 4},
+			writes:   []string{"one\n", "two\n", "three\n"},
+			expected: "one\n[gob: 10 B of output elided]\n",
+		},
+		{
+			name:     "head cut mid-line",
+			limit:    OutputLimit{Head: 2},
+			writes:   []string{"one\n"},
+			expected: "on\n[gob: 2 B of output elided]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newHeadTailWriter(&buf, tt.limit)
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestHeadTailWriter_LongTail(t *testing.T) {
+	var buf bytes.Buffer
+	w := newHeadTailWriter(&buf, OutputLimit{Tail: 10})
+	for i := 0; i < 1000; i++ {
+		w.Write([]byte("line\n"))
+	}
+	w.Flush()
+
+	if !strings.HasSuffix(buf.String(), "elided]\nline\nline\n") {
+		t.Errorf("expected the last two lines after the marker, got %q", buf.String())
+	}
+	if len(w.ring) > 20 {
+		t.Errorf("expected the kept tail to stay bounded, got %d bytes", len(w.ring))
+	}
+}
+
+func TestRealProcessExecutor_OutputLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	stdoutPath := filepath.Join(tmpDir, "run.stdout.log")
+	stderrPath := filepath.Join(tmpDir, "run.stderr.log")
+
+	executor := &RealProcessExecutor{}
+	handle, err := executor.Start([]string{"sh", "-c", "for i in 1 2 3 4 5 6 7 8 9; do echo line$i; done"}, tmpDir, os.Environ(),
+		stdoutPath, stderrPath, StartOptions{Output: OutputLimit{Head: 12, Tail: 12}})
+	if err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	handle.Wait()
+
+	stdout, _ := os.ReadFile(stdoutPath)
+	expected := "line1\nline2\n[gob: 30 B of output elided]\nline8\nline9\n"
+	if string(stdout) != expected {
+		t.Errorf("expected %q, got %q", expected, stdout)
+	}
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cpuMaxPeriod is the cgroup cpu.max period in microseconds
+const cpuMaxPeriod = 100000
+
+// ResourceLimits holds optional memory and CPU limits for a run.
+// Limits are enforced with a dedicated cgroup v2 per run (Linux only).
+type ResourceLimits struct {
+	MemoryBytes int64   // maximum memory in bytes (0 = unlimited)
+	CPUs        float64 // maximum CPU time in cores, e.g. 1.5 (0 = unlimited)
+	CPUWeight   int     // cpu.weight, the share of CPU under contention (0 = default)
+}
+
+// IsZero reports whether no limits are set. A CPU weight alone does not
+// call for a cgroup: it only applies to runs that have one anyway.
+func (l ResourceLimits) IsZero() bool {
+	return l.MemoryBytes == 0 && l.CPUs == 0
+}
+
+// LimitOff, as the memory or CPU limit of job options, removes the job's
+// limit. ParseMemoryLimit and ParseCPULimit return it for "off".
+const LimitOff = -1
+
+// ParseMemoryLimit parses a memory size such as "512M", "2G" or "1048576",
+// or "off". Suffixes are binary (K = 1024 bytes) and case-insensitive.
+func ParseMemoryLimit(s string) (int64, error) {
+	if strings.TrimSpace(s) == "off" {
+		return LimitOff, nil
+	}
+	size, ok := parseSize(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid memory limit %q (expected e.g. 512M or 2G)", s)
+	}
+	return size, nil
+}
+
+// parseSize parses a positive size in bytes with an optional binary suffix
+// (see ParseMemoryLimit)
+func parseSize(s string) (int64, bool) {
+	str := strings.TrimSpace(strings.ToUpper(s))
+	str = strings.TrimSuffix(str, "B")
+	str = strings.TrimSuffix(str, "I")
+
+	multiplier := int64(1)
+	if str != "" {
+		switch str[len(str)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier != 1 {
+			str = str[:len(str)-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return int64(value * float64(multiplier)), true
+}
+
+// ParseCPULimit parses a CPU limit in cores, such as "0.5" or "2", or "off"
+func ParseCPULimit(s string) (float64, error) {
+	if strings.TrimSpace(s) == "off" {
+		return LimitOff, nil
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid cpu limit %q (expected a number of cores, e.g. 1.5)", s)
+	}
+	return value, nil
+}
+
+// formatCPUMax returns the cpu.max value for a number of cores
+func formatCPUMax(cpus float64) string {
+	quota := int64(cpus * cpuMaxPeriod)
+	if quota < 1000 {
+		quota = 1000 // kernel minimum
+	}
+	return fmt.Sprintf("%d %d", quota, cpuMaxPeriod)
+}
+
+// runCgroup is a cgroup v2 directory holding the processes of a single run
+type runCgroup struct {
+	path string
+}
+
+// writeLimits writes the resource limits to the cgroup's control files
+func (c *runCgroup) writeLimits(limits ResourceLimits) error {
+	if limits.MemoryBytes > 0 {
+		value := strconv.FormatInt(limits.MemoryBytes, 10)
+		if err := os.WriteFile(filepath.Join(c.path, "memory.max"), []byte(value), 0644); err != nil {
+			return fmt.Errorf("failed to set memory limit: %w", err)
+		}
+	}
+	if limits.CPUs > 0 {
+		if err := os.WriteFile(filepath.Join(c.path, "cpu.max"), []byte(formatCPUMax(limits.CPUs)), 0644); err != nil {
+			return fmt.Errorf("failed to set cpu limit: %w", err)
+		}
+	}
+	if limits.CPUWeight > 0 {
+		value := strconv.Itoa(limits.CPUWeight)
+		if err := os.WriteFile(filepath.Join(c.path, "cpu.weight"), []byte(value), 0644); err != nil {
+			return fmt.Errorf("failed to set cpu weight: %w", err)
+		}
+	}
+	return nil
+}
+
+// pids returns the PIDs of all processes in the cgroup, including ones that
+// left the run's process group
+func (c *runCgroup) pids() []int {
+	data, err := os.ReadFile(filepath.Join(c.path, "cgroup.procs"))
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, line := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(line); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// signal sends a signal to every process in the cgroup. SIGKILL uses
+// cgroup.kill when available, which cannot be raced by forking children.
+func (c *runCgroup) signal(sig syscall.Signal) {
+	if sig == syscall.SIGKILL {
+		if err := os.WriteFile(filepath.Join(c.path, "cgroup.kill"), []byte("1"), 0644); err == nil {
+			return
+		}
+	}
+	killPIDs(c.pids(), sig)
+}
+
+// remove deletes the cgroup. Fails if processes are still inside it.
+func (c *runCgroup) remove() error {
+	return os.Remove(c.path)
+}
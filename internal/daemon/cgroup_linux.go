@@ -0,0 +1,141 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const cgroupMountPoint = "/sys/fs/cgroup"
+
+var (
+	cgroupParentOnce sync.Once
+	cgroupParent     string
+	cgroupParentErr  error
+)
+
+// createRunCgroup creates a cgroup for a run with the given limits.
+//
+// Run cgroups live under a "gob-runs" cgroup next to the daemon's own.
+// cgroup v2 only allows enabling controllers for children of a cgroup
+// without processes, so the daemon first moves itself into a "gob-daemon"
+// leaf of its original cgroup.
+func createRunCgroup(runID string, limits ResourceLimits) (*runCgroup, error) {
+	cgroupParentOnce.Do(func() {
+		cgroupParent, cgroupParentErr = setupCgroupParent()
+	})
+	if cgroupParentErr != nil {
+		return nil, cgroupParentErr
+	}
+
+	cg := &runCgroup{path: filepath.Join(cgroupParent, runID)}
+	if err := os.Mkdir(cg.path, 0755); err != nil && !os.IsExist(err) {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	if err := cg.writeLimits(limits); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	return cg, nil
+}
+
+// setupCgroupParent prepares the cgroup that holds all run cgroups
+func setupCgroupParent() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMountPoint, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not available at %s", cgroupMountPoint)
+	}
+
+	current, err := currentCgroup()
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Join(cgroupMountPoint, current)
+
+	// Already moved (e.g. the daemon was re-executed in place)
+	if filepath.Base(base) == "gob-daemon" {
+		base = filepath.Dir(base)
+	} else {
+		leaf := filepath.Join(base, "gob-daemon")
+		if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create daemon cgroup (is the cgroup delegated to this user?): %w", err)
+		}
+		pid := strconv.Itoa(os.Getpid())
+		if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644); err != nil {
+			return "", fmt.Errorf("failed to move daemon into its cgroup: %w", err)
+		}
+	}
+
+	if err := enableCgroupControllers(base); err != nil {
+		return "", err
+	}
+
+	parent := filepath.Join(base, "gob-runs")
+	if err := os.Mkdir(parent, 0755); err != nil && !os.IsExist(err) {
+		return "", fmt.Errorf("failed to create runs cgroup: %w", err)
+	}
+	if err := enableCgroupControllers(parent); err != nil {
+		return "", err
+	}
+
+	return parent, nil
+}
+
+// enableCgroupControllers enables the cpu and memory controllers for the children of a cgroup
+func enableCgroupControllers(path string) error {
+	if err := os.WriteFile(filepath.Join(path, "cgroup.subtree_control"), []byte("+cpu +memory"), 0644); err != nil {
+		return fmt.Errorf("failed to enable cpu and memory controllers in %s: %w", path, err)
+	}
+	return nil
+}
+
+// currentCgroup returns the daemon's cgroup v2 path relative to the mount point
+func currentCgroup() (string, error) {
+	return processCgroup("self")
+}
+
+// findRunCgroup returns the cgroup of a run started by a previous daemon, or
+// "" if its process is not in a run cgroup (the run has no resource limits)
+func findRunCgroup(pid int, runID string) string {
+	path, err := processCgroup(strconv.Itoa(pid))
+	if err != nil || filepath.Base(path) != runID || filepath.Base(filepath.Dir(path)) != "gob-runs" {
+		return ""
+	}
+	return filepath.Join(cgroupMountPoint, path)
+}
+
+// processCgroup returns the cgroup v2 path of a process ("self" for the
+// daemon) relative to the mount point
+func processCgroup(proc string) (string, error) {
+	f, err := os.Open(filepath.Join("/proc", proc, "cgroup"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroup: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// cgroup v2 entries have the form "0::<path>"
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("process is not in a cgroup v2 hierarchy")
+}
+
+// openCgroup opens the cgroup directory and configures the process attributes
+// so the child starts inside it (clone3 CLONE_INTO_CGROUP). The returned file
+// must stay open until the process has started.
+func openCgroup(cg *runCgroup, attr *syscall.SysProcAttr) (*os.File, error) {
+	dir, err := os.Open(cg.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(dir.Fd())
+	return dir, nil
+}
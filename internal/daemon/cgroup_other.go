@@ -0,0 +1,24 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// createRunCgroup is not supported outside Linux
+func createRunCgroup(runID string, limits ResourceLimits) (*runCgroup, error) {
+	return nil, fmt.Errorf("resource limits require cgroup v2 (Linux only)")
+}
+
+// openCgroup is not supported outside Linux
+func openCgroup(cg *runCgroup, attr *syscall.SysProcAttr) (*os.File, error) {
+	return nil, fmt.Errorf("resource limits require cgroup v2 (Linux only)")
+}
+
+// findRunCgroup always returns "" outside Linux, runs have no cgroup
+func findRunCgroup(pid int, runID string) string {
+	return ""
+}
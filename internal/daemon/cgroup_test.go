@@ -0,0 +1,113 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{"1048576", 1048576, false},
+		{"512K", 512 << 10, false},
+		{"512M", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"2g", 2 << 30, false},
+		{"2GB", 2 << 30, false},
+		{"2GiB", 2 << 30, false},
+		{"1.5G", 3 << 29, false},
+		{"off", LimitOff, false},
+		{"", 0, true},
+		{"0", 0, true},
+		{"-1G", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMemoryLimit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMemoryLimit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseMemoryLimit(%q) = %d, expected %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCPULimit(t *testing.T) {
+	if got, err := ParseCPULimit("1.5"); err != nil || got != 1.5 {
+		t.Errorf("ParseCPULimit(1.5) = %v, %v", got, err)
+	}
+	if got, err := ParseCPULimit("off"); err != nil || got != LimitOff {
+		t.Errorf("ParseCPULimit(off) = %v, %v", got, err)
+	}
+	for _, input := range []string{"", "0", "-2", "two"} {
+		if _, err := ParseCPULimit(input); err == nil {
+			t.Errorf("ParseCPULimit(%q) expected error", input)
+		}
+	}
+}
+
+func TestFormatCPUMax(t *testing.T) {
+	tests := []struct {
+		cpus     float64
+		expected string
+	}{
+		{1, "100000 100000"},
+		{1.5, "150000 100000"},
+		{0.25, "25000 100000"},
+		{0.001, "1000 100000"}, // clamped to kernel minimum
+	}
+
+	for _, tt := range tests {
+		if got := formatCPUMax(tt.cpus); got != tt.expected {
+			t.Errorf("formatCPUMax(%v) = %q, expected %q", tt.cpus, got, tt.expected)
+		}
+	}
+}
+
+func TestRunCgroup_WriteLimits(t *testing.T) {
+	cg := &runCgroup{path: t.TempDir()}
+
+	if err := cg.writeLimits(ResourceLimits{MemoryBytes: 1024, CPUs: 2, CPUWeight: 50}); err != nil {
+		t.Fatalf("writeLimits failed: %v", err)
+	}
+
+	memory, _ := os.ReadFile(filepath.Join(cg.path, "memory.max"))
+	if string(memory) != "1024" {
+		t.Errorf("expected memory.max 1024, got %q", memory)
+	}
+	cpu, _ := os.ReadFile(filepath.Join(cg.path, "cpu.max"))
+	if string(cpu) != "200000 100000" {
+		t.Errorf("expected cpu.max '200000 100000', got %q", cpu)
+	}
+	weight, _ := os.ReadFile(filepath.Join(cg.path, "cpu.weight"))
+	if string(weight) != "50" {
+		t.Errorf("expected cpu.weight 50, got %q", weight)
+	}
+}
+
+func TestRunCgroup_PIDs(t *testing.T) {
+	cg := &runCgroup{path: t.TempDir()}
+	if err := os.WriteFile(filepath.Join(cg.path, "cgroup.procs"), []byte("12\n34\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := cg.pids(); !reflect.DeepEqual(got, []int{12, 34}) {
+		t.Errorf("expected [12 34], got %v", got)
+	}
+}
+
+func TestMergePIDs(t *testing.T) {
+	got := mergePIDs([]int{1, 2, 3}, []int{3, 4, 1, 5})
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", got)
+	}
+}
@@ -1,11 +1,15 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,27 +19,62 @@ import (
 // ErrOldDaemon is returned when the daemon does not support version negotiation
 var ErrOldDaemon = errors.New("daemon does not support version negotiation")
 
+// ErrNotResponding is returned when the daemon does not answer a request
+// before the client's timeout or the deadline of its context
+var ErrNotResponding = errors.New("daemon not responding")
+
 // ErrVersionMismatch is returned when client and daemon versions don't match
+// and the daemon could not be upgraded
 type ErrVersionMismatch struct {
 	DaemonVersion string
 	ClientVersion string
+	Reason        string // why the daemon did not hand over, empty if it was not asked to
 }
 
 func (e *ErrVersionMismatch) Error() string {
-	return fmt.Sprintf("version mismatch: daemon=%s, client=%s\nRun 'gob shutdown' to stop the daemon, then run your command again.",
-		e.DaemonVersion, e.ClientVersion)
+	reason := ""
+	if e.Reason != "" {
+		reason = fmt.Sprintf("\nThe daemon could not hand its jobs over: %s", e.Reason)
+	}
+	return fmt.Sprintf("version mismatch: daemon=%s, client=%s%s\nRun 'gob shutdown' to stop the daemon, then run your command again.",
+		e.DaemonVersion, e.ClientVersion, reason)
 }
 
 // VersionInfo contains daemon version information
 type VersionInfo struct {
-	Version     string // Semantic version (e.g., "1.2.3")
-	RunningJobs int    // Number of currently running jobs
+	Version         string   // Semantic version (e.g., "1.2.3")
+	ProtocolVersion int      // 0 for daemons predating protocol versions
+	RunningJobs     int      // Number of currently running jobs
+	Capabilities    []string // See Capabilities; nil for daemons predating them
+}
+
+// Supports reports whether the daemon announced a capability
+func (v *VersionInfo) Supports(capability string) bool {
+	for _, c := range v.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
 }
 
 // Client represents a client connection to the daemon
 type Client struct {
 	conn       net.Conn
 	socketPath string
+	timeout    time.Duration   // per request, 0 means no timeout
+	name       string          // sent with each request, see ClientName
+	clientType string          // sent with each request: cli, tui or api
+	ctx        context.Context // ends the requests when done, nil for none
+
+	shared *sharedState // shared with the copies made by WithContext
+}
+
+// sharedState is the state of a client shared with its copies
+type sharedState struct {
+	mu      sync.Mutex
+	session *session // nil unless StartSession was called
+	closed  bool     // Close was called, so subscriptions do not reconnect
 }
 
 // NewClient creates a new daemon client
@@ -47,9 +86,110 @@ func NewClient() (*Client, error) {
 
 	return &Client{
 		socketPath: socketPath,
+		name:       ClientName(),
+		clientType: ClientTypeCLI,
+		shared:     &sharedState{},
 	}, nil
 }
 
+// SetTimeout limits how long each request may take. Used where a slow
+// daemon must not block the caller, such as shell completion.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetClientType sets the type of client sent with each request, recorded
+// with the jobs it creates and the runs it starts. Clients are of type cli
+// unless set.
+func (c *Client) SetClientType(clientType string) {
+	c.clientType = clientType
+}
+
+// WithContext returns a copy of the client whose requests end when ctx is
+// done, failing with ErrNotResponding if its deadline passed and with
+// ctx.Err() if it was canceled. Used where a hung daemon must not freeze the
+// caller, such as the TUI. The copy shares the connection and session of
+// the client, so only the client needs to be closed.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// context returns the context of the client's requests
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// deadline returns when a request started now must end: after the client's
+// timeout or at the deadline of ctx, whichever comes first
+func (c *Client) deadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if c.timeout > 0 {
+		if limit := time.Now().Add(c.timeout); !ok || limit.Before(deadline) {
+			return limit, true
+		}
+	}
+	return deadline, ok
+}
+
+// requestError describes why a request failed: the daemon did not answer in
+// time, the context was canceled, or the connection failed
+func requestError(ctx context.Context, what string, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return ctx.Err()
+	case ctx.Err() != nil, errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrNotResponding
+	}
+	return fmt.Errorf("%s: %w", what, err)
+}
+
+// StartSession makes the client send its requests over one persistent
+// connection instead of a new connection per request, for clients sending
+// many of them, such as the TUI. The client can then be used from several
+// goroutines at once. A lost connection is opened again on the next request.
+// Call after Connect, which starts the daemon if needed; the connection it
+// opened is closed, so subscriptions need a client of their own.
+func (c *Client) StartSession() error {
+	s, err := openSession(c.socketPath, c.timeout, c.name)
+	if err != nil {
+		return err
+	}
+
+	c.shared.mu.Lock()
+	defer c.shared.mu.Unlock()
+	if c.shared.session != nil {
+		c.shared.session.close()
+	}
+	c.shared.session = s
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	return nil
+}
+
+// currentSession returns the client's session, opened again if its
+// connection was lost, or nil if the client does not use one
+func (c *Client) currentSession() (*session, error) {
+	c.shared.mu.Lock()
+	defer c.shared.mu.Unlock()
+	if c.shared.session == nil || c.shared.session.failure() == nil {
+		return c.shared.session, nil
+	}
+
+	s, err := openSession(c.socketPath, c.timeout, c.name)
+	if err != nil {
+		return nil, err
+	}
+	c.shared.session = s
+	return s, nil
+}
+
 // Connect connects to the daemon, auto-starting it if necessary
 func (c *Client) Connect() error {
 	return c.connect(false)
@@ -60,10 +200,22 @@ func (c *Client) ConnectSkipVersionCheck() error {
 	return c.connect(true)
 }
 
+// ConnectRunning connects to the daemon only if it is already running.
+// Used where starting it would be a surprising side effect, such as the
+// shell prompt.
+func (c *Client) ConnectRunning() error {
+	conn, err := dialIPC(c.socketPath, c.timeout)
+	if err != nil {
+		return fmt.Errorf("daemon is not running")
+	}
+	c.conn = conn
+	return c.CheckDaemonVersion()
+}
+
 // connect is the internal connection logic
 func (c *Client) connect(skipVersionCheck bool) error {
 	// Try to connect
-	conn, err := net.Dial("unix", c.socketPath)
+	conn, err := dialIPC(c.socketPath, c.timeout)
 	if err == nil {
 		c.conn = conn
 		// Check daemon version compatibility (unless skipped)
@@ -74,13 +226,18 @@ func (c *Client) connect(skipVersionCheck bool) error {
 	}
 
 	// Connection failed - try to start daemon
+	return c.startDaemon()
+}
+
+// startDaemon starts the daemon and connects to it
+func (c *Client) startDaemon() error {
 	if err := StartDaemon(); err != nil {
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 
 	// Retry connection with timeout
 	for i := 0; i < 20; i++ {
-		conn, err := net.Dial("unix", c.socketPath)
+		conn, err := dialIPC(c.socketPath, 0)
 		if err == nil {
 			c.conn = conn
 			// No need to check version - we just started the daemon
@@ -94,25 +251,59 @@ func (c *Client) connect(skipVersionCheck bool) error {
 
 // SendRequest sends a request to the daemon and returns the response
 func (c *Client) SendRequest(req *Request) (*Response, error) {
+	if req.Client == "" {
+		req.Client = c.name
+	}
+	if req.ClientType == "" {
+		req.ClientType = c.clientType
+	}
+
+	ctx := c.context()
+	if err := ctx.Err(); err != nil {
+		return nil, requestError(ctx, "", err)
+	}
+	deadline, hasDeadline := c.deadline(ctx)
+
+	s, err := c.currentSession()
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		return s.send(ctx, req, deadline)
+	}
+
 	// Reconnect for each request (daemon closes connection after each response)
-	conn, err := net.Dial("unix", c.socketPath)
+	dialTimeout := c.timeout
+	if hasDeadline {
+		dialTimeout = time.Until(deadline)
+	}
+	conn, err := dialIPC(c.socketPath, dialTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
 	}
 	defer conn.Close()
 
+	if hasDeadline {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+	// Unblock the request if the context is canceled first
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
 	encoder := json.NewEncoder(conn)
 	decoder := json.NewDecoder(conn)
 
 	// Send request
 	if err := encoder.Encode(req); err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, requestError(ctx, "failed to send request", err)
 	}
 
 	// Decode response
 	var resp Response
 	if err := decoder.Decode(&resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, requestError(ctx, "failed to decode response", err)
 	}
 
 	return &resp, nil
@@ -150,12 +341,22 @@ func (c *Client) Shutdown() error {
 
 // List returns all jobs, optionally filtered by workdir
 func (c *Client) List(workdir string) ([]JobResponse, error) {
-	req := NewRequest(RequestTypeList)
-	if workdir != "" {
-		req.Payload["workdir"] = workdir
-	}
+	return c.ListByTag(workdir, "")
+}
 
-	resp, err := c.SendRequest(req)
+// ListByTag returns the jobs that have the given tag (all jobs if tag is empty)
+func (c *Client) ListByTag(workdir string, tag string) ([]JobResponse, error) {
+	return c.list(ListPayload{Workdir: workdir, Tag: tag})
+}
+
+// ListWithPorts is ListByTag with the listening ports of running jobs
+// scanned at the time of the request, and summarized in PortsSummary
+func (c *Client) ListWithPorts(workdir string, tag string) ([]JobResponse, error) {
+	return c.list(ListPayload{Workdir: workdir, Tag: tag, Ports: true})
+}
+
+func (c *Client) list(p ListPayload) ([]JobResponse, error) {
+	resp, err := c.SendRequest(NewTypedRequest(RequestTypeList, p))
 	if err != nil {
 		return nil, err
 	}
@@ -164,38 +365,38 @@ func (c *Client) List(workdir string) ([]JobResponse, error) {
 		return nil, fmt.Errorf("list failed: %s", resp.Error)
 	}
 
-	// Parse jobs from response
-	jobsRaw, ok := resp.Data["jobs"]
-	if !ok {
-		return []JobResponse{}, nil
-	}
-
-	// Convert to JobResponse slice
-	jobsJSON, err := json.Marshal(jobsRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal jobs: %w", err)
+	var data ListData
+	if err := resp.Decode(&data); err != nil {
+		return nil, err
 	}
+	return data.Jobs, nil
+}
 
-	var jobs []JobResponse
-	if err := json.Unmarshal(jobsJSON, &jobs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal jobs: %w", err)
-	}
+// Add creates and starts a new job with the given environment and options
+func (c *Client) Add(command []string, workdir string, env []string, opts JobOptions) (*AddResponse, error) {
+	return c.add(RequestTypeAdd, command, workdir, env, opts)
+}
 
-	return jobs, nil
+// Run adds a job and starts it like Add, unless another client started the
+// same command moments ago: then that run is returned, marked as deduplicated
+func (c *Client) Run(command []string, workdir string, env []string, opts JobOptions) (*AddResponse, error) {
+	return c.add(RequestTypeRun, command, workdir, env, opts)
 }
 
-// Add creates and starts a new job with the given environment and optional description
-func (c *Client) Add(command []string, workdir string, env []string, description string, blocked bool) (*AddResponse, error) {
-	req := NewRequest(RequestTypeAdd)
-	req.Payload["command"] = command
-	req.Payload["workdir"] = workdir
-	req.Payload["env"] = env
-	if description != "" {
-		req.Payload["description"] = description
-	}
-	if blocked {
-		req.Payload["blocked"] = true
+// add sends an add or run request
+func (c *Client) add(reqType RequestType, command []string, workdir string, env []string, opts JobOptions) (*AddResponse, error) {
+	env, err := filterClientEnv(env)
+	if err != nil {
+		return nil, err
 	}
+	req := NewTypedRequest(reqType, AddPayload{
+		Command:           command,
+		Workdir:           workdir,
+		Env:               env,
+		Label:             opts.RunLabel,
+		Project:           PortableProject(workdir),
+		JobOptionsPayload: jobOptionsPayload(opts),
+	})
 
 	resp, err := c.SendRequest(req)
 	if err != nil {
@@ -203,46 +404,24 @@ func (c *Client) Add(command []string, workdir string, env []string, description
 	}
 
 	if !resp.Success {
-		return nil, fmt.Errorf("add failed: %s", resp.Error)
-	}
-
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
-	}
-
-	jobJSON, err := json.Marshal(jobRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+		return nil, fmt.Errorf("%s failed: %s", reqType, resp.Error)
 	}
 
-	result := &AddResponse{Job: job}
-
-	// Parse action
-	if action, ok := resp.Data["action"].(string); ok {
-		result.Action = action
+	var result AddResponse
+	if err := resp.Decode(&result); err != nil {
+		return nil, err
 	}
-
-	return result, nil
+	return &result, nil
 }
 
 // Create creates a job without starting it (for autostart=false jobs)
-func (c *Client) Create(command []string, workdir string, description string, blocked bool) (*JobResponse, error) {
-	req := NewRequest(RequestTypeCreate)
-	req.Payload["command"] = command
-	req.Payload["workdir"] = workdir
-	if description != "" {
-		req.Payload["description"] = description
-	}
-	if blocked {
-		req.Payload["blocked"] = true
-	}
+func (c *Client) Create(command []string, workdir string, opts JobOptions) (*JobResponse, error) {
+	req := NewTypedRequest(RequestTypeCreate, AddPayload{
+		Command:           command,
+		Workdir:           workdir,
+		Project:           PortableProject(workdir),
+		JobOptionsPayload: jobOptionsPayload(opts),
+	})
 
 	resp, err := c.SendRequest(req)
 	if err != nil {
@@ -253,152 +432,278 @@ func (c *Client) Create(command []string, workdir string, description string, bl
 		return nil, fmt.Errorf("create failed: %s", resp.Error)
 	}
 
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
-	}
-
-	jobJSON, err := json.Marshal(jobRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	var data JobData
+	if err := resp.Decode(&data); err != nil {
+		return nil, err
 	}
+	return &data.Job, nil
+}
 
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+// jobOptionsPayload converts job options to their request payload
+func jobOptionsPayload(opts JobOptions) JobOptionsPayload {
+	return JobOptionsPayload{
+		Description:   opts.Description,
+		Blocked:       opts.Blocked,
+		Priority:      string(opts.Priority),
+		MemoryLimit:   opts.MemoryLimit,
+		CPULimit:      opts.CPULimit,
+		JobHooks:      opts.Hooks,
+		Stdin:         opts.Stdin,
+		LogFormat:     opts.LogFormat,
+		Timestamps:    opts.Timestamps,
+		CombineOutput: opts.CombineOutput,
+		OutputHead:    opts.OutputHead,
+		OutputTail:    opts.OutputTail,
+		LogDir:        opts.LogDir,
+		Container:     opts.Container,
+		DevEnv:        opts.DevEnv,
+		Sandbox:       opts.Sandbox,
+		RunDir:        opts.RunDir,
+		ReloadSignal:  opts.ReloadSignal,
+		EnvOverrides:  opts.EnvOverrides,
+		Tags:          opts.Tags,
+		Shell:         opts.Shell,
 	}
-
-	return &job, nil
 }
 
-// Stop stops a running job
-func (c *Client) Stop(jobID string, force bool) (int, error) {
-	req := NewRequest(RequestTypeStop)
-	req.Payload["job_id"] = jobID
-	req.Payload["force"] = force
-
+// request sends a request and decodes the data of a successful response
+// into data (if not nil). A failed request's error is the daemon's.
+func (c *Client) request(req *Request, data any) error {
 	resp, err := c.SendRequest(req)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
 	if !resp.Success {
-		return 0, fmt.Errorf("%s", resp.Error)
+		return errors.New(resp.Error)
 	}
 
-	pid, _ := resp.Data["pid"].(float64)
-	return int(pid), nil
+	if data == nil {
+		return nil
+	}
+	return resp.Decode(data)
 }
 
-// Start starts a stopped job with the given environment
-func (c *Client) Start(jobID string, env []string) (*JobResponse, error) {
-	req := NewRequest(RequestTypeStart)
-	req.Payload["job_id"] = jobID
-	req.Payload["env"] = env
-
-	resp, err := c.SendRequest(req)
-	if err != nil {
+// requestJob sends a request answered with a job
+func (c *Client) requestJob(req *Request) (*JobResponse, error) {
+	var data JobData
+	if err := c.request(req, &data); err != nil {
 		return nil, err
 	}
+	return &data.Job, nil
+}
 
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
+// Stop stops a running job
+func (c *Client) Stop(jobID string, force bool) (int, error) {
+	var data StopData
+	if err := c.request(NewTypedRequest(RequestTypeStop, StopPayload{JobID: jobID, Force: force}), &data); err != nil {
+		return 0, err
 	}
+	return data.PID, nil
+}
 
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
+// Start starts a stopped job with the given environment
+func (c *Client) Start(jobID string, env []string, envSource string) (*JobResponse, error) {
+	env, err := filterClientEnv(env)
+	if err != nil {
+		return nil, err
 	}
+	return c.requestJob(NewTypedRequest(RequestTypeStart, StartPayload{JobID: jobID, Env: env, EnvSource: envSource}))
+}
 
-	jobJSON, err := json.Marshal(jobRaw)
+// Restart restarts a job with the given environment
+func (c *Client) Restart(jobID string, env []string, envSource string) (*JobResponse, error) {
+	env, err := filterClientEnv(env)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
+		return nil, err
 	}
+	return c.requestJob(NewTypedRequest(RequestTypeRestart, StartPayload{JobID: jobID, Env: env, EnvSource: envSource}))
+}
 
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+// Remove removes a stopped job
+func (c *Client) Remove(jobID string, force bool) (int, error) {
+	var data RemoveData
+	if err := c.request(NewTypedRequest(RequestTypeRemove, RemovePayload{JobID: jobID, Force: force}), &data); err != nil {
+		return 0, err
 	}
+	return data.PID, nil
+}
 
-	return &job, nil
+// RemoveRun removes a stopped run and its log files
+func (c *Client) RemoveRun(runID string) error {
+	return c.request(NewTypedRequest(RequestTypeRemoveRun, RemoveRunPayload{RunID: runID}), nil)
 }
 
-// Restart restarts a job with the given environment
-func (c *Client) Restart(jobID string, env []string) (*JobResponse, error) {
-	req := NewRequest(RequestTypeRestart)
-	req.Payload["job_id"] = jobID
-	req.Payload["env"] = env
+// Adopt registers a running process as a job in workdir, named by command
+// or by the process's command line if command is empty
+func (c *Client) Adopt(pid int, command []string, workdir string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeAdopt, AdoptPayload{PID: pid, Command: command, Workdir: workdir}))
+}
 
-	resp, err := c.SendRequest(req)
-	if err != nil {
-		return nil, err
+// StatsAll returns the time spent and failures of each job in workdir (all
+// directories if empty), counting the time spent by runs started after since
+func (c *Client) StatsAll(workdir string, since time.Time) ([]JobTimeSpent, int64, error) {
+	var data StatsAllData
+	if err := c.request(NewTypedRequest(RequestTypeStatsAll, StatsAllPayload{Workdir: workdir, Since: since.UTC().Format(time.RFC3339)}), &data); err != nil {
+		return nil, 0, err
 	}
+	return data.Jobs, data.TotalDurationMs, nil
+}
 
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
+// DiskUsage returns the size of the stored logs of each job in workdir (all
+// directories if empty), largest first, and their total
+func (c *Client) DiskUsage(workdir string) ([]JobDiskUsage, int64, error) {
+	var data DiskUsageData
+	if err := c.request(NewTypedRequest(RequestTypeDiskUsage, DiskUsagePayload{Workdir: workdir}), &data); err != nil {
+		return nil, 0, err
 	}
+	return data.Jobs, data.TotalBytes, nil
+}
 
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
+// ArchiveRuns archives the stopped runs of jobs in workdir (all directories
+// if empty) started before cutoff. Returns the runs archived and the bytes
+// saved by gzipping their logs.
+func (c *Client) ArchiveRuns(workdir string, cutoff time.Time) (int, int64, error) {
+	var data ArchiveRunsData
+	if err := c.request(NewTypedRequest(RequestTypeArchiveRuns, ArchiveRunsPayload{Workdir: workdir, Before: cutoff.UTC().Format(time.RFC3339)}), &data); err != nil {
+		return 0, 0, err
 	}
+	return data.RunsArchived, data.BytesSaved, nil
+}
 
-	jobJSON, err := json.Marshal(jobRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
+// PruneLogs removes the stopped runs of each job in workdir (all directories
+// if empty) but the keep most recent, skipping pinned jobs unless force is
+// set. Returns the runs removed and the bytes freed.
+func (c *Client) PruneLogs(workdir string, keep int, force bool) (int, int64, error) {
+	var data PruneLogsData
+	if err := c.request(NewTypedRequest(RequestTypePruneLogs, PruneLogsPayload{Workdir: workdir, Keep: keep, Force: force}), &data); err != nil {
+		return 0, 0, err
 	}
+	return data.RunsRemoved, data.BytesFreed, nil
+}
 
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+// CleanupLogs removes the stopped runs of jobs in workdir (all directories if
+// empty) whose log files are missing. Returns the runs removed.
+func (c *Client) CleanupLogs(workdir string) (int, error) {
+	var data CleanupLogsData
+	if err := c.request(NewTypedRequest(RequestTypeCleanupLogs, CleanupLogsPayload{Workdir: workdir}), &data); err != nil {
+		return 0, err
 	}
+	return data.RunsRemoved, nil
+}
 
-	return &job, nil
+// Orphans returns the runs that are not running but still have processes
+func (c *Client) Orphans() ([]Orphan, error) {
+	var data OrphansData
+	if err := c.request(NewRequest(RequestTypeOrphans), &data); err != nil {
+		return nil, err
+	}
+	return data.Orphans, nil
 }
 
-// Remove removes a stopped job
-func (c *Client) Remove(jobID string) (int, error) {
-	req := NewRequest(RequestTypeRemove)
-	req.Payload["job_id"] = jobID
+// KillOrphans stops the processes of the given orphan runs, with SIGKILL
+// right away if force is set, and returns the orphans stopped
+func (c *Client) KillOrphans(runIDs []string, force bool) ([]Orphan, error) {
+	var data OrphansData
+	if err := c.request(NewTypedRequest(RequestTypeKillOrphans, KillOrphansPayload{RunIDs: runIDs, Force: force}), &data); err != nil {
+		return nil, err
+	}
+	return data.Orphans, nil
+}
 
-	resp, err := c.SendRequest(req)
-	if err != nil {
-		return 0, err
+// Approvals returns the requests waiting for approval, oldest first
+func (c *Client) Approvals() ([]Approval, error) {
+	var data ApprovalsData
+	if err := c.request(NewRequest(RequestTypeApprovals), &data); err != nil {
+		return nil, err
 	}
+	return data.Approvals, nil
+}
 
-	if !resp.Success {
-		return 0, fmt.Errorf("%s", resp.Error)
+// Approve approves a request waiting for approval, or denies it, and returns
+// it with its decision
+func (c *Client) Approve(id int64, approve bool) (*Approval, error) {
+	action := "deny"
+	if approve {
+		action = "approve"
+	}
+	var data ApproveData
+	if err := c.request(NewTypedRequest(RequestTypeApprove, ApprovePayload{ApprovalID: id, Action: action}), &data); err != nil {
+		return nil, err
 	}
+	return &data.Approval, nil
+}
 
-	pid, _ := resp.Data["pid"].(float64)
-	return int(pid), nil
+// SetPinned pins or unpins a job: bulk removals and pruning skip pinned jobs
+func (c *Client) SetPinned(jobID string, pinned bool) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeSetPinned, SetPinnedPayload{JobID: jobID, Pinned: pinned}))
 }
 
-// RemoveRun removes a stopped run and its log files
-func (c *Client) RemoveRun(runID string) error {
-	req := NewRequest(RequestTypeRemoveRun)
-	req.Payload["run_id"] = runID
+// SetAlias assigns an alias to a job, or removes it when alias is empty
+func (c *Client) SetAlias(jobID string, alias string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeSetAlias, SetAliasPayload{JobID: jobID, Alias: alias}))
+}
 
-	resp, err := c.SendRequest(req)
-	if err != nil {
-		return err
+// SetSlowThreshold sets when runs of a job count as slow, e.g. 5m or 2x, or
+// removes the threshold when empty
+func (c *Client) SetSlowThreshold(jobID string, threshold string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeSetSlowThreshold, SetSlowThresholdPayload{JobID: jobID, Threshold: threshold}))
+}
+
+// SetDescription replaces the description of a job, or removes it when
+// description is empty
+func (c *Client) SetDescription(jobID string, description string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeSetDescription, SetDescriptionPayload{JobID: jobID, Description: description}))
+}
+
+// AnnotateRun replaces the note of a run, or removes it when note is empty
+func (c *Client) AnnotateRun(runID string, note string) (*RunResponse, error) {
+	var data RunData
+	if err := c.request(NewTypedRequest(RequestTypeAnnotateRun, AnnotateRunPayload{RunID: runID, Note: note}), &data); err != nil {
+		return nil, err
 	}
+	return &data.Run, nil
+}
 
-	if !resp.Success {
-		return fmt.Errorf("%s", resp.Error)
+// Batch applies an action to several jobs in one request and returns the
+// result for each job
+func (c *Client) Batch(b BatchRequest) ([]BatchResult, error) {
+	p := BatchPayload{
+		Action:     b.Action,
+		JobIDs:     b.JobIDs,
+		Match:      b.Match,
+		Tag:        b.Tag,
+		FailedOnly: b.FailedOnly,
+		Workdir:    b.Workdir,
+		DryRun:     b.DryRun,
+	}
+	if !b.Before.IsZero() {
+		p.Before = b.Before.Format(time.RFC3339)
+	}
+	switch b.Action {
+	case BatchStop, BatchRemove:
+		p.Force = b.Force
+	case BatchSignal:
+		p.Signal = &b.Signal
+	case BatchRestart:
+		env, err := filterClientEnv(b.Env)
+		if err != nil {
+			return nil, err
+		}
+		p.Env = env
+		p.EnvSource = b.EnvFrom
 	}
 
-	return nil
+	var data BatchData
+	if err := c.request(NewTypedRequest(RequestTypeBatch, p), &data); err != nil {
+		return nil, err
+	}
+	return data.Results, nil
 }
 
 // StopAll stops all running jobs
 func (c *Client) StopAll() (stopped int, err error) {
-	req := NewRequest(RequestTypeStopAll)
-
-	resp, err := c.SendRequest(req)
+	resp, err := c.SendRequest(NewRequest(RequestTypeStopAll))
 	if err != nil {
 		return 0, err
 	}
@@ -407,198 +712,174 @@ func (c *Client) StopAll() (stopped int, err error) {
 		return 0, fmt.Errorf("stop_all failed: %s", resp.Error)
 	}
 
-	stoppedF, _ := resp.Data["stopped"].(float64)
-	return int(stoppedF), nil
+	var data StopAllData
+	if err := resp.Decode(&data); err != nil {
+		return 0, err
+	}
+	return data.Stopped, nil
 }
 
 // Signal sends a signal to a job
 func (c *Client) Signal(jobID string, signal syscall.Signal) (int, error) {
-	req := NewRequest(RequestTypeSignal)
-	req.Payload["job_id"] = jobID
-	req.Payload["signal"] = int(signal)
-
-	resp, err := c.SendRequest(req)
-	if err != nil {
+	signalNum := int(signal)
+	var data SignalData
+	if err := c.request(NewTypedRequest(RequestTypeSignal, SignalPayload{JobID: jobID, Signal: &signalNum}), &data); err != nil {
 		return 0, err
 	}
-
-	if !resp.Success {
-		return 0, fmt.Errorf("%s", resp.Error)
-	}
-
-	pid, _ := resp.Data["pid"].(float64)
-	return int(pid), nil
+	return data.PID, nil
 }
 
-// GetJob returns a job by ID
-func (c *Client) GetJob(jobID string) (*JobResponse, error) {
-	req := NewRequest(RequestTypeGetJob)
-	req.Payload["job_id"] = jobID
-
-	resp, err := c.SendRequest(req)
-	if err != nil {
+// Reload sends a job its reload signal without starting a new run
+func (c *Client) Reload(jobID string) (*ReloadData, error) {
+	var data ReloadData
+	if err := c.request(NewTypedRequest(RequestTypeReload, ReloadPayload{JobID: jobID}), &data); err != nil {
 		return nil, err
 	}
+	return &data, nil
+}
 
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
-	}
-
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
-	}
-
-	jobJSON, err := json.Marshal(jobRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+// MarkStuck records on the current run of a job that it looks stuck
+func (c *Client) MarkStuck(jobID string) (*RunResponse, error) {
+	var data RunData
+	if err := c.request(NewTypedRequest(RequestTypeMarkStuck, JobPayload{JobID: jobID}), &data); err != nil {
+		return nil, err
 	}
+	return &data.Run, nil
+}
 
-	return &job, nil
+// GetJob returns a job by ID
+func (c *Client) GetJob(jobID string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeGetJob, JobPayload{JobID: jobID}))
 }
 
 // Runs returns the run history for a job
 func (c *Client) Runs(jobID string) ([]RunResponse, error) {
-	req := NewRequest(RequestTypeRuns)
-	req.Payload["job_id"] = jobID
+	return c.runs(RunsPayload{JobID: jobID})
+}
 
-	resp, err := c.SendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+// RunsInWorkdir returns the run history of all jobs in a directory
+func (c *Client) RunsInWorkdir(workdir string) ([]RunResponse, error) {
+	return c.runs(RunsPayload{Workdir: workdir})
+}
 
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
+// RunsPage returns a page of the runs of a job, newest first, along with
+// the total number of runs of the job
+func (c *Client) RunsPage(p RunsPayload) ([]RunResponse, int, error) {
+	var data RunsData
+	if err := c.request(NewTypedRequest(RequestTypeRuns, p), &data); err != nil {
+		return nil, 0, err
 	}
+	return data.Runs, data.Total, nil
+}
 
-	// Parse runs from response
-	runsRaw, ok := resp.Data["runs"]
-	if !ok {
-		return []RunResponse{}, nil
+// runs sends a runs request and returns the runs in the response
+func (c *Client) runs(p RunsPayload) ([]RunResponse, error) {
+	var data RunsData
+	if err := c.request(NewTypedRequest(RequestTypeRuns, p), &data); err != nil {
+		return nil, err
 	}
+	return data.Runs, nil
+}
 
-	runsJSON, err := json.Marshal(runsRaw)
+// History returns recent runs across jobs in a workdir (all if empty) with
+// a label (any if empty), newest first, along with the total number of runs
+// available
+func (c *Client) History(workdir, label string, offset, limit int) ([]HistoryEntry, int, error) {
+	var data HistoryData
+	err := c.request(NewTypedRequest(RequestTypeHistory, HistoryPayload{Workdir: workdir, Label: label, Offset: offset, Limit: limit}), &data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal runs: %w", err)
-	}
-
-	var runs []RunResponse
-	if err := json.Unmarshal(runsJSON, &runs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal runs: %w", err)
+		return nil, 0, err
 	}
-
-	return runs, nil
+	return data.Runs, data.Total, nil
 }
 
-// Stats returns statistics for a job (as a JobResponse with stats fields populated)
-func (c *Client) Stats(jobID string) (*JobResponse, error) {
-	req := NewRequest(RequestTypeStats)
-	req.Payload["job_id"] = jobID
+// GrepRequest describes a search over stored run logs
+type GrepRequest struct {
+	Pattern    string
+	IgnoreCase bool
+	JobID      string    // only this job (all if empty)
+	Workdir    string    // only jobs in this directory (all if empty)
+	Since      time.Time // only runs active since this time (all if zero)
+	MaxMatches int       // daemon default if 0
+}
 
-	resp, err := c.SendRequest(req)
-	if err != nil {
-		return nil, err
+// Grep searches the stored logs of runs. Returns the matching lines, oldest
+// run first, and whether the results were cut off at the maximum.
+func (c *Client) Grep(g GrepRequest) ([]GrepMatch, bool, error) {
+	p := GrepPayload{
+		Pattern:    g.Pattern,
+		IgnoreCase: g.IgnoreCase,
+		JobID:      g.JobID,
+		Workdir:    g.Workdir,
+		MaxMatches: g.MaxMatches,
 	}
-
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
+	if !g.Since.IsZero() {
+		p.Since = g.Since.Format(time.RFC3339)
 	}
 
-	// Parse job from response
-	jobRaw, ok := resp.Data["job"]
-	if !ok {
-		return nil, fmt.Errorf("no job in response")
+	var data GrepData
+	if err := c.request(NewTypedRequest(RequestTypeGrep, p), &data); err != nil {
+		return nil, false, err
 	}
+	return data.Matches, data.Truncated, nil
+}
 
-	jobJSON, err := json.Marshal(jobRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
+// LogLines returns the stdout lines of a job's latest run at or above a
+// log level, and the number of lines too long to read their level. The job
+// must have JSON logs.
+func (c *Client) LogLines(jobID string, level string) ([]string, int, error) {
+	var data LogLinesData
+	if err := c.request(NewTypedRequest(RequestTypeLogLines, LogLinesPayload{JobID: jobID, Level: level}), &data); err != nil {
+		return nil, 0, err
 	}
-
-	var job JobResponse
-	if err := json.Unmarshal(jobJSON, &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	if data.Lines == nil {
+		return []string{}, data.Skipped, nil
 	}
+	return data.Lines, data.Skipped, nil
+}
+
+// Stats returns statistics for a job (as a JobResponse with stats fields populated)
+func (c *Client) Stats(jobID string) (*JobResponse, error) {
+	return c.StatsIn(jobID, nil)
+}
 
-	return &job, nil
+// StatsIn returns statistics for the runs of a job started in all the
+// conditions, e.g. battery or busy (see RunConditions)
+func (c *Client) StatsIn(jobID string, conditions []string) (*JobResponse, error) {
+	return c.requestJob(NewTypedRequest(RequestTypeStats, StatsPayload{JobID: jobID, Conditions: conditions}))
 }
 
 // Ports returns the listening ports for a job
 func (c *Client) Ports(jobID string) (*JobPorts, error) {
-	req := NewRequest(RequestTypePorts)
-	req.Payload["job_id"] = jobID
-
-	resp, err := c.SendRequest(req)
-	if err != nil {
+	var data JobPortsData
+	if err := c.request(NewTypedRequest(RequestTypePorts, PortsPayload{JobID: jobID}), &data); err != nil {
 		return nil, err
 	}
-
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
-	}
-
-	// Parse ports from response
-	portsRaw, ok := resp.Data["ports"]
-	if !ok {
+	if data.Ports == nil {
 		return nil, fmt.Errorf("no ports in response")
 	}
-
-	portsJSON, err := json.Marshal(portsRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal ports: %w", err)
-	}
-
-	var ports JobPorts
-	if err := json.Unmarshal(portsJSON, &ports); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal ports: %w", err)
-	}
-
-	return &ports, nil
+	return data.Ports, nil
 }
 
 // AllPorts returns the listening ports for all running jobs
 func (c *Client) AllPorts(workdir string) ([]JobPorts, error) {
-	req := NewRequest(RequestTypePorts)
-	if workdir != "" {
-		req.Payload["workdir"] = workdir
-	}
-
-	resp, err := c.SendRequest(req)
-	if err != nil {
+	var data AllPortsData
+	if err := c.request(NewTypedRequest(RequestTypePorts, PortsPayload{Workdir: workdir}), &data); err != nil {
 		return nil, err
 	}
-
-	if !resp.Success {
-		return nil, fmt.Errorf("%s", resp.Error)
-	}
-
-	// Parse ports from response
-	portsRaw, ok := resp.Data["ports"]
-	if !ok {
-		return []JobPorts{}, nil
-	}
-
-	portsJSON, err := json.Marshal(portsRaw)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal ports: %w", err)
-	}
-
-	var ports []JobPorts
-	if err := json.Unmarshal(portsJSON, &ports); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal ports: %w", err)
-	}
-
-	return ports, nil
+	return data.Ports, nil
 }
 
 // Close closes the connection to the daemon
 func (c *Client) Close() error {
+	c.shared.mu.Lock()
+	defer c.shared.mu.Unlock()
+	c.shared.closed = true
+	if c.shared.session != nil {
+		c.shared.session.close()
+		c.shared.session = nil
+	}
+
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -607,8 +888,10 @@ func (c *Client) Close() error {
 
 // GetDaemonVersion retrieves version information from the daemon
 func (c *Client) GetDaemonVersion() (*VersionInfo, error) {
-	req := NewRequest(RequestTypeVersion)
-	req.Payload["client_version"] = version.Version
+	req := NewTypedRequest(RequestTypeVersion, VersionPayload{
+		ClientVersion:   version.Version,
+		ProtocolVersion: ProtocolVersion,
+	})
 
 	resp, err := c.SendRequest(req)
 	if err != nil {
@@ -623,10 +906,15 @@ func (c *Client) GetDaemonVersion() (*VersionInfo, error) {
 		return nil, fmt.Errorf("version check failed: %s", resp.Error)
 	}
 
-	// Parse version info from daemon
+	var data VersionData
+	if err := resp.Decode(&data); err != nil {
+		return nil, err
+	}
 	return &VersionInfo{
-		Version:     resp.Data["version"].(string),
-		RunningJobs: int(resp.Data["running_jobs"].(float64)),
+		Version:         data.Version,
+		ProtocolVersion: data.ProtocolVersion,
+		RunningJobs:     data.RunningJobs,
+		Capabilities:    data.Capabilities,
 	}, nil
 }
 
@@ -645,11 +933,94 @@ func (c *Client) CheckDaemonVersion() error {
 		return nil
 	}
 
-	// Version mismatch - return error (don't restart daemon to avoid version wars)
-	return &ErrVersionMismatch{
+	mismatch := &ErrVersionMismatch{
 		DaemonVersion: info.Version,
 		ClientVersion: version.Version,
 	}
+
+	// Never replace a newer daemon, so that two installed versions don't
+	// keep replacing each other
+	if !info.Supports(string(RequestTypeHandover)) || versionNewer(info.Version, version.Version) {
+		return mismatch
+	}
+
+	if err := c.upgradeDaemon(); err != nil {
+		mismatch.Reason = err.Error()
+		return mismatch
+	}
+	return nil
+}
+
+// upgradeDaemon replaces the daemon with one of the client's version: the
+// daemon hands its running jobs over and exits, and the new daemon adopts them
+func (c *Client) upgradeDaemon() error {
+	if err := c.HandOver(); err != nil {
+		return err
+	}
+
+	// A socket left in place belongs to systemd, which starts the new
+	// daemon on the next connection
+	if ipcExists(c.socketPath) {
+		return nil
+	}
+	return c.startDaemon()
+}
+
+// HandOver asks the daemon to hand its running jobs over to the next daemon
+// and exit, and waits until it has
+func (c *Client) HandOver() error {
+	pidPath, err := GetPIDPath()
+	if err != nil {
+		return err
+	}
+
+	if err := c.request(NewRequest(RequestTypeHandover), nil); err != nil {
+		return err
+	}
+	c.Close()
+	c.conn = nil
+
+	// The daemon removes its PID file last when exiting
+	for i := 0; i < 50; i++ {
+		if _, err := os.Stat(pidPath); os.IsNotExist(err) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon did not exit after handing over")
+}
+
+// versionNewer reports whether version a is newer than version b. Versions
+// that are not releases (such as development builds) are never newer.
+func versionNewer(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersion parses a release version such as "1.2.3" or "v1.2.3"
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
 }
 
 // handleOldDaemon handles a daemon that doesn't support version negotiation
@@ -661,44 +1032,272 @@ func (c *Client) handleOldDaemon() error {
 	}
 }
 
+// SubscribeOptions selects what the daemon sends before new events
+type SubscribeOptions struct {
+	// Snapshot sends an EventTypeSnapshot event with the current jobs and
+	// runs first
+	Snapshot bool
+
+	// Replay sends the events numbered after Since first. If the daemon no
+	// longer has all of them, it sends a snapshot instead.
+	Replay bool
+	Since  uint64
+
+	// Match leaves out the events that do not match it (including replayed
+	// ones, but not the snapshot)
+	Match EventMatch
+
+	// Reconnect subscribes again when the connection to the daemon is lost,
+	// such as when it restarts or is upgraded, replaying the events missed
+	// meanwhile (or sending a snapshot if the daemon no longer has them).
+	// The subscription ends if the daemon does not come back within 30
+	// seconds.
+	Reconnect bool
+
+	// Coalesce asks for at most one event every 50ms. A burst of events is
+	// replaced by an EventTypeResync event with the current jobs and runs
+	// (of the directory, regardless of Match).
+	Coalesce bool
+}
+
+// Events returns the recorded events matching the filter, oldest first, and
+// the sequence number of the last event. Subscribing with Replay after that
+// number continues without missing events.
+func (c *Client) Events(f EventFilter) ([]Event, uint64, error) {
+	p := EventsPayload{Workdir: f.Workdir, After: f.AfterID}
+	if !f.Since.IsZero() {
+		p.Since = f.Since.Format(time.RFC3339)
+	}
+
+	var data EventsData
+	if err := c.request(NewTypedRequest(RequestTypeEvents, p), &data); err != nil {
+		return nil, 0, err
+	}
+	return data.Events, data.Seq, nil
+}
+
+// Audit returns the audit log entries matching the filter, oldest first
+func (c *Client) Audit(f AuditFilter) ([]AuditEntry, error) {
+	p := AuditPayload{Client: f.Client, Limit: f.Limit}
+	if !f.Since.IsZero() {
+		p.Since = f.Since.Format(time.RFC3339)
+	}
+
+	var data AuditData
+	if err := c.request(NewTypedRequest(RequestTypeAudit, p), &data); err != nil {
+		return nil, err
+	}
+	return data.Entries, nil
+}
+
+// ExportRuns returns a page of the stored runs matching the filter, oldest
+// first, and the cursor to pass as f.After for the next page (0 on the last
+// page). A zero f.Limit uses the daemon's default page size.
+func (c *Client) ExportRuns(f RunFilter) ([]HistoryEntry, int64, error) {
+	p := ExportRunsPayload{JobID: f.JobID, Workdir: f.Workdir, After: f.After, Limit: f.Limit}
+	if !f.Since.IsZero() {
+		p.Since = f.Since.Format(time.RFC3339)
+	}
+
+	var data ExportRunsData
+	if err := c.request(NewTypedRequest(RequestTypeExportRuns, p), &data); err != nil {
+		return nil, 0, err
+	}
+	return data.Runs, data.Next, nil
+}
+
+// ShellPresence sends a heartbeat of the shell with the given PID in project
+// ("" when it is in none), registering stopJobs to stop once no shell has
+// been in the project for stopAfter. Returns whether the shell entered the
+// project with it.
+func (c *Client) ShellPresence(pid int, project string, stopJobs []string, stopAfter time.Duration) (bool, error) {
+	p := ShellPresencePayload{PID: pid, Project: project, StopJobs: stopJobs, StopAfterMs: stopAfter.Milliseconds()}
+
+	var data ShellPresenceData
+	if err := c.request(NewTypedRequest(RequestTypeShellPresence, p), &data); err != nil {
+		return false, err
+	}
+	return data.Entered, nil
+}
+
+// GatewayStart starts the daemon's HTTP gateway on addr (empty for the
+// default address)
+func (c *Client) GatewayStart(addr string) (*GatewayInfo, error) {
+	return c.sendGatewayRequest(NewTypedRequest(RequestTypeGatewayStart, GatewayStartPayload{Addr: addr}))
+}
+
+// GatewayStop stops the daemon's HTTP gateway
+func (c *Client) GatewayStop() error {
+	return c.request(NewRequest(RequestTypeGatewayStop), nil)
+}
+
+// Health returns the uptime, paths, counts, last error and background tasks
+// of the daemon
+func (c *Client) Health() (*HealthData, error) {
+	var data HealthData
+	if err := c.request(NewRequest(RequestTypeHealth), &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GatewayStatus returns the running HTTP gateway, nil if it is not running
+func (c *Client) GatewayStatus() (*GatewayInfo, error) {
+	return c.sendGatewayRequest(NewRequest(RequestTypeGatewayStatus))
+}
+
+// sendGatewayRequest sends a gateway request and returns the gateway in the
+// response, if any
+func (c *Client) sendGatewayRequest(req *Request) (*GatewayInfo, error) {
+	var data GatewayData
+	if err := c.request(req, &data); err != nil {
+		return nil, err
+	}
+	return data.Gateway, nil
+}
+
 // Subscribe subscribes to daemon events and calls the callback for each event
 // This blocks until an error occurs or the connection is closed
 func (c *Client) Subscribe(workdir string, callback func(Event) error) error {
+	return c.SubscribeWithOptions(workdir, SubscribeOptions{}, callback)
+}
+
+// reconnectTimeout is how long a subscription with Reconnect waits for the
+// daemon to come back after losing its connection
+const reconnectTimeout = 30 * time.Second
+
+// SubscribeWithOptions is Subscribe with a snapshot or replayed events sent
+// before new events
+func (c *Client) SubscribeWithOptions(workdir string, opts SubscribeOptions, callback func(Event) error) error {
 	if c.conn == nil {
 		return fmt.Errorf("not connected to daemon")
 	}
 
-	encoder := json.NewEncoder(c.conn)
-	decoder := json.NewDecoder(c.conn)
+	conn := c.conn
+	for {
+		seq, lost, err := subscribe(conn, workdir, opts, callback)
+		if !lost || !opts.Reconnect {
+			return err
+		}
+
+		// Pick up after the last event received
+		if conn = c.reconnect(); conn == nil {
+			return err
+		}
+		opts.Snapshot = false
+		opts.Replay, opts.Since = true, seq
+	}
+}
+
+// subscribe subscribes on conn and calls the callback for each event, until
+// it returns an error or the connection ends. Returns the sequence number of
+// the last event received, and whether the connection was lost (rather than
+// closed by the client).
+func subscribe(conn net.Conn, workdir string, opts SubscribeOptions, callback func(Event) error) (seq uint64, lost bool, err error) {
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
 
 	// Send subscribe request
-	req := NewRequest(RequestTypeSubscribe)
-	if workdir != "" {
-		req.Payload["workdir"] = workdir
+	p := SubscribePayload{Workdir: workdir, Snapshot: opts.Snapshot, Coalesce: opts.Coalesce, EventMatch: opts.Match}
+	if opts.Replay {
+		p.Since = &opts.Since
 	}
+	req := NewTypedRequest(RequestTypeSubscribe, p)
 
 	if err := encoder.Encode(req); err != nil {
-		return fmt.Errorf("failed to send subscribe request: %w", err)
+		return 0, false, fmt.Errorf("failed to send subscribe request: %w", err)
 	}
 
 	// Read initial response
 	var resp Response
 	if err := decoder.Decode(&resp); err != nil {
-		return fmt.Errorf("failed to decode subscribe response: %w", err)
+		return 0, false, fmt.Errorf("failed to decode subscribe response: %w", err)
 	}
 
 	if !resp.Success {
-		return fmt.Errorf("subscribe failed: %s", resp.Error)
+		return 0, false, fmt.Errorf("subscribe failed: %s", resp.Error)
+	}
+
+	// Replayed events come before the newer ones
+	if opts.Replay {
+		seq = opts.Since
+	} else {
+		var data MessageData
+		resp.Decode(&data)
+		seq = data.Seq
 	}
 
 	// Read events in a loop
 	for {
 		var event Event
 		if err := decoder.Decode(&event); err != nil {
-			return fmt.Errorf("failed to decode event: %w", err)
+			return seq, !errors.Is(err, net.ErrClosed), fmt.Errorf("failed to decode event: %w", err)
 		}
+		seq = max(seq, event.Seq)
 
 		if err := callback(event); err != nil {
+			return seq, false, err
+		}
+	}
+}
+
+// reconnect connects again to the daemon for a subscription that lost its
+// connection, waiting up to reconnectTimeout. Returns nil if the daemon did
+// not come back or the client was closed meanwhile.
+func (c *Client) reconnect() net.Conn {
+	deadline := time.Now().Add(reconnectTimeout)
+	for {
+		conn, err := dialIPC(c.socketPath, c.timeout)
+
+		c.shared.mu.Lock()
+		closed := c.shared.closed
+		if err == nil && !closed {
+			c.conn = conn
+		}
+		c.shared.mu.Unlock()
+
+		switch {
+		case err == nil && closed:
+			conn.Close()
+			return nil
+		case err == nil:
+			return conn
+		case closed, time.Now().After(deadline):
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Stream sends a request that keeps the connection open, such as subscribe,
+// and calls onResponse with the daemon's response, then onEvent with each
+// event. This blocks until a callback returns an error or the connection is
+// closed (see Close).
+func (c *Client) Stream(req *Request, onResponse func(*Response) error, onEvent func(Event) error) error {
+	if c.conn == nil {
+		return fmt.Errorf("not connected to daemon")
+	}
+
+	decoder := json.NewDecoder(c.conn)
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := onResponse(&resp); err != nil || !resp.Success {
+		return err
+	}
+
+	for {
+		var event Event
+		if err := decoder.Decode(&event); err != nil {
+			return fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		if err := onEvent(event); err != nil {
 			return err
 		}
 	}
@@ -708,6 +1307,12 @@ func (c *Client) Subscribe(workdir string, callback func(Event) error) error {
 // The caller should select on both channels and handle events/errors appropriately
 // To stop the subscription, close the client connection
 func (c *Client) SubscribeChan(workdir string) (<-chan Event, <-chan error) {
+	return c.SubscribeChanWithOptions(workdir, SubscribeOptions{})
+}
+
+// SubscribeChanWithOptions is SubscribeChan with a snapshot or replayed
+// events sent before new events
+func (c *Client) SubscribeChanWithOptions(workdir string, opts SubscribeOptions) (<-chan Event, <-chan error) {
 	eventCh := make(chan Event, 10)
 	errCh := make(chan error, 1)
 
@@ -715,7 +1320,7 @@ func (c *Client) SubscribeChan(workdir string) (<-chan Event, <-chan error) {
 		defer close(eventCh)
 		defer close(errCh)
 
-		err := c.Subscribe(workdir, func(event Event) error {
+		err := c.SubscribeWithOptions(workdir, opts, func(event Event) error {
 			eventCh <- event
 			return nil
 		})
@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestClient_SubscribeReconnect(t *testing.T) {
+	// Unix socket paths are limited in length, so avoid t.TempDir
+	dir, err := os.MkdirTemp("", "gob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := socketPath(dir)
+
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(t.TempDir(), d.handleEvent, NewFakeProcessExecutor(), nil)
+
+	// listen serves connections like the daemon, handing over each accepted one
+	conns := make(chan net.Conn, 10)
+	listen := func() net.Listener {
+		listener, err := listenIPC(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				conns <- conn
+				go d.handleConnection(conn)
+			}
+		}()
+		return listener
+	}
+	waitSubscribers := func(want int) {
+		for range 200 {
+			d.subscribersMu.RLock()
+			n := len(d.subscribers)
+			d.subscribersMu.RUnlock()
+			if n == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected %d subscribers", want)
+	}
+	nextAdded := func(events <-chan Event) Event {
+		for {
+			select {
+			case event := <-events:
+				if event.Type == EventTypeJobAdded {
+					return event
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected a job_added event")
+			}
+		}
+	}
+
+	listener := listen()
+	client := &Client{socketPath: path, shared: &sharedState{}}
+	if client.conn, err = dialIPC(path, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	events, errs := client.SubscribeChanWithOptions("", SubscribeOptions{Reconnect: true})
+	server := <-conns
+	waitSubscribers(1)
+
+	first, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if event := nextAdded(events); event.JobID != first.ID {
+		t.Errorf("expected job %s to be added, got %s", first.ID, event.JobID)
+	}
+
+	// The daemon restarts, and a job is added before the client reconnects
+	listener.Close()
+	server.Close()
+	waitSubscribers(0)
+	second, _, _ := d.jobManager.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	listener = listen()
+	defer listener.Close()
+
+	if event := nextAdded(events); event.JobID != second.ID {
+		t.Errorf("expected the missed job %s to be replayed, got %s", second.ID, event.JobID)
+	}
+
+	// Closing the client ends the subscription
+	client.Close()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-events:
+		case <-errs:
+			return
+		case <-timeout:
+			t.Fatal("expected the subscription to end")
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package daemon
+
+import "time"
+
+// coalesceWindow is the shortest time between two events sent to a
+// subscriber that coalesces them
+const coalesceWindow = 50 * time.Millisecond
+
+// holdCoalesced opens a coalescing window for an event about to be sent to
+// the subscriber, or holds the event back if one is open. It reports whether
+// the event was held. Caller must hold d.eventsMu.
+func (d *Daemon) holdCoalesced(sub *Subscriber, event Event) bool {
+	if sub.window != nil {
+		sub.held = append(sub.held, event)
+		return true
+	}
+	sub.window = time.AfterFunc(coalesceWindow, func() { d.flushCoalesced(sub) })
+	return false
+}
+
+// flushCoalesced ends a coalescing window of a subscriber: a single held
+// event is sent as is, and several are replaced by a resync event with the
+// current jobs and runs. A new window opens if anything was sent.
+func (d *Daemon) flushCoalesced(sub *Subscriber) {
+	d.eventsMu.Lock()
+	held := sub.held
+	sub.held = nil
+	if len(held) == 0 {
+		sub.window = nil
+		d.eventsMu.Unlock()
+		return
+	}
+
+	var err error
+	sub.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if len(held) == 1 {
+		err = sub.encoder.Encode(held[0])
+	} else {
+		// Building the resync locks the job manager, which may be waiting
+		// for eventsMu to send an event: queue new events meanwhile, like
+		// during a replay
+		seq := d.events.seq
+		sub.replaying = true
+		d.eventsMu.Unlock()
+
+		resync := d.snapshotEvent(sub.workdir, seq)
+		resync.Type = EventTypeResync
+		err = sub.encoder.Encode(resync)
+
+		d.eventsMu.Lock()
+		sub.held = sub.pending
+		sub.pending = nil
+		sub.replaying = false
+	}
+
+	if err == nil {
+		sub.window.Reset(coalesceWindow)
+	} else {
+		sub.window = nil
+	}
+	d.eventsMu.Unlock()
+
+	if err != nil {
+		Logger.Error("error sending coalesced events to subscriber", "error", err)
+		d.removeSubscriber(sub)
+		sub.conn.Close()
+	}
+}
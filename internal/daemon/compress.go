@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// Logs of stopped runs are compressed with zstd when they are at least
+// GOB_COMPRESS_MIN_SIZE (1M by default, 0 or off to never compress): a
+// log <path> becomes <path>.zst and clients read it through the logfile
+// package. Timestamp indexes are small and stay as they are.
+
+// defaultCompressMinSize is the size from which logs are compressed when
+// GOB_COMPRESS_MIN_SIZE is not set
+const defaultCompressMinSize = 1 << 20
+
+// CompressMinSizeFromEnv returns the size from which the logs of stopped
+// runs are compressed, set with GOB_COMPRESS_MIN_SIZE. Zero means never.
+func CompressMinSizeFromEnv() (int64, error) {
+	value := strings.TrimSpace(os.Getenv("GOB_COMPRESS_MIN_SIZE"))
+	switch value {
+	case "":
+		return defaultCompressMinSize, nil
+	case "0", "off":
+		return 0, nil
+	}
+	size, ok := parseSize(value)
+	if !ok {
+		return 0, fmt.Errorf("invalid GOB_COMPRESS_MIN_SIZE %q (expected e.g. 1M, or 0 to never compress)", value)
+	}
+	return size, nil
+}
+
+// compressRunLogs compresses the logs of a stopped run that are at least
+// compressMinSize, without holding the lock. Returns the paths of the logs
+// compressed.
+func (jm *JobManager) compressRunLogs(run *Run) []string {
+	if jm.compressMinSize <= 0 {
+		return nil
+	}
+
+	paths := []string{run.StdoutPath}
+	if run.StderrPath != run.StdoutPath {
+		paths = append(paths, run.StderrPath)
+	}
+
+	var compressed []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() < jm.compressMinSize {
+			continue
+		}
+		if _, err := logfile.Compress(path); err != nil {
+			Logger.Warn("failed to compress log", "run", run.ID, "path", path, "error", err)
+			continue
+		}
+		compressed = append(compressed, path)
+	}
+	return compressed
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+func TestCompressMinSizeFromEnv(t *testing.T) {
+	t.Setenv("GOB_COMPRESS_MIN_SIZE", "")
+	if size, err := CompressMinSizeFromEnv(); err != nil || size != defaultCompressMinSize {
+		t.Errorf("expected the default, got %d (%v)", size, err)
+	}
+
+	t.Setenv("GOB_COMPRESS_MIN_SIZE", "64K")
+	if size, _ := CompressMinSizeFromEnv(); size != 64<<10 {
+		t.Errorf("expected 64K, got %d", size)
+	}
+
+	t.Setenv("GOB_COMPRESS_MIN_SIZE", "off")
+	if size, _ := CompressMinSizeFromEnv(); size != 0 {
+		t.Errorf("expected compression off, got %d", size)
+	}
+
+	t.Setenv("GOB_COMPRESS_MIN_SIZE", "lots")
+	if _, err := CompressMinSizeFromEnv(); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
+
+func TestJobManager_CompressesLogs(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	jm.compressMinSize = 512
+
+	job, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	run := jm.GetCurrentRun(job.ID)
+	stdout := strings.Repeat("ok test\n", 200)
+	os.WriteFile(run.StdoutPath, []byte(stdout), 0644)
+	os.WriteFile(run.StderrPath, []byte("warning\n"), 0644)
+	stopAndWait(t, jm, executor, job.ID)
+	<-run.done
+
+	// Only the stdout log is large enough
+	if _, err := os.Stat(run.StdoutPath); !os.IsNotExist(err) {
+		t.Error("expected the stdout log replaced with its compressed copy")
+	}
+	if _, err := os.Stat(run.StderrPath); err != nil {
+		t.Error("expected the small stderr log left alone")
+	}
+
+	content, err := logfile.ReadFile(run.StdoutPath)
+	if err != nil || string(content) != stdout {
+		t.Fatalf("expected the compressed log read back, got %d bytes (%v)", len(content), err)
+	}
+	if runLogsMissing(run) {
+		t.Error("expected a compressed log not to count as missing")
+	}
+	if run.LogBytes == nil || *run.LogBytes >= int64(len(stdout)) {
+		t.Errorf("expected the compressed size recorded, got %v", run.LogBytes)
+	}
+
+	matches, err := grepFile(run.StdoutPath, regexp.MustCompile("ok"), 5)
+	if err != nil || len(matches) != 5 {
+		t.Errorf("expected grep to search the compressed log, got %v (%v)", matches, err)
+	}
+
+	removeRunLogs(run)
+	if _, err := logfile.Stat(run.StdoutPath); !os.IsNotExist(err) {
+		t.Error("expected the compressed log removed with the run")
+	}
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+)
+
+// busyLoadPerCPU is the 1-minute load average per CPU from which the
+// machine counts as busy when a run starts
+const busyLoadPerCPU = 0.7
+
+// Conditions of the machine a run can start in
+const (
+	ConditionBattery = "battery" // running on battery
+	ConditionAC      = "ac"      // plugged in
+	ConditionBusy    = "busy"    // load average per CPU of at least busyLoadPerCPU
+	ConditionIdle    = "idle"
+)
+
+// RunConditions are all the conditions, in the order stats group by them
+var RunConditions = []string{ConditionAC, ConditionBattery, ConditionIdle, ConditionBusy}
+
+// ConditionStats are the durations of the successful runs of a job started
+// in a condition
+type ConditionStats struct {
+	Condition     string `json:"condition"`
+	RunCount      int    `json:"run_count"`
+	P50DurationMs int64  `json:"p50_duration_ms"`
+	P90DurationMs int64  `json:"p90_duration_ms"`
+}
+
+// sampleConditions records the load and power state of the machine on a
+// run being started. What the platform does not tell is left unknown.
+func sampleConditions(run *Run) {
+	if load, ok := systemLoad(); ok {
+		run.LoadAvg = &load
+	}
+	if battery, ok := onBattery(); ok {
+		run.OnBattery = &battery
+	}
+}
+
+// Conditions returns the conditions the run started in, leaving out the
+// unknown ones
+func (r *Run) Conditions() []string {
+	var conditions []string
+	if r.OnBattery != nil {
+		if *r.OnBattery {
+			conditions = append(conditions, ConditionBattery)
+		} else {
+			conditions = append(conditions, ConditionAC)
+		}
+	}
+	if r.LoadAvg != nil {
+		if *r.LoadAvg >= busyLoadPerCPU {
+			conditions = append(conditions, ConditionBusy)
+		} else {
+			conditions = append(conditions, ConditionIdle)
+		}
+	}
+	return conditions
+}
+
+// startedIn reports whether the run started in all the conditions. Runs
+// that did not record a condition did not start in it.
+func (r *Run) startedIn(conditions []string) bool {
+	own := r.Conditions()
+	for _, c := range conditions {
+		if !slices.Contains(own, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateConditions returns an error for names that are not conditions
+func validateConditions(conditions []string) error {
+	for _, c := range conditions {
+		if !slices.Contains(RunConditions, c) {
+			return fmt.Errorf("invalid condition %q: want ac, battery, idle or busy", c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// systemLoad returns the 1-minute load average per CPU
+func systemLoad() (float64, bool) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, false
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return load / float64(runtime.NumCPU()), true
+}
+
+// onBattery reports whether the machine runs on battery, from the power
+// supplies in sysfs. Machines without a battery are unknown.
+func onBattery() (bool, bool) {
+	supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+	hasBattery := false
+	for _, supply := range supplies {
+		kind, err := os.ReadFile(filepath.Join(supply, "type"))
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(string(kind)) {
+		case "Mains", "USB":
+			if online, err := os.ReadFile(filepath.Join(supply, "online")); err == nil && strings.TrimSpace(string(online)) == "1" {
+				return false, true
+			}
+		case "Battery":
+			hasBattery = true
+		}
+	}
+	return hasBattery, hasBattery
+}
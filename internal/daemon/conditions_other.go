@@ -0,0 +1,13 @@
+//go:build !linux
+
+package daemon
+
+// systemLoad is unknown on platforms without /proc/loadavg
+func systemLoad() (float64, bool) {
+	return 0, false
+}
+
+// onBattery is unknown on platforms without sysfs power supplies
+func onBattery() (bool, bool) {
+	return false, false
+}
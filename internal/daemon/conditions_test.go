@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRun_Conditions(t *testing.T) {
+	battery, ac := true, false
+	busy, idle := 0.9, 0.2
+
+	tests := []struct {
+		run  Run
+		want []string
+	}{
+		{Run{}, nil},
+		{Run{OnBattery: &battery}, []string{ConditionBattery}},
+		{Run{OnBattery: &ac, LoadAvg: &busy}, []string{ConditionAC, ConditionBusy}},
+		{Run{LoadAvg: &idle}, []string{ConditionIdle}},
+	}
+	for _, tt := range tests {
+		if got := tt.run.Conditions(); !slices.Equal(got, tt.want) {
+			t.Errorf("Conditions() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestJobManager_JobStatsIn(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	job, _ := jm.CreateJob([]string{"make", "build"}, "/workdir", JobOptions{})
+
+	addRunOnBattery := func(exitCode int, durationMs int64, battery bool) {
+		addStoppedRun(jm, job, exitCode, durationMs)
+		jm.runs[fmt.Sprintf("%s-%d", job.ID, job.NextRunSeq-1)].OnBattery = &battery
+	}
+	for i := 0; i < 5; i++ {
+		addRunOnBattery(0, 1000, false)
+		addRunOnBattery(0, 2000, true)
+	}
+	addRunOnBattery(1, 500, true)
+	addRunOnBattery(0, 2100, true)
+
+	// The last run is only as slow as the previous runs on battery
+	stats, err := jm.JobStats(job.ID)
+	if err != nil {
+		t.Fatalf("JobStats failed: %v", err)
+	}
+	if stats.Slowdown != 0 {
+		t.Errorf("expected no slowdown against runs on battery, got %vx", stats.Slowdown)
+	}
+	if len(stats.ByCondition) != 2 || stats.ByCondition[0] != (ConditionStats{Condition: ConditionAC, RunCount: 5, P50DurationMs: 1000, P90DurationMs: 1000}) ||
+		stats.ByCondition[1].Condition != ConditionBattery || stats.ByCondition[1].RunCount != 6 {
+		t.Errorf("unexpected durations by condition: %+v", stats.ByCondition)
+	}
+
+	// Only the runs on battery are counted
+	stats, err = jm.JobStatsIn(job.ID, []string{ConditionBattery})
+	if err != nil {
+		t.Fatalf("JobStatsIn failed: %v", err)
+	}
+	if stats.RunCount != 7 || stats.SuccessCount != 6 || stats.FailureCount != 1 || stats.AvgDurationMs != 2016 || stats.MinDurationMs != 500 {
+		t.Errorf("unexpected stats of runs on battery: %d runs, %d/%d, avg %dms, min %dms",
+			stats.RunCount, stats.SuccessCount, stats.FailureCount, stats.AvgDurationMs, stats.MinDurationMs)
+	}
+
+	// Runs without a recorded load are not idle
+	if stats, _ := jm.JobStatsIn(job.ID, []string{ConditionIdle}); stats.RunCount != 0 {
+		t.Errorf("expected no idle runs, got %d", stats.RunCount)
+	}
+	if _, err := jm.JobStatsIn(job.ID, []string{"sunny"}); err == nil {
+		t.Error("expected an error for an unknown condition")
+	}
+}
+
+func TestStore_RunConditions(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), store)
+	job, _ := jm.CreateJob([]string{"make"}, "/workdir", JobOptions{})
+	load, battery := 1.25, true
+	run := &Run{ID: job.ID + "-1", JobID: job.ID, Status: "running", StartedAt: time.Now(), LoadAvg: &load, OnBattery: &battery}
+	if err := store.InsertRun(run); err != nil {
+		t.Fatalf("InsertRun failed: %v", err)
+	}
+
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].LoadAvg == nil || *runs[0].LoadAvg != 1.25 || runs[0].OnBattery == nil || !*runs[0].OnBattery {
+		t.Errorf("expected the conditions loaded, got %+v", runs[0])
+	}
+}
@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// containerRuntimeEnvVar selects the container runtime's CLI, e.g. podman
+const containerRuntimeEnvVar = "GOB_CONTAINER_RUNTIME"
+
+// containerRuntimes are the runtimes looked up in PATH, in order, when
+// GOB_CONTAINER_RUNTIME is not set
+var containerRuntimes = []string{"docker", "podman"}
+
+// containerHostEnv are the variables describing the host rather than the
+// project, which the container's image sets for itself
+var containerHostEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "PWD", "OLDPWD", "TMPDIR", "HOSTNAME", "SHLVL", "_", runIDEnvVar}
+
+// runContainer is the container a run's command runs in
+type runContainer struct {
+	runtime string // container runtime's CLI, e.g. docker
+	name    string // name of the container, unique to the run
+}
+
+// newRunContainer returns the container of a job's run of a daemon, nil if
+// the job runs on the host
+func newRunContainer(job *Job, daemonID, runID string, env []string) (*runContainer, error) {
+	if job.Container == "" {
+		return nil, nil
+	}
+	runtime, err := containerRuntime(env)
+	if err != nil {
+		return nil, err
+	}
+	return &runContainer{runtime: runtime, name: containerName(daemonID, runID)}, nil
+}
+
+// containerName returns the name of the container of a run of a daemon.
+// Container names are shared by every user and daemon of the container
+// host, and run IDs are only unique within a daemon.
+func containerName(daemonID, runID string) string {
+	return "gob-" + daemonID + "-" + runID
+}
+
+// containerRuntime returns the container runtime's CLI: GOB_CONTAINER_RUNTIME
+// from the job's environment, or the first of docker and podman in PATH
+func containerRuntime(env []string) (string, error) {
+	if env == nil {
+		env = os.Environ()
+	}
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, containerRuntimeEnvVar+"="); ok && value != "" {
+			return value, nil
+		}
+	}
+	for _, runtime := range containerRuntimes {
+		if _, err := exec.LookPath(runtime); err == nil {
+			return runtime, nil
+		}
+	}
+	return "", fmt.Errorf("no container runtime found: install docker or podman, or set %s", containerRuntimeEnvVar)
+}
+
+// signal stops or signals the container. Its processes are not in the
+// process tree of the runtime's CLI, so SIGTERM maps to a stop (which kills
+// the container after the same grace period as other runs) and SIGKILL to a
+// kill. It does not wait: the run ends when the runtime's CLI exits with the
+// container.
+func (c *runContainer) signal(sig syscall.Signal) {
+	var args []string
+	switch sig {
+	case syscall.SIGTERM:
+		args = []string{"stop", "--time", strconv.Itoa(int(stopTermTimeout / time.Second)), c.name}
+	case syscall.SIGKILL:
+		args = []string{"kill", c.name}
+	default:
+		args = []string{"kill", "--signal", strconv.Itoa(int(sig)), c.name}
+	}
+
+	cmd := exec.Command(c.runtime, args...)
+	if err := cmd.Start(); err != nil {
+		Logger.Warn("failed to signal container", "name", c.name, "error", err)
+		return
+	}
+	go cmd.Wait()
+}
+
+// removeStale removes a container left with the run's name, e.g. by a
+// daemon that crashed, which would keep the new one from starting. Only
+// this daemon names containers after its ID, so it is not another's.
+func (c *runContainer) removeStale() {
+	cmd := exec.Command(c.runtime, "rm", "--force", c.name)
+	if out, err := cmd.CombinedOutput(); err == nil && strings.TrimSpace(string(out)) != "" {
+		Logger.Info("removed stale container", "name", c.name)
+	}
+}
+
+// pid returns the host PID of the container's main process, 0 if unknown
+// (e.g. the container runs in a virtual machine, as with Docker Desktop)
+func (c *runContainer) pid() int {
+	out, err := exec.Command(c.runtime, "inspect", "--format", "{{.State.Pid}}", c.name).Output()
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil || !processExists(pid) {
+		return 0
+	}
+	return pid
+}
+
+// containerExecutor starts commands in a container through a base executor,
+// which runs the container runtime's CLI in the foreground: the run's logs,
+// stdin and exit code are the container's.
+type containerExecutor struct {
+	base      ProcessExecutor
+	image     string
+	container *runContainer
+}
+
+// Start starts the command in a new container, removed when it exits
+func (e *containerExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	e.container.removeStale()
+	args := containerRunArgs(e.container, e.image, command, workdir, env, opts)
+
+	// The limits apply to the container, not to the runtime's CLI
+	opts.Limits = ResourceLimits{}
+
+	return e.base.Start(args, workdir, env, stdoutPath, stderrPath, opts)
+}
+
+// containerRunArgs returns the command running command in a container of
+// image. The container shares the host's network, so its ports are the
+// host's, and the working directory is mounted at the same path. Variables
+// of env are passed by name, with the values the runtime's CLI gets.
+func containerRunArgs(c *runContainer, image string, command []string, workdir string, env []string, opts StartOptions) []string {
+	args := []string{c.runtime, "run", "--rm", "--init", "--name", c.name, "--network", "host",
+		"--volume", workdir + ":" + workdir, "--workdir", workdir}
+
+	if opts.Stdin != "" && opts.Stdin != StdinNull {
+		args = append(args, "--interactive")
+	}
+	if opts.Limits.MemoryBytes > 0 {
+		args = append(args, "--memory", strconv.FormatInt(opts.Limits.MemoryBytes, 10))
+	}
+	if opts.Limits.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(opts.Limits.CPUs, 'f', -1, 64))
+	}
+
+	if env == nil {
+		env = os.Environ()
+	}
+	for _, kv := range env {
+		name, _, ok := strings.Cut(kv, "=")
+		if !ok || name == "" || slices.Contains(containerHostEnv, name) {
+			continue
+		}
+		args = append(args, "--env", name)
+	}
+
+	args = append(args, image)
+	return append(args, command...)
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestContainerRunArgs(t *testing.T) {
+	c := &runContainer{runtime: "podman", name: "gob-abc-1"}
+	env := []string{"PATH=/usr/bin", "HOME=/home/me", "NODE_ENV=test", runIDEnvVar + "=abc-1", "PORT=3000"}
+	opts := StartOptions{Stdin: StdinOpen, Limits: ResourceLimits{MemoryBytes: 512 << 20, CPUs: 1.5}}
+
+	got := containerRunArgs(c, "node:20", []string{"npm", "test"}, "/project", env, opts)
+	want := []string{"podman", "run", "--rm", "--init", "--name", "gob-abc-1", "--network", "host",
+		"--volume", "/project:/project", "--workdir", "/project",
+		"--interactive", "--memory", "536870912", "--cpus", "1.5",
+		"--env", "NODE_ENV", "--env", "PORT",
+		"node:20", "npm", "test"}
+	if !slices.Equal(got, want) {
+		t.Errorf("unexpected command\n got: %s\nwant: %s", strings.Join(got, " "), strings.Join(want, " "))
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	if containerName("d1", "abc-1") == containerName("d2", "abc-1") {
+		t.Error("expected the same run ID of two daemons to get different container names")
+	}
+}
+
+func TestRunContainer_RemoveStale(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake runtime is a shell script")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "docker")
+	os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+calls+"\n"), 0755)
+
+	c := &runContainer{runtime: script, name: containerName("d1", "abc-1")}
+	c.removeStale()
+	got, _ := os.ReadFile(calls)
+	if string(got) != "rm --force gob-d1-abc-1\n" {
+		t.Errorf("expected the container removed by name, got %q", got)
+	}
+}
+
+func TestJobManager_Container(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	env := []string{containerRuntimeEnvVar + "=podman", "PATH=/usr/bin"}
+
+	job, _, err := jm.AddJob([]string{"npm", "test"}, "/project", JobOptions{Container: "node:20", MemoryLimit: 1 << 30}, env)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	command := executor.LastCommand()
+	if len(command) < 3 || command[0] != "podman" || command[1] != "run" || !slices.Equal(command[len(command)-3:], []string{"node:20", "npm", "test"}) {
+		t.Errorf("expected the command to run in a podman container, got %v", command)
+	}
+	if !executor.LastOptions().Limits.IsZero() {
+		t.Errorf("expected the limits to apply to the container only, got %+v", executor.LastOptions().Limits)
+	}
+
+	run := jm.GetCurrentRun(job.ID)
+	if run.container == nil || run.container.name != "gob-"+jm.daemonID+"-"+run.ID || run.container.runtime != "podman" {
+		t.Errorf("expected the run to track its container, got %+v", run.container)
+	}
+	if tree := jm.runProcessTree(run); tree.container != run.container {
+		t.Error("expected stopping the run to stop its container")
+	}
+	if resp := jm.jobToResponse(job); resp.Container != "node:20" {
+		t.Errorf("expected container node:20 in the response, got %q", resp.Container)
+	}
+
+	// Re-adding the job without an image keeps it
+	stopAndWait(t, jm, executor, job.ID)
+	jm.AddJob([]string{"npm", "test"}, "/project", JobOptions{}, env)
+	if command := executor.LastCommand(); command[0] != "podman" {
+		t.Errorf("expected the job to keep its container, got %v", command)
+	}
+}
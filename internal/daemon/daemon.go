@@ -8,8 +8,10 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -21,6 +23,25 @@ type Subscriber struct {
 	conn    net.Conn
 	encoder *json.Encoder
 	workdir string
+	match   EventMatch
+
+	// While a snapshot or replayed events are being sent, new events are
+	// queued in pending instead (guarded by Daemon.eventsMu)
+	replaying bool
+	pending   []Event
+
+	// Subscribers that coalesce events are sent at most one per
+	// coalesceWindow: events are held while a window is open (guarded by
+	// Daemon.eventsMu)
+	coalesce bool
+	window   *time.Timer
+	held     []Event
+}
+
+// wants reports whether the event belongs to the subscriber's directory and
+// matches its filters
+func (s *Subscriber) wants(event Event) bool {
+	return (s.workdir == "" || event.Job.Workdir == s.workdir || event.Approval != nil) && s.match.Matches(event)
 }
 
 // Daemon represents the gob daemon server
@@ -28,6 +49,7 @@ type Daemon struct {
 	listener      net.Listener
 	socketPath    string
 	pidPath       string
+	dbPath        string
 	runtimeDir    string
 	logDir        string
 	db            *sql.DB
@@ -37,6 +59,19 @@ type Daemon struct {
 	jobManager    *JobManager
 	subscribers   []*Subscriber
 	subscribersMu sync.RWMutex
+	events        eventLog   // numbered recent events, for replay
+	eventsMu      sync.Mutex // serializes numbering and sending events
+	gateway       *gateway   // HTTP gateway, nil when not running
+	gatewayMu     sync.Mutex
+	handingOver   bool // shutting down for a new daemon, running jobs are left alone
+	limiter       rateLimiter
+	nextConnID    atomic.Uint64
+	presence      presence    // projects of the shells using 'gob hook cd'
+	activated     bool        // the socket was passed by systemd, which keeps it
+	logJanitor    atomic.Bool // the janitor of missing log files is running
+	policy        Policy      // requests of clients needing approval
+	approvals     approvals   // requests waiting for approval
+	startedAt     time.Time
 }
 
 // New creates a new daemon instance
@@ -85,6 +120,7 @@ func New() (*Daemon, error) {
 	d := &Daemon{
 		socketPath:  socketPath,
 		pidPath:     pidPath,
+		dbPath:      dbPath,
 		runtimeDir:  runtimeDir,
 		logDir:      logDir,
 		db:          db,
@@ -107,20 +143,35 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("failed to ensure runtime directory: %w", err)
 	}
 
-	// Clean up stale socket if it exists
-	if err := d.cleanupStaleSocket(); err != nil {
-		return fmt.Errorf("failed to cleanup stale socket: %w", err)
+	// Use the socket passed by systemd, if any (socket activation)
+	inherited, err := inheritedIPC()
+	if err != nil {
+		return fmt.Errorf("failed to use inherited socket: %w", err)
 	}
 
-	// Check for crash recovery
-	if !d.store.WasCleanShutdown() {
-		Logger.Info("previous shutdown was not clean, performing crash recovery")
-		if err := d.recoverFromCrash(); err != nil {
-			Logger.Error("crash recovery failed", "error", err)
-			// Continue anyway - jobs will be loaded but orphans may not be cleaned
+	// Clean up stale socket if it exists
+	if inherited == nil {
+		if err := d.cleanupStaleSocket(); err != nil {
+			return fmt.Errorf("failed to cleanup stale socket: %w", err)
 		}
 	}
 
+	// Continue numbering events after the recorded ones, dropping old ones
+	if err := d.store.DeleteEventsBefore(time.Now().Add(-eventRetention)); err != nil {
+		Logger.Error("failed to delete old events", "error", err)
+	}
+	if err := d.store.DeleteAuditEntriesBefore(time.Now().Add(-auditRetention)); err != nil {
+		Logger.Error("failed to delete old audit entries", "error", err)
+	}
+	lastEventID, err := d.store.LastEventID()
+	if err != nil {
+		return fmt.Errorf("failed to read last event: %w", err)
+	}
+	d.events.seq = lastEventID
+
+	// Check for crash recovery (done once the jobs are loaded)
+	crashed := !d.store.WasCleanShutdown()
+
 	// Set shutdown_clean = false (will be set to true on graceful shutdown)
 	if err := d.store.SetShutdownClean(false); err != nil {
 		return fmt.Errorf("failed to set shutdown flag: %w", err)
@@ -131,6 +182,37 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("failed to set instance ID: %w", err)
 	}
 
+	// Mark the processes of runs with an ID that outlives this instance, so
+	// they are told apart from the runs of other daemons after a restart
+	daemonID, err := d.store.DaemonID()
+	if err != nil {
+		return fmt.Errorf("failed to read daemon ID: %w", err)
+	}
+	d.jobManager.daemonID = daemonID
+
+	// Filter the environments clients send, with the same settings they use
+	if filter, err := EnvFilterFromEnv(); err != nil {
+		Logger.Error("ignoring environment filter settings", "error", err)
+		d.jobManager.envFilter = DefaultEnvFilter()
+	} else {
+		d.jobManager.envFilter = filter
+	}
+
+	// Compress large logs of stopped runs
+	if size, err := CompressMinSizeFromEnv(); err != nil {
+		Logger.Error("ignoring log compression settings", "error", err)
+		d.jobManager.compressMinSize = defaultCompressMinSize
+	} else {
+		d.jobManager.compressMinSize = size
+	}
+
+	// Hold the destructive requests of agents for approval
+	if policy, err := LoadPolicy(PolicyPath()); err != nil {
+		Logger.Error("ignoring approval policy", "error", err)
+	} else {
+		d.policy = policy
+	}
+
 	// Load jobs and runs from database
 	if err := d.jobManager.LoadFromStore(); err != nil {
 		return fmt.Errorf("failed to load state from database: %w", err)
@@ -138,35 +220,77 @@ func (d *Daemon) Start() error {
 
 	Logger.Info("loaded persisted state", "jobs", d.jobManager.JobCount())
 
-	// Create Unix socket listener
-	listener, err := net.Listen("unix", d.socketPath)
+	// Archive the runs that have not been looked at for months
+	if archived, _, err := d.jobManager.ArchiveRuns("", time.Now().Add(-runArchiveAge)); err != nil {
+		Logger.Error("failed to archive old runs", "error", err)
+	} else if archived > 0 {
+		Logger.Info("archived old runs", "runs", archived)
+	}
+
+	// Take over the running jobs of a daemon that handed over to this one,
+	// or that crashed
+	handoverPath, err := GetHandoverPath()
 	if err != nil {
-		return fmt.Errorf("failed to create socket: %w", err)
+		return fmt.Errorf("failed to get handover path: %w", err)
+	}
+	if h, err := readHandover(handoverPath); err != nil {
+		Logger.Error("failed to read handover", "error", err)
+	} else if h != nil {
+		adopted := d.jobManager.AdoptRuns(h.Runs)
+		Logger.Info("took over from previous daemon", "version", h.Version, "runs", adopted)
+	} else if crashed {
+		Logger.Info("previous shutdown was not clean, performing crash recovery")
+		if err := d.recoverFromCrash(); err != nil {
+			Logger.Error("crash recovery failed", "error", err)
+			// Continue anyway - runs left running will show as running
+		}
 	}
-	d.listener = listener
 
-	// Set socket permissions to user-only (0600)
-	if err := os.Chmod(d.socketPath, 0600); err != nil {
-		d.listener.Close()
-		os.Remove(d.socketPath)
-		return fmt.Errorf("failed to set socket permissions: %w", err)
+	// Create the socket listener (a named pipe on Windows)
+	if inherited != nil {
+		d.listener = inherited
+		d.activated = true
+	} else {
+		listener, err := listenIPC(d.socketPath)
+		if err != nil {
+			return err
+		}
+		d.listener = listener
 	}
 
 	// Write PID file
 	if err := d.writePIDFile(); err != nil {
 		d.listener.Close()
-		os.Remove(d.socketPath)
+		if !d.activated {
+			removeIPC(d.socketPath)
+		}
 		return fmt.Errorf("failed to write PID file: %w", err)
 	}
 
 	// Setup signal handling
 	d.setupSignalHandling()
 
+	d.startedAt = time.Now()
 	Logger.Info("daemon started", "socket", d.socketPath)
 
 	// Accept connections
 	go d.acceptConnections()
 
+	// Stop the jobs of projects no shell is in anymore
+	go d.watchPresence()
+
+	// Flag the runs whose log files were deleted
+	go d.watchRunLogs()
+
+	// Start the HTTP gateway if it was enabled
+	if addr := d.store.GatewayAddr(); addr != "" {
+		d.gatewayMu.Lock()
+		if _, err := d.startGateway(addr); err != nil {
+			Logger.Error("failed to start gateway", "error", err)
+		}
+		d.gatewayMu.Unlock()
+	}
+
 	return nil
 }
 
@@ -186,6 +310,11 @@ func (d *Daemon) Run() error {
 func (d *Daemon) Shutdown() error {
 	Logger.Info("shutting down daemon")
 
+	// Stop the HTTP gateway (it starts again with the next daemon)
+	d.gatewayMu.Lock()
+	d.stopGateway()
+	d.gatewayMu.Unlock()
+
 	// Close all subscriber connections
 	d.subscribersMu.Lock()
 	for _, sub := range d.subscribers {
@@ -194,10 +323,12 @@ func (d *Daemon) Shutdown() error {
 	d.subscribers = nil
 	d.subscribersMu.Unlock()
 
-	// Stop all managed jobs first
-	stopped := d.jobManager.StopAll()
-	if stopped > 0 {
-		Logger.Info("stopped running jobs", "count", stopped)
+	// Stop all managed jobs first, unless the next daemon takes them over
+	if !d.handingOver {
+		stopped := d.jobManager.StopAll()
+		if stopped > 0 {
+			Logger.Info("stopped running jobs", "count", stopped)
+		}
 	}
 
 	// Set shutdown_clean = true since we're shutting down gracefully
@@ -217,9 +348,11 @@ func (d *Daemon) Shutdown() error {
 		d.listener.Close()
 	}
 
-	// Remove socket
-	if err := os.Remove(d.socketPath); err != nil && !os.IsNotExist(err) {
-		Logger.Warn("failed to remove socket", "error", err)
+	// Remove socket, unless systemd keeps it to start the next daemon
+	if !d.activated {
+		if err := removeIPC(d.socketPath); err != nil && !os.IsNotExist(err) {
+			Logger.Warn("failed to remove socket", "error", err)
+		}
 	}
 
 	// Remove PID file
@@ -234,16 +367,16 @@ func (d *Daemon) Shutdown() error {
 // cleanupStaleSocket removes a stale socket file if it exists
 func (d *Daemon) cleanupStaleSocket() error {
 	// Check if socket exists
-	if _, err := os.Stat(d.socketPath); os.IsNotExist(err) {
+	if !ipcExists(d.socketPath) {
 		return nil
 	}
 
 	// Try to connect to see if it's stale
-	conn, err := net.Dial("unix", d.socketPath)
+	conn, err := dialIPC(d.socketPath, 0)
 	if err != nil {
 		// Socket exists but can't connect - it's stale
 		Logger.Info("removing stale socket", "path", d.socketPath)
-		return os.Remove(d.socketPath)
+		return removeIPC(d.socketPath)
 	}
 
 	// Socket is active
@@ -291,6 +424,14 @@ func (d *Daemon) acceptConnections() {
 
 // handleConnection handles a single client connection
 func (d *Daemon) handleConnection(conn net.Conn) {
+	// Only the daemon's user may use it, whatever the socket's permissions
+	peerPID, err := checkPeer(conn)
+	if err != nil {
+		Logger.Warn("rejected connection", "error", err)
+		conn.Close()
+		return
+	}
+
 	decoder := json.NewDecoder(conn)
 	encoder := json.NewEncoder(conn)
 
@@ -302,18 +443,25 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 		conn.Close()
 		return
 	}
+	req.peerPID = peerPID
+	req.connID = d.nextConnID.Add(1)
+	defer d.limiter.forget(rateLimitKey(&req))
 
-	// Handle subscribe specially - don't close connection
-	if req.Type == RequestTypeSubscribe {
+	// Handle subscribe and session specially - don't close connection
+	switch req.Type {
+	case RequestTypeSubscribe:
 		d.handleSubscribe(&req, conn, encoder)
 		return
+	case RequestTypeSession:
+		d.handleSession(&req, conn, decoder, encoder)
+		return
 	}
 
 	// For all other requests, close connection after handling
 	defer conn.Close()
 
 	// Handle request
-	resp := d.handleRequest(&req)
+	resp := d.serveRequest(&req)
 
 	// Send response
 	if err := encoder.Encode(resp); err != nil {
@@ -323,6 +471,11 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 
 // handleRequest dispatches a request to the appropriate handler
 func (d *Daemon) handleRequest(req *Request) *Response {
+	if err := checkProtocolVersion(req); err != nil {
+		return NewErrorResponse(err)
+	}
+	normalizeWorkdirPayload(req)
+
 	switch req.Type {
 	case RequestTypePing:
 		return d.handlePing(req)
@@ -332,6 +485,8 @@ func (d *Daemon) handleRequest(req *Request) *Response {
 		return d.handleList(req)
 	case RequestTypeAdd:
 		return d.handleAdd(req)
+	case RequestTypeRun:
+		return d.handleRun(req)
 	case RequestTypeCreate:
 		return d.handleCreate(req)
 	case RequestTypeStop:
@@ -352,22 +507,101 @@ func (d *Daemon) handleRequest(req *Request) *Response {
 		return d.handleVersion(req)
 	case RequestTypeRuns:
 		return d.handleRuns(req)
+	case RequestTypeHistory:
+		return d.handleHistory(req)
+	case RequestTypeGrep:
+		return d.handleGrep(req)
+	case RequestTypeLogLines:
+		return d.handleLogLines(req)
+	case RequestTypeEvents:
+		return d.handleEvents(req)
+	case RequestTypeHandover:
+		return d.handleHandover(req)
+	case RequestTypeAdopt:
+		return d.handleAdopt(req)
+	case RequestTypeDiskUsage:
+		return d.handleDiskUsage(req)
+	case RequestTypePruneLogs:
+		return d.handlePruneLogs(req)
+	case RequestTypeCleanupLogs:
+		return d.handleCleanupLogs(req)
+	case RequestTypeApprovals:
+		return d.handleApprovals(req)
+	case RequestTypeApprove:
+		return d.handleApprove(req)
+	case RequestTypeOrphans:
+		return d.handleOrphans(req)
+	case RequestTypeKillOrphans:
+		return d.handleKillOrphans(req)
+	case RequestTypeArchiveRuns:
+		return d.handleArchiveRuns(req)
+	case RequestTypeAudit:
+		return d.handleAudit(req)
 	case RequestTypeStats:
 		return d.handleStats(req)
+	case RequestTypeStatsAll:
+		return d.handleStatsAll(req)
 	case RequestTypePorts:
 		return d.handlePorts(req)
 	case RequestTypeRemoveRun:
 		return d.handleRemoveRun(req)
+	case RequestTypeSetAlias:
+		return d.handleSetAlias(req)
+	case RequestTypeSetDescription:
+		return d.handleSetDescription(req)
+	case RequestTypeAnnotateRun:
+		return d.handleAnnotateRun(req)
+	case RequestTypeSetPinned:
+		return d.handleSetPinned(req)
+	case RequestTypeReload:
+		return d.handleReload(req)
+	case RequestTypeMarkStuck:
+		return d.handleMarkStuck(req)
+	case RequestTypeSetSlowThreshold:
+		return d.handleSetSlowThreshold(req)
+	case RequestTypeExportRuns:
+		return d.handleExportRuns(req)
+	case RequestTypeShellPresence:
+		return d.handleShellPresence(req)
+	case RequestTypeBatch:
+		return d.handleBatch(req)
+	case RequestTypeGatewayStart:
+		return d.handleGatewayStart(req)
+	case RequestTypeGatewayStop:
+		return d.handleGatewayStop(req)
+	case RequestTypeGatewayStatus:
+		return d.handleGatewayStatus(req)
+	case RequestTypeHealth:
+		return d.handleHealth(req)
 	default:
 		return NewErrorResponse(fmt.Errorf("unknown request type: %s", req.Type))
 	}
 }
 
+// checkProtocolVersion rejects requests from clients speaking a newer
+// protocol. Requests without a version come from clients predating it.
+func checkProtocolVersion(req *Request) error {
+	if req.Version > ProtocolVersion {
+		return fmt.Errorf("unsupported protocol version %d (daemon speaks %d)", req.Version, ProtocolVersion)
+	}
+	return nil
+}
+
+// jobIDFromPayload returns the resolved job of a request with a JobPayload
+func (d *Daemon) jobIDFromPayload(req *Request) (string, error) {
+	var p JobPayload
+	if err := decodePayload(req, &p); err != nil {
+		return "", err
+	}
+	if p.JobID == "" {
+		return "", fmt.Errorf("missing job_id")
+	}
+	return d.jobManager.ResolveJobID(p.JobID), nil
+}
+
 // handlePing handles a ping request
 func (d *Daemon) handlePing(req *Request) *Response {
-	resp := NewSuccessResponse()
-	resp.Data["message"] = "pong"
-	return resp
+	return NewDataResponse(MessageData{Message: "pong"})
 }
 
 // handleShutdown handles a shutdown request
@@ -377,142 +611,231 @@ func (d *Daemon) handleShutdown(req *Request) *Response {
 		d.cancel()
 	}()
 
-	resp := NewSuccessResponse()
-	resp.Data["message"] = "shutting down"
-	return resp
+	return NewDataResponse(MessageData{Message: "shutting down"})
 }
 
 // handleList handles a list request
 func (d *Daemon) handleList(req *Request) *Response {
-	workdir, _ := req.Payload["workdir"].(string)
-	jobs := d.jobManager.ListJobs(workdir)
+	var p ListPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	jobs := d.jobManager.ListJobs(p.Workdir)
 
-	var jobResponses []JobResponse
+	var data ListData
 	for _, job := range jobs {
-		jobResponses = append(jobResponses, d.jobManager.jobToResponse(job))
+		if p.Tag != "" && !job.HasTag(p.Tag) {
+			continue
+		}
+		resp := d.jobManager.jobToResponse(job)
+		if p.Ports && job.IsRunning() {
+			if jobPorts, err := d.jobManager.RefreshJobPorts(job.ID); err == nil {
+				resp.Ports = jobPorts.Ports
+				resp.PortsSummary = FormatPorts(jobPorts.Ports)
+			}
+		}
+		data.Jobs = append(data.Jobs, resp)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["jobs"] = jobResponses
-	return resp
+	return NewDataResponse(data)
 }
 
 // handleAdd handles an add request
 func (d *Daemon) handleAdd(req *Request) *Response {
-	// Extract command
-	commandRaw, ok := req.Payload["command"]
-	if !ok {
-		return NewErrorResponse(fmt.Errorf("missing command"))
-	}
-
-	// Convert to []string
-	var command []string
-	switch v := commandRaw.(type) {
-	case []interface{}:
-		for _, item := range v {
-			if s, ok := item.(string); ok {
-				command = append(command, s)
-			}
+	return d.addJob(req, false)
+}
+
+// handleRun handles a run request: an add request that reuses a run another
+// client started moments ago, or that is still running, and marks the
+// response as deduplicated
+func (d *Daemon) handleRun(req *Request) *Response {
+	return d.addJob(req, true)
+}
+
+// addJob implements add and run requests
+func (d *Daemon) addJob(req *Request, dedupe bool) *Response {
+	p, opts, err := addPayload(req)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	addJob := d.jobManager.AddJob
+	if dedupe {
+		addJob = d.jobManager.RunJob
+	}
+	job, action, err := addJob(p.Command, p.Workdir, opts, p.Env)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	data := AddResponse{Job: d.jobManager.jobToResponse(job), Action: action}
+	if dedupe && (action == "already_running" || action == "deduplicated") {
+		data.Deduplicated = true
+		if run := d.jobManager.GetLatestRun(job.ID); run != nil {
+			runResp := runToResponse(run)
+			data.Run = &runResp
 		}
-	default:
-		return NewErrorResponse(fmt.Errorf("invalid command format"))
 	}
 
-	if len(command) == 0 {
-		return NewErrorResponse(fmt.Errorf("empty command"))
+	return NewDataResponse(data)
+}
+
+// addPayload decodes and checks the payload of add, run and create requests
+func addPayload(req *Request) (AddPayload, JobOptions, error) {
+	var p AddPayload
+	if err := decodePayload(req, &p); err != nil {
+		return p, JobOptions{}, err
+	}
+	if len(p.Command) == 0 {
+		return p, JobOptions{}, fmt.Errorf("missing command")
+	}
+	if p.Workdir == "" {
+		return p, JobOptions{}, fmt.Errorf("missing workdir")
 	}
 
-	workdir, _ := req.Payload["workdir"].(string)
-	if workdir == "" {
-		return NewErrorResponse(fmt.Errorf("missing workdir"))
+	opts, err := p.JobOptionsPayload.jobOptions()
+	if err != nil {
+		return p, opts, err
+	}
+	if p.Label != "" {
+		if err := ValidateRunLabel(p.Label); err != nil {
+			return p, opts, err
+		}
+		opts.RunLabel = p.Label
+	}
+	if p.Project != nil {
+		if err := p.Project.validate(); err != nil {
+			return p, opts, err
+		}
+		opts.Project = p.Project
 	}
+	opts.Origin = requestOrigin(req)
+	return p, opts, nil
+}
 
-	// Extract optional description
-	description, _ := req.Payload["description"].(string)
+// jobOptions checks the job settings and converts them to JobOptions
+func (p JobOptionsPayload) jobOptions() (JobOptions, error) {
+	opts := JobOptions{
+		Description:   p.Description,
+		Blocked:       p.Blocked,
+		MemoryLimit:   p.MemoryLimit,
+		CPULimit:      p.CPULimit,
+		Hooks:         p.JobHooks,
+		Timestamps:    p.Timestamps,
+		CombineOutput: p.CombineOutput,
+		OutputHead:    p.OutputHead,
+		OutputTail:    p.OutputTail,
+		Container:     p.Container,
+		Shell:         p.Shell,
+	}
+
+	if p.Priority != "" {
+		priority, err := ParsePriority(p.Priority)
+		if err != nil {
+			return opts, err
+		}
+		opts.Priority = priority
+	}
 
-	// Extract optional blocked flag
-	blocked, _ := req.Payload["blocked"].(bool)
+	if p.Stdin != "" {
+		if err := ValidateStdin(p.Stdin); err != nil {
+			return opts, err
+		}
+		opts.Stdin = p.Stdin
+	}
 
-	// Extract environment
-	var env []string
-	if envRaw, ok := req.Payload["env"]; ok {
-		switch v := envRaw.(type) {
-		case []interface{}:
-			for _, item := range v {
-				if s, ok := item.(string); ok {
-					env = append(env, s)
-				}
-			}
+	if p.LogFormat != "" {
+		if err := ValidateLogFormat(p.LogFormat); err != nil {
+			return opts, err
 		}
+		opts.LogFormat = p.LogFormat
 	}
 
-	job, action, err := d.jobManager.AddJob(command, workdir, description, blocked, env)
-	if err != nil {
-		return NewErrorResponse(err)
+	if p.OutputHead < 0 || p.OutputTail < 0 {
+		return opts, fmt.Errorf("output limits must not be negative")
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
-	resp.Data["action"] = action
+	if (p.MemoryLimit < 0 && p.MemoryLimit != LimitOff) || (p.CPULimit < 0 && p.CPULimit != LimitOff) {
+		return opts, fmt.Errorf("resource limits must not be negative (%d removes them)", LimitOff)
+	}
 
-	return resp
-}
+	if p.ReloadSignal < 0 {
+		return opts, fmt.Errorf("reload signal must not be negative")
+	}
+	opts.ReloadSignal = p.ReloadSignal
 
-// handleCreate handles a create request (add job without starting)
-func (d *Daemon) handleCreate(req *Request) *Response {
-	// Extract command
-	commandRaw, ok := req.Payload["command"]
-	if !ok {
-		return NewErrorResponse(fmt.Errorf("missing command"))
-	}
-
-	// Convert to []string
-	var command []string
-	switch v := commandRaw.(type) {
-	case []interface{}:
-		for _, item := range v {
-			if s, ok := item.(string); ok {
-				command = append(command, s)
-			}
+	if p.DevEnv != "" {
+		if err := ValidateDevEnv(p.DevEnv); err != nil {
+			return opts, err
 		}
-	default:
-		return NewErrorResponse(fmt.Errorf("invalid command format"))
+		opts.DevEnv = p.DevEnv
 	}
 
-	if len(command) == 0 {
-		return NewErrorResponse(fmt.Errorf("empty command"))
+	if p.Sandbox != "" {
+		if err := ValidateSandbox(p.Sandbox); err != nil {
+			return opts, err
+		}
+		opts.Sandbox = p.Sandbox
 	}
 
-	workdir, _ := req.Payload["workdir"].(string)
-	if workdir == "" {
-		return NewErrorResponse(fmt.Errorf("missing workdir"))
+	if p.EnvOverrides != nil {
+		if err := ValidateEnvOverrides(p.EnvOverrides); err != nil {
+			return opts, err
+		}
+		opts.EnvOverrides = p.EnvOverrides
 	}
 
-	// Extract optional description
-	description, _ := req.Payload["description"].(string)
+	if p.LogDir != "" {
+		if !filepath.IsAbs(p.LogDir) {
+			return opts, fmt.Errorf("log directory must be an absolute path: %s", p.LogDir)
+		}
+		opts.LogDir = filepath.Clean(p.LogDir)
+	}
 
-	// Extract optional blocked flag
-	blocked, _ := req.Payload["blocked"].(bool)
+	if p.RunDir != "" {
+		if !filepath.IsAbs(p.RunDir) {
+			return opts, fmt.Errorf("run directory must be an absolute path: %s", p.RunDir)
+		}
+		opts.RunDir = filepath.Clean(p.RunDir)
+	}
 
-	job, err := d.jobManager.CreateJob(command, workdir, description, blocked)
+	// Tags replace the current ones when given
+	if p.Tags != nil {
+		normalized, err := NormalizeTags(p.Tags)
+		if err != nil {
+			return opts, err
+		}
+		opts.Tags = normalized
+	}
+
+	return opts, nil
+}
+
+// handleCreate handles a create request (add job without starting)
+func (d *Daemon) handleCreate(req *Request) *Response {
+	p, opts, err := addPayload(req)
 	if err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
+	job, err := d.jobManager.CreateJob(p.Command, p.Workdir, opts)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
 
-	return resp
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
 }
 
 // handleStop handles a stop request
 func (d *Daemon) handleStop(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p StopPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
-
-	force, _ := req.Payload["force"].(bool)
+	jobID := d.jobManager.ResolveJobID(p.JobID)
 
 	// Get PID from current run, or latest run if stopped
 	run := d.jobManager.GetCurrentRun(jobID)
@@ -524,85 +847,73 @@ func (d *Daemon) handleStop(req *Request) *Response {
 		pid = run.PID
 	}
 
-	if err := d.jobManager.StopJob(jobID, force); err != nil {
+	if err := d.jobManager.StopJob(jobID, p.Force); err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job_id"] = jobID
-	resp.Data["pid"] = pid
-	resp.Data["force"] = force
-	return resp
+	return NewDataResponse(StopData{JobID: jobID, PID: pid, Force: p.Force})
 }
 
 // handleStart handles a start request
 func (d *Daemon) handleStart(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p StartPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
-
-	// Extract environment
-	var env []string
-	if envRaw, ok := req.Payload["env"]; ok {
-		switch v := envRaw.(type) {
-		case []interface{}:
-			for _, item := range v {
-				if s, ok := item.(string); ok {
-					env = append(env, s)
-				}
-			}
+	if p.EnvSource != "" {
+		if err := ValidateEnvSource(p.EnvSource); err != nil {
+			return NewErrorResponse(err)
 		}
 	}
+	jobID := d.jobManager.ResolveJobID(p.JobID)
 
-	if err := d.jobManager.StartJob(jobID, env); err != nil {
+	if err := d.jobManager.StartJob(jobID, p.Env, p.EnvSource, requestOrigin(req)); err != nil {
 		return NewErrorResponse(err)
 	}
 
 	job, _ := d.jobManager.GetJob(jobID)
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
-	return resp
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
 }
 
 // handleRestart handles a restart request
 func (d *Daemon) handleRestart(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p StartPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
-
-	// Extract environment
-	var env []string
-	if envRaw, ok := req.Payload["env"]; ok {
-		switch v := envRaw.(type) {
-		case []interface{}:
-			for _, item := range v {
-				if s, ok := item.(string); ok {
-					env = append(env, s)
-				}
-			}
+	if p.EnvSource != "" {
+		if err := ValidateEnvSource(p.EnvSource); err != nil {
+			return NewErrorResponse(err)
 		}
 	}
+	jobID := d.jobManager.ResolveJobID(p.JobID)
 
-	if err := d.jobManager.RestartJob(jobID, env); err != nil {
+	if err := d.jobManager.RestartJob(jobID, p.Env, p.EnvSource, requestOrigin(req)); err != nil {
 		return NewErrorResponse(err)
 	}
 
 	job, _ := d.jobManager.GetJob(jobID)
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
-	return resp
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
 }
 
 // handleRemove handles a remove request
 func (d *Daemon) handleRemove(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p RemovePayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
+	jobID := d.jobManager.ResolveJobID(p.JobID)
 
 	run := d.jobManager.GetLatestRun(jobID)
 	var pid int
@@ -610,52 +921,123 @@ func (d *Daemon) handleRemove(req *Request) *Response {
 		pid = run.PID
 	}
 
-	if err := d.jobManager.RemoveJob(jobID); err != nil {
+	if err := d.jobManager.RemoveJob(jobID, p.Force); err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job_id"] = jobID
-	resp.Data["pid"] = pid
-	return resp
+	return NewDataResponse(RemoveData{JobID: jobID, PID: pid})
 }
 
 // handleRemoveRun handles a remove_run request
 func (d *Daemon) handleRemoveRun(req *Request) *Response {
-	runID, ok := req.Payload["run_id"].(string)
-	if !ok {
+	var p RemoveRunPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.RunID == "" {
 		return NewErrorResponse(fmt.Errorf("missing run_id"))
 	}
 
-	if err := d.jobManager.RemoveRun(runID); err != nil {
+	if err := d.jobManager.RemoveRun(p.RunID); err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["run_id"] = runID
-	return resp
+	return NewDataResponse(RemoveRunData{RunID: p.RunID})
+}
+
+// handleSetAlias handles a set_alias request (empty alias removes it)
+func (d *Daemon) handleSetAlias(req *Request) *Response {
+	var p SetAliasPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+
+	job, err := d.jobManager.SetAlias(p.JobID, p.Alias)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
+}
+
+// handleSetDescription handles a set_description request (empty description
+// removes it)
+func (d *Daemon) handleSetDescription(req *Request) *Response {
+	var p SetDescriptionPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+
+	job, err := d.jobManager.SetDescription(p.JobID, p.Description)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
+}
+
+// handleSetPinned handles a set_pinned request
+func (d *Daemon) handleSetPinned(req *Request) *Response {
+	var p SetPinnedPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+
+	job, err := d.jobManager.SetPinned(p.JobID, p.Pinned)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
+}
+
+// handleAnnotateRun handles an annotate_run request (empty note removes it)
+func (d *Daemon) handleAnnotateRun(req *Request) *Response {
+	var p AnnotateRunPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.RunID == "" {
+		return NewErrorResponse(fmt.Errorf("missing run_id"))
+	}
+
+	run, err := d.jobManager.AnnotateRun(p.RunID, p.Note)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(RunData{Run: runToResponse(run)})
 }
 
 // handleStopAll handles a stop_all request
 func (d *Daemon) handleStopAll(req *Request) *Response {
 	stopped := d.jobManager.StopAll()
 
-	resp := NewSuccessResponse()
-	resp.Data["stopped"] = stopped
-	return resp
+	return NewDataResponse(StopAllData{Stopped: stopped})
 }
 
 // handleSignal handles a signal request
 func (d *Daemon) handleSignal(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p SignalPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
-
-	signalNum, ok := req.Payload["signal"].(float64)
-	if !ok {
+	if p.Signal == nil {
 		return NewErrorResponse(fmt.Errorf("missing signal"))
 	}
+	jobID := d.jobManager.ResolveJobID(p.JobID)
 
 	// Check if job exists first
 	_, err := d.jobManager.GetJob(jobID)
@@ -668,22 +1050,51 @@ func (d *Daemon) handleSignal(req *Request) *Response {
 		return NewErrorResponse(fmt.Errorf("job %s is not running", jobID))
 	}
 
-	if err := d.jobManager.Signal(jobID, syscall.Signal(int(signalNum))); err != nil {
+	if err := d.jobManager.Signal(jobID, syscall.Signal(*p.Signal)); err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job_id"] = jobID
-	resp.Data["pid"] = run.PID
-	resp.Data["signal"] = int(signalNum)
-	return resp
+	return NewDataResponse(SignalData{JobID: jobID, PID: run.PID, Signal: *p.Signal})
+}
+
+// handleReload handles a reload request
+func (d *Daemon) handleReload(req *Request) *Response {
+	var p ReloadPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+
+	run, sig, err := d.jobManager.ReloadJob(d.jobManager.ResolveJobID(p.JobID))
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(ReloadData{JobID: run.JobID, RunID: run.ID, PID: run.PID, Signal: int(sig)})
+}
+
+// handleMarkStuck handles a mark_stuck request
+func (d *Daemon) handleMarkStuck(req *Request) *Response {
+	jobID, err := d.jobIDFromPayload(req)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	run, err := d.jobManager.MarkStuck(jobID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(RunData{Run: runToResponse(run)})
 }
 
 // handleGetJob handles a get_job request
 func (d *Daemon) handleGetJob(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
-		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	jobID, err := d.jobIDFromPayload(req)
+	if err != nil {
+		return NewErrorResponse(err)
 	}
 
 	job, err := d.jobManager.GetJob(jobID)
@@ -691,17 +1102,17 @@ func (d *Daemon) handleGetJob(req *Request) *Response {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
-	return resp
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
 }
 
 // handleVersion handles a version request
 func (d *Daemon) handleVersion(req *Request) *Response {
-	resp := NewSuccessResponse()
-	resp.Data["version"] = version.Version
-	resp.Data["running_jobs"] = d.countRunningJobs()
-	return resp
+	return NewDataResponse(VersionData{
+		Version:         version.Version,
+		ProtocolVersion: ProtocolVersion,
+		RunningJobs:     d.countRunningJobs(),
+		Capabilities:    Capabilities,
+	})
 }
 
 // countRunningJobs returns the count of currently running jobs
@@ -716,69 +1127,104 @@ func (d *Daemon) countRunningJobs() int {
 	return count
 }
 
-// handleRuns handles a runs request
+// handleRuns handles a runs request. Without job_id, it returns the runs
+// of all jobs in the given workdir.
 func (d *Daemon) handleRuns(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p RunsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	var jobIDs []string
+	if p.JobID != "" {
+		jobIDs = []string{d.jobManager.ResolveJobID(p.JobID)}
+	} else if p.Workdir != "" {
+		for _, job := range d.jobManager.ListJobs(p.Workdir) {
+			jobIDs = append(jobIDs, job.ID)
+		}
+	} else {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
 
-	runs, err := d.jobManager.ListRunsForJob(jobID)
-	if err != nil {
-		return NewErrorResponse(err)
+	var runs []*Run
+	for _, jobID := range jobIDs {
+		jobRuns, err := d.jobManager.ListRunsForJob(jobID)
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		runs = append(runs, jobRuns...)
+		if p.IncludeArchived {
+			archived, err := d.jobManager.ListArchivedRuns(jobID)
+			if err != nil {
+				return NewErrorResponse(fmt.Errorf("failed to load archived runs: %w", err))
+			}
+			runs = append(runs, archived...)
+		}
 	}
 
-	var runResponses []RunResponse
-	for _, run := range runs {
-		runResponses = append(runResponses, runToResponse(run))
+	data := RunsData{Total: len(runs)}
+	for _, run := range page(runs, p.Offset, p.Limit) {
+		data.Runs = append(data.Runs, runToResponse(run))
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["runs"] = runResponses
-	return resp
+	return NewDataResponse(data)
+}
+
+// handleHistory handles a history request
+func (d *Daemon) handleHistory(req *Request) *Response {
+	var p HistoryPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.Offset < 0 || p.Limit < 0 {
+		return NewErrorResponse(fmt.Errorf("offset and limit must not be negative"))
+	}
+
+	entries, total := d.jobManager.ListHistory(p.Workdir, p.Label, p.Offset, p.Limit)
+
+	return NewDataResponse(HistoryData{Runs: entries, Total: total})
 }
 
 // handleStats handles a stats request
 func (d *Daemon) handleStats(req *Request) *Response {
-	jobID, ok := req.Payload["job_id"].(string)
-	if !ok {
+	var p StatsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
 		return NewErrorResponse(fmt.Errorf("missing job_id"))
 	}
 
-	job, err := d.jobManager.GetJob(jobID)
+	stats, err := d.jobManager.JobStatsIn(d.jobManager.ResolveJobID(p.JobID), p.Conditions)
 	if err != nil {
 		return NewErrorResponse(err)
 	}
 
-	resp := NewSuccessResponse()
-	resp.Data["job"] = d.jobManager.jobToResponse(job)
-	return resp
+	return NewDataResponse(JobData{Job: stats})
 }
 
 // handlePorts handles a ports request
 func (d *Daemon) handlePorts(req *Request) *Response {
-	jobID, _ := req.Payload["job_id"].(string)
-	workdir, _ := req.Payload["workdir"].(string)
-
-	resp := NewSuccessResponse()
+	var p PortsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
 
-	if jobID != "" {
+	if p.JobID != "" {
 		// Refresh and get ports for specific job (triggers event if changed)
-		jobPorts, err := d.jobManager.RefreshJobPorts(jobID)
-		if err != nil {
-			return NewErrorResponse(err)
-		}
-		resp.Data["ports"] = jobPorts
-	} else {
-		// Refresh and get ports for all running jobs (triggers events if changed)
-		allPorts, err := d.jobManager.RefreshAllJobPorts(workdir)
+		jobPorts, err := d.jobManager.RefreshJobPorts(d.jobManager.ResolveJobID(p.JobID))
 		if err != nil {
 			return NewErrorResponse(err)
 		}
-		resp.Data["ports"] = allPorts
+		return NewDataResponse(JobPortsData{Ports: jobPorts})
 	}
 
-	return resp
+	// Refresh and get ports for all running jobs (triggers events if changed)
+	allPorts, err := d.jobManager.RefreshAllJobPorts(p.Workdir)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewDataResponse(AllPortsData{Ports: allPorts})
 }
 
 // sendErrorResponse sends an error response to the client
@@ -789,25 +1235,59 @@ func (d *Daemon) sendErrorResponse(encoder *json.Encoder, err error) {
 
 // handleSubscribe handles a subscribe request
 func (d *Daemon) handleSubscribe(req *Request, conn net.Conn, encoder *json.Encoder) {
-	workdir, _ := req.Payload["workdir"].(string)
+	var p SubscribePayload
+	err := checkProtocolVersion(req)
+	if err == nil {
+		err = decodePayload(req, &p)
+	}
+	if err == nil {
+		err = p.EventMatch.Validate()
+	}
+	if err != nil {
+		d.sendErrorResponse(encoder, err)
+		conn.Close()
+		return
+	}
+	for i, jobID := range p.JobIDs {
+		p.JobIDs[i] = d.jobManager.ResolveJobID(jobID)
+	}
+	snapshot := p.Snapshot
+	replay := p.Since != nil
 
 	// Create subscriber
 	sub := &Subscriber{
-		conn:    conn,
-		encoder: encoder,
-		workdir: workdir,
+		conn:      conn,
+		encoder:   encoder,
+		workdir:   NormalizeWorkdir(p.Workdir),
+		match:     p.EventMatch,
+		coalesce:  p.Coalesce,
+		replaying: snapshot || replay,
 	}
 
-	// Add to subscribers list
+	// Add to subscribers list, and note where its replay starts and ends
+	d.eventsMu.Lock()
 	d.subscribersMu.Lock()
 	d.subscribers = append(d.subscribers, sub)
 	d.subscribersMu.Unlock()
+	seq := d.events.seq
+	var backlog []Event
+	if replay {
+		var ok bool
+		backlog, ok = d.events.since(*p.Since)
+		if !ok && *p.Since < seq && d.store != nil {
+			backlog, ok = d.replayFromStore(*p.Since)
+		}
+		// Events that can no longer be replayed are covered by a snapshot
+		if !ok {
+			snapshot = true
+		}
+	}
+	d.eventsMu.Unlock()
 
-	Logger.Debug("subscriber added", "workdir", workdir, "total", len(d.subscribers))
+	Logger.Debug("subscriber added", "workdir", p.Workdir, "total", len(d.subscribers))
 
 	// Send success response
-	resp := NewSuccessResponse()
-	resp.Data["message"] = "subscribed"
+	resp := NewDataResponse(MessageData{Message: "subscribed", Seq: seq})
 	if err := encoder.Encode(resp); err != nil {
 		Logger.Error("error sending subscribe response", "error", err)
 		d.removeSubscriber(sub)
@@ -815,6 +1295,36 @@ func (d *Daemon) handleSubscribe(req *Request, conn net.Conn, encoder *json.Enco
 		return
 	}
 
+	// Send the snapshot and replayed events, then the events that happened
+	// meanwhile. A snapshot may already include some of those.
+	if sub.replaying {
+		if snapshot {
+			err = encoder.Encode(d.snapshotEvent(p.Workdir, seq))
+		}
+		for _, event := range backlog {
+			if err == nil && sub.wants(event) {
+				err = encoder.Encode(event)
+			}
+		}
+
+		d.eventsMu.Lock()
+		for _, event := range sub.pending {
+			if err == nil {
+				err = encoder.Encode(event)
+			}
+		}
+		sub.pending = nil
+		sub.replaying = false
+		d.eventsMu.Unlock()
+
+		if err != nil {
+			Logger.Error("error replaying events to subscriber", "error", err)
+			d.removeSubscriber(sub)
+			conn.Close()
+			return
+		}
+	}
+
 	// Keep connection open and wait for it to close
 	// The connection will be closed when the client disconnects or daemon shuts down
 	// We detect this by trying to read (which will block until close or error)
@@ -833,7 +1343,42 @@ func (d *Daemon) handleSubscribe(req *Request, conn net.Conn, encoder *json.Enco
 	Logger.Debug("subscriber removed", "total", len(d.subscribers))
 }
 
-// broadcastEvent sends an event to all subscribed clients
+// replayFromStore returns the recorded events after since, when the recent
+// events no longer go back that far. ok is false if some were deleted.
+// Caller must hold d.eventsMu.
+func (d *Daemon) replayFromStore(since uint64) (events []Event, ok bool) {
+	events, err := d.store.ListEvents(EventFilter{AfterID: since})
+	if err != nil {
+		Logger.Error("failed to read recorded events", "error", err)
+		return nil, false
+	}
+	if len(events) == 0 || events[0].Seq != since+1 {
+		return nil, false
+	}
+	return events, true
+}
+
+// snapshotEvent returns a snapshot of the jobs in a directory (all if empty)
+// and their runs, marked with the sequence number of the last event
+func (d *Daemon) snapshotEvent(workdir string, seq uint64) Event {
+	event := Event{
+		Seq:             seq,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+		Type:            EventTypeSnapshot,
+		JobCount:        d.jobManager.JobCount(),
+		RunningJobCount: d.jobManager.RunningJobCount(),
+	}
+	for _, job := range d.jobManager.ListJobs(workdir) {
+		event.Jobs = append(event.Jobs, d.jobManager.jobToResponse(job))
+		runs, _ := d.jobManager.ListRunsForJob(job.ID)
+		for _, run := range runs {
+			event.Runs = append(event.Runs, runToResponse(run))
+		}
+	}
+	return event
+}
+
+// broadcastEvent sends an event to all subscribed clients. Caller must hold d.eventsMu.
 func (d *Daemon) broadcastEvent(event Event) {
 	d.subscribersMu.RLock()
 	subscribers := make([]*Subscriber, len(d.subscribers))
@@ -844,7 +1389,18 @@ func (d *Daemon) broadcastEvent(event Event) {
 
 	for _, sub := range subscribers {
 		// Check workdir filter
-		if sub.workdir != "" && event.Job.Workdir != sub.workdir {
+		if !sub.wants(event) {
+			continue
+		}
+
+		// Hold events back until the subscriber's replay is done
+		if sub.replaying {
+			sub.pending = append(sub.pending, event)
+			continue
+		}
+
+		// Approvals are never folded into a resync, which doesn't carry them
+		if sub.coalesce && event.Approval == nil && d.holdCoalesced(sub, event) {
 			continue
 		}
 
@@ -878,11 +1434,71 @@ func (d *Daemon) removeSubscriber(sub *Subscriber) {
 
 // handleEvent processes events from the job manager
 func (d *Daemon) handleEvent(event Event) {
-	// Broadcast to subscribers
+	d.eventsMu.Lock()
+	defer d.eventsMu.Unlock()
+
+	// Number the event, keep it for replay, record it and broadcast to subscribers
+	event.Time = time.Now().UTC().Format(time.RFC3339)
+	event = d.events.append(event)
+	if d.store != nil {
+		if err := d.store.InsertEvent(event); err != nil {
+			Logger.Error("failed to record event", "seq", event.Seq, "error", err)
+		}
+	}
 	d.broadcastEvent(event)
 }
 
-// recoverFromCrash handles cleanup after a daemon crash
+// handleEvents handles an events request: the recorded events matching the
+// filter, and the sequence number of the last event
+func (d *Daemon) handleEvents(req *Request) *Response {
+	var p EventsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	f := EventFilter{Workdir: p.Workdir, AfterID: p.After}
+	if p.Since != "" {
+		since, err := time.Parse(time.RFC3339, p.Since)
+		if err != nil {
+			return NewErrorResponse(fmt.Errorf("invalid since: %w", err))
+		}
+		f.Since = since
+	}
+
+	d.eventsMu.Lock()
+	seq := d.events.seq
+	events, err := d.recordedEvents(f)
+	d.eventsMu.Unlock()
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("failed to list events: %w", err))
+	}
+
+	return NewDataResponse(EventsData{Events: events, Seq: seq})
+}
+
+// recordedEvents returns the recorded events matching the filter, from the
+// store, or from the recent events without one. Caller must hold d.eventsMu.
+func (d *Daemon) recordedEvents(f EventFilter) ([]Event, error) {
+	if d.store != nil {
+		return d.store.ListEvents(f)
+	}
+
+	var events []Event
+	for _, event := range d.events.events {
+		if event.Seq <= f.AfterID || (f.Workdir != "" && event.Job.Workdir != f.Workdir) {
+			continue
+		}
+		if t, _ := time.Parse(time.RFC3339, event.Time); t.Before(f.Since) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// recoverFromCrash re-adopts the runs left running by a daemon that crashed.
+// Their processes still write to the log files directly, unless their output
+// or stdin went through the crashed daemon.
 func (d *Daemon) recoverFromCrash() error {
 	// Find all runs marked as 'running' (they're orphans now)
 	orphans, err := d.store.FindOrphanRuns()
@@ -890,33 +1506,21 @@ func (d *Daemon) recoverFromCrash() error {
 		return fmt.Errorf("failed to find orphan runs: %w", err)
 	}
 
+	runs := make([]handoverRun, 0, len(orphans))
 	for _, orphan := range orphans {
 		run := orphan.Run
 		Logger.Info("found orphan run", "id", run.ID, "pid", run.PID)
 
-		// Verify this is actually our process (PIDs can be reused!)
-		if isOurProcess(run.PID, run.StartedAt, orphan.Command) {
-			Logger.Info("killing orphan process", "pid", run.PID)
-
-			// Signal the process group
-			syscall.Kill(-run.PID, syscall.SIGTERM)
-
-			// Wait briefly for graceful shutdown
-			time.Sleep(2 * time.Second)
-
-			// Force kill if still running
-			if processExists(run.PID) {
-				syscall.Kill(-run.PID, syscall.SIGKILL)
-			}
-		} else {
-			Logger.Info("orphan process no longer exists or doesn't match", "pid", run.PID)
-		}
-
-		// Mark run as stopped
-		if err := d.store.MarkRunStopped(run.ID); err != nil {
-			Logger.Warn("failed to mark run as stopped", "id", run.ID, "error", err)
-		}
+		runs = append(runs, handoverRun{
+			ID:         run.ID,
+			PID:        run.PID,
+			StartedAt:  run.StartedAt,
+			CgroupPath: findRunCgroup(run.PID, run.ID),
+		})
 	}
 
+	// Processes are verified with isOurProcess, PIDs can be reused
+	adopted := d.jobManager.AdoptRuns(runs)
+	Logger.Info("recovered orphan runs", "orphans", len(orphans), "adopted", adopted)
 	return nil
 }
@@ -1,6 +1,8 @@
 package daemon
 
 import (
+	"encoding/json"
+	"net"
 	"testing"
 	"time"
 )
@@ -53,7 +55,7 @@ func TestDaemon_handleList_WithJobs(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job
-	jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 
 	d := &Daemon{jobManager: jm}
 	req := &Request{Type: RequestTypeList, Payload: map[string]interface{}{}}
@@ -211,12 +213,87 @@ func TestDaemon_handleAdd_MissingWorkdir(t *testing.T) {
 	}
 }
 
+func TestDaemon_handleAdd_InvalidPriority(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	d := &Daemon{jobManager: jm}
+	req := &Request{
+		Type: RequestTypeAdd,
+		Payload: map[string]interface{}{
+			"command":  []interface{}{"echo"},
+			"workdir":  "/tmp",
+			"priority": "urgent",
+		},
+	}
+
+	resp := d.handleRequest(req)
+
+	if resp.Success {
+		t.Error("expected error for invalid priority")
+	}
+	if executor.StartCount() != 0 {
+		t.Error("expected job not to be started")
+	}
+}
+
+func TestDaemon_handleAdd_Stdin(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	d := &Daemon{jobManager: jm}
+	req := &Request{
+		Type: RequestTypeAdd,
+		Payload: map[string]interface{}{
+			"command": []interface{}{"cat"},
+			"workdir": "/tmp",
+			"stdin":   StdinOpen,
+		},
+	}
+
+	resp := d.handleRequest(req)
+
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	if executor.LastOptions().Stdin != StdinOpen {
+		t.Errorf("expected executor stdin %q, got %q", StdinOpen, executor.LastOptions().Stdin)
+	}
+}
+
+func TestDaemon_handleAdd_InvalidStdin(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	d := &Daemon{jobManager: jm}
+	req := &Request{
+		Type: RequestTypeAdd,
+		Payload: map[string]interface{}{
+			"command": []interface{}{"cat"},
+			"workdir": "/tmp",
+			"stdin":   "relative/input.txt",
+		},
+	}
+
+	resp := d.handleRequest(req)
+
+	if resp.Success {
+		t.Error("expected error for relative stdin path")
+	}
+	if executor.StartCount() != 0 {
+		t.Error("expected job not to be started")
+	}
+}
+
 func TestDaemon_handleGetJob(t *testing.T) {
 	tmpDir := t.TempDir()
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	d := &Daemon{jobManager: jm}
 	req := &Request{
@@ -263,7 +340,7 @@ func TestDaemon_handleStop(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Stop the fake process so Stop() can succeed
 	executor.LastHandle().Stop()
@@ -356,8 +433,8 @@ func TestDaemon_handleVersion_WithRunningJobs(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add running jobs
-	jm.AddJob([]string{"echo", "1"}, "/workdir", "", false, nil)
-	jm.AddJob([]string{"echo", "2"}, "/workdir", "", false, nil)
+	jm.AddJob([]string{"echo", "1"}, "/workdir", JobOptions{}, nil)
+	jm.AddJob([]string{"echo", "2"}, "/workdir", JobOptions{}, nil)
 
 	d := &Daemon{jobManager: jm}
 	req := &Request{
@@ -382,7 +459,7 @@ func TestDaemon_handlePorts(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	d := &Daemon{jobManager: jm}
 	req := &Request{
@@ -410,7 +487,7 @@ func TestDaemon_handlePorts_StoppedJob(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Stop the job
 	executor.LastHandle().Stop()
@@ -443,8 +520,8 @@ func TestDaemon_handlePorts_AllJobs(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add multiple jobs
-	jm.AddJob([]string{"echo", "1"}, "/workdir", "", false, nil)
-	jm.AddJob([]string{"echo", "2"}, "/workdir", "", false, nil)
+	jm.AddJob([]string{"echo", "1"}, "/workdir", JobOptions{}, nil)
+	jm.AddJob([]string{"echo", "2"}, "/workdir", JobOptions{}, nil)
 
 	d := &Daemon{jobManager: jm}
 	req := &Request{
@@ -471,7 +548,7 @@ func TestDaemon_handleRemoveRun(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job (which creates a run)
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Get the run ID
 	runs, _ := jm.ListRunsForJob(job.ID)
@@ -550,7 +627,7 @@ func TestDaemon_handleRemoveRun_Running(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job (which creates a running run)
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Get the run ID while it's still running
 	runs, _ := jm.ListRunsForJob(job.ID)
@@ -577,12 +654,12 @@ func TestDaemon_handleList_IncludesStats(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job and complete a run to build stats
-	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
 	// Start a second run so we have RunCount > 0
-	jm.StartJob(job.ID, nil)
+	jm.StartJob(job.ID, nil, EnvSourceClient, Origin{})
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
@@ -622,7 +699,7 @@ func TestDaemon_handleStats_ReturnsJobResponse(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job and complete a run
-	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
@@ -663,7 +740,7 @@ func TestDaemon_handleAdd_IncludesStatsInJobResponse(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job and complete a run to build stats
-	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
@@ -699,3 +776,238 @@ func TestDaemon_handleAdd_IncludesStatsInJobResponse(t *testing.T) {
 		t.Error("stats should not be a separate field in response, should be part of job")
 	}
 }
+
+func TestDaemon_handleRuns_Workdir(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	first, _, _ := jm.AddJob([]string{"echo", "one"}, "/workdir", JobOptions{}, nil)
+	second, _, _ := jm.AddJob([]string{"echo", "two"}, "/workdir", JobOptions{}, nil)
+	jm.AddJob([]string{"echo", "three"}, "/other", JobOptions{}, nil)
+
+	d := &Daemon{jobManager: jm}
+	req := &Request{
+		Type: RequestTypeRuns,
+		Payload: map[string]interface{}{
+			"workdir": "/workdir",
+		},
+	}
+
+	resp := d.handleRequest(req)
+
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	runs := resp.Data["runs"].([]RunResponse)
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+	for _, run := range runs {
+		if run.JobID != first.ID && run.JobID != second.ID {
+			t.Errorf("unexpected run %s from another directory", run.ID)
+		}
+	}
+}
+
+func TestDaemon_handleRuns_Page(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	job, _ := jm.CreateJob([]string{"make", "test"}, "/workdir", JobOptions{})
+	for range 5 {
+		addStoppedRun(jm, job, 0, 1000)
+	}
+
+	d := &Daemon{jobManager: jm}
+	resp := d.handleRequest(NewTypedRequest(RequestTypeRuns, RunsPayload{JobID: job.ID, Offset: 1, Limit: 2}))
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	runs := resp.Data["runs"].([]RunResponse)
+	if len(runs) != 2 || runs[0].ID != job.ID+"-4" || runs[1].ID != job.ID+"-3" {
+		t.Errorf("expected runs %s-4 and %s-3, got %v", job.ID, job.ID, runs)
+	}
+	if total := resp.Data["total"].(int); total != 5 {
+		t.Errorf("expected a total of 5 runs, got %d", total)
+	}
+}
+
+func TestDaemon_handleSubscribe_SnapshotAndReplay(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(tmpDir, d.handleEvent, executor, nil)
+
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	d.jobManager.AddJob([]string{"make", "test"}, "/other", JobOptions{}, nil)
+	if d.events.seq == 0 {
+		t.Fatal("expected events to be numbered")
+	}
+
+	server, client := net.Pipe()
+	defer client.Close()
+	req := &Request{Type: RequestTypeSubscribe, Payload: map[string]interface{}{
+		"workdir":  "/workdir",
+		"snapshot": true,
+		"since":    float64(0),
+	}}
+	go d.handleSubscribe(req, server, json.NewEncoder(server))
+
+	decoder := json.NewDecoder(client)
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("expected subscribe success, got %+v (%v)", resp, err)
+	}
+	if resp.Data["seq"] != float64(d.events.seq) {
+		t.Errorf("expected seq %d, got %v", d.events.seq, resp.Data["seq"])
+	}
+
+	var snapshot Event
+	decoder.Decode(&snapshot)
+	if snapshot.Type != EventTypeSnapshot || snapshot.Seq != d.events.seq {
+		t.Fatalf("expected snapshot at seq %d, got %s at %d", d.events.seq, snapshot.Type, snapshot.Seq)
+	}
+	if len(snapshot.Jobs) != 1 || snapshot.Jobs[0].ID != job.ID || len(snapshot.Runs) != 1 {
+		t.Errorf("expected the /workdir job and its run, got %d jobs and %d runs", len(snapshot.Jobs), len(snapshot.Runs))
+	}
+
+	// Replayed events only include the subscriber's directory
+	var replayed Event
+	decoder.Decode(&replayed)
+	if replayed.Seq != 1 || replayed.JobID != job.ID {
+		t.Errorf("expected event 1 of job %s, got %d of %s", job.ID, replayed.Seq, replayed.JobID)
+	}
+	decoder.Decode(&replayed)
+	if replayed.Seq != 2 || replayed.JobID != job.ID {
+		t.Errorf("expected event 2 of job %s, got %d of %s", job.ID, replayed.Seq, replayed.JobID)
+	}
+
+	// New events follow, also numbered
+	lastSeq := d.events.seq
+	go d.jobManager.AddJob([]string{"make", "lint"}, "/workdir", JobOptions{}, nil)
+	var live Event
+	if err := decoder.Decode(&live); err != nil {
+		t.Fatal(err)
+	}
+	if live.Seq != lastSeq+1 || live.Type != EventTypeJobAdded {
+		t.Errorf("expected job_added with seq %d, got %s with %d", lastSeq+1, live.Type, live.Seq)
+	}
+}
+
+func TestDaemon_handleSubscribe_Filters(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(tmpDir, d.handleEvent, executor, nil)
+
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	d.jobManager.SetAlias(job.ID, "build")
+	handle := executor.LastHandle()
+	other, _, _ := d.jobManager.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	otherHandle := executor.LastHandle()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	req := NewTypedRequest(RequestTypeSubscribe, SubscribePayload{
+		EventMatch: EventMatch{Types: []EventType{EventTypeJobStopped}, JobIDs: []string{"build"}},
+	})
+	go d.handleSubscribe(req, server, json.NewEncoder(server))
+
+	decoder := json.NewDecoder(client)
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("expected subscribe success, got %+v (%v)", resp, err)
+	}
+
+	// The other job's stop and the job's run events are left out
+	go func() {
+		otherHandle.Stop()
+		for d.jobManager.GetCurrentRun(other.ID) != nil {
+			time.Sleep(5 * time.Millisecond)
+		}
+		handle.Stop()
+	}()
+	var event Event
+	if err := decoder.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypeJobStopped || event.JobID != job.ID {
+		t.Errorf("expected job_stopped of %s, got %s of %s", job.ID, event.Type, event.JobID)
+	}
+
+	// Unknown event types are rejected
+	server, client = net.Pipe()
+	defer client.Close()
+	req = NewTypedRequest(RequestTypeSubscribe, SubscribePayload{EventMatch: EventMatch{Types: []EventType{"job_exploded"}}})
+	go d.handleSubscribe(req, server, json.NewEncoder(server))
+	if err := json.NewDecoder(client).Decode(&resp); err != nil || resp.Success {
+		t.Errorf("expected an error for an unknown event type, got %+v (%v)", resp, err)
+	}
+}
+
+func TestDaemon_handleSubscribe_Coalesce(t *testing.T) {
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(t.TempDir(), d.handleEvent, NewFakeProcessExecutor(), nil)
+
+	server, client := net.Pipe()
+	defer client.Close()
+	req := NewTypedRequest(RequestTypeSubscribe, SubscribePayload{Coalesce: true})
+	go d.handleSubscribe(req, server, json.NewEncoder(server))
+
+	decoder := json.NewDecoder(client)
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil || !resp.Success {
+		t.Fatalf("expected subscribe success, got %+v (%v)", resp, err)
+	}
+
+	// The first event of a burst is sent right away, the others are
+	// replaced by a resync
+	go func() {
+		for _, target := range []string{"build", "test", "lint"} {
+			d.jobManager.AddJob([]string{"make", target}, "/workdir", JobOptions{}, nil)
+		}
+	}()
+	var event Event
+	if err := decoder.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypeJobAdded {
+		t.Errorf("expected job_added first, got %s", event.Type)
+	}
+	if err := decoder.Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypeResync || len(event.Jobs) != 3 || len(event.Runs) != 3 {
+		t.Errorf("expected a resync with 3 jobs and runs, got %s with %d jobs and %d runs", event.Type, len(event.Jobs), len(event.Runs))
+	}
+}
+
+func TestDaemon_handleEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(tmpDir, d.handleEvent, executor, nil)
+
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	d.jobManager.AddJob([]string{"make", "test"}, "/other", JobOptions{}, nil)
+
+	resp := d.handleRequest(&Request{Type: RequestTypeEvents, Payload: map[string]interface{}{
+		"workdir": "/workdir",
+		"after":   float64(1),
+	}})
+	if !resp.Success {
+		t.Fatalf("expected success, got %s", resp.Error)
+	}
+
+	events := resp.Data["events"].([]Event)
+	if len(events) != 1 || events[0].Seq != 2 || events[0].JobID != job.ID {
+		t.Errorf("expected event 2 of job %s, got %v", job.ID, events)
+	}
+	if events[0].Time == "" {
+		t.Error("expected events to have a time")
+	}
+	if resp.Data["seq"] != d.events.seq {
+		t.Errorf("expected seq %d, got %v", d.events.seq, resp.Data["seq"])
+	}
+}
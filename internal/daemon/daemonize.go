@@ -8,7 +8,8 @@ import (
 )
 
 // StartDaemon starts the daemon process as a fully detached background process.
-// The daemon command uses go-daemon internally to properly daemonize with PPID=1.
+// On Unix the daemon command uses go-daemon internally to properly daemonize
+// with PPID=1; on Windows it is started as a detached process.
 func StartDaemon() error {
 	// Get path to current executable
 	exe, err := os.Executable()
@@ -22,7 +23,12 @@ func StartDaemon() error {
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
-	if err := cmd.Run(); err != nil {
+	// An isolated daemon does not run in its project directory
+	if project := ProjectDir(); project != "" {
+		cmd.Env = append(os.Environ(), "GOB_PROJECT_DIR="+project)
+	}
+
+	if err := startDaemonProcess(cmd); err != nil {
 		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 
@@ -33,7 +39,7 @@ func StartDaemon() error {
 	}
 
 	for i := 0; i < 20; i++ {
-		if _, err := os.Stat(socketPath); err == nil {
+		if ipcExists(socketPath) {
 			return nil
 		}
 		time.Sleep(100 * time.Millisecond)
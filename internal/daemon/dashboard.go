@@ -0,0 +1,18 @@
+package daemon
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// dashboardHTML is the web dashboard: a single page using the gateway's API
+//
+//go:embed web/dashboard.html
+var dashboardHTML []byte
+
+// serveDashboard serves the web dashboard
+func serveDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(dashboardHTML)
+}
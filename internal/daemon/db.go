@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"syscall"
+	"slices"
 	"time"
 
 	"github.com/pressly/goose/v3"
@@ -100,6 +100,25 @@ func (s *Store) SetShutdownClean(clean bool) error {
 	return err
 }
 
+// DaemonID returns the ID of the daemon keeping its state in this database,
+// created on first use. Unlike the instance ID, it stays the same when the
+// daemon restarts or hands over to a new version.
+func (s *Store) DaemonID() (string, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM daemon_state WHERE key = 'daemon_id'").Scan(&value)
+	if err == nil {
+		return value, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+	value = newDaemonID()
+	if _, err := s.db.Exec("INSERT INTO daemon_state (key, value) VALUES ('daemon_id', ?)", value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
 // SetInstanceID records the current daemon instance ID
 func (s *Store) SetInstanceID() error {
 	_, err := s.db.Exec(`
@@ -109,6 +128,28 @@ func (s *Store) SetInstanceID() error {
 	return err
 }
 
+// GatewayAddr returns the address of the HTTP gateway to start with the
+// daemon, empty if it is not enabled
+func (s *Store) GatewayAddr() string {
+	var value string
+	s.db.QueryRow("SELECT value FROM daemon_state WHERE key = 'gateway_addr'").Scan(&value)
+	return value
+}
+
+// SetGatewayAddr records the address of the HTTP gateway to start with the
+// daemon (empty disables it)
+func (s *Store) SetGatewayAddr(addr string) error {
+	if addr == "" {
+		_, err := s.db.Exec("DELETE FROM daemon_state WHERE key = 'gateway_addr'")
+		return err
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO daemon_state (key, value) VALUES ('gateway_addr', ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, addr)
+	return err
+}
+
 // InsertJob persists a new job to the database
 func (s *Store) InsertJob(job *Job) error {
 	commandJSON, err := json.Marshal(job.Command)
@@ -120,15 +161,33 @@ func (s *Store) InsertJob(job *Job) error {
 	if job.Blocked {
 		blocked = 1
 	}
+	timestamps := 0
+	if job.Timestamps {
+		timestamps = 1
+	}
+	combineOutput := 0
+	if job.CombineOutput {
+		combineOutput = 1
+	}
+	pinned := 0
+	if job.Pinned {
+		pinned = 1
+	}
 
 	_, err = s.db.Exec(`
-		INSERT INTO jobs (id, command_json, command_signature, workdir, description, blocked, next_run_seq, created_at,
+		INSERT INTO jobs (id, command_json, command_signature, workdir, alias, description, blocked, pinned, priority, memory_limit, cpu_limit,
+			on_start, on_success, on_failure, on_slow, stdin, log_format, timestamps, combine_output, output_head, output_tail, log_dir, container, dev_env, sandbox, run_dir, project_id, project_path, reload_signal, env_overrides_json, shell, slow_threshold, next_run_seq, created_at, created_by, created_via,
 			run_count, success_count, failure_count, success_total_duration_ms, failure_total_duration_ms, min_duration_ms, max_duration_ms)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, job.ID, string(commandJSON), job.CommandSignature, job.Workdir, nullableString(job.Description), blocked, job.NextRunSeq,
-		job.CreatedAt.Format(time.RFC3339), job.RunCount, job.SuccessCount, job.FailureCount,
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.ID, string(commandJSON), job.CommandSignature, job.Workdir, nullableString(job.Alias), nullableString(job.Description), blocked, pinned, priorityOrNormal(job.Priority),
+		job.MemoryLimit, job.CPULimit, nullableString(job.Hooks.OnStart), nullableString(job.Hooks.OnSuccess), nullableString(job.Hooks.OnFailure), nullableString(job.Hooks.OnSlow),
+		stdinOrNull(job.Stdin), logFormatOrText(job.LogFormat), timestamps, combineOutput, job.OutputHead, job.OutputTail, nullableString(job.LogDir), nullableString(job.Container), nullableString(job.DevEnv), nullableString(job.Sandbox), nullableString(job.RunDir), nullableString(job.ProjectID), nullableString(job.ProjectPath), job.ReloadSignal, nullableStrings(job.EnvOverrides), nullableString(job.Shell), nullableString(job.SlowThreshold), job.NextRunSeq,
+		job.CreatedAt.Format(time.RFC3339), nullableString(job.CreatedBy), nullableString(job.CreatedVia), job.RunCount, job.SuccessCount, job.FailureCount,
 		job.SuccessTotalDurationMs, job.FailureTotalDurationMs, nullableInt64(job.MinDurationMs), nullableInt64(job.MaxDurationMs))
-	return err
+	if err != nil {
+		return err
+	}
+	return s.saveJobTags(job)
 }
 
 // UpdateJob updates an existing job in the database
@@ -137,6 +196,18 @@ func (s *Store) UpdateJob(job *Job) error {
 	if job.Blocked {
 		blocked = 1
 	}
+	timestamps := 0
+	if job.Timestamps {
+		timestamps = 1
+	}
+	combineOutput := 0
+	if job.CombineOutput {
+		combineOutput = 1
+	}
+	pinned := 0
+	if job.Pinned {
+		pinned = 1
+	}
 
 	_, err := s.db.Exec(`
 		UPDATE jobs SET
@@ -148,16 +219,84 @@ func (s *Store) UpdateJob(job *Job) error {
 			failure_total_duration_ms = ?,
 			min_duration_ms = ?,
 			max_duration_ms = ?,
+			alias = ?,
 			description = ?,
-			blocked = ?
+			blocked = ?,
+			pinned = ?,
+			priority = ?,
+			memory_limit = ?,
+			cpu_limit = ?,
+			on_start = ?,
+			on_success = ?,
+			on_failure = ?,
+			on_slow = ?,
+			stdin = ?,
+			log_format = ?,
+			timestamps = ?,
+			combine_output = ?,
+			output_head = ?,
+			output_tail = ?,
+			log_dir = ?,
+			container = ?,
+			dev_env = ?,
+			sandbox = ?,
+			run_dir = ?,
+			project_id = ?,
+			project_path = ?,
+			reload_signal = ?,
+			env_overrides_json = ?,
+			slow_threshold = ?,
+			workdir = ?
 		WHERE id = ?
 	`, job.NextRunSeq, job.RunCount, job.SuccessCount, job.FailureCount,
 		job.SuccessTotalDurationMs, job.FailureTotalDurationMs, nullableInt64(job.MinDurationMs), nullableInt64(job.MaxDurationMs),
-		nullableString(job.Description), blocked, job.ID)
-	return err
+		nullableString(job.Alias), nullableString(job.Description), blocked, pinned, priorityOrNormal(job.Priority), job.MemoryLimit, job.CPULimit,
+		nullableString(job.Hooks.OnStart), nullableString(job.Hooks.OnSuccess), nullableString(job.Hooks.OnFailure), nullableString(job.Hooks.OnSlow), stdinOrNull(job.Stdin), logFormatOrText(job.LogFormat), timestamps, combineOutput, job.OutputHead, job.OutputTail, nullableString(job.LogDir), nullableString(job.Container), nullableString(job.DevEnv), nullableString(job.Sandbox), nullableString(job.RunDir), nullableString(job.ProjectID), nullableString(job.ProjectPath), job.ReloadSignal, nullableStrings(job.EnvOverrides), nullableString(job.SlowThreshold), job.Workdir, job.ID)
+	if err != nil {
+		return err
+	}
+	return s.saveJobTags(job)
+}
+
+// saveJobTags replaces the stored tags of a job
+func (s *Store) saveJobTags(job *Job) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM job_tags WHERE job_id = ?", job.ID); err != nil {
+		return err
+	}
+	for _, tag := range job.Tags {
+		if _, err := tx.Exec("INSERT INTO job_tags (job_id, tag) VALUES (?, ?)", job.ID, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// loadJobTags returns the tags of all jobs, keyed by job ID
+func (s *Store) loadJobTags() (map[string][]string, error) {
+	rows, err := s.db.Query("SELECT job_id, tag FROM job_tags ORDER BY job_id, tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var jobID, tag string
+		if err := rows.Scan(&jobID, &tag); err != nil {
+			return nil, err
+		}
+		tags[jobID] = append(tags[jobID], tag)
+	}
+	return tags, rows.Err()
 }
 
-// DeleteJob removes a job from the database (runs cascade)
+// DeleteJob removes a job from the database (runs and tags cascade)
 func (s *Store) DeleteJob(jobID string) error {
 	_, err := s.db.Exec("DELETE FROM jobs WHERE id = ?", jobID)
 	return err
@@ -166,13 +305,45 @@ func (s *Store) DeleteJob(jobID string) error {
 // InsertRun persists a new run to the database
 func (s *Store) InsertRun(run *Run) error {
 	_, err := s.db.Exec(`
-		INSERT INTO runs (id, job_id, pid, status, exit_code, stdout_path, stderr_path, started_at, stopped_at, daemon_instance_id)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO runs (id, job_id, pid, status, exit_code, stdout_path, stderr_path, started_at, stopped_at, daemon_instance_id, env_source, label, load_avg, on_battery, dir, started_by, started_via)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, run.ID, run.JobID, run.PID, run.Status, run.ExitCode, run.StdoutPath, run.StderrPath,
-		run.StartedAt.Format(time.RFC3339), nil, s.instanceID)
+		run.StartedAt.Format(time.RFC3339), nil, s.instanceID, run.EnvSource, run.Label, run.LoadAvg, run.OnBattery, nullableString(run.Dir), nullableString(run.StartedBy), nullableString(run.StartedVia))
 	return err
 }
 
+// InsertRunMarker records a marker of a run
+func (s *Store) InsertRunMarker(runID string, marker RunMarker) error {
+	_, err := s.db.Exec(`
+		INSERT INTO run_markers (run_id, kind, detail, stdout_offset, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, runID, marker.Kind, marker.Detail, marker.Offset, marker.At.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+// loadRunMarkers returns the markers of all runs, oldest first, keyed by run ID
+func (s *Store) loadRunMarkers() (map[string][]RunMarker, error) {
+	rows, err := s.db.Query("SELECT run_id, kind, detail, stdout_offset, created_at FROM run_markers ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	markers := make(map[string][]RunMarker)
+	for rows.Next() {
+		var runID, createdAt string
+		var marker RunMarker
+		if err := rows.Scan(&runID, &marker.Kind, &marker.Detail, &marker.Offset, &createdAt); err != nil {
+			return nil, err
+		}
+		if marker.At, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, fmt.Errorf("failed to parse marker time: %w", err)
+		}
+		markers[runID] = append(markers[runID], marker)
+	}
+	return markers, rows.Err()
+}
+
 // UpdateRun updates a run in the database
 func (s *Store) UpdateRun(run *Run) error {
 	var stoppedAt *string
@@ -181,10 +352,17 @@ func (s *Store) UpdateRun(run *Run) error {
 		stoppedAt = &t
 	}
 
+	var stdoutBytes, stdoutLines, stderrBytes, stderrLines *int64
+	if o := run.Output; o != nil {
+		stdoutBytes, stdoutLines, stderrBytes, stderrLines = &o.StdoutBytes, &o.StdoutLines, &o.StderrBytes, &o.StderrLines
+	}
+
 	_, err := s.db.Exec(`
-		UPDATE runs SET status = ?, exit_code = ?, stopped_at = ?
+		UPDATE runs SET status = ?, exit_code = ?, stopped_at = ?, log_bytes = ?, duration_ms = ?, note = ?, logs_missing = ?,
+			stdout_bytes = ?, stdout_lines = ?, stderr_bytes = ?, stderr_lines = ?
 		WHERE id = ?
-	`, run.Status, run.ExitCode, stoppedAt, run.ID)
+	`, run.Status, run.ExitCode, stoppedAt, run.LogBytes, run.DurationMs, run.Note, run.LogsMissing,
+		stdoutBytes, stdoutLines, stderrBytes, stderrLines, run.ID)
 	return err
 }
 
@@ -196,8 +374,14 @@ func (s *Store) DeleteRun(runID string) error {
 
 // LoadJobs loads all jobs from the database
 func (s *Store) LoadJobs() ([]*Job, error) {
+	tags, err := s.loadJobTags()
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := s.db.Query(`
-		SELECT id, command_json, command_signature, workdir, description, blocked, next_run_seq, created_at,
+		SELECT id, command_json, command_signature, workdir, alias, description, blocked, pinned, priority, memory_limit, cpu_limit,
+			on_start, on_success, on_failure, on_slow, stdin, log_format, timestamps, combine_output, output_head, output_tail, log_dir, container, dev_env, sandbox, run_dir, project_id, project_path, reload_signal, env_overrides_json, shell, slow_threshold, next_run_seq, created_at, created_by, created_via,
 			run_count, success_count, failure_count, success_total_duration_ms, failure_total_duration_ms, min_duration_ms, max_duration_ms
 		FROM jobs
 	`)
@@ -213,10 +397,38 @@ func (s *Store) LoadJobs() ([]*Job, error) {
 			commandJSON            string
 			commandSignature       string
 			workdir                string
+			alias                  sql.NullString
 			description            sql.NullString
 			blocked                int
+			pinned                 int
+			priority               string
+			memoryLimit            int64
+			cpuLimit               float64
+			onStart                sql.NullString
+			onSuccess              sql.NullString
+			onFailure              sql.NullString
+			onSlow                 sql.NullString
+			stdin                  string
+			logFormat              string
+			timestamps             int
+			combineOutput          int
+			outputHead             int64
+			outputTail             int64
+			logDir                 sql.NullString
+			container              sql.NullString
+			devEnv                 sql.NullString
+			sandbox                sql.NullString
+			runDir                 sql.NullString
+			projectID              sql.NullString
+			projectPath            sql.NullString
+			reloadSignal           int
+			envOverridesJSON       sql.NullString
+			shell                  sql.NullString
+			slowThreshold          sql.NullString
 			nextRunSeq             int
 			createdAtStr           string
+			createdBy              sql.NullString
+			createdVia             sql.NullString
 			runCount               int
 			successCount           int
 			failureCount           int
@@ -226,7 +438,8 @@ func (s *Store) LoadJobs() ([]*Job, error) {
 			maxDurationMs          sql.NullInt64
 		)
 
-		if err := rows.Scan(&id, &commandJSON, &commandSignature, &workdir, &description, &blocked, &nextRunSeq, &createdAtStr,
+		if err := rows.Scan(&id, &commandJSON, &commandSignature, &workdir, &alias, &description, &blocked, &pinned, &priority, &memoryLimit, &cpuLimit,
+			&onStart, &onSuccess, &onFailure, &onSlow, &stdin, &logFormat, &timestamps, &combineOutput, &outputHead, &outputTail, &logDir, &container, &devEnv, &sandbox, &runDir, &projectID, &projectPath, &reloadSignal, &envOverridesJSON, &shell, &slowThreshold, &nextRunSeq, &createdAtStr, &createdBy, &createdVia,
 			&runCount, &successCount, &failureCount, &successTotalDurationMs, &failureTotalDurationMs, &minDurationMs, &maxDurationMs); err != nil {
 			return nil, err
 		}
@@ -241,15 +454,48 @@ func (s *Store) LoadJobs() ([]*Job, error) {
 			return nil, fmt.Errorf("failed to parse created_at: %w", err)
 		}
 
+		var envOverrides []string
+		if envOverridesJSON.Valid {
+			if err := json.Unmarshal([]byte(envOverridesJSON.String), &envOverrides); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal env overrides: %w", err)
+			}
+		}
+
 		job := &Job{
 			ID:                     id,
 			Command:                command,
+			Shell:                  shell.String, // Empty if NULL
 			CommandSignature:       commandSignature,
 			Workdir:                workdir,
+			Alias:                  alias.String,       // Empty if NULL
 			Description:            description.String, // Empty if NULL
 			Blocked:                blocked != 0,
+			Pinned:                 pinned != 0,
+			Priority:               Priority(priority),
+			MemoryLimit:            memoryLimit,
+			CPULimit:               cpuLimit,
+			Hooks:                  JobHooks{OnStart: onStart.String, OnSuccess: onSuccess.String, OnFailure: onFailure.String, OnSlow: onSlow.String},
+			Stdin:                  stdin,
+			LogFormat:              logFormat,
+			Timestamps:             timestamps != 0,
+			CombineOutput:          combineOutput != 0,
+			OutputHead:             outputHead,
+			OutputTail:             outputTail,
+			LogDir:                 logDir.String,    // Empty if NULL
+			Container:              container.String, // Empty if NULL
+			DevEnv:                 devEnv.String,    // Empty if NULL
+			Sandbox:                sandbox.String,   // Empty if NULL
+			RunDir:                 runDir.String,    // Empty if NULL
+			ProjectID:              projectID.String, // Empty if NULL
+			ProjectPath:            projectPath.String,
+			ReloadSignal:           reloadSignal,
+			EnvOverrides:           envOverrides,
+			SlowThreshold:          slowThreshold.String, // Empty if NULL
+			Tags:                   tags[id],
 			NextRunSeq:             nextRunSeq,
 			CreatedAt:              createdAt,
+			CreatedBy:              createdBy.String,  // Empty if NULL
+			CreatedVia:             createdVia.String, // Empty if NULL
 			RunCount:               runCount,
 			SuccessCount:           successCount,
 			FailureCount:           failureCount,
@@ -266,10 +512,36 @@ func (s *Store) LoadJobs() ([]*Job, error) {
 
 // LoadRuns loads all runs from the database
 func (s *Store) LoadRuns() ([]*Run, error) {
+	return s.loadRuns("WHERE archived_at IS NULL")
+}
+
+// LoadArchivedRuns loads the archived runs of a job, which the daemon does
+// not keep in memory, newest first. Runs started in the same second are
+// ordered by their sequence number, the suffix of their ID.
+func (s *Store) LoadArchivedRuns(jobID string) ([]*Run, error) {
+	return s.loadRuns(`WHERE job_id = ? AND archived_at IS NOT NULL
+		ORDER BY started_at DESC, CAST(substr(id, length(job_id) + 2) AS INTEGER) DESC`, jobID)
+}
+
+// ArchiveRun records that a run was archived, with the size of its
+// gzipped logs
+func (s *Store) ArchiveRun(run *Run) error {
+	_, err := s.db.Exec("UPDATE runs SET archived_at = ?, log_bytes = ? WHERE id = ?",
+		run.ArchivedAt.Format(time.RFC3339), run.LogBytes, run.ID)
+	return err
+}
+
+// loadRuns loads the runs matching a WHERE clause
+func (s *Store) loadRuns(where string, args ...interface{}) ([]*Run, error) {
+	markers, err := s.loadRunMarkers()
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := s.db.Query(`
-		SELECT id, job_id, pid, status, exit_code, stdout_path, stderr_path, started_at, stopped_at
-		FROM runs
-	`)
+		SELECT id, job_id, pid, status, exit_code, stdout_path, stderr_path, started_at, stopped_at, log_bytes, duration_ms, note, env_source, label, archived_at, load_avg, on_battery, dir, logs_missing, started_by, started_via,
+			stdout_bytes, stdout_lines, stderr_bytes, stderr_lines
+		FROM runs `+where, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -287,9 +559,26 @@ func (s *Store) LoadRuns() ([]*Run, error) {
 			stderrPath   string
 			startedAtStr string
 			stoppedAtStr sql.NullString
+			logBytes     sql.NullInt64
+			durationMs   sql.NullInt64
+			note         string
+			envSource    string
+			label        string
+			archivedAt   sql.NullString
+			loadAvg      sql.NullFloat64
+			onBattery    sql.NullBool
+			dir          sql.NullString
+			logsMissing  bool
+			startedBy    sql.NullString
+			startedVia   sql.NullString
+			stdoutBytes  sql.NullInt64
+			stdoutLines  sql.NullInt64
+			stderrBytes  sql.NullInt64
+			stderrLines  sql.NullInt64
 		)
 
-		if err := rows.Scan(&id, &jobID, &pid, &status, &exitCode, &stdoutPath, &stderrPath, &startedAtStr, &stoppedAtStr); err != nil {
+		if err := rows.Scan(&id, &jobID, &pid, &status, &exitCode, &stdoutPath, &stderrPath, &startedAtStr, &stoppedAtStr, &logBytes, &durationMs, &note, &envSource, &label, &archivedAt, &loadAvg, &onBattery, &dir, &logsMissing, &startedBy, &startedVia,
+			&stdoutBytes, &stdoutLines, &stderrBytes, &stderrLines); err != nil {
 			return nil, err
 		}
 
@@ -299,13 +588,21 @@ func (s *Store) LoadRuns() ([]*Run, error) {
 		}
 
 		run := &Run{
-			ID:         id,
-			JobID:      jobID,
-			PID:        pid,
-			Status:     status,
-			StdoutPath: stdoutPath,
-			StderrPath: stderrPath,
-			StartedAt:  startedAt,
+			ID:          id,
+			JobID:       jobID,
+			PID:         pid,
+			Status:      status,
+			StdoutPath:  stdoutPath,
+			StderrPath:  stderrPath,
+			StartedAt:   startedAt,
+			Note:        note,
+			EnvSource:   envSource,
+			Label:       label,
+			StartedBy:   startedBy.String,  // Empty if NULL
+			StartedVia:  startedVia.String, // Empty if NULL
+			Markers:     markers[id],
+			Dir:         dir.String, // Empty if NULL
+			LogsMissing: logsMissing,
 		}
 
 		if exitCode.Valid {
@@ -321,12 +618,132 @@ func (s *Store) LoadRuns() ([]*Run, error) {
 			run.StoppedAt = &stoppedAt
 		}
 
+		if logBytes.Valid {
+			run.LogBytes = &logBytes.Int64
+		}
+		if durationMs.Valid {
+			run.DurationMs = &durationMs.Int64
+		}
+		if stdoutBytes.Valid {
+			run.Output = &OutputStats{
+				StdoutBytes: stdoutBytes.Int64,
+				StdoutLines: stdoutLines.Int64,
+				StderrBytes: stderrBytes.Int64,
+				StderrLines: stderrLines.Int64,
+			}
+		}
+		if loadAvg.Valid {
+			run.LoadAvg = &loadAvg.Float64
+		}
+		if onBattery.Valid {
+			run.OnBattery = &onBattery.Bool
+		}
+		if archivedAt.Valid {
+			t, err := time.Parse(time.RFC3339, archivedAt.String)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse archived_at: %w", err)
+			}
+			run.ArchivedAt = &t
+		}
+
 		runs = append(runs, run)
 	}
 
 	return runs, rows.Err()
 }
 
+// RunFilter selects a page of runs to export
+type RunFilter struct {
+	JobID   string    // only runs of this job (all jobs if empty)
+	Workdir string    // only runs of jobs in this directory (all if empty)
+	Since   time.Time // only runs started at or after this time (all if zero)
+	After   int64     // only runs after this cursor, from the previous page
+	Limit   int       // at most this many runs
+}
+
+// ListRuns returns a page of the runs matching the filter with their job,
+// oldest first, and the cursor of the next page (0 on the last page). Runs
+// are read from the database page by page, so exports of long histories do
+// not hold every run in memory.
+func (s *Store) ListRuns(f RunFilter) ([]HistoryEntry, int64, error) {
+	query := `
+		SELECT r.rowid, r.id, r.job_id, r.pid, r.status, r.exit_code, r.stdout_path, r.stderr_path, r.started_at, r.stopped_at,
+			r.log_bytes, r.duration_ms, r.note, r.load_avg, r.on_battery, r.dir, r.logs_missing, j.command_json, j.workdir
+		FROM runs r JOIN jobs j ON j.id = r.job_id
+		WHERE r.rowid > ?`
+	args := []interface{}{f.After}
+	if f.JobID != "" {
+		query += " AND r.job_id = ?"
+		args = append(args, f.JobID)
+	}
+	if f.Workdir != "" {
+		query += " AND j.workdir = ?"
+		args = append(args, f.Workdir)
+	}
+	if !f.Since.IsZero() {
+		query += " AND julianday(r.started_at) >= julianday(?)"
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY r.rowid LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		entries []HistoryEntry
+		last    int64
+	)
+	for rows.Next() {
+		var (
+			e           HistoryEntry
+			exitCode    sql.NullInt64
+			stoppedAt   sql.NullString
+			logBytes    sql.NullInt64
+			durationMs  sql.NullInt64
+			loadAvg     sql.NullFloat64
+			onBattery   sql.NullBool
+			dir         sql.NullString
+			commandJSON string
+		)
+		if err := rows.Scan(&last, &e.ID, &e.JobID, &e.PID, &e.Status, &exitCode, &e.StdoutPath, &e.StderrPath, &e.StartedAt, &stoppedAt,
+			&logBytes, &durationMs, &e.Note, &loadAvg, &onBattery, &dir, &e.LogsMissing, &commandJSON, &e.Workdir); err != nil {
+			return nil, 0, err
+		}
+		if err := json.Unmarshal([]byte(commandJSON), &e.Command); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse command: %w", err)
+		}
+		if exitCode.Valid {
+			code := int(exitCode.Int64)
+			e.ExitCode = &code
+		}
+		e.StoppedAt = stoppedAt.String
+		if logBytes.Valid {
+			e.LogBytes = &logBytes.Int64
+		}
+		e.DurationMs = durationMs.Int64
+		if loadAvg.Valid {
+			e.LoadAvg = &loadAvg.Float64
+		}
+		if onBattery.Valid {
+			e.OnBattery = &onBattery.Bool
+		}
+		e.Dir = dir.String
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(entries) < f.Limit {
+		last = 0
+	}
+	return entries, last, nil
+}
+
 // OrphanRun represents a run that may need cleanup after a crash
 type OrphanRun struct {
 	Run     *Run
@@ -409,6 +826,150 @@ func (s *Store) MarkRunStopped(runID string) error {
 	return err
 }
 
+// EventFilter selects recorded events
+type EventFilter struct {
+	Workdir string    // only events of jobs in this directory (all if empty)
+	Since   time.Time // only events recorded at or after this time (all if zero)
+	AfterID uint64    // only events with a greater sequence number
+}
+
+// InsertEvent records an event under its sequence number
+func (s *Store) InsertEvent(event Event) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO events (id, type, job_id, workdir, created_at, event_json)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, event.Seq, event.Type, event.JobID, event.Job.Workdir, event.Time, string(eventJSON))
+	return err
+}
+
+// ListEvents returns the recorded events matching the filter, oldest first
+func (s *Store) ListEvents(f EventFilter) ([]Event, error) {
+	query := "SELECT event_json FROM events WHERE id > ?"
+	args := []interface{}{f.AfterID}
+	if f.Workdir != "" {
+		query += " AND workdir = ?"
+		args = append(args, f.Workdir)
+	}
+	if !f.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var eventJSON string
+		if err := rows.Scan(&eventJSON); err != nil {
+			return nil, err
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(eventJSON), &event); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// LastEventID returns the sequence number of the last recorded event, 0 if
+// there is none
+func (s *Store) LastEventID() (uint64, error) {
+	var id sql.NullInt64
+	if err := s.db.QueryRow("SELECT MAX(id) FROM events").Scan(&id); err != nil {
+		return 0, err
+	}
+	return uint64(id.Int64), nil
+}
+
+// DeleteEventsBefore removes the events recorded before the given time
+func (s *Store) DeleteEventsBefore(t time.Time) error {
+	_, err := s.db.Exec("DELETE FROM events WHERE created_at < ?", t.UTC().Format(time.RFC3339))
+	return err
+}
+
+// InsertAuditEntry records a state-changing request
+func (s *Store) InsertAuditEntry(e AuditEntry) error {
+	success := 0
+	if e.Success {
+		success = 1
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO audit_log (created_at, client, type, target, success, error, approval, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Time, e.Client, string(e.Type), e.Target, success, nullableString(e.Error), nullableString(e.Approval), e.DurationMs)
+	return err
+}
+
+// ListAuditEntries returns the audit log entries matching the filter,
+// oldest first
+func (s *Store) ListAuditEntries(f AuditFilter) ([]AuditEntry, error) {
+	query := "SELECT id, created_at, client, type, target, success, error, approval, duration_ms FROM audit_log WHERE 1 = 1"
+	var args []interface{}
+	if !f.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, f.Since.UTC().Format(time.RFC3339))
+	}
+	if f.Client != "" {
+		query += " AND client = ?"
+		args = append(args, f.Client)
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var (
+			e         AuditEntry
+			entryType string
+			success   int
+			errorMsg  sql.NullString
+			approval  sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &e.Time, &e.Client, &entryType, &e.Target, &success, &errorMsg, &approval, &e.DurationMs); err != nil {
+			return nil, err
+		}
+		e.Type = RequestType(entryType)
+		e.Success = success != 0
+		e.Error = errorMsg.String
+		e.Approval = approval.String
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest were selected first for the limit
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// DeleteAuditEntriesBefore removes the audit log entries recorded before
+// the given time
+func (s *Store) DeleteAuditEntriesBefore(t time.Time) error {
+	_, err := s.db.Exec("DELETE FROM audit_log WHERE created_at < ?", t.UTC().Format(time.RFC3339))
+	return err
+}
+
 // Close closes the database connection
 func (s *Store) Close() error {
 	return s.db.Close()
@@ -443,12 +1004,6 @@ func getProcessInfo(pid int) (*ProcessInfo, error) {
 	return &ProcessInfo{StartTime: startTime, Command: cmdline}, nil
 }
 
-// processExists checks if a process with the given PID exists
-func processExists(pid int) bool {
-	// Signal 0 checks if process exists without sending signal
-	return syscall.Kill(pid, 0) == nil
-}
-
 // isOurProcess verifies that a PID belongs to a process we started.
 // This prevents killing unrelated processes if PIDs were reused.
 func isOurProcess(pid int, expectedStartTime time.Time, expectedCmd []string) bool {
@@ -490,6 +1045,23 @@ func nullableInt64(v int64) interface{} {
 	return v
 }
 
+// priorityOrNormal returns the priority to store, defaulting to normal
+func priorityOrNormal(p Priority) string {
+	if p == "" {
+		return string(PriorityNormal)
+	}
+	return string(p)
+}
+
+// nullableStrings returns nil for empty lists, otherwise the list as JSON
+func nullableStrings(v []string) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
 // nullableString returns nil for empty strings, otherwise the string
 func nullableString(v string) interface{} {
 	if v == "" {
@@ -0,0 +1,39 @@
+package daemon
+
+import "fmt"
+
+// SetDescription replaces the description of a job. An empty description
+// removes the current one.
+func (jm *JobManager) SetDescription(jobID string, description string) (*Job, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jm.resolveJobIDLocked(jobID)]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if job.Description == description {
+		return job, nil
+	}
+
+	previous := job.Description
+	job.Description = description
+
+	if jm.store != nil {
+		if err := jm.store.UpdateJob(job); err != nil {
+			job.Description = previous
+			return nil, fmt.Errorf("failed to persist description: %w", err)
+		}
+	}
+
+	jm.emitEvent(Event{
+		Type:            EventTypeJobUpdated,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return job, nil
+}
@@ -0,0 +1,48 @@
+package daemon
+
+import "testing"
+
+func TestJobManager_SetDescription(t *testing.T) {
+	tmpDir := t.TempDir()
+	var events []Event
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, NewFakeProcessExecutor(), nil)
+
+	job, _, err := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if _, err := jm.SetAlias(job.ID, "web"); err != nil {
+		t.Fatalf("SetAlias failed: %v", err)
+	}
+	events = nil
+
+	// Through the alias
+	if _, err := jm.SetDescription("web", "Dev server"); err != nil {
+		t.Fatalf("SetDescription failed: %v", err)
+	}
+	if resp := jm.jobToResponse(job); resp.Description != "Dev server" {
+		t.Errorf("expected description in response, got %q", resp.Description)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeJobUpdated || events[0].Job.Description != "Dev server" {
+		t.Errorf("expected one job_updated event with the description, got %v", events)
+	}
+
+	// Same description: no event
+	if _, err := jm.SetDescription(job.ID, "Dev server"); err != nil {
+		t.Fatalf("SetDescription failed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected no event for an unchanged description, got %d", len(events))
+	}
+
+	if _, err := jm.SetDescription(job.ID, ""); err != nil {
+		t.Fatalf("SetDescription failed: %v", err)
+	}
+	if job.Description != "" {
+		t.Errorf("expected description to be removed, got %q", job.Description)
+	}
+
+	if _, err := jm.SetDescription("unknown", "x"); err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import "fmt"
+
+// Development environments runs can start in, set up from a project file
+// instead of coming from the environment of the client that started them
+const (
+	DevEnvNix    = "nix"    // nix develop with the flake.nix of the project
+	DevEnvDirenv = "direnv" // direnv exec with the .envrc of the project
+)
+
+// devEnvFiles are the project files defining each development environment
+var devEnvFiles = map[string]string{
+	DevEnvNix:    "flake.nix",
+	DevEnvDirenv: ".envrc",
+}
+
+// ValidateDevEnv checks that a development environment is known
+func ValidateDevEnv(devEnv string) error {
+	if _, ok := devEnvFiles[devEnv]; ok {
+		return nil
+	}
+	return fmt.Errorf("invalid dev environment %q (expected nix or direnv)", devEnv)
+}
+
+// devEnvExecutor starts commands in the development environment of the
+// project, through a base executor. The environment is loaded on each
+// start, so restarts from the TUI or the daemon get the same one as the
+// shell the job was added from, and pick up changes to the project file.
+type devEnvExecutor struct {
+	base   ProcessExecutor
+	devEnv string
+}
+
+// Start starts the command in the development environment of the project
+// containing workdir
+func (e *devEnvExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	args, err := devEnvCommand(e.devEnv, command, workdir)
+	if err != nil {
+		return nil, err
+	}
+	return e.base.Start(args, workdir, env, stdoutPath, stderrPath, opts)
+}
+
+// devEnvCommand returns the command running command in a development
+// environment, with the project file found in workdir or its parents. Both
+// nix develop and direnv exec replace themselves with the command, so the
+// run's process is the command's.
+func devEnvCommand(devEnv string, command []string, workdir string) ([]string, error) {
+	file, ok := devEnvFiles[devEnv]
+	if !ok {
+		return nil, ValidateDevEnv(devEnv)
+	}
+	dir := findProjectDir(workdir, file)
+	if dir == "" {
+		return nil, fmt.Errorf("no %s found in %s or its parents (needed by dev environment %s)", file, workdir, devEnv)
+	}
+
+	if devEnv == DevEnvNix {
+		return append([]string{"nix", "develop", dir, "--command"}, command...), nil
+	}
+	return append([]string{"direnv", "exec", dir}, command...), nil
+}
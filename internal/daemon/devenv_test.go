@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDevEnvCommand(t *testing.T) {
+	project := t.TempDir()
+	workdir := filepath.Join(project, "web")
+	os.MkdirAll(workdir, 0755)
+	os.WriteFile(filepath.Join(project, "flake.nix"), []byte("{}"), 0644)
+
+	got, err := devEnvCommand(DevEnvNix, []string{"npm", "test"}, workdir)
+	if err != nil {
+		t.Fatalf("devEnvCommand failed: %v", err)
+	}
+	if want := []string{"nix", "develop", project, "--command", "npm", "test"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// The project has no .envrc
+	if _, err := devEnvCommand(DevEnvDirenv, []string{"npm", "test"}, workdir); err == nil || !strings.Contains(err.Error(), "no .envrc found") {
+		t.Errorf("expected an error for the missing .envrc, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(workdir, ".envrc"), []byte("use flake"), 0644)
+	got, _ = devEnvCommand(DevEnvDirenv, []string{"npm", "test"}, workdir)
+	if want := []string{"direnv", "exec", workdir, "npm", "test"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestJobManager_DevEnv(t *testing.T) {
+	workdir := t.TempDir()
+	os.WriteFile(filepath.Join(workdir, "flake.nix"), []byte("{}"), 0644)
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"npm", "run", "dev"}, workdir, JobOptions{DevEnv: DevEnvNix}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if command := executor.LastCommand(); !slices.Equal(command, []string{"nix", "develop", workdir, "--command", "npm", "run", "dev"}) {
+		t.Errorf("expected the command to run with nix develop, got %v", command)
+	}
+	if resp := jm.jobToResponse(job); resp.DevEnv != DevEnvNix {
+		t.Errorf("expected dev_env nix in the response, got %q", resp.DevEnv)
+	}
+
+	// Restarts load the environment again
+	stopAndWait(t, jm, executor, job.ID)
+	if err := jm.StartJob(job.ID, nil, EnvSourceClient, Origin{}); err != nil {
+		t.Fatalf("StartJob failed: %v", err)
+	}
+	if command := executor.LastCommand(); command[0] != "nix" {
+		t.Errorf("expected the restarted command to run with nix develop, got %v", command)
+	}
+}
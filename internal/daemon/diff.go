@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"slices"
+	"strings"
+)
+
+// DiffLine is a line of a diff: kept (' '), removed ('-') or added ('+')
+type DiffLine struct {
+	Kind byte
+	Text string
+}
+
+// DiffHunk is a group of changes with the lines around them, as in a
+// unified diff. Starts are 1-based; an empty side starts at the line before.
+type DiffHunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []DiffLine
+}
+
+// SplitLines splits output into lines, a last line without a trailing
+// newline included
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// DiffOutput compares the lines of two outputs and returns the hunks of
+// changes turning a into b, with up to context kept lines around each.
+// Nil if they are the same.
+func DiffOutput(a, b []string, context int) []DiffHunk {
+	edits := diffLines(a, b)
+
+	// Lines of a and b before each edit
+	oldPos := make([]int, len(edits)+1)
+	newPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if e.Kind != '+' {
+			oldPos[i+1]++
+		}
+		if e.Kind != '-' {
+			newPos[i+1]++
+		}
+	}
+
+	var hunks []DiffHunk
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == ' ' {
+			i++
+			continue
+		}
+
+		// Changes closer than twice the context share a hunk
+		end := i + 1
+		for j := i; j < len(edits); j++ {
+			if edits[j].Kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+
+		start, stop := max(0, i-context), min(len(edits), end+context)
+		h := DiffHunk{
+			OldStart: oldPos[start],
+			OldLines: oldPos[stop] - oldPos[start],
+			NewStart: newPos[start],
+			NewLines: newPos[stop] - newPos[start],
+			Lines:    edits[start:stop],
+		}
+		if h.OldLines > 0 {
+			h.OldStart++
+		}
+		if h.NewLines > 0 {
+			h.NewStart++
+		}
+		hunks = append(hunks, h)
+		i = stop
+	}
+	return hunks
+}
+
+// maxDiffEdits bounds the work on outputs with nothing in common: past it,
+// the lines between the common start and end are shown removed and added
+const maxDiffEdits = 4000
+
+// diffLines returns an edit script turning a into b
+func diffLines(a, b []string) []DiffLine {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []DiffLine
+	for _, line := range a[:prefix] {
+		edits = append(edits, DiffLine{Kind: ' ', Text: line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if middle := myersDiff(midA, midB); middle != nil {
+		edits = append(edits, middle...)
+	} else {
+		for _, line := range midA {
+			edits = append(edits, DiffLine{Kind: '-', Text: line})
+		}
+		for _, line := range midB {
+			edits = append(edits, DiffLine{Kind: '+', Text: line})
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, DiffLine{Kind: ' ', Text: line})
+	}
+	return edits
+}
+
+// myersDiff returns a shortest edit script turning a into b, with Myers'
+// algorithm, or nil if it takes more than maxDiffEdits edits. Only the
+// diagonals reached at each step are kept, so similar outputs are cheap
+// however long they are.
+func myersDiff(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+
+	// trace[d] holds the furthest x on diagonals -d..d before step d
+	var trace [][]int
+	for d := 0; d <= min(n+m, maxDiffEdits); d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insertion
+			} else {
+				x = v[offset+k-1] + 1 // right: deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrackDiff walks the trace of myersDiff back from the end of both
+// outputs
+func backtrackDiff(a, b []string, trace [][]int) []DiffLine {
+	x, y := len(a), len(b)
+	var edits []DiffLine
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, DiffLine{Kind: ' ', Text: a[x-1]})
+			x, y = x-1, y-1
+		}
+		if x == prevX {
+			edits = append(edits, DiffLine{Kind: '+', Text: b[y-1]})
+			y--
+		} else {
+			edits = append(edits, DiffLine{Kind: '-', Text: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, DiffLine{Kind: ' ', Text: a[x-1]})
+		x, y = x-1, y-1
+	}
+	slices.Reverse(edits)
+	return edits
+}
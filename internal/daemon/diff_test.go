@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// applyDiff rebuilds both sides of an edit script
+func applyDiff(edits []DiffLine) (old, new []string) {
+	for _, e := range edits {
+		if e.Kind != '+' {
+			old = append(old, e.Text)
+		}
+		if e.Kind != '-' {
+			new = append(new, e.Text)
+		}
+	}
+	return old, new
+}
+
+func TestSplitLines(t *testing.T) {
+	if lines := SplitLines(""); lines != nil {
+		t.Errorf("expected no lines, got %q", lines)
+	}
+	if lines := SplitLines("a\nb\n"); len(lines) != 2 {
+		t.Errorf("expected 2 lines, got %q", lines)
+	}
+	if lines := SplitLines("a\nb"); len(lines) != 2 || lines[1] != "b" {
+		t.Errorf("expected the last line without a newline, got %q", lines)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", ""},
+		{"", "a b"},
+		{"a b", ""},
+		{"a b c", "a b c"},
+		{"a b c a b b a", "c b a b a c"},
+		{"ok ok FAIL ok", "ok ok ok"},
+		{"x", "y"},
+	}
+	for _, c := range cases {
+		a, b := strings.Fields(c.a), strings.Fields(c.b)
+		edits := diffLines(a, b)
+		old, new := applyDiff(edits)
+		if strings.Join(old, " ") != c.a || strings.Join(new, " ") != c.b {
+			t.Errorf("%q -> %q: edits %v do not rebuild both sides", c.a, c.b, edits)
+		}
+	}
+
+	// Shortest: a b c a b b a -> c b a b a c takes 5 edits
+	var changes int
+	for _, e := range diffLines(strings.Fields("a b c a b b a"), strings.Fields("c b a b a c")) {
+		if e.Kind != ' ' {
+			changes++
+		}
+	}
+	if changes != 5 {
+		t.Errorf("expected 5 edits, got %d", changes)
+	}
+}
+
+func TestDiffLines_NothingInCommon(t *testing.T) {
+	var a, b []string
+	for i := range maxDiffEdits {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append([]string{"start"}, append(a, "end")...)
+	b = append([]string{"start"}, append(b, "end")...)
+
+	edits := diffLines(a, b)
+	old, new := applyDiff(edits)
+	if len(old) != len(a) || len(new) != len(b) {
+		t.Fatalf("expected both sides rebuilt, got %d and %d lines", len(old), len(new))
+	}
+	if edits[0].Kind != ' ' || edits[1].Kind != '-' || edits[len(edits)-2].Kind != '+' {
+		t.Error("expected the lines between the common start and end removed then added")
+	}
+}
+
+func TestDiffOutput(t *testing.T) {
+	var a []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+	}
+	b := append([]string(nil), a...)
+	b[1] = "changed 2"
+	b[17] = "changed 18"
+	b = append(b, "line 21")
+
+	if hunks := DiffOutput(a, a, 3); hunks != nil {
+		t.Errorf("expected no hunks for the same output, got %v", hunks)
+	}
+
+	hunks := DiffOutput(a, b, 3)
+	if len(hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(hunks))
+	}
+	h := hunks[0]
+	if h.OldStart != 1 || h.OldLines != 5 || h.NewStart != 1 || h.NewLines != 5 {
+		t.Errorf("unexpected first hunk @@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+	h = hunks[1]
+	if h.OldStart != 15 || h.OldLines != 6 || h.NewStart != 15 || h.NewLines != 7 {
+		t.Errorf("unexpected second hunk @@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	}
+
+	// More context joins them
+	if hunks := DiffOutput(a, b, 8); len(hunks) != 1 {
+		t.Errorf("expected 1 hunk with more context, got %d", len(hunks))
+	}
+
+	// An empty side starts at the line before
+	hunks = DiffOutput(nil, []string{"new"}, 3)
+	if len(hunks) != 1 || hunks[0].OldStart != 0 || hunks[0].OldLines != 0 || hunks[0].NewStart != 1 {
+		t.Errorf("unexpected hunk for an empty output: %+v", hunks)
+	}
+}
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// JobDiskUsage is the size of the stored logs of a job
+type JobDiskUsage struct {
+	JobID   string   `json:"job_id"`
+	Command []string `json:"command"`
+	Workdir string   `json:"workdir"`
+	LogDir  string   `json:"log_dir,omitempty"` // empty for the daemon's log directory
+	Runs    int      `json:"runs"`
+	Bytes   int64    `json:"bytes"`
+}
+
+// runLogPaths returns the log file paths of a new run of a job, in the job's
+// log directory if it has one (created if needed), or else the daemon's
+func (jm *JobManager) runLogPaths(job *Job, runID string) (stdoutPath, stderrPath string, err error) {
+	dir := jm.runtimeDir
+	if job.LogDir != "" {
+		dir = job.LogDir
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return "", "", fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+	return filepath.Join(dir, runID+".stdout.log"), filepath.Join(dir, runID+".stderr.log"), nil
+}
+
+// runLogBytes returns the size of the log files of a run, compressed or
+// not, and their timestamp indexes. Missing files count as empty.
+func runLogBytes(run *Run) int64 {
+	var total int64
+	for _, file := range runLogFiles(run) {
+		if info, err := logfile.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// logBytesLocked returns the size of the logs of a run. Stopped runs keep
+// the size recorded when they stopped; runs stopped before sizes were
+// recorded are measured once and recorded. Running runs are measured.
+// Caller must hold the write lock.
+func (jm *JobManager) logBytesLocked(run *Run) int64 {
+	if run.LogBytes != nil {
+		return *run.LogBytes
+	}
+	size := runLogBytes(run)
+	if run.Status == "running" {
+		return size
+	}
+	run.LogBytes = &size
+	if jm.store != nil {
+		if err := jm.store.UpdateRun(run); err != nil {
+			Logger.Warn("failed to update run", "id", run.ID, "error", err)
+		}
+	}
+	return size
+}
+
+// jobLogUsage is the log files of a job and their size
+type jobLogUsage struct {
+	files []string
+	bytes int64
+}
+
+// jobLogUsage returns the log files of every run of a job, archived ones
+// too, as removing the job deletes them
+func (jm *JobManager) jobLogUsage(jobID string) jobLogUsage {
+	jm.mu.RLock()
+	runs := slices.Clone(jm.jobRunsLocked(jobID))
+	jm.mu.RUnlock()
+
+	if jm.store != nil {
+		archived, err := jm.store.LoadArchivedRuns(jobID)
+		if err != nil {
+			Logger.Warn("failed to load archived runs", "id", jobID, "error", err)
+		}
+		runs = append(runs, archived...)
+	}
+
+	var usage jobLogUsage
+	for _, run := range runs {
+		for _, file := range runLogFiles(run) {
+			// The same files removeRunLogs removes
+			for _, path := range []string{file, file + logfile.CompressedSuffix, file + archiveSuffix} {
+				if info, err := os.Stat(path); err == nil {
+					usage.files = append(usage.files, path)
+					usage.bytes += info.Size()
+				}
+			}
+		}
+	}
+	return usage
+}
+
+// DiskUsage returns the size of the stored logs of each job in workdir (all
+// directories if empty), largest first, and their total
+func (jm *JobManager) DiskUsage(workdir string) ([]JobDiskUsage, int64) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	usage := make(map[string]*JobDiskUsage)
+	for _, job := range jm.jobs {
+		if workdir != "" && job.Workdir != workdir {
+			continue
+		}
+		usage[job.ID] = &JobDiskUsage{JobID: job.ID, Command: job.Command, Workdir: job.Workdir, LogDir: job.LogDir}
+	}
+
+	var total int64
+	for _, run := range jm.runs {
+		u, ok := usage[run.JobID]
+		if !ok {
+			continue
+		}
+		size := jm.logBytesLocked(run)
+		u.Runs++
+		u.Bytes += size
+		total += size
+	}
+
+	jobs := make([]JobDiskUsage, 0, len(usage))
+	for _, u := range usage {
+		jobs = append(jobs, *u)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Bytes != jobs[j].Bytes {
+			return jobs[i].Bytes > jobs[j].Bytes
+		}
+		return jobs[i].JobID < jobs[j].JobID
+	})
+	return jobs, total
+}
+
+// PruneLogs removes the stopped runs of each job in workdir (all
+// directories if empty) except the keep most recent ones, with their log
+// files. Pinned jobs are skipped unless force is set. Returns the number of
+// runs removed and the bytes freed.
+func (jm *JobManager) PruneLogs(workdir string, keep int, force bool) (int, int64, error) {
+	if keep < 0 {
+		return 0, 0, fmt.Errorf("keep must not be negative")
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	stopped := make(map[string][]*Run)
+	for _, run := range jm.runs {
+		job, ok := jm.jobs[run.JobID]
+		if !ok || (workdir != "" && job.Workdir != workdir) || run.Status == "running" || (job.Pinned && !force) {
+			continue
+		}
+		stopped[run.JobID] = append(stopped[run.JobID], run)
+	}
+
+	removed := 0
+	var freed int64
+	for _, runs := range stopped {
+		// Newest first
+		sort.Slice(runs, func(i, j int) bool {
+			return runs[i].StartedAt.After(runs[j].StartedAt)
+		})
+		for _, run := range runs[min(keep, len(runs)):] {
+			freed += jm.logBytesLocked(run)
+			jm.removeRunLocked(run)
+			removed++
+		}
+	}
+	return removed, freed, nil
+}
+
+// handleDiskUsage handles a disk_usage request
+func (d *Daemon) handleDiskUsage(req *Request) *Response {
+	var p DiskUsagePayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	jobs, total := d.jobManager.DiskUsage(p.Workdir)
+	return NewDataResponse(DiskUsageData{Jobs: jobs, TotalBytes: total})
+}
+
+// handlePruneLogs handles a prune_logs request
+func (d *Daemon) handlePruneLogs(req *Request) *Response {
+	var p PruneLogsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	removed, freed, err := d.jobManager.PruneLogs(p.Workdir, p.Keep, p.Force)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	return NewDataResponse(PruneLogsData{RunsRemoved: removed, BytesFreed: freed})
+}
+
+// FormatBytes formats a size in bytes with binary units (1 KB = 1024 bytes)
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n)
+	for _, suffix := range []string{"KB", "MB", "GB", "TB"} {
+		size /= unit
+		if size < unit || suffix == "TB" {
+			return fmt.Sprintf("%.1f %s", size, suffix)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stopAndWait stops the last fake process and waits until its run stopped
+func stopAndWait(t *testing.T, jm *JobManager, executor *FakeProcessExecutor, jobID string) {
+	t.Helper()
+	stopHandleAndWait(t, jm, executor.LastHandle(), jobID)
+}
+
+// stopHandleAndWait stops a run through its handle and waits for its job to
+// show as stopped
+func stopHandleAndWait(t *testing.T, jm *JobManager, handle *FakeProcessHandle, jobID string) {
+	t.Helper()
+	handle.Stop()
+	deadline := time.Now().Add(time.Second)
+	for jm.GetCurrentRun(jobID) != nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if jm.GetCurrentRun(jobID) != nil {
+		t.Fatalf("run of job %s did not stop", jobID)
+	}
+}
+
+func TestJobManager_LogDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	logDir := filepath.Join(tmpDir, "project", "logs")
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make"}, "/workdir", JobOptions{LogDir: logDir}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	run := jm.GetCurrentRun(job.ID)
+	if filepath.Dir(run.StdoutPath) != logDir || filepath.Dir(run.StderrPath) != logDir {
+		t.Errorf("expected logs in %s, got %s and %s", logDir, run.StdoutPath, run.StderrPath)
+	}
+	if info, err := os.Stat(logDir); err != nil || !info.IsDir() {
+		t.Errorf("expected the log directory to be created: %v", err)
+	}
+	if resp := jm.jobToResponse(job); resp.LogDir != logDir {
+		t.Errorf("expected log_dir %s in the response, got %q", logDir, resp.LogDir)
+	}
+
+	// Jobs without a log directory keep using the daemon's
+	other, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	if dir := filepath.Dir(jm.GetCurrentRun(other.ID).StdoutPath); dir != tmpDir {
+		t.Errorf("expected logs in %s, got %s", tmpDir, dir)
+	}
+}
+
+func TestJobManager_DiskUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, store)
+
+	stopped, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+	run := jm.GetCurrentRun(stopped.ID)
+	os.WriteFile(run.StdoutPath, make([]byte, 100), 0644)
+	os.WriteFile(run.StderrPath, make([]byte, 20), 0644)
+	stopAndWait(t, jm, executor, stopped.ID)
+
+	// The size is recorded when the run stops
+	jm.mu.RLock()
+	logBytes := run.LogBytes
+	jm.mu.RUnlock()
+	if logBytes == nil || *logBytes != 120 {
+		t.Fatalf("expected 120 log bytes recorded, got %v", logBytes)
+	}
+
+	running, _, _ := jm.AddJob([]string{"make", "watch"}, "/workdir", JobOptions{}, nil)
+	os.WriteFile(jm.GetCurrentRun(running.ID).StdoutPath, make([]byte, 300), 0644)
+
+	jm.AddJob([]string{"make", "other"}, "/other", JobOptions{}, nil)
+
+	jobs, total := jm.DiskUsage("/workdir")
+	if len(jobs) != 2 || total != 420 {
+		t.Fatalf("expected 2 jobs using 420 bytes, got %+v (total %d)", jobs, total)
+	}
+	if jobs[0].JobID != running.ID || jobs[0].Bytes != 300 || jobs[0].Runs != 1 {
+		t.Errorf("expected the running job first with 300 bytes, got %+v", jobs[0])
+	}
+	if jobs[1].JobID != stopped.ID || jobs[1].Bytes != 120 {
+		t.Errorf("expected the stopped job with 120 bytes, got %+v", jobs[1])
+	}
+
+	// Recorded sizes survive a restart
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loaded := range runs {
+		if loaded.ID == run.ID && (loaded.LogBytes == nil || *loaded.LogBytes != 120) {
+			t.Errorf("expected 120 log bytes stored, got %v", loaded.LogBytes)
+		}
+	}
+
+	if _, total := jm.DiskUsage(""); total != 420 {
+		t.Errorf("expected 420 bytes in all directories, got %d", total)
+	}
+}
+
+func TestJobManager_PruneLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, _ := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, nil)
+	var runs []*Run
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			time.Sleep(5 * time.Millisecond) // distinct start times
+			if err := jm.StartJob(job.ID, nil, EnvSourceClient, Origin{}); err != nil {
+				t.Fatalf("StartJob failed: %v", err)
+			}
+		}
+		run := jm.GetCurrentRun(job.ID)
+		os.WriteFile(run.StdoutPath, make([]byte, 10), 0644)
+		runs = append(runs, run)
+		stopAndWait(t, jm, executor, job.ID)
+	}
+
+	if _, _, err := jm.PruneLogs("/workdir", -1, false); err == nil {
+		t.Error("expected an error for a negative keep")
+	}
+
+	// Other directories are left alone
+	if removed, _, _ := jm.PruneLogs("/other", 0, false); removed != 0 {
+		t.Errorf("expected nothing pruned in /other, got %d", removed)
+	}
+
+	removed, freed, err := jm.PruneLogs("/workdir", 1, false)
+	if err != nil {
+		t.Fatalf("PruneLogs failed: %v", err)
+	}
+	if removed != 2 || freed != 20 {
+		t.Errorf("expected 2 runs and 20 bytes pruned, got %d and %d", removed, freed)
+	}
+
+	left, _ := jm.ListRunsForJob(job.ID)
+	if len(left) != 1 || left[0].ID != runs[2].ID {
+		t.Fatalf("expected only the latest run kept, got %v", left)
+	}
+	if _, err := os.Stat(runs[0].StdoutPath); !os.IsNotExist(err) {
+		t.Error("expected the log of a pruned run to be removed")
+	}
+
+	// Running runs are never pruned
+	jm.StartJob(job.ID, nil, EnvSourceClient, Origin{})
+	removed, _, _ = jm.PruneLogs("/workdir", 0, false)
+	if removed != 1 || jm.GetCurrentRun(job.ID) == nil {
+		t.Errorf("expected only the stopped run pruned, got %d", removed)
+	}
+}
@@ -0,0 +1,207 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Environment filtering.
+//
+// Clients send their whole environment on every add, start and restart, so
+// a shell with large or noisy variables bloats each request. Clients filter
+// the environment before sending it, and the daemon filters what it receives
+// again with the settings it was started with:
+//
+//   - GOB_ENV_ALLOW: comma-separated name patterns (e.g. AWS_*); if set,
+//     only matching variables are captured
+//   - GOB_ENV_DENY: name patterns of variables dropped; defaults to
+//     DefaultEnvDeny, an empty value denies nothing
+//   - GOB_ENV_MAX_SIZE: the total size of the captured variables, e.g. 512K
+//     (DefaultEnvMaxBytes by default, 0 for no limit); the largest variables
+//     are dropped first
+//
+// Denying wins over allowing. PATH and HOME are always kept. 'gob env' shows
+// what the current environment loses.
+
+// DefaultEnvDeny are the variables dropped unless GOB_ENV_DENY is set: shell
+// state jobs don't need
+var DefaultEnvDeny = []string{"BASH_FUNC_*", "LS_COLORS", "OLDPWD", "_"}
+
+// DefaultEnvMaxBytes is the size limit of a captured environment unless
+// GOB_ENV_MAX_SIZE is set
+const DefaultEnvMaxBytes = 256 << 10
+
+// essentialEnv are the variables kept whatever the filter
+var essentialEnv = []string{"PATH", "HOME"}
+
+// EnvFilter selects the variables of a captured environment. The zero value
+// keeps everything.
+type EnvFilter struct {
+	Allow    []string `json:"allow,omitempty"`     // name patterns; if any, only matching variables are kept
+	Deny     []string `json:"deny,omitempty"`      // name patterns of dropped variables
+	MaxBytes int64    `json:"max_bytes,omitempty"` // total size of the kept variables, 0 for no limit
+}
+
+// DroppedEnvVar is a variable an EnvFilter left out
+type DroppedEnvVar struct {
+	Name   string `json:"name"`
+	Bytes  int    `json:"bytes"`
+	Reason string `json:"reason"` // not allowed, denied by <pattern> or over the size limit
+}
+
+// DefaultEnvFilter returns the environment filter used when none is
+// configured
+func DefaultEnvFilter() EnvFilter {
+	return EnvFilter{Deny: DefaultEnvDeny, MaxBytes: DefaultEnvMaxBytes}
+}
+
+// EnvFilterFromEnv returns the environment filter configured in the process
+// environment (see GOB_ENV_ALLOW, GOB_ENV_DENY and GOB_ENV_MAX_SIZE)
+func EnvFilterFromEnv() (EnvFilter, error) {
+	f := DefaultEnvFilter()
+
+	var err error
+	if value, ok := os.LookupEnv("GOB_ENV_ALLOW"); ok {
+		if f.Allow, err = parseEnvPatterns(value); err != nil {
+			return EnvFilter{}, fmt.Errorf("invalid GOB_ENV_ALLOW: %w", err)
+		}
+	}
+	if value, ok := os.LookupEnv("GOB_ENV_DENY"); ok {
+		if f.Deny, err = parseEnvPatterns(value); err != nil {
+			return EnvFilter{}, fmt.Errorf("invalid GOB_ENV_DENY: %w", err)
+		}
+	}
+	if value := strings.TrimSpace(os.Getenv("GOB_ENV_MAX_SIZE")); value == "0" {
+		f.MaxBytes = 0
+	} else if value != "" {
+		size, ok := parseSize(value)
+		if !ok {
+			return EnvFilter{}, fmt.Errorf("invalid GOB_ENV_MAX_SIZE %q (expected e.g. 512K, or 0 for no limit)", value)
+		}
+		f.MaxBytes = size
+	}
+	return f, nil
+}
+
+// parseEnvPatterns parses comma-separated name patterns
+func parseEnvPatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q", pattern)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// matchEnvPattern returns the first pattern matching name, or ""
+func matchEnvPattern(patterns []string, name string) string {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// Apply returns the variables of env the filter keeps, in order, and the
+// ones it drops. A nil env stays nil.
+func (f EnvFilter) Apply(env []string) ([]string, []DroppedEnvVar) {
+	if env == nil {
+		return nil, nil
+	}
+
+	var dropped []DroppedEnvVar
+	kept := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		reason := ""
+		switch {
+		case slices.Contains(essentialEnv, name):
+		case matchEnvPattern(f.Deny, name) != "":
+			reason = "denied by " + matchEnvPattern(f.Deny, name)
+		case len(f.Allow) > 0 && matchEnvPattern(f.Allow, name) == "":
+			reason = "not allowed"
+		}
+		if reason != "" {
+			dropped = append(dropped, DroppedEnvVar{Name: name, Bytes: len(kv), Reason: reason})
+			continue
+		}
+		kept = append(kept, kv)
+	}
+
+	total := EnvBytes(kept)
+	if f.MaxBytes <= 0 || total <= f.MaxBytes {
+		return kept, dropped
+	}
+
+	// Over the limit: drop the largest variables first
+	bySize := make([]string, 0, len(kept))
+	for _, kv := range kept {
+		if name, _, _ := strings.Cut(kv, "="); !slices.Contains(essentialEnv, name) {
+			bySize = append(bySize, kv)
+		}
+	}
+	sort.SliceStable(bySize, func(i, j int) bool { return len(bySize[i]) > len(bySize[j]) })
+	over := make(map[string]bool)
+	for _, kv := range bySize {
+		if total <= f.MaxBytes {
+			break
+		}
+		over[kv] = true
+		total -= int64(len(kv)) + 1
+	}
+
+	result := kept[:0]
+	for _, kv := range kept {
+		if over[kv] {
+			name, _, _ := strings.Cut(kv, "=")
+			dropped = append(dropped, DroppedEnvVar{Name: name, Bytes: len(kv), Reason: "over the size limit"})
+			continue
+		}
+		result = append(result, kv)
+	}
+	return result, dropped
+}
+
+// EnvBytes returns the size of an environment as sent to processes
+func EnvBytes(env []string) int64 {
+	var total int64
+	for _, kv := range env {
+		total += int64(len(kv)) + 1
+	}
+	return total
+}
+
+// filterClientEnv applies the client's environment filter to env
+func filterClientEnv(env []string) ([]string, error) {
+	f, err := EnvFilterFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	env, _ = f.Apply(env)
+	return env, nil
+}
+
+// filterEnvLocked applies the daemon's environment filter to the environment
+// a client sent for a run of job (caller must hold lock)
+func (jm *JobManager) filterEnvLocked(job *Job, env []string) []string {
+	env, dropped := jm.envFilter.Apply(env)
+	if len(dropped) > 0 {
+		names := make([]string, len(dropped))
+		for i, d := range dropped {
+			names[i] = d.Name
+		}
+		Logger.Info("dropped environment variables", "job", job.ID, "variables", strings.Join(names, ","))
+	}
+	return env
+}
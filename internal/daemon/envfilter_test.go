@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnvFilter_Apply(t *testing.T) {
+	env := []string{"PATH=/bin", "HOME=/home/me", "AWS_REGION=eu", "LS_COLORS=di=34", "_=/usr/bin/gob", "EDITOR=vim"}
+
+	kept, dropped := DefaultEnvFilter().Apply(env)
+	if want := []string{"PATH=/bin", "HOME=/home/me", "AWS_REGION=eu", "EDITOR=vim"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("default filter kept %v, want %v", kept, want)
+	}
+	if len(dropped) != 2 || dropped[0].Name != "LS_COLORS" || dropped[0].Reason != "denied by LS_COLORS" || dropped[0].Bytes != len("LS_COLORS=di=34") {
+		t.Errorf("unexpected dropped variables: %+v", dropped)
+	}
+
+	// Allowing keeps only matching variables, and the essential ones;
+	// denying wins
+	f := EnvFilter{Allow: []string{"AWS_*", "EDITOR"}, Deny: []string{"EDITOR"}}
+	kept, dropped = f.Apply(env)
+	if want := []string{"PATH=/bin", "HOME=/home/me", "AWS_REGION=eu"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("allow filter kept %v, want %v", kept, want)
+	}
+	reasons := make(map[string]string)
+	for _, d := range dropped {
+		reasons[d.Name] = d.Reason
+	}
+	if reasons["EDITOR"] != "denied by EDITOR" || reasons["LS_COLORS"] != "not allowed" {
+		t.Errorf("unexpected reasons: %v", reasons)
+	}
+
+	// A nil environment stands for the daemon's and stays nil
+	if kept, _ := f.Apply(nil); kept != nil {
+		t.Errorf("expected nil, got %v", kept)
+	}
+}
+
+func TestEnvFilter_ApplyMaxBytes(t *testing.T) {
+	big := "BIG=" + strings.Repeat("x", 100)
+	env := []string{"PATH=" + strings.Repeat("p", 60), "A=1", big, "MEDIUM=" + strings.Repeat("m", 40)}
+
+	// The largest variables go first, PATH stays whatever its size
+	kept, dropped := EnvFilter{MaxBytes: 120}.Apply(env)
+	if want := []string{env[0], "A=1", env[3]}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %v, want %v", kept, want)
+	}
+	if len(dropped) != 1 || dropped[0].Name != "BIG" || dropped[0].Reason != "over the size limit" {
+		t.Errorf("unexpected dropped variables: %+v", dropped)
+	}
+	if EnvBytes(kept) > 120 {
+		t.Errorf("expected at most 120 bytes, got %d", EnvBytes(kept))
+	}
+
+	if kept, dropped := (EnvFilter{}).Apply(env); len(kept) != len(env) || dropped != nil {
+		t.Errorf("expected no limit by default, kept %v and dropped %v", kept, dropped)
+	}
+}
+
+func TestEnvFilterFromEnv(t *testing.T) {
+	t.Setenv("GOB_ENV_ALLOW", "AWS_*, NODE_*")
+	t.Setenv("GOB_ENV_DENY", "")
+	t.Setenv("GOB_ENV_MAX_SIZE", "64K")
+	f, err := EnvFilterFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := EnvFilter{Allow: []string{"AWS_*", "NODE_*"}, MaxBytes: 64 << 10}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("got %+v, want %+v", f, want)
+	}
+
+	t.Setenv("GOB_ENV_MAX_SIZE", "0")
+	if f, _ := EnvFilterFromEnv(); f.MaxBytes != 0 {
+		t.Errorf("expected no limit, got %d", f.MaxBytes)
+	}
+
+	t.Setenv("GOB_ENV_MAX_SIZE", "lots")
+	if _, err := EnvFilterFromEnv(); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+
+	t.Setenv("GOB_ENV_MAX_SIZE", "")
+	t.Setenv("GOB_ENV_DENY", "[")
+	if _, err := EnvFilterFromEnv(); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestJobManager_FiltersClientEnv(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	jm.envFilter = EnvFilter{Deny: []string{"SECRET_*"}}
+
+	if _, _, err := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, []string{"PATH=/bin", "SECRET_TOKEN=x"}); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	env := executor.LastEnv()
+	if !slices.Contains(env, "PATH=/bin") || envHasName(env, "SECRET_TOKEN") {
+		t.Errorf("expected the denied variable dropped, got %v", env)
+	}
+}
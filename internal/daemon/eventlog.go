@@ -0,0 +1,42 @@
+package daemon
+
+import "time"
+
+// eventBacklogSize is how many recent events the daemon keeps in memory for
+// subscribers that ask to replay the events they missed. Older events are
+// read back from the store.
+const eventBacklogSize = 1000
+
+// eventRetention is how long recorded events are kept
+const eventRetention = 30 * 24 * time.Hour
+
+// eventLog numbers events and keeps the most recent ones
+type eventLog struct {
+	seq    uint64  // sequence number of the last event
+	events []Event // at most eventBacklogSize events, oldest first
+}
+
+// append gives the event the next sequence number, keeps it and returns it
+func (l *eventLog) append(event Event) Event {
+	l.seq++
+	event.Seq = l.seq
+	l.events = append(l.events, event)
+	if len(l.events) > eventBacklogSize {
+		l.events = l.events[1:]
+	}
+	return event
+}
+
+// since returns the kept events with a sequence number greater than seq.
+// ok is false when some of those events are no longer kept in memory, or
+// when seq was never given out.
+func (l *eventLog) since(seq uint64) (events []Event, ok bool) {
+	if seq > l.seq {
+		return nil, false
+	}
+	oldest := l.seq - uint64(len(l.events)) + 1
+	if seq+1 < oldest {
+		return nil, false
+	}
+	return append([]Event(nil), l.events[seq+1-oldest:]...), true
+}
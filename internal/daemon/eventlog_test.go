@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventLog_AppendNumbersEvents(t *testing.T) {
+	var l eventLog
+
+	first := l.append(Event{Type: EventTypeJobAdded})
+	second := l.append(Event{Type: EventTypeJobStarted})
+
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("expected seq 1 and 2, got %d and %d", first.Seq, second.Seq)
+	}
+}
+
+func TestEventLog_Since(t *testing.T) {
+	var l eventLog
+	for i := 0; i < 3; i++ {
+		l.append(Event{Type: EventTypeJobUpdated})
+	}
+
+	events, ok := l.since(1)
+	if !ok || len(events) != 2 || events[0].Seq != 2 || events[1].Seq != 3 {
+		t.Errorf("expected events 2 and 3, got %v (ok=%v)", events, ok)
+	}
+
+	events, ok = l.since(0)
+	if !ok || len(events) != 3 {
+		t.Errorf("expected all 3 events, got %d (ok=%v)", len(events), ok)
+	}
+
+	events, ok = l.since(3)
+	if !ok || len(events) != 0 {
+		t.Errorf("expected no events, got %d (ok=%v)", len(events), ok)
+	}
+
+	// A sequence number the daemon never gave out (previous daemon)
+	if _, ok := l.since(10); ok {
+		t.Error("expected a future seq not to be replayable")
+	}
+}
+
+func TestEventLog_SinceDroppedEvents(t *testing.T) {
+	var l eventLog
+	for i := 0; i < eventBacklogSize+5; i++ {
+		l.append(Event{Type: EventTypeJobUpdated})
+	}
+
+	if len(l.events) != eventBacklogSize {
+		t.Errorf("expected %d kept events, got %d", eventBacklogSize, len(l.events))
+	}
+	if _, ok := l.since(4); ok {
+		t.Error("expected dropped events not to be replayable")
+	}
+	events, ok := l.since(5)
+	if !ok || len(events) != eventBacklogSize || events[0].Seq != 6 {
+		t.Errorf("expected events from 6, got %d events (ok=%v)", len(events), ok)
+	}
+}
+
+func TestStore_Events(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	now := time.Now().UTC().Format(time.RFC3339)
+	store.InsertEvent(Event{Seq: 1, Time: old, Type: EventTypeJobAdded, JobID: "abc", Job: JobResponse{ID: "abc", Workdir: "/a"}})
+	store.InsertEvent(Event{Seq: 2, Time: now, Type: EventTypeJobAdded, JobID: "def", Job: JobResponse{ID: "def", Workdir: "/b"}})
+	store.InsertEvent(Event{Seq: 3, Time: now, Type: EventTypeJobStopped, JobID: "abc", Job: JobResponse{ID: "abc", Workdir: "/a"}})
+
+	if last, _ := store.LastEventID(); last != 3 {
+		t.Errorf("expected last event 3, got %d", last)
+	}
+
+	events, _ := store.ListEvents(EventFilter{Workdir: "/a"})
+	if len(events) != 2 || events[0].Seq != 1 || events[1].Type != EventTypeJobStopped {
+		t.Errorf("expected events 1 and 3 of /a, got %v", events)
+	}
+
+	events, _ = store.ListEvents(EventFilter{Since: time.Now().Add(-time.Hour)})
+	if len(events) != 2 || events[0].Seq != 2 {
+		t.Errorf("expected events 2 and 3, got %v", events)
+	}
+
+	events, _ = store.ListEvents(EventFilter{AfterID: 2})
+	if len(events) != 1 || events[0].Seq != 3 {
+		t.Errorf("expected event 3, got %v", events)
+	}
+
+	store.DeleteEventsBefore(time.Now().Add(-time.Hour))
+	events, _ = store.ListEvents(EventFilter{})
+	if len(events) != 2 {
+		t.Errorf("expected the old event to be deleted, got %d events", len(events))
+	}
+}
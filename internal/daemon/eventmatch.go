@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+)
+
+// eventTypes lists the types of events a subscriber can select. Snapshots
+// are only sent when asked for, so they are not selected by type.
+var eventTypes = []EventType{
+	EventTypeJobAdded,
+	EventTypeJobStarted,
+	EventTypeJobStopped,
+	EventTypeJobRemoved,
+	EventTypeJobUpdated,
+	EventTypeRunStarted,
+	EventTypeRunStopped,
+	EventTypeRunRemoved,
+	EventTypeRunUpdated,
+	EventTypeJobSlow,
+	EventTypePortsUpdated,
+	EventTypeApprovalRequested,
+	EventTypeApprovalDecided,
+}
+
+// EventMatch selects events by type, job and tag, so that clients interested
+// in a few events are not sent all of them. An event matches when it has one
+// of the types, belongs to one of the jobs and its job has one of the tags;
+// an empty list does not filter.
+type EventMatch struct {
+	Types  []EventType `json:"types,omitempty"`
+	JobIDs []string    `json:"job_ids,omitempty"`
+	Tags   []string    `json:"tags,omitempty"`
+}
+
+// Validate checks that the event types are known
+func (m EventMatch) Validate() error {
+	for _, t := range m.Types {
+		if !slices.Contains(eventTypes, t) {
+			return fmt.Errorf("unknown event type: %s", t)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the event is selected
+func (m EventMatch) Matches(event Event) bool {
+	if len(m.Types) > 0 && !slices.Contains(m.Types, event.Type) {
+		return false
+	}
+	if len(m.JobIDs) > 0 && !slices.Contains(m.JobIDs, event.JobID) {
+		return false
+	}
+	if len(m.Tags) > 0 && !slices.ContainsFunc(m.Tags, func(tag string) bool {
+		return slices.Contains(event.Job.Tags, tag)
+	}) {
+		return false
+	}
+	return true
+}
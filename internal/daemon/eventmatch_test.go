@@ -0,0 +1,43 @@
+package daemon
+
+import "testing"
+
+func TestEventMatch_Matches(t *testing.T) {
+	event := Event{Type: EventTypeJobStarted, JobID: "abc", Job: JobResponse{ID: "abc", Tags: []string{"web", "api"}}}
+
+	tests := []struct {
+		name     string
+		match    EventMatch
+		expected bool
+	}{
+		{"empty", EventMatch{}, true},
+		{"type", EventMatch{Types: []EventType{EventTypeJobStopped, EventTypeJobStarted}}, true},
+		{"other type", EventMatch{Types: []EventType{EventTypeJobStopped}}, false},
+		{"job", EventMatch{JobIDs: []string{"abc"}}, true},
+		{"other job", EventMatch{JobIDs: []string{"def"}}, false},
+		{"tag", EventMatch{Tags: []string{"db", "api"}}, true},
+		{"other tag", EventMatch{Tags: []string{"db"}}, false},
+		{"all", EventMatch{Types: []EventType{EventTypeJobStarted}, JobIDs: []string{"abc"}, Tags: []string{"web"}}, true},
+		{"one of all", EventMatch{Types: []EventType{EventTypeJobStarted}, JobIDs: []string{"def"}, Tags: []string{"web"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(event); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEventMatch_Validate(t *testing.T) {
+	if err := (EventMatch{Types: []EventType{EventTypeRunUpdated}}).Validate(); err != nil {
+		t.Errorf("expected a known type to be valid, got %v", err)
+	}
+	if err := (EventMatch{Types: []EventType{"job_exploded"}}).Validate(); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+	if err := (EventMatch{Types: []EventType{EventTypeSnapshot}}).Validate(); err == nil {
+		t.Error("expected an error for snapshots, which are not selected by type")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 )
 
@@ -13,11 +15,26 @@ type ProcessHandle interface {
 	Wait() error
 	Signal(sig syscall.Signal) error
 	IsRunning() bool
+	CgroupPath() string // empty if the process has no dedicated cgroup
+	Detachable() bool   // false if its output or stdin goes through the daemon, so it cannot outlive it
+}
+
+// StartOptions holds optional settings applied when starting a process
+type StartOptions struct {
+	DaemonID   string         // ID of the daemon starting the run
+	JobID      string         // ID of the job of the run
+	RunID      string         // ID of the run being started
+	Priority   Priority       // scheduling priority (nice and I/O priority)
+	Limits     ResourceLimits // memory/CPU limits (runs in its own cgroup when set)
+	Stdin      string         // stdin mode or file path (empty means null)
+	Timestamps bool           // capture output through the daemon to index line times
+	Combine    bool           // write stderr to the stdout log, keeping the order of writes
+	Output     OutputLimit    // capture output through the daemon to keep only its head and tail
 }
 
 // ProcessExecutor handles process creation
 type ProcessExecutor interface {
-	Start(command []string, workdir string, env []string, stdoutPath, stderrPath string) (ProcessHandle, error)
+	Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error)
 }
 
 // RealProcessExecutor implements ProcessExecutor using os/exec
@@ -25,7 +42,12 @@ type RealProcessExecutor struct{}
 
 // realProcessHandle wraps exec.Cmd to implement ProcessHandle
 type realProcessHandle struct {
-	cmd *exec.Cmd
+	cmd      *exec.Cmd
+	runID    string
+	release  func()            // releases the platform's tracking of the run's processes
+	cgroup   *runCgroup        // nil unless resource limits were requested
+	stdin    *os.File          // write end of the stdin pipe, nil unless stdin is kept open
+	captures []<-chan struct{} // output copies to drain on exit, nil unless timestamps or an output limit apply
 }
 
 func (h *realProcessHandle) Pid() int {
@@ -33,21 +55,47 @@ func (h *realProcessHandle) Pid() int {
 }
 
 func (h *realProcessHandle) Wait() error {
-	return h.cmd.Wait()
+	err := h.cmd.Wait()
+	if h.stdin != nil {
+		h.stdin.Close()
+	}
+	waitForCaptures(h.captures, captureFlushTimeout)
+	h.release()
+	if h.cgroup != nil {
+		// Fails if processes outlived the main one; they keep the cgroup alive
+		if rmErr := h.cgroup.remove(); rmErr != nil {
+			Logger.Debug("cgroup not removed", "path", h.cgroup.path, "error", rmErr)
+		}
+	}
+	return err
 }
 
 func (h *realProcessHandle) Signal(sig syscall.Signal) error {
-	// Send to process group (negative PID)
-	return syscall.Kill(-h.cmd.Process.Pid, sig)
+	if h.cgroup != nil {
+		// Also reaches processes that left the process group
+		h.cgroup.signal(sig)
+	}
+	signalRun(h.runID, sig)
+	return signalGroup(h.cmd.Process.Pid, sig)
 }
 
 func (h *realProcessHandle) IsRunning() bool {
-	err := syscall.Kill(h.cmd.Process.Pid, syscall.Signal(0))
-	return err == nil
+	return processExists(h.cmd.Process.Pid)
+}
+
+func (h *realProcessHandle) Detachable() bool {
+	return h.captures == nil && h.stdin == nil
+}
+
+func (h *realProcessHandle) CgroupPath() string {
+	if h.cgroup == nil {
+		return ""
+	}
+	return h.cgroup.path
 }
 
 // Start starts a process with the given command and environment
-func (e *RealProcessExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string) (ProcessHandle, error) {
+func (e *RealProcessExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error) {
 	if len(command) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
@@ -58,48 +106,145 @@ func (e *RealProcessExecutor) Start(command []string, workdir string, env []stri
 	// Use the provided environment (clean, not inherited from daemon)
 	// This ensures the process runs with the client's environment
 	cmd.Env = env
+	if opts.RunID != "" {
+		cmd.Env = withRunEnv(env, opts.DaemonID, opts.JobID, opts.RunID, filepath.Dir(stdoutPath))
+	}
 
 	// Create a new process group so we can signal all children together
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
+	cmd.SysProcAttr = newSysProcAttr()
+
+	// Start the process directly inside its own cgroup when limits are set
+	var cgroup *runCgroup
+	if !opts.Limits.IsZero() {
+		cg, err := createRunCgroup(opts.RunID, opts.Limits)
+		if err != nil {
+			return nil, err
+		}
+		cgroupDir, err := openCgroup(cg, cmd.SysProcAttr)
+		if err != nil {
+			cg.remove()
+			return nil, err
+		}
+		defer cgroupDir.Close()
+		cgroup = cg
 	}
 
 	// Create log files
 	stdoutFile, err := os.OpenFile(stdoutPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
+		removeCgroup(cgroup)
 		return nil, fmt.Errorf("failed to open stdout log file: %w", err)
 	}
 
 	stderrFile, err := os.OpenFile(stderrPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		stdoutFile.Close()
+		removeCgroup(cgroup)
 		return nil, fmt.Errorf("failed to open stderr log file: %w", err)
 	}
 
-	// Redirect stdin to /dev/null
-	devNull, err := os.OpenFile(os.DevNull, os.O_RDONLY, 0)
+	// With timestamps or an output limit, the process writes to pipes and
+	// the daemon copies the output to the log files, indexing when each line
+	// was written or dropping the middle of it
+	var captures []<-chan struct{}
+	if opts.Timestamps || !opts.Output.IsZero() {
+		stdoutPipe, stdoutDone, err := captureOutput(stdoutFile, stdoutPath, opts.Timestamps, opts.Output)
+		if err != nil {
+			stdoutFile.Close()
+			stderrFile.Close()
+			removeCgroup(cgroup)
+			return nil, err
+		}
+		stdoutFile = stdoutPipe
+		captures = append(captures, stdoutDone)
+
+		if !opts.Combine {
+			stderrPipe, stderrDone, err := captureOutput(stderrFile, stderrPath, opts.Timestamps, opts.Output)
+			if err != nil {
+				stdoutPipe.Close()
+				stderrFile.Close()
+				removeCgroup(cgroup)
+				return nil, err
+			}
+			stderrFile = stderrPipe
+			captures = append(captures, stderrDone)
+		}
+	}
+
+	// Redirect stdin to /dev/null, a file, or a pipe the daemon keeps open
+	stdinFile, stdinWriter, err := openStdin(opts.Stdin)
 	if err != nil {
 		stdoutFile.Close()
 		stderrFile.Close()
-		return nil, fmt.Errorf("failed to open /dev/null: %w", err)
+		removeCgroup(cgroup)
+		return nil, err
 	}
 
 	cmd.Stdout = stdoutFile
 	cmd.Stderr = stderrFile
-	cmd.Stdin = devNull
+	if opts.Combine {
+		// Both streams share one file, so writes keep their relative order.
+		// The stderr log is still created, and stays empty.
+		cmd.Stderr = stdoutFile
+	}
+	cmd.Stdin = stdinFile
 
-	// Start the process
-	if err := cmd.Start(); err != nil {
+	// Start the process, with its priority from the start
+	if err := startWithPriority(cmd, opts.Priority); err != nil {
 		stdoutFile.Close()
 		stderrFile.Close()
-		devNull.Close()
+		stdinFile.Close()
+		if stdinWriter != nil {
+			stdinWriter.Close()
+		}
+		removeCgroup(cgroup)
 		return nil, fmt.Errorf("failed to start process: %w", err)
 	}
 
 	// Close file descriptors in daemon (child keeps them)
 	stdoutFile.Close()
 	stderrFile.Close()
-	devNull.Close()
+	stdinFile.Close()
+
+	release, err := attachRun(opts.RunID, cmd.Process.Pid)
+	if err != nil {
+		Logger.Warn("failed to track run processes", "run", opts.RunID, "error", err)
+	}
+
+	return &realProcessHandle{
+		cmd:      cmd,
+		runID:    opts.RunID,
+		release:  release,
+		cgroup:   cgroup,
+		stdin:    stdinWriter,
+		captures: captures,
+	}, nil
+}
 
-	return &realProcessHandle{cmd: cmd}, nil
+// withRunEnv returns a copy of env that marks processes as belonging to the
+// run of a daemon, with the IDs of its job and itself and the directory of
+// its logs. Inherited values (e.g. when gob is called from within a job) are
+// replaced, and an inherited hook event dropped, as the run is no hook.
+func withRunEnv(env []string, daemonID, jobID, runID, logDir string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	result := make([]string, 0, len(env)+4)
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if name != daemonIDEnvVar && name != jobIDEnvVar && name != runIDEnvVar && name != logDirEnvVar && name != hookEventEnvVar {
+			result = append(result, kv)
+		}
+	}
+	if jobID != "" {
+		result = append(result, jobIDEnvVar+"="+jobID)
+	}
+	return append(result, daemonIDEnvVar+"="+daemonID, runIDEnvVar+"="+runID, logDirEnvVar+"="+logDir)
+}
+
+// removeCgroup removes a run cgroup after a failed start (nil-safe)
+func removeCgroup(cg *runCgroup) {
+	if cg != nil {
+		cg.remove()
+	}
 }
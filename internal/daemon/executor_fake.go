@@ -14,6 +14,7 @@ type FakeProcessHandle struct {
 	waitErr   error
 	mu        sync.Mutex
 	signalLog []syscall.Signal
+	attached  bool // output or stdin would go through the daemon
 }
 
 func (h *FakeProcessHandle) Pid() int {
@@ -38,6 +39,14 @@ func (h *FakeProcessHandle) IsRunning() bool {
 	return h.running
 }
 
+func (h *FakeProcessHandle) CgroupPath() string {
+	return ""
+}
+
+func (h *FakeProcessHandle) Detachable() bool {
+	return !h.attached
+}
+
 // Stop simulates the process stopping (unblocks Wait)
 func (h *FakeProcessHandle) Stop() {
 	h.mu.Lock()
@@ -83,6 +92,10 @@ type FakeProcessExecutor struct {
 	handles     []*FakeProcessHandle
 	startErr    error
 	startCalled int
+	lastOpts    StartOptions
+	lastCommand []string
+	lastWorkdir string
+	lastEnv     []string
 }
 
 // NewFakeProcessExecutor creates a new fake executor
@@ -93,11 +106,15 @@ func NewFakeProcessExecutor() *FakeProcessExecutor {
 }
 
 // Start creates a fake process
-func (e *FakeProcessExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string) (ProcessHandle, error) {
+func (e *FakeProcessExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	e.startCalled++
+	e.lastOpts = opts
+	e.lastCommand = command
+	e.lastWorkdir = workdir
+	e.lastEnv = env
 
 	if e.startErr != nil {
 		return nil, e.startErr
@@ -108,9 +125,10 @@ func (e *FakeProcessExecutor) Start(command []string, workdir string, env []stri
 	}
 
 	handle := &FakeProcessHandle{
-		pid:     e.nextPID,
-		running: true,
-		waitCh:  make(chan struct{}),
+		pid:      e.nextPID,
+		running:  true,
+		waitCh:   make(chan struct{}),
+		attached: opts.Timestamps || !opts.Output.IsZero() || opts.Stdin == StdinOpen,
 	}
 	e.nextPID++
 	e.handles = append(e.handles, handle)
@@ -132,6 +150,34 @@ func (e *FakeProcessExecutor) StartCount() int {
 	return e.startCalled
 }
 
+// LastCommand returns the command passed to the most recent Start call
+func (e *FakeProcessExecutor) LastCommand() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastCommand
+}
+
+// LastWorkdir returns the directory passed to the most recent Start call
+func (e *FakeProcessExecutor) LastWorkdir() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastWorkdir
+}
+
+// LastEnv returns the environment passed to the most recent Start call
+func (e *FakeProcessExecutor) LastEnv() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastEnv
+}
+
+// LastOptions returns the options passed to the most recent Start call
+func (e *FakeProcessExecutor) LastOptions() StartOptions {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastOpts
+}
+
 // Handles returns all created handles
 func (e *FakeProcessExecutor) Handles() []*FakeProcessHandle {
 	e.mu.Lock()
@@ -0,0 +1,40 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRealProcessExecutor_CombineOutput(t *testing.T) {
+	for _, timestamps := range []bool{false, true} {
+		tmpDir := t.TempDir()
+		stdoutPath := filepath.Join(tmpDir, "run.stdout.log")
+		stderrPath := filepath.Join(tmpDir, "run.stderr.log")
+
+		executor := &RealProcessExecutor{}
+		handle, err := executor.Start([]string{"sh", "-c", "echo out1; echo err1 >&2; echo out2; echo err2 >&2"}, tmpDir, os.Environ(),
+			stdoutPath, stderrPath, StartOptions{Combine: true, Timestamps: timestamps})
+		if err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		handle.Wait()
+
+		stdout, _ := os.ReadFile(stdoutPath)
+		if string(stdout) != "out1\nerr1\nout2\nerr2\n" {
+			t.Errorf("timestamps=%v: expected interleaved output, got %q", timestamps, stdout)
+		}
+
+		// The stderr log exists so readers find it, but stays empty
+		stderr, err := os.ReadFile(stderrPath)
+		if err != nil || len(stderr) != 0 {
+			t.Errorf("timestamps=%v: expected empty stderr log, got %q (%v)", timestamps, stderr, err)
+		}
+
+		if timestamps {
+			if stamps, _ := ReadTimestampIndex(stdoutPath); len(stamps) != 4 {
+				t.Errorf("expected 4 stamps for the combined log, got %d", len(stamps))
+			}
+		}
+	}
+}
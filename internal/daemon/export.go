@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// defaultExportPageSize is how many runs an export_runs response holds
+	// when the request does not say
+	defaultExportPageSize = 500
+
+	// maxExportPageSize bounds the runs of an export_runs response
+	maxExportPageSize = 5000
+)
+
+// handleExportRuns handles an export_runs request: a page of the stored runs
+// matching the filter, oldest first, and the cursor of the next page
+func (d *Daemon) handleExportRuns(req *Request) *Response {
+	var p ExportRunsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.Limit < 0 || p.After < 0 {
+		return NewErrorResponse(fmt.Errorf("limit and after must not be negative"))
+	}
+
+	f := RunFilter{Workdir: p.Workdir, After: p.After, Limit: min(p.Limit, maxExportPageSize)}
+	if f.Limit == 0 {
+		f.Limit = defaultExportPageSize
+	}
+	if p.JobID != "" {
+		job, err := d.jobManager.GetJob(d.jobManager.ResolveJobID(p.JobID))
+		if err != nil {
+			return NewErrorResponse(err)
+		}
+		f.JobID = job.ID
+	}
+	if p.Since != "" {
+		since, err := time.Parse(time.RFC3339, p.Since)
+		if err != nil {
+			return NewErrorResponse(fmt.Errorf("invalid since: %w", err))
+		}
+		f.Since = since
+	}
+
+	if d.store == nil {
+		return NewDataResponse(ExportRunsData{Runs: []HistoryEntry{}})
+	}
+	runs, next, err := d.store.ListRuns(f)
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("failed to list runs: %w", err))
+	}
+	if runs == nil {
+		runs = []HistoryEntry{}
+	}
+	return NewDataResponse(ExportRunsData{Runs: runs, Next: next})
+}
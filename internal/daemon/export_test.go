@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_ListRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, store)
+
+	build, _, _ := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	for i := 0; i < 4; i++ {
+		stopAndWait(t, jm, executor, build.ID)
+		if err := jm.StartJob(build.ID, nil, EnvSourceClient, Origin{}); err != nil {
+			t.Fatalf("StartJob failed: %v", err)
+		}
+	}
+	jm.AddJob([]string{"make", "other"}, "/other", JobOptions{}, nil)
+
+	// Pages of two runs of the job, oldest first
+	var ids []string
+	f := RunFilter{JobID: build.ID, Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("expected the pages to end")
+		}
+		runs, next, err := store.ListRuns(f)
+		if err != nil {
+			t.Fatalf("ListRuns failed: %v", err)
+		}
+		for _, run := range runs {
+			ids = append(ids, run.ID)
+			if run.Workdir != "/workdir" || len(run.Command) != 2 {
+				t.Errorf("expected the run's job, got %+v", run)
+			}
+		}
+		if next == 0 {
+			break
+		}
+		f.After = next
+	}
+	expected := []string{build.ID + "-1", build.ID + "-2", build.ID + "-3", build.ID + "-4", build.ID + "-5"}
+	if len(ids) != len(expected) {
+		t.Fatalf("expected runs %v, got %v", expected, ids)
+	}
+	for i := range expected {
+		if ids[i] != expected[i] {
+			t.Errorf("expected runs %v, got %v", expected, ids)
+			break
+		}
+	}
+
+	// Stopped runs have their duration and exit code
+	runs, _, _ := store.ListRuns(RunFilter{JobID: build.ID, Limit: 1})
+	if len(runs) != 1 || runs[0].Status != "stopped" || runs[0].StoppedAt == "" {
+		t.Errorf("expected the first run stopped, got %+v", runs)
+	}
+
+	if runs, _, _ := store.ListRuns(RunFilter{Workdir: "/other", Limit: 10}); len(runs) != 1 {
+		t.Errorf("expected 1 run in /other, got %d", len(runs))
+	}
+	if runs, _, _ := store.ListRuns(RunFilter{Since: time.Now().Add(time.Hour), Limit: 10}); len(runs) != 0 {
+		t.Errorf("expected no runs started in the future, got %d", len(runs))
+	}
+}
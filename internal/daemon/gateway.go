@@ -0,0 +1,357 @@
+package daemon
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/tail"
+)
+
+// DefaultGatewayAddr is where the HTTP gateway listens unless told otherwise
+const DefaultGatewayAddr = "127.0.0.1:7070"
+
+// gateway is the HTTP server exposing the daemon protocol
+type gateway struct {
+	server *http.Server
+	info   GatewayInfo
+}
+
+// startGateway starts the HTTP gateway on addr, replacing a running one.
+// Caller must hold d.gatewayMu.
+func (d *Daemon) startGateway(addr string) (*GatewayInfo, error) {
+	if d.gateway != nil {
+		if d.gateway.info.Addr == addr {
+			return &d.gateway.info, nil
+		}
+		d.stopGateway()
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	g := &gateway{info: GatewayInfo{
+		Addr:  listener.Addr().String(),
+		URL:   "http://" + listener.Addr().String(),
+		Token: hex.EncodeToString(tokenBytes),
+	}}
+	g.server = &http.Server{
+		Handler:           d.gatewayHandler(g.info.Token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := writeGatewayInfo(&g.info); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	go func() {
+		if err := g.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			Logger.Error("gateway stopped", "error", err)
+		}
+	}()
+
+	d.gateway = g
+	Logger.Info("gateway started", "addr", g.info.Addr)
+	return &g.info, nil
+}
+
+// stopGateway stops the HTTP gateway if it is running. Caller must hold
+// d.gatewayMu.
+func (d *Daemon) stopGateway() {
+	if d.gateway == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.gateway.server.Shutdown(ctx); err != nil {
+		d.gateway.server.Close()
+	}
+	if path, err := GetGatewayInfoPath(); err == nil {
+		os.Remove(path)
+	}
+
+	Logger.Info("gateway stopped", "addr", d.gateway.info.Addr)
+	d.gateway = nil
+}
+
+// writeGatewayInfo writes the gateway's address and token for local clients
+func writeGatewayInfo(info *GatewayInfo) error {
+	path, err := GetGatewayInfoPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write gateway info: %w", err)
+	}
+	return nil
+}
+
+// handleGatewayStart handles a gateway start request. The address is
+// remembered, so the gateway starts again with the daemon.
+func (d *Daemon) handleGatewayStart(req *Request) *Response {
+	var p GatewayStartPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	addr := p.Addr
+	if addr == "" {
+		addr = DefaultGatewayAddr
+	}
+
+	d.gatewayMu.Lock()
+	info, err := d.startGateway(addr)
+	d.gatewayMu.Unlock()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	if d.store != nil {
+		if err := d.store.SetGatewayAddr(addr); err != nil {
+			Logger.Warn("failed to save gateway address", "error", err)
+		}
+	}
+
+	return NewDataResponse(GatewayData{Gateway: info})
+}
+
+// handleGatewayStop handles a gateway stop request
+func (d *Daemon) handleGatewayStop(req *Request) *Response {
+	d.gatewayMu.Lock()
+	running := d.gateway != nil
+	d.stopGateway()
+	d.gatewayMu.Unlock()
+
+	if d.store != nil {
+		if err := d.store.SetGatewayAddr(""); err != nil {
+			Logger.Warn("failed to clear gateway address", "error", err)
+		}
+	}
+
+	return NewDataResponse(GatewayStopData{Stopped: running})
+}
+
+// handleGatewayStatus handles a gateway status request
+func (d *Daemon) handleGatewayStatus(req *Request) *Response {
+	var data GatewayData
+	d.gatewayMu.Lock()
+	if d.gateway != nil {
+		info := d.gateway.info
+		data.Gateway = &info
+	}
+	d.gatewayMu.Unlock()
+	return NewDataResponse(data)
+}
+
+// gatewayHandler serves the daemon protocol over HTTP:
+//
+//	POST /api/{type}     runs a request; the body is its payload, the
+//	                     response is the daemon's response
+//	GET  /api/subscribe  streams events over a WebSocket (query: workdir,
+//	                     snapshot=true, since=<seq>, coalesce=true, and
+//	                     comma-separated types, job_ids and tags)
+//	GET  /api/logs       streams a run's output over a WebSocket (query:
+//	                     job_id, run_id for another run than the latest,
+//	                     clean=true for lines cleaned with CleanOutput)
+//	GET  /               the web dashboard
+//
+// Every request needs the token, as "Authorization: Bearer <token>" or,
+// for browsers opening a WebSocket, a token query parameter.
+func (d *Daemon) gatewayHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", serveDashboard)
+	mux.HandleFunc("GET /api/subscribe", d.serveGatewaySubscribe)
+	mux.HandleFunc("GET /api/logs", d.serveGatewayLogs)
+	mux.HandleFunc("POST /api/{type}", d.serveGatewayRequest)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			given = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			writeGatewayResponse(w, http.StatusUnauthorized, NewErrorResponse(fmt.Errorf("missing or invalid token")))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// serveGatewayRequest runs a request of the type in the path
+func (d *Daemon) serveGatewayRequest(w http.ResponseWriter, r *http.Request) {
+	req := &Request{Type: RequestType(r.PathValue("type")), Payload: map[string]any{}, Client: r.Header.Get("X-Gob-Client"), ClientType: ClientTypeAPI}
+	if req.Client == "" {
+		req.Client = "http"
+	}
+
+	switch req.Type {
+	case RequestTypeSubscribe:
+		writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(fmt.Errorf("subscribe is a WebSocket: GET /api/subscribe")))
+		return
+	case RequestTypeSession:
+		writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(fmt.Errorf("session is not available over HTTP")))
+		return
+	case RequestTypeGatewayStart, RequestTypeGatewayStop, RequestTypeGatewayStatus, RequestTypeHandover:
+		writeGatewayResponse(w, http.StatusForbidden, NewErrorResponse(fmt.Errorf("%s is not available over HTTP", req.Type)))
+		return
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err := decoder.Decode(&req.Payload); err != nil && err != io.EOF {
+		writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid payload: %w", err)))
+		return
+	}
+	if req.Payload == nil {
+		req.Payload = map[string]any{}
+	}
+
+	resp := d.serveRequest(req)
+	status := http.StatusOK
+	if !resp.Success {
+		status = http.StatusBadRequest
+	}
+	writeGatewayResponse(w, status, resp)
+}
+
+// serveGatewaySubscribe upgrades to a WebSocket and sends events like a
+// subscribe request: first the success response, then one event per message
+func (d *Daemon) serveGatewaySubscribe(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	p := SubscribePayload{Workdir: query.Get("workdir")}
+	p.Snapshot, _ = strconv.ParseBool(query.Get("snapshot"))
+	p.Coalesce, _ = strconv.ParseBool(query.Get("coalesce"))
+	for _, t := range queryList(query.Get("types")) {
+		p.Types = append(p.Types, EventType(t))
+	}
+	p.JobIDs = queryList(query.Get("job_ids"))
+	p.Tags = queryList(query.Get("tags"))
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := strconv.ParseUint(sinceStr, 10, 64)
+		if err != nil {
+			writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(fmt.Errorf("invalid since: %s", sinceStr)))
+			return
+		}
+		p.Since = &since
+	}
+	req := NewTypedRequest(RequestTypeSubscribe, p)
+
+	conn, err := upgradeWebsocket(w, r)
+	if err != nil {
+		writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(err))
+		return
+	}
+	d.handleSubscribe(req, conn, json.NewEncoder(conn))
+}
+
+// queryList splits a comma-separated query parameter, nil if empty
+func queryList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// logLine is a message of the logs WebSocket
+type logLine struct {
+	Stream string `json:"stream"` // stdout or stderr
+	Line   string `json:"line"`
+}
+
+// logLineWriter sends each line written by a tail.Follower as a logLine,
+// binary lines as their size
+type logLineWriter struct {
+	conn   *wsConn
+	stream string
+}
+
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	line := logLine{Stream: w.stream, Line: strings.TrimSuffix(string(p), "\n")}
+	if logfile.IsBinary(p) {
+		line.Line = logfile.BinaryPlaceholder(len(p))
+	}
+	if err := json.NewEncoder(w.conn).Encode(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// serveGatewayLogs upgrades to a WebSocket and sends the output of a run,
+// from the start of its logs, one line per message until the client closes
+// the connection
+func (d *Daemon) serveGatewayLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	jobID := d.jobManager.ResolveJobID(query.Get("job_id"))
+	runs, err := d.jobManager.ListRunsForJob(jobID)
+	if err != nil {
+		writeGatewayResponse(w, http.StatusNotFound, NewErrorResponse(err))
+		return
+	}
+
+	var run *Run
+	for _, candidate := range runs {
+		if runID := query.Get("run_id"); runID == "" || candidate.ID == runID {
+			run = candidate
+			break
+		}
+	}
+	if run == nil {
+		writeGatewayResponse(w, http.StatusNotFound, NewErrorResponse(fmt.Errorf("run not found")))
+		return
+	}
+
+	conn, err := upgradeWebsocket(w, r)
+	if err != nil {
+		writeGatewayResponse(w, http.StatusBadRequest, NewErrorResponse(err))
+		return
+	}
+	defer conn.Close()
+
+	var transform func([]byte) []byte
+	if clean, _ := strconv.ParseBool(query.Get("clean")); clean {
+		transform = CleanLine
+	}
+
+	stdout := tail.NewFollower(&logLineWriter{conn: conn, stream: "stdout"})
+	stdout.AddSource(tail.FileSource{Path: run.StdoutPath, Transform: transform})
+	defer stdout.Stop()
+	if run.StderrPath != run.StdoutPath {
+		stderr := tail.NewFollower(&logLineWriter{conn: conn, stream: "stderr"})
+		stderr.AddSource(tail.FileSource{Path: run.StderrPath, Transform: transform})
+		defer stderr.Stop()
+	}
+
+	// Clients send nothing but control frames; stop once they close
+	io.Copy(io.Discard, conn)
+}
+
+// writeGatewayResponse writes a daemon response as JSON
+func writeGatewayResponse(w http.ResponseWriter, status int, resp *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,240 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newGatewayTestServer(t *testing.T) (*Daemon, *httptest.Server) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(tmpDir, d.handleEvent, executor, nil)
+
+	server := httptest.NewServer(d.gatewayHandler("secret"))
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+func gatewayPost(t *testing.T, url, token, body string) (int, Response) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return httpResp.StatusCode, resp
+}
+
+func TestGateway_RequiresToken(t *testing.T) {
+	_, server := newGatewayTestServer(t)
+
+	status, resp := gatewayPost(t, server.URL+"/api/ping", "", "")
+	if status != http.StatusUnauthorized || resp.Success {
+		t.Errorf("expected 401 without token, got %d %+v", status, resp)
+	}
+
+	status, _ = gatewayPost(t, server.URL+"/api/ping", "wrong", "")
+	if status != http.StatusUnauthorized {
+		t.Errorf("expected 401 with a wrong token, got %d", status)
+	}
+
+	status, resp = gatewayPost(t, server.URL+"/api/ping?token=secret", "", "")
+	if status != http.StatusOK || resp.Data["message"] != "pong" {
+		t.Errorf("expected pong with the token parameter, got %d %+v", status, resp)
+	}
+}
+
+func TestGateway_Requests(t *testing.T) {
+	d, server := newGatewayTestServer(t)
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	d.jobManager.AddJob([]string{"make", "test"}, "/other", JobOptions{}, nil)
+
+	status, resp := gatewayPost(t, server.URL+"/api/list", "secret", `{"workdir":"/workdir"}`)
+	if status != http.StatusOK || !resp.Success {
+		t.Fatalf("expected list success, got %d %+v", status, resp)
+	}
+	jobs, _ := resp.Data["jobs"].([]interface{})
+	if len(jobs) != 1 || jobs[0].(map[string]interface{})["id"] != job.ID {
+		t.Errorf("expected job %s only, got %v", job.ID, resp.Data["jobs"])
+	}
+
+	status, resp = gatewayPost(t, server.URL+"/api/stop", "secret", `{"job_id":"missing"}`)
+	if status != http.StatusBadRequest || resp.Success || resp.Error == "" {
+		t.Errorf("expected 400 with an error, got %d %+v", status, resp)
+	}
+
+	status, _ = gatewayPost(t, server.URL+"/api/list", "secret", `not json`)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid payload, got %d", status)
+	}
+
+	status, _ = gatewayPost(t, server.URL+"/api/gateway_stop", "secret", "")
+	if status != http.StatusForbidden {
+		t.Errorf("expected 403 for gateway requests, got %d", status)
+	}
+}
+
+func TestGateway_Subscribe(t *testing.T) {
+	d, server := newGatewayTestServer(t)
+
+	ws := dialGatewayWebsocket(t, server, "/api/subscribe?workdir=/workdir&token=secret")
+
+	opcode, payload, err := ws.readFrame()
+	if err != nil || opcode != wsOpText {
+		t.Fatalf("expected a text message, got opcode %d (%v)", opcode, err)
+	}
+	var resp Response
+	if err := json.Unmarshal(payload, &resp); err != nil || !resp.Success {
+		t.Fatalf("expected subscribe success, got %s", payload)
+	}
+
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	_, payload, err = ws.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventTypeJobAdded || event.JobID != job.ID {
+		t.Errorf("expected job_added for %s, got %s for %s", job.ID, event.Type, event.JobID)
+	}
+}
+
+// dialGatewayWebsocket opens a WebSocket to the test server
+func dialGatewayWebsocket(t *testing.T, server *httptest.Server, target string) *wsConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	handshake := "GET " + target + " HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+	httpResp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if httpResp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", httpResp.StatusCode)
+	}
+	if accept := httpResp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("unexpected Sec-WebSocket-Accept %q", accept)
+	}
+
+	// Server frames are unmasked, so the server side reader decodes them too
+	return &wsConn{Conn: conn, r: reader}
+}
+
+func TestGateway_Logs(t *testing.T) {
+	d, server := newGatewayTestServer(t)
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	run := d.jobManager.GetLatestRun(job.ID)
+	if err := os.WriteFile(run.StdoutPath, []byte("compiling\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(run.StderrPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := dialGatewayWebsocket(t, server, "/api/logs?job_id="+job.ID+"&token=secret")
+	_, payload, err := ws.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line logLine
+	if err := json.Unmarshal(payload, &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Stream != "stdout" || line.Line != "compiling" {
+		t.Errorf("expected stdout line %q, got %+v", "compiling", line)
+	}
+
+	// Lines written later follow
+	if err := os.WriteFile(run.StderrPath, []byte("warning\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, payload, err = ws.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(payload, &line)
+	if line.Stream != "stderr" || line.Line != "warning" {
+		t.Errorf("expected stderr line %q, got %+v", "warning", line)
+	}
+}
+
+func TestGateway_LogsClean(t *testing.T) {
+	d, server := newGatewayTestServer(t)
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	run := d.jobManager.GetLatestRun(job.ID)
+	if err := os.WriteFile(run.StdoutPath, []byte("10%\r100%\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(run.StderrPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := dialGatewayWebsocket(t, server, "/api/logs?job_id="+job.ID+"&clean=true&token=secret")
+	_, payload, err := ws.readFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line logLine
+	if err := json.Unmarshal(payload, &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Line != "100%" {
+		t.Errorf("expected the last state of the progress line, got %q", line.Line)
+	}
+}
+
+func TestGateway_Dashboard(t *testing.T) {
+	_, server := newGatewayTestServer(t)
+
+	resp, err := http.Get(server.URL + "/?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("expected the dashboard page, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,211 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// defaultGrepMaxMatches bounds the matches returned by a search
+const defaultGrepMaxMatches = 1000
+
+// maxGrepLineSize is the longest part of a log line a search looks at.
+// Longer lines are cut to this size.
+const maxGrepLineSize = 1024 * 1024
+
+// GrepOptions selects the logs searched by SearchLogs
+type GrepOptions struct {
+	Pattern    *regexp.Regexp
+	JobID      string    // only runs of this job (all if empty)
+	Workdir    string    // only jobs in this directory (all if empty)
+	Since      time.Time // only runs still active at or after this time (all if zero)
+	MaxMatches int       // stop after this many matches (default if 0)
+}
+
+// GrepMatch is a log line matching a search
+type GrepMatch struct {
+	RunID     string `json:"run_id"`
+	JobID     string `json:"job_id"`
+	Stream    string `json:"stream"` // "stdout" or "stderr"
+	Line      int    `json:"line"`   // 1-based line number in the stream
+	Text      string `json:"text"`
+	Truncated bool   `json:"truncated,omitempty"` // the line was longer than the part searched and returned
+	StartedAt string `json:"started_at"`          // start time of the run
+}
+
+// SearchLogs searches the stored logs of runs for lines matching a pattern.
+// Runs are searched oldest first, so the first match shows when a line first
+// appeared. Returns whether the search stopped early at MaxMatches.
+func (jm *JobManager) SearchLogs(opts GrepOptions) ([]GrepMatch, bool, error) {
+	maxMatches := opts.MaxMatches
+	if maxMatches <= 0 {
+		maxMatches = defaultGrepMaxMatches
+	}
+
+	jm.mu.RLock()
+	if opts.JobID != "" {
+		if _, ok := jm.jobs[opts.JobID]; !ok {
+			jm.mu.RUnlock()
+			return nil, false, fmt.Errorf("job not found: %s", opts.JobID)
+		}
+	}
+
+	var runs []Run
+	for _, run := range jm.runs {
+		job, ok := jm.jobs[run.JobID]
+		if !ok {
+			continue
+		}
+		if opts.JobID != "" && run.JobID != opts.JobID {
+			continue
+		}
+		if opts.Workdir != "" && job.Workdir != opts.Workdir {
+			continue
+		}
+		if !opts.Since.IsZero() && run.StoppedAt != nil && run.StoppedAt.Before(opts.Since) {
+			continue
+		}
+		runs = append(runs, Run{ID: run.ID, JobID: run.JobID, StdoutPath: run.StdoutPath, StderrPath: run.StderrPath, StartedAt: run.StartedAt})
+	}
+	jm.mu.RUnlock()
+
+	// Oldest first
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+
+	var matches []GrepMatch
+	for _, run := range runs {
+		for _, stream := range []struct{ name, path string }{{"stdout", run.StdoutPath}, {"stderr", run.StderrPath}} {
+			// Look for one extra match to know whether there are more
+			found, err := grepFile(stream.path, opts.Pattern, maxMatches+1-len(matches))
+			if err != nil {
+				return nil, false, err
+			}
+			for _, m := range found {
+				m.RunID = run.ID
+				m.JobID = run.JobID
+				m.Stream = stream.name
+				m.StartedAt = run.StartedAt.Format("2006-01-02T15:04:05Z07:00")
+				matches = append(matches, m)
+			}
+			if len(matches) > maxMatches {
+				return matches[:maxMatches], true, nil
+			}
+		}
+	}
+
+	return matches, false, nil
+}
+
+// grepFile returns up to limit lines of a log file matching a pattern.
+// Missing files (runs whose logs were deleted) have no matches.
+func grepFile(path string, pattern *regexp.Regexp, limit int) ([]GrepMatch, error) {
+	file, err := logfile.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer file.Close()
+
+	var matches []GrepMatch
+	line := 0
+	err = scanLogLines(file, func(text []byte, truncated bool) bool {
+		line++
+		if pattern.Match(text) {
+			m := GrepMatch{Line: line, Text: string(text), Truncated: truncated}
+			if logfile.IsBinary(text) {
+				m.Text = logfile.BinaryPlaceholder(len(text))
+			}
+			matches = append(matches, m)
+		}
+		return len(matches) < limit
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	return matches, nil
+}
+
+// scanLogLines calls fn with each line read from r, without its line ending,
+// until fn returns false. Lines longer than maxGrepLineSize are cut to that
+// size and passed with truncated set; the rest of them is skipped.
+func scanLogLines(r io.Reader, fn func(line []byte, truncated bool) bool) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var line []byte
+	truncated := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if err == nil {
+			chunk = chunk[:len(chunk)-1]
+		}
+		if room := maxGrepLineSize - len(line); len(chunk) > room {
+			line = append(line, chunk[:room]...)
+			truncated = true
+		} else {
+			line = append(line, chunk...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF && len(line) == 0 && !truncated {
+			return nil
+		}
+
+		if !fn(bytes.TrimSuffix(line, []byte("\r")), truncated) || err == io.EOF {
+			return nil
+		}
+		line, truncated = line[:0], false
+	}
+}
+
+// handleGrep handles a grep request
+func (d *Daemon) handleGrep(req *Request) *Response {
+	var p GrepPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	pattern := p.Pattern
+	if pattern == "" {
+		return NewErrorResponse(fmt.Errorf("missing pattern"))
+	}
+	if p.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("invalid pattern: %w", err))
+	}
+
+	opts := GrepOptions{Pattern: re, Workdir: p.Workdir, MaxMatches: p.MaxMatches}
+	if p.JobID != "" {
+		opts.JobID = d.jobManager.ResolveJobID(p.JobID)
+	}
+	if p.Since != "" {
+		opts.Since, err = time.Parse(time.RFC3339, p.Since)
+		if err != nil {
+			return NewErrorResponse(fmt.Errorf("invalid since: %w", err))
+		}
+	}
+
+	matches, truncated, err := d.jobManager.SearchLogs(opts)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(GrepData{Matches: matches, Truncated: truncated})
+}
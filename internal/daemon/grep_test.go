@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobManager_SearchLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	first, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+	firstRun := jm.GetCurrentRun(first.ID)
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	second, _, _ := jm.AddJob([]string{"make", "test"}, "/other", JobOptions{}, nil)
+	secondRun := jm.GetCurrentRun(second.ID)
+
+	os.WriteFile(firstRun.StdoutPath, []byte("starting\nerror: connection refused\n"), 0644)
+	os.WriteFile(firstRun.StderrPath, []byte("ERROR: boom\n"), 0644)
+	os.WriteFile(secondRun.StdoutPath, []byte("error: connection refused\n"), 0644)
+
+	matches, truncated, err := jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("(?i)error")})
+	if err != nil {
+		t.Fatalf("SearchLogs failed: %v", err)
+	}
+	if truncated || len(matches) != 3 {
+		t.Fatalf("expected 3 matches, got %d (truncated %v)", len(matches), truncated)
+	}
+	if matches[0].RunID != firstRun.ID || matches[0].Stream != "stdout" || matches[0].Line != 2 || matches[0].Text != "error: connection refused" {
+		t.Errorf("unexpected first match: %+v", matches[0])
+	}
+	if matches[1].Stream != "stderr" || matches[2].RunID != secondRun.ID {
+		t.Errorf("expected oldest run first, got %+v", matches)
+	}
+
+	// Filter by workdir
+	matches, _, _ = jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("refused"), Workdir: "/other"})
+	if len(matches) != 1 || matches[0].JobID != second.ID {
+		t.Errorf("expected only the match in /other, got %+v", matches)
+	}
+
+	// Stopped runs before since are skipped
+	matches, _, _ = jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("refused"), Since: time.Now().Add(time.Hour)})
+	if len(matches) != 1 || matches[0].JobID != second.ID {
+		t.Errorf("expected only the running job's match, got %+v", matches)
+	}
+
+	// Max matches
+	matches, truncated, _ = jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("refused"), MaxMatches: 1})
+	if !truncated || len(matches) != 1 {
+		t.Errorf("expected 1 truncated match, got %d (truncated %v)", len(matches), truncated)
+	}
+	matches, truncated, _ = jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("refused"), MaxMatches: 2})
+	if truncated || len(matches) != 2 {
+		t.Errorf("expected 2 matches without truncation, got %d (truncated %v)", len(matches), truncated)
+	}
+
+	if _, _, err := jm.SearchLogs(GrepOptions{Pattern: regexp.MustCompile("x"), JobID: "missing"}); err == nil {
+		t.Error("expected error for unknown job")
+	}
+}
+
+func TestGrepFile_LongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	long := "error " + strings.Repeat("x", maxGrepLineSize)
+	os.WriteFile(path, []byte("starting\n"+long+"\nerror: after\r\n"), 0644)
+
+	matches, err := grepFile(path, regexp.MustCompile("error"), 10)
+	if err != nil {
+		t.Fatalf("grepFile failed: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	if !matches[0].Truncated || matches[0].Line != 2 || len(matches[0].Text) != maxGrepLineSize {
+		t.Errorf("expected the long line cut and marked truncated, got line %d (%d bytes, truncated %v)", matches[0].Line, len(matches[0].Text), matches[0].Truncated)
+	}
+	if matches[1].Truncated || matches[1].Line != 3 || matches[1].Text != "error: after" {
+		t.Errorf("expected the line after the long one, got %+v", matches[1])
+	}
+}
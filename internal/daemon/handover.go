@@ -0,0 +1,274 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/version"
+)
+
+// A daemon replaced by one of a newer version hands its running jobs over
+// instead of stopping them: it writes the running runs to the handover file
+// and exits, leaving their processes alone. The new daemon adopts the runs
+// whose processes are still alive (checked with isOurProcess, since PIDs can
+// be reused) and its supervisor watches them until they exit. After a crash, the runs left
+// running in the database are adopted the same way.
+
+// errHandedOver is returned when starting a run after a handover
+var errHandedOver = errors.New("daemon is handing over to a new version, try again")
+
+// handover is the state left by a daemon for the next one
+type handover struct {
+	Version string        `json:"version"` // version of the daemon that handed over
+	Runs    []handoverRun `json:"runs"`
+}
+
+// handoverRun is the state of a running run not kept in the database
+type handoverRun struct {
+	ID         string    `json:"id"`
+	PID        int       `json:"pid"`
+	StartedAt  time.Time `json:"started_at"`
+	CgroupPath string    `json:"cgroup_path,omitempty"`
+	Runtime    string    `json:"container_runtime,omitempty"` // CLI of the run's container runtime, empty if on the host
+	Container  string    `json:"container,omitempty"`         // name of the run's container
+}
+
+// argv returns the command of the run's process: the container runtime's
+// CLI for a run in a container, else the job's command
+func (h handoverRun) argv(job *Job) []string {
+	if h.Runtime != "" {
+		return []string{h.Runtime}
+	}
+	return job.Argv()
+}
+
+// writeHandover writes the handover file
+func writeHandover(path string, h *handover) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// readHandover reads and removes the handover file. Returns nil if there is
+// none, that is the previous daemon did not hand over.
+func readHandover(path string) (*handover, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+
+	var h handover
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("invalid handover file: %w", err)
+	}
+	return &h, nil
+}
+
+// HandOver stops starting runs and returns the running ones, to be adopted
+// by the next daemon. Fails if a running process cannot outlive the daemon.
+func (jm *JobManager) HandOver() ([]handoverRun, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	var runs []handoverRun
+	for _, job := range jm.jobs {
+		if job.CurrentRunID == nil {
+			continue
+		}
+		run := jm.runs[*job.CurrentRunID]
+		if run == nil || run.process == nil {
+			continue
+		}
+		if !run.process.Detachable() {
+			return nil, fmt.Errorf("job %s writes its output or stdin through the daemon (--timestamps or --stdin open) and would be stopped", job.ID)
+		}
+		h := handoverRun{
+			ID:         run.ID,
+			PID:        run.PID,
+			StartedAt:  run.StartedAt,
+			CgroupPath: run.cgroupPath,
+		}
+		if run.container != nil {
+			h.Runtime = run.container.runtime
+			h.Container = run.container.name
+		}
+		runs = append(runs, h)
+	}
+
+	jm.handedOver = true
+	return runs, nil
+}
+
+// CancelHandOver starts runs again after a failed handover
+func (jm *JobManager) CancelHandOver() {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jm.handedOver = false
+}
+
+// AdoptRuns takes over the runs left running by the previous daemon, handed
+// over or orphaned by a crash, whose processes are still alive. Runs still
+// marked as running in the database that cannot be adopted are marked
+// stopped. Returns the number adopted.
+func (jm *JobManager) AdoptRuns(handed []handoverRun) int {
+	byID := make(map[string]handoverRun, len(handed))
+	for _, h := range handed {
+		byID[h.ID] = h
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	adopted := 0
+	for _, run := range jm.runs {
+		if run.Status != "running" || run.process != nil {
+			continue
+		}
+
+		job := jm.jobs[run.JobID]
+		h, ok := byID[run.ID]
+		if job == nil || !ok || h.PID != run.PID || !isOurProcess(run.PID, run.StartedAt, h.argv(job)) {
+			Logger.Info("run not adopted, process is gone", "id", run.ID, "pid", run.PID)
+			now := time.Now()
+			run.Status = "stopped"
+			run.StoppedAt = &now
+			if jm.store != nil {
+				if err := jm.store.MarkRunStopped(run.ID); err != nil {
+					Logger.Warn("failed to mark run as stopped", "id", run.ID, "error", err)
+				}
+			}
+			// Counted like a killed run, which removing it takes back
+			if job != nil {
+				job.RunCount++
+				if jm.store != nil {
+					if err := jm.store.UpdateJob(job); err != nil {
+						Logger.Warn("failed to update job stats", "id", job.ID, "error", err)
+					}
+				}
+			}
+			continue
+		}
+
+		// The database keeps start times to the second
+		run.StartedAt = h.StartedAt
+		run.process = &adoptedProcessHandle{
+			pid:        run.PID,
+			runID:      run.ID,
+			cgroupPath: h.CgroupPath,
+			exited:     jm.supervisor.watch(run.PID),
+		}
+		run.cgroupPath = h.CgroupPath
+		if h.Runtime != "" {
+			name := h.Container
+			if name == "" {
+				name = "gob-" + run.ID // named by a version without daemon IDs
+			}
+			run.container = &runContainer{runtime: h.Runtime, name: name}
+		}
+		runID := run.ID
+		job.CurrentRunID = &runID
+
+		jm.watchRun(job, run)
+		jm.schedulePortPolling(job, run)
+
+		Logger.Info("adopted run", "id", run.ID, "pid", run.PID)
+		adopted++
+	}
+	return adopted
+}
+
+// adoptedProcessHandle is a process started by a previous daemon. It is not
+// a child of this daemon, so its exit is noticed by the supervisor, and its
+// exit code is unknown (exit hooks do not run) unless the supervisor could
+// read it.
+type adoptedProcessHandle struct {
+	pid        int
+	runID      string
+	cgroupPath string
+	exited     <-chan processExit
+}
+
+func (h *adoptedProcessHandle) Pid() int {
+	return h.pid
+}
+
+func (h *adoptedProcessHandle) Wait() error {
+	exit := <-h.exited
+	if h.cgroupPath != "" {
+		cg := &runCgroup{path: h.cgroupPath}
+		if err := cg.remove(); err != nil {
+			Logger.Debug("cgroup not removed", "path", h.cgroupPath, "error", err)
+		}
+	}
+	if !exit.known {
+		return fmt.Errorf("exit code of adopted process %d is unknown", h.pid)
+	}
+	if exit.status.Exited() && exit.status.ExitStatus() == 0 {
+		return nil
+	}
+	return &exitStatusError{status: exit.status}
+}
+
+func (h *adoptedProcessHandle) Signal(sig syscall.Signal) error {
+	if h.cgroupPath != "" {
+		cg := &runCgroup{path: h.cgroupPath}
+		cg.signal(sig)
+	}
+	signalRun(h.runID, sig)
+	// A process adopted with gob adopt may not lead its process group
+	if !isGroupLeader(h.pid) {
+		return signalProcess(h.pid, sig)
+	}
+	return signalGroup(h.pid, sig)
+}
+
+func (h *adoptedProcessHandle) IsRunning() bool {
+	_, exited := checkProcessExit(h.pid)
+	return !exited
+}
+
+func (h *adoptedProcessHandle) CgroupPath() string {
+	return h.cgroupPath
+}
+
+func (h *adoptedProcessHandle) Detachable() bool {
+	return true
+}
+
+// handleHandover handles a handover request: the daemon writes its running
+// runs to the handover file and exits without stopping them
+func (d *Daemon) handleHandover(req *Request) *Response {
+	path, err := GetHandoverPath()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	runs, err := d.jobManager.HandOver()
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	if err := writeHandover(path, &handover{Version: version.Version, Runs: runs}); err != nil {
+		d.jobManager.CancelHandOver()
+		return NewErrorResponse(fmt.Errorf("failed to write handover file: %w", err))
+	}
+
+	Logger.Info("handing over to a new daemon", "runs", len(runs))
+
+	d.handingOver = true
+	go func() {
+		d.cancel()
+	}()
+
+	return NewDataResponse(HandoverData{Runs: len(runs)})
+}
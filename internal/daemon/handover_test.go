@@ -0,0 +1,276 @@
+package daemon
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestJobManager_HandOver(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	server, _, _ := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	timestamps := true
+	_, _, _ = jm.AddJob([]string{"tail", "-f", "log"}, "/workdir", JobOptions{Timestamps: &timestamps}, nil)
+	captured := executor.LastHandle()
+
+	// Output captured through the daemon would be lost
+	if _, err := jm.HandOver(); err == nil {
+		t.Fatal("expected handover to fail with a captured run")
+	}
+
+	captured.Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	runs, err := jm.HandOver()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	run := jm.GetCurrentRun(server.ID)
+	if len(runs) != 1 || runs[0].ID != run.ID || runs[0].PID != run.PID || !runs[0].StartedAt.Equal(run.StartedAt) {
+		t.Errorf("unexpected handover runs: %+v", runs)
+	}
+
+	// No run starts once the jobs are handed over
+	if _, _, err := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, nil); !errors.Is(err, errHandedOver) {
+		t.Errorf("expected errHandedOver, got %v", err)
+	}
+
+	jm.CancelHandOver()
+	if _, _, err := jm.AddJob([]string{"make"}, "/workdir", JobOptions{}, nil); err != nil {
+		t.Errorf("expected runs to start after a cancelled handover, got %v", err)
+	}
+}
+
+func TestJobManager_AdoptRuns(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	startedAt := time.Now()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	alive := &Job{ID: "abc", Command: []string{"sleep", "30"}, Workdir: "/workdir"}
+	gone := &Job{ID: "def", Command: []string{"true"}, Workdir: "/workdir"}
+	jm.jobs[alive.ID] = alive
+	jm.jobs[gone.ID] = gone
+	jm.addRunLocked(&Run{ID: "abc-1", JobID: alive.ID, PID: cmd.Process.Pid, Status: "running", StartedAt: startedAt.Truncate(time.Second)})
+	jm.addRunLocked(&Run{ID: "def-1", JobID: gone.ID, PID: exited.Process.Pid, Status: "running", StartedAt: startedAt})
+
+	adopted := jm.AdoptRuns([]handoverRun{
+		{ID: "abc-1", PID: cmd.Process.Pid, StartedAt: startedAt},
+		{ID: "def-1", PID: exited.Process.Pid, StartedAt: startedAt},
+	})
+	if adopted != 1 {
+		t.Fatalf("expected 1 adopted run, got %d", adopted)
+	}
+
+	if !alive.IsRunning() || *alive.CurrentRunID != "abc-1" {
+		t.Fatal("expected the live run to be adopted")
+	}
+	if run := jm.runs["abc-1"]; !run.StartedAt.Equal(startedAt) || !run.IsRunning() {
+		t.Errorf("unexpected adopted run: %+v", run)
+	}
+	if gone.IsRunning() || jm.runs["def-1"].Status != "stopped" || jm.runs["def-1"].StoppedAt == nil {
+		t.Error("expected the run of the exited process to be marked stopped")
+	}
+	if gone.RunCount != 1 || gone.SuccessCount != 0 || gone.FailureCount != 0 {
+		t.Errorf("expected the stopped run counted like a killed one, got %+v", gone)
+	}
+
+	// The exit of an adopted process is noticed, without an exit code
+	cmd.Process.Kill()
+	cmd.Wait()
+	deadline := time.Now().Add(5 * time.Second)
+	for jm.GetCurrentRun(alive.ID) != nil && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if jm.GetCurrentRun(alive.ID) != nil {
+		t.Fatal("expected the adopted run to stop with its process")
+	}
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	if run := jm.runs["abc-1"]; run.Status != "stopped" || run.ExitCode != nil {
+		t.Errorf("unexpected stopped run: %+v", run)
+	}
+}
+
+func TestJobManager_AdoptRunsExitCode(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("exit status of adopted processes is only read on Linux")
+	}
+
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	jm.supervisor = newSupervisor(10 * time.Millisecond)
+
+	// Not waited for, the process stays a zombie once it exits
+	cmd := exec.Command("sh", "-c", "sleep 0.2; exit 3")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	info, err := getProcessInfo(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startedAt := info.StartTime
+
+	job := &Job{ID: "abc", Command: []string{"sh", "-c", "sleep 0.2; exit 3"}, Workdir: "/workdir"}
+	jm.jobs[job.ID] = job
+	jm.addRunLocked(&Run{ID: "abc-1", JobID: job.ID, PID: cmd.Process.Pid, Status: "running", StartedAt: startedAt})
+
+	if adopted := jm.AdoptRuns([]handoverRun{{ID: "abc-1", PID: cmd.Process.Pid, StartedAt: startedAt}}); adopted != 1 {
+		t.Fatalf("expected 1 adopted run, got %d", adopted)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for jm.GetCurrentRun(job.ID) != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	run := jm.runs["abc-1"]
+	if run.ExitCode == nil || *run.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %v", run.ExitCode)
+	}
+	if job.FailureCount != 1 || job.FailureTotalDurationMs != run.Duration().Milliseconds() {
+		t.Errorf("expected the failure and its duration to be recorded, got %d runs in %dms for a run of %s", job.FailureCount, job.FailureTotalDurationMs, run.Duration())
+	}
+}
+
+func TestJobManager_AdoptRunsSkipsReusedPIDs(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	// The PID belongs to another command than the job's
+	job := &Job{ID: "abc", Command: []string{"npm", "run", "dev"}, Workdir: "/workdir"}
+	jm.jobs[job.ID] = job
+	jm.addRunLocked(&Run{ID: "abc-1", JobID: job.ID, PID: cmd.Process.Pid, Status: "running", StartedAt: time.Now()})
+
+	if adopted := jm.AdoptRuns([]handoverRun{{ID: "abc-1", PID: cmd.Process.Pid, StartedAt: time.Now()}}); adopted != 0 {
+		t.Errorf("expected no adopted run, got %d", adopted)
+	}
+	if job.IsRunning() || !processExists(cmd.Process.Pid) {
+		t.Error("expected the run to be marked stopped and the process left alone")
+	}
+}
+
+func TestHandoverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "handover.json")
+
+	h, err := readHandover(path)
+	if err != nil || h != nil {
+		t.Fatalf("expected no handover, got %+v, %v", h, err)
+	}
+
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	written := &handover{Version: "1.2.3", Runs: []handoverRun{{ID: "abc-1", PID: 1234, StartedAt: startedAt, CgroupPath: "/sys/fs/cgroup/gob/abc-1"}}}
+	if err := writeHandover(path, written); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err = readHandover(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Version != "1.2.3" || len(h.Runs) != 1 || h.Runs[0].ID != "abc-1" || !h.Runs[0].StartedAt.Equal(startedAt) || h.Runs[0].CgroupPath != written.Runs[0].CgroupPath {
+		t.Errorf("unexpected handover: %+v", h)
+	}
+
+	// The file is consumed, a later daemon does not adopt again
+	if h, _ := readHandover(path); h != nil {
+		t.Errorf("expected the handover file to be removed, got %+v", h)
+	}
+}
+
+func TestDaemon_recoverFromCrash(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	exited := exec.Command("true")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs left marked as running by a daemon that crashed
+	now := time.Now()
+	for _, job := range []*Job{
+		{ID: "abc", Command: []string{"sleep", "30"}, CommandSignature: "sleep 30", Workdir: "/workdir", CreatedAt: now},
+		{ID: "def", Command: []string{"true"}, CommandSignature: "true", Workdir: "/workdir", CreatedAt: now},
+	} {
+		if err := store.InsertJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, run := range []*Run{
+		{ID: "abc-1", JobID: "abc", PID: cmd.Process.Pid, Status: "running", StartedAt: now},
+		{ID: "def-1", JobID: "def", PID: exited.Process.Pid, Status: "running", StartedAt: now},
+	} {
+		if err := store.InsertRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), store)
+	if err := jm.LoadFromStore(); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{jobManager: jm, store: store}
+
+	if err := d.recoverFromCrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	if job, _ := jm.GetJob("abc"); !job.IsRunning() || !processExists(cmd.Process.Pid) {
+		t.Error("expected the live process to be adopted, not stopped")
+	}
+	if job, _ := jm.GetJob("def"); job.IsRunning() {
+		t.Error("expected the run of the exited process to be stopped")
+	}
+	orphans, err := store.FindOrphanRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0].Run.ID != "abc-1" {
+		t.Errorf("expected only the adopted run to stay running, got %d orphans", len(orphans))
+	}
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/version"
+)
+
+// handleHealth handles a health request: whether the daemon is healthy, in
+// one response
+func (d *Daemon) handleHealth(req *Request) *Response {
+	data := HealthData{
+		Version:      version.Version,
+		PID:          os.Getpid(),
+		SocketPath:   d.socketPath,
+		DatabasePath: d.dbPath,
+		Jobs:         d.jobManager.JobCount(),
+		RunningJobs:  d.countRunningJobs(),
+		Runs:         d.jobManager.RunCount(),
+		LastError:    LastError(),
+		Components:   d.componentHealth(),
+	}
+	if !d.startedAt.IsZero() {
+		data.StartedAt = d.startedAt.UTC().Format(time.RFC3339)
+		data.UptimeMs = time.Since(d.startedAt).Milliseconds()
+	}
+	if d.dbPath != "" {
+		for _, path := range []string{d.dbPath, d.dbPath + "-wal"} {
+			if info, err := os.Stat(path); err == nil {
+				data.DatabaseBytes += info.Size()
+			}
+		}
+	}
+	if logPath, err := GetLogPath(); err == nil {
+		data.LogPath = logPath
+	}
+
+	d.subscribersMu.RLock()
+	data.Subscribers = len(d.subscribers)
+	d.subscribersMu.RUnlock()
+
+	return NewDataResponse(data)
+}
+
+// componentHealth returns the state of the daemon's background tasks
+func (d *Daemon) componentHealth() []ComponentHealth {
+	shells, projects := d.presence.counts()
+	presence := ComponentHealth{Name: "presence watcher", State: "stopped"}
+	if d.presence.running.Load() {
+		presence.State = "running"
+	}
+	presence.Detail = fmt.Sprintf("shells: %d, projects: %d", shells, projects)
+
+	supervisor := ComponentHealth{Name: "supervisor", State: "idle"}
+	if n := d.jobManager.supervisor.count(); n > 0 {
+		supervisor.State = "running"
+		supervisor.Detail = fmt.Sprintf("adopted processes: %d", n)
+	}
+
+	gateway := ComponentHealth{Name: "gateway", State: "stopped"}
+	d.gatewayMu.Lock()
+	if d.gateway != nil {
+		gateway.State = "running"
+		gateway.Detail = d.gateway.info.URL
+	}
+	d.gatewayMu.Unlock()
+
+	janitor := ComponentHealth{Name: "log janitor", State: "stopped"}
+	if d.logJanitor.Load() {
+		janitor.State = "running"
+	}
+
+	return []ComponentHealth{presence, supervisor, gateway, janitor}
+}
@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemon_handleHealth(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	if err := os.WriteFile(dbPath, make([]byte, 100), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d := &Daemon{jobManager: jm, socketPath: "/run/gob/daemon.sock", dbPath: dbPath, startedAt: time.Now().Add(-time.Minute)}
+
+	if _, _, err := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	Logger.Error("failed to scan ports", "error", errors.New("permission denied"))
+
+	resp := d.handleRequest(NewRequest(RequestTypeHealth))
+	if !resp.Success {
+		t.Fatalf("expected success, got %s", resp.Error)
+	}
+	var data HealthData
+	if err := resp.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	if data.PID != os.Getpid() || data.SocketPath != "/run/gob/daemon.sock" || data.DatabasePath != dbPath {
+		t.Errorf("unexpected identity: %+v", data)
+	}
+	if data.DatabaseBytes != 100 {
+		t.Errorf("expected database size 100, got %d", data.DatabaseBytes)
+	}
+	if data.UptimeMs < time.Minute.Milliseconds() {
+		t.Errorf("expected an uptime of at least a minute, got %dms", data.UptimeMs)
+	}
+	if data.Jobs != 1 || data.RunningJobs != 1 || data.Runs != 1 {
+		t.Errorf("expected 1 job, running, with 1 run, got %d, %d, %d", data.Jobs, data.RunningJobs, data.Runs)
+	}
+	if data.LastError == nil || data.LastError.Message != "failed to scan ports: permission denied" {
+		t.Errorf("expected the last error logged, got %+v", data.LastError)
+	}
+
+	states := make(map[string]string)
+	for _, c := range data.Components {
+		states[c.Name] = c.State
+	}
+	want := map[string]string{"presence watcher": "stopped", "supervisor": "idle", "gateway": "stopped", "log janitor": "stopped"}
+	for name, state := range want {
+		if states[name] != state {
+			t.Errorf("expected %s %s, got %q", name, state, states[name])
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hook events
+const (
+	HookOnStart   = "on_start"
+	HookOnSuccess = "on_success"
+	HookOnFailure = "on_failure"
+	HookOnSlow    = "on_slow"
+)
+
+// hookTimeout is the maximum time a hook may run before it is killed
+var hookTimeout = 60 * time.Second
+
+// JobHooks holds shell commands run by the daemon on job lifecycle events
+type JobHooks struct {
+	OnStart   string `json:"on_start,omitempty"`   // after a run starts
+	OnSuccess string `json:"on_success,omitempty"` // after a run exits with code 0
+	OnFailure string `json:"on_failure,omitempty"` // after a run exits with a non-zero code
+	OnSlow    string `json:"on_slow,omitempty"`    // when a run passes the job's slow threshold
+}
+
+// IsZero reports whether no hooks are set
+func (h JobHooks) IsZero() bool {
+	return h.OnStart == "" && h.OnSuccess == "" && h.OnFailure == "" && h.OnSlow == ""
+}
+
+// command returns the hook command for an event
+func (h JobHooks) command(event string) string {
+	switch event {
+	case HookOnStart:
+		return h.OnStart
+	case HookOnSuccess:
+		return h.OnSuccess
+	case HookOnFailure:
+		return h.OnFailure
+	case HookOnSlow:
+		return h.OnSlow
+	}
+	return ""
+}
+
+// HookOff, as a hook of job options, removes the job's hook for the event
+const HookOff = "off"
+
+// merge returns h with every hook set in other replacing the current one
+func (h JobHooks) merge(other JobHooks) JobHooks {
+	h.OnStart = mergeHook(h.OnStart, other.OnStart)
+	h.OnSuccess = mergeHook(h.OnSuccess, other.OnSuccess)
+	h.OnFailure = mergeHook(h.OnFailure, other.OnFailure)
+	h.OnSlow = mergeHook(h.OnSlow, other.OnSlow)
+	return h
+}
+
+// mergeHook returns the command of a hook set to value: empty keeps the
+// current one and HookOff removes it
+func mergeHook(current, value string) string {
+	switch value {
+	case "":
+		return current
+	case HookOff:
+		return ""
+	}
+	return value
+}
+
+// exitHookEvent returns the hook event for a finished run, or "" if the run
+// was killed by a signal (stopped runs don't trigger hooks)
+func exitHookEvent(exitCode *int) string {
+	if exitCode == nil {
+		return ""
+	}
+	if *exitCode == 0 {
+		return HookOnSuccess
+	}
+	return HookOnFailure
+}
+
+// hookEventEnvVar is set in the environment of hooks. They get the markers of
+// their run as well, for their own use, but they are not part of it: the
+// processes carrying it are left alone when the run is stopped, and are not
+// its orphans.
+const hookEventEnvVar = "GOB_HOOK_EVENT"
+
+// runHook runs a job's hook for an event in the background.
+//
+// The hook runs through `sh -c` in the job's working directory with the run's
+// environment plus GOB_HOOK_EVENT, GOB_JOB_ID, GOB_DAEMON_ID, GOB_RUN_ID,
+// GOB_LOG_DIR and, for exit events, GOB_EXIT_CODE. Its output goes to the
+// daemon log.
+func (jm *JobManager) runHook(job *Job, run *Run, event string) {
+	command := job.Hooks.command(event)
+	if command == "" {
+		return
+	}
+
+	env := run.env
+	if env == nil {
+		env = os.Environ()
+	}
+	env = append(append([]string{}, env...),
+		hookEventEnvVar+"="+event,
+		"GOB_JOB_ID="+job.ID,
+		daemonIDEnvVar+"="+jm.daemonID,
+		"GOB_RUN_ID="+run.ID,
+		"GOB_LOG_DIR="+filepath.Dir(run.StdoutPath),
+	)
+	if run.ExitCode != nil {
+		env = append(env, fmt.Sprintf("GOB_EXIT_CODE=%d", *run.ExitCode))
+	}
+
+	workdir := job.Workdir
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		defer cancel()
+
+		cmd := shellCommand(ctx, command)
+		cmd.Dir = workdir
+		cmd.Env = env
+
+		output, err := cmd.CombinedOutput()
+		out := strings.TrimSpace(string(output))
+		if err != nil {
+			Logger.Warn("hook failed", "job", job.ID, "run", run.ID, "event", event, "error", err, "output", out)
+			return
+		}
+		Logger.Info("hook completed", "job", job.ID, "run", run.ID, "event", event, "output", out)
+	}()
+}
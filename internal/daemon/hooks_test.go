@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExitHookEvent(t *testing.T) {
+	zero, one := 0, 1
+
+	if got := exitHookEvent(nil); got != "" {
+		t.Errorf("expected no event for killed run, got %q", got)
+	}
+	if got := exitHookEvent(&zero); got != HookOnSuccess {
+		t.Errorf("expected %q, got %q", HookOnSuccess, got)
+	}
+	if got := exitHookEvent(&one); got != HookOnFailure {
+		t.Errorf("expected %q, got %q", HookOnFailure, got)
+	}
+}
+
+func TestJobHooks_Merge(t *testing.T) {
+	hooks := JobHooks{OnStart: "start", OnFailure: "fail"}
+	merged := hooks.merge(JobHooks{OnFailure: "notify"})
+
+	if merged.OnStart != "start" {
+		t.Errorf("expected on_start to be kept, got %q", merged.OnStart)
+	}
+	if merged.OnFailure != "notify" {
+		t.Errorf("expected on_failure to be replaced, got %q", merged.OnFailure)
+	}
+	if merged.OnSuccess != "" {
+		t.Errorf("expected on_success to stay empty, got %q", merged.OnSuccess)
+	}
+
+	if merged = hooks.merge(JobHooks{OnStart: HookOff}); merged.OnStart != "" || merged.OnFailure != "fail" {
+		t.Errorf("expected only on_start to be removed, got %+v", merged)
+	}
+}
+
+// waitForFile waits up to a second for a file to exist and returns its content
+func waitForFile(t *testing.T, path string) string {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if data, err := os.ReadFile(path); err == nil {
+			return string(data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("file %s was not created", path)
+	return ""
+}
+
+func TestJobManager_Hooks_RunOnStartAndExit(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	opts := JobOptions{Hooks: JobHooks{
+		OnStart:   `echo "$GOB_HOOK_EVENT $GOB_JOB_ID" > started`,
+		OnSuccess: `echo "$GOB_HOOK_EVENT $GOB_EXIT_CODE" > succeeded`,
+		OnFailure: "touch failed",
+	}}
+	job, _, err := jm.AddJob([]string{"make", "test"}, tmpDir, opts, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	started := waitForFile(t, filepath.Join(tmpDir, "started"))
+	if strings.TrimSpace(started) != "on_start "+job.ID {
+		t.Errorf("unexpected on_start output: %q", started)
+	}
+
+	// The fake process exits with code 0
+	executor.LastHandle().Stop()
+
+	succeeded := waitForFile(t, filepath.Join(tmpDir, "succeeded"))
+	if strings.TrimSpace(succeeded) != "on_success 0" {
+		t.Errorf("unexpected on_success output: %q", succeeded)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "failed")); err == nil {
+		t.Error("expected on_failure hook not to run for a successful run")
+	}
+}
+
+func TestJobManager_AddJob_KeepsHooksWhenNotGiven(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{Hooks: JobHooks{OnFailure: "notify"}}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	if _, _, err := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{Hooks: JobHooks{OnSuccess: "deploy"}}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Hooks.OnFailure != "notify" || job.Hooks.OnSuccess != "deploy" {
+		t.Errorf("unexpected hooks: %+v", job.Hooks)
+	}
+	if resp := jm.jobToResponse(job); resp.Hooks == nil || resp.Hooks.OnSuccess != "deploy" {
+		t.Errorf("expected hooks in response, got %+v", resp.Hooks)
+	}
+
+	// Limits and hooks can be removed again
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+	jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{MemoryLimit: 1 << 30, CPULimit: 2}, nil)
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+	jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{MemoryLimit: LimitOff, CPULimit: LimitOff, Hooks: JobHooks{OnFailure: HookOff}}, nil)
+	if job.MemoryLimit != 0 || job.CPULimit != 0 || job.Hooks.OnFailure != "" || job.Hooks.OnSuccess != "deploy" {
+		t.Errorf("expected the limits and on_failure removed, got %d bytes, %v cores and %+v", job.MemoryLimit, job.CPULimit, job.Hooks)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// socketPath returns the path of the daemon's Unix socket
+func socketPath(runtimeDir string) string {
+	return filepath.Join(runtimeDir, "daemon.sock")
+}
+
+// listenIPC creates the daemon's Unix socket, accessible only by the user
+func listenIPC(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket: %w", err)
+	}
+
+	// Set socket permissions to user-only (0600)
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// inheritedIPC returns the socket passed by systemd when the daemon runs as
+// a socket-activated service (see 'gob daemon install'), or nil when there
+// is none. The socket stays with systemd when the daemon exits.
+func inheritedIPC() (net.Listener, error) {
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) || os.Getenv("LISTEN_FDS") != "1" {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	// Passed sockets start at file descriptor 3
+	f := os.NewFile(3, "daemon.sock")
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// dialIPC connects to the daemon's socket. A zero timeout means no timeout.
+func dialIPC(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
+
+// ipcExists reports whether the daemon's socket file exists
+func ipcExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// removeIPC removes the daemon's socket file
+func removeIPC(path string) error {
+	return os.Remove(path)
+}
+
+// startDaemonProcess runs the daemon command, which daemonizes itself with
+// go-daemon and returns once the daemon has been forked off
+func startDaemonProcess(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
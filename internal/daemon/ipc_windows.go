@@ -0,0 +1,346 @@
+package daemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeBufferSize is the size of the in and out buffers of each pipe instance
+const pipeBufferSize = 64 * 1024
+
+// pipeBusyWait is how long a client waits for a pipe instance at a time
+const pipeBusyWait = 100 * time.Millisecond
+
+var procWaitNamedPipe = windows.NewLazySystemDLL("kernel32.dll").NewProc("WaitNamedPipeW")
+
+// socketPath returns the name of the daemon's named pipe. Pipe names are
+// global, so the name is derived from the runtime directory to keep daemons
+// of different users (and isolated runtime directories) apart.
+func socketPath(runtimeDir string) string {
+	sum := sha256.Sum256([]byte(runtimeDir))
+	return `\\.\pipe\gob-` + hex.EncodeToString(sum[:8])
+}
+
+// pipeAddr is the net.Addr of a named pipe
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// listenIPC creates the daemon's named pipe, accessible only by the user.
+// Creating the first instance fails if another daemon owns the pipe.
+func listenIPC(path string) (net.Listener, error) {
+	sd, err := userOnlySecurityDescriptor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe security descriptor: %w", err)
+	}
+
+	l := &pipeListener{path: path, sd: sd}
+	first, err := l.createInstance(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create named pipe: %w", err)
+	}
+	l.next = first
+	return l, nil
+}
+
+// inheritedIPC returns nil: there is no socket activation on Windows
+func inheritedIPC() (net.Listener, error) {
+	return nil, nil
+}
+
+// userOnlySecurityDescriptor returns a security descriptor that grants
+// access to the current user only
+func userOnlySecurityDescriptor() (*windows.SECURITY_DESCRIPTOR, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	return windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + user.User.Sid.String() + ")")
+}
+
+// dialIPC connects to the daemon's named pipe, waiting for a free instance
+// while the daemon is busy accepting. A zero timeout means no timeout.
+func dialIPC(path string, timeout time.Duration) (net.Conn, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		handle, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil,
+			windows.OPEN_EXISTING, windows.FILE_FLAG_OVERLAPPED, 0)
+		if err == nil {
+			return &pipeConn{handle: handle, path: path}, nil
+		}
+		if err != windows.ERROR_PIPE_BUSY {
+			return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr(path), Err: err}
+		}
+
+		wait := pipeBusyWait
+		if !deadline.IsZero() {
+			wait = min(wait, time.Until(deadline))
+			if wait <= 0 {
+				return nil, &net.OpError{Op: "dial", Net: "pipe", Addr: pipeAddr(path), Err: os.ErrDeadlineExceeded}
+			}
+		}
+		waitNamedPipe(name, wait)
+	}
+}
+
+// waitNamedPipe waits until an instance of a named pipe is available
+func waitNamedPipe(name *uint16, timeout time.Duration) error {
+	r, _, err := procWaitNamedPipe.Call(uintptr(unsafe.Pointer(name)), uintptr(timeout.Milliseconds()))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// ipcExists reports whether the daemon's named pipe exists
+func ipcExists(path string) bool {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	err = waitNamedPipe(name, time.Millisecond)
+	return err == nil || err == windows.ERROR_SEM_TIMEOUT
+}
+
+// removeIPC is a no-op: a named pipe goes away with its last handle
+func removeIPC(path string) error {
+	return nil
+}
+
+// startDaemonProcess starts the daemon command as a detached process without
+// a console and returns without waiting for it
+func startDaemonProcess(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: windows.DETACHED_PROCESS | windows.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// pipeListener accepts connections on a named pipe. One instance of the pipe
+// always waits for the next client, so clients can connect at any time.
+type pipeListener struct {
+	path string
+	sd   *windows.SECURITY_DESCRIPTOR
+
+	mu     sync.Mutex
+	next   windows.Handle // instance waiting for the next client
+	closed bool
+}
+
+// createInstance creates a new instance of the pipe
+func (l *pipeListener) createInstance(first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.path)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX | windows.FILE_FLAG_OVERLAPPED)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	mode := uint32(windows.PIPE_TYPE_BYTE | windows.PIPE_READMODE_BYTE | windows.PIPE_WAIT | windows.PIPE_REJECT_REMOTE_CLIENTS)
+	sa := &windows.SecurityAttributes{
+		Length:             uint32(unsafe.Sizeof(windows.SecurityAttributes{})),
+		SecurityDescriptor: l.sd,
+	}
+	return windows.CreateNamedPipe(name, flags, mode, windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize, pipeBufferSize, 0, sa)
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	handle := l.next
+	l.mu.Unlock()
+
+	_, err := overlappedIO(handle, time.Time{}, func(ov *windows.Overlapped, _ *uint32) error {
+		return windows.ConnectNamedPipe(handle, ov)
+	})
+	if err == windows.ERROR_PIPE_CONNECTED {
+		// The client connected before ConnectNamedPipe was called
+		err = nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, net.ErrClosed
+	}
+	if err != nil {
+		// Reset the instance so it can wait for another client
+		windows.DisconnectNamedPipe(handle)
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+
+	next, err := l.createInstance(false)
+	if err != nil {
+		windows.DisconnectNamedPipe(handle)
+		return nil, &net.OpError{Op: "accept", Net: "pipe", Addr: pipeAddr(l.path), Err: err}
+	}
+	l.next = next
+	return &pipeConn{handle: handle, path: l.path}, nil
+}
+
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	windows.CancelIoEx(l.next, nil)
+	return windows.CloseHandle(l.next)
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.path)
+}
+
+// pipeConn is a connection over a named pipe opened for overlapped I/O, so a
+// pending read does not block writes and deadlines can cancel operations
+type pipeConn struct {
+	handle windows.Handle
+	path   string
+	closed atomic.Bool
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	n, err := overlappedIO(c.handle, c.deadline(&c.readDeadline), func(ov *windows.Overlapped, done *uint32) error {
+		return windows.ReadFile(c.handle, b, done, ov)
+	})
+	if err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED {
+		return n, io.EOF
+	}
+	return n, c.opError("read", err)
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		n, err := overlappedIO(c.handle, c.deadline(&c.writeDeadline), func(ov *windows.Overlapped, done *uint32) error {
+			return windows.WriteFile(c.handle, b[written:], done, ov)
+		})
+		written += n
+		if err != nil {
+			return written, c.opError("write", err)
+		}
+	}
+	return written, nil
+}
+
+func (c *pipeConn) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	windows.CancelIoEx(c.handle, nil)
+	return windows.CloseHandle(c.handle)
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return pipeAddr(c.path) }
+func (c *pipeConn) RemoteAddr() net.Addr { return pipeAddr(c.path) }
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	return nil
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// deadline returns the current value of one of the connection's deadlines
+func (c *pipeConn) deadline(d *time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *d
+}
+
+// opError wraps an I/O error, reporting operations on a closed connection
+// as net.ErrClosed
+func (c *pipeConn) opError(op string, err error) error {
+	if err == nil || err == os.ErrDeadlineExceeded {
+		return err
+	}
+	if c.closed.Load() {
+		err = net.ErrClosed
+	}
+	return &net.OpError{Op: op, Net: "pipe", Addr: pipeAddr(c.path), Err: err}
+}
+
+// overlappedIO starts an overlapped operation on a handle and waits for it to
+// complete. The operation is cancelled at the deadline (zero means none).
+func overlappedIO(handle windows.Handle, deadline time.Time, op func(*windows.Overlapped, *uint32) error) (int, error) {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(event)
+
+	ov := &windows.Overlapped{HEvent: event}
+	var done uint32
+	err = op(ov, &done)
+	if err != windows.ERROR_IO_PENDING {
+		return int(done), err
+	}
+
+	timeout := uint32(windows.INFINITE)
+	if !deadline.IsZero() {
+		timeout = uint32(max(time.Until(deadline).Milliseconds(), 0))
+	}
+	if event, _ := windows.WaitForSingleObject(event, timeout); event == uint32(windows.WAIT_TIMEOUT) {
+		windows.CancelIoEx(handle, ov)
+		windows.GetOverlappedResult(handle, ov, &done, true)
+		return int(done), os.ErrDeadlineExceeded
+	}
+	err = windows.GetOverlappedResult(handle, ov, &done, true)
+	return int(done), err
+}
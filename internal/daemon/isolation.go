@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Isolated daemons.
+//
+// By default one daemon serves every directory. A project can instead get its
+// own daemon, whose socket, database and logs live in the project's .gob
+// directory, so unrelated repositories do not share a daemon and each can use
+// its own gob version. Isolation is selected with GOB_ISOLATED=1 or with
+// `isolated = true` in the project's gobfile. The daemon is told its project
+// with GOB_PROJECT_DIR, since it does not run in the project directory.
+
+// isolatedDirName is the directory of an isolated daemon in its project
+const isolatedDirName = ".gob"
+
+// gobfileName is the gobfile path relative to a project directory
+const gobfileName = ".config/gobfile.toml"
+
+// ProjectDir returns the directory of the project whose isolated daemon is
+// used, or "" for the global daemon.
+//
+//   - GOB_PROJECT_DIR names the project directly (set for isolated daemons)
+//   - GOB_ISOLATED=1 isolates the project around the current directory: the
+//     nearest directory with a gobfile or a .git, or else the current one.
+//     GOB_ISOLATED=0 always uses the global daemon
+//   - otherwise, the nearest gobfile up from the current directory isolates
+//     its project if it sets `isolated = true`
+func ProjectDir() string {
+	if dir := os.Getenv("GOB_PROJECT_DIR"); dir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			return abs
+		}
+		return dir
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	if value := os.Getenv("GOB_ISOLATED"); value != "" {
+		if isolated, _ := strconv.ParseBool(value); !isolated {
+			return ""
+		}
+		if dir := findProjectDir(cwd, gobfileName, ".git"); dir != "" {
+			return dir
+		}
+		return cwd
+	}
+
+	dir := findProjectDir(cwd, gobfileName)
+	if dir == "" || !gobfileIsolated(filepath.Join(dir, gobfileName)) {
+		return ""
+	}
+	return dir
+}
+
+// findProjectDir returns the nearest directory from dir up containing one of
+// the given entries, or "" if there is none
+func findProjectDir(dir string, entries ...string) string {
+	for {
+		for _, entry := range entries {
+			if _, err := os.Stat(filepath.Join(dir, entry)); err == nil {
+				return dir
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// GobfileDir returns the nearest directory from dir up with a gobfile, or ""
+// if there is none
+func GobfileDir(dir string) string {
+	return findProjectDir(dir, gobfileName)
+}
+
+// gobfileIsolated reports whether a gobfile asks for an isolated daemon
+func gobfileIsolated(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var config struct {
+		Isolated bool `toml:"isolated"`
+	}
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	return config.Isolated
+}
+
+// ignoreIsolatedDir keeps the .gob directory of a project out of git
+func ignoreIsolatedDir(dir string) {
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+}
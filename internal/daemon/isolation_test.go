@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectDir(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolated := filepath.Join(root, "isolated")
+	shared := filepath.Join(root, "shared")
+	repo := filepath.Join(root, "repo")
+	for _, dir := range []string{
+		filepath.Join(isolated, ".config"), filepath.Join(isolated, "src"),
+		filepath.Join(shared, ".config"),
+		filepath.Join(repo, ".git"), filepath.Join(repo, "src"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(isolated, gobfileName), []byte("isolated = true\n"), 0644)
+	os.WriteFile(filepath.Join(shared, gobfileName), []byte("[[job]]\ncommand = \"make\"\n"), 0644)
+
+	tests := []struct {
+		name     string
+		cwd      string
+		isolated string // GOB_ISOLATED
+		project  string // GOB_PROJECT_DIR
+		expected string
+	}{
+		{"gobfile isolates its project", filepath.Join(isolated, "src"), "", "", isolated},
+		{"gobfile without isolation", shared, "", "", ""},
+		{"no gobfile", filepath.Join(repo, "src"), "", "", ""},
+		{"GOB_ISOLATED finds the git root", filepath.Join(repo, "src"), "1", "", repo},
+		{"GOB_ISOLATED finds the gobfile", shared, "1", "", shared},
+		{"GOB_ISOLATED without a project", root, "1", "", root},
+		{"GOB_ISOLATED=0 overrides the gobfile", isolated, "0", "", ""},
+		{"GOB_PROJECT_DIR names the project", root, "", repo, repo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(tt.cwd)
+			t.Setenv("GOB_ISOLATED", tt.isolated)
+			t.Setenv("GOB_PROJECT_DIR", tt.project)
+
+			if got := ProjectDir(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIsolatedPaths(t *testing.T) {
+	project := t.TempDir()
+	t.Setenv("GOB_PROJECT_DIR", project)
+
+	dir := filepath.Join(project, isolatedDirName)
+	runtimeDir, _ := GetRuntimeDir()
+	db, _ := GetDatabasePath()
+	if runtimeDir != dir || filepath.Dir(db) != dir {
+		t.Errorf("expected the runtime files and database in %s, got %s and %s", dir, runtimeDir, db)
+	}
+
+	if _, err := EnsureStateDir(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err != nil || string(data) != "*\n" {
+		t.Errorf("expected the directory to be ignored by git, got %q, %v", data, err)
+	}
+}
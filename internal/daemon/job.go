@@ -5,8 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"os"
-	"os/exec"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -17,11 +16,37 @@ import (
 // Job represents a managed background job (a command that can be run repeatedly)
 type Job struct {
 	ID               string    `json:"id"`                // user-facing identifier (e.g., "abc")
-	Command          []string  `json:"command"`           // the command + args
+	Command          []string  `json:"command"`           // the command + args, or the script of a shell command
+	Shell            string    `json:"shell"`             // shell running the script in Command (empty for argv commands)
 	CommandSignature string    `json:"command_signature"` // hash for lookups
 	Workdir          string    `json:"workdir"`           // directory scope
+	Alias            string    `json:"alias"`             // optional human-friendly name, accepted in place of the ID
 	Description      string    `json:"description"`       // optional human-readable description
 	Blocked          bool      `json:"blocked"`           // if true, job cannot be started
+	Pinned           bool      `json:"pinned"`            // if true, bulk removals and pruning skip the job (set with gob pin)
+	Priority         Priority  `json:"priority"`          // scheduling priority for runs
+	MemoryLimit      int64     `json:"memory_limit"`      // max memory in bytes per run (0 = unlimited)
+	CPULimit         float64   `json:"cpu_limit"`         // max CPU cores per run (0 = unlimited)
+	Hooks            JobHooks  `json:"hooks"`             // commands run on lifecycle events
+	Stdin            string    `json:"stdin"`             // stdin mode (null, open) or file path
+	LogFormat        string    `json:"log_format"`        // format of stdout: text or json
+	Timestamps       bool      `json:"timestamps"`        // if true, runs record when each output line was written
+	CombineOutput    bool      `json:"combine_output"`    // if true, stderr is written to the stdout log, interleaved
+	OutputHead       int64     `json:"output_head"`       // bytes kept from the start of each log of a run (0 = see OutputLimit)
+	OutputTail       int64     `json:"output_tail"`       // bytes kept from the end of each log of a run (0 = see OutputLimit)
+	LogDir           string    `json:"log_dir"`           // directory of the run logs (empty = the daemon's log directory)
+	Container        string    `json:"container"`         // image of the container runs are started in (empty = on the host)
+	DevEnv           string    `json:"dev_env"`           // development environment runs are started in: nix or direnv (empty = none)
+	Sandbox          string    `json:"sandbox"`           // sandbox runs are started in: project or readonly (empty = none)
+	CreatedBy        string    `json:"created_by"`        // name of the client that created the job (empty if unknown)
+	CreatedVia       string    `json:"created_via"`       // type of the client that created the job: cli, tui or api (empty if unknown)
+	RunDir           string    `json:"run_dir"`           // directory runs execute in, if not Workdir (empty = Workdir)
+	ProjectID        string    `json:"project_id"`        // git repository Workdir is in, for portable workdirs (empty = not portable)
+	ProjectPath      string    `json:"project_path"`      // Workdir relative to the repository root
+	ReloadSignal     int       `json:"reload_signal"`     // signal sent by gob reload (0 = SIGHUP)
+	EnvOverrides     []string  `json:"env_overrides"`     // KEY=VALUE variables set in the environment of every run
+	SlowThreshold    string    `json:"slow_threshold"`    // when runs count as slow, e.g. 5m or 2x (see ParseSlowThreshold)
+	Tags             []string  `json:"tags"`              // sorted labels used for filtering and bulk actions
 	CurrentRunID     *string   `json:"current_run_id"`    // nil if not running, points to active run
 	NextRunSeq       int       `json:"next_run_seq"`      // counter for internal run IDs
 	CreatedAt        time.Time `json:"created_at"`
@@ -73,6 +98,69 @@ func (j *Job) SuccessRate() float64 {
 	return float64(j.SuccessCount) / float64(j.RunCount) * 100
 }
 
+// JobOptions holds the optional settings given when adding or creating a job
+type JobOptions struct {
+	Description   string      // human-readable description (empty keeps the current one)
+	Blocked       bool        // if true, job cannot be started
+	Priority      Priority    // scheduling priority (empty keeps the current one)
+	MemoryLimit   int64       // max memory in bytes (0 keeps the current one, LimitOff removes it)
+	CPULimit      float64     // max CPU cores (0 keeps the current one, LimitOff removes it)
+	Hooks         JobHooks    // lifecycle hooks (empty hooks keep the current ones, HookOff removes one)
+	Stdin         string      // stdin mode or file path (empty keeps the current one)
+	LogFormat     string      // stdout format, text or json (empty keeps the current one)
+	Timestamps    *bool       // record line timestamps (nil keeps the current setting)
+	CombineOutput *bool       // write stderr into the stdout log (nil keeps the current setting)
+	OutputHead    int64       // bytes of output kept from the start of each log (0 keeps the current limit)
+	OutputTail    int64       // bytes of output kept from the end of each log (0 keeps the current limit)
+	LogDir        string      // absolute directory of the run logs (empty keeps the current one)
+	Container     string      // image of the container runs are started in (empty keeps the current one)
+	DevEnv        string      // development environment runs are started in, nix or direnv (empty keeps the current one)
+	Sandbox       string      // sandbox runs are started in, project or readonly (empty keeps the current one, off removes it)
+	RunDir        string      // absolute directory runs execute in (empty keeps the current one, the workdir clears it)
+	Project       *ProjectRef // project the workdir is in, the job follows it to other checkouts (nil keeps the current one)
+	ReloadSignal  int         // signal sent by gob reload (0 keeps the current one)
+	EnvOverrides  []string    // KEY=VALUE variables set in every run (nil keeps the current ones)
+	Tags          []string    // normalized tags (nil keeps the current ones)
+	RunLabel      string      // label of the run started by add or run, not stored on the job
+	Origin        Origin      // client creating the job and starting its run, not a setting
+
+	// Shell runs the command, a single script, with "<shell> -c". It is
+	// part of the job's identity rather than a setting: the same script
+	// with or without a shell is a different job.
+	Shell string
+}
+
+// Options returns the settings of a job as the options that create it again
+// (everything but its alias, ID and runs)
+func (j JobResponse) Options() JobOptions {
+	opts := JobOptions{
+		Description:   j.Description,
+		Blocked:       j.Blocked,
+		Priority:      j.Priority,
+		MemoryLimit:   j.MemoryLimit,
+		CPULimit:      j.CPULimit,
+		Stdin:         j.Stdin,
+		LogFormat:     j.LogFormat,
+		Timestamps:    &j.Timestamps,
+		CombineOutput: &j.CombineOutput,
+		OutputHead:    j.OutputHead,
+		OutputTail:    j.OutputTail,
+		LogDir:        j.LogDir,
+		Container:     j.Container,
+		DevEnv:        j.DevEnv,
+		Sandbox:       j.Sandbox,
+		RunDir:        j.RunDir,
+		ReloadSignal:  j.ReloadSignal,
+		EnvOverrides:  j.EnvOverrides,
+		Tags:          j.Tags,
+		Shell:         j.Shell,
+	}
+	if j.Hooks != nil {
+		opts.Hooks = *j.Hooks
+	}
+	return opts
+}
+
 // ComputeCommandSignature creates a hash from command array for lookups
 func ComputeCommandSignature(command []string) string {
 	// Join with null byte separator (can't appear in command args)
@@ -81,16 +169,65 @@ func ComputeCommandSignature(command []string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// ComputeJobSignature creates the lookup hash of a job's command, run by
+// shell if not empty. Argv commands keep their ComputeCommandSignature.
+func ComputeJobSignature(command []string, shell string) string {
+	if shell == "" {
+		return ComputeCommandSignature(command)
+	}
+	return ComputeCommandSignature(append([]string{"\x00shell", shell}, command...))
+}
+
+// Argv returns the arguments of the job's process: the command itself, or
+// the shell running its script
+func (j *Job) Argv() []string {
+	if j.Shell == "" {
+		return j.Command
+	}
+	return []string{j.Shell, "-c", j.Command[0]}
+}
+
+// Dir returns the directory the job's runs execute in
+func (j *Job) Dir() string {
+	if j.RunDir != "" {
+		return j.RunDir
+	}
+	return j.Workdir
+}
+
+// runDirOrEmpty returns the directory runs execute in to store for a job in
+// workdir: empty when it is the workdir itself
+func runDirOrEmpty(dir, workdir string) string {
+	if dir == workdir {
+		return ""
+	}
+	return dir
+}
+
+// validateShellCommand checks that a shell command is a single script
+func validateShellCommand(command []string, shell string) error {
+	if shell != "" && len(command) != 1 {
+		return fmt.Errorf("a shell command must be a single script")
+	}
+	return nil
+}
+
 // JobManager manages all jobs and runs in the daemon
 type JobManager struct {
-	jobs       map[string]*Job   // keyed by job ID
-	runs       map[string]*Run   // keyed by run ID
-	jobIndex   map[string]string // signature+workdir -> job ID for quick lookup
-	mu         sync.RWMutex
-	runtimeDir string
-	onEvent    func(Event)
-	executor   ProcessExecutor
-	store      *Store // database store for persistence
+	jobs            map[string]*Job   // keyed by job ID
+	runs            map[string]*Run   // keyed by run ID
+	jobRuns         map[string][]*Run // runs of each job, oldest first (see addRunLocked)
+	jobIndex        map[string]string // signature+workdir -> job ID for quick lookup
+	mu              sync.RWMutex
+	runtimeDir      string
+	onEvent         func(Event)
+	executor        ProcessExecutor
+	store           *Store      // database store for persistence
+	daemonID        string      // set in the environment of runs, see daemonIDEnvVar
+	envFilter       EnvFilter   // applied to the environments clients send
+	compressMinSize int64       // logs of stopped runs this large are compressed, 0 for never
+	supervisor      *supervisor // watches the exit of adopted processes
+	handedOver      bool        // running runs were left to a new daemon, no more runs start
 }
 
 // NewJobManager creates a new job manager
@@ -98,11 +235,14 @@ func NewJobManager(runtimeDir string, onEvent func(Event), store *Store) *JobMan
 	return &JobManager{
 		jobs:       make(map[string]*Job),
 		runs:       make(map[string]*Run),
+		jobRuns:    make(map[string][]*Run),
 		jobIndex:   make(map[string]string),
 		runtimeDir: runtimeDir,
 		onEvent:    onEvent,
 		executor:   &RealProcessExecutor{},
 		store:      store,
+		daemonID:   newDaemonID(),
+		supervisor: newSupervisor(superviseInterval),
 	}
 }
 
@@ -111,11 +251,14 @@ func NewJobManagerWithExecutor(runtimeDir string, onEvent func(Event), executor
 	return &JobManager{
 		jobs:       make(map[string]*Job),
 		runs:       make(map[string]*Run),
+		jobRuns:    make(map[string][]*Run),
 		jobIndex:   make(map[string]string),
 		runtimeDir: runtimeDir,
 		onEvent:    onEvent,
 		executor:   executor,
 		store:      store,
+		daemonID:   newDaemonID(),
+		supervisor: newSupervisor(superviseInterval),
 	}
 }
 
@@ -126,6 +269,13 @@ func (jm *JobManager) JobCount() int {
 	return len(jm.jobs)
 }
 
+// RunCount returns the number of loaded runs
+func (jm *JobManager) RunCount() int {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	return len(jm.runs)
+}
+
 // HasRunningJobs returns true if there are any running jobs
 func (jm *JobManager) HasRunningJobs() bool {
 	jm.mu.RLock()
@@ -138,6 +288,13 @@ func (jm *JobManager) HasRunningJobs() bool {
 	return false
 }
 
+// RunningJobCount returns the number of running jobs
+func (jm *JobManager) RunningJobCount() int {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	return jm.countRunningJobsLocked()
+}
+
 // countRunningJobsLocked returns the number of running jobs (caller must hold lock)
 func (jm *JobManager) countRunningJobsLocked() int {
 	count := 0
@@ -169,6 +326,7 @@ func (jm *JobManager) LoadFromStore() error {
 		indexKey := makeJobIndexKey(job.CommandSignature, job.Workdir)
 		jm.jobIndex[indexKey] = job.ID
 	}
+	jm.normalizeWorkdirsLocked()
 
 	// Load runs
 	runs, err := jm.store.LoadRuns()
@@ -177,9 +335,9 @@ func (jm *JobManager) LoadFromStore() error {
 	}
 
 	for _, run := range runs {
-		jm.runs[run.ID] = run
-		// Note: We don't restore CurrentRunID here because all runs
-		// should be stopped after crash recovery
+		jm.addRunLocked(run)
+		// Note: We don't restore CurrentRunID here, runs left running are
+		// adopted (or marked stopped) after a handover or crash
 	}
 
 	return nil
@@ -200,13 +358,38 @@ func (jm *JobManager) emitEvent(event Event) {
 // jobToResponse converts a Job to JobResponse
 func (jm *JobManager) jobToResponse(job *Job) JobResponse {
 	resp := JobResponse{
-		ID:          job.ID,
-		Status:      job.Status(),
-		Command:     job.Command,
-		Workdir:     job.Workdir,
-		Description: job.Description,
-		Blocked:     job.Blocked,
-		CreatedAt:   job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:            job.ID,
+		Status:        job.Status(),
+		Command:       job.Command,
+		Shell:         job.Shell,
+		Workdir:       job.Workdir,
+		Alias:         job.Alias,
+		Description:   job.Description,
+		Blocked:       job.Blocked,
+		Pinned:        job.Pinned,
+		Priority:      job.Priority,
+		MemoryLimit:   job.MemoryLimit,
+		CPULimit:      job.CPULimit,
+		Stdin:         job.Stdin,
+		LogFormat:     job.LogFormat,
+		Timestamps:    job.Timestamps,
+		CombineOutput: job.CombineOutput,
+		OutputHead:    job.OutputHead,
+		OutputTail:    job.OutputTail,
+		LogDir:        job.LogDir,
+		Container:     job.Container,
+		DevEnv:        job.DevEnv,
+		Sandbox:       job.Sandbox,
+		RunDir:        job.RunDir,
+		ProjectID:     job.ProjectID,
+		ProjectPath:   job.ProjectPath,
+		ReloadSignal:  job.ReloadSignal,
+		EnvOverrides:  job.EnvOverrides,
+		SlowThreshold: job.SlowThreshold,
+		Tags:          job.Tags,
+		CreatedAt:     job.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedBy:     job.CreatedBy,
+		CreatedVia:    job.CreatedVia,
 
 		// Statistics
 		RunCount:             job.RunCount,
@@ -219,6 +402,11 @@ func (jm *JobManager) jobToResponse(job *Job) JobResponse {
 		MaxDurationMs:        job.MaxDurationMs,
 	}
 
+	if !job.Hooks.IsZero() {
+		hooks := job.Hooks
+		resp.Hooks = &hooks
+	}
+
 	// If there's a current run, include its details
 	if job.CurrentRunID != nil {
 		if run, ok := jm.runs[*job.CurrentRunID]; ok {
@@ -228,6 +416,8 @@ func (jm *JobManager) jobToResponse(job *Job) JobResponse {
 			resp.StderrPath = run.StderrPath
 			resp.ExitCode = run.ExitCode
 			resp.Ports = run.Ports // Include ports for running jobs
+			resp.StartedBy = run.StartedBy
+			resp.StartedVia = run.StartedVia
 			if run.StoppedAt != nil {
 				resp.StoppedAt = run.StoppedAt.Format("2006-01-02T15:04:05Z07:00")
 			}
@@ -241,6 +431,9 @@ func (jm *JobManager) jobToResponse(job *Job) JobResponse {
 			resp.StdoutPath = latestRun.StdoutPath
 			resp.StderrPath = latestRun.StderrPath
 			resp.ExitCode = latestRun.ExitCode
+			resp.StartedBy = latestRun.StartedBy
+			resp.StartedVia = latestRun.StartedVia
+			resp.Output = latestRun.Output
 			if latestRun.StoppedAt != nil {
 				resp.StoppedAt = latestRun.StoppedAt.Format("2006-01-02T15:04:05Z07:00")
 			}
@@ -252,17 +445,17 @@ func (jm *JobManager) jobToResponse(job *Job) JobResponse {
 
 // getLatestRunForJobLocked returns the most recent run for a job (caller must hold lock)
 func (jm *JobManager) getLatestRunForJobLocked(jobID string) *Run {
-	var latest *Run
-	for _, run := range jm.runs {
-		if run.JobID == jobID {
-			if latest == nil || run.StartedAt.After(latest.StartedAt) {
-				latest = run
-			}
-		}
+	runs := jm.jobRunsLocked(jobID)
+	if len(runs) == 0 {
+		return nil
 	}
-	return latest
+	return runs[len(runs)-1]
 }
 
+// runDedupWindow is how long after a run starts that identical run requests
+// reuse it instead of starting another run
+var runDedupWindow = 2 * time.Second
+
 const base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 const jobIDLength = 3
 
@@ -304,31 +497,54 @@ func (e *ErrJobBlocked) Error() string {
 
 // AddJob finds or creates a job for the command, then starts a new run.
 // Returns the job, the action taken ("created", "started", or "already_running"), and any error.
-func (jm *JobManager) AddJob(command []string, workdir string, description string, blocked bool, env []string) (*Job, string, error) {
-	if len(command) == 0 {
-		return nil, "", fmt.Errorf("empty command")
-	}
+func (jm *JobManager) AddJob(command []string, workdir string, opts JobOptions, env []string) (*Job, string, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	return jm.addJobLocked(command, workdir, opts, env)
+}
 
+// RunJob is AddJob for clients that run a job and wait for its result. When
+// several clients run the same command at about the same time, only one run
+// is started: a run that started less than runDedupWindow ago is returned
+// with the "deduplicated" action even if it already finished.
+func (jm *JobManager) RunJob(command []string, workdir string, opts JobOptions, env []string) (*Job, string, error) {
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
 
-	signature := ComputeCommandSignature(command)
+	signature := ComputeJobSignature(command, opts.Shell)
+	jm.followProjectLocked(signature, workdir, opts.Project)
+	if jobID, ok := jm.jobIndex[makeJobIndexKey(signature, workdir)]; ok {
+		job := jm.jobs[jobID]
+		if !job.IsRunning() && !job.Blocked {
+			if run := jm.getLatestRunForJobLocked(job.ID); run != nil && time.Since(run.StartedAt) < runDedupWindow {
+				return job, "deduplicated", nil
+			}
+		}
+	}
+
+	return jm.addJobLocked(command, workdir, opts, env)
+}
+
+// addJobLocked implements AddJob. Caller must hold jm.mu.
+func (jm *JobManager) addJobLocked(command []string, workdir string, opts JobOptions, env []string) (*Job, string, error) {
+	if len(command) == 0 {
+		return nil, "", fmt.Errorf("empty command")
+	}
+	if err := validateShellCommand(command, opts.Shell); err != nil {
+		return nil, "", err
+	}
+
+	signature := ComputeJobSignature(command, opts.Shell)
 	indexKey := makeJobIndexKey(signature, workdir)
+	jm.followProjectLocked(signature, workdir, opts.Project)
 
 	// Check if job already exists for this command+workdir
 	if existingJobID, ok := jm.jobIndex[indexKey]; ok {
 		job := jm.jobs[existingJobID]
 
 		// Update blocked status and description if provided
-		jobChanged := false
-		if job.Blocked != blocked {
-			job.Blocked = blocked
-			jobChanged = true
-		}
-		if description != "" && job.Description != description {
-			job.Description = description
-			jobChanged = true
-		}
+		jobChanged := jm.applyJobOptionsLocked(job, opts)
 
 		// Persist changes to database
 		if jobChanged && jm.store != nil {
@@ -358,7 +574,7 @@ func (jm *JobManager) AddJob(command []string, workdir string, description strin
 		}
 
 		// Start a new run for existing job with the provided environment
-		run, err := jm.startRunLocked(job, env)
+		run, err := jm.startRunLocked(job, env, EnvSourceClient, opts.RunLabel, opts.Origin)
 		if err != nil {
 			return nil, "", err
 		}
@@ -388,19 +604,51 @@ func (jm *JobManager) AddJob(command []string, workdir string, description strin
 
 	// Create new job
 	existingIDs := make(map[string]bool)
-	for id := range jm.jobs {
+	for id, j := range jm.jobs {
 		existingIDs[id] = true
+		if j.Alias != "" {
+			existingIDs[j.Alias] = true // IDs must not shadow aliases
+		}
 	}
 	jobID := generateJobID(existingIDs)
 
+	if opts.Priority == "" {
+		opts.Priority = PriorityNormal
+	}
+	opts.Stdin = stdinOrNull(opts.Stdin)
+	opts.LogFormat = logFormatOrText(opts.LogFormat)
+
 	now := time.Now()
 	job := &Job{
 		ID:               jobID,
 		Command:          command,
+		Shell:            opts.Shell,
 		CommandSignature: signature,
 		Workdir:          workdir,
-		Description:      description,
-		Blocked:          blocked,
+		Description:      opts.Description,
+		Blocked:          opts.Blocked,
+		Priority:         opts.Priority,
+		MemoryLimit:      max(opts.MemoryLimit, 0),
+		CPULimit:         max(opts.CPULimit, 0),
+		Hooks:            JobHooks{}.merge(opts.Hooks),
+		Stdin:            opts.Stdin,
+		LogFormat:        opts.LogFormat,
+		Timestamps:       opts.Timestamps != nil && *opts.Timestamps,
+		CombineOutput:    opts.CombineOutput != nil && *opts.CombineOutput,
+		OutputHead:       opts.OutputHead,
+		OutputTail:       opts.OutputTail,
+		LogDir:           opts.LogDir,
+		Container:        opts.Container,
+		DevEnv:           opts.DevEnv,
+		Sandbox:          sandboxOrEmpty(opts.Sandbox),
+		RunDir:           runDirOrEmpty(opts.RunDir, workdir),
+		ProjectID:        opts.Project.id(),
+		ProjectPath:      opts.Project.path(),
+		ReloadSignal:     opts.ReloadSignal,
+		EnvOverrides:     opts.EnvOverrides,
+		Tags:             opts.Tags,
+		CreatedBy:        opts.Origin.Name,
+		CreatedVia:       opts.Origin.Type,
 		NextRunSeq:       1,
 		CreatedAt:        now,
 	}
@@ -418,7 +666,7 @@ func (jm *JobManager) AddJob(command []string, workdir string, description strin
 	}
 
 	// Check if job is blocked - return error with description (after creating)
-	if blocked {
+	if opts.Blocked {
 		// Emit job added event for the blocked job
 		jm.emitEvent(Event{
 			Type:            EventTypeJobAdded,
@@ -431,7 +679,7 @@ func (jm *JobManager) AddJob(command []string, workdir string, description strin
 	}
 
 	// Start first run with the provided environment
-	run, err := jm.startRunLocked(job, env)
+	run, err := jm.startRunLocked(job, env, EnvSourceClient, opts.RunLabel, opts.Origin)
 	if err != nil {
 		// Clean up job if run failed to start
 		if jm.store != nil {
@@ -465,8 +713,100 @@ func (jm *JobManager) AddJob(command []string, workdir string, description strin
 	return job, "created", nil
 }
 
+// applyJobOptionsLocked updates an existing job with the given options and
+// reports whether anything changed (caller must hold lock)
+func (jm *JobManager) applyJobOptionsLocked(job *Job, opts JobOptions) bool {
+	changed := false
+	if job.Blocked != opts.Blocked {
+		job.Blocked = opts.Blocked
+		changed = true
+	}
+	if opts.Description != "" && job.Description != opts.Description {
+		job.Description = opts.Description
+		changed = true
+	}
+	if opts.Priority != "" && job.Priority != opts.Priority {
+		job.Priority = opts.Priority
+		changed = true
+	}
+	if limit := max(opts.MemoryLimit, 0); opts.MemoryLimit != 0 && job.MemoryLimit != limit {
+		job.MemoryLimit = limit
+		changed = true
+	}
+	if limit := max(opts.CPULimit, 0); opts.CPULimit != 0 && job.CPULimit != limit {
+		job.CPULimit = limit
+		changed = true
+	}
+	if hooks := job.Hooks.merge(opts.Hooks); hooks != job.Hooks {
+		job.Hooks = hooks
+		changed = true
+	}
+	if opts.Stdin != "" && job.Stdin != opts.Stdin {
+		job.Stdin = opts.Stdin
+		changed = true
+	}
+	if opts.LogFormat != "" && job.LogFormat != opts.LogFormat {
+		job.LogFormat = opts.LogFormat
+		changed = true
+	}
+	if opts.Timestamps != nil && *opts.Timestamps != job.Timestamps {
+		job.Timestamps = *opts.Timestamps
+		changed = true
+	}
+	if opts.CombineOutput != nil && *opts.CombineOutput != job.CombineOutput {
+		job.CombineOutput = *opts.CombineOutput
+		changed = true
+	}
+	if opts.OutputHead != 0 && job.OutputHead != opts.OutputHead {
+		job.OutputHead = opts.OutputHead
+		changed = true
+	}
+	if opts.OutputTail != 0 && job.OutputTail != opts.OutputTail {
+		job.OutputTail = opts.OutputTail
+		changed = true
+	}
+	if opts.LogDir != "" && opts.LogDir != job.LogDir {
+		job.LogDir = opts.LogDir
+		changed = true
+	}
+	if opts.Container != "" && opts.Container != job.Container {
+		job.Container = opts.Container
+		changed = true
+	}
+	if opts.DevEnv != "" && opts.DevEnv != job.DevEnv {
+		job.DevEnv = opts.DevEnv
+		changed = true
+	}
+	if sandbox := sandboxOrEmpty(opts.Sandbox); opts.Sandbox != "" && sandbox != job.Sandbox {
+		job.Sandbox = sandbox
+		changed = true
+	}
+	if dir := runDirOrEmpty(opts.RunDir, job.Workdir); opts.RunDir != "" && dir != job.RunDir {
+		job.RunDir = dir
+		changed = true
+	}
+	if opts.Project != nil && (opts.Project.ID != job.ProjectID || opts.Project.Path != job.ProjectPath) {
+		job.ProjectID = opts.Project.ID
+		job.ProjectPath = opts.Project.Path
+		changed = true
+	}
+	if opts.ReloadSignal != 0 && opts.ReloadSignal != job.ReloadSignal {
+		job.ReloadSignal = opts.ReloadSignal
+		changed = true
+	}
+	if opts.EnvOverrides != nil && !slices.Equal(opts.EnvOverrides, job.EnvOverrides) {
+		job.EnvOverrides = opts.EnvOverrides
+		changed = true
+	}
+	if opts.Tags != nil && !tagsEqual(job.Tags, opts.Tags) {
+		job.Tags = opts.Tags
+		changed = true
+	}
+	return changed
+}
+
 // CreateJob creates a job without starting it (for autostart=false in gobfile)
-func (jm *JobManager) CreateJob(command []string, workdir string, description string, blocked bool) (*Job, error) {
+func (jm *JobManager) CreateJob(command []string, workdir string, opts JobOptions) (*Job, error) {
 	if len(command) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
@@ -474,23 +814,25 @@ func (jm *JobManager) CreateJob(command []string, workdir string, description st
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
 
-	signature := ComputeCommandSignature(command)
+	return jm.createJobLocked(command, workdir, opts)
+}
+
+// createJobLocked implements CreateJob. Caller must hold jm.mu.
+func (jm *JobManager) createJobLocked(command []string, workdir string, opts JobOptions) (*Job, error) {
+	if err := validateShellCommand(command, opts.Shell); err != nil {
+		return nil, err
+	}
+
+	signature := ComputeJobSignature(command, opts.Shell)
 	indexKey := makeJobIndexKey(signature, workdir)
+	jm.followProjectLocked(signature, workdir, opts.Project)
 
 	// Check if job already exists for this command+workdir
 	if existingJobID, ok := jm.jobIndex[indexKey]; ok {
 		job := jm.jobs[existingJobID]
 
 		// Update description and blocked status if different from current
-		jobChanged := false
-		if job.Blocked != blocked {
-			job.Blocked = blocked
-			jobChanged = true
-		}
-		if description != "" && job.Description != description {
-			job.Description = description
-			jobChanged = true
-		}
+		jobChanged := jm.applyJobOptionsLocked(job, opts)
 
 		if jobChanged {
 			// Persist updates to database
@@ -514,19 +856,51 @@ func (jm *JobManager) CreateJob(command []string, workdir string, description st
 
 	// Create new job
 	existingIDs := make(map[string]bool)
-	for id := range jm.jobs {
+	for id, j := range jm.jobs {
 		existingIDs[id] = true
+		if j.Alias != "" {
+			existingIDs[j.Alias] = true // IDs must not shadow aliases
+		}
 	}
 	jobID := generateJobID(existingIDs)
 
+	if opts.Priority == "" {
+		opts.Priority = PriorityNormal
+	}
+	opts.Stdin = stdinOrNull(opts.Stdin)
+	opts.LogFormat = logFormatOrText(opts.LogFormat)
+
 	now := time.Now()
 	job := &Job{
 		ID:               jobID,
 		Command:          command,
+		Shell:            opts.Shell,
 		CommandSignature: signature,
 		Workdir:          workdir,
-		Description:      description,
-		Blocked:          blocked,
+		Description:      opts.Description,
+		Blocked:          opts.Blocked,
+		Priority:         opts.Priority,
+		MemoryLimit:      max(opts.MemoryLimit, 0),
+		CPULimit:         max(opts.CPULimit, 0),
+		Hooks:            JobHooks{}.merge(opts.Hooks),
+		Stdin:            opts.Stdin,
+		LogFormat:        opts.LogFormat,
+		Timestamps:       opts.Timestamps != nil && *opts.Timestamps,
+		CombineOutput:    opts.CombineOutput != nil && *opts.CombineOutput,
+		OutputHead:       opts.OutputHead,
+		OutputTail:       opts.OutputTail,
+		LogDir:           opts.LogDir,
+		Container:        opts.Container,
+		DevEnv:           opts.DevEnv,
+		Sandbox:          sandboxOrEmpty(opts.Sandbox),
+		RunDir:           runDirOrEmpty(opts.RunDir, workdir),
+		ProjectID:        opts.Project.id(),
+		ProjectPath:      opts.Project.path(),
+		ReloadSignal:     opts.ReloadSignal,
+		EnvOverrides:     opts.EnvOverrides,
+		Tags:             opts.Tags,
+		CreatedBy:        opts.Origin.Name,
+		CreatedVia:       opts.Origin.Type,
 		NextRunSeq:       1,
 		CreatedAt:        now,
 	}
@@ -555,17 +929,63 @@ func (jm *JobManager) CreateJob(command []string, workdir string, description st
 	return job, nil
 }
 
-// startRunLocked creates and starts a new run for a job (caller must hold lock)
-func (jm *JobManager) startRunLocked(job *Job, env []string) (*Run, error) {
+// startRunLocked creates and starts a new run for a job, with the environment
+// of the given source (see runEnvLocked), an optional label and the client
+// starting it (caller must hold lock)
+func (jm *JobManager) startRunLocked(job *Job, env []string, envSource, label string, origin Origin) (*Run, error) {
+	if jm.handedOver {
+		return nil, errHandedOver
+	}
+
+	env, envSource = jm.runEnvLocked(job, env, envSource)
+
 	runID := fmt.Sprintf("%s-%d", job.ID, job.NextRunSeq)
-	job.NextRunSeq++
 
 	// Create log file paths
-	stdoutPath := fmt.Sprintf("%s/%s.stdout.log", jm.runtimeDir, runID)
-	stderrPath := fmt.Sprintf("%s/%s.stderr.log", jm.runtimeDir, runID)
+	stdoutPath, stderrPath, err := jm.runLogPaths(job, runID)
+	if err != nil {
+		return nil, err
+	}
+	job.NextRunSeq++
+
+	// Runs of jobs with a container image start in a new container, and
+	// runs of jobs with a development environment in it (inside the
+	// container, if any)
+	executor := jm.executor
+	if job.Sandbox != "" && job.Container != "" {
+		job.NextRunSeq--
+		return nil, fmt.Errorf("a sandbox can't be combined with a container, which already isolates the run")
+	}
+	container, err := newRunContainer(job, jm.daemonID, runID, env)
+	if err != nil {
+		job.NextRunSeq--
+		return nil, err
+	}
+	if container != nil {
+		executor = &containerExecutor{base: jm.executor, image: job.Container, container: container}
+	}
+	if job.DevEnv != "" {
+		executor = &devEnvExecutor{base: executor, devEnv: job.DevEnv}
+	}
+
+	// Sandboxed runs have only their command in the sandbox, not the tools
+	// setting up their development environment
+	if job.Sandbox != "" {
+		executor = &sandboxExecutor{base: executor, mode: job.Sandbox}
+	}
 
 	// Start the process with the provided environment
-	process, err := jm.executor.Start(job.Command, job.Workdir, env, stdoutPath, stderrPath)
+	process, err := executor.Start(job.Argv(), job.Dir(), env, stdoutPath, stderrPath, StartOptions{
+		DaemonID:   jm.daemonID,
+		JobID:      job.ID,
+		RunID:      runID,
+		Priority:   job.Priority,
+		Limits:     ResourceLimits{MemoryBytes: job.MemoryLimit, CPUs: job.CPULimit, CPUWeight: job.Priority.CPUWeight()},
+		Stdin:      job.Stdin,
+		Timestamps: job.Timestamps,
+		Combine:    job.CombineOutput,
+		Output:     OutputLimit{Head: job.OutputHead, Tail: job.OutputTail},
+	})
 	if err != nil {
 		job.NextRunSeq-- // Rollback sequence number
 		return nil, err
@@ -581,9 +1001,18 @@ func (jm *JobManager) startRunLocked(job *Job, env []string) (*Run, error) {
 		StderrPath: stderrPath,
 		StartedAt:  now,
 		process:    process,
-	}
-
-	jm.runs[runID] = run
+		cgroupPath: process.CgroupPath(),
+		EnvSource:  envSource,
+		Label:      label,
+		StartedBy:  origin.Name,
+		StartedVia: origin.Type,
+		Dir:        job.RunDir,
+		container:  container,
+		env:        env,
+	}
+	sampleConditions(run)
+
+	jm.addRunLocked(run)
 	job.CurrentRunID = &runID
 
 	// Persist run to database
@@ -597,9 +1026,11 @@ func (jm *JobManager) startRunLocked(job *Job, env []string) (*Run, error) {
 			Logger.Warn("failed to update job", "id", job.ID, "error", err)
 		}
 	}
+	jm.appendMarkerLocked(run, RunMarker{At: now.UTC(), Kind: MarkerStarted})
 
-	// Start goroutine to wait for process exit
-	go jm.waitForProcessExit(job, run)
+	jm.watchRun(job, run)
+
+	jm.runHook(job, run, HookOnStart)
 
 	// Schedule port polling at 2s, 5s, 10s
 	jm.schedulePortPolling(job, run)
@@ -607,8 +1038,17 @@ func (jm *JobManager) startRunLocked(job *Job, env []string) (*Run, error) {
 	return run, nil
 }
 
+// watchRun records the run as stopped when its process exits. Caller must
+// hold jm.mu.
+func (jm *JobManager) watchRun(job *Job, run *Run) {
+	run.done = make(chan struct{})
+	jm.armSlowTimerLocked(job, run)
+	go jm.waitForProcessExit(job, run)
+}
+
 // waitForProcessExit waits for a run's process to exit and updates state
 func (jm *JobManager) waitForProcessExit(job *Job, run *Run) {
+	defer close(run.done)
 	if run.process == nil {
 		return
 	}
@@ -622,11 +1062,19 @@ func (jm *JobManager) waitForProcessExit(job *Job, run *Run) {
 	now := time.Now()
 	run.StoppedAt = &now
 	run.Status = "stopped"
+	if run.slowTimer != nil {
+		run.slowTimer.Stop()
+	}
 	run.Ports = nil // Clear ports when run stops
 
+	// Record the size of the logs, which no longer grow
+	logBytes := runLogBytes(run)
+	run.LogBytes = &logBytes
+
 	// Extract exit code from the error
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		// *exec.ExitError, or *exitStatusError for adopted processes
+		if exitErr, ok := err.(interface{ Sys() any }); ok {
 			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
 				// Only get exit code if process exited normally (not killed by signal)
 				if status.Exited() {
@@ -643,6 +1091,10 @@ func (jm *JobManager) waitForProcessExit(job *Job, run *Run) {
 		run.ExitCode = &code
 	}
 
+	if event := exitHookEvent(run.ExitCode); event != "" {
+		jm.runHook(job, run, event)
+	}
+
 	// Clear job's current run pointer only if it still points to this run.
 	// This prevents a race condition where a restart creates a new run before
 	// this goroutine completes, and we would incorrectly clear the new run's ID.
@@ -652,6 +1104,7 @@ func (jm *JobManager) waitForProcessExit(job *Job, run *Run) {
 
 	// Update job statistics
 	durationMs := run.StoppedAt.Sub(run.StartedAt).Milliseconds()
+	run.DurationMs = &durationMs
 	job.RunCount++
 
 	if run.ExitCode != nil && *run.ExitCode == 0 {
@@ -710,6 +1163,9 @@ func (jm *JobManager) waitForProcessExit(job *Job, run *Run) {
 		JobCount:        jobCount,
 		RunningJobCount: runningJobCount,
 	})
+
+	// The logs don't grow anymore: count them and compress the large ones
+	jm.finishRunLogs(run)
 }
 
 // GetJob returns a job by ID
@@ -792,70 +1248,25 @@ func (jm *JobManager) StopJob(jobID string, force bool) error {
 	}
 
 	run := jm.runs[*job.CurrentRunID]
-	pid := run.PID
+	tree := jm.runProcessTree(run)
 	jm.mu.RUnlock()
 
-	// Snapshot all PIDs in the process tree before signaling
-	treePIDs := getProcessTreePIDs(pid)
-
-	if force {
-		// Send SIGKILL to process group
-		if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
-			return fmt.Errorf("failed to kill process: %w", err)
-		}
-		// Also SIGKILL each PID individually (handles processes that escaped the group)
-		killPIDs(treePIDs, syscall.SIGKILL)
-	} else {
-		// Send SIGTERM for graceful shutdown
-		if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
-			return fmt.Errorf("failed to stop process: %w", err)
-		}
-	}
-
-	// Wait for entire process tree to terminate
-	deadline := time.Now().Add(10 * time.Second)
-	for time.Now().Before(deadline) {
-		survivors := filterRunningPIDs(treePIDs)
-		if len(survivors) == 0 {
-			return nil
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	// If still running after timeout and we used SIGTERM, escalate to SIGKILL
-	if !force {
-		survivors := filterRunningPIDs(treePIDs)
-		if len(survivors) > 0 {
-			// Kill process group
-			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
-				return fmt.Errorf("failed to kill process: %w", err)
-			}
-			// Also kill each survivor individually
-			killPIDs(survivors, syscall.SIGKILL)
-
-			// Wait again for SIGKILL to take effect
-			deadline = time.Now().Add(5 * time.Second)
-			for time.Now().Before(deadline) {
-				survivors = filterRunningPIDs(treePIDs)
-				if len(survivors) == 0 {
-					return nil
-				}
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-	}
-
-	// Final verification
-	survivors := filterRunningPIDs(treePIDs)
+	// Signal the whole process tree and wait for it to terminate
+	// (SIGTERM escalates to SIGKILL unless force is set)
+	survivors := killProcessTrees([]processTree{tree}, force)
 	if len(survivors) > 0 {
 		return fmt.Errorf("process tree has %d surviving processes after SIGKILL: %v", len(survivors), survivors)
 	}
 
+	// Return once the job shows as stopped
+	run.waitStopped(stopExitTimeout)
 	return nil
 }
 
-// StartJob starts a new run for a stopped job with the provided environment
-func (jm *JobManager) StartJob(jobID string, env []string) error {
+// StartJob starts a new run for a stopped job with the environment of the
+// given source: the provided one, the previous run's or the stored one,
+// recording the client starting it
+func (jm *JobManager) StartJob(jobID string, env []string, envSource string, origin Origin) error {
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
 
@@ -874,8 +1285,8 @@ func (jm *JobManager) StartJob(jobID string, env []string) error {
 		return fmt.Errorf("job %s is already running (use 'gob restart' to restart a running job)", jobID)
 	}
 
-	// Start new run with the provided environment
-	run, err := jm.startRunLocked(job, env)
+	// Start new run with the environment of the requested source
+	run, err := jm.startRunLocked(job, env, envSource, "", origin)
 	if err != nil {
 		return err
 	}
@@ -903,8 +1314,9 @@ func (jm *JobManager) StartJob(jobID string, env []string) error {
 	return nil
 }
 
-// RestartJob stops (if running) and starts a new run with the provided environment
-func (jm *JobManager) RestartJob(jobID string, env []string) error {
+// RestartJob stops (if running) and starts a new run with the environment of
+// the given source, as StartJob
+func (jm *JobManager) RestartJob(jobID string, env []string, envSource string, origin Origin) error {
 	jm.mu.Lock()
 
 	job, ok := jm.jobs[jobID]
@@ -922,57 +1334,30 @@ func (jm *JobManager) RestartJob(jobID string, env []string) error {
 	// Stop if running
 	if job.CurrentRunID != nil {
 		run := jm.runs[*job.CurrentRunID]
-		pid := run.PID
+		tree := jm.runProcessTree(run)
 		jm.mu.Unlock()
 
-		// Snapshot all PIDs in the process tree before signaling
-		treePIDs := getProcessTreePIDs(pid)
-
-		if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil && err != syscall.ESRCH {
-			return fmt.Errorf("failed to stop process: %w", err)
-		}
-
-		// Wait for entire process tree to terminate
-		deadline := time.Now().Add(10 * time.Second)
-		for time.Now().Before(deadline) {
-			survivors := filterRunningPIDs(treePIDs)
-			if len(survivors) == 0 {
-				break
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
-
-		// Escalate to SIGKILL if needed
-		survivors := filterRunningPIDs(treePIDs)
-		if len(survivors) > 0 {
-			// Kill process group
-			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
-				return fmt.Errorf("failed to kill process: %w", err)
-			}
-			// Also kill each survivor individually
-			killPIDs(survivors, syscall.SIGKILL)
-
-			deadline = time.Now().Add(5 * time.Second)
-			for time.Now().Before(deadline) {
-				survivors = filterRunningPIDs(treePIDs)
-				if len(survivors) == 0 {
-					break
-				}
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-
-		// Final verification before restarting
-		survivors = filterRunningPIDs(treePIDs)
+		// Stop the whole process tree (SIGTERM, escalating to SIGKILL)
+		survivors := killProcessTrees([]processTree{tree}, false)
 		if len(survivors) > 0 {
 			return fmt.Errorf("cannot restart: process tree has %d surviving processes after SIGKILL: %v", len(survivors), survivors)
 		}
+		run.waitStopped(stopExitTimeout)
 
+		// The job may have been removed or started again meanwhile
 		jm.mu.Lock()
+		if _, ok := jm.jobs[jobID]; !ok {
+			jm.mu.Unlock()
+			return fmt.Errorf("job not found: %s", jobID)
+		}
+		if job.CurrentRunID != nil && *job.CurrentRunID != run.ID {
+			jm.mu.Unlock()
+			return fmt.Errorf("job %s was started by another request while restarting", jobID)
+		}
 	}
 
-	// Start new run with the provided environment
-	run, err := jm.startRunLocked(job, env)
+	// Start new run with the environment of the requested source
+	run, err := jm.startRunLocked(job, env, envSource, "", origin)
 	if err != nil {
 		jm.mu.Unlock()
 		return err
@@ -1002,8 +1387,9 @@ func (jm *JobManager) RestartJob(jobID string, env []string) error {
 	return nil
 }
 
-// RemoveJob removes a stopped job and all its runs
-func (jm *JobManager) RemoveJob(jobID string) error {
+// RemoveJob removes a stopped job and all its runs. Pinned jobs are only
+// removed with force.
+func (jm *JobManager) RemoveJob(jobID string, force bool) error {
 	jm.mu.Lock()
 	defer jm.mu.Unlock()
 
@@ -1016,15 +1402,26 @@ func (jm *JobManager) RemoveJob(jobID string) error {
 		return fmt.Errorf("cannot remove running job: %s (use 'stop' first)", jobID)
 	}
 
+	if job.Pinned && !force {
+		return fmt.Errorf("cannot remove pinned job: %s (use --force, or 'gob unpin' first)", jobID)
+	}
+
 	// Capture job info for event before deletion
 	jobResp := jm.jobToResponse(job)
 
-	// Remove all runs for this job and their log files
-	for runID, run := range jm.runs {
-		if run.JobID == jobID {
-			os.Remove(run.StdoutPath)
-			os.Remove(run.StderrPath)
-			delete(jm.runs, runID)
+	// Remove all runs for this job and their log files, archived ones too
+	for _, run := range jm.jobRunsLocked(jobID) {
+		removeRunLogs(run)
+		delete(jm.runs, run.ID)
+	}
+	delete(jm.jobRuns, jobID)
+	if jm.store != nil {
+		archived, err := jm.store.LoadArchivedRuns(jobID)
+		if err != nil {
+			Logger.Warn("failed to load archived runs", "id", jobID, "error", err)
+		}
+		for _, run := range archived {
+			removeRunLogs(run)
 		}
 	}
 
@@ -1068,6 +1465,15 @@ func (jm *JobManager) RemoveRun(runID string) error {
 		return fmt.Errorf("cannot remove running run: %s (stop the job first)", runID)
 	}
 
+	jm.removeRunLocked(run)
+	return nil
+}
+
+// removeRunLocked removes a stopped run and its log files, updating the
+// job's statistics (caller must hold lock)
+func (jm *JobManager) removeRunLocked(run *Run) {
+	runID := run.ID
+
 	// Get the job for stats update
 	job, jobExists := jm.jobs[run.JobID]
 
@@ -1076,7 +1482,7 @@ func (jm *JobManager) RemoveRun(runID string) error {
 
 	// Update job statistics if job exists
 	if jobExists && run.StoppedAt != nil {
-		durationMs := run.StoppedAt.Sub(run.StartedAt).Milliseconds()
+		durationMs := run.Duration().Milliseconds()
 
 		// Decrement counts
 		job.RunCount--
@@ -1094,11 +1500,10 @@ func (jm *JobManager) RemoveRun(runID string) error {
 	}
 
 	// Delete log files
-	os.Remove(run.StdoutPath)
-	os.Remove(run.StderrPath)
+	removeRunLogs(run)
 
-	// Remove from in-memory map
-	delete(jm.runs, runID)
+	// Remove from memory
+	jm.deleteRunLocked(run)
 
 	// Delete from database and update job stats
 	if jm.store != nil {
@@ -1125,8 +1530,6 @@ func (jm *JobManager) RemoveRun(runID string) error {
 		JobCount:        len(jm.jobs),
 		RunningJobCount: jm.countRunningJobsLocked(),
 	})
-
-	return nil
 }
 
 // recalculateMinMaxDuration recalculates min/max duration from all stopped runs for a job
@@ -1135,11 +1538,11 @@ func (jm *JobManager) recalculateMinMaxDuration(job *Job) {
 	job.MaxDurationMs = 0
 
 	first := true
-	for _, run := range jm.runs {
-		if run.JobID != job.ID || run.StoppedAt == nil {
+	for _, run := range jm.jobRunsLocked(job.ID) {
+		if run.StoppedAt == nil {
 			continue
 		}
-		durationMs := run.StoppedAt.Sub(run.StartedAt).Milliseconds()
+		durationMs := run.Duration().Milliseconds()
 		if first {
 			job.MinDurationMs = durationMs
 			job.MaxDurationMs = durationMs
@@ -1157,60 +1560,38 @@ func (jm *JobManager) recalculateMinMaxDuration(job *Job) {
 
 // StopAll stops all running jobs and their process trees
 func (jm *JobManager) StopAll() (stopped int) {
-	jm.mu.Lock()
-	defer jm.mu.Unlock()
-
-	// Collect running jobs and snapshot all PIDs in their process trees
-	var runningRuns []*Run
-	var allTreePIDs []int
+	// Collect the process trees of all running jobs
+	jm.mu.RLock()
+	var runs []*Run
+	var trees []processTree
 	for _, job := range jm.jobs {
 		if job.CurrentRunID != nil {
 			if run, ok := jm.runs[*job.CurrentRunID]; ok {
-				runningRuns = append(runningRuns, run)
-				// Snapshot PIDs for this process tree
-				treePIDs := getProcessTreePIDs(run.PID)
-				allTreePIDs = append(allTreePIDs, treePIDs...)
+				runs = append(runs, run)
+				trees = append(trees, jm.runProcessTree(run))
 			}
 		}
 	}
+	jm.mu.RUnlock()
 
-	if len(runningRuns) == 0 {
+	if len(trees) == 0 {
 		return 0
 	}
 
-	// Stop running jobs with SIGTERM (to process groups)
-	for _, run := range runningRuns {
-		syscall.Kill(-run.PID, syscall.SIGTERM)
-	}
-
-	// Wait for entire process trees to terminate
-	deadline := time.Now().Add(10 * time.Second)
-	for time.Now().Before(deadline) {
-		survivors := filterRunningPIDs(allTreePIDs)
-		if len(survivors) == 0 {
-			return len(runningRuns)
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	// SIGKILL any remaining - both process groups and individual survivors
-	for _, run := range runningRuns {
-		syscall.Kill(-run.PID, syscall.SIGKILL)
+	// Stop all trees together (SIGTERM, escalating to SIGKILL), without
+	// holding the lock so the runs can be recorded as stopped meanwhile
+	killProcessTrees(trees, false)
+	deadline := time.Now().Add(stopExitTimeout)
+	for _, run := range runs {
+		run.waitStopped(time.Until(deadline))
 	}
-	survivors := filterRunningPIDs(allTreePIDs)
-	killPIDs(survivors, syscall.SIGKILL)
 
-	// Wait for SIGKILL to take effect
-	deadline = time.Now().Add(5 * time.Second)
-	for time.Now().Before(deadline) {
-		survivors = filterRunningPIDs(allTreePIDs)
-		if len(survivors) == 0 {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
+	return len(trees)
+}
 
-	return len(runningRuns)
+// runProcessTree returns the process tree identifying a run's processes
+func (jm *JobManager) runProcessTree(run *Run) processTree {
+	return processTree{rootPID: run.PID, daemonID: jm.daemonID, runID: run.ID, cgroupPath: run.cgroupPath, container: run.container}
 }
 
 // Signal sends a signal to a running job
@@ -1232,11 +1613,18 @@ func (jm *JobManager) Signal(jobID string, signal syscall.Signal) error {
 	jm.mu.RUnlock()
 
 	// Send signal to process group
-	err := syscall.Kill(-pid, signal)
+	err := signalGroup(pid, signal)
 	if err != nil && err != syscall.ESRCH {
 		return fmt.Errorf("failed to send signal: %w", err)
 	}
 
+	// Signal 0 only checks that the process exists
+	if signal != 0 {
+		jm.mu.Lock()
+		jm.addMarkerLocked(run, MarkerSignal, signal.String())
+		jm.mu.Unlock()
+	}
+
 	return nil
 }
 
@@ -1276,11 +1664,35 @@ func (jm *JobManager) ListRunsForJob(jobID string) ([]*Run, error) {
 		return nil, fmt.Errorf("job not found: %s", jobID)
 	}
 
+	// Newest first
+	runs := slices.Clone(jm.jobRunsLocked(jobID))
+	slices.Reverse(runs)
+	return runs, nil
+}
+
+// page returns the items after skipping offset, at most limit (all if 0)
+func page[T any](items []T, offset, limit int) []T {
+	items = items[min(max(offset, 0), len(items)):]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// ListHistory returns runs across all jobs in a workdir (all workdirs if
+// empty), with a label (any if empty), newest first, skipping offset runs and returning at most limit
+// (all if limit is 0). It also returns the total number of matching runs.
+func (jm *JobManager) ListHistory(workdirFilter, labelFilter string, offset, limit int) ([]HistoryEntry, int) {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
 	var runs []*Run
 	for _, run := range jm.runs {
-		if run.JobID == jobID {
-			runs = append(runs, run)
+		job, ok := jm.jobs[run.JobID]
+		if !ok || (workdirFilter != "" && job.Workdir != workdirFilter) || (labelFilter != "" && run.Label != labelFilter) {
+			continue
 		}
+		runs = append(runs, run)
 	}
 
 	// Sort by StartedAt, newest first
@@ -1288,7 +1700,20 @@ func (jm *JobManager) ListRunsForJob(jobID string) ([]*Run, error) {
 		return runs[i].StartedAt.After(runs[j].StartedAt)
 	})
 
-	return runs, nil
+	total := len(runs)
+	runs = page(runs, offset, limit)
+
+	entries := make([]HistoryEntry, 0, len(runs))
+	for _, run := range runs {
+		job := jm.jobs[run.JobID]
+		entries = append(entries, HistoryEntry{
+			RunResponse: runToResponse(run),
+			Command:     job.Command,
+			Workdir:     job.Workdir,
+		})
+	}
+
+	return entries, total
 }
 
 // schedulePortPolling schedules port polling at 2s, 5s, and 10s after run starts
@@ -1313,7 +1738,7 @@ func (jm *JobManager) refreshPorts(jobID, runID string) {
 	}
 
 	run := jm.runs[runID]
-	ports, _ := getProcessTreePorts(run.PID)
+	ports, _ := runPorts(run)
 
 	if len(ports) == 0 {
 		return // Don't emit for empty ports
@@ -1358,20 +1783,35 @@ func portsEqual(a, b []PortInfo) bool {
 // runToResponse converts a Run to RunResponse
 func runToResponse(run *Run) RunResponse {
 	resp := RunResponse{
-		ID:         run.ID,
-		JobID:      run.JobID,
-		PID:        run.PID,
-		Status:     run.Status,
-		ExitCode:   run.ExitCode,
-		StdoutPath: run.StdoutPath,
-		StderrPath: run.StderrPath,
-		StartedAt:  run.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
-		DurationMs: run.Duration().Milliseconds(),
+		ID:          run.ID,
+		JobID:       run.JobID,
+		PID:         run.PID,
+		Status:      run.Status,
+		ExitCode:    run.ExitCode,
+		StdoutPath:  run.StdoutPath,
+		StderrPath:  run.StderrPath,
+		StartedAt:   run.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
+		DurationMs:  run.Duration().Milliseconds(),
+		LogBytes:    run.LogBytes,
+		Output:      run.Output,
+		Note:        run.Note,
+		EnvSource:   run.EnvSource,
+		Label:       run.Label,
+		StartedBy:   run.StartedBy,
+		StartedVia:  run.StartedVia,
+		Markers:     slices.Clone(run.Markers),
+		LoadAvg:     run.LoadAvg,
+		OnBattery:   run.OnBattery,
+		Dir:         run.Dir,
+		LogsMissing: run.LogsMissing,
 	}
 	if run.StoppedAt != nil {
 		resp.StoppedAt = run.StoppedAt.Format("2006-01-02T15:04:05Z07:00")
 	}
+	if run.ArchivedAt != nil {
+		resp.ArchivedAt = run.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+		resp.StdoutPath = archivedLogPath(run.StdoutPath)
+		resp.StderrPath = archivedLogPath(run.StderrPath)
+	}
 	return resp
 }
-
-
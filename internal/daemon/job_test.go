@@ -91,7 +91,7 @@ func TestJobManager_AddJob(t *testing.T) {
 
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
-	job, action, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job, action, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -148,13 +148,58 @@ func TestJobManager_AddJob(t *testing.T) {
 	}
 }
 
+func TestJobManager_RunJob_Deduplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, action, err := jm.RunJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if err != nil || action != "created" {
+		t.Fatalf("expected created, got %s (%v)", action, err)
+	}
+
+	// A second client while the run is going attaches to it
+	_, action, _ = jm.RunJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if action != "already_running" {
+		t.Errorf("expected already_running, got %s", action)
+	}
+
+	// A run that finished moments ago is reused too
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+	_, action, _ = jm.RunJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if action != "deduplicated" {
+		t.Errorf("expected deduplicated, got %s", action)
+	}
+	if runs, _ := jm.ListRunsForJob(job.ID); len(runs) != 1 {
+		t.Errorf("expected a single run, got %d", len(runs))
+	}
+
+	// Outside the window a new run starts
+	defer func(window time.Duration) { runDedupWindow = window }(runDedupWindow)
+	runDedupWindow = 0
+	_, action, _ = jm.RunJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if action != "started" {
+		t.Errorf("expected started, got %s", action)
+	}
+
+	// AddJob always starts a new run
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+	runDedupWindow = time.Minute
+	_, action, _ = jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+	if action != "started" {
+		t.Errorf("expected AddJob to start a run, got %s", action)
+	}
+}
+
 func TestJobManager_AddJob_SameCommand_CreatesNewRun(t *testing.T) {
 	tmpDir := t.TempDir()
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add first job
-	job1, action1, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job1, action1, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -167,7 +212,7 @@ func TestJobManager_AddJob_SameCommand_CreatesNewRun(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Add same command again - should reuse job and create new run
-	job2, action2, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job2, action2, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -192,7 +237,7 @@ func TestJobManager_AddJob_RunningJob_ReturnsAlreadyRunning(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add first job
-	job1, action1, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job1, action1, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -201,7 +246,7 @@ func TestJobManager_AddJob_RunningJob_ReturnsAlreadyRunning(t *testing.T) {
 	}
 
 	// Try to add same command while running - should return "already_running", not error
-	job2, action2, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job2, action2, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Errorf("expected no error for already running job, got: %v", err)
 	}
@@ -220,7 +265,7 @@ func TestJobManager_AddJob_RunningJob_UpdatesDescription(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add job without description
-	job1, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job1, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -229,7 +274,7 @@ func TestJobManager_AddJob_RunningJob_UpdatesDescription(t *testing.T) {
 	}
 
 	// Add same command while running with new description - should update
-	job2, action, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "new description", false, nil)
+	job2, action, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{Description: "new description"}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -251,7 +296,7 @@ func TestJobManager_AddJob_RunningJob_EmitsEventOnDescriptionChange(t *testing.T
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
 	// Add job without description
-	_, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	_, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -260,7 +305,7 @@ func TestJobManager_AddJob_RunningJob_EmitsEventOnDescriptionChange(t *testing.T
 	events = nil
 
 	// Add same command while running with new description
-	_, _, err = jm.AddJob([]string{"echo", "hello"}, "/workdir", "new description", false, nil)
+	_, _, err = jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{Description: "new description"}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -287,7 +332,7 @@ func TestJobManager_AddJob_RunningJob_NoEventWhenDescriptionSame(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
 	// Add job with description
-	_, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", "my description", false, nil)
+	_, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{Description: "my description"}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -296,7 +341,7 @@ func TestJobManager_AddJob_RunningJob_NoEventWhenDescriptionSame(t *testing.T) {
 	events = nil
 
 	// Add same command while running with same description
-	_, _, err = jm.AddJob([]string{"echo", "hello"}, "/workdir", "my description", false, nil)
+	_, _, err = jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{Description: "my description"}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -307,13 +352,137 @@ func TestJobManager_AddJob_RunningJob_NoEventWhenDescriptionSame(t *testing.T) {
 	}
 }
 
+func TestJobManager_AddJob_DefaultsToNormalPriority(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Priority != PriorityNormal {
+		t.Errorf("expected priority normal, got %q", job.Priority)
+	}
+	if executor.LastOptions().Priority != PriorityNormal {
+		t.Errorf("expected executor priority normal, got %q", executor.LastOptions().Priority)
+	}
+}
+
+func TestJobManager_AddJob_PassesPriorityToExecutor(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{Priority: PriorityLow}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Priority != PriorityLow {
+		t.Errorf("expected priority low, got %q", job.Priority)
+	}
+	if executor.LastOptions().Priority != PriorityLow {
+		t.Errorf("expected executor priority low, got %q", executor.LastOptions().Priority)
+	}
+	if resp := jm.jobToResponse(job); resp.Priority != PriorityLow {
+		t.Errorf("expected response priority low, got %q", resp.Priority)
+	}
+}
+
+func TestJobManager_AddJob_KeepsPriorityWhenNotGiven(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{Priority: PriorityHigh}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	// Re-adding without a priority keeps the stored one
+	if _, _, err := jm.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Priority != PriorityHigh {
+		t.Errorf("expected priority high, got %q", job.Priority)
+	}
+	if executor.LastOptions().Priority != PriorityHigh {
+		t.Errorf("expected executor priority high, got %q", executor.LastOptions().Priority)
+	}
+}
+
+func TestJobManager_AddJob_PassesResourceLimitsToExecutor(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	opts := JobOptions{MemoryLimit: 2 << 30, CPULimit: 1.5}
+	job, _, err := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", opts, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	limits := executor.LastOptions().Limits
+	if limits.MemoryBytes != 2<<30 || limits.CPUs != 1.5 {
+		t.Errorf("unexpected limits passed to executor: %+v", limits)
+	}
+	if executor.LastOptions().RunID != job.ID+"-1" {
+		t.Errorf("expected run ID %s-1, got %s", job.ID, executor.LastOptions().RunID)
+	}
+
+	resp := jm.jobToResponse(job)
+	if resp.MemoryLimit != 2<<30 || resp.CPULimit != 1.5 {
+		t.Errorf("unexpected limits in response: %d, %v", resp.MemoryLimit, resp.CPULimit)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Priority
+		wantErr  bool
+	}{
+		{"", PriorityNormal, false},
+		{"low", PriorityLow, false},
+		{"normal", PriorityNormal, false},
+		{"high", PriorityHigh, false},
+		{"urgent", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			p, err := ParsePriority(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePriority(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if p != tt.expected {
+				t.Errorf("ParsePriority(%q) = %q, expected %q", tt.input, p, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPriority_Nice(t *testing.T) {
+	if PriorityLow.Nice() <= PriorityNormal.Nice() {
+		t.Error("low priority should have a higher nice value than normal")
+	}
+	if PriorityHigh.Nice() >= PriorityNormal.Nice() {
+		t.Error("high priority should have a lower nice value than normal")
+	}
+	if PriorityNormal.Nice() != 0 {
+		t.Errorf("expected normal nice 0, got %d", PriorityNormal.Nice())
+	}
+}
+
 func TestJobManager_AddJob_DifferentWorkdir_CreatesSeparateJob(t *testing.T) {
 	tmpDir := t.TempDir()
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job1, _, _ := jm.AddJob([]string{"echo"}, "/workdir1", "", false, nil)
-	job2, _, _ := jm.AddJob([]string{"echo"}, "/workdir2", "", false, nil)
+	job1, _, _ := jm.AddJob([]string{"echo"}, "/workdir1", JobOptions{}, nil)
+	job2, _, _ := jm.AddJob([]string{"echo"}, "/workdir2", JobOptions{}, nil)
 
 	if job1.ID == job2.ID {
 		t.Error("different workdirs should create different jobs")
@@ -325,7 +494,7 @@ func TestJobManager_AddJob_EmptyCommand(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	_, _, err := jm.AddJob([]string{}, "/workdir", "", false, nil)
+	_, _, err := jm.AddJob([]string{}, "/workdir", JobOptions{}, nil)
 	if err == nil {
 		t.Error("expected error for empty command")
 	}
@@ -338,7 +507,7 @@ func TestJobManager_AddJob_ExecutorError(t *testing.T) {
 
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	_, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	_, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err == nil {
 		t.Error("expected error when executor fails")
 	}
@@ -349,7 +518,7 @@ func TestJobManager_GetJob(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Get existing job
 	retrieved, err := jm.GetJob(job.ID)
@@ -379,9 +548,9 @@ func TestJobManager_ListJobs(t *testing.T) {
 	}
 
 	// Add jobs
-	job1, _, _ := jm.AddJob([]string{"cmd1"}, "/workdir1", "", false, nil)
+	job1, _, _ := jm.AddJob([]string{"cmd1"}, "/workdir1", JobOptions{}, nil)
 	time.Sleep(time.Millisecond)
-	job2, _, _ := jm.AddJob([]string{"cmd2"}, "/workdir2", "", false, nil)
+	job2, _, _ := jm.AddJob([]string{"cmd2"}, "/workdir2", JobOptions{}, nil)
 
 	// List all - AddJob starts runs, so sorted by most recent run (job2 was added last)
 	jobs = jm.ListJobs("")
@@ -397,7 +566,7 @@ func TestJobManager_ListJobs(t *testing.T) {
 	time.Sleep(50 * time.Millisecond)
 
 	// Start job1 - now it should appear first (most recent run)
-	err := jm.StartJob(job1.ID, nil)
+	err := jm.StartJob(job1.ID, nil, EnvSourceClient, Origin{})
 	if err != nil {
 		t.Fatalf("failed to start job1: %v", err)
 	}
@@ -411,7 +580,7 @@ func TestJobManager_ListJobs(t *testing.T) {
 	executor.StopAll()
 	time.Sleep(50 * time.Millisecond)
 
-	err = jm.StartJob(job2.ID, nil)
+	err = jm.StartJob(job2.ID, nil, EnvSourceClient, Origin{})
 	if err != nil {
 		t.Fatalf("failed to start job2: %v", err)
 	}
@@ -436,7 +605,7 @@ func TestJobManager_FindJobByCommand(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo", "hello"}, "/workdir", JobOptions{}, nil)
 
 	// Find existing
 	found := jm.FindJobByCommand([]string{"echo", "hello"}, "/workdir")
@@ -469,7 +638,7 @@ func TestJobManager_RemoveJob(t *testing.T) {
 
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Stop the fake process first
 	executor.LastHandle().Stop()
@@ -480,7 +649,7 @@ func TestJobManager_RemoveJob(t *testing.T) {
 	events = nil // Clear events
 
 	// Remove job
-	err := jm.RemoveJob(job.ID)
+	err := jm.RemoveJob(job.ID, false)
 	if err != nil {
 		t.Fatalf("RemoveJob failed: %v", err)
 	}
@@ -502,10 +671,10 @@ func TestJobManager_RemoveJob_RunningFails(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Process is still "running" (not stopped in fake)
-	err := jm.RemoveJob(job.ID)
+	err := jm.RemoveJob(job.ID, false)
 	if err == nil {
 		t.Error("expected error when removing running job")
 	}
@@ -520,7 +689,7 @@ func TestJobManager_RemoveRun(t *testing.T) {
 
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Get the run ID
 	runs, err := jm.ListRunsForJob(job.ID)
@@ -583,7 +752,7 @@ func TestJobManager_RemoveRun_RunningFails(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Get the run ID while it's still running
 	runs, err := jm.ListRunsForJob(job.ID)
@@ -615,12 +784,12 @@ func TestJobManager_RemoveRun_UpdatesStats(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
 	// Add a job and let the first run complete
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
 	// Restart to create a second run
-	jm.RestartJob(job.ID, nil)
+	jm.RestartJob(job.ID, nil, EnvSourceClient, Origin{})
 	executor.LastHandle().Stop()
 	time.Sleep(10 * time.Millisecond)
 
@@ -667,7 +836,7 @@ func TestJobManager_StartJob(t *testing.T) {
 
 	jm := NewJobManagerWithExecutor(tmpDir, onEvent, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Stop the job first
 	executor.LastHandle().Stop()
@@ -677,7 +846,7 @@ func TestJobManager_StartJob(t *testing.T) {
 	startCount := executor.StartCount()
 
 	// Start it again with nil environment
-	err := jm.StartJob(job.ID, nil)
+	err := jm.StartJob(job.ID, nil, EnvSourceClient, Origin{})
 	if err != nil {
 		t.Fatalf("StartJob failed: %v", err)
 	}
@@ -714,10 +883,10 @@ func TestJobManager_StartJob_AlreadyRunning(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Try to start while still running
-	err := jm.StartJob(job.ID, nil)
+	err := jm.StartJob(job.ID, nil, EnvSourceClient, Origin{})
 	if err == nil {
 		t.Error("expected error when starting running job")
 	}
@@ -729,9 +898,9 @@ func TestJobManager_StopAll(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Create some jobs
-	job1, _, _ := jm.AddJob([]string{"cmd1"}, "/workdir", "", false, nil)
+	job1, _, _ := jm.AddJob([]string{"cmd1"}, "/workdir", JobOptions{}, nil)
 	time.Sleep(2 * time.Millisecond) // Ensure unique job IDs
-	job2, _, _ := jm.AddJob([]string{"cmd2"}, "/workdir", "", false, nil)
+	job2, _, _ := jm.AddJob([]string{"cmd2"}, "/workdir", JobOptions{}, nil)
 
 	// Verify jobs are running
 	if job1.CurrentRunID == nil {
@@ -741,7 +910,9 @@ func TestJobManager_StopAll(t *testing.T) {
 		t.Error("expected job2 to have a current run")
 	}
 
-	// Stop all jobs
+	// Stop all jobs. The fake processes ignore signals, so end them as
+	// signals would.
+	time.AfterFunc(50*time.Millisecond, executor.StopAll)
 	stopped := jm.StopAll()
 
 	// Jobs were running, so we expect 2 stopped
@@ -749,8 +920,10 @@ func TestJobManager_StopAll(t *testing.T) {
 		t.Errorf("expected 2 stopped, got %d", stopped)
 	}
 
-	// Wait for processes to stop
-	time.Sleep(10 * time.Millisecond)
+	// StopAll returns once the runs are recorded as stopped
+	if jm.GetCurrentRun(job1.ID) != nil || jm.GetCurrentRun(job2.ID) != nil {
+		t.Error("expected the jobs to be stopped when StopAll returns")
+	}
 
 	// Verify jobs still exist (StopAll doesn't remove jobs)
 	jobs := jm.ListJobs("")
@@ -764,7 +937,7 @@ func TestJobManager_Signal(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Signal is sent through syscall, not through process handle in current impl
 	// This test just verifies no error is returned for valid job
@@ -793,7 +966,7 @@ func TestJobManager_Signal_StoppedJob(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, _ := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 
 	// Stop the job
 	executor.LastHandle().Stop()
@@ -969,7 +1142,7 @@ func TestJobManager_PortsClearedOnStop(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -998,7 +1171,7 @@ func TestJobToResponse_RunningJobDoesNotShowPreviousExitCode(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1025,7 +1198,7 @@ func TestJobToResponse_RunningJobDoesNotShowPreviousExitCode(t *testing.T) {
 	}
 
 	// Start the job again
-	err = jm.StartJob(job.ID, nil)
+	err = jm.StartJob(job.ID, nil, EnvSourceClient, Origin{})
 	if err != nil {
 		t.Fatalf("StartJob failed: %v", err)
 	}
@@ -1049,7 +1222,7 @@ func TestJobToResponse_RestartedJobDoesNotShowPreviousExitCode(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1076,7 +1249,7 @@ func TestJobToResponse_RestartedJobDoesNotShowPreviousExitCode(t *testing.T) {
 	}
 
 	// Restart the job (it's already stopped, so this just starts a new run)
-	err = jm.RestartJob(job.ID, nil)
+	err = jm.RestartJob(job.ID, nil, EnvSourceClient, Origin{})
 	if err != nil {
 		t.Fatalf("RestartJob failed: %v", err)
 	}
@@ -1100,7 +1273,7 @@ func TestJobToResponse_AddJobAgainDoesNotShowPreviousExitCode(t *testing.T) {
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1127,7 +1300,7 @@ func TestJobToResponse_AddJobAgainDoesNotShowPreviousExitCode(t *testing.T) {
 	}
 
 	// Add the same command again (should reuse job and start new run)
-	job2, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job2, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1160,7 +1333,7 @@ func TestWaitForProcessExit_DoesNotClearCurrentRunIDIfNewRunStarted(t *testing.T
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
 	// Add a job - starts first run
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1178,7 +1351,7 @@ func TestWaitForProcessExit_DoesNotClearCurrentRunIDIfNewRunStarted(t *testing.T
 
 	// Start a new run (simulating what RestartJob does after stopping)
 	jm.mu.Lock()
-	newRun, err := jm.startRunLocked(job, nil)
+	newRun, err := jm.startRunLocked(job, nil, EnvSourceClient, "", Origin{})
 	jm.mu.Unlock()
 	if err != nil {
 		t.Fatalf("startRunLocked failed: %v", err)
@@ -1225,7 +1398,7 @@ func TestJobToResponse_IncludesPorts(t *testing.T) {
 	executor := NewFakeProcessExecutor()
 	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
 
-	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", "", false, nil)
+	job, _, err := jm.AddJob([]string{"echo"}, "/workdir", JobOptions{}, nil)
 	if err != nil {
 		t.Fatalf("AddJob failed: %v", err)
 	}
@@ -1252,3 +1425,47 @@ func TestJobToResponse_IncludesPorts(t *testing.T) {
 		t.Errorf("expected port 8080, got %d", resp.Ports[0].Port)
 	}
 }
+
+func TestJobManager_ListHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	first, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	second, _, _ := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	time.Sleep(10 * time.Millisecond)
+
+	jm.AddJob([]string{"echo"}, "/other", JobOptions{}, nil)
+
+	entries, total := jm.ListHistory("/workdir", "", 0, 0)
+	if total != 2 || len(entries) != 2 {
+		t.Fatalf("expected 2 runs, got %d (total %d)", len(entries), total)
+	}
+	if entries[0].JobID != second.ID || entries[1].JobID != first.ID {
+		t.Errorf("expected newest run first, got %s then %s", entries[0].JobID, entries[1].JobID)
+	}
+	if strings.Join(entries[1].Command, " ") != "make test" || entries[1].Workdir != "/workdir" {
+		t.Errorf("expected job command and workdir, got %v in %s", entries[1].Command, entries[1].Workdir)
+	}
+
+	// Pagination
+	entries, total = jm.ListHistory("", "", 1, 1)
+	if total != 3 || len(entries) != 1 || entries[0].JobID != second.ID {
+		t.Errorf("expected second newest run of 3, got %v (total %d)", entries, total)
+	}
+
+	entries, total = jm.ListHistory("", "", 5, 10)
+	if total != 3 || len(entries) != 0 {
+		t.Errorf("expected no runs past the end, got %d (total %d)", len(entries), total)
+	}
+
+	// Filter by label, across directories
+	labelled, _, _ := jm.AddJob([]string{"make", "lint"}, "/other", JobOptions{RunLabel: "pr-1"}, nil)
+	entries, total = jm.ListHistory("", "pr-1", 0, 0)
+	if total != 1 || entries[0].JobID != labelled.ID || entries[0].Label != "pr-1" {
+		t.Errorf("expected the labelled run, got %v (total %d)", entries, total)
+	}
+}
@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobOrder is an order of the job list, shared by gob list and the TUI
+type JobOrder string
+
+const (
+	JobOrderStarted  JobOrder = "started"  // most recently started first (the default)
+	JobOrderDuration JobOrder = "duration" // longest current or last run first
+	JobOrderStatus   JobOrder = "status"   // running, then failed, then stopped
+	JobOrderCommand  JobOrder = "command"  // alphabetically by command
+)
+
+// JobOrders are the job list orders, in the order the TUI cycles through them
+var JobOrders = []JobOrder{JobOrderStarted, JobOrderDuration, JobOrderStatus, JobOrderCommand}
+
+// ParseJobOrder parses the name of a job list order
+func ParseJobOrder(s string) (JobOrder, error) {
+	for _, o := range JobOrders {
+		if string(o) == s {
+			return o, nil
+		}
+	}
+	names := make([]string, len(JobOrders))
+	for i, o := range JobOrders {
+		names[i] = string(o)
+	}
+	return "", fmt.Errorf("invalid sort order %q: must be one of %s", s, strings.Join(names, ", "))
+}
+
+// JobSortKey holds the fields of a job the orders compare
+type JobSortKey struct {
+	StartedAt time.Time // start of the current or last run, zero if never run
+	StoppedAt time.Time // zero while running
+	Running   bool
+	ExitCode  *int
+	Command   string
+}
+
+// Duration is how long the current run has been going, or how long the last
+// run took
+func (k JobSortKey) Duration(now time.Time) time.Duration {
+	if k.StartedAt.IsZero() {
+		return 0
+	}
+	if k.Running || k.StoppedAt.IsZero() {
+		return now.Sub(k.StartedAt)
+	}
+	return k.StoppedAt.Sub(k.StartedAt)
+}
+
+// statusRank orders statuses: running, then failed, then stopped
+func (k JobSortKey) statusRank() int {
+	switch {
+	case k.Running:
+		return 0
+	case k.ExitCode != nil && *k.ExitCode != 0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// SortJobs sorts jobs in the given order, reversed if reverse is set. Jobs
+// the order does not tell apart keep their relative position.
+func SortJobs[T any](jobs []T, order JobOrder, reverse bool, key func(T) JobSortKey) {
+	now := time.Now()
+	less := func(a, b JobSortKey) bool {
+		switch order {
+		case JobOrderDuration:
+			return a.Duration(now) > b.Duration(now)
+		case JobOrderStatus:
+			return a.statusRank() < b.statusRank()
+		case JobOrderCommand:
+			return a.Command < b.Command
+		default:
+			return a.StartedAt.After(b.StartedAt)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		a, b := key(jobs[i]), key(jobs[j])
+		if reverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// SortKey returns the fields of the job the job list orders compare
+func (j JobResponse) SortKey() JobSortKey {
+	startedAt, _ := time.Parse(time.RFC3339, j.StartedAt)
+	stoppedAt, _ := time.Parse(time.RFC3339, j.StoppedAt)
+	return JobSortKey{
+		StartedAt: startedAt,
+		StoppedAt: stoppedAt,
+		Running:   j.Status == "running",
+		ExitCode:  j.ExitCode,
+		Command:   strings.Join(j.Command, " "),
+	}
+}
@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortJobs(t *testing.T) {
+	now := time.Now()
+	exit0, exit1 := 0, 1
+	jobs := []JobSortKey{
+		{Command: "make build", StartedAt: now.Add(-time.Minute), StoppedAt: now.Add(-50 * time.Second), ExitCode: &exit0},
+		{Command: "npm run dev", StartedAt: now.Add(-10 * time.Minute), Running: true},
+		{Command: "make test", StartedAt: now.Add(-2 * time.Minute), StoppedAt: now.Add(-time.Minute), ExitCode: &exit1},
+	}
+
+	tests := []struct {
+		order   JobOrder
+		reverse bool
+		want    []string
+	}{
+		{JobOrderStarted, false, []string{"make build", "make test", "npm run dev"}},
+		{JobOrderDuration, false, []string{"npm run dev", "make test", "make build"}},
+		{JobOrderStatus, false, []string{"npm run dev", "make test", "make build"}},
+		{JobOrderCommand, false, []string{"make build", "make test", "npm run dev"}},
+		{JobOrderCommand, true, []string{"npm run dev", "make test", "make build"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			sorted := append([]JobSortKey(nil), jobs...)
+			SortJobs(sorted, tt.order, tt.reverse, func(k JobSortKey) JobSortKey { return k })
+			for i, want := range tt.want {
+				if sorted[i].Command != want {
+					t.Errorf("position %d: expected %q, got %q", i, want, sorted[i].Command)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJobOrder(t *testing.T) {
+	if order, err := ParseJobOrder("duration"); err != nil || order != JobOrderDuration {
+		t.Errorf("expected duration, got %q (err %v)", order, err)
+	}
+	if _, err := ParseJobOrder("size"); err == nil {
+		t.Error("expected an unknown order to be rejected")
+	}
+}
@@ -1,27 +1,40 @@
 package daemon
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
 )
 
 // Logger is the daemon's structured logger
 var Logger *slog.Logger
 
+// The daemon log is rotated when the daemon starts and when it grows past
+// maxLogBytes, keeping logBackups older files: daemon.log.1 is the newest
+const (
+	maxLogBytes = 10 << 20
+	logBackups  = 3
+)
+
 func init() {
 	// Default to discarding logs until InitLogger is called
-	Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	Logger = slog.New(errorRecorder{slog.NewTextHandler(io.Discard, nil)})
 }
 
-// InitLogger initializes the daemon logger with the specified log file path.
-// If logPath is empty, logs are discarded.
-// The log file is created with mode 0600 (user-only).
-func InitLogger(logPath string) error {
+// InitLogger initializes the daemon logger with the specified log file path,
+// logging messages at level and above. If logPath is empty, logs are
+// discarded. The log file is created with mode 0600 (user-only), after
+// rotating the log of the previous daemon.
+func InitLogger(logPath string, level slog.Level) error {
 	var handler slog.Handler
 	opts := &slog.HandlerOptions{
-		Level: slog.LevelDebug, // Log everything, filter at output
+		Level: level,
 	}
 
 	if logPath == "" {
@@ -32,17 +45,85 @@ func InitLogger(logPath string) error {
 			return err
 		}
 
-		file, err := os.OpenFile(logPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		file, err := openRotatingFile(logPath, maxLogBytes)
 		if err != nil {
 			return err
 		}
 		handler = slog.NewTextHandler(file, opts)
 	}
 
-	Logger = slog.New(handler)
+	Logger = slog.New(errorRecorder{handler})
 	return nil
 }
 
+// lastError is the last message logged at error level, reported by health
+// requests
+var lastError struct {
+	sync.Mutex
+	err *DaemonError
+}
+
+// LastError returns the last message logged at error level, or nil
+func LastError() *DaemonError {
+	lastError.Lock()
+	defer lastError.Unlock()
+	if lastError.err == nil {
+		return nil
+	}
+	e := *lastError.err
+	return &e
+}
+
+// errorRecorder is a log handler recording the last error in lastError,
+// whatever the level of the log
+type errorRecorder struct {
+	slog.Handler
+}
+
+func (h errorRecorder) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.Handler.Enabled(ctx, level)
+}
+
+func (h errorRecorder) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		message := r.Message
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "error" {
+				message += ": " + a.Value.String()
+			}
+			return true
+		})
+		lastError.Lock()
+		lastError.err = &DaemonError{Time: r.Time.UTC().Format(time.RFC3339), Message: message}
+		lastError.Unlock()
+	}
+	if !h.Handler.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h errorRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return errorRecorder{h.Handler.WithAttrs(attrs)}
+}
+
+func (h errorRecorder) WithGroup(name string) slog.Handler {
+	return errorRecorder{h.Handler.WithGroup(name)}
+}
+
+// ParseDaemonLogLevel parses the level of the daemon log: debug, info, warn
+// or error. An empty name is info.
+func ParseDaemonLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid daemon log level %q (expected debug, info, warn or error)", name)
+	}
+	return level, nil
+}
+
 // GetLogPath returns the path to the daemon log file
 func GetLogPath() (string, error) {
 	runtimeDir, err := GetRuntimeDir()
@@ -51,3 +132,62 @@ func GetLogPath() (string, error) {
 	}
 	return filepath.Join(runtimeDir, "daemon.log"), nil
 }
+
+// LogBackupPath returns the path of the nth older daemon log, from 1 (the
+// newest) to logBackups
+func LogBackupPath(logPath string, n int) string {
+	return logPath + "." + strconv.Itoa(n)
+}
+
+// rotatingFile is a log file rotated once it grows past a limit
+type rotatingFile struct {
+	mu    sync.Mutex
+	path  string
+	limit int64
+	file  *os.File
+	size  int64
+}
+
+// openRotatingFile rotates the existing logs at path and opens a new one
+func openRotatingFile(path string, limit int64) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, limit: limit}
+	if err := r.rotateLocked(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write writes to the log, rotating it first if p would take it past the
+// limit
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.limit {
+		if err := r.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotateLocked shifts the log and its backups by one, dropping the oldest,
+// and opens a new empty log. Caller must hold r.mu.
+func (r *rotatingFile) rotateLocked() error {
+	if r.file != nil {
+		r.file.Close()
+	}
+	for n := logBackups - 1; n >= 1; n-- {
+		os.Rename(LogBackupPath(r.path, n), LogBackupPath(r.path, n+1))
+	}
+	os.Rename(r.path, LogBackupPath(r.path, 1))
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	r.file, r.size = file, 0
+	return nil
+}
@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	if err := os.WriteFile(path, []byte("previous daemon\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening rotates the log of the previous daemon
+	r, err := openRotatingFile(path, 20)
+	if err != nil {
+		t.Fatalf("openRotatingFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(LogBackupPath(path, 1)); string(data) != "previous daemon\n" {
+		t.Errorf("expected the previous log in daemon.log.1, got %q", data)
+	}
+
+	// Writes past the limit rotate, keeping logBackups older logs
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	r.file.Close()
+
+	want := map[string]string{
+		path:                   "fourth line\n",
+		LogBackupPath(path, 1): "third line\n",
+		LogBackupPath(path, 2): "second line\n",
+		LogBackupPath(path, 3): "first line\n",
+	}
+	for file, content := range want {
+		if data, _ := os.ReadFile(file); string(data) != content {
+			t.Errorf("expected %q in %s, got %q", content, filepath.Base(file), data)
+		}
+	}
+	if _, err := os.Stat(LogBackupPath(path, 4)); !os.IsNotExist(err) {
+		t.Error("expected no more than 3 older logs")
+	}
+}
+
+func TestInitLogger_Level(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	t.Cleanup(func() { Logger = slog.New(slog.NewTextHandler(io.Discard, nil)) })
+
+	if err := InitLogger(path, slog.LevelWarn); err != nil {
+		t.Fatalf("InitLogger failed: %v", err)
+	}
+	Logger.Info("port scan done")
+	Logger.Warn("port scan failed")
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "port scan done") || !strings.Contains(string(data), "port scan failed") {
+		t.Errorf("expected only the warning, got %q", data)
+	}
+}
+
+func TestParseDaemonLogLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"WARN", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"loud", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDaemonLogLevel(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseDaemonLogLevel(%q) = %v, %v, want %v (error %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
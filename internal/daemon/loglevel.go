@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// Log formats of a job's stdout
+const (
+	LogFormatText = "text" // plain output (default)
+	LogFormatJSON = "json" // one JSON object per line, with a level field
+)
+
+// Log levels, from least to most severe
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+	LevelFatal = "fatal"
+)
+
+// logLevels lists the levels in order of severity
+var logLevels = []string{LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal}
+
+// levelAliases maps level names used by common loggers to a level
+var levelAliases = map[string]string{
+	"trace":    LevelTrace,
+	"debug":    LevelDebug,
+	"info":     LevelInfo,
+	"notice":   LevelInfo,
+	"warn":     LevelWarn,
+	"warning":  LevelWarn,
+	"error":    LevelError,
+	"err":      LevelError,
+	"fatal":    LevelFatal,
+	"panic":    LevelFatal,
+	"critical": LevelFatal,
+	"crit":     LevelFatal,
+}
+
+// levelKeys are the JSON fields checked for a level, in order
+var levelKeys = []string{"level", "lvl", "severity", "log.level", "levelname"}
+
+// ValidateLogFormat checks that a log format is known
+func ValidateLogFormat(format string) error {
+	if format == LogFormatText || format == LogFormatJSON {
+		return nil
+	}
+	return fmt.Errorf("invalid log format %q (expected text or json)", format)
+}
+
+// logFormatOrText returns the log format to store, defaulting to text
+func logFormatOrText(format string) string {
+	if format == "" {
+		return LogFormatText
+	}
+	return format
+}
+
+// ParseLevelName normalizes a level name given by a user
+func ParseLevelName(name string) (string, error) {
+	if level, ok := levelAliases[strings.ToLower(name)]; ok {
+		return level, nil
+	}
+	return "", fmt.Errorf("invalid log level %q (expected %s)", name, strings.Join(logLevels, ", "))
+}
+
+// ParseLogLevel returns the level of a JSON log line, or "" if the line is
+// not a JSON object or has no recognizable level. Numeric levels follow the
+// pino/bunyan convention (10 trace, 20 debug, 30 info, 40 warn, 50 error, 60 fatal).
+func ParseLogLevel(line string) string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return ""
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return ""
+	}
+
+	for _, key := range levelKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if level, ok := levelAliases[strings.ToLower(v)]; ok {
+				return level
+			}
+		case float64:
+			index := int(v)/10 - 1
+			if index >= 0 && index < len(logLevels) {
+				return logLevels[index]
+			}
+		}
+	}
+	return ""
+}
+
+// levelRank returns the severity of a level (-1 if unknown)
+func levelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// LevelAtLeast reports whether a level is at least as severe as min.
+// Unknown levels never match.
+func LevelAtLeast(level, min string) bool {
+	rank := levelRank(level)
+	return rank >= 0 && rank >= levelRank(min)
+}
+
+// filterLogLevel returns the lines of a JSON log file at or above a level,
+// and the number of lines skipped because they are too long to parse
+func filterLogLevel(path string, min string) ([]string, int, error) {
+	file, err := logfile.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	skipped := 0
+	err = scanLogLines(file, func(line []byte, truncated bool) bool {
+		if truncated {
+			skipped++
+		} else if LevelAtLeast(ParseLogLevel(string(line)), min) {
+			lines = append(lines, string(line))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read log: %w", err)
+	}
+	return lines, skipped, nil
+}
+
+// handleLogLines handles a log_lines request: the stdout lines of a job's
+// latest run at or above a level. Only jobs with JSON logs have levels.
+func (d *Daemon) handleLogLines(req *Request) *Response {
+	var p LogLinesPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+	jobID := d.jobManager.ResolveJobID(p.JobID)
+
+	level, err := ParseLevelName(p.Level)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	job, err := d.jobManager.GetJob(jobID)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	if job.LogFormat != LogFormatJSON {
+		return NewErrorResponse(fmt.Errorf("job %s does not have JSON logs (add it with --log-format json)", jobID))
+	}
+
+	data := LogLinesData{Lines: []string{}}
+
+	run := d.jobManager.GetLatestRun(jobID)
+	if run == nil {
+		return NewDataResponse(data)
+	}
+
+	lines, skipped, err := filterLogLevel(run.StdoutPath, level)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+	if lines != nil {
+		data.Lines = lines
+	}
+	data.Skipped = skipped
+	return NewDataResponse(data)
+}
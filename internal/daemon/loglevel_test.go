@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{`{"level":"error","msg":"boom"}`, LevelError},
+		{`{"level":"WARNING","msg":"careful"}`, LevelWarn},
+		{`{"severity":"info"}`, LevelInfo},
+		{`{"lvl":"dbug"}`, ""},
+		{`{"level":30,"msg":"pino info"}`, LevelInfo},
+		{`{"level":50}`, LevelError},
+		{`{"level":99}`, ""},
+		{`{"msg":"no level"}`, ""},
+		{`plain text`, ""},
+		{`{not json`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLogLevel(tt.line); got != tt.expected {
+			t.Errorf("ParseLogLevel(%q) = %q, want %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestLevelAtLeast(t *testing.T) {
+	if !LevelAtLeast(LevelError, LevelWarn) {
+		t.Error("expected error to be at least warn")
+	}
+	if !LevelAtLeast(LevelWarn, LevelWarn) {
+		t.Error("expected warn to be at least warn")
+	}
+	if LevelAtLeast(LevelInfo, LevelWarn) {
+		t.Error("expected info to be below warn")
+	}
+	if LevelAtLeast("", LevelTrace) {
+		t.Error("expected lines without a level to never match")
+	}
+}
+
+func TestParseLevelName(t *testing.T) {
+	if level, err := ParseLevelName("Warning"); err != nil || level != LevelWarn {
+		t.Errorf("expected warn, got %q (%v)", level, err)
+	}
+	if _, err := ParseLevelName("loud"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestJobManager_AddJob_LogFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), nil)
+
+	text, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+	if text.LogFormat != LogFormatText {
+		t.Errorf("expected default log format %q, got %q", LogFormatText, text.LogFormat)
+	}
+
+	structured, _, _ := jm.AddJob([]string{"node", "app.js"}, "/workdir", JobOptions{LogFormat: LogFormatJSON}, nil)
+	if structured.LogFormat != LogFormatJSON {
+		t.Errorf("expected log format %q, got %q", LogFormatJSON, structured.LogFormat)
+	}
+}
+
+func TestDaemon_handleLogLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), nil)
+	d := &Daemon{jobManager: jm}
+
+	job, _, _ := jm.AddJob([]string{"node", "app.js"}, "/workdir", JobOptions{LogFormat: LogFormatJSON}, nil)
+	run := jm.GetCurrentRun(job.ID)
+	os.WriteFile(run.StdoutPath, []byte(`{"level":"info","msg":"listening"}
+{"level":"error","msg":"boom"}
+not json
+{"level":"error","msg":"`+strings.Repeat("x", maxGrepLineSize)+`"}
+{"level":60,"msg":"dead"}
+`), 0644)
+
+	resp := d.handleLogLines(&Request{Type: RequestTypeLogLines, Payload: map[string]interface{}{"job_id": job.ID, "level": "error"}})
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+	lines := resp.Data["lines"].([]string)
+	if len(lines) != 2 || lines[0] != `{"level":"error","msg":"boom"}` || lines[1] != `{"level":60,"msg":"dead"}` {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+	if skipped, _ := resp.Data["skipped"].(int); skipped != 1 {
+		t.Errorf("expected the too long line skipped, got %v", resp.Data["skipped"])
+	}
+
+	// Jobs with text logs have no levels
+	text, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+	resp = d.handleLogLines(&Request{Type: RequestTypeLogLines, Payload: map[string]interface{}{"job_id": text.ID, "level": "error"}})
+	if resp.Success {
+		t.Error("expected error for job without JSON logs")
+	}
+
+	// Invalid level
+	resp = d.handleLogLines(&Request{Type: RequestTypeLogLines, Payload: map[string]interface{}{"job_id": job.ID, "level": "loud"}})
+	if resp.Success {
+		t.Error("expected error for invalid level")
+	}
+}
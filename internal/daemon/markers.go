@@ -0,0 +1,133 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Kinds of run markers
+const (
+	MarkerStarted  = "started"  // the run's process was started
+	MarkerReloaded = "reloaded" // the reload signal was sent (see gob reload)
+	MarkerSignal   = "signal"   // a signal was sent with gob signal
+	MarkerStuck    = "stuck"    // a client waiting for the run saw no output for a while
+	MarkerSlow     = "slow"     // the run passed its job's slow threshold
+)
+
+// RunMarker is a lifecycle event of a run, recorded without starting a new
+// run
+type RunMarker struct {
+	At     time.Time `json:"at"`
+	Kind   string    `json:"kind"`
+	Detail string    `json:"detail,omitempty"` // e.g. the signal sent
+	Offset int64     `json:"offset"`           // size of the stdout log when recorded
+}
+
+// String describes the marker, e.g. "reloaded (hangup)"
+func (m RunMarker) String() string {
+	if m.Detail == "" {
+		return m.Kind
+	}
+	return m.Kind + " (" + m.Detail + ")"
+}
+
+// newMarker returns a marker of a run recorded now, after the output written
+// so far
+func newMarker(run *Run, kind, detail string) RunMarker {
+	marker := RunMarker{At: time.Now().UTC(), Kind: kind, Detail: detail}
+	if info, err := os.Stat(run.StdoutPath); err == nil {
+		marker.Offset = info.Size()
+	}
+	return marker
+}
+
+// appendMarkerLocked records a marker on a run. Must be called with jm.mu
+// held for writing.
+func (jm *JobManager) appendMarkerLocked(run *Run, marker RunMarker) {
+	run.Markers = append(run.Markers, marker)
+
+	if jm.store != nil {
+		if err := jm.store.InsertRunMarker(run.ID, marker); err != nil {
+			Logger.Warn("failed to persist run marker", "run_id", run.ID, "kind", marker.Kind, "error", err)
+		}
+	}
+}
+
+// addMarkerLocked records a marker on a run and tells subscribers. Must be
+// called with jm.mu held for writing.
+func (jm *JobManager) addMarkerLocked(run *Run, kind, detail string) {
+	jm.appendMarkerLocked(run, newMarker(run, kind, detail))
+
+	runResp := runToResponse(run)
+	var jobResp JobResponse
+	if job, ok := jm.jobs[run.JobID]; ok {
+		jobResp = jm.jobToResponse(job)
+	}
+	jm.emitEvent(Event{
+		Type:            EventTypeRunUpdated,
+		JobID:           run.JobID,
+		Job:             jobResp,
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+}
+
+// MarkStuck records a stuck marker on the job's current run
+func (jm *JobManager) MarkStuck(jobID string) (*Run, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	if job.CurrentRunID == nil {
+		return nil, fmt.Errorf("job %s is not running", jobID)
+	}
+
+	run := jm.runs[*job.CurrentRunID]
+	jm.addMarkerLocked(run, MarkerStuck, "")
+	return run, nil
+}
+
+// InsertMarkers inserts a line for each marker into content, the stdout log
+// of the markers' run or a line by line transformation of it (e.g. with
+// timestamps). A marker goes after the lines complete when it was recorded,
+// found from its offset in raw, the log as stored. line formats a marker,
+// without the newline.
+func InsertMarkers(raw, content string, markers []RunMarker, line func(RunMarker) string) string {
+	if len(markers) == 0 {
+		return content
+	}
+
+	// Number of complete lines before each marker
+	before := make([]int, len(markers))
+	for i, marker := range markers {
+		before[i] = strings.Count(raw[:min(max(marker.Offset, 0), int64(len(raw)))], "\n")
+	}
+
+	var result strings.Builder
+	next := 0
+	lineStart := true
+	for i, l := range strings.SplitAfter(content, "\n") {
+		for ; next < len(markers) && before[next] <= i; next++ {
+			result.WriteString(line(markers[next]) + "\n")
+		}
+		result.WriteString(l)
+		if l != "" {
+			lineStart = strings.HasSuffix(l, "\n")
+		}
+	}
+
+	// Markers after a last line without a newline, or past the content
+	if next < len(markers) && !lineStart {
+		result.WriteString("\n")
+	}
+	for ; next < len(markers); next++ {
+		result.WriteString(line(markers[next]) + "\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,83 @@
+package daemon
+
+import (
+	"os"
+	"testing"
+)
+
+func TestInsertMarkers(t *testing.T) {
+	line := func(m RunMarker) string { return "-- " + m.String() }
+	raw := "one\ntwo\nthree\n"
+
+	tests := []struct {
+		name     string
+		content  string
+		markers  []RunMarker
+		expected string
+	}{
+		{"no markers", raw, nil, raw},
+		{
+			"between lines",
+			raw,
+			[]RunMarker{{Kind: MarkerStarted}, {Kind: MarkerSignal, Detail: "hangup", Offset: 4}},
+			"-- started\none\n-- signal (hangup)\ntwo\nthree\n",
+		},
+		{
+			"during a line",
+			raw,
+			[]RunMarker{{Kind: MarkerStuck, Offset: 6}},
+			"one\n-- stuck\ntwo\nthree\n",
+		},
+		{
+			"after the last line",
+			raw,
+			[]RunMarker{{Kind: MarkerReloaded, Offset: 14}},
+			"one\ntwo\nthree\n-- reloaded\n",
+		},
+		{
+			"in transformed content",
+			"1 one\n2 two\n3 three\n",
+			[]RunMarker{{Kind: MarkerReloaded, Offset: 8}},
+			"1 one\n2 two\n-- reloaded\n3 three\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InsertMarkers(raw, tt.content, tt.markers, line); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestJobManager_MarkStuck(t *testing.T) {
+	var events []Event
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), func(e Event) { events = append(events, e) }, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	run := jm.runs[*job.CurrentRunID]
+	if err := os.WriteFile(run.StdoutPath, []byte("building\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events = nil
+
+	if _, err := jm.MarkStuck(job.ID); err != nil {
+		t.Fatalf("MarkStuck failed: %v", err)
+	}
+	if len(run.Markers) != 2 || run.Markers[1].Kind != MarkerStuck || run.Markers[1].Offset != 9 {
+		t.Errorf("expected a stuck marker after the output, got %+v", run.Markers)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeRunUpdated {
+		t.Errorf("expected one run_updated event, got %v", events)
+	}
+
+	stopAndWait(t, jm, executor, job.ID)
+	if _, err := jm.MarkStuck(job.ID); err == nil {
+		t.Error("expected an error for a stopped job")
+	}
+}
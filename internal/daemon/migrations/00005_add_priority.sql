@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN priority TEXT NOT NULL DEFAULT 'normal';
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN priority;
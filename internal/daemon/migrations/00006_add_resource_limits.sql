@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN memory_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN cpu_limit REAL NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN cpu_limit;
+ALTER TABLE jobs DROP COLUMN memory_limit;
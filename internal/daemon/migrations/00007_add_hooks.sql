@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN on_start TEXT;
+ALTER TABLE jobs ADD COLUMN on_success TEXT;
+ALTER TABLE jobs ADD COLUMN on_failure TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN on_failure;
+ALTER TABLE jobs DROP COLUMN on_success;
+ALTER TABLE jobs DROP COLUMN on_start;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN stdin TEXT NOT NULL DEFAULT 'null';
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN stdin;
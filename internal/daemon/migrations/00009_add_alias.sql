@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN alias TEXT;
+CREATE UNIQUE INDEX idx_jobs_alias ON jobs(alias) WHERE alias IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_jobs_alias;
+ALTER TABLE jobs DROP COLUMN alias;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE job_tags (
+    job_id TEXT NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (job_id, tag)
+);
+
+CREATE INDEX idx_job_tags_tag ON job_tags(tag);
+
+-- +goose Down
+DROP INDEX idx_job_tags_tag;
+DROP TABLE job_tags;
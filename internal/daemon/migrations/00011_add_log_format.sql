@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN log_format TEXT NOT NULL DEFAULT 'text';
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN log_format;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Daemon events, kept for auditing and for subscribers that replay missed
+-- events. Not tied to jobs: events outlive the jobs they describe.
+CREATE TABLE events (
+    id INTEGER PRIMARY KEY,          -- the event's sequence number
+    type TEXT NOT NULL,
+    job_id TEXT NOT NULL,
+    workdir TEXT NOT NULL,
+    created_at TEXT NOT NULL,        -- RFC3339, UTC
+    event_json TEXT NOT NULL         -- the event as sent to subscribers
+);
+
+CREATE INDEX idx_events_created_at ON events(created_at);
+
+-- +goose Down
+DROP INDEX idx_events_created_at;
+DROP TABLE events;
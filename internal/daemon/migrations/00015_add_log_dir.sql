@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN log_dir TEXT;
+ALTER TABLE runs ADD COLUMN log_bytes INTEGER;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN log_bytes;
+ALTER TABLE jobs DROP COLUMN log_dir;
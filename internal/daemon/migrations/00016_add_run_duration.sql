@@ -0,0 +1,9 @@
+-- +goose Up
+-- Exact duration of stopped runs: started_at and stopped_at only keep seconds.
+-- Runs stopped before are measured from their timestamps.
+ALTER TABLE runs ADD COLUMN duration_ms INTEGER;
+UPDATE runs SET duration_ms = CAST((julianday(stopped_at) - julianday(started_at)) * 86400000 AS INTEGER)
+WHERE stopped_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN duration_ms;
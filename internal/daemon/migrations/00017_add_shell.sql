@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN shell TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN shell;
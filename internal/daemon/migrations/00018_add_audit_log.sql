@@ -0,0 +1,19 @@
+-- +goose Up
+-- Requests that change state, with the client that sent them and their
+-- result, for finding out what an agent (or anyone) did to the jobs
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY,
+    created_at TEXT NOT NULL,        -- RFC3339, UTC
+    client TEXT NOT NULL,
+    type TEXT NOT NULL,
+    target TEXT NOT NULL,            -- job IDs, command or selection
+    success INTEGER NOT NULL,
+    error TEXT,
+    duration_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- +goose Down
+DROP INDEX idx_audit_log_created_at;
+DROP TABLE audit_log;
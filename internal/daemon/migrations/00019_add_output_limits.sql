@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN output_head INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN output_tail INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN output_tail;
+ALTER TABLE jobs DROP COLUMN output_head;
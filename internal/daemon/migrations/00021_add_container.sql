@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN container TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN container;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN dev_env TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN dev_env;
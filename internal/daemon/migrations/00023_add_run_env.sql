@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN env_overrides_json TEXT;
+ALTER TABLE runs ADD COLUMN env_source TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN env_source;
+ALTER TABLE jobs DROP COLUMN env_overrides_json;
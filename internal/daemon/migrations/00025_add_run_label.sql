@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE runs ADD COLUMN label TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN label;
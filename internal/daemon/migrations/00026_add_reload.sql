@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN reload_signal INTEGER NOT NULL DEFAULT 0;
+
+-- Lifecycle events of a run, e.g. a reload, in the order they happened
+CREATE TABLE run_markers (
+    id INTEGER PRIMARY KEY,
+    run_id TEXT NOT NULL REFERENCES runs(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL         -- RFC3339 with nanoseconds, UTC
+);
+
+CREATE INDEX idx_run_markers_run_id ON run_markers(run_id);
+
+-- +goose Down
+DROP INDEX idx_run_markers_run_id;
+DROP TABLE run_markers;
+ALTER TABLE jobs DROP COLUMN reload_signal;
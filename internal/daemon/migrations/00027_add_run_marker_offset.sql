@@ -0,0 +1,7 @@
+-- +goose Up
+-- Size of the run's stdout log when the marker was recorded, to show the
+-- marker among the output lines
+ALTER TABLE run_markers ADD COLUMN stdout_offset INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE run_markers DROP COLUMN stdout_offset;
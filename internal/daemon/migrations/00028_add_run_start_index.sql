@@ -0,0 +1,13 @@
+-- +goose Up
+-- Runs of a job in start order, for the history of jobs with many runs. It
+-- covers the queries by job_id, so that index is no longer needed.
+CREATE INDEX idx_runs_job_started_at ON runs(job_id, started_at);
+DROP INDEX idx_runs_job_id;
+
+-- Jobs of a directory, for exports and history filtered by directory
+CREATE INDEX idx_jobs_workdir ON jobs(workdir);
+
+-- +goose Down
+DROP INDEX idx_jobs_workdir;
+CREATE INDEX idx_runs_job_id ON runs(job_id);
+DROP INDEX idx_runs_job_started_at;
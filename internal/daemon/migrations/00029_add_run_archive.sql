@@ -0,0 +1,7 @@
+-- +goose Up
+-- Runs archived to cold storage: the daemon no longer loads them, and their
+-- logs are gzipped. The statistics of their job still count them.
+ALTER TABLE runs ADD COLUMN archived_at TEXT; -- RFC3339, NULL if not archived
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN archived_at;
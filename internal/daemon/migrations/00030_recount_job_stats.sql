@@ -0,0 +1,40 @@
+-- +goose Up
+-- Recount the statistics of jobs from their runs, archived ones included.
+-- The totals recalculated when stats were split by outcome (00003) only had
+-- whole seconds, and runs found dead after a crash were never counted.
+-- Killed runs only count in run_count.
+UPDATE runs SET duration_ms = CAST((julianday(stopped_at) - julianday(started_at)) * 86400000 AS INTEGER)
+WHERE stopped_at IS NOT NULL AND duration_ms IS NULL;
+
+UPDATE jobs SET
+    run_count = (
+        SELECT COUNT(*) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL
+    ),
+    success_count = (
+        SELECT COUNT(*) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL AND runs.exit_code = 0
+    ),
+    failure_count = (
+        SELECT COUNT(*) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL AND runs.exit_code != 0
+    ),
+    success_total_duration_ms = COALESCE((
+        SELECT SUM(duration_ms) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL AND runs.exit_code = 0
+    ), 0),
+    failure_total_duration_ms = COALESCE((
+        SELECT SUM(duration_ms) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL AND runs.exit_code != 0
+    ), 0),
+    min_duration_ms = (
+        SELECT MIN(duration_ms) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL
+    ),
+    max_duration_ms = (
+        SELECT MAX(duration_ms) FROM runs
+        WHERE runs.job_id = jobs.id AND runs.stopped_at IS NOT NULL
+    );
+
+-- +goose Down
+-- The recounted statistics are kept
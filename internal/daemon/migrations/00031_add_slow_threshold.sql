@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN slow_threshold TEXT; -- e.g. 5m or 2x (times the p90 duration)
+ALTER TABLE jobs ADD COLUMN on_slow TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN on_slow;
+ALTER TABLE jobs DROP COLUMN slow_threshold;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- The load and power state of the machine when a run started, unknown for
+-- older runs
+ALTER TABLE runs ADD COLUMN load_avg REAL;
+ALTER TABLE runs ADD COLUMN on_battery INTEGER;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN on_battery;
+ALTER TABLE runs DROP COLUMN load_avg;
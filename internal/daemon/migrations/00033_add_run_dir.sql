@@ -0,0 +1,9 @@
+-- +goose Up
+-- The directory runs of a job execute in when not its workdir (gob run
+-- --workdir), and the directory each run executed in
+ALTER TABLE jobs ADD COLUMN run_dir TEXT;
+ALTER TABLE runs ADD COLUMN dir TEXT;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN dir;
+ALTER TABLE jobs DROP COLUMN run_dir;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- The project a job's workdir is in, for jobs that follow their project to
+-- other checkouts (see portable.go)
+ALTER TABLE jobs ADD COLUMN project_id TEXT;
+ALTER TABLE jobs ADD COLUMN project_path TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN project_path;
+ALTER TABLE jobs DROP COLUMN project_id;
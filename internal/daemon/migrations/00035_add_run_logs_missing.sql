@@ -0,0 +1,6 @@
+-- +goose Up
+-- Runs whose log files were deleted outside of gob, found by the log janitor
+ALTER TABLE runs ADD COLUMN logs_missing INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN logs_missing;
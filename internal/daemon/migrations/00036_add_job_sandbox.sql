@@ -0,0 +1,6 @@
+-- +goose Up
+-- The sandbox runs of a job start in (gob add --sandbox): project or readonly
+ALTER TABLE jobs ADD COLUMN sandbox TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN sandbox;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- How a request needing approval was decided: approved by <client>, denied by <client> or timed out
+ALTER TABLE audit_log ADD COLUMN approval TEXT;
+
+-- +goose Down
+ALTER TABLE audit_log DROP COLUMN approval;
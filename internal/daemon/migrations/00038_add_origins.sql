@@ -0,0 +1,13 @@
+-- +goose Up
+-- The clients that created jobs and started runs: their name (GOB_CLIENT or
+-- the parent process) and type (cli, tui or api)
+ALTER TABLE jobs ADD COLUMN created_by TEXT;
+ALTER TABLE jobs ADD COLUMN created_via TEXT;
+ALTER TABLE runs ADD COLUMN started_by TEXT;
+ALTER TABLE runs ADD COLUMN started_via TEXT;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN started_via;
+ALTER TABLE runs DROP COLUMN started_by;
+ALTER TABLE jobs DROP COLUMN created_via;
+ALTER TABLE jobs DROP COLUMN created_by;
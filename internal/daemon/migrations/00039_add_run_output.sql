@@ -0,0 +1,12 @@
+-- +goose Up
+-- Bytes and lines of the output of runs, counted when they stop
+ALTER TABLE runs ADD COLUMN stdout_bytes INTEGER;
+ALTER TABLE runs ADD COLUMN stdout_lines INTEGER;
+ALTER TABLE runs ADD COLUMN stderr_bytes INTEGER;
+ALTER TABLE runs ADD COLUMN stderr_lines INTEGER;
+
+-- +goose Down
+ALTER TABLE runs DROP COLUMN stderr_lines;
+ALTER TABLE runs DROP COLUMN stderr_bytes;
+ALTER TABLE runs DROP COLUMN stdout_lines;
+ALTER TABLE runs DROP COLUMN stdout_bytes;
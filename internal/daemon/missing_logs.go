@@ -0,0 +1,130 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// Missing logs.
+//
+// Log files can be deleted outside of gob, leaving runs whose logs can't be
+// shown. A janitor looks for them when the daemon starts and then
+// periodically, and flags them as logs_missing (clearing the flag if the
+// files come back). 'gob cleanup --logs' removes the flagged runs, so the
+// database matches the filesystem again.
+
+// logJanitorInterval is how often the janitor looks for missing log files
+const logJanitorInterval = 10 * time.Minute
+
+// runLogsMissing reports whether the stdout or stderr log of a run is gone,
+// compressed or not. Timestamp indexes are optional and not checked.
+func runLogsMissing(run *Run) bool {
+	for _, path := range []string{run.StdoutPath, run.StderrPath} {
+		if _, err := logfile.Stat(path); path != "" && errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRunLogs flags the stopped runs of jobs in workdir (all directories if
+// empty) whose log files are missing, and clears the flag of runs whose files
+// are back. Returns the number of runs flagged as missing.
+func (jm *JobManager) CheckRunLogs(workdir string) int {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	return jm.checkRunLogsLocked(workdir)
+}
+
+// checkRunLogsLocked is CheckRunLogs. Caller must hold the write lock.
+func (jm *JobManager) checkRunLogsLocked(workdir string) int {
+	missing := 0
+	for _, run := range jm.runs {
+		job, ok := jm.jobs[run.JobID]
+		if !ok || (workdir != "" && job.Workdir != workdir) || run.Status == "running" {
+			continue
+		}
+		gone := runLogsMissing(run)
+		if gone {
+			missing++
+		}
+		if gone == run.LogsMissing {
+			continue
+		}
+
+		run.LogsMissing = gone
+		if jm.store != nil {
+			if err := jm.store.UpdateRun(run); err != nil {
+				Logger.Warn("failed to update run", "id", run.ID, "error", err)
+			}
+		}
+		runResp := runToResponse(run)
+		jm.emitEvent(Event{
+			Type:            EventTypeRunUpdated,
+			JobID:           run.JobID,
+			Job:             jm.jobToResponse(job),
+			Run:             &runResp,
+			JobCount:        len(jm.jobs),
+			RunningJobCount: jm.countRunningJobsLocked(),
+		})
+	}
+	return missing
+}
+
+// RemoveRunsWithMissingLogs removes the stopped runs of jobs in workdir (all
+// directories if empty) whose log files are missing, with what is left of
+// their logs. Returns the number of runs removed.
+func (jm *JobManager) RemoveRunsWithMissingLogs(workdir string) int {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	jm.checkRunLogsLocked(workdir)
+
+	var flagged []*Run
+	for _, run := range jm.runs {
+		job, ok := jm.jobs[run.JobID]
+		if ok && (workdir == "" || job.Workdir == workdir) && run.LogsMissing {
+			flagged = append(flagged, run)
+		}
+	}
+	for _, run := range flagged {
+		jm.removeRunLocked(run)
+	}
+	return len(flagged)
+}
+
+// watchRunLogs flags runs with missing log files until the daemon shuts down
+func (d *Daemon) watchRunLogs() {
+	d.logJanitor.Store(true)
+	defer d.logJanitor.Store(false)
+
+	if n := d.jobManager.CheckRunLogs(""); n > 0 {
+		Logger.Info("found runs with missing logs", "runs", n)
+	}
+
+	ticker := time.NewTicker(logJanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+			d.jobManager.CheckRunLogs("")
+		}
+	}
+}
+
+// handleCleanupLogs handles a cleanup_logs request
+func (d *Daemon) handleCleanupLogs(req *Request) *Response {
+	var p CleanupLogsPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+
+	removed := d.jobManager.RemoveRunsWithMissingLogs(p.Workdir)
+	return NewDataResponse(CleanupLogsData{RunsRemoved: removed})
+}
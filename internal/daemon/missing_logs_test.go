@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJobManager_CheckRunLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	var events []Event
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, executor, store)
+
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	run := jm.GetCurrentRun(job.ID)
+	for _, path := range []string{run.StdoutPath, run.StderrPath} {
+		if err := os.WriteFile(path, []byte("output\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Running runs are left alone
+	os.Remove(run.StdoutPath)
+	if n := jm.CheckRunLogs(""); n != 0 || run.LogsMissing {
+		t.Errorf("expected a running run not to be flagged, got %d", n)
+	}
+
+	stopAndWait(t, jm, executor, job.ID)
+	events = nil
+
+	if n := jm.CheckRunLogs("/other"); n != 0 {
+		t.Errorf("expected no runs in another directory, got %d", n)
+	}
+	if n := jm.CheckRunLogs("/workdir"); n != 1 || !run.LogsMissing {
+		t.Fatalf("expected the run flagged, got %d", n)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeRunUpdated || !events[0].Run.LogsMissing {
+		t.Errorf("expected one run_updated event with logs_missing, got %v", events)
+	}
+
+	// The flag is persisted
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || !runs[0].LogsMissing {
+		t.Errorf("expected the stored run flagged, got %+v", runs)
+	}
+
+	// Files coming back clear the flag
+	events = nil
+	if err := os.WriteFile(run.StdoutPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n := jm.CheckRunLogs(""); n != 0 || run.LogsMissing {
+		t.Errorf("expected the flag cleared, got %d", n)
+	}
+	if len(events) != 1 || events[0].Run.LogsMissing {
+		t.Errorf("expected one run_updated event clearing logs_missing, got %v", events)
+	}
+}
+
+func TestJobManager_RemoveRunsWithMissingLogs(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	first := jm.GetCurrentRun(job.ID)
+	stopAndWait(t, jm, executor, job.ID)
+
+	if err := jm.StartJob(job.ID, nil, "", Origin{}); err != nil {
+		t.Fatalf("StartJob failed: %v", err)
+	}
+	second := jm.GetCurrentRun(job.ID)
+	stopAndWait(t, jm, executor, job.ID)
+	os.Remove(first.StdoutPath)
+
+	// Only the second run kept its logs
+	for _, path := range []string{second.StdoutPath, second.StderrPath} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := jm.RemoveRunsWithMissingLogs("/workdir"); n != 1 {
+		t.Fatalf("expected 1 run removed, got %d", n)
+	}
+	runs, _ := jm.ListRunsForJob(job.ID)
+	if len(runs) != 1 || runs[0].ID != second.ID {
+		t.Errorf("expected only %s left, got %v", second.ID, runs)
+	}
+	if job.RunCount != 1 {
+		t.Errorf("expected the remaining run counted, got %d runs", job.RunCount)
+	}
+}
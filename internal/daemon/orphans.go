@@ -0,0 +1,210 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Orphans are processes that escaped their run: they carry the ID of one of
+// the daemon's runs in their environment (see runIDEnvVar and
+// daemonIDEnvVar) but no running run tracks them, e.g. children re-parented
+// while the daemon was down. Processes of other daemons' runs are theirs.
+
+// Orphan is a run whose processes outlived it
+type Orphan struct {
+	RunID     string          `json:"run_id"`
+	JobID     string          `json:"job_id,omitempty"`  // empty if the job was removed
+	Command   []string        `json:"command,omitempty"` // of the job, empty if it was removed
+	Workdir   string          `json:"workdir,omitempty"` // of the job, or of the main process if the job was removed
+	Processes []OrphanProcess `json:"processes"`         // by PID
+}
+
+// OrphanProcess is a process of an orphan
+type OrphanProcess struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	Command string `json:"command"`
+}
+
+// MainPID returns the process the others descend from: the first whose
+// parent is not part of the orphan
+func (o Orphan) MainPID() int {
+	for _, p := range o.Processes {
+		if !slices.ContainsFunc(o.Processes, func(q OrphanProcess) bool { return q.PID == p.PPID }) {
+			return p.PID
+		}
+	}
+	return 0
+}
+
+// PIDs returns the PIDs of the processes of the orphan
+func (o Orphan) PIDs() []int {
+	pids := make([]int, len(o.Processes))
+	for i, p := range o.Processes {
+		pids[i] = p.PID
+	}
+	return pids
+}
+
+// FindOrphans returns the runs that are not running but still have
+// processes, oldest run first. Processes of running runs, including adopted
+// ones, children of the daemon and hooks with their children are not
+// orphans.
+// Processes whose environment can't be read (other users, unsupported
+// platforms) are skipped.
+func (jm *JobManager) FindOrphans() []Orphan {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+
+	// Processes carrying the ID of one of our runs, read without holding
+	// the lock
+	daemonMarker := daemonIDEnvVar + "=" + jm.daemonID
+	self := int32(os.Getpid())
+	var candidates []OrphanProcess
+	runIDs := make(map[int]string)
+	for _, proc := range procs {
+		if proc.Pid == self {
+			continue
+		}
+		ppid, err := proc.Ppid()
+		if err != nil || ppid == self || isZombie(int(proc.Pid)) {
+			continue
+		}
+		env, err := proc.Environ()
+		if err != nil || !slices.Contains(env, daemonMarker) {
+			continue
+		}
+		if slices.ContainsFunc(env, func(kv string) bool { return strings.HasPrefix(kv, hookEventEnvVar+"=") }) {
+			continue
+		}
+		for _, kv := range env {
+			if value, ok := strings.CutPrefix(kv, runIDEnvVar+"="); ok {
+				runIDs[int(proc.Pid)] = value
+			}
+		}
+		if runIDs[int(proc.Pid)] != "" {
+			command, _ := proc.Cmdline()
+			candidates = append(candidates, OrphanProcess{PID: int(proc.Pid), PPID: int(ppid), Command: command})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	tracked := make(map[int]bool)
+	for _, pid := range jm.runningPIDs() {
+		for _, pid := range getProcessTreePIDs(pid) {
+			tracked[pid] = true
+		}
+	}
+
+	jm.mu.RLock()
+	byRun := make(map[string]*Orphan)
+	for _, p := range candidates {
+		runID := runIDs[p.PID]
+		if tracked[p.PID] {
+			continue
+		}
+		if run, ok := jm.runs[runID]; ok && run.IsRunning() {
+			continue
+		}
+		o, ok := byRun[runID]
+		if !ok {
+			o = &Orphan{RunID: runID}
+			if run, ok := jm.runs[runID]; ok {
+				if job, ok := jm.jobs[run.JobID]; ok {
+					o.JobID, o.Command, o.Workdir = job.ID, job.Command, job.Workdir
+				}
+			}
+			byRun[runID] = o
+		}
+		o.Processes = append(o.Processes, p)
+	}
+	jm.mu.RUnlock()
+
+	orphans := make([]Orphan, 0, len(byRun))
+	for _, o := range byRun {
+		slices.SortFunc(o.Processes, func(a, b OrphanProcess) int { return a.PID - b.PID })
+		if o.Workdir == "" {
+			if proc, err := process.NewProcess(int32(o.MainPID())); err == nil {
+				o.Workdir, _ = proc.Cwd()
+			}
+		}
+		orphans = append(orphans, *o)
+	}
+	slices.SortFunc(orphans, func(a, b Orphan) int { return a.Processes[0].PID - b.Processes[0].PID })
+	return orphans
+}
+
+// runningPIDs returns the main processes of the running runs
+func (jm *JobManager) runningPIDs() []int {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+	var pids []int
+	for _, run := range jm.runs {
+		if run.IsRunning() {
+			pids = append(pids, run.PID)
+		}
+	}
+	return pids
+}
+
+// KillOrphans stops the processes of the given orphans, as stop does:
+// SIGTERM, escalating to SIGKILL, or SIGKILL right away with force. Runs
+// that are not orphans are skipped. It returns the orphans that were
+// stopped and the PIDs still running at the end.
+func (jm *JobManager) KillOrphans(runIDs []string, force bool) ([]Orphan, []int) {
+	var killed []Orphan
+	var pids []int
+	for _, o := range jm.FindOrphans() {
+		if slices.Contains(runIDs, o.RunID) {
+			killed = append(killed, o)
+			pids = append(pids, o.PIDs()...)
+		}
+	}
+	if len(pids) == 0 {
+		return killed, nil
+	}
+
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	survivors := waitProcessTrees(nil, pids, sig, stopTermTimeout)
+	if len(survivors) > 0 && !force {
+		survivors = waitProcessTrees(nil, survivors, syscall.SIGKILL, stopKillTimeout)
+	}
+	for _, o := range killed {
+		Logger.Info("killed orphan", "run", o.RunID, "pids", o.PIDs())
+	}
+	return killed, survivors
+}
+
+// handleOrphans handles an orphans request
+func (d *Daemon) handleOrphans(req *Request) *Response {
+	return NewDataResponse(OrphansData{Orphans: d.jobManager.FindOrphans()})
+}
+
+// handleKillOrphans handles a kill_orphans request
+func (d *Daemon) handleKillOrphans(req *Request) *Response {
+	var p KillOrphansPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if len(p.RunIDs) == 0 {
+		return NewErrorResponse(fmt.Errorf("missing run_ids"))
+	}
+
+	killed, survivors := d.jobManager.KillOrphans(p.RunIDs, p.Force)
+	if len(survivors) > 0 {
+		return NewErrorResponse(fmt.Errorf("%d processes survived SIGKILL: %v", len(survivors), survivors))
+	}
+	return NewDataResponse(OrphansData{Orphans: killed})
+}
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOrphan_MainPID(t *testing.T) {
+	o := Orphan{Processes: []OrphanProcess{{PID: 10, PPID: 12}, {PID: 11, PPID: 10}, {PID: 12, PPID: 1}}}
+	if got := o.MainPID(); got != 12 {
+		t.Errorf("expected the process whose parent is not in the orphan, got %d", got)
+	}
+}
+
+func TestJobManager_FindOrphans(t *testing.T) {
+	// The subshell exits, leaving its child re-parented with the run's marker
+	_, childPID := startTreeFixture(t, "tst-orphan-1", `(setsid sleep 300 & echo $! > "$PIDFILE")`)
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	jm.daemonID = fixtureDaemonID
+
+	var orphan *Orphan
+	deadline := time.Now().Add(5 * time.Second)
+	for orphan == nil && time.Now().Before(deadline) {
+		for _, o := range jm.FindOrphans() {
+			if o.RunID == "tst-orphan-1" {
+				orphan = &o
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if orphan == nil {
+		t.Skip("child was not re-parented or its environment can't be read")
+	}
+	if orphan.MainPID() != childPID || orphan.JobID != "" {
+		t.Errorf("unexpected orphan %+v", orphan)
+	}
+
+	// Another daemon leaves it alone
+	other := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	for _, o := range other.FindOrphans() {
+		if o.RunID == "tst-orphan-1" {
+			t.Error("expected another daemon not to take the run's processes for its orphans")
+		}
+	}
+	if killed, _ := other.KillOrphans([]string{"tst-orphan-1"}, true); len(killed) != 0 {
+		t.Errorf("expected another daemon not to kill them, got %+v", killed)
+	}
+
+	killed, survivors := jm.KillOrphans([]string{"tst-orphan-1", "tst-other-1"}, false)
+	if len(killed) != 1 || len(survivors) != 0 {
+		t.Fatalf("expected the orphan stopped, got %+v with survivors %v", killed, survivors)
+	}
+	if len(filterRunningPIDs([]int{childPID})) != 0 {
+		t.Error("expected the orphan's process to be stopped")
+	}
+}
@@ -0,0 +1,102 @@
+package daemon
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// HugeOutputLines is the number of lines from which clients warn before
+// printing the whole output of a run
+const HugeOutputLines = 10000
+
+// OutputStats counts the output of a run, recorded once it stops. A run
+// whose stderr goes to its stdout log counts everything as stdout.
+type OutputStats struct {
+	StdoutBytes int64 `json:"stdout_bytes"`
+	StdoutLines int64 `json:"stdout_lines"`
+	StderrBytes int64 `json:"stderr_bytes"`
+	StderrLines int64 `json:"stderr_lines"`
+}
+
+// countOutput returns the size of a log and its number of lines, a last
+// line without a trailing newline included. Missing logs are empty.
+func countOutput(path string) (size, lines int64) {
+	file, err := logfile.Open(path)
+	if err != nil {
+		return 0, 0
+	}
+	defer file.Close()
+
+	buf := make([]byte, 64*1024)
+	var last byte
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			size += int64(n)
+			lines += int64(bytes.Count(buf[:n], []byte{'\n'}))
+			last = buf[n-1]
+		}
+		if err != nil {
+			break
+		}
+	}
+	if size > 0 && last != '\n' {
+		lines++
+	}
+	return size, lines
+}
+
+// runOutputStats counts the output of a run
+func runOutputStats(run *Run) OutputStats {
+	var stats OutputStats
+	stats.StdoutBytes, stats.StdoutLines = countOutput(run.StdoutPath)
+	if run.StderrPath != run.StdoutPath {
+		stats.StderrBytes, stats.StderrLines = countOutput(run.StderrPath)
+	}
+	return stats
+}
+
+// finishRunLogs counts the output of a stopped run and compresses its large
+// logs, without holding the lock, then records both
+func (jm *JobManager) finishRunLogs(run *Run) {
+	output := runOutputStats(run)
+	compressed := jm.compressRunLogs(run)
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	// The run may have been removed meanwhile, leaving the copies behind
+	if jm.runs[run.ID] != run {
+		for _, path := range compressed {
+			os.Remove(path + logfile.CompressedSuffix)
+		}
+		return
+	}
+
+	run.Output = &output
+	if len(compressed) > 0 {
+		logBytes := runLogBytes(run)
+		run.LogBytes = &logBytes
+	}
+	if jm.store != nil {
+		if err := jm.store.UpdateRun(run); err != nil {
+			Logger.Warn("failed to update run", "id", run.ID, "error", err)
+		}
+	}
+
+	job, ok := jm.jobs[run.JobID]
+	if !ok {
+		return
+	}
+	runResp := runToResponse(run)
+	jm.emitEvent(Event{
+		Type:            EventTypeRunUpdated,
+		JobID:           run.JobID,
+		Job:             jm.jobToResponse(job),
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+}
@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountOutput(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		lines   int64
+	}{
+		{"", 0},
+		{"one\n", 1},
+		{"one\ntwo", 2},
+		{"\n\n\n", 3},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, "out.log")
+		os.WriteFile(path, []byte(tt.content), 0644)
+		if size, lines := countOutput(path); size != int64(len(tt.content)) || lines != tt.lines {
+			t.Errorf("countOutput(%q) = %d bytes, %d lines, want %d, %d", tt.content, size, lines, len(tt.content), tt.lines)
+		}
+	}
+
+	if size, lines := countOutput(filepath.Join(dir, "missing.log")); size != 0 || lines != 0 {
+		t.Errorf("expected a missing log to be empty, got %d bytes, %d lines", size, lines)
+	}
+}
+
+func TestJobManager_RecordsOutputStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, store)
+
+	job, _, _ := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	run := jm.GetCurrentRun(job.ID)
+	os.WriteFile(run.StdoutPath, []byte("compiling\ntesting\nok"), 0644)
+	os.WriteFile(run.StderrPath, []byte("warning: slow\n"), 0644)
+	stopAndWait(t, jm, executor, job.ID)
+	<-run.done
+
+	want := OutputStats{StdoutBytes: 20, StdoutLines: 3, StderrBytes: 14, StderrLines: 1}
+	jm.mu.RLock()
+	output, resp := run.Output, jm.jobToResponse(job)
+	jm.mu.RUnlock()
+	if output == nil || *output != want {
+		t.Fatalf("expected %+v recorded, got %+v", want, output)
+	}
+	if resp.Output == nil || *resp.Output != want {
+		t.Errorf("expected the job response to carry the output of its latest run, got %+v", resp.Output)
+	}
+
+	// The counts survive a restart
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, loaded := range runs {
+		if loaded.ID == run.ID && (loaded.Output == nil || *loaded.Output != want) {
+			t.Errorf("expected %+v stored, got %+v", want, loaded.Output)
+		}
+	}
+}
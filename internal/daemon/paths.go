@@ -8,23 +8,32 @@ import (
 	"github.com/adrg/xdg"
 )
 
-// GetRuntimeDir returns the runtime directory for gob daemon files
+// GetRuntimeDir returns the runtime directory for gob daemon files (the
+// project's .gob directory for an isolated daemon)
 func GetRuntimeDir() (string, error) {
+	if project := ProjectDir(); project != "" {
+		return filepath.Join(project, isolatedDirName), nil
+	}
 	return filepath.Join(xdg.RuntimeDir, "gob"), nil
 }
 
-// GetStateDir returns the state directory for persistent data (survives reboots)
+// GetStateDir returns the state directory for persistent data (survives
+// reboots), the project's .gob directory for an isolated daemon
 func GetStateDir() (string, error) {
+	if project := ProjectDir(); project != "" {
+		return filepath.Join(project, isolatedDirName), nil
+	}
 	return filepath.Join(xdg.StateHome, "gob"), nil
 }
 
-// GetSocketPath returns the path to the daemon Unix socket
+// GetSocketPath returns the address of the daemon's IPC endpoint: a Unix
+// socket in the runtime directory, or a named pipe on Windows
 func GetSocketPath() (string, error) {
 	runtimeDir, err := GetRuntimeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(runtimeDir, "daemon.sock"), nil
+	return socketPath(runtimeDir), nil
 }
 
 // GetPIDPath returns the path to the daemon PID file
@@ -36,6 +45,26 @@ func GetPIDPath() (string, error) {
 	return filepath.Join(runtimeDir, "daemon.pid"), nil
 }
 
+// GetGatewayInfoPath returns the path to the file describing the running
+// HTTP gateway (address and token), readable only by the user
+func GetGatewayInfoPath() (string, error) {
+	runtimeDir, err := GetRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runtimeDir, "gateway.json"), nil
+}
+
+// GetHandoverPath returns the path of the running runs left by a daemon
+// that handed over to a new one
+func GetHandoverPath() (string, error) {
+	runtimeDir, err := GetRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(runtimeDir, "handover.json"), nil
+}
+
 // GetDatabasePath returns the path to the SQLite database file
 func GetDatabasePath() (string, error) {
 	stateDir, err := GetStateDir()
@@ -64,6 +93,9 @@ func EnsureRuntimeDir() (string, error) {
 	if err := os.MkdirAll(runtimeDir, 0700); err != nil {
 		return "", fmt.Errorf("failed to create runtime directory: %w", err)
 	}
+	if ProjectDir() != "" {
+		ignoreIsolatedDir(runtimeDir)
+	}
 
 	return runtimeDir, nil
 }
@@ -78,6 +110,9 @@ func EnsureStateDir() (string, error) {
 	if err := os.MkdirAll(stateDir, 0700); err != nil {
 		return "", fmt.Errorf("failed to create state directory: %w", err)
 	}
+	if ProjectDir() != "" {
+		ignoreIsolatedDir(stateDir)
+	}
 
 	return stateDir, nil
 }
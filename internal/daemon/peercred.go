@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Peer credentials.
+//
+// The daemon's socket is only accessible by its user (0600), but a socket
+// whose permissions were changed, or a runtime directory shared by mistake,
+// would let other users start processes as that user. The daemon therefore
+// checks the credentials of every connection to its socket, and closes
+// connections from other users. Linux (SO_PEERCRED) and macOS
+// (LOCAL_PEERCRED) report them; elsewhere the socket permissions are all
+// there is.
+//
+// They also report the process that connected, which the approval policy
+// identifies requests by (see peerAncestry): unlike the client name a
+// request carries, it can't be made up.
+
+// errPeerCredUnsupported is returned where the platform can't tell who is
+// connected
+var errPeerCredUnsupported = errors.New("peer credentials are not supported on this platform")
+
+// daemonUID returns the user connections must come from
+var daemonUID = os.Getuid
+
+// maxAncestry bounds the processes peerAncestry walks up
+const maxAncestry = 64
+
+// checkPeer returns an error if conn comes from another user than the
+// daemon's, or else the process that connected (0 if unknown). Connections
+// that aren't Unix sockets, and platforms without peer credentials, are not
+// checked.
+func checkPeer(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, nil
+	}
+	uid, pid, err := peerCred(unixConn)
+	if errors.Is(err, errPeerCredUnsupported) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read peer credentials: %w", err)
+	}
+	if uid != daemonUID() {
+		return 0, fmt.Errorf("connection from uid %d, daemon runs as uid %d", uid, daemonUID())
+	}
+	return pid, nil
+}
+
+// peerAncestry returns the names of a process and of the processes it runs
+// under, up to init: the gob client, then e.g. the shell and agent running it
+func peerAncestry(pid int) []string {
+	var names []string
+	for len(names) < maxAncestry && pid > 1 {
+		proc, err := process.NewProcess(int32(pid))
+		if err != nil {
+			break
+		}
+		if name, err := proc.Name(); err == nil && name != "" {
+			names = append(names, name)
+		}
+		ppid, err := proc.Ppid()
+		if err != nil || int(ppid) == pid {
+			break
+		}
+		pid = int(ppid)
+	}
+	return names
+}
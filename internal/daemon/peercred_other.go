@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "net"
+
+// peerCred is not supported outside Linux and macOS
+func peerCred(conn *net.UnixConn) (uid, pid int, err error) {
+	return 0, 0, errPeerCredUnsupported
+}
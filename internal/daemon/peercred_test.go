@@ -0,0 +1,70 @@
+//go:build linux || darwin
+
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unixConnPair returns both ends of a connection to a Unix socket
+func unixConnPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "test.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	client, err := net.Dial("unix", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return client, server
+}
+
+func TestCheckPeer(t *testing.T) {
+	_, server := unixConnPair(t)
+
+	if pid, err := checkPeer(server); err != nil || pid != os.Getpid() {
+		t.Errorf("expected a connection from the same user to be accepted with its process, got %d, %v", pid, err)
+	}
+
+	daemonUID = func() int { return os.Getuid() + 1 }
+	defer func() { daemonUID = os.Getuid }()
+	if _, err := checkPeer(server); err == nil {
+		t.Error("expected a connection from another user to be rejected")
+	}
+
+	// Other connections are not checked
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if _, err := checkPeer(a); err != nil {
+		t.Errorf("expected a pipe not to be checked, got %v", err)
+	}
+}
+
+func TestDaemon_RejectsOtherUsers(t *testing.T) {
+	d := &Daemon{jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)}
+	client, server := unixConnPair(t)
+
+	daemonUID = func() int { return os.Getuid() + 1 }
+	defer func() { daemonUID = os.Getuid }()
+	d.handleConnection(server)
+
+	// The connection is closed without an answer
+	json.NewEncoder(client).Encode(NewRequest(RequestTypePing))
+	if n, _ := client.Read(make([]byte, 1)); n != 0 {
+		t.Error("expected no response to a rejected connection")
+	}
+}
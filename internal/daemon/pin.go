@@ -0,0 +1,38 @@
+package daemon
+
+import "fmt"
+
+// SetPinned pins or unpins a job. Pinned jobs are skipped by bulk removals
+// and log pruning, and only removed explicitly with force.
+func (jm *JobManager) SetPinned(jobID string, pinned bool) (*Job, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jm.resolveJobIDLocked(jobID)]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if job.Pinned == pinned {
+		return job, nil
+	}
+
+	job.Pinned = pinned
+
+	if jm.store != nil {
+		if err := jm.store.UpdateJob(job); err != nil {
+			job.Pinned = !pinned
+			return nil, fmt.Errorf("failed to persist pin: %w", err)
+		}
+	}
+
+	jm.emitEvent(Event{
+		Type:            EventTypeJobUpdated,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return job, nil
+}
@@ -0,0 +1,72 @@
+package daemon
+
+import "testing"
+
+func TestJobManager_SetPinned(t *testing.T) {
+	tmpDir := t.TempDir()
+	var events []Event
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, NewFakeProcessExecutor(), nil)
+
+	job, _, err := jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	events = nil
+
+	if _, err := jm.SetPinned(job.ID, true); err != nil {
+		t.Fatalf("SetPinned failed: %v", err)
+	}
+	if resp := jm.jobToResponse(job); !resp.Pinned {
+		t.Error("expected pinned in response")
+	}
+	if len(events) != 1 || events[0].Type != EventTypeJobUpdated || !events[0].Job.Pinned {
+		t.Errorf("expected one job_updated event with the pin, got %v", events)
+	}
+
+	// Already pinned: no event
+	jm.SetPinned(job.ID, true)
+	if len(events) != 1 {
+		t.Errorf("expected no event for an unchanged pin, got %d", len(events))
+	}
+
+	if _, err := jm.SetPinned("unknown", true); err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+}
+
+func TestJobManager_PinnedJobsAreKept(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	pinned, _, _ := jm.AddJob([]string{"make", "db"}, "/workdir", JobOptions{}, nil)
+	stopAndWait(t, jm, executor, pinned.ID)
+	jm.StartJob(pinned.ID, nil, EnvSourceClient, Origin{})
+	stopAndWait(t, jm, executor, pinned.ID)
+	jm.SetPinned(pinned.ID, true)
+
+	// Pruning skips the pinned job unless forced
+	if removed, _, _ := jm.PruneLogs("/workdir", 1, false); removed != 0 {
+		t.Errorf("expected no runs pruned, got %d", removed)
+	}
+
+	// Selection by pattern skips it unless forced
+	if _, err := jm.selectBatchJobs(BatchRequest{Action: BatchRemove, Match: "make *", Workdir: "/workdir"}); err == nil {
+		t.Error("expected no jobs to match")
+	}
+	ids, err := jm.selectBatchJobs(BatchRequest{Action: BatchRemove, Match: "make *", Workdir: "/workdir", Force: true})
+	if err != nil || len(ids) != 1 {
+		t.Errorf("expected the pinned job with force, got %v (%v)", ids, err)
+	}
+
+	// Removal by ID fails unless forced
+	if err := jm.RemoveJob(pinned.ID, false); err == nil {
+		t.Error("expected an error when removing a pinned job")
+	}
+
+	if removed, _, _ := jm.PruneLogs("/workdir", 1, true); removed != 1 {
+		t.Errorf("expected 1 run pruned with force, got %d", removed)
+	}
+	if err := jm.RemoveJob(pinned.ID, true); err != nil {
+		t.Errorf("RemoveJob with force failed: %v", err)
+	}
+}
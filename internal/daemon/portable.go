@@ -0,0 +1,218 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Portable workdirs.
+//
+// Jobs are scoped to an absolute workdir, so moving or cloning a repository
+// again starts the history of its jobs over. With GOB_PORTABLE=1, or
+// `portable = true` in the project's gobfile, clients also send the project a
+// workdir is in: the identity of its git repository (a hash of the origin
+// URL) and the workdir relative to the repository root. When a command has
+// no job in the workdir yet, the job of the same command at the same place in
+// another checkout of the repository moves to the workdir, keeping its runs.
+// Running jobs stay where they are.
+
+// ProjectRef locates a workdir within a project, independently of where the
+// project is checked out
+type ProjectRef struct {
+	ID   string `json:"id"`   // hash of the normalized git origin URL
+	Path string `json:"path"` // workdir relative to the repository root, "." for the root
+}
+
+// PortableProject returns the project workdir is in if portable workdirs are
+// enabled for it, or nil. Repositories without an origin remote have no
+// identity and are not portable.
+func PortableProject(workdir string) *ProjectRef {
+	if !portableEnabled(workdir) {
+		return nil
+	}
+	root := findProjectDir(workdir, ".git")
+	if root == "" {
+		return nil
+	}
+	origin := gitOrigin(filepath.Join(root, ".git"))
+	if origin == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(root, workdir)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(normalizeGitURL(origin)))
+	return &ProjectRef{ID: hex.EncodeToString(sum[:8]), Path: filepath.ToSlash(rel)}
+}
+
+// portableEnabled reports whether GOB_PORTABLE, or else the nearest gobfile
+// up from dir, enables portable workdirs
+func portableEnabled(dir string) bool {
+	if value := os.Getenv("GOB_PORTABLE"); value != "" {
+		enabled, _ := strconv.ParseBool(value)
+		return enabled
+	}
+	project := GobfileDir(dir)
+	if project == "" {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(project, gobfileName))
+	if err != nil {
+		return false
+	}
+	var config struct {
+		Portable bool `toml:"portable"`
+	}
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	return config.Portable
+}
+
+// gitOrigin returns the URL of the origin remote of the repository whose .git
+// entry is at gitPath, or "" if it has none. Worktrees and submodules, whose
+// .git is a file pointing at the git directory, share its config.
+func gitOrigin(gitPath string) string {
+	gitDir := gitPath
+	if info, err := os.Stat(gitPath); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(gitPath)
+		if err != nil {
+			return ""
+		}
+		dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return ""
+		}
+		gitDir = strings.TrimSpace(dir)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(filepath.Dir(gitPath), gitDir)
+		}
+		if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+			gitDir = filepath.Join(gitDir, strings.TrimSpace(string(common)))
+		}
+	}
+
+	file, err := os.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inOrigin := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if !inOrigin {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// normalizeGitURL reduces the URLs of a repository to one form, so clones
+// over SSH and HTTPS are the same project: git@github.com:me/app.git and
+// https://github.com/me/app are both github.com/me/app
+func normalizeGitURL(url string) string {
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	} else if host, path, ok := strings.Cut(url, ":"); ok && !strings.Contains(host, "/") {
+		url = host + "/" + path // scp-like syntax
+	}
+	if user, rest, ok := strings.Cut(url, "@"); ok && !strings.Contains(user, "/") {
+		url = rest
+	}
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	return strings.ToLower(url)
+}
+
+func (p *ProjectRef) id() string {
+	if p == nil {
+		return ""
+	}
+	return p.ID
+}
+
+func (p *ProjectRef) path() string {
+	if p == nil {
+		return ""
+	}
+	return p.Path
+}
+
+// validate checks a project sent by a client
+func (p *ProjectRef) validate() error {
+	if p.ID == "" {
+		return fmt.Errorf("missing project id")
+	}
+	if p.Path == "" || filepath.IsAbs(p.Path) || p.Path != filepath.ToSlash(filepath.Clean(p.Path)) || strings.HasPrefix(p.Path, "..") {
+		return fmt.Errorf("invalid project path: %q", p.Path)
+	}
+	return nil
+}
+
+// followProjectLocked moves the job of a command at the same place in
+// another checkout of project to workdir, if the command has no job in
+// workdir yet, so the job keeps its history. Of several candidates, the
+// newest job moves. Caller must hold jm.mu.
+func (jm *JobManager) followProjectLocked(signature, workdir string, project *ProjectRef) {
+	if project == nil {
+		return
+	}
+	if _, ok := jm.jobIndex[makeJobIndexKey(signature, workdir)]; ok {
+		return
+	}
+
+	var job *Job
+	for _, j := range jm.jobs {
+		if j.CommandSignature != signature || j.ProjectID != project.ID || j.ProjectPath != project.Path || j.IsRunning() {
+			continue
+		}
+		if job == nil || j.CreatedAt.After(job.CreatedAt) {
+			job = j
+		}
+	}
+	if job == nil {
+		return
+	}
+
+	from := job.Workdir
+	delete(jm.jobIndex, makeJobIndexKey(signature, from))
+	jm.jobIndex[makeJobIndexKey(signature, workdir)] = job.ID
+	job.Workdir = workdir
+	// A run directory relative to the old checkout stays relative
+	if job.RunDir != "" {
+		if rel, err := filepath.Rel(from, job.RunDir); err == nil {
+			job.RunDir = runDirOrEmpty(filepath.Join(workdir, rel), workdir)
+		}
+	}
+	Logger.Info("job followed its project", "job", job.ID, "from", from, "to", workdir)
+
+	if jm.store != nil {
+		if err := jm.store.UpdateJob(job); err != nil {
+			Logger.Warn("failed to update job", "id", job.ID, "error", err)
+		}
+	}
+	jm.emitEvent(Event{
+		Type:            EventTypeJobUpdated,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+}
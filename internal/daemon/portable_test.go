@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeGitURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:me/app.git":          "github.com/me/app",
+		"https://github.com/me/app":          "github.com/me/app",
+		"https://user@GitHub.com/me/app.git": "github.com/me/app",
+		"ssh://git@github.com/me/app.git":    "github.com/me/app",
+		"/srv/git/app.git":                   "/srv/git/app",
+	}
+	for url, want := range tests {
+		if got := normalizeGitURL(url); got != want {
+			t.Errorf("normalizeGitURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+// makeCheckout creates a git checkout with the given origin and a pkg/api
+// directory, and returns its root
+func makeCheckout(t *testing.T, origin string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "pkg", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := "[core]\n\tbare = false\n"
+	if origin != "" {
+		config += "[remote \"origin\"]\n\turl = " + origin + "\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n"
+	}
+	if err := os.WriteFile(filepath.Join(root, ".git", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestPortableProject(t *testing.T) {
+	ssh := makeCheckout(t, "git@github.com:me/app.git")
+	https := makeCheckout(t, "https://github.com/me/app")
+	local := makeCheckout(t, "")
+
+	t.Setenv("GOB_PORTABLE", "0")
+	if p := PortableProject(filepath.Join(ssh, "pkg", "api")); p != nil {
+		t.Errorf("expected no project when disabled, got %+v", p)
+	}
+
+	t.Setenv("GOB_PORTABLE", "1")
+	a := PortableProject(filepath.Join(ssh, "pkg", "api"))
+	b := PortableProject(filepath.Join(https, "pkg", "api"))
+	if a == nil || b == nil {
+		t.Fatalf("expected projects, got %+v and %+v", a, b)
+	}
+	if a.ID != b.ID || a.Path != "pkg/api" || b.Path != "pkg/api" {
+		t.Errorf("expected the same project for both checkouts, got %+v and %+v", a, b)
+	}
+	if p := PortableProject(ssh); p == nil || p.Path != "." {
+		t.Errorf("expected path . at the root, got %+v", p)
+	}
+	if p := PortableProject(local); p != nil {
+		t.Errorf("expected no project without an origin, got %+v", p)
+	}
+}
+
+func TestPortableProject_Gobfile(t *testing.T) {
+	t.Setenv("GOB_PORTABLE", "")
+	root := makeCheckout(t, "git@github.com:me/app.git")
+	if p := PortableProject(root); p != nil {
+		t.Errorf("expected no project without a gobfile, got %+v", p)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, ".config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, gobfileName), []byte("portable = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if p := PortableProject(filepath.Join(root, "pkg")); p == nil || p.Path != "pkg" {
+		t.Errorf("expected the gobfile to enable portable workdirs, got %+v", p)
+	}
+}
+
+func TestJobManager_FollowProject(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	project := &ProjectRef{ID: "0123456789abcdef", Path: "pkg/api"}
+
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/old/pkg/api", JobOptions{Project: project, RunDir: "/old"}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	handle := executor.LastHandle()
+
+	// A running job stays in its checkout
+	other, _, err := jm.AddJob([]string{"make", "test"}, "/new/pkg/api", JobOptions{Project: project}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if other.ID == job.ID {
+		t.Fatal("expected a running job not to move")
+	}
+	stopAndWait(t, jm, executor, other.ID)
+	if err := jm.RemoveJob(other.ID, false); err != nil {
+		t.Fatalf("RemoveJob failed: %v", err)
+	}
+
+	// A stopped one moves, with its history
+	stopHandleAndWait(t, jm, handle, job.ID)
+	moved, _, err := jm.AddJob([]string{"make", "test"}, "/new/pkg/api", JobOptions{Project: project}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if moved.ID != job.ID || moved.Workdir != "/new/pkg/api" || moved.RunCount != 1 {
+		t.Errorf("expected job %s moved with its run, got %s in %s with %d runs", job.ID, moved.ID, moved.Workdir, moved.RunCount)
+	}
+	if moved.RunDir != "/new" || executor.LastWorkdir() != "/new" {
+		t.Errorf("expected the run dir to follow, got %q (started in %q)", moved.RunDir, executor.LastWorkdir())
+	}
+	if jobs := jm.ListJobs("/old/pkg/api"); len(jobs) != 0 {
+		t.Errorf("expected no job left in the old checkout, got %d", len(jobs))
+	}
+
+	// Another command of the checkout is not affected
+	if _, _, err := jm.AddJob([]string{"make", "lint"}, "/other/pkg/api", JobOptions{Project: project}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if moved.Workdir != "/new/pkg/api" {
+		t.Errorf("expected the job to stay, got %s", moved.Workdir)
+	}
+}
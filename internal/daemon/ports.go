@@ -2,6 +2,8 @@ package daemon
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/shirou/gopsutil/v4/process"
 )
@@ -23,6 +25,24 @@ type JobPorts struct {
 	Message string     `json:"message,omitempty"` // Message for stopped jobs
 }
 
+// FormatPorts summarizes listening ports as ":3000,:5432", each port once
+// and in ascending order
+func FormatPorts(ports []PortInfo) string {
+	var numbers []int
+	for _, p := range ports {
+		if !slices.Contains(numbers, int(p.Port)) {
+			numbers = append(numbers, int(p.Port))
+		}
+	}
+	slices.Sort(numbers)
+
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = fmt.Sprintf(":%d", n)
+	}
+	return strings.Join(parts, ",")
+}
+
 // connectionTypeToString converts gopsutil connection type to string
 func connectionTypeToString(connType uint32) string {
 	switch connType {
@@ -76,6 +96,18 @@ func getProcessTreePorts(rootPID int) ([]PortInfo, error) {
 	return ports, nil
 }
 
+// runPorts returns the listening ports of a run. For a run in a container,
+// these are the ports of the container's main process, found with the
+// runtime's inspect: the runtime's CLI only relays its output.
+func runPorts(run *Run) ([]PortInfo, error) {
+	if run.container != nil {
+		if pid := run.container.pid(); pid != 0 {
+			return getProcessTreePorts(pid)
+		}
+	}
+	return getProcessTreePorts(run.PID)
+}
+
 // GetJobPorts returns the listening ports for a job's process tree
 func (jm *JobManager) GetJobPorts(jobID string) (*JobPorts, error) {
 	job, err := jm.GetJob(jobID)
@@ -105,7 +137,7 @@ func (jm *JobManager) GetJobPorts(jobID string) (*JobPorts, error) {
 		}, nil
 	}
 
-	ports, err := getProcessTreePorts(run.PID)
+	ports, err := runPorts(run)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +181,7 @@ func (jm *JobManager) RefreshJobPorts(jobID string) (*JobPorts, error) {
 		}, nil
 	}
 
-	ports, err := getProcessTreePorts(run.PID)
+	ports, err := runPorts(run)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,17 @@
+package daemon
+
+import "testing"
+
+func TestFormatPorts(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 5432, Protocol: "tcp", Address: "127.0.0.1"},
+		{Port: 3000, Protocol: "tcp", Address: "0.0.0.0"},
+		{Port: 3000, Protocol: "tcp6", Address: "::"},
+	}
+	if got := FormatPorts(ports); got != ":3000,:5432" {
+		t.Errorf("expected :3000,:5432, got %q", got)
+	}
+	if got := FormatPorts(nil); got != "" {
+		t.Errorf("expected empty summary, got %q", got)
+	}
+}
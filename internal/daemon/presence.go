@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Shell presence.
+//
+// Shells set up with 'gob hook cd' send a heartbeat when they start and
+// whenever they change directory, telling the daemon which project (the
+// nearest directory with a gobfile) they are in. A shell counts as being in
+// its last reported project for as long as its process lives. Jobs started
+// by the hook can be registered with their project; once no shell has been
+// in the project for its grace period, the daemon stops them.
+
+// presenceCheckInterval is how often shells and left projects are checked
+const presenceCheckInterval = 5 * time.Second
+
+// presence tracks the project of each shell and the jobs to stop when
+// projects are left
+type presence struct {
+	mu       sync.Mutex
+	shells   map[int]string // project of each shell, by PID
+	projects map[string]*projectPresence
+	running  atomic.Bool // the watcher is checking shells
+}
+
+// projectPresence holds the jobs to stop once a project is left
+type projectPresence struct {
+	jobs   []string
+	grace  time.Duration
+	leftAt time.Time // when the last shell left, zero while one is in it
+}
+
+// report records a heartbeat of the shell with the given PID in project ("" when
+// it is in none). Returns whether the shell entered the project with it.
+func (p *presence) report(pid int, project string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.shells == nil {
+		p.shells = make(map[int]string)
+	}
+	previous, known := p.shells[pid]
+	if project == "" {
+		delete(p.shells, pid)
+		return false
+	}
+	p.shells[pid] = project
+	if proj, ok := p.projects[project]; ok {
+		proj.leftAt = time.Time{}
+	}
+	return !known || previous != project
+}
+
+// watch registers jobs to stop once no shell has been in project for grace.
+// Jobs already registered for the project are kept.
+func (p *presence) watch(project string, jobs []string, grace time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.projects == nil {
+		p.projects = make(map[string]*projectPresence)
+	}
+	proj, ok := p.projects[project]
+	if !ok {
+		proj = &projectPresence{}
+		p.projects[project] = proj
+	}
+	proj.grace = grace
+	for _, id := range jobs {
+		if !slices.Contains(proj.jobs, id) {
+			proj.jobs = append(proj.jobs, id)
+		}
+	}
+}
+
+// expired forgets shells whose process is gone, and returns the jobs of the
+// projects no shell has been in for their grace period, forgetting them too
+func (p *presence) expired(now time.Time, alive func(pid int) bool) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	occupied := make(map[string]bool)
+	for pid, project := range p.shells {
+		if !alive(pid) {
+			delete(p.shells, pid)
+			continue
+		}
+		occupied[project] = true
+	}
+
+	var jobs []string
+	for project, proj := range p.projects {
+		switch {
+		case occupied[project]:
+			proj.leftAt = time.Time{}
+		case proj.leftAt.IsZero():
+			proj.leftAt = now
+		}
+		if !proj.leftAt.IsZero() && now.Sub(proj.leftAt) >= proj.grace {
+			jobs = append(jobs, proj.jobs...)
+			delete(p.projects, project)
+		}
+	}
+	return jobs
+}
+
+// watchPresence stops the jobs of left projects until the daemon shuts down
+func (d *Daemon) watchPresence() {
+	d.presence.running.Store(true)
+	defer d.presence.running.Store(false)
+
+	ticker := time.NewTicker(presenceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, jobID := range d.presence.expired(now, processExists) {
+				Logger.Info("stopping job of left project", "job", jobID)
+				if err := d.jobManager.StopJob(jobID, false); err != nil {
+					Logger.Error("failed to stop job of left project", "job", jobID, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// counts returns the number of shells reporting and of projects whose jobs
+// are stopped once left
+func (p *presence) counts() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.shells), len(p.projects)
+}
+
+// handleShellPresence handles a heartbeat of a shell
+func (d *Daemon) handleShellPresence(req *Request) *Response {
+	var p ShellPresencePayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.PID <= 0 {
+		return NewErrorResponse(fmt.Errorf("missing shell pid"))
+	}
+	if p.StopAfterMs < 0 {
+		return NewErrorResponse(fmt.Errorf("grace period must not be negative"))
+	}
+
+	entered := d.presence.report(p.PID, p.Project)
+	if p.Project != "" && len(p.StopJobs) > 0 {
+		d.presence.watch(p.Project, p.StopJobs, time.Duration(p.StopAfterMs)*time.Millisecond)
+	}
+
+	return NewDataResponse(ShellPresenceData{Entered: entered})
+}
@@ -0,0 +1,75 @@
+package daemon
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPresence_Report(t *testing.T) {
+	var p presence
+
+	if !p.report(100, "/project") {
+		t.Error("expected a new shell to enter its project")
+	}
+	if p.report(100, "/project") {
+		t.Error("expected a heartbeat in the same project not to enter it again")
+	}
+	if p.report(100, "") {
+		t.Error("expected leaving projects not to enter one")
+	}
+	if !p.report(100, "/project") {
+		t.Error("expected coming back to enter the project again")
+	}
+	if !p.report(100, "/other") {
+		t.Error("expected moving to another project to enter it")
+	}
+}
+
+func TestPresence_Expired(t *testing.T) {
+	var p presence
+	alive := map[int]bool{100: true, 200: true}
+	isAlive := func(pid int) bool { return alive[pid] }
+	now := time.Now()
+
+	p.report(100, "/project")
+	p.report(200, "/project")
+	p.watch("/project", []string{"abc"}, time.Minute)
+	p.watch("/project", []string{"abc", "def"}, time.Minute)
+
+	if jobs := p.expired(now, isAlive); len(jobs) != 0 {
+		t.Fatalf("expected no jobs to stop while shells are in the project, got %v", jobs)
+	}
+
+	// One shell leaves, the other one exits
+	p.report(100, "/other")
+	alive[200] = false
+	if jobs := p.expired(now, isAlive); len(jobs) != 0 {
+		t.Fatalf("expected no jobs to stop before the grace period, got %v", jobs)
+	}
+
+	jobs := p.expired(now.Add(time.Minute), isAlive)
+	if !slices.Equal(jobs, []string{"abc", "def"}) {
+		t.Errorf("expected [abc def] to stop after the grace period, got %v", jobs)
+	}
+	if jobs := p.expired(now.Add(2*time.Minute), isAlive); len(jobs) != 0 {
+		t.Errorf("expected the project to be forgotten once its jobs stopped, got %v", jobs)
+	}
+}
+
+func TestPresence_ComingBackCancelsStop(t *testing.T) {
+	var p presence
+	isAlive := func(pid int) bool { return true }
+	now := time.Now()
+
+	p.report(100, "/project")
+	p.watch("/project", []string{"abc"}, time.Minute)
+
+	p.report(100, "")
+	p.expired(now, isAlive)
+
+	p.report(100, "/project")
+	if jobs := p.expired(now.Add(2*time.Minute), isAlive); len(jobs) != 0 {
+		t.Errorf("expected no jobs to stop after coming back, got %v", jobs)
+	}
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import "fmt"
+
+// Priority is the scheduling priority of a job
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// ParsePriority parses a priority name. An empty string means normal.
+func ParsePriority(s string) (Priority, error) {
+	switch Priority(s) {
+	case "", PriorityNormal:
+		return PriorityNormal, nil
+	case PriorityLow:
+		return PriorityLow, nil
+	case PriorityHigh:
+		return PriorityHigh, nil
+	default:
+		return "", fmt.Errorf("invalid priority %q (expected low, normal or high)", s)
+	}
+}
+
+// CPUWeight returns the cgroup cpu.weight for the priority, 0 for the
+// default (100)
+func (p Priority) CPUWeight() int {
+	switch p {
+	case PriorityLow:
+		return 50
+	case PriorityHigh:
+		return 200
+	default:
+		return 0
+	}
+}
+
+// Nice returns the nice value for the priority
+func (p Priority) Nice() int {
+	switch p {
+	case PriorityLow:
+		return 10
+	case PriorityHigh:
+		return -5
+	default:
+		return 0
+	}
+}
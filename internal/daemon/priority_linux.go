@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+const (
+	ioprioClassBestEffort = 2
+	ioprioClassShift      = 13
+	ioprioWhoProcess      = 1
+)
+
+// ioprioLevel returns the best-effort I/O priority level of a priority
+func ioprioLevel(priority Priority) int {
+	switch priority {
+	case PriorityLow:
+		return 7
+	case PriorityHigh:
+		return 0
+	default:
+		return 4
+	}
+}
+
+// ioprioSet calls ioprio_set with a best-effort level (the equivalent of
+// `ionice -c2 -n<level>`)
+func ioprioSet(which, who int, priority Priority) error {
+	ioprio := ioprioClassBestEffort<<ioprioClassShift | ioprioLevel(priority)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, uintptr(which), uintptr(who), uintptr(ioprio))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// startWithPriority starts cmd with the nice value and I/O priority of a
+// priority already set, so processes it forks before the daemon gets to it
+// have them too. On Linux both belong to threads and are inherited when
+// forking, so the process is forked from a thread set to the priority.
+func startWithPriority(cmd *exec.Cmd, priority Priority) error {
+	if priority == "" || priority == PriorityNormal {
+		return cmd.Start()
+	}
+
+	errc := make(chan error, 1)
+	go startOnPriorityThread(cmd, priority, errc)
+	return <-errc
+}
+
+// startOnPriorityThread sets the priority of the thread it locks and starts
+// cmd from it. The thread is never unlocked, so it exits with the goroutine:
+// lowering its nice value back needs privileges.
+func startOnPriorityThread(cmd *exec.Cmd, priority Priority, errc chan<- error) {
+	runtime.LockOSThread()
+	tid := syscall.Gettid()
+
+	// The main thread never exits, and tools show its nice value for the
+	// whole daemon: hold it while another goroutine gets another thread
+	if tid == syscall.Getpid() {
+		done := make(chan struct{})
+		go func() {
+			startOnPriorityThread(cmd, priority, errc)
+			close(done)
+		}()
+		<-done
+		runtime.UnlockOSThread()
+		return
+	}
+
+	// Raising priority usually requires privileges, so failures are logged
+	// and otherwise ignored
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, priority.Nice()); err != nil {
+		Logger.Warn("failed to set nice value", "priority", priority, "error", err)
+	}
+	if err := ioprioSet(ioprioWhoProcess, tid, priority); err != nil {
+		Logger.Warn("failed to set io priority", "priority", priority, "error", err)
+	}
+	errc <- cmd.Start()
+}
@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// niceOf returns the nice value in a /proc/<pid>/stat line
+func niceOf(stat string) string {
+	// Fields after the command name, which is in parentheses
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	return fields[16]
+}
+
+func TestStartWithPriority(t *testing.T) {
+	self, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		t.Skip("no /proc")
+	}
+	if niceOf(string(self)) != "0" {
+		t.Skip("tests already run with a nice value")
+	}
+
+	// The command reads the stat of a process it forks right away
+	var out bytes.Buffer
+	cmd := exec.Command("sh", "-c", "cat /proc/self/stat")
+	cmd.Stdout = &out
+	if err := startWithPriority(cmd, PriorityLow); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if nice := niceOf(out.String()); nice != "10" {
+		t.Errorf("expected the forked process to start with nice 10, got %s", nice)
+	}
+
+	// The daemon keeps its own priority
+	self, _ = os.ReadFile("/proc/self/stat")
+	if nice := niceOf(string(self)); nice != "0" {
+		t.Errorf("expected the daemon to keep nice 0, got %s", nice)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux && !windows
+
+package daemon
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startWithPriority starts cmd, then sets the nice value of its process
+// group. Nice values belong to processes here, so they cannot be set before
+// the command runs: processes it forks right away keep the default one.
+// Raising priority usually requires privileges, so failures are logged and
+// otherwise ignored.
+func startWithPriority(cmd *exec.Cmd, priority Priority) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if priority == "" || priority == PriorityNormal {
+		return nil
+	}
+
+	// The process is the leader of its own group, so this also covers
+	// any children it spawns from now on
+	pgid := cmd.Process.Pid
+	if err := syscall.Setpriority(syscall.PRIO_PGRP, pgid, priority.Nice()); err != nil {
+		Logger.Warn("failed to set nice value", "pgid", pgid, "priority", priority, "error", err)
+	}
+	return nil
+}
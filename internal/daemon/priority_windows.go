@@ -0,0 +1,23 @@
+package daemon
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// startWithPriority starts cmd in the priority class of a priority, which
+// the processes it creates inherit
+func startWithPriority(cmd *exec.Cmd, priority Priority) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	switch priority {
+	case PriorityLow:
+		cmd.SysProcAttr.CreationFlags |= windows.BELOW_NORMAL_PRIORITY_CLASS
+	case PriorityHigh:
+		cmd.SysProcAttr.CreationFlags |= windows.ABOVE_NORMAL_PRIORITY_CLASS
+	}
+	return cmd.Start()
+}
@@ -1,11 +1,83 @@
 package daemon
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/shirou/gopsutil/v4/process"
 )
 
+// runIDEnvVar is set in the environment of every run. Descendants inherit it,
+// which lets the daemon find processes that were re-parented away from the
+// run's process tree.
+const runIDEnvVar = "GOB_RUN_ID"
+
+// daemonIDEnvVar is set next to runIDEnvVar to the ID of the daemon that
+// started the run. Run IDs are only unique within a daemon, and a user can
+// have several (isolated daemons), so a process belongs to one of this
+// daemon's runs only if it carries both.
+const daemonIDEnvVar = "GOB_DAEMON_ID"
+
+// newDaemonID returns a random daemon ID
+func newDaemonID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// jobIDEnvVar and logDirEnvVar are set in the environment of every run next
+// to runIDEnvVar, so its processes can tell which job they run for and where
+// their output goes
+const (
+	jobIDEnvVar  = "GOB_JOB_ID"
+	logDirEnvVar = "GOB_LOG_DIR"
+)
+
+// Grace periods used when stopping process trees
+var (
+	stopTermTimeout = 10 * time.Second // wait after SIGTERM before escalating
+	stopKillTimeout = 5 * time.Second  // wait after SIGKILL before giving up
+	stopExitTimeout = 5 * time.Second  // wait for a run whose processes exited to be recorded as stopped
+)
+
+// processTree identifies the processes that belong to a run
+type processTree struct {
+	rootPID    int           // PID of the run's main process (also its process group ID)
+	daemonID   string        // ID of the daemon set in the environment of descendants
+	runID      string        // run ID set in the environment of descendants
+	cgroupPath string        // dedicated cgroup of the run, empty if none
+	container  *runContainer // container of the run, stopped with its runtime, nil if none
+}
+
+// treePIDs returns a snapshot of all processes of the runs of the trees: the
+// roots and their descendants, every process in the runs' cgroups, and any
+// process that carries one of the runs' IDs in its environment. Environments
+// are read once for all the trees.
+func treePIDs(trees []processTree) []int {
+	var pids []int
+	var runs []runMarker
+	for _, t := range trees {
+		pids = mergePIDs(pids, getProcessTreePIDs(t.rootPID))
+		if t.cgroupPath != "" {
+			cg := &runCgroup{path: t.cgroupPath}
+			pids = mergePIDs(pids, cg.pids())
+		}
+		if t.runID != "" {
+			runs = append(runs, runMarker{daemonID: t.daemonID, runID: t.runID})
+		}
+	}
+	if len(runs) > 0 {
+		for _, runPIDs := range findRunProcesses(runs...) {
+			pids = mergePIDs(pids, runPIDs)
+		}
+	}
+	return pids
+}
+
 // getProcessTreePIDs returns all PIDs in a process tree (root + all descendants).
 // Returns empty slice if root process doesn't exist.
 func getProcessTreePIDs(rootPID int) []int {
@@ -30,21 +102,188 @@ func getProcessTreePIDs(rootPID int) []int {
 	return pids
 }
 
+// runMarker is the run of a daemon, as marked in the environment of its
+// processes
+type runMarker struct {
+	daemonID string
+	runID    string
+}
+
+// findRunProcesses returns the PIDs of processes whose environment marks them
+// as part of one of the given runs, by run. Every process is listed and its
+// environment read once, whatever the number of runs. Hooks, and processes
+// whose environment can't be read (other users, unsupported platforms), are
+// skipped.
+func findRunProcesses(runs ...runMarker) map[runMarker][]int {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+
+	wanted := make(map[runMarker]bool, len(runs))
+	for _, run := range runs {
+		wanted[run] = true
+	}
+	daemonPrefix := daemonIDEnvVar + "="
+	runPrefix := runIDEnvVar + "="
+	self := int32(os.Getpid())
+
+	found := make(map[runMarker][]int)
+	for _, proc := range procs {
+		if proc.Pid == self {
+			continue
+		}
+		env, err := proc.Environ()
+		if err != nil {
+			continue
+		}
+		var run runMarker
+		hook := false
+		for _, kv := range env {
+			if id, ok := strings.CutPrefix(kv, daemonPrefix); ok {
+				run.daemonID = id
+			} else if id, ok := strings.CutPrefix(kv, runPrefix); ok {
+				run.runID = id
+			} else if strings.HasPrefix(kv, hookEventEnvVar+"=") {
+				hook = true
+			}
+		}
+		if !hook && wanted[run] {
+			found[run] = append(found[run], int(proc.Pid))
+		}
+	}
+	return found
+}
+
+// mergePIDs returns the union of two PID lists, preserving order
+func mergePIDs(a, b []int) []int {
+	seen := make(map[int]bool, len(a))
+	merged := append([]int{}, a...)
+	for _, pid := range a {
+		seen[pid] = true
+	}
+	for _, pid := range b {
+		if !seen[pid] {
+			seen[pid] = true
+			merged = append(merged, pid)
+		}
+	}
+	return merged
+}
+
 // filterRunningPIDs returns only the PIDs that are still running.
+// Zombies count as stopped: they are gone and only wait to be reaped.
 func filterRunningPIDs(pids []int) []int {
 	var running []int
 	for _, pid := range pids {
-		if syscall.Kill(pid, 0) == nil {
+		if processExists(pid) && !isZombie(pid) {
 			running = append(running, pid)
 		}
 	}
 	return running
 }
 
+// isZombie reports whether a process has exited but not been reaped
+func isZombie(pid int) bool {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false
+	}
+	status, err := proc.Status()
+	if err != nil {
+		return false
+	}
+	for _, s := range status {
+		if s == process.Zombie {
+			return true
+		}
+	}
+	return false
+}
+
 // killPIDs sends the given signal to each PID individually.
 // Ignores errors (e.g., ESRCH if process already gone).
 func killPIDs(pids []int, sig syscall.Signal) {
 	for _, pid := range pids {
-		syscall.Kill(pid, sig)
+		signalProcess(pid, sig)
+	}
+}
+
+// signalProcessTrees sends a signal to the process group of each tree and to
+// every process in the given snapshot, including the process groups they
+// lead. This reaches processes that moved to their own process group or
+// session (e.g. some node tooling), which a signal to the run's group misses.
+func signalProcessTrees(trees []processTree, pids []int, sig syscall.Signal) {
+	for _, tree := range trees {
+		if tree.container != nil {
+			tree.container.signal(sig)
+		}
+		signalRun(tree.runID, sig)
+		signalGroup(tree.rootPID, sig)
+	}
+	for _, pid := range pids {
+		if isGroupLeader(pid) {
+			signalGroup(pid, sig)
+		}
+	}
+	killPIDs(pids, sig)
+}
+
+// killProcessTrees stops every process of the given trees and waits for them
+// to exit. Without force it sends SIGTERM first and escalates to SIGKILL after
+// stopTermTimeout. While waiting it re-scans the trees, so children forked
+// after the first snapshot are signaled too.
+// Returns the PIDs that are still running at the end.
+func killProcessTrees(trees []processTree, force bool) []int {
+	// Snapshot all PIDs before signaling: once the root exits, its
+	// descendants are re-parented and can no longer be found by walking it
+	pids := treePIDs(trees)
+
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+
+	if survivors := waitProcessTrees(trees, pids, sig, stopTermTimeout); len(survivors) == 0 || force {
+		return survivors
+	}
+
+	return waitProcessTrees(trees, pids, syscall.SIGKILL, stopKillTimeout)
+}
+
+// waitProcessTrees signals the trees and waits up to timeout for all of their
+// processes to exit. Processes discovered while waiting are signaled as well.
+func waitProcessTrees(trees []processTree, pids []int, sig syscall.Signal, timeout time.Duration) []int {
+	signalProcessTrees(trees, pids, sig)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		// Pick up stragglers forked since the last scan
+		var fresh []int
+		for _, pid := range treePIDs(trees) {
+			if !containsPID(pids, pid) {
+				fresh = append(fresh, pid)
+			}
+		}
+		if len(fresh) > 0 {
+			killPIDs(fresh, sig)
+			pids = mergePIDs(pids, fresh)
+		}
+
+		survivors := filterRunningPIDs(pids)
+		if len(survivors) == 0 || !time.Now().Before(deadline) {
+			return survivors
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// containsPID reports whether pid is in pids
+func containsPID(pids []int, pid int) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
 	}
+	return false
 }
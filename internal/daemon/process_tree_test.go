@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fixtureDaemonID is the daemon ID of the runs started by startTreeFixture
+const fixtureDaemonID = "tst-daemon"
+
+// startTreeFixture starts a shell script as a run and returns its handle and
+// the PID written by the script to $PIDFILE
+func startTreeFixture(t *testing.T, runID, script string) (ProcessHandle, int) {
+	t.Helper()
+
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid not available")
+	}
+
+	tmpDir := t.TempDir()
+	pidFile := filepath.Join(tmpDir, "child.pid")
+	env := append(os.Environ(), "PIDFILE="+pidFile)
+
+	executor := &RealProcessExecutor{}
+	handle, err := executor.Start([]string{"sh", "-c", script}, tmpDir, env,
+		filepath.Join(tmpDir, "stdout.log"), filepath.Join(tmpDir, "stderr.log"), StartOptions{DaemonID: fixtureDaemonID, RunID: runID})
+	if err != nil {
+		t.Fatalf("failed to start fixture: %v", err)
+	}
+	go handle.Wait() // reap the root like the daemon does
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(pidFile)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err == nil {
+				t.Cleanup(func() { killPIDs([]int{pid}, syscall.SIGKILL) })
+				return handle, pid
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("fixture did not write its child PID")
+	return nil, 0
+}
+
+func TestKillProcessTrees_ChildInOwnProcessGroup(t *testing.T) {
+	// The child starts its own session, so a signal to the run's process group misses it
+	handle, childPID := startTreeFixture(t, "tst-1", `setsid sleep 300 & echo $! > "$PIDFILE"; wait`)
+
+	start := time.Now()
+	survivors := killProcessTrees([]processTree{{rootPID: handle.Pid(), daemonID: fixtureDaemonID, runID: "tst-1"}}, false)
+	elapsed := time.Since(start)
+
+	if len(survivors) != 0 {
+		t.Fatalf("expected no survivors, got %v", survivors)
+	}
+	if len(filterRunningPIDs([]int{childPID})) != 0 {
+		t.Error("expected child in its own process group to be stopped")
+	}
+	if elapsed >= stopTermTimeout {
+		t.Errorf("expected SIGTERM to reach the child without escalation, took %s", elapsed)
+	}
+}
+
+func TestKillProcessTrees_ReparentedChild(t *testing.T) {
+	// The intermediate subshell exits, so the child is re-parented away from the run's tree
+	handle, childPID := startTreeFixture(t, "tst-2", `(setsid sleep 300 & echo $! > "$PIDFILE"); exec sleep 300`)
+
+	for _, pid := range getProcessTreePIDs(handle.Pid()) {
+		if pid == childPID {
+			t.Skip("child was not re-parented (daemon process is a subreaper)")
+		}
+	}
+
+	start := time.Now()
+	survivors := killProcessTrees([]processTree{{rootPID: handle.Pid(), daemonID: fixtureDaemonID, runID: "tst-2"}}, false)
+	elapsed := time.Since(start)
+
+	if len(survivors) != 0 {
+		t.Fatalf("expected no survivors, got %v", survivors)
+	}
+	if len(filterRunningPIDs([]int{childPID})) != 0 {
+		t.Error("expected re-parented child to be stopped")
+	}
+	if elapsed >= stopTermTimeout {
+		t.Errorf("expected SIGTERM to reach the child without escalation, took %s", elapsed)
+	}
+}
+
+func TestFindRunProcesses(t *testing.T) {
+	_, childPID := startTreeFixture(t, "tst-3", `(setsid sleep 300 & echo $! > "$PIDFILE"); exec sleep 300`)
+
+	run := runMarker{fixtureDaemonID, "tst-3"}
+	otherRun := runMarker{fixtureDaemonID, "tst-other"}
+	otherDaemon := runMarker{"other-daemon", "tst-3"}
+
+	// One scan finds the processes of every run asked for
+	found := findRunProcesses(run, otherRun, otherDaemon)
+	if !containsPID(found[run], childPID) {
+		t.Error("expected child to be found by its run ID marker")
+	}
+	if containsPID(found[otherRun], childPID) {
+		t.Error("expected child not to match another run ID")
+	}
+	if containsPID(found[otherDaemon], childPID) {
+		t.Error("expected child not to match the same run ID of another daemon")
+	}
+}
+
+func TestFindRunProcesses_SkipsHooks(t *testing.T) {
+	// Hooks carry the markers of their run, and outlive it when it is stopped
+	_, hookPID := startTreeFixture(t, "tst-5", `(setsid env `+hookEventEnvVar+`=on_start sleep 300 & echo $! > "$PIDFILE"); exec sleep 300`)
+
+	run := runMarker{fixtureDaemonID, "tst-5"}
+	if containsPID(findRunProcesses(run)[run], hookPID) {
+		t.Error("expected a hook not to be found as a process of its run")
+	}
+}
+
+func TestStore_DaemonID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := NewStore(db).DaemonID()
+	if err != nil || id == "" {
+		t.Fatalf("expected a daemon ID, got %q (%v)", id, err)
+	}
+	db.Close()
+
+	// A restarted daemon keeps it
+	db, err = OpenDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if again, _ := NewStore(db).DaemonID(); again != id {
+		t.Errorf("expected the same daemon ID after a restart, got %q and %q", id, again)
+	}
+}
+
+func TestWithRunEnv(t *testing.T) {
+	env := []string{"PATH=/bin", runIDEnvVar + "=outer-1", "HOME=/home/user", jobIDEnvVar + "=outer", daemonIDEnvVar + "=outer-daemon", hookEventEnvVar + "=on_start"}
+
+	got := withRunEnv(env, "inner-daemon", "inner", "inner-1", "/logs")
+
+	expected := []string{"PATH=/bin", "HOME=/home/user", jobIDEnvVar + "=inner", daemonIDEnvVar + "=inner-daemon", runIDEnvVar + "=inner-1", logDirEnvVar + "=/logs"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if env[1] != runIDEnvVar+"=outer-1" {
+		t.Error("expected input environment to be left untouched")
+	}
+}
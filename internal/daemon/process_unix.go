@@ -0,0 +1,51 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// newSysProcAttr returns the attributes of a job's process: it leads a new
+// process group so all of its children can be signaled together
+func newSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// attachRun is a no-op on Unix, where the process group (and the cgroup,
+// when limits are set) already tracks the run's processes
+func attachRun(runID string, pid int) (func(), error) {
+	return func() {}, nil
+}
+
+// signalRun is a no-op on Unix (see attachRun)
+func signalRun(runID string, sig syscall.Signal) {}
+
+// processExists checks if a process with the given PID exists
+func processExists(pid int) bool {
+	// Signal 0 checks if process exists without sending signal
+	return syscall.Kill(pid, 0) == nil
+}
+
+// signalProcess sends a signal to a single process
+func signalProcess(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
+
+// signalGroup sends a signal to the process group led by pgid
+func signalGroup(pgid int, sig syscall.Signal) error {
+	return syscall.Kill(-pgid, sig)
+}
+
+// isGroupLeader reports whether a process leads its own process group
+func isGroupLeader(pid int) bool {
+	pgid, err := syscall.Getpgid(pid)
+	return err == nil && pgid == pid
+}
+
+// shellCommand returns a command that runs a command line through the shell
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,155 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows has no signals or process groups. gob emulates them:
+//   - every run is assigned to a Job Object named after the run, so its
+//     whole process tree can be terminated at once (SIGKILL)
+//   - other signals are delivered with taskkill, which asks the process
+//     tree to close (console programs that ignore it are killed when the
+//     stop escalates to SIGKILL)
+
+const (
+	stillActive          = 259 // exit code of a process that has not exited
+	jobObjectTerminate   = 0x0008
+	jobObjectQueryAccess = 0x0004
+)
+
+var procOpenJobObject = windows.NewLazySystemDLL("kernel32.dll").NewProc("OpenJobObjectW")
+
+// newSysProcAttr returns the attributes of a job's process: it leads a new
+// process group and gets no console window
+func newSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
+
+// jobObjectName returns the name of the Job Object that holds a run's processes
+func jobObjectName(runID string) string {
+	return `Local\gob-run-` + runID
+}
+
+// attachRun assigns a process to a new Job Object named after the run.
+// Processes it spawns afterwards join the Job Object too. The returned
+// function closes the daemon's handle once the process has exited.
+func attachRun(runID string, pid int) (func(), error) {
+	noop := func() {}
+	if runID == "" {
+		return noop, nil
+	}
+
+	name, err := windows.UTF16PtrFromString(jobObjectName(runID))
+	if err != nil {
+		return noop, err
+	}
+	job, err := windows.CreateJobObject(nil, name)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create job object: %w", err)
+	}
+
+	proc, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		windows.CloseHandle(job)
+		return noop, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(proc)
+
+	if err := windows.AssignProcessToJobObject(job, proc); err != nil {
+		windows.CloseHandle(job)
+		return noop, fmt.Errorf("failed to assign process to job object: %w", err)
+	}
+	return func() { windows.CloseHandle(job) }, nil
+}
+
+// signalRun terminates every process in the run's Job Object on SIGKILL.
+// Other signals are left to signalGroup.
+func signalRun(runID string, sig syscall.Signal) {
+	if runID == "" || sig != syscall.SIGKILL {
+		return
+	}
+
+	name, err := windows.UTF16PtrFromString(jobObjectName(runID))
+	if err != nil {
+		return
+	}
+	r, _, _ := procOpenJobObject.Call(jobObjectTerminate|jobObjectQueryAccess, 0, uintptr(unsafe.Pointer(name)))
+	if r == 0 {
+		return // Job Object gone: every process of the run has exited
+	}
+	job := windows.Handle(r)
+	defer windows.CloseHandle(job)
+	windows.TerminateJobObject(job, 1)
+}
+
+// processExists checks if a process with the given PID exists
+func processExists(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// signalProcess emulates sending a signal to a single process
+func signalProcess(pid int, sig syscall.Signal) error {
+	return taskkill(pid, sig, false)
+}
+
+// signalGroup emulates sending a signal to a process and its descendants
+func signalGroup(pgid int, sig syscall.Signal) error {
+	return taskkill(pgid, sig, true)
+}
+
+// taskkill delivers an emulated signal: signal 0 checks that the process
+// exists, SIGKILL forcefully terminates it and anything else asks it to close
+func taskkill(pid int, sig syscall.Signal, tree bool) error {
+	if !processExists(pid) {
+		return syscall.ESRCH
+	}
+	if sig == 0 {
+		return nil
+	}
+
+	args := []string{"/PID", strconv.Itoa(pid)}
+	if tree {
+		args = append(args, "/T")
+	}
+	if sig == syscall.SIGKILL {
+		args = append(args, "/F")
+	}
+
+	cmd := exec.Command("taskkill", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("taskkill failed: %w: %s", err, output)
+	}
+	return nil
+}
+
+// isGroupLeader is always false on Windows: trees are signaled through their
+// root process and Job Object instead
+func isGroupLeader(pid int) bool {
+	return false
+}
+
+// shellCommand returns a command that runs a command line through cmd.exe
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
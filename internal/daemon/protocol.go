@@ -4,7 +4,8 @@
 //
 // The daemon and clients communicate over a Unix domain socket using JSON-encoded messages.
 // Each connection handles one request-response cycle, except for subscriptions which
-// stream events over a long-lived connection.
+// stream events over a long-lived connection, and sessions which carry any
+// number of requests over one (see [RequestTypeSession]).
 //
 // Socket location: $XDG_RUNTIME_DIR/gob/daemon.sock (see paths.go)
 //
@@ -12,13 +13,17 @@
 //
 // Request (client to daemon):
 //
-//	{"type": "<RequestType>", "payload": {...}}
+//	{"version": 1, "type": "<RequestType>", "payload": {...}}
 //
 // Response (daemon to client):
 //
 //	{"success": true, "data": {...}}
 //	{"success": false, "error": "message"}
 //
+// The version is the client's [ProtocolVersion]; the daemon rejects requests
+// of versions newer than its own. Payloads and data are described by typed
+// structs (see schema.go), such as [AddPayload] and [ListData].
+//
 // See [RequestType] constants for available request types and [EventType] for subscription events.
 package daemon
 
@@ -27,28 +32,67 @@ import (
 	"fmt"
 )
 
+// ProtocolVersion is the version of the request, response and event formats.
+// It increases when they change in a way older clients cannot handle.
+const ProtocolVersion = 1
+
 // RequestType represents the type of request being made to the daemon
 type RequestType string
 
 const (
-	RequestTypePing      RequestType = "ping"
-	RequestTypeShutdown  RequestType = "shutdown"
-	RequestTypeList      RequestType = "list"
-	RequestTypeAdd       RequestType = "add"
-	RequestTypeCreate    RequestType = "create" // Add job without starting
-	RequestTypeStop      RequestType = "stop"
-	RequestTypeStart     RequestType = "start"
-	RequestTypeRestart   RequestType = "restart"
-	RequestTypeRemove    RequestType = "remove"
-	RequestTypeStopAll   RequestType = "stop_all"
-	RequestTypeSignal    RequestType = "signal"
-	RequestTypeGetJob    RequestType = "get_job"
-	RequestTypeRuns      RequestType = "runs"
-	RequestTypeStats     RequestType = "stats"
-	RequestTypeSubscribe RequestType = "subscribe"
-	RequestTypeVersion   RequestType = "version"
-	RequestTypePorts     RequestType = "ports"
-	RequestTypeRemoveRun RequestType = "remove_run"
+	RequestTypePing        RequestType = "ping"
+	RequestTypeShutdown    RequestType = "shutdown"
+	RequestTypeList        RequestType = "list"
+	RequestTypeAdd         RequestType = "add"
+	RequestTypeRun         RequestType = "run"    // Add job, reusing a run another client just started
+	RequestTypeCreate      RequestType = "create" // Add job without starting
+	RequestTypeStop        RequestType = "stop"
+	RequestTypeStart       RequestType = "start"
+	RequestTypeRestart     RequestType = "restart"
+	RequestTypeRemove      RequestType = "remove"
+	RequestTypeStopAll     RequestType = "stop_all"
+	RequestTypeSignal      RequestType = "signal"
+	RequestTypeGetJob      RequestType = "get_job"
+	RequestTypeRuns        RequestType = "runs"
+	RequestTypeStats       RequestType = "stats"
+	RequestTypeStatsAll    RequestType = "stats_all" // Time spent and failures of each job in a directory
+	RequestTypeSubscribe   RequestType = "subscribe"
+	RequestTypeSession     RequestType = "session" // Keep the connection open for requests with IDs, answered concurrently
+	RequestTypeVersion     RequestType = "version"
+	RequestTypePorts       RequestType = "ports"
+	RequestTypeRemoveRun   RequestType = "remove_run"
+	RequestTypeSetAlias    RequestType = "set_alias"
+	RequestTypeBatch       RequestType = "batch"        // Apply stop/restart/remove/signal to several jobs
+	RequestTypeHistory     RequestType = "history"      // Recent runs across jobs
+	RequestTypeGrep        RequestType = "grep"         // Search stored run logs
+	RequestTypeLogLines    RequestType = "log_lines"    // Stdout lines of a JSON-logging job at or above a level
+	RequestTypeEvents      RequestType = "events"       // Recorded events
+	RequestTypeHandover    RequestType = "handover"     // Exit leaving running jobs to a new daemon
+	RequestTypeAdopt       RequestType = "adopt"        // Register a process started outside gob as a job
+	RequestTypeDiskUsage   RequestType = "disk_usage"   // Size of the stored logs of each job
+	RequestTypePruneLogs   RequestType = "prune_logs"   // Remove old stopped runs and their logs
+	RequestTypeArchiveRuns RequestType = "archive_runs" // Gzip the logs of old stopped runs and stop loading them
+	RequestTypeCleanupLogs RequestType = "cleanup_logs" // Remove stopped runs whose log files are missing
+	RequestTypeAudit       RequestType = "audit"        // Recorded state-changing requests
+	RequestTypeApprovals   RequestType = "approvals"    // Requests waiting for approval
+	RequestTypeApprove     RequestType = "approve"      // Approve or deny a request waiting for approval
+	RequestTypeOrphans     RequestType = "orphans"      // Processes of runs that are not running anymore
+	RequestTypeKillOrphans RequestType = "kill_orphans" // Stop the processes of runs that are not running anymore
+
+	RequestTypeSetDescription   RequestType = "set_description"    // Replace the description of a job
+	RequestTypeAnnotateRun      RequestType = "annotate_run"       // Replace the note of a run
+	RequestTypeSetPinned        RequestType = "set_pinned"         // Pin or unpin a job
+	RequestTypeReload           RequestType = "reload"             // Send a job its reload signal, keeping the run
+	RequestTypeMarkStuck        RequestType = "mark_stuck"         // Record that a client saw the current run stuck
+	RequestTypeExportRuns       RequestType = "export_runs"        // A page of runs with their job, read from the database
+	RequestTypeShellPresence    RequestType = "shell_presence"     // Heartbeat of a shell reporting its project
+	RequestTypeSetSlowThreshold RequestType = "set_slow_threshold" // Set when the runs of a job count as slow
+
+	RequestTypeGatewayStart  RequestType = "gateway_start"  // Start the HTTP gateway
+	RequestTypeGatewayStop   RequestType = "gateway_stop"   // Stop the HTTP gateway
+	RequestTypeGatewayStatus RequestType = "gateway_status" // Address of the HTTP gateway
+
+	RequestTypeHealth RequestType = "health" // Uptime, paths, counts, last error and background tasks of the daemon
 )
 
 // EventType represents the type of event emitted by the daemon
@@ -63,28 +107,49 @@ const (
 	EventTypeRunStarted   EventType = "run_started"
 	EventTypeRunStopped   EventType = "run_stopped"
 	EventTypeRunRemoved   EventType = "run_removed"
+	EventTypeRunUpdated   EventType = "run_updated" // A run's note or markers changed
+	EventTypeJobSlow      EventType = "job_slow"    // The current run passed the job's slow threshold
 	EventTypePortsUpdated EventType = "ports_updated"
+	EventTypeSnapshot     EventType = "snapshot" // Current jobs and runs, sent to subscribers that ask for it
+	EventTypeResync       EventType = "resync"   // Current jobs and runs, sent instead of a burst of events to subscribers that coalesce them
+
+	EventTypeApprovalRequested EventType = "approval_requested" // A request is waiting for approval
+	EventTypeApprovalDecided   EventType = "approval_decided"   // A request waiting for approval was approved, denied or timed out
 )
 
 // Event represents a job/run state change event
 type Event struct {
-	Type            EventType    `json:"type"`
-	JobID           string       `json:"job_id"`
-	Job             JobResponse  `json:"job"`
-	Run             *RunResponse `json:"run,omitempty"`
-	Ports           []PortInfo   `json:"ports,omitempty"` // For EventTypePortsUpdated
-	JobCount        int          `json:"job_count"`
-	RunningJobCount int          `json:"running_job_count"`
+	Seq             uint64        `json:"seq"`            // Increases by one with each event; a snapshot has the seq of the last event it includes
+	Time            string        `json:"time,omitempty"` // RFC3339, UTC
+	Type            EventType     `json:"type"`
+	JobID           string        `json:"job_id"`
+	Job             JobResponse   `json:"job"`
+	Run             *RunResponse  `json:"run,omitempty"`
+	Ports           []PortInfo    `json:"ports,omitempty"`    // For EventTypePortsUpdated
+	Jobs            []JobResponse `json:"jobs,omitempty"`     // For EventTypeSnapshot and EventTypeResync
+	Runs            []RunResponse `json:"runs,omitempty"`     // For EventTypeSnapshot and EventTypeResync
+	Approval        *Approval     `json:"approval,omitempty"` // For EventTypeApprovalRequested and EventTypeApprovalDecided
+	JobCount        int           `json:"job_count"`
+	RunningJobCount int           `json:"running_job_count"`
 }
 
 // Request represents a client request to the daemon
 type Request struct {
+	ID      uint64         `json:"id,omitempty"`      // tells the requests of a session apart, echoed in the response
+	Version int            `json:"version,omitempty"` // ProtocolVersion of the client; 0 for clients predating it
 	Type    RequestType    `json:"type"`
 	Payload map[string]any `json:"payload,omitempty"`
+	Client  string         `json:"client,omitempty"` // who sent the request, for rate limiting and the audit log
+
+	ClientType string `json:"client_type,omitempty"` // cli, tui or api
+
+	peerPID int    // process that sent the request, from the connection (0 if unknown)
+	connID  uint64 // connection that sent the request, its rate limit bucket (0 if none, e.g. the gateway)
 }
 
 // Response represents a daemon response to a client request
 type Response struct {
+	ID      uint64         `json:"id,omitempty"` // ID of the request in a session
 	Success bool           `json:"success"`
 	Error   string         `json:"error,omitempty"`
 	Data    map[string]any `json:"data,omitempty"`
@@ -92,20 +157,52 @@ type Response struct {
 
 // JobResponse represents a job in API responses
 type JobResponse struct {
-	ID          string     `json:"id"`
-	PID         int        `json:"pid"`
-	Status      string     `json:"status"`
-	Command     []string   `json:"command"`
-	Workdir     string     `json:"workdir"`
-	Description string     `json:"description,omitempty"`
-	Blocked     bool       `json:"blocked,omitempty"`
-	CreatedAt   string     `json:"created_at"`
-	StartedAt   string     `json:"started_at"`
-	StoppedAt   string     `json:"stopped_at,omitempty"`
-	StdoutPath  string     `json:"stdout_path"`
-	StderrPath  string     `json:"stderr_path"`
-	ExitCode    *int       `json:"exit_code,omitempty"`
-	Ports       []PortInfo `json:"ports,omitempty"` // Listening ports (only for running jobs)
+	ID            string     `json:"id"`
+	PID           int        `json:"pid"`
+	Status        string     `json:"status"`
+	Command       []string   `json:"command"`
+	Shell         string     `json:"shell,omitempty"` // shell running the script in command
+	Workdir       string     `json:"workdir"`
+	Alias         string     `json:"alias,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	Blocked       bool       `json:"blocked,omitempty"`
+	Pinned        bool       `json:"pinned,omitempty"`
+	Priority      Priority   `json:"priority,omitempty"`
+	MemoryLimit   int64      `json:"memory_limit,omitempty"` // bytes
+	CPULimit      float64    `json:"cpu_limit,omitempty"`    // cores
+	Hooks         *JobHooks  `json:"hooks,omitempty"`
+	Stdin         string     `json:"stdin,omitempty"`          // null, open or file path
+	LogFormat     string     `json:"log_format,omitempty"`     // text or json
+	Timestamps    bool       `json:"timestamps,omitempty"`     // runs record line timestamps
+	CombineOutput bool       `json:"combine_output,omitempty"` // stderr is interleaved into the stdout log
+	OutputHead    int64      `json:"output_head,omitempty"`    // bytes kept from the start of each log
+	OutputTail    int64      `json:"output_tail,omitempty"`    // bytes kept from the end of each log
+	LogDir        string     `json:"log_dir,omitempty"`        // directory of the run logs if not the daemon's
+	Container     string     `json:"container,omitempty"`      // image of the container runs are started in
+	DevEnv        string     `json:"dev_env,omitempty"`        // development environment runs are started in: nix or direnv
+	Sandbox       string     `json:"sandbox,omitempty"`        // sandbox runs are started in: project or readonly
+	RunDir        string     `json:"run_dir,omitempty"`        // directory runs execute in, if not the workdir
+	ProjectID     string     `json:"project_id,omitempty"`     // git repository of the workdir, if portable
+	ProjectPath   string     `json:"project_path,omitempty"`   // workdir relative to the repository root, if portable
+	ReloadSignal  int        `json:"reload_signal,omitempty"`  // signal sent by gob reload (0 = SIGHUP)
+	EnvOverrides  []string   `json:"env_overrides,omitempty"`  // KEY=VALUE variables set in every run
+	SlowThreshold string     `json:"slow_threshold,omitempty"` // when runs count as slow, e.g. 5m or 2x the p90
+	Tags          []string   `json:"tags,omitempty"`
+	CreatedAt     string     `json:"created_at"`
+	CreatedBy     string     `json:"created_by,omitempty"`  // name of the client that created the job
+	CreatedVia    string     `json:"created_via,omitempty"` // cli, tui or api
+	StartedAt     string     `json:"started_at"`
+	StartedBy     string     `json:"started_by,omitempty"`  // name of the client that started the current or latest run
+	StartedVia    string     `json:"started_via,omitempty"` // cli, tui or api
+	StoppedAt     string     `json:"stopped_at,omitempty"`
+	StdoutPath    string     `json:"stdout_path"`
+	StderrPath    string     `json:"stderr_path"`
+	ExitCode      *int       `json:"exit_code,omitempty"`
+	Ports         []PortInfo `json:"ports,omitempty"`         // Listening ports (only for running jobs)
+	PortsSummary  string     `json:"ports_summary,omitempty"` // e.g. ":3000,:5432", only in list responses asking for ports
+
+	// Bytes and lines of the output of the latest run, once stopped
+	Output *OutputStats `json:"output,omitempty"`
 
 	// Statistics (aggregated across all completed runs)
 	RunCount             int     `json:"run_count"`
@@ -116,31 +213,79 @@ type JobResponse struct {
 	FailureAvgDurationMs int64   `json:"failure_avg_duration_ms"` // Average of failed runs
 	MinDurationMs        int64   `json:"min_duration_ms"`
 	MaxDurationMs        int64   `json:"max_duration_ms"`
+
+	// Duration trend, only in stats responses (see JobManager.JobStats)
+	P50DurationMs        int64   `json:"p50_duration_ms,omitempty"` // percentiles of successful runs
+	P90DurationMs        int64   `json:"p90_duration_ms,omitempty"`
+	P99DurationMs        int64   `json:"p99_duration_ms,omitempty"`
+	SuccessMinDurationMs int64   `json:"success_min_duration_ms,omitempty"` // fastest successful run
+	SuccessMaxDurationMs int64   `json:"success_max_duration_ms,omitempty"`
+	FailureMinDurationMs int64   `json:"failure_min_duration_ms,omitempty"` // fastest failed run, killed ones excluded
+	FailureMaxDurationMs int64   `json:"failure_max_duration_ms,omitempty"`
+	FailureP50DurationMs int64   `json:"failure_p50_duration_ms,omitempty"`
+	LastDurationMs       int64   `json:"last_duration_ms,omitempty"` // most recent stopped run
+	Slowdown             float64 `json:"slowdown,omitempty"`         // last run / median of the previous successful runs, if slower by slowRunFactor
+
+	// Durations of successful runs by the conditions they started in, only in stats responses
+	ByCondition []ConditionStats `json:"by_condition,omitempty"`
 }
 
 // RunResponse represents a run in API responses
 type RunResponse struct {
-	ID         string `json:"id"`
-	JobID      string `json:"job_id"`
-	PID        int    `json:"pid"`
-	Status     string `json:"status"`
-	ExitCode   *int   `json:"exit_code,omitempty"`
-	StdoutPath string `json:"stdout_path"`
-	StderrPath string `json:"stderr_path"`
-	StartedAt  string `json:"started_at"`
-	StoppedAt  string `json:"stopped_at,omitempty"`
-	DurationMs int64  `json:"duration_ms"`
+	ID          string      `json:"id"`
+	JobID       string      `json:"job_id"`
+	PID         int         `json:"pid"`
+	Status      string      `json:"status"`
+	ExitCode    *int        `json:"exit_code,omitempty"`
+	StdoutPath  string      `json:"stdout_path"`
+	StderrPath  string      `json:"stderr_path"`
+	StartedAt   string      `json:"started_at"`
+	StoppedAt   string      `json:"stopped_at,omitempty"`
+	DurationMs  int64       `json:"duration_ms"`
+	LogBytes    *int64      `json:"log_bytes,omitempty"` // size of the log files once stopped
+	Note        string      `json:"note,omitempty"`
+	EnvSource   string      `json:"env_source,omitempty"` // client, previous or stored
+	Label       string      `json:"label,omitempty"`
+	StartedBy   string      `json:"started_by,omitempty"`  // name of the client that started the run
+	StartedVia  string      `json:"started_via,omitempty"` // cli, tui or api
+	Markers     []RunMarker `json:"markers,omitempty"`     // lifecycle events of the run, oldest first
+	ArchivedAt  string      `json:"archived_at,omitempty"` // set if archived, the log paths are then gzipped files
+	LoadAvg     *float64    `json:"load_avg,omitempty"`    // 1-minute load average per CPU when started
+	OnBattery   *bool       `json:"on_battery,omitempty"`
+	Dir         string      `json:"dir,omitempty"`          // directory the run executed in, if not its job's workdir
+	LogsMissing bool        `json:"logs_missing,omitempty"` // its log files were deleted outside of gob
+
+	Output *OutputStats `json:"output,omitempty"` // bytes and lines of its output once stopped
+}
+
+// HistoryEntry is a run together with the job it belongs to
+type HistoryEntry struct {
+	RunResponse
+	Command []string `json:"command"`
+	Workdir string   `json:"workdir"`
 }
 
 // AddResponse represents the response from adding a job
 type AddResponse struct {
 	Job    JobResponse `json:"job"`
-	Action string      `json:"action"` // "created", "started", or "already_running"
+	Action string      `json:"action"` // "created", "started", "already_running", or "deduplicated"
+
+	// Set for run requests that reused an existing run instead of starting one
+	Deduplicated bool         `json:"deduplicated,omitempty"`
+	Run          *RunResponse `json:"run,omitempty"`
+}
+
+// GatewayInfo describes a running HTTP gateway
+type GatewayInfo struct {
+	Addr  string `json:"addr"`  // host:port the gateway listens on
+	URL   string `json:"url"`   // base URL of the API
+	Token string `json:"token"` // bearer token required by every request
 }
 
 // NewRequest creates a new request with the given type
 func NewRequest(reqType RequestType) *Request {
 	return &Request{
+		Version: ProtocolVersion,
 		Type:    reqType,
 		Payload: make(map[string]interface{}),
 	}
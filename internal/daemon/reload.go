@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// reloadSignal returns the signal gob reload sends to the job: the one set
+// with --reload-signal, or SIGHUP
+func (j *Job) reloadSignal() syscall.Signal {
+	if j.ReloadSignal != 0 {
+		return syscall.Signal(j.ReloadSignal)
+	}
+	return syscall.SIGHUP
+}
+
+// ReloadJob sends the job's reload signal to its current run and records a
+// reload marker on the run, which keeps going: servers that reload their
+// configuration on a signal do not get a new run.
+func (jm *JobManager) ReloadJob(jobID string) (*Run, syscall.Signal, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jobID]
+	if !ok {
+		return nil, 0, fmt.Errorf("job not found: %s", jobID)
+	}
+	if job.CurrentRunID == nil {
+		return nil, 0, fmt.Errorf("job %s is not running", jobID)
+	}
+	run := jm.runs[*job.CurrentRunID]
+	if run.process == nil {
+		return nil, 0, fmt.Errorf("job %s is not running", jobID)
+	}
+
+	// Through the process handle, which also reaches the run's cgroup
+	sig := job.reloadSignal()
+	if err := run.process.Signal(sig); err != nil && err != syscall.ESRCH {
+		return nil, 0, fmt.Errorf("failed to send signal: %w", err)
+	}
+
+	jm.addMarkerLocked(run, MarkerReloaded, sig.String())
+	return run, sig, nil
+}
@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+func TestJobManager_ReloadJob(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	var events []Event
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, func(e Event) { events = append(events, e) }, executor, store)
+
+	job, _, err := jm.AddJob([]string{"nginx"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	handle := executor.LastHandle()
+	runID := *job.CurrentRunID
+	events = nil
+
+	run, sig, err := jm.ReloadJob(job.ID)
+	if err != nil {
+		t.Fatalf("ReloadJob failed: %v", err)
+	}
+	if sig != syscall.SIGHUP || !slices.Equal(handle.SignalLog(), []syscall.Signal{syscall.SIGHUP}) {
+		t.Errorf("expected SIGHUP sent, got %v", handle.SignalLog())
+	}
+	if run.ID != runID || *job.CurrentRunID != runID {
+		t.Errorf("expected the run to keep going, got %s", *job.CurrentRunID)
+	}
+	if len(run.Markers) != 2 || run.Markers[0].Kind != MarkerStarted || run.Markers[1].Kind != MarkerReloaded {
+		t.Errorf("expected a start and a reload marker, got %v", run.Markers)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeRunUpdated || len(events[0].Run.Markers) != 2 {
+		t.Errorf("expected one run_updated event with the marker, got %v", events)
+	}
+
+	// Markers survive a restart
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || len(runs[0].Markers) != 2 || runs[0].Markers[1].Detail != run.Markers[1].Detail {
+		t.Errorf("expected the marker stored, got %+v", runs)
+	}
+
+	// A configured reload signal
+	jm.AddJob([]string{"nginx"}, "/workdir", JobOptions{ReloadSignal: int(syscall.SIGQUIT)}, nil)
+	if _, sig, _ := jm.ReloadJob(job.ID); sig != syscall.SIGQUIT {
+		t.Errorf("expected SIGQUIT, got %v", sig)
+	}
+
+	stopAndWait(t, jm, executor, job.ID)
+	if _, _, err := jm.ReloadJob(job.ID); err == nil {
+		t.Error("expected an error for a stopped job")
+	}
+}
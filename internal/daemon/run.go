@@ -6,19 +6,40 @@ import (
 
 // Run represents a single execution of a job
 type Run struct {
-	ID         string     `json:"id"`          // internal identifier (e.g., "abc-1", "abc-2")
-	JobID      string     `json:"job_id"`      // reference to Job
-	PID        int        `json:"pid"`         // process ID (0 if stopped)
-	Status     string     `json:"status"`      // "running" | "stopped"
-	ExitCode   *int       `json:"exit_code"`   // nil if running or killed
-	StdoutPath string     `json:"stdout_path"` // path to stdout log
-	StderrPath string     `json:"stderr_path"` // path to stderr log
-	StartedAt  time.Time  `json:"started_at"`
-	StoppedAt  *time.Time `json:"stopped_at,omitempty"` // nil if running
+	ID          string      `json:"id"`          // internal identifier (e.g., "abc-1", "abc-2")
+	JobID       string      `json:"job_id"`      // reference to Job
+	PID         int         `json:"pid"`         // process ID (0 if stopped)
+	Status      string      `json:"status"`      // "running" | "stopped"
+	ExitCode    *int        `json:"exit_code"`   // nil if running or killed
+	StdoutPath  string      `json:"stdout_path"` // path to stdout log
+	StderrPath  string      `json:"stderr_path"` // path to stderr log
+	StartedAt   time.Time   `json:"started_at"`
+	StoppedAt   *time.Time  `json:"stopped_at,omitempty"`   // nil if running
+	LogBytes    *int64      `json:"log_bytes,omitempty"`    // size of the log files once stopped, nil if unknown
+	DurationMs  *int64      `json:"duration_ms,omitempty"`  // exact duration once stopped, nil if only known from the timestamps
+	Note        string      `json:"note,omitempty"`         // set with gob annotate
+	EnvSource   string      `json:"env_source,omitempty"`   // where the run's environment came from (see EnvSourceClient), empty if unknown
+	Label       string      `json:"label,omitempty"`        // groups runs started for the same purpose, e.g. pr-1234 (see gob summary)
+	StartedBy   string      `json:"started_by,omitempty"`   // name of the client that started the run, empty if unknown
+	StartedVia  string      `json:"started_via,omitempty"`  // type of the client that started the run: cli, tui or api
+	Markers     []RunMarker `json:"markers,omitempty"`      // lifecycle events of the run, oldest first
+	ArchivedAt  *time.Time  `json:"archived_at,omitempty"`  // nil unless archived (see ArchiveRuns)
+	LoadAvg     *float64    `json:"load_avg,omitempty"`     // 1-minute load average per CPU when started, nil if unknown
+	OnBattery   *bool       `json:"on_battery,omitempty"`   // whether the machine ran on battery when started, nil if unknown
+	Dir         string      `json:"dir,omitempty"`          // directory the run executed in, empty if its job's workdir
+	LogsMissing bool        `json:"logs_missing,omitempty"` // its log files were deleted outside of gob (see checkRunLogs)
+
+	// Bytes and lines of its output once stopped, nil if unknown (see finishRunLogs)
+	Output *OutputStats `json:"output,omitempty"`
 
 	// Internal fields for process management
-	process ProcessHandle
-	Ports   []PortInfo // In-memory only, not persisted - listening ports for this run
+	process    ProcessHandle
+	cgroupPath string        // dedicated cgroup of the run, empty if none
+	container  *runContainer // container the run's command runs in, nil if on the host
+	env        []string      // environment the run was started with (for hooks)
+	done       chan struct{} // closed once the run is recorded as stopped, nil if its process is not watched
+	slowTimer  *time.Timer   // fires when the run passes its job's slow threshold, nil if none
+	Ports      []PortInfo    // In-memory only, not persisted - listening ports for this run
 }
 
 // IsRunning checks if the run's process is still running
@@ -29,6 +50,20 @@ func (r *Run) IsRunning() bool {
 	return r.process.IsRunning()
 }
 
+// waitStopped waits up to timeout for the run to be recorded as stopped,
+// once its processes exited. Returns false if it was not.
+func (r *Run) waitStopped(timeout time.Duration) bool {
+	if r.done == nil {
+		return true
+	}
+	select {
+	case <-r.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // GetStatus returns "running" or "stopped" based on the process state
 func (r *Run) GetStatus() string {
 	if r.IsRunning() {
@@ -39,6 +74,9 @@ func (r *Run) GetStatus() string {
 
 // Duration returns the duration of the run, or time since start if still running
 func (r *Run) Duration() time.Duration {
+	if r.DurationMs != nil {
+		return time.Duration(*r.DurationMs) * time.Millisecond
+	}
 	if r.StoppedAt != nil {
 		return r.StoppedAt.Sub(r.StartedAt)
 	}
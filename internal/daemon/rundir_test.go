@@ -0,0 +1,69 @@
+package daemon
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJobManager_RunDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, store)
+
+	// Runs execute in the run directory, the job stays in its workdir
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/repo/pkg/api", JobOptions{RunDir: "/repo"}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Workdir != "/repo/pkg/api" || job.RunDir != "/repo" {
+		t.Errorf("expected workdir /repo/pkg/api and run dir /repo, got %q and %q", job.Workdir, job.RunDir)
+	}
+	if dir := executor.LastWorkdir(); dir != "/repo" {
+		t.Errorf("expected the run started in /repo, got %q", dir)
+	}
+	if run := jm.GetCurrentRun(job.ID); run.Dir != "/repo" {
+		t.Errorf("expected the run to record /repo, got %q", run.Dir)
+	}
+
+	// Both directories are stored
+	jobs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].RunDir != "/repo" {
+		t.Errorf("expected the run dir stored, got %+v", jobs)
+	}
+	runs, err := store.LoadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Dir != "/repo" {
+		t.Errorf("expected the run's dir stored, got %+v", runs)
+	}
+
+	// Later runs keep it, the workdir itself clears it
+	stopAndWait(t, jm, executor, job.ID)
+	if _, _, err := jm.AddJob([]string{"make", "test"}, "/repo/pkg/api", JobOptions{}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if dir := executor.LastWorkdir(); dir != "/repo" {
+		t.Errorf("expected the run dir kept, got %q", dir)
+	}
+	stopAndWait(t, jm, executor, job.ID)
+	if _, _, err := jm.AddJob([]string{"make", "test"}, "/repo/pkg/api", JobOptions{RunDir: "/repo/pkg/api"}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if dir := executor.LastWorkdir(); dir != "/repo/pkg/api" || job.RunDir != "" {
+		t.Errorf("expected the run dir cleared, got %q (run dir %q)", dir, job.RunDir)
+	}
+	if run := jm.GetCurrentRun(job.ID); run.Dir != "" {
+		t.Errorf("expected no dir recorded for a run in the workdir, got %q", run.Dir)
+	}
+}
@@ -0,0 +1,80 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environments a run can start with, recorded on the run
+const (
+	EnvSourceClient   = "client"   // the environment of the client starting the run (default)
+	EnvSourcePrevious = "previous" // the environment of the job's previous run
+	EnvSourceStored   = "stored"   // the daemon's environment with the job's stored overrides only
+)
+
+// ValidateEnvSource checks that an environment source is known
+func ValidateEnvSource(source string) error {
+	switch source {
+	case EnvSourceClient, EnvSourcePrevious, EnvSourceStored:
+		return nil
+	}
+	return fmt.Errorf("invalid environment source %q (expected client, previous or stored)", source)
+}
+
+// ValidateEnvOverrides checks that stored overrides are KEY=VALUE pairs
+func ValidateEnvOverrides(overrides []string) error {
+	for _, kv := range overrides {
+		if name, _, ok := strings.Cut(kv, "="); !ok || name == "" {
+			return fmt.Errorf("invalid environment variable %q (expected KEY=VALUE)", kv)
+		}
+	}
+	return nil
+}
+
+// withEnvOverrides returns a copy of env with the overrides set. A nil env
+// stands for the daemon's environment.
+func withEnvOverrides(env, overrides []string) []string {
+	if len(overrides) == 0 {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+
+	result := make([]string, 0, len(env)+len(overrides))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if !envHasName(overrides, name) {
+			result = append(result, kv)
+		}
+	}
+	return append(result, overrides...)
+}
+
+// envHasName reports whether env sets the variable name
+func envHasName(env []string, name string) bool {
+	for _, kv := range env {
+		if n, _, _ := strings.Cut(kv, "="); n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runEnvLocked returns the environment of a job's next run for the given
+// source, and the source it actually came from: the previous run's
+// environment is only known while the daemon that started it runs, else
+// the client's is used (caller must hold lock)
+func (jm *JobManager) runEnvLocked(job *Job, clientEnv []string, source string) ([]string, string) {
+	switch source {
+	case EnvSourcePrevious:
+		previousID := fmt.Sprintf("%s-%d", job.ID, job.NextRunSeq-1)
+		if previous := jm.runs[previousID]; previous != nil && previous.env != nil {
+			return previous.env, EnvSourcePrevious
+		}
+	case EnvSourceStored:
+		return withEnvOverrides(os.Environ(), job.EnvOverrides), EnvSourceStored
+	}
+	return withEnvOverrides(jm.filterEnvLocked(job, clientEnv), job.EnvOverrides), EnvSourceClient
+}
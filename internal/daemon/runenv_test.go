@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWithEnvOverrides(t *testing.T) {
+	got := withEnvOverrides([]string{"A=1", "B=2"}, []string{"B=3", "C=4"})
+	if want := []string{"A=1", "B=3", "C=4"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if err := ValidateEnvOverrides([]string{"NOVALUE"}); err == nil {
+		t.Error("expected an error for an override without a value")
+	}
+}
+
+func TestJobManager_RunEnvSources(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"server"}, "/workdir", JobOptions{EnvOverrides: []string{"PORT=3000"}}, []string{"TOKEN=old"})
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	lastRun := func() *Run {
+		t.Helper()
+		jm.mu.RLock()
+		defer jm.mu.RUnlock()
+		return jm.runs[*job.CurrentRunID]
+	}
+
+	run := lastRun()
+	if run.EnvSource != EnvSourceClient || !slices.Equal(run.env, []string{"TOKEN=old", "PORT=3000"}) {
+		t.Errorf("expected the client's environment with the overrides, got %s %v", run.EnvSource, run.env)
+	}
+
+	// previous keeps the environment of the first run
+	stopAndWait(t, jm, executor, job.ID)
+	if err := jm.RestartJob(job.ID, []string{"TOKEN=new"}, EnvSourcePrevious, Origin{}); err != nil {
+		t.Fatalf("RestartJob failed: %v", err)
+	}
+	run = lastRun()
+	if run.EnvSource != EnvSourcePrevious || !slices.Contains(run.env, "TOKEN=old") {
+		t.Errorf("expected the previous run's environment, got %s %v", run.EnvSource, run.env)
+	}
+
+	// stored ignores the client's environment
+	stopAndWait(t, jm, executor, job.ID)
+	if err := jm.StartJob(job.ID, []string{"TOKEN=new"}, EnvSourceStored, Origin{}); err != nil {
+		t.Fatalf("StartJob failed: %v", err)
+	}
+	run = lastRun()
+	if run.EnvSource != EnvSourceStored || slices.Contains(run.env, "TOKEN=new") || !slices.Contains(run.env, "PORT=3000") {
+		t.Errorf("expected the daemon's environment with the overrides, got %s %v", run.EnvSource, run.env)
+	}
+}
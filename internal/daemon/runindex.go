@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The manager keeps runs by ID in jm.runs, and by job in jm.jobRuns, oldest
+// first, so that the runs of a job are found without going through the
+// whole history. Runs are only added and removed through these functions.
+
+// addRunLocked adds a run (caller must hold lock)
+func (jm *JobManager) addRunLocked(run *Run) {
+	jm.runs[run.ID] = run
+
+	// New runs start after the others, but loaded runs come in any order
+	runs := jm.jobRuns[run.JobID]
+	i := sort.Search(len(runs), func(i int) bool {
+		return compareRuns(runs[i], run) > 0
+	})
+	jm.jobRuns[run.JobID] = slices.Insert(runs, i, run)
+}
+
+// deleteRunLocked removes a run (caller must hold lock)
+func (jm *JobManager) deleteRunLocked(run *Run) {
+	delete(jm.runs, run.ID)
+
+	runs := slices.DeleteFunc(jm.jobRuns[run.JobID], func(r *Run) bool { return r == run })
+	if len(runs) == 0 {
+		delete(jm.jobRuns, run.JobID)
+		return
+	}
+	jm.jobRuns[run.JobID] = runs
+}
+
+// jobRunsLocked returns the runs of a job, oldest first (caller must hold
+// lock). The slice belongs to the manager and must not be modified.
+func (jm *JobManager) jobRunsLocked(jobID string) []*Run {
+	return jm.jobRuns[jobID]
+}
+
+// compareRuns orders runs of a job by start time, then by sequence number,
+// as stored start times only have a precision of one second
+func compareRuns(a, b *Run) int {
+	return cmp.Or(a.StartedAt.Truncate(time.Second).Compare(b.StartedAt.Truncate(time.Second)), cmp.Compare(runSeq(a.ID), runSeq(b.ID)))
+}
+
+// runSeq returns the sequence number of a run in its job, the suffix of
+// its ID (0 if it has none)
+func runSeq(runID string) int {
+	seq, _ := strconv.Atoi(runID[strings.LastIndex(runID, "-")+1:])
+	return seq
+}
@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestJobManager_RunIndex(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	job, _ := jm.CreateJob([]string{"make", "build"}, "/workdir", JobOptions{})
+
+	// Runs loaded from the store come in any order
+	now := time.Now()
+	for _, n := range []int{2, 3, 1} {
+		jm.addRunLocked(&Run{ID: fmt.Sprintf("%s-%d", job.ID, n), JobID: job.ID, Status: "stopped", StartedAt: now.Add(time.Duration(n) * time.Minute)})
+	}
+	runs, _ := jm.ListRunsForJob(job.ID)
+	if len(runs) != 3 || runs[0].ID != job.ID+"-3" || runs[2].ID != job.ID+"-1" {
+		t.Fatalf("expected the runs newest first, got %v", runs)
+	}
+	if latest := jm.GetLatestRun(job.ID); latest == nil || latest.ID != job.ID+"-3" {
+		t.Errorf("expected the latest run to be %s-3, got %v", job.ID, latest)
+	}
+
+	if err := jm.RemoveRun(job.ID + "-3"); err != nil {
+		t.Fatalf("RemoveRun failed: %v", err)
+	}
+	if latest := jm.GetLatestRun(job.ID); latest == nil || latest.ID != job.ID+"-2" {
+		t.Errorf("expected the latest run to be %s-2 after removing the newest, got %v", job.ID, latest)
+	}
+
+	if err := jm.RemoveJob(job.ID, false); err != nil {
+		t.Fatalf("RemoveJob failed: %v", err)
+	}
+	if len(jm.runs) != 0 || len(jm.jobRuns) != 0 {
+		t.Errorf("expected no runs left, got %d runs and %d indexed jobs", len(jm.runs), len(jm.jobRuns))
+	}
+}
+
+// benchmarkManager returns a manager with 100 jobs of 100 stopped runs each,
+// like a daemon that has been used for months
+func benchmarkManager(b *testing.B) *JobManager {
+	jm := NewJobManagerWithExecutor(b.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	for i := range 100 {
+		job, err := jm.CreateJob([]string{"make", fmt.Sprint(i)}, "/workdir", JobOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for range 100 {
+			addStoppedRun(jm, job, 0, 1000)
+		}
+	}
+	return jm
+}
+
+// BenchmarkJobManager_List measures the work of 'gob list' with 10k runs
+func BenchmarkJobManager_List(b *testing.B) {
+	jm := benchmarkManager(b)
+	d := &Daemon{jobManager: jm}
+	req := NewTypedRequest(RequestTypeList, ListPayload{Workdir: "/workdir"})
+
+	for b.Loop() {
+		if resp := d.handleList(req); !resp.Success {
+			b.Fatal(resp.Error)
+		}
+	}
+}
+
+// BenchmarkDaemon_Snapshot measures the snapshot the TUI and the dashboard
+// refresh from, with 10k runs
+func BenchmarkDaemon_Snapshot(b *testing.B) {
+	jm := benchmarkManager(b)
+	d := &Daemon{jobManager: jm}
+
+	for b.Loop() {
+		d.snapshotEvent("/workdir", 0)
+	}
+}
+
+// BenchmarkJobManager_ListRunsForJob measures the runs panel of the TUI
+func BenchmarkJobManager_ListRunsForJob(b *testing.B) {
+	jm := benchmarkManager(b)
+	jobID := jm.ListJobs("")[0].ID
+
+	for b.Loop() {
+		if _, err := jm.ListRunsForJob(jobID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestCompareRuns(t *testing.T) {
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	second := &Run{ID: "abc-2", StartedAt: startedAt.Add(300 * time.Millisecond)}
+	tenth := &Run{ID: "abc-10", StartedAt: startedAt}
+
+	// Within the same second, the sequence number decides
+	if compareRuns(second, tenth) >= 0 || compareRuns(tenth, second) <= 0 {
+		t.Error("expected run 2 before run 10 when started in the same second")
+	}
+	if later := (&Run{ID: "abc-1", StartedAt: startedAt.Add(time.Second)}); compareRuns(tenth, later) >= 0 {
+		t.Error("expected the earlier run first")
+	}
+}
@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Sandboxes runs can start in, restricting where their commands can write.
+// Meant for commands started by agents, so that a command run for them
+// can't write over the home directory. Every sandboxed run gets a scratch
+// directory of its own, removed when the run's process exits, as TMPDIR.
+const (
+	SandboxProject  = "project"  // writes allowed in the directory the run executes in and the scratch directory
+	SandboxReadonly = "readonly" // writes allowed in the scratch directory only
+	SandboxOff      = "off"      // removes the sandbox of a job
+)
+
+// sandboxOrEmpty returns the sandbox of a job for a sandbox option: off
+// removes it
+func sandboxOrEmpty(mode string) string {
+	if mode == SandboxOff {
+		return ""
+	}
+	return mode
+}
+
+// sandboxBackends are the tools sandboxes are set up with, by OS
+var sandboxBackends = map[string]string{
+	"linux":  "bwrap",
+	"darwin": "sandbox-exec",
+}
+
+// ValidateSandbox checks that a sandbox mode is known
+func ValidateSandbox(mode string) error {
+	switch mode {
+	case SandboxProject, SandboxReadonly, SandboxOff:
+		return nil
+	}
+	return fmt.Errorf("invalid sandbox %q (expected project, readonly or off)", mode)
+}
+
+// sandboxBackend returns the path of the tool sandboxes are set up with on
+// this OS
+func sandboxBackend() (string, error) {
+	name, ok := sandboxBackends[runtime.GOOS]
+	if !ok {
+		return "", fmt.Errorf("sandboxes need bubblewrap (Linux) or sandbox-exec (macOS)")
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		if name == "bwrap" {
+			return "", fmt.Errorf("sandboxes need bwrap: install bubblewrap")
+		}
+		return "", fmt.Errorf("sandboxes need %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// sandboxExecutor starts commands in a sandbox through a base executor
+type sandboxExecutor struct {
+	base ProcessExecutor
+	mode string
+}
+
+// Start starts the command in a sandbox with a new scratch directory
+func (e *sandboxExecutor) Start(command []string, workdir string, env []string, stdoutPath, stderrPath string, opts StartOptions) (ProcessHandle, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	backend, err := sandboxBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	scratch, err := os.MkdirTemp("", "gob-sandbox-"+opts.RunID+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	// Sandbox rules match the real paths (/tmp is /private/tmp on macOS)
+	scratch = NormalizeWorkdir(scratch)
+
+	var writable []string
+	if e.mode == SandboxProject {
+		writable = append(writable, NormalizeWorkdir(workdir))
+	}
+	writable = append(writable, scratch)
+
+	if env == nil {
+		env = os.Environ()
+	}
+	env = withEnvOverrides(env, []string{"TMPDIR=" + scratch})
+
+	args := sandboxCommand(filepath.Base(backend), backend, command, workdir, writable)
+	handle, err := e.base.Start(args, workdir, env, stdoutPath, stderrPath, opts)
+	if err != nil {
+		os.RemoveAll(scratch)
+		return nil, err
+	}
+	return &sandboxHandle{ProcessHandle: handle, scratch: scratch}, nil
+}
+
+// sandboxCommand returns the command running command with backend, allowed
+// to write only in the writable directories
+func sandboxCommand(name, backend string, command []string, workdir string, writable []string) []string {
+	if name == "sandbox-exec" {
+		return append([]string{backend, "-p", sandboxProfile(writable)}, command...)
+	}
+
+	// bubblewrap: the whole filesystem read-only, with fresh /dev and /proc,
+	// and the writable directories bound over it
+	args := []string{backend, "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc"}
+	for _, dir := range writable {
+		args = append(args, "--bind", dir, dir)
+	}
+	args = append(args, "--chdir", workdir, "--")
+	return append(args, command...)
+}
+
+// sandboxProfile returns the sandbox-exec profile allowing writes in the
+// writable directories and to terminals and /dev/null only
+func sandboxProfile(writable []string) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	b.WriteString("(allow file-write* (literal \"/dev/null\") (literal \"/dev/tty\") (subpath \"/dev/fd\")")
+	for _, dir := range writable {
+		b.WriteString(" (subpath " + strconv.Quote(dir) + ")")
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// sandboxHandle is the process of a sandboxed run, whose scratch directory
+// is removed once it exits
+type sandboxHandle struct {
+	ProcessHandle
+	scratch string
+}
+
+// Wait waits for the process to exit and removes its scratch directory
+func (h *sandboxHandle) Wait() error {
+	err := h.ProcessHandle.Wait()
+	if rmErr := os.RemoveAll(h.scratch); rmErr != nil {
+		Logger.Warn("failed to remove sandbox scratch directory", "path", h.scratch, "error", rmErr)
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSandboxCommand(t *testing.T) {
+	got := sandboxCommand("bwrap", "/usr/bin/bwrap", []string{"npm", "test"}, "/src/app", []string{"/src/app", "/tmp/scratch"})
+	want := []string{"/usr/bin/bwrap", "--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc",
+		"--bind", "/src/app", "/src/app", "--bind", "/tmp/scratch", "/tmp/scratch", "--chdir", "/src/app", "--", "npm", "test"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got = sandboxCommand("sandbox-exec", "/usr/bin/sandbox-exec", []string{"npm", "test"}, "/src/app", []string{"/tmp/scratch"})
+	if len(got) != 5 || got[1] != "-p" || !slices.Equal(got[3:], []string{"npm", "test"}) {
+		t.Fatalf("unexpected sandbox-exec command %v", got)
+	}
+	if profile := got[2]; !strings.Contains(profile, "(deny file-write*)") || !strings.Contains(profile, `(subpath "/tmp/scratch")`) || strings.Contains(profile, "/src/app") {
+		t.Errorf("unexpected profile:\n%s", profile)
+	}
+}
+
+func TestValidateSandbox(t *testing.T) {
+	for _, mode := range []string{SandboxProject, SandboxReadonly, SandboxOff} {
+		if err := ValidateSandbox(mode); err != nil {
+			t.Errorf("expected %q to be valid, got %v", mode, err)
+		}
+	}
+	if err := ValidateSandbox("strict"); err == nil {
+		t.Error("expected an error for an unknown sandbox")
+	}
+}
+
+func TestJobManager_Sandbox(t *testing.T) {
+	backend, ok := sandboxBackends[runtime.GOOS]
+	if !ok {
+		t.Skip("sandboxes are not supported on " + runtime.GOOS)
+	}
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, backend), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("PATH", bin)
+
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+	workdir := t.TempDir()
+
+	job, _, err := jm.AddJob([]string{"npm", "install"}, workdir, JobOptions{Sandbox: SandboxReadonly}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	command := executor.LastCommand()
+	if command[0] != filepath.Join(bin, backend) || !slices.Equal(command[len(command)-2:], []string{"npm", "install"}) {
+		t.Errorf("expected the command to run in the sandbox, got %v", command)
+	}
+
+	// The run gets a scratch TMPDIR, removed when it exits
+	var scratch string
+	for _, kv := range executor.LastEnv() {
+		if value, ok := strings.CutPrefix(kv, "TMPDIR="); ok {
+			scratch = value
+		}
+	}
+	if _, err := os.Stat(scratch); scratch == "" || err != nil {
+		t.Fatalf("expected a scratch TMPDIR, got %q (%v)", scratch, err)
+	}
+	stopAndWait(t, jm, executor, job.ID)
+	if _, err := os.Stat(scratch); !os.IsNotExist(err) {
+		t.Errorf("expected the scratch directory removed, got %v", err)
+	}
+
+	// off removes the sandbox
+	if _, _, err := jm.AddJob([]string{"npm", "install"}, workdir, JobOptions{Sandbox: SandboxOff}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if job.Sandbox != "" || executor.LastCommand()[0] != "npm" {
+		t.Errorf("expected the sandbox removed, got %q running %v", job.Sandbox, executor.LastCommand())
+	}
+
+	// Containers already isolate runs
+	if _, _, err := jm.AddJob([]string{"make"}, workdir, JobOptions{Sandbox: SandboxProject, Container: "alpine"}, nil); err == nil || !strings.Contains(err.Error(), "container") {
+		t.Errorf("expected an error for a sandbox in a container, got %v", err)
+	}
+}
+
+func TestJobManager_SandboxPersisted(t *testing.T) {
+	backend, ok := sandboxBackends[runtime.GOOS]
+	if !ok {
+		t.Skip("sandboxes are not supported on " + runtime.GOOS)
+	}
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, backend), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("PATH", bin)
+
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), store)
+	job, _, err := jm.AddJob([]string{"npm", "install"}, t.TempDir(), JobOptions{Sandbox: SandboxProject}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+
+	jobs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != job.ID || jobs[0].Sandbox != SandboxProject {
+		t.Errorf("expected the job reloaded with its sandbox, got %v", jobs)
+	}
+}
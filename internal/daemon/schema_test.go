@@ -0,0 +1,303 @@
+package daemon
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTripRequest encodes a typed request as a client would and decodes it
+// as the daemon does
+func roundTripRequest(t *testing.T, reqType RequestType, payload any, decoded any) *Request {
+	t.Helper()
+
+	data, err := EncodeRequest(NewTypedRequest(reqType, payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := DecodeRequest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decodePayload(req, decoded); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestSchema_RequestPayloads(t *testing.T) {
+	signal := 15
+	since := uint64(41)
+	on := true
+
+	tests := []struct {
+		reqType RequestType
+		payload any
+	}{
+		{RequestTypePing, struct{}{}},
+		{RequestTypeShutdown, struct{}{}},
+		{RequestTypeList, ListPayload{Workdir: "/workdir", Tag: "web", Ports: true}},
+		{RequestTypeAdd, AddPayload{
+			Command: []string{"npm", "run", "dev"},
+			Workdir: "/workdir",
+			Env:     []string{"PORT=3000"},
+			Label:   "pr-1234",
+			Project: &ProjectRef{ID: "0123456789abcdef", Path: "web"},
+			JobOptionsPayload: JobOptionsPayload{
+				Description:   "dev server",
+				Blocked:       true,
+				Priority:      "high",
+				MemoryLimit:   512 << 20,
+				CPULimit:      1.5,
+				JobHooks:      JobHooks{OnStart: "echo start", OnSuccess: "echo ok", OnFailure: "echo fail"},
+				Stdin:         "open",
+				LogFormat:     LogFormatJSON,
+				Timestamps:    &on,
+				CombineOutput: &on,
+				LogDir:        "/workdir/logs",
+				Container:     "node:20",
+				ReloadSignal:  10,
+				DevEnv:        DevEnvNix,
+				Sandbox:       SandboxProject,
+				RunDir:        "/",
+				OutputHead:    64 << 10,
+				OutputTail:    1 << 20,
+				Tags:          []string{"web", "dev"},
+			},
+		}},
+		{RequestTypeRun, AddPayload{Command: []string{"make"}, Workdir: "/workdir"}},
+		{RequestTypeCreate, AddPayload{Command: []string{"make"}, Workdir: "/workdir", JobOptionsPayload: JobOptionsPayload{Tags: []string{}}}},
+		{RequestTypeStop, StopPayload{JobID: "abc", Force: true}},
+		{RequestTypeStart, StartPayload{JobID: "abc", Env: []string{"A=1"}}},
+		{RequestTypeRestart, StartPayload{JobID: "abc", EnvSource: EnvSourcePrevious}},
+		{RequestTypeRemove, RemovePayload{JobID: "abc", Force: true}},
+		{RequestTypeStopAll, struct{}{}},
+		{RequestTypeSignal, SignalPayload{JobID: "abc", Signal: &signal}},
+		{RequestTypeGetJob, JobPayload{JobID: "abc"}},
+		{RequestTypeRuns, RunsPayload{JobID: "abc", Offset: 20, Limit: 10, IncludeArchived: true}},
+		{RequestTypeStats, StatsPayload{JobID: "abc", Conditions: []string{"battery", "busy"}}},
+		{RequestTypeSubscribe, SubscribePayload{Workdir: "/workdir", Snapshot: true, Since: &since, Coalesce: true, EventMatch: EventMatch{Types: []EventType{EventTypeJobStarted}, JobIDs: []string{"abc"}, Tags: []string{"web"}}}},
+		{RequestTypeSession, struct{}{}},
+		{RequestTypeVersion, VersionPayload{ClientVersion: "1.2.3", ProtocolVersion: ProtocolVersion}},
+		{RequestTypePorts, PortsPayload{Workdir: "/workdir"}},
+		{RequestTypeRemoveRun, RemoveRunPayload{RunID: "abc-1"}},
+		{RequestTypeAnnotateRun, AnnotateRunPayload{RunID: "abc-1", Note: "flaky"}},
+		{RequestTypeExportRuns, ExportRunsPayload{JobID: "abc", Workdir: "/workdir", Since: "2026-01-05T13:03:12Z", After: 42, Limit: 100}},
+		{RequestTypeShellPresence, ShellPresencePayload{PID: 1234, Project: "/project", StopJobs: []string{"abc"}, StopAfterMs: 60000}},
+		{RequestTypeSetAlias, SetAliasPayload{JobID: "abc", Alias: "web"}},
+		{RequestTypeSetDescription, SetDescriptionPayload{JobID: "abc", Description: "Dev server"}},
+		{RequestTypeSetPinned, SetPinnedPayload{JobID: "abc", Pinned: true}},
+		{RequestTypeSetSlowThreshold, SetSlowThresholdPayload{JobID: "abc", Threshold: "5m"}},
+		{RequestTypeReload, ReloadPayload{JobID: "abc"}},
+		{RequestTypeMarkStuck, JobPayload{JobID: "abc"}},
+		{RequestTypeBatch, BatchPayload{Action: BatchSignal, JobIDs: []string{"abc"}, Match: "npm *", Tag: "web", Before: "2026-01-02T03:04:05Z", FailedOnly: true, Workdir: "/workdir", DryRun: true, Force: true, Signal: &signal, Env: []string{"A=1"}}},
+		{RequestTypeHistory, HistoryPayload{Workdir: "/workdir", Label: "pr-1234", Offset: 10, Limit: 20}},
+		{RequestTypeGrep, GrepPayload{Pattern: "error", IgnoreCase: true, JobID: "abc", Workdir: "/workdir", Since: "2026-01-02T03:04:05Z", MaxMatches: 5}},
+		{RequestTypeLogLines, LogLinesPayload{JobID: "abc", Level: "warn"}},
+		{RequestTypeEvents, EventsPayload{Workdir: "/workdir", Since: "2026-01-02T03:04:05Z", After: 7}},
+		{RequestTypeHandover, struct{}{}},
+		{RequestTypeAdopt, AdoptPayload{PID: 1234, Command: []string{"npm", "run", "dev"}, Workdir: "/workdir"}},
+		{RequestTypeDiskUsage, DiskUsagePayload{Workdir: "/workdir"}},
+		{RequestTypeStatsAll, StatsAllPayload{Workdir: "/workdir", Since: "2026-10-09T00:00:00Z"}},
+		{RequestTypePruneLogs, PruneLogsPayload{Workdir: "/workdir", Keep: 2, Force: true}},
+		{RequestTypeCleanupLogs, CleanupLogsPayload{Workdir: "/workdir"}},
+		{RequestTypeArchiveRuns, ArchiveRunsPayload{Workdir: "/workdir", Before: "2026-01-02T15:04:05Z"}},
+		{RequestTypeAudit, AuditPayload{Since: "2026-01-02T03:04:05Z", Client: "claude", Limit: 20}},
+		{RequestTypeApprovals, struct{}{}},
+		{RequestTypeApprove, ApprovePayload{ApprovalID: 3, Action: "deny"}},
+		{RequestTypeOrphans, struct{}{}},
+		{RequestTypeKillOrphans, KillOrphansPayload{RunIDs: []string{"abc-2"}, Force: true}},
+		{RequestTypeGatewayStart, GatewayStartPayload{Addr: "127.0.0.1:0"}},
+		{RequestTypeGatewayStop, struct{}{}},
+		{RequestTypeGatewayStatus, struct{}{}},
+		{RequestTypeHealth, struct{}{}},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		covered[string(tt.reqType)] = true
+		t.Run(string(tt.reqType), func(t *testing.T) {
+			decoded := reflect.New(reflect.TypeOf(tt.payload))
+			req := roundTripRequest(t, tt.reqType, tt.payload, decoded.Interface())
+
+			if req.Version != ProtocolVersion {
+				t.Errorf("expected version %d, got %d", ProtocolVersion, req.Version)
+			}
+			if req.Type != tt.reqType {
+				t.Errorf("expected type %s, got %s", tt.reqType, req.Type)
+			}
+			if got := decoded.Elem().Interface(); !reflect.DeepEqual(got, tt.payload) {
+				t.Errorf("payload changed in transit:\nsent: %+v\ngot:  %+v", tt.payload, got)
+			}
+		})
+	}
+
+	// Every request type the daemon announces has a payload above
+	for _, capability := range Capabilities {
+		if !strings.Contains(capability, ".") && !covered[capability] {
+			t.Errorf("no payload test for request type %s", capability)
+		}
+	}
+}
+
+func TestSchema_ResponseData(t *testing.T) {
+	exitCode := 1
+	job := JobResponse{
+		ID:       "abc",
+		PID:      1234,
+		Status:   "running",
+		Command:  []string{"npm", "run", "dev"},
+		Workdir:  "/workdir",
+		Priority: PriorityHigh,
+		Hooks:    &JobHooks{OnStart: "echo start"},
+		Tags:     []string{"web"},
+		ExitCode: &exitCode,
+		Ports:    []PortInfo{{Port: 3000, Protocol: "tcp", Address: "0.0.0.0", PID: 1234}},
+	}
+	run := RunResponse{ID: "abc-1", JobID: "abc", PID: 1234, Status: "stopped", ExitCode: &exitCode, DurationMs: 1500, Note: "flaky"}
+
+	tests := []struct {
+		name string
+		data any
+	}{
+		{"message", MessageData{Message: "subscribed", Seq: 41}},
+		{"list", ListData{Jobs: []JobResponse{job}}},
+		{"job", JobData{Job: job}},
+		{"add", AddResponse{Job: job, Action: "already_running", Deduplicated: true, Run: &run}},
+		{"stop", StopData{JobID: "abc", PID: 1234, Force: true}},
+		{"remove", RemoveData{JobID: "abc", PID: 1234}},
+		{"remove_run", RemoveRunData{RunID: "abc-1"}},
+		{"annotate_run", RunData{Run: run}},
+		{"export_runs", ExportRunsData{Runs: []HistoryEntry{{RunResponse: run, Command: []string{"make"}, Workdir: "/workdir"}}, Next: 42}},
+		{"shell_presence", ShellPresenceData{Entered: true}},
+		{"stop_all", StopAllData{Stopped: 3}},
+		{"signal", SignalData{JobID: "abc", PID: 1234, Signal: 15}},
+		{"version", VersionData{Version: "1.2.3", ProtocolVersion: ProtocolVersion, RunningJobs: 2, Capabilities: Capabilities}},
+		{"runs", RunsData{Runs: []RunResponse{run}}},
+		{"history", HistoryData{Runs: []HistoryEntry{{RunResponse: run, Command: []string{"make"}, Workdir: "/workdir"}}, Total: 9}},
+		{"job ports", JobPortsData{Ports: &JobPorts{JobID: "abc", PID: 1234, Ports: job.Ports, Status: "running"}}},
+		{"all ports", AllPortsData{Ports: []JobPorts{{JobID: "abc", PID: 1234, Ports: job.Ports, Status: "running"}}}},
+		{"grep", GrepData{Matches: []GrepMatch{{JobID: "abc", RunID: "abc-1", Stream: "stdout", Line: 3, Text: "error", StartedAt: "2026-01-02T03:04:05Z"}}, Truncated: true}},
+		{"log_lines", LogLinesData{Lines: []string{`{"level":"error"}`}}},
+		{"batch", BatchData{Results: []BatchResult{{JobID: "abc", Success: true, PID: 1234, Job: &job}, {JobID: "def", Error: "job not found: def"}}}},
+		{"events", EventsData{Events: []Event{{Seq: 7, Type: EventTypeRunStarted, JobID: "abc", Job: job, Run: &run, JobCount: 1}}, Seq: 7}},
+		{"gateway", GatewayData{Gateway: &GatewayInfo{Addr: "127.0.0.1:7070", URL: "http://127.0.0.1:7070", Token: "secret"}}},
+		{"gateway not running", GatewayData{}},
+		{"gateway_stop", GatewayStopData{Stopped: true}},
+		{"health", HealthData{Version: "1.2.3", PID: 1234, StartedAt: "2026-01-02T03:04:05Z", UptimeMs: 60000, SocketPath: "/run/gob/daemon.sock", DatabasePath: "/state/gob/state.db", DatabaseBytes: 4096, LogPath: "/run/gob/daemon.log", Subscribers: 2, Jobs: 3, RunningJobs: 1, Runs: 9, LastError: &DaemonError{Time: "2026-01-02T03:04:05Z", Message: "failed to scan ports"}, Components: []ComponentHealth{{Name: "gateway", State: "stopped"}}}},
+		{"handover", HandoverData{Runs: 2}},
+		{"stats_all", StatsAllData{Jobs: []JobTimeSpent{{JobID: "abc", Command: []string{"make"}, Workdir: "/workdir", RunCount: 10, FailureCount: 3, SuccessRate: 70, AvgDurationMs: 1500, RecentRuns: 4, RecentDurationMs: 6000}}, TotalDurationMs: 6000}},
+		{"disk_usage", DiskUsageData{Jobs: []JobDiskUsage{{JobID: "abc", Command: []string{"make"}, Workdir: "/workdir", LogDir: "/workdir/logs", Runs: 3, Bytes: 2048}}, TotalBytes: 2048}},
+		{"prune_logs", PruneLogsData{RunsRemoved: 2, BytesFreed: 1024}},
+		{"cleanup_logs", CleanupLogsData{RunsRemoved: 3}},
+		{"archive_runs", ArchiveRunsData{RunsArchived: 2, BytesSaved: 1024}},
+		{"audit", AuditData{Entries: []AuditEntry{{ID: 1, Time: "2026-01-02T03:04:05Z", Client: "claude", Type: RequestTypeRestart, Target: "abc", Error: "job not found: abc", DurationMs: 3}}}},
+		{"approvals", ApprovalsData{Approvals: []Approval{{ID: 1, Time: "2026-01-02T03:04:05Z", Client: "claude", Type: RequestTypeRemove, Target: "abc", Reason: "remove"}}}},
+		{"orphans", OrphansData{Orphans: []Orphan{{RunID: "abc-2", JobID: "abc", Command: []string{"npm", "run", "dev"}, Workdir: "/workdir", Processes: []OrphanProcess{{PID: 1234, PPID: 1, Command: "node server.js"}}}}}},
+		{"approve", ApproveData{Approval: Approval{ID: 1, Time: "2026-01-02T03:04:05Z", Client: "claude", Type: RequestTypeStopAll, Reason: "stop_all", Decision: "approved by gob"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := EncodeResponse(NewDataResponse(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := DecodeResponse(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !resp.Success {
+				t.Fatal("expected success")
+			}
+
+			decoded := reflect.New(reflect.TypeOf(tt.data))
+			if err := resp.Decode(decoded.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if got := decoded.Elem().Interface(); !reflect.DeepEqual(got, tt.data) {
+				t.Errorf("data changed in transit:\nsent: %+v\ngot:  %+v", tt.data, got)
+			}
+		})
+	}
+}
+
+func TestSchema_JSONFieldsMatchEncoding(t *testing.T) {
+	fields := jsonFields(AddPayload{
+		Command:           []string{"make"},
+		Workdir:           "/workdir",
+		JobOptionsPayload: JobOptionsPayload{JobHooks: JobHooks{OnStart: "echo start"}},
+	})
+
+	// Embedded structs are inlined, empty omitempty fields are left out
+	// and tags is always present
+	expected := map[string]any{
+		"command":   []string{"make"},
+		"workdir":   "/workdir",
+		HookOnStart: "echo start",
+		"tags":      []string(nil),
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected %#v, got %#v", expected, fields)
+	}
+}
+
+func TestSchema_TagsNullKeepsAndEmptyClears(t *testing.T) {
+	var kept AddPayload
+	roundTripRequest(t, RequestTypeAdd, AddPayload{Command: []string{"make"}, Workdir: "/workdir"}, &kept)
+	if kept.Tags != nil {
+		t.Errorf("expected nil tags, got %#v", kept.Tags)
+	}
+
+	var cleared AddPayload
+	roundTripRequest(t, RequestTypeAdd, AddPayload{Command: []string{"make"}, Workdir: "/workdir", JobOptionsPayload: JobOptionsPayload{Tags: []string{}}}, &cleared)
+	if cleared.Tags == nil || len(cleared.Tags) != 0 {
+		t.Errorf("expected empty tags, got %#v", cleared.Tags)
+	}
+}
+
+func TestSchema_WrongFieldTypeIsAnError(t *testing.T) {
+	req := NewRequest(RequestTypeStop)
+	req.Payload["job_id"] = 12
+
+	var p StopPayload
+	err := decodePayload(req, &p)
+	if err == nil || !strings.Contains(err.Error(), "invalid stop payload") {
+		t.Errorf("expected invalid payload error, got %v", err)
+	}
+
+	d := &Daemon{jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)}
+	if resp := d.handleRequest(req); resp.Success || !strings.Contains(resp.Error, "invalid stop payload") {
+		t.Errorf("expected invalid payload response, got %+v", resp)
+	}
+}
+
+func TestSchema_ProtocolVersion(t *testing.T) {
+	d := &Daemon{jobManager: NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)}
+
+	// Clients predating versions send none
+	legacy := &Request{Type: RequestTypePing}
+	if resp := d.handleRequest(legacy); !resp.Success {
+		t.Errorf("expected request without version to succeed, got %s", resp.Error)
+	}
+
+	future := NewRequest(RequestTypePing)
+	future.Version = ProtocolVersion + 1
+	resp := d.handleRequest(future)
+	if resp.Success || !strings.Contains(resp.Error, "unsupported protocol version") {
+		t.Errorf("expected unsupported protocol version error, got %+v", resp)
+	}
+}
+
+func TestVersionInfo_Supports(t *testing.T) {
+	info := &VersionInfo{Capabilities: Capabilities}
+	if !info.Supports("subscribe.replay") {
+		t.Error("expected subscribe.replay to be supported")
+	}
+	if info.Supports("teleport") {
+		t.Error("expected unknown capability to be unsupported")
+	}
+	if (&VersionInfo{}).Supports(string(RequestTypePing)) {
+		t.Error("expected daemons without capabilities to support nothing")
+	}
+}
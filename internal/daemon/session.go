@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// handleSession answers a session request, then serves the requests sent on
+// the connection until the client closes it. Requests are handled
+// concurrently, so responses may come out of order: each carries the ID of
+// its request.
+func (d *Daemon) handleSession(req *Request, conn net.Conn, decoder *json.Decoder, encoder *json.Encoder) {
+	defer conn.Close()
+
+	if err := checkProtocolVersion(req); err != nil {
+		d.sendErrorResponse(encoder, err)
+		return
+	}
+
+	var mu sync.Mutex
+	send := func(resp *Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(resp); err != nil {
+			Logger.Error("error encoding response", "error", err)
+		}
+	}
+	send(NewSuccessResponse())
+
+	var wg sync.WaitGroup
+	for {
+		var r Request
+		if err := decoder.Decode(&r); err != nil {
+			break
+		}
+		// Requests are the session's: they share its rate limit, and can't
+		// pass for other clients
+		r.peerPID, r.connID = req.peerPID, req.connID
+		if req.Client != "" {
+			r.Client = req.Client
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var resp Response
+			switch r.Type {
+			case RequestTypeSubscribe, RequestTypeSession:
+				resp = *NewErrorResponse(fmt.Errorf("%s needs its own connection", r.Type))
+			default:
+				resp = *d.serveRequest(&r)
+			}
+			resp.ID = r.ID
+			send(&resp)
+		}()
+	}
+	wg.Wait()
+}
+
+// session is the client side of a session: one connection to the daemon
+// carrying the requests of a client, possibly several at once
+type session struct {
+	conn net.Conn
+
+	writeMu sync.Mutex // serializes requests on the connection
+	encoder *json.Encoder
+
+	mu      sync.Mutex
+	lastID  uint64
+	pending map[uint64]chan *Response // by request ID
+	err     error                     // why the session ended, nil while it lasts
+}
+
+// openSession connects to the daemon and starts a session
+func openSession(socketPath string, timeout time.Duration, name string) (*session, error) {
+	conn, err := dialIPC(socketPath, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	return startSession(conn, timeout, name)
+}
+
+// startSession starts a session on a connection to the daemon, closing it
+// if the daemon refuses
+func startSession(conn net.Conn, timeout time.Duration, name string) (*session, error) {
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+
+	req := NewRequest(RequestTypeSession)
+	req.Client = name
+	if err := encoder.Encode(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp Response
+	if err := decoder.Decode(&resp); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !resp.Success {
+		conn.Close()
+		return nil, fmt.Errorf("session failed: %s", resp.Error)
+	}
+	conn.SetDeadline(time.Time{})
+
+	s := &session{conn: conn, encoder: encoder, pending: make(map[uint64]chan *Response)}
+	go s.readResponses(decoder)
+	return s, nil
+}
+
+// send sends a request and waits for its response, until the deadline (no
+// limit if zero) or until ctx is done
+func (s *session) send(ctx context.Context, req *Request, deadline time.Time) (*Response, error) {
+	ch := make(chan *Response, 1)
+	s.mu.Lock()
+	if s.err != nil {
+		s.mu.Unlock()
+		return nil, s.err
+	}
+	s.lastID++
+	id := s.lastID
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	// The caller's request is left without the ID
+	sent := *req
+	sent.ID = id
+	s.writeMu.Lock()
+	s.conn.SetWriteDeadline(deadline)
+	err := s.encoder.Encode(&sent)
+	s.writeMu.Unlock()
+	if err != nil {
+		// A partly written request leaves the connection unusable
+		s.fail(fmt.Errorf("failed to send request: %w", err))
+		return nil, requestError(ctx, "failed to send request", err)
+	}
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case resp, ok := <-ch:
+		if !ok {
+			return nil, s.failure()
+		}
+		return resp, nil
+	case <-expired:
+		s.forget(id)
+		return nil, ErrNotResponding
+	case <-ctx.Done():
+		s.forget(id)
+		return nil, requestError(ctx, "", ctx.Err())
+	}
+}
+
+// forget stops waiting for the response to a request
+func (s *session) forget(id uint64) {
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.mu.Unlock()
+}
+
+// readResponses hands the responses read from the connection to the
+// requests waiting for them, until the connection ends
+func (s *session) readResponses(decoder *json.Decoder) {
+	for {
+		var resp Response
+		if err := decoder.Decode(&resp); err != nil {
+			s.fail(fmt.Errorf("connection to daemon lost: %w", err))
+			return
+		}
+
+		s.mu.Lock()
+		ch, ok := s.pending[resp.ID]
+		delete(s.pending, resp.ID)
+		s.mu.Unlock()
+		if ok {
+			ch <- &resp
+		}
+	}
+}
+
+// fail ends the session with err, which the waiting and later requests get
+func (s *session) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = err
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+	s.conn.Close()
+}
+
+// failure returns why the session ended, nil while it lasts
+func (s *session) failure() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// close ends the session
+func (s *session) close() {
+	s.fail(errors.New("session closed"))
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	d := &Daemon{}
+	d.jobManager = NewJobManagerWithExecutor(t.TempDir(), d.handleEvent, NewFakeProcessExecutor(), nil)
+	job, _, _ := d.jobManager.AddJob([]string{"make", "build"}, "/workdir", JobOptions{}, nil)
+
+	server, client := net.Pipe()
+	go d.handleConnection(server)
+	s, err := startSession(client, 0, "test")
+	if err != nil {
+		t.Fatalf("startSession failed: %v", err)
+	}
+	defer s.close()
+
+	// Requests sent at once each get their own response
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.send(context.Background(), NewTypedRequest(RequestTypeGetJob, JobPayload{JobID: job.ID}), time.Time{})
+			if err != nil || !resp.Success {
+				t.Errorf("expected the job, got %+v (%v)", resp, err)
+				return
+			}
+			var data JobData
+			if err := resp.Decode(&data); err != nil || data.Job.ID != job.ID {
+				t.Errorf("expected job %s, got %+v (%v)", job.ID, data, err)
+			}
+		}()
+	}
+	resp, err := s.send(context.Background(), NewRequest(RequestTypePing), time.Time{})
+	if err != nil || resp.Data["message"] != "pong" {
+		t.Errorf("expected pong, got %+v (%v)", resp, err)
+	}
+	wg.Wait()
+
+	// Subscriptions need their own connection
+	resp, err = s.send(context.Background(), NewRequest(RequestTypeSubscribe), time.Time{})
+	if err != nil || resp.Success {
+		t.Errorf("expected an error for subscribe, got %+v (%v)", resp, err)
+	}
+
+	// Requests fail once the connection is lost
+	server.Close()
+	if _, err := s.send(context.Background(), NewRequest(RequestTypePing), time.Time{}); err == nil {
+		t.Error("expected an error after the connection was lost")
+	}
+}
+
+func TestSession_NotResponding(t *testing.T) {
+	// A daemon that starts the session, then never answers
+	server, client := net.Pipe()
+	go func() {
+		decoder := json.NewDecoder(server)
+		var req Request
+		if decoder.Decode(&req) != nil {
+			return
+		}
+		json.NewEncoder(server).Encode(NewSuccessResponse())
+		for decoder.Decode(&req) == nil {
+		}
+	}()
+	s, err := startSession(client, 0, "test")
+	if err != nil {
+		t.Fatalf("startSession failed: %v", err)
+	}
+	defer s.close()
+
+	_, err = s.send(context.Background(), NewRequest(RequestTypePing), time.Now().Add(50*time.Millisecond))
+	if !errors.Is(err, ErrNotResponding) {
+		t.Errorf("expected ErrNotResponding after the deadline, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = s.send(ctx, NewRequest(RequestTypePing), time.Time{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = s.send(ctx, NewRequest(RequestTypePing), time.Time{})
+	if !errors.Is(err, ErrNotResponding) {
+		t.Errorf("expected ErrNotResponding after the context's deadline, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestJobManager_ShellCommand(t *testing.T) {
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, executor, nil)
+
+	script := `npm run build && npm test | tee "test output.log"`
+	job, _, err := jm.AddJob([]string{script}, "/workdir", JobOptions{Shell: "sh"}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if want := []string{"sh", "-c", script}; !slices.Equal(executor.LastCommand(), want) {
+		t.Errorf("expected the shell to run the script, got %q", executor.LastCommand())
+	}
+	if resp := jm.jobToResponse(job); resp.Shell != "sh" || !slices.Equal(resp.Command, []string{script}) {
+		t.Errorf("expected the script kept as is, got %q run by %q", resp.Command, resp.Shell)
+	}
+
+	// The same script is another job without a shell or with another one
+	argv, _ := jm.CreateJob([]string{script}, "/workdir", JobOptions{})
+	bash, _ := jm.CreateJob([]string{script}, "/workdir", JobOptions{Shell: "bash"})
+	if argv.ID == job.ID || bash.ID == job.ID || argv.ID == bash.ID {
+		t.Errorf("expected three jobs, got %s, %s and %s", job.ID, argv.ID, bash.ID)
+	}
+	if again, _ := jm.CreateJob([]string{script}, "/workdir", JobOptions{Shell: "sh"}); again.ID != job.ID {
+		t.Errorf("expected the shell job to be found again, got %s", again.ID)
+	}
+
+	if _, _, err := jm.AddJob([]string{"make", "&&", "make", "test"}, "/workdir", JobOptions{Shell: "sh"}, nil); err == nil {
+		t.Error("expected an error for a shell command of several arguments")
+	}
+}
+
+func TestStore_Shell(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), store)
+	job, _ := jm.CreateJob([]string{"make | tee log"}, "/workdir", JobOptions{Shell: "bash"})
+
+	jobs, err := store.LoadJobs()
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("expected 1 job, got %d (%v)", len(jobs), err)
+	}
+	if jobs[0].Shell != "bash" || jobs[0].CommandSignature != job.CommandSignature {
+		t.Errorf("expected the shell and signature stored, got %q and %s", jobs[0].Shell, jobs[0].CommandSignature)
+	}
+}
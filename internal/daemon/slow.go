@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSlowThreshold parses the slow threshold of a job: a duration like
+// "5m", or a factor of the p90 of its successful runs like "2x". Exactly one
+// of the results is non-zero.
+func ParseSlowThreshold(s string) (time.Duration, float64, error) {
+	if factor, ok := strings.CutSuffix(s, "x"); ok {
+		f, err := strconv.ParseFloat(factor, 64)
+		if err != nil || f <= 0 {
+			return 0, 0, fmt.Errorf("invalid slow threshold %q: want a duration like 5m or a factor like 2x", s)
+		}
+		return 0, f, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, 0, fmt.Errorf("invalid slow threshold %q: want a duration like 5m or a factor like 2x", s)
+	}
+	return d, 0, nil
+}
+
+// slowAfterLocked returns how long after starting a run of the job counts as
+// slow, or 0 if the job has no threshold. A relative threshold needs
+// minTrendRuns successful runs to take the p90 of. Caller must hold jm.mu.
+func (jm *JobManager) slowAfterLocked(job *Job) time.Duration {
+	if job.SlowThreshold == "" {
+		return 0
+	}
+	after, factor, err := ParseSlowThreshold(job.SlowThreshold)
+	if err != nil || factor == 0 {
+		return after
+	}
+
+	var durations []int64
+	for _, run := range jm.jobRunsLocked(job.ID) {
+		if run.StoppedAt != nil && run.ExitCode != nil && *run.ExitCode == 0 {
+			durations = append(durations, run.Duration().Milliseconds())
+		}
+	}
+	if len(durations) < minTrendRuns {
+		return 0
+	}
+	_, p90, _ := durationPercentiles(durations)
+	return time.Duration(float64(p90)*factor) * time.Millisecond
+}
+
+// armSlowTimerLocked schedules the slow alert of a running run, replacing
+// any scheduled one. Caller must hold jm.mu.
+func (jm *JobManager) armSlowTimerLocked(job *Job, run *Run) {
+	if run.slowTimer != nil {
+		run.slowTimer.Stop()
+		run.slowTimer = nil
+	}
+	after := jm.slowAfterLocked(job)
+	if after <= 0 || run.Status != "running" {
+		return
+	}
+	runID := run.ID
+	run.slowTimer = time.AfterFunc(max(time.Until(run.StartedAt.Add(after)), 0), func() {
+		jm.markSlow(runID, after)
+	})
+}
+
+// markSlow records that a run is still going after its job's slow
+// threshold: it gets a slow marker, subscribers get a job_slow event and
+// the job's on_slow hook runs. Runs are alerted on once.
+func (jm *JobManager) markSlow(runID string, after time.Duration) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	run, ok := jm.runs[runID]
+	if !ok || run.Status != "running" {
+		return
+	}
+	job, ok := jm.jobs[run.JobID]
+	if !ok {
+		return
+	}
+	if slices.ContainsFunc(run.Markers, func(m RunMarker) bool { return m.Kind == MarkerSlow }) {
+		return
+	}
+
+	jm.addMarkerLocked(run, MarkerSlow, "over "+after.Round(time.Second).String())
+
+	runResp := runToResponse(run)
+	jm.emitEvent(Event{
+		Type:            EventTypeJobSlow,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		Run:             &runResp,
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	jm.runHook(job, run, HookOnSlow)
+}
+
+// SetSlowThreshold sets when runs of a job count as slow, e.g. "5m" or "2x"
+// the p90 of its successful runs. An empty threshold, or "off", removes the
+// current one. A running run is checked against the new threshold.
+func (jm *JobManager) SetSlowThreshold(jobID string, threshold string) (*Job, error) {
+	if threshold == "off" {
+		threshold = ""
+	}
+	if threshold != "" {
+		if _, _, err := ParseSlowThreshold(threshold); err != nil {
+			return nil, err
+		}
+	}
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+
+	job, ok := jm.jobs[jm.resolveJobIDLocked(jobID)]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if job.SlowThreshold == threshold {
+		return job, nil
+	}
+
+	previous := job.SlowThreshold
+	job.SlowThreshold = threshold
+
+	if jm.store != nil {
+		if err := jm.store.UpdateJob(job); err != nil {
+			job.SlowThreshold = previous
+			return nil, fmt.Errorf("failed to persist slow threshold: %w", err)
+		}
+	}
+
+	if job.CurrentRunID != nil {
+		if run, ok := jm.runs[*job.CurrentRunID]; ok {
+			jm.armSlowTimerLocked(job, run)
+		}
+	}
+
+	jm.emitEvent(Event{
+		Type:            EventTypeJobUpdated,
+		JobID:           job.ID,
+		Job:             jm.jobToResponse(job),
+		JobCount:        len(jm.jobs),
+		RunningJobCount: jm.countRunningJobsLocked(),
+	})
+
+	return job, nil
+}
+
+// handleSetSlowThreshold handles a set_slow_threshold request
+func (d *Daemon) handleSetSlowThreshold(req *Request) *Response {
+	var p SetSlowThresholdPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	if p.JobID == "" {
+		return NewErrorResponse(fmt.Errorf("missing job_id"))
+	}
+
+	job, err := d.jobManager.SetSlowThreshold(p.JobID, p.Threshold)
+	if err != nil {
+		return NewErrorResponse(err)
+	}
+
+	return NewDataResponse(JobData{Job: d.jobManager.jobToResponse(job)})
+}
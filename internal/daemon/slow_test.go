@@ -0,0 +1,92 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSlowThreshold(t *testing.T) {
+	tests := []struct {
+		input   string
+		after   time.Duration
+		factor  float64
+		wantErr bool
+	}{
+		{"5m", 5 * time.Minute, 0, false},
+		{"90s", 90 * time.Second, 0, false},
+		{"2x", 0, 2, false},
+		{"1.5x", 0, 1.5, false},
+		{"0x", 0, 0, true},
+		{"-1m", 0, 0, true},
+		{"x", 0, 0, true},
+		{"soon", 0, 0, true},
+	}
+	for _, tt := range tests {
+		after, factor, err := ParseSlowThreshold(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSlowThreshold(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if after != tt.after || factor != tt.factor {
+			t.Errorf("ParseSlowThreshold(%q) = %v, %v, want %v, %v", tt.input, after, factor, tt.after, tt.factor)
+		}
+	}
+}
+
+func TestJobManager_SlowThreshold(t *testing.T) {
+	slow := make(chan Event, 1)
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(t.TempDir(), func(e Event) {
+		if e.Type == EventTypeJobSlow {
+			slow <- e
+		}
+	}, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "test"}, "/workdir", JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if _, err := jm.SetSlowThreshold(job.ID, "soon"); err == nil {
+		t.Error("expected an invalid threshold to be rejected")
+	}
+
+	// Setting a threshold checks the running run against it
+	if _, err := jm.SetSlowThreshold(job.ID, "10ms"); err != nil {
+		t.Fatalf("SetSlowThreshold failed: %v", err)
+	}
+	select {
+	case e := <-slow:
+		if e.JobID != job.ID || e.Run == nil || e.Job.SlowThreshold != "10ms" {
+			t.Errorf("unexpected job_slow event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a job_slow event")
+	}
+
+	run := jm.GetCurrentRun(job.ID)
+	jm.mu.RLock()
+	markers := append([]RunMarker(nil), run.Markers...)
+	jm.mu.RUnlock()
+	if last := markers[len(markers)-1]; last.Kind != MarkerSlow {
+		t.Errorf("expected a slow marker on the run, got %+v", markers)
+	}
+
+	// A relative threshold waits for enough runs to know the usual duration
+	stopAndWait(t, jm, executor, job.ID)
+	if _, err := jm.SetSlowThreshold(job.ID, "2x"); err != nil {
+		t.Fatalf("SetSlowThreshold failed: %v", err)
+	}
+	jm.mu.RLock()
+	after := jm.slowAfterLocked(job)
+	jm.mu.RUnlock()
+	if after != 0 {
+		t.Errorf("expected no slow threshold without a baseline, got %v", after)
+	}
+
+	if _, err := jm.SetSlowThreshold(job.ID, "off"); err != nil {
+		t.Fatalf("SetSlowThreshold failed: %v", err)
+	}
+	if job.SlowThreshold != "" {
+		t.Errorf("expected the threshold removed, got %q", job.SlowThreshold)
+	}
+}
@@ -0,0 +1,190 @@
+package daemon
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// slowRunFactor is how many times slower than the median of a job's
+// previous successful runs a successful run must be to count as a slowdown
+const slowRunFactor = 2.0
+
+// minTrendRuns is the number of previous successful runs needed before
+// slowdowns are reported, so a few noisy runs do not make a baseline
+const minTrendRuns = 5
+
+// JobStats returns a job with its statistics and the trend of its run
+// durations, split by outcome: percentiles and range of successful runs,
+// range and median of failed ones, and how much slower the last run was
+// than usual if it is a slowdown. Killed runs are in neither.
+func (jm *JobManager) JobStats(jobID string) (JobResponse, error) {
+	return jm.JobStatsIn(jobID, nil)
+}
+
+// JobStatsIn returns the statistics of JobStats for the runs of a job
+// started in all the conditions (see RunConditions), with the counts and
+// averages taken from those runs; all runs if there are none. The
+// percentiles of successful runs are also grouped by condition, and a
+// slowdown of the last run is measured against the previous runs started
+// in the same conditions once there are enough of them.
+func (jm *JobManager) JobStatsIn(jobID string, conditions []string) (JobResponse, error) {
+	if err := validateConditions(conditions); err != nil {
+		return JobResponse{}, err
+	}
+
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	job, ok := jm.jobs[jobID]
+	if !ok {
+		return JobResponse{}, fmt.Errorf("job not found: %s", jobID)
+	}
+	resp := jm.jobToResponse(job)
+
+	var stopped []*Run
+	for _, run := range jm.jobRunsLocked(jobID) {
+		if run.StoppedAt != nil && run.startedIn(conditions) {
+			stopped = append(stopped, run)
+		}
+	}
+	if len(conditions) > 0 {
+		countRuns(&resp, stopped)
+	}
+	if len(stopped) == 0 {
+		return resp, nil
+	}
+
+	// Durations of successful and failed runs, oldest first
+	var durations, failures []int64
+	for _, run := range stopped {
+		switch {
+		case run.ExitCode == nil:
+		case *run.ExitCode == 0:
+			durations = append(durations, run.Duration().Milliseconds())
+		default:
+			failures = append(failures, run.Duration().Milliseconds())
+		}
+	}
+	resp.P50DurationMs, resp.P90DurationMs, resp.P99DurationMs = durationPercentiles(durations)
+	if len(durations) > 0 {
+		resp.SuccessMinDurationMs, resp.SuccessMaxDurationMs = slices.Min(durations), slices.Max(durations)
+	}
+	if len(failures) > 0 {
+		resp.FailureMinDurationMs, resp.FailureMaxDurationMs = slices.Min(failures), slices.Max(failures)
+		resp.FailureP50DurationMs, _, _ = durationPercentiles(failures)
+	}
+
+	resp.ByCondition = conditionStats(stopped)
+
+	last := stopped[len(stopped)-1]
+	resp.LastDurationMs = last.Duration().Milliseconds()
+	if last.ExitCode != nil && *last.ExitCode == 0 {
+		previous := durations[:len(durations)-1]
+		if alike := successfulDurations(stopped[:len(stopped)-1], last.Conditions()); len(alike) >= minTrendRuns {
+			previous = alike
+		}
+		resp.Slowdown = slowdown(resp.LastDurationMs, previous)
+	}
+
+	return resp, nil
+}
+
+// countRuns replaces the counts and averages of a job response with those
+// of some of its stopped runs
+func countRuns(resp *JobResponse, runs []*Run) {
+	resp.RunCount, resp.SuccessCount, resp.FailureCount = len(runs), 0, 0
+	resp.SuccessRate, resp.AvgDurationMs, resp.FailureAvgDurationMs = 0, 0, 0
+	resp.MinDurationMs, resp.MaxDurationMs = 0, 0
+
+	var successTotal, failureTotal int64
+	for _, run := range runs {
+		ms := run.Duration().Milliseconds()
+		switch {
+		case run.ExitCode == nil:
+			continue
+		case *run.ExitCode == 0:
+			resp.SuccessCount++
+			successTotal += ms
+		default:
+			resp.FailureCount++
+			failureTotal += ms
+		}
+		if resp.MinDurationMs == 0 || ms < resp.MinDurationMs {
+			resp.MinDurationMs = ms
+		}
+		resp.MaxDurationMs = max(resp.MaxDurationMs, ms)
+	}
+	if resp.RunCount > 0 {
+		resp.SuccessRate = float64(resp.SuccessCount) / float64(resp.RunCount) * 100
+	}
+	if resp.SuccessCount > 0 {
+		resp.AvgDurationMs = successTotal / int64(resp.SuccessCount)
+	}
+	if resp.FailureCount > 0 {
+		resp.FailureAvgDurationMs = failureTotal / int64(resp.FailureCount)
+	}
+}
+
+// successfulDurations returns the durations of the successful runs started
+// in all the conditions, in the order of the runs
+func successfulDurations(runs []*Run, conditions []string) []int64 {
+	var durations []int64
+	for _, run := range runs {
+		if run.ExitCode != nil && *run.ExitCode == 0 && run.startedIn(conditions) {
+			durations = append(durations, run.Duration().Milliseconds())
+		}
+	}
+	return durations
+}
+
+// conditionStats returns the percentiles of the successful runs started in
+// each condition, for the conditions some of the runs started in
+func conditionStats(runs []*Run) []ConditionStats {
+	var stats []ConditionStats
+	for _, condition := range RunConditions {
+		durations := successfulDurations(runs, []string{condition})
+		if len(durations) == 0 {
+			continue
+		}
+		p50, p90, _ := durationPercentiles(durations)
+		stats = append(stats, ConditionStats{Condition: condition, RunCount: len(durations), P50DurationMs: p50, P90DurationMs: p90})
+	}
+	return stats
+}
+
+// durationPercentiles returns the 50th, 90th and 99th percentiles of
+// durations (nearest rank), or zeros if there are none
+func durationPercentiles(durations []int64) (p50, p90, p99 int64) {
+	if len(durations) == 0 {
+		return 0, 0, 0
+	}
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 99)
+}
+
+// percentile returns the p-th percentile of sorted values by nearest rank
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// slowdown returns how many times slower a run took than the median of
+// the previous durations (rounded to a tenth), or 0 if it is not a slowdown
+// or there are too few previous durations to tell
+func slowdown(durationMs int64, previous []int64) float64 {
+	if len(previous) < minTrendRuns {
+		return 0
+	}
+	median, _, _ := durationPercentiles(previous)
+	if median <= 0 {
+		return 0
+	}
+	factor := float64(durationMs) / float64(median)
+	if factor < slowRunFactor {
+		return 0
+	}
+	return math.Round(factor*10) / 10
+}
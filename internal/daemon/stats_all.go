@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// JobTimeSpent is how much time a job took, and how often it failed
+type JobTimeSpent struct {
+	JobID            string   `json:"job_id"`
+	Command          []string `json:"command"`
+	Workdir          string   `json:"workdir"`
+	Description      string   `json:"description,omitempty"`
+	RunCount         int      `json:"run_count"`
+	FailureCount     int      `json:"failure_count"`
+	SuccessRate      float64  `json:"success_rate"`
+	AvgDurationMs    int64    `json:"avg_duration_ms"`    // of successful runs
+	RecentRuns       int      `json:"recent_runs"`        // runs started since the time asked for
+	RecentDurationMs int64    `json:"recent_duration_ms"` // time spent by them, up to now for running ones
+}
+
+// StatsAll returns the time spent and failures of each job in workdir (all
+// directories if empty), the jobs that took the most time since the given
+// time first, and the time spent by all of them since then. Archived runs
+// are not in the recent time spent.
+func (jm *JobManager) StatsAll(workdir string, since time.Time) ([]JobTimeSpent, int64) {
+	jm.mu.RLock()
+	defer jm.mu.RUnlock()
+
+	spent := make(map[string]*JobTimeSpent)
+	for _, job := range jm.jobs {
+		if workdir != "" && job.Workdir != workdir {
+			continue
+		}
+		spent[job.ID] = &JobTimeSpent{
+			JobID:         job.ID,
+			Command:       job.Command,
+			Workdir:       job.Workdir,
+			Description:   job.Description,
+			RunCount:      job.RunCount,
+			FailureCount:  job.FailureCount,
+			SuccessRate:   job.SuccessRate(),
+			AvgDurationMs: job.AverageDurationMs(),
+		}
+	}
+
+	var total int64
+	for _, run := range jm.runs {
+		s, ok := spent[run.JobID]
+		if !ok || run.StartedAt.Before(since) {
+			continue
+		}
+		ms := run.Duration().Milliseconds()
+		s.RecentRuns++
+		s.RecentDurationMs += ms
+		total += ms
+	}
+
+	jobs := make([]JobTimeSpent, 0, len(spent))
+	for _, s := range spent {
+		jobs = append(jobs, *s)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].RecentDurationMs != jobs[j].RecentDurationMs {
+			return jobs[i].RecentDurationMs > jobs[j].RecentDurationMs
+		}
+		return jobs[i].JobID < jobs[j].JobID
+	})
+	return jobs, total
+}
+
+// handleStatsAll handles a stats_all request
+func (d *Daemon) handleStatsAll(req *Request) *Response {
+	var p StatsAllPayload
+	if err := decodePayload(req, &p); err != nil {
+		return NewErrorResponse(err)
+	}
+	since, err := time.Parse(time.RFC3339, p.Since)
+	if err != nil {
+		return NewErrorResponse(fmt.Errorf("invalid since: %w", err))
+	}
+
+	jobs, total := d.jobManager.StatsAll(p.Workdir, since)
+	return NewDataResponse(StatsAllData{Jobs: jobs, TotalDurationMs: total})
+}
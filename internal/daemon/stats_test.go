@@ -0,0 +1,159 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDurationPercentiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		durations     []int64
+		p50, p90, p99 int64
+	}{
+		{"none", nil, 0, 0, 0},
+		{"one", []int64{42}, 42, 42, 42},
+		{"unsorted", []int64{30, 10, 20}, 20, 30, 30},
+		{"ten", []int64{10, 20, 30, 40, 50, 60, 70, 80, 90, 1000}, 50, 90, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p50, p90, p99 := durationPercentiles(tt.durations)
+			if p50 != tt.p50 || p90 != tt.p90 || p99 != tt.p99 {
+				t.Errorf("expected %d/%d/%d, got %d/%d/%d", tt.p50, tt.p90, tt.p99, p50, p90, p99)
+			}
+		})
+	}
+}
+
+func TestSlowdown(t *testing.T) {
+	previous := []int64{100, 110, 90, 100, 105}
+
+	if got := slowdown(340, previous); got != 3.4 {
+		t.Errorf("expected 3.4x slowdown, got %v", got)
+	}
+	if got := slowdown(150, previous); got != 0 {
+		t.Errorf("expected no slowdown under %vx, got %v", slowRunFactor, got)
+	}
+	if got := slowdown(1000, previous[:minTrendRuns-1]); got != 0 {
+		t.Errorf("expected no slowdown with too few runs, got %v", got)
+	}
+}
+
+// addStoppedRun adds a stopped run of a job that took durationMs
+func addStoppedRun(jm *JobManager, job *Job, exitCode int, durationMs int64) {
+	startedAt := time.Now().Add(time.Duration(job.NextRunSeq) * time.Minute)
+	stoppedAt := startedAt.Add(time.Duration(durationMs) * time.Millisecond)
+	id := fmt.Sprintf("%s-%d", job.ID, job.NextRunSeq)
+	job.NextRunSeq++
+	jm.addRunLocked(&Run{ID: id, JobID: job.ID, Status: "stopped", ExitCode: &exitCode, StartedAt: startedAt, StoppedAt: &stoppedAt, DurationMs: &durationMs})
+}
+
+func TestJobManager_JobStats(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	job, _ := jm.CreateJob([]string{"make", "build"}, "/workdir", JobOptions{})
+
+	for _, ms := range []int64{1000, 1100, 900, 1000, 1050} {
+		addStoppedRun(jm, job, 0, ms)
+	}
+	addStoppedRun(jm, job, 1, 50) // failures are not in the percentiles
+	addStoppedRun(jm, job, 0, 3400)
+
+	stats, err := jm.JobStats(job.ID)
+	if err != nil {
+		t.Fatalf("JobStats failed: %v", err)
+	}
+	if stats.P50DurationMs != 1000 || stats.P90DurationMs != 3400 || stats.P99DurationMs != 3400 {
+		t.Errorf("unexpected percentiles: %d/%d/%d", stats.P50DurationMs, stats.P90DurationMs, stats.P99DurationMs)
+	}
+	if stats.LastDurationMs != 3400 || stats.Slowdown != 3.4 {
+		t.Errorf("expected the last run flagged 3.4x slower, got %dms (%vx)", stats.LastDurationMs, stats.Slowdown)
+	}
+	if stats.SuccessMinDurationMs != 900 || stats.SuccessMaxDurationMs != 3400 {
+		t.Errorf("expected successes from 900ms to 3400ms, got %d to %d", stats.SuccessMinDurationMs, stats.SuccessMaxDurationMs)
+	}
+	if stats.FailureMinDurationMs != 50 || stats.FailureMaxDurationMs != 50 || stats.FailureP50DurationMs != 50 {
+		t.Errorf("expected the failure at 50ms, got %d to %d (p50 %d)", stats.FailureMinDurationMs, stats.FailureMaxDurationMs, stats.FailureP50DurationMs)
+	}
+
+	// A failed last run is not a slowdown
+	addStoppedRun(jm, job, 1, 10000)
+	stats, _ = jm.JobStats(job.ID)
+	if stats.LastDurationMs != 10000 || stats.Slowdown != 0 {
+		t.Errorf("expected no slowdown for a failed run, got %dms (%vx)", stats.LastDurationMs, stats.Slowdown)
+	}
+
+	if _, err := jm.JobStats("nope"); err == nil {
+		t.Error("expected an error for an unknown job")
+	}
+}
+
+func TestStore_RunDuration(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), store)
+	job, _ := jm.CreateJob([]string{"make"}, "/workdir", JobOptions{})
+
+	// Timestamps are stored to the second, the duration to the millisecond
+	startedAt := time.Date(2026, 1, 2, 3, 4, 5, 900e6, time.UTC)
+	stoppedAt := startedAt.Add(1234 * time.Millisecond)
+	durationMs := int64(1234)
+	run := &Run{ID: job.ID + "-1", JobID: job.ID, Status: "stopped", StartedAt: startedAt}
+	if err := store.InsertRun(run); err != nil {
+		t.Fatal(err)
+	}
+	run.StoppedAt = &stoppedAt
+	run.DurationMs = &durationMs
+	if err := store.UpdateRun(run); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := store.LoadRuns()
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %d (%v)", len(runs), err)
+	}
+	if got := runs[0].Duration(); got != 1234*time.Millisecond {
+		t.Errorf("expected a duration of 1.234s, got %v", got)
+	}
+}
+
+func TestJobManager_StatsAll(t *testing.T) {
+	jm := NewJobManagerWithExecutor(t.TempDir(), nil, NewFakeProcessExecutor(), nil)
+	build, _ := jm.CreateJob([]string{"make", "build"}, "/workdir", JobOptions{})
+	lint, _ := jm.CreateJob([]string{"make", "lint"}, "/workdir", JobOptions{})
+	other, _ := jm.CreateJob([]string{"make"}, "/other", JobOptions{})
+
+	addStoppedRun(jm, build, 0, 1000)
+	addStoppedRun(jm, lint, 1, 3000)
+	addStoppedRun(jm, lint, 0, 2000)
+	addStoppedRun(jm, other, 0, 9000)
+
+	// Runs started before the time asked for do not count as recent
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	oldStopped, oldMs := old.Add(time.Hour), int64(time.Hour/time.Millisecond)
+	exitCode := 0
+	jm.addRunLocked(&Run{ID: build.ID + "-old", JobID: build.ID, Status: "stopped", ExitCode: &exitCode, StartedAt: old, StoppedAt: &oldStopped, DurationMs: &oldMs})
+
+	jobs, total := jm.StatsAll("/workdir", time.Now().Add(-7*24*time.Hour))
+	if len(jobs) != 2 || total != 6000 {
+		t.Fatalf("expected 2 jobs taking 6s, got %d taking %dms", len(jobs), total)
+	}
+	if jobs[0].JobID != lint.ID || jobs[0].RecentRuns != 2 || jobs[0].RecentDurationMs != 5000 {
+		t.Errorf("expected lint first with 2 runs taking 5s, got %+v", jobs[0])
+	}
+	if jobs[1].JobID != build.ID || jobs[1].RecentRuns != 1 || jobs[1].RecentDurationMs != 1000 {
+		t.Errorf("expected build second with 1 recent run, got %+v", jobs[1])
+	}
+
+	if jobs, _ := jm.StatsAll("", time.Now().Add(-7*24*time.Hour)); len(jobs) != 3 || jobs[0].JobID != other.ID {
+		t.Errorf("expected the jobs of all directories, got %+v", jobs)
+	}
+}
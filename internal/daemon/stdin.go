@@ -0,0 +1,56 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Stdin modes of a job. Any other value is the absolute path of a file
+// that is fed to the process.
+const (
+	StdinNull = "null" // stdin is /dev/null, reads get EOF immediately (default)
+	StdinOpen = "open" // stdin is a pipe kept open until the process exits
+)
+
+// ValidateStdin checks that stdin is a known mode or an absolute file path
+func ValidateStdin(stdin string) error {
+	if stdin == StdinNull || stdin == StdinOpen || filepath.IsAbs(stdin) {
+		return nil
+	}
+	return fmt.Errorf("invalid stdin %q (expected null, open or an absolute file path)", stdin)
+}
+
+// stdinOrNull returns the stdin mode to store, defaulting to null
+func stdinOrNull(stdin string) string {
+	if stdin == "" {
+		return StdinNull
+	}
+	return stdin
+}
+
+// openStdin opens the stdin source for a process. For StdinOpen it also
+// returns the write end of the pipe, which the caller must keep open for
+// as long as the process should see stdin as open.
+func openStdin(stdin string) (reader *os.File, writer *os.File, err error) {
+	switch stdinOrNull(stdin) {
+	case StdinNull:
+		reader, err = os.OpenFile(os.DevNull, os.O_RDONLY, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open /dev/null: %w", err)
+		}
+		return reader, nil, nil
+	case StdinOpen:
+		reader, writer, err = os.Pipe()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+		}
+		return reader, writer, nil
+	default:
+		reader, err = os.Open(stdin)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open stdin file: %w", err)
+		}
+		return reader, nil, nil
+	}
+}
@@ -0,0 +1,101 @@
+package daemon
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateStdin(t *testing.T) {
+	valid := []string{StdinNull, StdinOpen, "/tmp/input.txt"}
+	for _, stdin := range valid {
+		if err := ValidateStdin(stdin); err != nil {
+			t.Errorf("ValidateStdin(%q) failed: %v", stdin, err)
+		}
+	}
+
+	invalid := []string{"closed", "input.txt", "./input.txt"}
+	for _, stdin := range invalid {
+		if err := ValidateStdin(stdin); err == nil {
+			t.Errorf("ValidateStdin(%q) expected error", stdin)
+		}
+	}
+}
+
+func TestOpenStdin_NullReturnsEOF(t *testing.T) {
+	reader, writer, err := openStdin("")
+	if err != nil {
+		t.Fatalf("openStdin failed: %v", err)
+	}
+	defer reader.Close()
+
+	if writer != nil {
+		t.Error("expected no writer for null stdin")
+	}
+	if n, err := reader.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Errorf("expected EOF, got n=%d err=%v", n, err)
+	}
+}
+
+func TestOpenStdin_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, writer, err := openStdin(path)
+	if err != nil {
+		t.Fatalf("openStdin failed: %v", err)
+	}
+	defer reader.Close()
+
+	if writer != nil {
+		t.Error("expected no writer for file stdin")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(data) != "hello\n" {
+		t.Errorf("expected file content, got %q", data)
+	}
+}
+
+func TestOpenStdin_MissingFile(t *testing.T) {
+	if _, _, err := openStdin(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected error for missing stdin file")
+	}
+}
+
+func TestOpenStdin_OpenStaysOpenUntilWriterCloses(t *testing.T) {
+	reader, writer, err := openStdin(StdinOpen)
+	if err != nil {
+		t.Fatalf("openStdin failed: %v", err)
+	}
+	defer reader.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := reader.Read(make([]byte, 1))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected read to block while stdin is open, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	writer.Close()
+
+	select {
+	case err := <-done:
+		if err != io.EOF {
+			t.Errorf("expected EOF after closing writer, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected read to return after closing writer")
+	}
+}
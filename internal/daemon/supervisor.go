@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// superviseInterval is how often supervised processes are checked for exit
+const superviseInterval = 500 * time.Millisecond
+
+// processExit is the exit of a supervised process
+type processExit struct {
+	status syscall.WaitStatus
+	known  bool // false if the process was gone before its status could be read
+}
+
+// supervisor tracks the exit of processes the daemon did not spawn (adopted
+// after a handover or crash), which it cannot wait for. A single goroutine
+// polls them while any is watched. The exit status can be read while the
+// process is a zombie, before its parent reaps it (Linux only).
+type supervisor struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	watched map[int][]chan processExit
+}
+
+func newSupervisor(interval time.Duration) *supervisor {
+	return &supervisor{
+		interval: interval,
+		watched:  make(map[int][]chan processExit),
+	}
+}
+
+// watch returns a channel that receives the exit of the process
+func (s *supervisor) watch(pid int) <-chan processExit {
+	ch := make(chan processExit, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.watched) == 0 {
+		go s.poll()
+	}
+	s.watched[pid] = append(s.watched[pid], ch)
+	return ch
+}
+
+// poll checks the watched processes until none is left
+func (s *supervisor) poll() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for pid, chans := range s.watched {
+			exit, exited := checkProcessExit(pid)
+			if !exited {
+				continue
+			}
+			for _, ch := range chans {
+				ch <- exit
+			}
+			delete(s.watched, pid)
+		}
+		done := len(s.watched) == 0
+		s.mu.Unlock()
+
+		if done {
+			return
+		}
+	}
+}
+
+// count returns the number of processes watched
+func (s *supervisor) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watched)
+}
+
+// exitStatusError reports the exit status of a process the daemon did not
+// spawn, like exec.ExitError does for its children
+type exitStatusError struct {
+	status syscall.WaitStatus
+}
+
+func (e *exitStatusError) Error() string {
+	if e.status.Signaled() {
+		return fmt.Sprintf("signal: %v", e.status.Signal())
+	}
+	return fmt.Sprintf("exit status %d", e.status.ExitStatus())
+}
+
+// Sys returns the syscall.WaitStatus of the exit
+func (e *exitStatusError) Sys() any {
+	return e.status
+}
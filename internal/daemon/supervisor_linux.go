@@ -0,0 +1,38 @@
+package daemon
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// checkProcessExit reports whether a process has exited, with its exit
+// status if it is still a zombie. The status is field 52 (exit_code) of
+// /proc/<pid>/stat, in the form reported by waitpid.
+func checkProcessExit(pid int) (processExit, bool) {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return processExit{}, true
+	}
+
+	// The command name in parentheses may contain spaces, fields start
+	// with the state (field 3) after it
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) == 0 {
+		return processExit{}, true
+	}
+	if fields[0] != "Z" && fields[0] != "X" {
+		return processExit{}, false
+	}
+
+	if len(fields) < 50 {
+		return processExit{}, true
+	}
+	code, err := strconv.Atoi(fields[49])
+	if err != nil {
+		return processExit{}, true
+	}
+	return processExit{status: syscall.WaitStatus(code), known: true}, true
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package daemon
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// checkProcessExit reports whether a process has exited. The exit status of
+// a process the daemon did not spawn is not available outside Linux.
+func checkProcessExit(pid int) (processExit, bool) {
+	if !processExists(pid) {
+		return processExit{}, true
+	}
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return processExit{}, true
+	}
+	// An exited process stays a zombie until its parent reaps it
+	status, err := proc.Status()
+	if err != nil || len(status) == 0 {
+		return processExit{}, false
+	}
+	return processExit{}, status[0] == process.Zombie
+}
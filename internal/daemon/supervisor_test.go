@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSupervisor(t *testing.T) {
+	s := newSupervisor(10 * time.Millisecond)
+
+	// Not waited for until the end, so the exited processes stay zombies
+	// like adopted ones do until their parent reaps them
+	failed := exec.Command("sh", "-c", "exit 3")
+	killed := exec.Command("sleep", "30")
+	for _, cmd := range []*exec.Cmd{failed, killed} {
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			cmd.Process.Kill()
+			cmd.Wait()
+		})
+	}
+
+	failedExit := s.watch(failed.Process.Pid)
+	killedExit := s.watch(killed.Process.Pid)
+
+	select {
+	case <-killedExit:
+		t.Fatal("expected a running process not to be reported")
+	case <-time.After(50 * time.Millisecond):
+	}
+	killed.Process.Kill()
+
+	for _, tt := range []struct {
+		name   string
+		exited <-chan processExit
+		check  func(processExit) bool
+	}{
+		{"exit 3", failedExit, func(e processExit) bool { return e.status.Exited() && e.status.ExitStatus() == 3 }},
+		{"killed", killedExit, func(e processExit) bool { return e.status.Signaled() }},
+	} {
+		select {
+		case exit := <-tt.exited:
+			// The exit status is only read on Linux
+			if runtime.GOOS == "linux" && (!exit.known || !tt.check(exit)) {
+				t.Errorf("%s: unexpected exit %+v", tt.name, exit)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: exit not reported", tt.name)
+		}
+	}
+}
+
+func TestSupervisor_Gone(t *testing.T) {
+	s := newSupervisor(10 * time.Millisecond)
+
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case exit := <-s.watch(cmd.Process.Pid):
+		if exit.known {
+			t.Errorf("expected the exit status of a reaped process to be unknown, got %+v", exit)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("exit not reported")
+	}
+}
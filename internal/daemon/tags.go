@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// tagPattern restricts tags to simple names that are easy to type in a shell
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// maxTagLength is the maximum length of a job tag
+const maxTagLength = 64
+
+// NormalizeTags validates tags and returns them sorted without duplicates.
+// The result is never nil, so an empty list clears a job's tags.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+	for _, tag := range tags {
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag too long (max %d characters)", maxTagLength)
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag %q (use letters, digits, '.', '_' and '-')", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// ValidateRunLabel checks that a run label follows the rules of tags
+func ValidateRunLabel(label string) error {
+	if len(label) > maxTagLength {
+		return fmt.Errorf("label too long (max %d characters)", maxTagLength)
+	}
+	if !tagPattern.MatchString(label) {
+		return fmt.Errorf("invalid label %q (use letters, digits, '.', '_' and '-')", label)
+	}
+	return nil
+}
+
+// HasTag reports whether the job has the given tag
+func (j *Job) HasTag(tag string) bool {
+	for _, t := range j.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagsEqual reports whether two normalized tag lists are the same
+func tagsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{"slow", "backend", "slow"})
+	if err != nil {
+		t.Fatalf("NormalizeTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"backend", "slow"}) {
+		t.Errorf("expected sorted unique tags, got %v", tags)
+	}
+
+	empty, err := NormalizeTags(nil)
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Errorf("expected empty non-nil tags, got %v (err %v)", empty, err)
+	}
+
+	for _, tag := range []string{"", "with space", "-dash", "a/b"} {
+		if _, err := NormalizeTags([]string{tag}); err == nil {
+			t.Errorf("expected error for tag %q", tag)
+		}
+	}
+}
+
+func TestJobManager_AddJob_Tags(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := NewFakeProcessExecutor()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, executor, nil)
+
+	job, _, err := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Tags: []string{"backend", "slow"}}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if !job.HasTag("backend") || job.HasTag("frontend") {
+		t.Errorf("unexpected tags: %v", job.Tags)
+	}
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	// Re-adding without tags keeps them
+	if _, _, err := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if !reflect.DeepEqual(job.Tags, []string{"backend", "slow"}) {
+		t.Errorf("expected tags to be kept, got %v", job.Tags)
+	}
+	executor.LastHandle().Stop()
+	time.Sleep(10 * time.Millisecond)
+
+	// Re-adding with tags replaces them
+	if _, _, err := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Tags: []string{"api"}}, nil); err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	if !reflect.DeepEqual(job.Tags, []string{"api"}) {
+		t.Errorf("expected tags to be replaced, got %v", job.Tags)
+	}
+}
+
+func TestDaemon_handleList_FiltersByTag(t *testing.T) {
+	tmpDir := t.TempDir()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), nil)
+
+	tagged, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Tags: []string{"backend"}}, nil)
+	jm.AddJob([]string{"npm", "run", "dev"}, "/workdir", JobOptions{Tags: []string{"frontend"}}, nil)
+
+	d := &Daemon{jobManager: jm}
+	resp := d.handleRequest(&Request{
+		Type:    RequestTypeList,
+		Payload: map[string]interface{}{"tag": "backend"},
+	})
+	if !resp.Success {
+		t.Fatalf("expected success, got error: %s", resp.Error)
+	}
+
+	jobs := resp.Data["jobs"].([]JobResponse)
+	if len(jobs) != 1 || jobs[0].ID != tagged.ID {
+		t.Errorf("expected only job %s, got %v", tagged.ID, jobs)
+	}
+}
@@ -0,0 +1,186 @@
+package daemon
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+)
+
+// TimestampFormat is the RFC3339 layout of the times in a timestamp index
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// captureFlushTimeout bounds how long Wait lets captured output drain after
+// the process exits (children that inherited the pipe can keep it open)
+const captureFlushTimeout = time.Second
+
+// LineTimestamp records when a line of a log file was written
+type LineTimestamp struct {
+	Time   time.Time
+	Offset int64 // byte offset of the start of the line in the log file
+}
+
+// TimestampIndexPath returns the path of the timestamp index of a log file.
+// The index is a sidecar: the log file itself stays the raw output.
+func TimestampIndexPath(logPath string) string {
+	return strings.TrimSuffix(logPath, ".log") + ".ts"
+}
+
+// timestampWriter writes output to a log file and records, for every line,
+// the time it started and its offset in a sidecar index
+type timestampWriter struct {
+	log     *os.File
+	index   *os.File
+	offset  int64
+	midLine bool // last write did not end with a newline
+}
+
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	now := time.Now().UTC().Format(TimestampFormat)
+
+	var entries []byte
+	for i, b := range p {
+		if !w.midLine {
+			entries = fmt.Appendf(entries, "%s %d\n", now, w.offset+int64(i))
+			w.midLine = true
+		}
+		if b == '\n' {
+			w.midLine = false
+		}
+	}
+
+	n, err := w.log.Write(p)
+	w.offset += int64(n)
+	if len(entries) > 0 {
+		if _, indexErr := w.index.Write(entries); indexErr != nil {
+			Logger.Debug("failed to write timestamp index", "path", w.index.Name(), "error", indexErr)
+		}
+	}
+	return n, err
+}
+
+// waitForCaptures waits for captured output to drain, up to a timeout
+func waitForCaptures(captures []<-chan struct{}, timeout time.Duration) {
+	deadline := time.After(timeout)
+	for _, done := range captures {
+		select {
+		case <-done:
+		case <-deadline:
+			return
+		}
+	}
+}
+
+// ReadTimestampIndex reads the timestamp index of a log file. Returns nil
+// if the log has no index (the job did not record timestamps).
+func ReadTimestampIndex(logPath string) ([]LineTimestamp, error) {
+	file, err := os.Open(TimestampIndexPath(logPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open timestamp index: %w", err)
+	}
+	defer file.Close()
+
+	var stamps []LineTimestamp
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		timeStr, offsetStr, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(TimestampFormat, timeStr)
+		if err != nil {
+			continue
+		}
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		stamps = append(stamps, LineTimestamp{Time: t, Offset: offset})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read timestamp index: %w", err)
+	}
+	return stamps, nil
+}
+
+// PrefixTimestamps prefixes each line of a log file's content with the time
+// it was written, formatted by format. Lines missing from the index (written
+// after it was read) get a blank prefix of the same width.
+func PrefixTimestamps(content string, stamps []LineTimestamp, format func(time.Time) string) string {
+	if len(stamps) == 0 {
+		return content
+	}
+	blank := strings.Repeat(" ", len(format(stamps[0].Time)))
+
+	var result strings.Builder
+	var offset int64
+	next := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		for next < len(stamps) && stamps[next].Offset < offset {
+			next++
+		}
+		if next < len(stamps) && stamps[next].Offset == offset {
+			result.WriteString(format(stamps[next].Time))
+		} else {
+			result.WriteString(blank)
+		}
+		result.WriteString(" ")
+		result.WriteString(line)
+		offset += int64(len(line))
+	}
+	return result.String()
+}
+
+// TimedLine is a line of a log file with the time it was written
+type TimedLine struct {
+	Time time.Time
+	Text string // with its trailing newline, if any
+}
+
+// TimedLines splits a log file's content into lines with the time each was
+// written, for replaying the output. Lines missing from the index get the
+// time of the line before them. Returns nil without an index.
+func TimedLines(content string, stamps []LineTimestamp) []TimedLine {
+	if len(stamps) == 0 {
+		return nil
+	}
+
+	var lines []TimedLine
+	var offset int64
+	next := 0
+	last := stamps[0].Time
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		for next < len(stamps) && stamps[next].Offset < offset {
+			next++
+		}
+		if next < len(stamps) && stamps[next].Offset == offset {
+			last = stamps[next].Time
+		}
+		lines = append(lines, TimedLine{Time: last, Text: line})
+		offset += int64(len(line))
+	}
+	return lines
+}
+
+// removeRunLogs deletes the log files of a run and their timestamp indexes,
+// compressed or gzipped if the run was archived
+func removeRunLogs(run *Run) {
+	for _, file := range runLogFiles(run) {
+		os.Remove(file)
+		os.Remove(file + logfile.CompressedSuffix)
+		os.Remove(file + archiveSuffix)
+	}
+}
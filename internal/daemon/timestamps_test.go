@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampIndexPath(t *testing.T) {
+	if got := TimestampIndexPath("/logs/abc-1.stdout.log"); got != "/logs/abc-1.stdout.ts" {
+		t.Errorf("unexpected index path: %s", got)
+	}
+}
+
+func TestTimestampWriter_IndexesLineStarts(t *testing.T) {
+	tmpDir := t.TempDir()
+	logPath := filepath.Join(tmpDir, "run.stdout.log")
+	logFile, _ := os.Create(logPath)
+	index, _ := os.Create(TimestampIndexPath(logPath))
+
+	w := &timestampWriter{log: logFile, index: index}
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\nthree\n"))
+	w.Write([]byte("four"))
+	logFile.Close()
+	index.Close()
+
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "one\ntwo\nthree\nfour" {
+		t.Errorf("log file should hold the raw output, got %q", content)
+	}
+
+	stamps, err := ReadTimestampIndex(logPath)
+	if err != nil {
+		t.Fatalf("ReadTimestampIndex failed: %v", err)
+	}
+	var offsets []int64
+	for _, s := range stamps {
+		offsets = append(offsets, s.Offset)
+		if time.Since(s.Time) > time.Minute {
+			t.Errorf("unexpected timestamp %v", s.Time)
+		}
+	}
+	if len(offsets) != 4 || offsets[0] != 0 || offsets[1] != 4 || offsets[2] != 8 || offsets[3] != 14 {
+		t.Errorf("expected offsets [0 4 8 14], got %v", offsets)
+	}
+}
+
+func TestReadTimestampIndex_Missing(t *testing.T) {
+	stamps, err := ReadTimestampIndex(filepath.Join(t.TempDir(), "run.stdout.log"))
+	if err != nil || stamps != nil {
+		t.Errorf("expected no stamps and no error, got %v, %v", stamps, err)
+	}
+}
+
+func TestPrefixTimestamps(t *testing.T) {
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stamps := []LineTimestamp{
+		{Time: first, Offset: 0},
+		{Time: first.Add(time.Second), Offset: 4},
+	}
+	format := func(t time.Time) string { return t.Format("15:04:05") }
+
+	got := PrefixTimestamps("one\ntwo\nthree\n", stamps, format)
+	want := "03:04:05 one\n03:04:06 two\n         three\n"
+	if got != want {
+		t.Errorf("PrefixTimestamps() = %q, want %q", got, want)
+	}
+
+	// Without an index the content is unchanged
+	if got := PrefixTimestamps("one\n", nil, format); got != "one\n" {
+		t.Errorf("expected unchanged content, got %q", got)
+	}
+}
+
+func TestTimedLines(t *testing.T) {
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	stamps := []LineTimestamp{
+		{Time: first, Offset: 0},
+		{Time: first.Add(time.Second), Offset: 4},
+	}
+
+	lines := TimedLines("one\ntwo\nthree", stamps)
+	want := []TimedLine{
+		{Time: first, Text: "one\n"},
+		{Time: first.Add(time.Second), Text: "two\n"},
+		{Time: first.Add(time.Second), Text: "three"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %v", len(want), lines)
+	}
+	for i := range want {
+		if !lines[i].Time.Equal(want[i].Time) || lines[i].Text != want[i].Text {
+			t.Errorf("line %d: expected %v, got %v", i, want[i], lines[i])
+		}
+	}
+
+	if lines := TimedLines("one\n", nil); lines != nil {
+		t.Errorf("expected no lines without an index, got %v", lines)
+	}
+}
+
+func TestRealProcessExecutor_Timestamps(t *testing.T) {
+	tmpDir := t.TempDir()
+	stdoutPath := filepath.Join(tmpDir, "run.stdout.log")
+	stderrPath := filepath.Join(tmpDir, "run.stderr.log")
+
+	executor := &RealProcessExecutor{}
+	handle, err := executor.Start([]string{"sh", "-c", "echo a; echo b; echo c >&2"}, tmpDir, os.Environ(),
+		stdoutPath, stderrPath, StartOptions{Timestamps: true})
+	if err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	handle.Wait()
+
+	stdout, _ := os.ReadFile(stdoutPath)
+	if string(stdout) != "a\nb\n" {
+		t.Errorf("unexpected stdout %q", stdout)
+	}
+	stdoutStamps, _ := ReadTimestampIndex(stdoutPath)
+	stderrStamps, _ := ReadTimestampIndex(stderrPath)
+	if len(stdoutStamps) != 2 || len(stderrStamps) != 1 {
+		t.Errorf("expected 2 stdout and 1 stderr stamps, got %d and %d", len(stdoutStamps), len(stderrStamps))
+	}
+
+	// Runs are removed along with their indexes
+	removeRunLogs(&Run{StdoutPath: stdoutPath, StderrPath: stderrPath})
+	entries, _ := os.ReadDir(tmpDir)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 0 {
+		t.Errorf("expected logs and indexes removed, found %s", strings.Join(names, ", "))
+	}
+}
+
+func TestJobManager_AddJob_Timestamps(t *testing.T) {
+	tmpDir := t.TempDir()
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), nil)
+
+	on, off := true, false
+	job, _, _ := jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Timestamps: &on}, nil)
+	if !job.Timestamps {
+		t.Error("expected timestamps to be enabled")
+	}
+
+	// Re-adding without the option keeps the setting
+	job, _, _ = jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{}, nil)
+	if !job.Timestamps {
+		t.Error("expected timestamps to stay enabled")
+	}
+
+	// And turning it off clears it
+	job, _, _ = jm.AddJob([]string{"make", "server"}, "/workdir", JobOptions{Timestamps: &off}, nil)
+	if job.Timestamps {
+		t.Error("expected timestamps to be disabled")
+	}
+}
@@ -192,3 +192,38 @@ func TestErrVersionMismatch_PreVersionNegotiation(t *testing.T) {
 		t.Errorf("error message should indicate pre-version-negotiation daemon, got: %s", msg)
 	}
 }
+
+func TestErrVersionMismatch_Reason(t *testing.T) {
+	err := &ErrVersionMismatch{
+		DaemonVersion: "1.0.0",
+		ClientVersion: "1.1.0",
+		Reason:        "job abc writes its output or stdin through the daemon",
+	}
+
+	msg := err.Error()
+	if !strings.Contains(msg, "could not hand its jobs over: job abc") || !strings.Contains(msg, "gob shutdown") {
+		t.Errorf("error message should explain the failed handover, got: %s", msg)
+	}
+}
+
+func TestVersionNewer(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"1.2.4", "1.2.3", true},
+		{"v2.0.0", "1.9.9", true},
+		{"1.10.0", "1.9.0", true},
+		{"1.2.3", "1.2.3", false},
+		{"1.2.3", "1.3.0", false},
+		{"dev-abc1234", "1.2.3", false}, // development builds are never newer
+		{"1.2.3", "dev-abc1234", false},
+		{"1.2", "1.1.0", false},
+	}
+
+	for _, tt := range tests {
+		if got := versionNewer(tt.a, tt.b); got != tt.expected {
+			t.Errorf("versionNewer(%q, %q) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
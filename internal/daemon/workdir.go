@@ -0,0 +1,110 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Workdir normalization.
+//
+// Jobs are scoped to their workdir, compared as strings. The same directory
+// can be spelled in several ways: through a symlink (/tmp is /private/tmp on
+// macOS), or with another case on a case-insensitive filesystem (the default
+// on macOS and Windows). The daemon normalizes every workdir it receives, so
+// all spellings of a directory are one scope, and normalizes the workdirs of
+// stored jobs when it starts.
+
+// caseInsensitivePaths reports whether path elements are folded to the case
+// stored on disk
+var caseInsensitivePaths = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// NormalizeWorkdir returns the canonical form of a workdir: cleaned, with
+// symlinks resolved and, on case-insensitive systems, each element spelled as
+// stored on disk. A workdir that does not exist is only cleaned.
+func NormalizeWorkdir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return dir
+	}
+	if caseInsensitivePaths {
+		resolved = realCase(resolved)
+	}
+	return resolved
+}
+
+// realCase spells each element of an absolute path as its directory stores
+// it. Elements that match exactly, or that can't be listed, are kept.
+func realCase(path string) string {
+	sep := string(filepath.Separator)
+	vol := filepath.VolumeName(path)
+	current := vol + sep
+	for _, name := range strings.Split(strings.Trim(path[len(vol):], sep), sep) {
+		if name == "" {
+			continue
+		}
+		if entries, err := os.ReadDir(current); err == nil {
+			name = matchEntryName(entries, name)
+		}
+		current = filepath.Join(current, name)
+	}
+	return current
+}
+
+// matchEntryName returns the entry name matching name, preferring an exact
+// match over a case-insensitive one
+func matchEntryName(entries []os.DirEntry, name string) string {
+	folded := ""
+	for _, entry := range entries {
+		if entry.Name() == name {
+			return name
+		}
+		if folded == "" && strings.EqualFold(entry.Name(), name) {
+			folded = entry.Name()
+		}
+	}
+	if folded != "" {
+		return folded
+	}
+	return name
+}
+
+// normalizeWorkdirPayload normalizes the workdir of a request payload in
+// place, so every handler sees the canonical form
+func normalizeWorkdirPayload(req *Request) {
+	workdir, ok := req.Payload["workdir"].(string)
+	if !ok || workdir == "" {
+		return
+	}
+	req.Payload["workdir"] = NormalizeWorkdir(workdir)
+}
+
+// normalizeWorkdirsLocked moves stored jobs to their normalized workdir. A
+// job whose normalized scope already has a job of the same command keeps its
+// workdir, so neither history is lost. Caller must hold jm.mu.
+func (jm *JobManager) normalizeWorkdirsLocked() {
+	for _, job := range jm.jobs {
+		workdir := NormalizeWorkdir(job.Workdir)
+		if workdir == job.Workdir {
+			continue
+		}
+		key := makeJobIndexKey(job.CommandSignature, workdir)
+		if other, exists := jm.jobIndex[key]; exists {
+			Logger.Warn("job workdir not normalized: scope has a job of the same command", "job", job.ID, "workdir", job.Workdir, "other", other)
+			continue
+		}
+		delete(jm.jobIndex, makeJobIndexKey(job.CommandSignature, job.Workdir))
+		job.Workdir = workdir
+		jm.jobIndex[key] = job.ID
+		if jm.store != nil {
+			if err := jm.store.UpdateJob(job); err != nil {
+				Logger.Error("failed to persist normalized workdir", "job", job.ID, "error", err)
+			}
+		}
+	}
+}
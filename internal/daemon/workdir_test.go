@@ -0,0 +1,122 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeLinkedWorkdir creates a project directory and a symlink to it, and
+// returns both resolved through any symlinks in the temp dir itself
+func makeLinkedWorkdir(t *testing.T) (string, string) {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(root, "Project")
+	if err := os.Mkdir(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(project, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	return project, link
+}
+
+func TestNormalizeWorkdir(t *testing.T) {
+	project, link := makeLinkedWorkdir(t)
+
+	if got := NormalizeWorkdir(link); got != project {
+		t.Errorf("NormalizeWorkdir(%q) = %q, want %q", link, got, project)
+	}
+	if got := NormalizeWorkdir(project + "/sub/.."); got != project {
+		t.Errorf("expected a cleaned path, got %q", got)
+	}
+	if got := NormalizeWorkdir("/does/not/../exist"); got != "/does/exist" {
+		t.Errorf("expected a missing workdir to be only cleaned, got %q", got)
+	}
+	if got := NormalizeWorkdir(""); got != "" {
+		t.Errorf("expected an empty workdir to stay empty, got %q", got)
+	}
+}
+
+func TestRealCase(t *testing.T) {
+	project, _ := makeLinkedWorkdir(t)
+	root := filepath.Dir(project)
+	if err := os.Mkdir(filepath.Join(project, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := realCase(filepath.Join(root, "PROJECT", "Src")); got != filepath.Join(project, "src") {
+		t.Errorf("expected the case stored on disk, got %q", got)
+	}
+	if got := realCase(filepath.Join(root, "Project", "missing")); got != filepath.Join(project, "missing") {
+		t.Errorf("expected a missing element to be kept, got %q", got)
+	}
+}
+
+func TestNormalizeWorkdirPayload(t *testing.T) {
+	project, link := makeLinkedWorkdir(t)
+
+	req := NewRequest(RequestTypeList)
+	req.Payload["workdir"] = link
+	normalizeWorkdirPayload(req)
+	if req.Payload["workdir"] != project {
+		t.Errorf("expected the payload workdir to be normalized, got %v", req.Payload["workdir"])
+	}
+
+	req = NewRequest(RequestTypeList)
+	normalizeWorkdirPayload(req)
+	if _, ok := req.Payload["workdir"]; ok {
+		t.Errorf("expected no workdir to be added, got %v", req.Payload["workdir"])
+	}
+}
+
+func TestJobManager_LoadFromStoreNormalizesWorkdirs(t *testing.T) {
+	project, link := makeLinkedWorkdir(t)
+	tmpDir := t.TempDir()
+	db, err := OpenDatabase(filepath.Join(tmpDir, "state.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	defer store.Close()
+
+	// Jobs stored before normalization: one through the symlink, and the
+	// same command both through the symlink and at the resolved path
+	jm := NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), store)
+	build, _, err := jm.AddJob([]string{"make", "build"}, link, JobOptions{}, nil)
+	if err != nil {
+		t.Fatalf("AddJob failed: %v", err)
+	}
+	testLinked, _, _ := jm.AddJob([]string{"make", "test"}, link, JobOptions{}, nil)
+	testResolved, _, _ := jm.AddJob([]string{"make", "test"}, project, JobOptions{}, nil)
+
+	jm = NewJobManagerWithExecutor(tmpDir, nil, NewFakeProcessExecutor(), store)
+	if err := jm.LoadFromStore(); err != nil {
+		t.Fatalf("LoadFromStore failed: %v", err)
+	}
+
+	if job := jm.FindJobByCommand([]string{"make", "build"}, project); job == nil || job.ID != build.ID {
+		t.Errorf("expected job %s at the resolved workdir, got %+v", build.ID, job)
+	}
+	if job := jm.FindJobByCommand([]string{"make", "test"}, project); job == nil || job.ID != testResolved.ID {
+		t.Errorf("expected job %s to keep the resolved workdir, got %+v", testResolved.ID, job)
+	}
+	if job, err := jm.GetJob(testLinked.ID); err != nil || job.Workdir != link {
+		t.Errorf("expected the colliding job to keep its workdir, got %+v (%v)", job, err)
+	}
+
+	// The normalized workdir is persisted
+	jobs, err := store.LoadJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, job := range jobs {
+		if job.ID == build.ID && job.Workdir != project {
+			t.Errorf("expected the stored workdir to be normalized, got %q", job.Workdir)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package logfile
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// binarySniffLen is how much of the output IsBinary looks at
+const binarySniffLen = 8000
+
+// IsBinary reports whether output looks like binary data (a tarball, a
+// protobuf dump) rather than text: its start has a NUL byte or is not valid
+// UTF-8. Escape sequences of terminals are text.
+func IsBinary(data []byte) bool {
+	sample := data[:min(len(data), binarySniffLen)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	// Drop a multi-byte character cut at the end of the sample
+	if cut := len(sample); cut < len(data) {
+		for i := cut - 1; i >= max(0, cut-utf8.UTFMax); i-- {
+			if utf8.RuneStart(sample[i]) {
+				if !utf8.FullRune(sample[i:]) {
+					sample = sample[:i]
+				}
+				break
+			}
+		}
+	}
+	return !utf8.Valid(sample)
+}
+
+// BinaryPlaceholder is shown instead of size bytes of binary output
+func BinaryPlaceholder(size int) string {
+	return fmt.Sprintf("[binary output: %d bytes]", size)
+}
@@ -0,0 +1,30 @@
+package logfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"empty", "", false},
+		{"text", "building...\nok\n", false},
+		{"colors", "\033[32mPASS\033[0m\n", false},
+		{"utf-8", "héllo wörld ✓\n", false},
+		{"nul", "ustar\x00\x00\x00", true},
+		{"invalid utf-8", "\xff\xfe\x08\x96\x01", true},
+		{"character cut by the sample", strings.Repeat("a", binarySniffLen-1) + "✓", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBinary([]byte(tt.data)); got != tt.want {
+				t.Errorf("IsBinary(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package logfile reads the log files of runs. Once a run stops, the daemon
+// may compress its logs with zstd, replacing <path> with <path>.zst; readers
+// open logs through this package so compressed logs read like the others.
+package logfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressedSuffix is appended to the path of a compressed log
+const CompressedSuffix = ".zst"
+
+// Open opens a log for reading, decompressing it if it was compressed.
+// If neither the log nor its compressed copy exist, the error is the one of
+// the log.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	compressed, zerr := os.Open(path + CompressedSuffix)
+	if zerr != nil {
+		return nil, err
+	}
+	decoder, zerr := zstd.NewReader(compressed)
+	if zerr != nil {
+		compressed.Close()
+		return nil, zerr
+	}
+	return &decompressor{decoder: decoder, file: compressed}, nil
+}
+
+// OpenSeeker is Open for readers that seek, such as followers. A compressed
+// log is decompressed in memory; it belongs to a stopped run, so it does not
+// grow anymore.
+func OpenSeeker(path string) (io.ReadSeekCloser, error) {
+	file, err := os.Open(path)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	content, zerr := ReadFile(path)
+	if zerr != nil {
+		if errors.Is(zerr, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, zerr
+	}
+	return nopCloser{bytes.NewReader(content)}, nil
+}
+
+// ReadFile reads a whole log, decompressing it if it was compressed
+func ReadFile(path string) ([]byte, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Stat returns the file info of a log, or of its compressed copy. Use it to
+// tell whether a log exists.
+func Stat(path string) (os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return info, err
+	}
+	if info, zerr := os.Stat(path + CompressedSuffix); zerr == nil {
+		return info, nil
+	}
+	return nil, err
+}
+
+// Compress replaces a log with a compressed copy, and returns the size of
+// the copy. The log is removed once the copy is complete, so readers always
+// find one of them.
+func Compress(path string) (int64, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	target := path + CompressedSuffix
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	zw, err := zstd.NewWriter(out)
+	if err == nil {
+		_, err = io.Copy(zw, in)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		os.Remove(target)
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// decompressor reads a compressed log, closing the file with the decoder
+type decompressor struct {
+	decoder *zstd.Decoder
+	file    *os.File
+}
+
+func (d *decompressor) Read(p []byte) (int, error) {
+	return d.decoder.Read(p)
+}
+
+func (d *decompressor) Close() error {
+	d.decoder.Close()
+	return d.file.Close()
+}
+
+// nopCloser is a ReadSeeker with a Close that does nothing
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,76 @@
+// Package output decides whether the CLI colors what it prints.
+//
+// Colors are used when the --color flag says so, or with --color=auto (the
+// default) when $NO_COLOR is unset and the output is a terminal.
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Mode is the value of the --color flag
+type Mode string
+
+const (
+	ModeAuto   Mode = "auto"   // color terminals, unless $NO_COLOR is set
+	ModeAlways Mode = "always" // color everything, even pipes
+	ModeNever  Mode = "never"  // never color
+)
+
+// Color is an ANSI foreground color
+type Color string
+
+const (
+	Red     Color = "31"
+	Green   Color = "32"
+	Yellow  Color = "33"
+	Blue    Color = "34"
+	Magenta Color = "35"
+	Cyan    Color = "36"
+)
+
+var mode = ModeAuto
+
+// SetMode sets the color mode from the value of the --color flag
+func SetMode(value string) error {
+	switch m := Mode(value); m {
+	case ModeAuto, ModeAlways, ModeNever:
+		mode = m
+		return nil
+	default:
+		return fmt.Errorf("invalid color mode %q: must be auto, always or never", value)
+	}
+}
+
+// Enabled reports whether output written to w should be colored
+func Enabled(w io.Writer) bool {
+	switch mode {
+	case ModeAlways:
+		return true
+	case ModeNever:
+		return false
+	}
+
+	// https://no-color.org: set and not empty disables color
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// Colorize returns s in color c if output written to w should be colored,
+// and s unchanged otherwise (or if c is empty)
+func Colorize(w io.Writer, c Color, s string) string {
+	if c == "" || !Enabled(w) {
+		return s
+	}
+	return "\033[" + string(c) + "m" + s + "\033[0m"
+}
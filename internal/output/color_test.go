@@ -0,0 +1,40 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetMode_Invalid(t *testing.T) {
+	if err := SetMode("sometimes"); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+}
+
+func TestColorize(t *testing.T) {
+	defer SetMode("auto")
+
+	tests := []struct {
+		mode    string
+		noColor string
+		want    string
+	}{
+		{"always", "", "\033[33mwarn\033[0m"},
+		{"always", "1", "\033[33mwarn\033[0m"},
+		{"never", "", "warn"},
+		{"auto", "", "warn"}, // not a terminal
+		{"auto", "1", "warn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.noColor, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			if err := SetMode(tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if got := Colorize(&bytes.Buffer{}, Yellow, "warn"); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package process
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code GetExitCodeProcess reports for a running process
+const stillActive = 259
+
+// IsProcessRunning checks if a process with the given PID is currently running
+// It opens the process and checks that it has not exited yet
+func IsProcessRunning(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
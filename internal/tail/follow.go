@@ -4,24 +4,29 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"sync"
 	"time"
+
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/output"
 )
 
 // Follow continuously reads new content from a file and writes it to the given writer.
 // It polls the file for changes and streams new content as it appears.
 // This function blocks until an error occurs or the context is cancelled.
 func Follow(filePath string, w io.Writer) error {
-	file, err := os.Open(filePath)
+	// Start from the beginning of the file
+	return FollowFrom(filePath, 0, w)
+}
+
+// FollowFrom is Follow starting at a byte offset of the file
+func FollowFrom(filePath string, offset int64, w io.Writer) error {
+	file, err := logfile.OpenSeeker(filePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	// Start from the beginning of the file
-	offset := int64(0)
-
 	buf := make([]byte, 4096)
 
 	for {
@@ -52,17 +57,27 @@ func Follow(filePath string, w io.Writer) error {
 	}
 }
 
-// FileSource represents a file to follow with an optional prefix for each line
+// FileSource represents a file to follow with an optional prefix for each line,
+// an optional filter deciding which lines are written and an optional
+// transform of the lines written
 type FileSource struct {
-	Path   string
-	Prefix string
+	Path      string
+	Prefix    string
+	Filter    func(line []byte) bool
+	Transform func(line []byte) []byte
+}
+
+// followedSource tracks the goroutine following a source
+type followedSource struct {
+	remove   chan struct{} // closed to stop following once the file is drained
+	finished chan struct{} // closed when the goroutine returns
 }
 
 // Follower manages following multiple files with support for dynamic source addition
 type Follower struct {
 	w              io.Writer
 	mu             sync.Mutex
-	sources        map[string]bool // tracks which paths are already being followed
+	sources        map[string]*followedSource // sources being followed, by path
 	errCh          chan error
 	wg             sync.WaitGroup
 	done           chan struct{}
@@ -74,11 +89,10 @@ type Follower struct {
 const SystemLogTag = "gob"
 
 // SystemLog writes a system log message with the monitor prefix
-// The message is colored cyan to distinguish it from job output
+// The prefix is colored cyan to distinguish it from job output
 func (f *Follower) SystemLog(format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
-	// Cyan color for system messages
-	prefix := fmt.Sprintf("\033[36m[%s]\033[0m ", SystemLogTag)
+	prefix := output.Colorize(f.w, output.Cyan, "["+SystemLogTag+"]") + " "
 	f.mu.Lock()
 	f.w.Write([]byte(prefix + msg + "\n"))
 	f.mu.Unlock()
@@ -88,7 +102,7 @@ func (f *Follower) SystemLog(format string, args ...interface{}) {
 func NewFollower(w io.Writer) *Follower {
 	return &Follower{
 		w:              w,
-		sources:        make(map[string]bool),
+		sources:        make(map[string]*followedSource),
 		errCh:          make(chan error, 100),
 		done:           make(chan struct{}),
 		lastOutputTime: time.Now(), // initialize to now so we don't immediately trigger stuck detection
@@ -111,23 +125,41 @@ func (f *Follower) updateLastOutputTime() {
 // followed, this is a no-op.
 func (f *Follower) AddSource(source FileSource) {
 	f.mu.Lock()
-	if f.sources[source.Path] {
+	if f.sources[source.Path] != nil {
 		f.mu.Unlock()
 		return
 	}
-	f.sources[source.Path] = true
+	followed := &followedSource{remove: make(chan struct{}), finished: make(chan struct{})}
+	f.sources[source.Path] = followed
 	f.mu.Unlock()
 
 	f.wg.Add(1)
 	go func() {
 		defer f.wg.Done()
-		err := followWithPrefix(source.Path, source.Prefix, f.w, &f.mu, f.done, f.updateLastOutputTime)
+		defer close(followed.finished)
+		err := followWithPrefix(source, f.w, &f.mu, f.done, followed.remove, f.updateLastOutputTime)
 		if err != nil {
 			f.errCh <- err
 		}
 	}()
 }
 
+// RemoveSource stops following a source after writing what is left in the
+// file, and blocks until that is done. Removing a source that is not being
+// followed is a no-op.
+func (f *Follower) RemoveSource(path string) {
+	f.mu.Lock()
+	followed := f.sources[path]
+	delete(f.sources, path)
+	f.mu.Unlock()
+
+	if followed == nil {
+		return
+	}
+	close(followed.remove)
+	<-followed.finished
+}
+
 // Stop signals all followers to stop and waits for them to finish
 func (f *Follower) Stop() {
 	f.mu.Lock()
@@ -162,10 +194,12 @@ func FollowMultiple(sources []FileSource, w io.Writer) error {
 	return f.Wait()
 }
 
-// followWithPrefix follows a file and prefixes each line with the given prefix
+// followWithPrefix follows a file and prefixes each line with the source's prefix,
+// skipping lines rejected by its filter. Once remove is closed it returns at
+// the end of the file, writing a last line without a trailing newline.
 // onOutput is called (with mu held) each time output is written
-func followWithPrefix(filePath string, prefix string, w io.Writer, mu *sync.Mutex, done <-chan struct{}, onOutput func()) error {
-	file, err := os.Open(filePath)
+func followWithPrefix(source FileSource, w io.Writer, mu *sync.Mutex, done, remove <-chan struct{}, onOutput func()) error {
+	file, err := logfile.OpenSeeker(source.Path)
 	if err != nil {
 		return err
 	}
@@ -174,12 +208,15 @@ func followWithPrefix(filePath string, prefix string, w io.Writer, mu *sync.Mute
 	offset := int64(0)
 	buf := make([]byte, 4096)
 	var lineBuf bytes.Buffer
+	draining := false
 
 	for {
 		// Check if we should stop
 		select {
 		case <-done:
 			return nil
+		case <-remove:
+			draining = true
 		default:
 		}
 
@@ -202,15 +239,20 @@ func followWithPrefix(filePath string, prefix string, w io.Writer, mu *sync.Mute
 					line := lineBuf.Bytes()
 					lineBuf.Reset()
 
-					mu.Lock()
-					if prefix != "" {
-						w.Write([]byte(prefix))
-					}
-					w.Write(line)
-					if onOutput != nil {
-						onOutput()
+					if source.Filter == nil || source.Filter(line) {
+						if source.Transform != nil {
+							line = source.Transform(line)
+						}
+						mu.Lock()
+						if source.Prefix != "" {
+							w.Write([]byte(source.Prefix))
+						}
+						w.Write(line)
+						if onOutput != nil {
+							onOutput()
+						}
+						mu.Unlock()
 					}
-					mu.Unlock()
 
 					data = data[idx+1:]
 				} else {
@@ -226,6 +268,23 @@ func followWithPrefix(filePath string, prefix string, w io.Writer, mu *sync.Mute
 		}
 
 		if n == 0 || err == io.EOF {
+			if draining {
+				if lineBuf.Len() > 0 && (source.Filter == nil || source.Filter(lineBuf.Bytes())) {
+					line := lineBuf.Bytes()
+					if source.Transform != nil {
+						line = source.Transform(line)
+					}
+					mu.Lock()
+					w.Write([]byte(source.Prefix))
+					w.Write(line)
+					w.Write([]byte("\n"))
+					if onOutput != nil {
+						onOutput()
+					}
+					mu.Unlock()
+				}
+				return nil
+			}
 			time.Sleep(100 * time.Millisecond)
 		}
 	}
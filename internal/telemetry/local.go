@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/posthog/posthog-go"
+)
+
+var (
+	local   *os.File // events file in local mode, nil otherwise
+	localMu sync.Mutex
+)
+
+// initLocal opens the events file of local mode
+func initLocal() {
+	path := LocalPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		slog.Error("Failed to create telemetry directory", "error", err)
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		slog.Error("Failed to open telemetry file", "error", err)
+		return
+	}
+	local = f
+}
+
+// writeLocal appends an event to the events file
+func writeLocal(event string, props posthog.Properties) {
+	line, err := json.Marshal(map[string]any{
+		"time":       time.Now().UTC().Format(time.RFC3339),
+		"event":      event,
+		"properties": props,
+	})
+	if err != nil {
+		slog.Error("Failed to encode telemetry event", "event", event, "error", err)
+		return
+	}
+
+	localMu.Lock()
+	defer localMu.Unlock()
+	if local == nil {
+		return
+	}
+	if _, err := local.Write(append(line, '\n')); err != nil {
+		slog.Error("Failed to write telemetry event", "event", event, "error", err)
+	}
+}
+
+// closeLocal closes the events file
+func closeLocal() {
+	localMu.Lock()
+	defer localMu.Unlock()
+	if local != nil {
+		local.Close()
+		local = nil
+	}
+}
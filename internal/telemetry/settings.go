@@ -0,0 +1,141 @@
+package telemetry
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Telemetry modes
+const (
+	ModeOn    = "on"    // events are sent
+	ModeOff   = "off"   // no events are recorded
+	ModeLocal = "local" // events are written to LocalPath instead of sent
+)
+
+// Sources of the telemetry mode in effect
+const (
+	SourceEnv    = "environment" // GOB_TELEMETRY, GOB_TELEMETRY_DISABLED or DO_NOT_TRACK
+	SourceConfig = "config"      // chosen at the consent prompt or with gob telemetry
+	SourceUnset  = ""            // not chosen yet: off until the user answers the prompt
+)
+
+// settings are the telemetry settings from $XDG_CONFIG_HOME/gob/telemetry.toml
+type settings struct {
+	Mode string `toml:"mode"`
+}
+
+// SettingsPath returns the path of the telemetry settings file
+func SettingsPath() string {
+	return filepath.Join(xdg.ConfigHome, "gob", "telemetry.toml")
+}
+
+// LocalPath returns the file events are written to in local mode, one JSON
+// object per line
+func LocalPath() string {
+	return filepath.Join(xdg.StateHome, "gob", "telemetry.jsonl")
+}
+
+// ParseMode validates a telemetry mode
+func ParseMode(s string) (string, error) {
+	switch s {
+	case ModeOn, ModeOff, ModeLocal:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid telemetry mode %q: want on, off or local", s)
+}
+
+// Mode returns the telemetry mode in effect and where it comes from. The
+// environment overrides the settings file; without either telemetry is off.
+func Mode() (string, string, error) {
+	if v, _ := strconv.ParseBool(os.Getenv("GOB_TELEMETRY_DISABLED")); v {
+		return ModeOff, SourceEnv, nil
+	}
+	if v, _ := strconv.ParseBool(os.Getenv("DO_NOT_TRACK")); v {
+		return ModeOff, SourceEnv, nil
+	}
+	if v := os.Getenv("GOB_TELEMETRY"); v != "" {
+		mode, err := ParseMode(v)
+		if err != nil {
+			return ModeOff, SourceEnv, fmt.Errorf("GOB_TELEMETRY: %w", err)
+		}
+		return mode, SourceEnv, nil
+	}
+
+	mode, err := savedMode()
+	if err != nil || mode == "" {
+		return ModeOff, SourceUnset, err
+	}
+	return mode, SourceConfig, nil
+}
+
+// savedMode returns the mode in the settings file, or "" if there is none
+func savedMode() (string, error) {
+	path := SettingsPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var s settings
+	if err := toml.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if s.Mode == "" {
+		return "", nil
+	}
+	if _, err := ParseMode(s.Mode); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return s.Mode, nil
+}
+
+// SaveMode writes the telemetry mode to the settings file
+func SaveMode(mode string) error {
+	if _, err := ParseMode(mode); err != nil {
+		return err
+	}
+	data, err := toml.Marshal(settings{Mode: mode})
+	if err != nil {
+		return err
+	}
+
+	path := SettingsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// AskConsent asks whether to send telemetry, and saves the answer. An empty
+// or unknown answer, or none, is no.
+func AskConsent(in io.Reader, out io.Writer) (string, error) {
+	fmt.Fprint(out, `gob can send anonymous usage statistics: the commands run, how long they
+took and the TUI actions used, never their arguments, output or paths
+(see https://github.com/juanibiapina/gob/blob/main/docs/telemetry.md).
+Change your answer any time with 'gob telemetry on|off|local'.
+
+Send usage statistics? [y]es, [n]o, [l]ocal file only: `)
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	mode := ModeOff
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		mode = ModeOn
+	case "l", "local":
+		mode = ModeLocal
+	}
+	fmt.Fprintln(out)
+	return mode, SaveMode(mode)
+}
@@ -0,0 +1,107 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+// isolate points the settings and the local events file at a temporary
+// directory, without telemetry variables in the environment
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("GOB_TELEMETRY", "")
+	t.Setenv("GOB_TELEMETRY_DISABLED", "")
+	t.Setenv("DO_NOT_TRACK", "")
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+}
+
+func TestMode(t *testing.T) {
+	isolate(t)
+
+	if mode, source, err := Mode(); err != nil || mode != ModeOff || source != SourceUnset {
+		t.Errorf("expected off until chosen, got %q from %q (%v)", mode, source, err)
+	}
+
+	if err := SaveMode(ModeLocal); err != nil {
+		t.Fatalf("SaveMode failed: %v", err)
+	}
+	if mode, source, _ := Mode(); mode != ModeLocal || source != SourceConfig {
+		t.Errorf("expected local from config, got %q from %q", mode, source)
+	}
+	if err := SaveMode("maybe"); err == nil {
+		t.Error("expected an invalid mode to be rejected")
+	}
+
+	// The environment overrides the settings file
+	t.Setenv("GOB_TELEMETRY", "on")
+	if mode, source, _ := Mode(); mode != ModeOn || source != SourceEnv {
+		t.Errorf("expected on from the environment, got %q from %q", mode, source)
+	}
+	t.Setenv("DO_NOT_TRACK", "1")
+	if mode, _, _ := Mode(); mode != ModeOff {
+		t.Errorf("expected DO_NOT_TRACK to turn telemetry off, got %q", mode)
+	}
+}
+
+func TestAskConsent(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"y\n", ModeOn},
+		{"Yes\n", ModeOn},
+		{"l\n", ModeLocal},
+		{"n\n", ModeOff},
+		{"\n", ModeOff},
+		{"", ModeOff},
+	}
+	for _, tt := range tests {
+		isolate(t)
+		var out bytes.Buffer
+		mode, err := AskConsent(strings.NewReader(tt.answer), &out)
+		if err != nil {
+			t.Fatalf("AskConsent(%q) failed: %v", tt.answer, err)
+		}
+		if saved, _, _ := Mode(); mode != tt.want || saved != tt.want {
+			t.Errorf("AskConsent(%q) = %q, saved %q, want %q", tt.answer, mode, saved, tt.want)
+		}
+		if !strings.Contains(out.String(), "Send usage statistics?") {
+			t.Errorf("expected the question, got %q", out.String())
+		}
+	}
+}
+
+func TestLocalMode(t *testing.T) {
+	isolate(t)
+	t.Setenv("GOB_TELEMETRY", ModeLocal)
+
+	Init()
+	TUIActionExecute("restart")
+	Flush()
+
+	data, err := os.ReadFile(LocalPath())
+	if err != nil {
+		t.Fatalf("expected the events file: %v", err)
+	}
+	var event struct {
+		Event      string         `json:"event"`
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", data, err)
+	}
+	if event.Event != "tui:action_execute" || event.Properties["action_name"] != "restart" || event.Properties["goos"] == nil {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if client != nil {
+		t.Error("expected nothing to be sent in local mode")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
-	"strconv"
 
 	"github.com/juanibiapina/gob/internal/version"
 	"github.com/posthog/posthog-go"
@@ -30,10 +29,20 @@ var (
 			Set("go_version", runtime.Version())
 )
 
+// Init starts recording events in the mode in effect (see Mode)
 func Init() {
-	if isDisabled() {
+	mode, _, err := Mode()
+	if err != nil {
+		slog.Error("Failed to read telemetry settings", "error", err)
+	}
+	switch mode {
+	case ModeOff:
+		return
+	case ModeLocal:
+		initLocal()
 		return
 	}
+
 	c, err := posthog.NewWithConfig(key, posthog.Config{
 		Endpoint: endpoint,
 		Logger:   logger{},
@@ -45,17 +54,11 @@ func Init() {
 	distinctId = getDistinctId()
 }
 
-func isDisabled() bool {
-	if v, _ := strconv.ParseBool(os.Getenv("GOB_TELEMETRY_DISABLED")); v {
-		return true
-	}
-	if v, _ := strconv.ParseBool(os.Getenv("DO_NOT_TRACK")); v {
-		return true
-	}
-	return false
-}
-
 func send(event string, props ...any) {
+	if local != nil {
+		writeLocal(event, pairsToProps(props...).Merge(baseProps))
+		return
+	}
 	if client == nil {
 		return
 	}
@@ -71,7 +74,7 @@ func send(event string, props ...any) {
 }
 
 func Error(err any, props ...any) {
-	if client == nil {
+	if client == nil && local == nil {
 		return
 	}
 	props = append(
@@ -87,6 +90,7 @@ func Error(err any, props ...any) {
 }
 
 func Flush() {
+	closeLocal()
 	if client == nil {
 		return
 	}
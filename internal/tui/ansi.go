@@ -3,32 +3,18 @@
 package tui
 
 import (
-	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
-)
-
-// cursorSequenceRegex matches ANSI cursor movement and screen control sequences.
-// These are used by progress bars and spinners to update in place, but they
-// break TUI rendering when displayed in a viewport.
-var cursorSequenceRegex = regexp.MustCompile(
-	`\x1b\[` + // CSI (Control Sequence Introducer)
-		`(?:` +
-		`\d*[ABCDEFGH]` + // Cursor movement (up/down/forward/back/next line/prev line/column/position)
-		`|\d*;\d*[Hf]` + // Cursor position (row;col)
-		`|[suKJ]` + // Save/restore cursor, erase line/screen
-		`|\d*[KJ]` + // Erase with count
-		`|\?(?:25[hl]|\d+[hl])` + // Show/hide cursor, other private modes
-		`)`,
+	"github.com/juanibiapina/gob/internal/daemon"
 )
 
 // StripCursorSequences removes ANSI cursor movement and line erasing sequences
 // while preserving color codes. Progress bars and spinners use these sequences
 // to update in place, which breaks TUI rendering.
 func StripCursorSequences(s string) string {
-	return cursorSequenceRegex.ReplaceAllString(s, "")
+	return daemon.StripCursorSequences(s)
 }
 
 // FitToWidth ensures a string is exactly the specified visual width.
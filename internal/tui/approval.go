@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// approvalsMsg carries the requests waiting for approval when the TUI
+// subscribes
+type approvalsMsg struct {
+	approvals []daemon.Approval
+}
+
+// fetchApprovals loads the requests already waiting for approval. New ones
+// arrive as events.
+func (m Model) fetchApprovals() tea.Cmd {
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			return nil
+		}
+		approvals, err := client.Approvals()
+		if err != nil {
+			return nil
+		}
+		return approvalsMsg{approvals: approvals}
+	}
+}
+
+// handleApprovalEvent queues a request waiting for approval, or drops one
+// that was decided, and shows the approval modal while any is waiting
+func (m *Model) handleApprovalEvent(event daemon.Event) {
+	switch event.Type {
+	case daemon.EventTypeApprovalRequested:
+		m.approvals = append(m.approvals, *event.Approval)
+	case daemon.EventTypeApprovalDecided:
+		m.approvals = slices.DeleteFunc(m.approvals, func(a daemon.Approval) bool { return a.ID == event.Approval.ID })
+	}
+	m.showApprovals()
+}
+
+// showApprovals opens the approval modal if a request is waiting and no
+// other modal is open, and closes it when none is left
+func (m *Model) showApprovals() {
+	switch {
+	case len(m.approvals) > 0 && m.modal == modalNone:
+		m.modal = modalApproval
+	case len(m.approvals) == 0 && m.modal == modalApproval:
+		m.modal = modalNone
+	}
+}
+
+// decideApproval approves or denies a request waiting for approval
+func (m Model) decideApproval(approval daemon.Approval, approve bool) tea.Cmd {
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			if msg := checkVersionMismatch(err); msg != nil {
+				return msg
+			}
+			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
+		}
+
+		decided, err := client.Approve(approval.ID, approve)
+		if err != nil {
+			return actionResultMsg{message: fmt.Sprintf("Failed to decide: %v", err), isError: true}
+		}
+		return actionResultMsg{message: fmt.Sprintf("%s: %s %s", decided.Decision, decided.Client, decided.Type)}
+	}
+}
+
+// updateApprovalModal handles keys while a request waits for approval. The
+// modal stays open until every request is decided; esc hides it until the
+// next request arrives.
+func (m Model) updateApprovalModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if len(m.approvals) == 0 {
+		m.modal = modalNone
+		return m, nil
+	}
+	approval := m.approvals[0]
+
+	switch msg.String() {
+	case "y":
+		m.approvals = m.approvals[1:]
+		m.modal = modalNone
+		m.showApprovals()
+		telemetry.TUIActionExecute("approve_request")
+		return m, m.decideApproval(approval, true)
+	case "n":
+		m.approvals = m.approvals[1:]
+		m.modal = modalNone
+		m.showApprovals()
+		telemetry.TUIActionExecute("deny_request")
+		return m, m.decideApproval(approval, false)
+	case "esc":
+		m.modal = modalNone
+	case "ctrl+c":
+		if m.subClient != nil {
+			m.subClient.Close()
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderApprovalModal() string {
+	if len(m.approvals) == 0 {
+		return ""
+	}
+	a := m.approvals[0]
+
+	title := dialogTitleStyle.Render("Approve request?")
+	text := fmt.Sprintf("%s wants to %s", a.Client, a.Type)
+	if a.Target != "" {
+		text += "\n" + m.truncate(a.Target, 50)
+	}
+	text += "\n\nNeeds approval: " + a.Reason
+	if waiting := len(m.approvals) - 1; waiting > 0 {
+		text += fmt.Sprintf(" (%d more waiting)", waiting)
+	}
+	help := helpDescStyle.Render("y: approve • n: deny • esc: later")
+
+	return dialogStyle.Render(title + "\n\n" + text + "\n\n" + help)
+}
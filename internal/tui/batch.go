@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// maxBatchModalJobs is how many jobs the batch confirmation lists by command
+const maxBatchModalJobs = 8
+
+// pendingBatch is a lifecycle action on the marked jobs (or the selected
+// job, for actions the config asks to confirm), waiting for confirmation
+type pendingBatch struct {
+	key     string // the lifecycle key: "s", "S", "r" or "d"
+	jobs    []Job  // jobs the action applies to
+	skipped int    // marked jobs it does not apply to (e.g. stopping stopped jobs)
+	marked  bool   // the jobs are the marked ones, not the selected one
+}
+
+// verb names the batch action in the confirmation and result messages
+func (b pendingBatch) verb() (present, past string) {
+	switch b.key {
+	case "s":
+		return "Stop", "Stopped"
+	case "S":
+		return "Kill", "Killed"
+	case "r":
+		return "Restart", "Restarted"
+	default:
+		return "Delete", "Deleted"
+	}
+}
+
+// actionApplies reports whether a lifecycle key acts on a job: stop and
+// kill act on running jobs, delete on stopped jobs that are not pinned and
+// restart on all
+func actionApplies(key string, job Job) bool {
+	switch key {
+	case "s", "S":
+		return job.Running
+	case "d":
+		return !job.Running && !job.Pinned
+	}
+	return true
+}
+
+// jobCount formats a number of jobs: "1 job", "3 jobs"
+func jobCount(n int) string {
+	if n == 1 {
+		return "1 job"
+	}
+	return fmt.Sprintf("%d jobs", n)
+}
+
+// toggleMark marks or unmarks the selected job and moves to the next one
+func (m *Model) toggleMark() tea.Cmd {
+	if len(m.jobs) == 0 {
+		return nil
+	}
+	id := m.jobs[m.jobScroll.Cursor].ID
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+	telemetry.TUIActionExecute("toggle_mark")
+	if m.jobScroll.Down(len(m.jobs)) {
+		return m.onJobChanged()
+	}
+	return nil
+}
+
+// pruneMarks forgets marks of jobs that are no longer listed
+func (m *Model) pruneMarks() {
+	for id := range m.marked {
+		found := false
+		for _, job := range m.jobs {
+			if job.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			delete(m.marked, id)
+		}
+	}
+}
+
+// newPendingBatch collects the marked jobs a lifecycle key applies to.
+// Returns false if no job is marked.
+func (m Model) newPendingBatch(key string) (pendingBatch, bool) {
+	b := pendingBatch{key: key, marked: true}
+	for _, job := range m.jobs {
+		if !m.marked[job.ID] {
+			continue
+		}
+		if actionApplies(key, job) {
+			b.jobs = append(b.jobs, job)
+		} else {
+			b.skipped++
+		}
+	}
+	return b, len(b.jobs) > 0 || b.skipped > 0
+}
+
+// runBatch applies a batch in one daemon request. Deleted jobs can be
+// restored for undoWindow (see restoreJobs).
+func (m Model) runBatch(b pendingBatch) tea.Cmd {
+	present, past := b.verb()
+
+	req := daemon.BatchRequest{Env: m.env}
+	switch b.key {
+	case "s", "S":
+		req.Action = daemon.BatchStop
+		req.Force = b.key == "S"
+	case "r":
+		req.Action = daemon.BatchRestart
+	default:
+		req.Action = daemon.BatchRemove
+	}
+	for _, job := range b.jobs {
+		req.JobIDs = append(req.JobIDs, job.ID)
+	}
+
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			if msg := checkVersionMismatch(err); msg != nil {
+				return msg
+			}
+			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
+		}
+
+		// Keep the definitions of deleted jobs to undo the deletion
+		definitions := make(map[string]daemon.JobResponse)
+		if req.Action == daemon.BatchRemove {
+			for _, id := range req.JobIDs {
+				if job, err := client.GetJob(id); err == nil {
+					definitions[id] = *job
+				}
+			}
+		}
+
+		results, err := client.Batch(req)
+		if err != nil {
+			return actionResultMsg{message: fmt.Sprintf("Failed to %s: %v", strings.ToLower(present), err), isError: true}
+		}
+
+		var failed []daemon.BatchResult
+		var deleted []daemon.JobResponse
+		for _, r := range results {
+			if !r.Success {
+				failed = append(failed, r)
+			} else if job, ok := definitions[r.JobID]; ok {
+				deleted = append(deleted, job)
+			}
+		}
+
+		var result actionResultMsg
+		if len(failed) > 0 {
+			result = actionResultMsg{
+				message: fmt.Sprintf("%s %d of %s; %s: %s", past, len(results)-len(failed), jobCount(len(results)), failed[0].JobID, failed[0].Error),
+				isError: true,
+			}
+		} else {
+			result = actionResultMsg{message: fmt.Sprintf("%s %s", past, jobCount(len(results)))}
+		}
+		if len(deleted) > 0 {
+			return jobsDeletedMsg{result: result, jobs: deleted}
+		}
+		return result
+	}
+}
+
+// updateBatchModal handles keys while a batch waits for confirmation
+func (m Model) updateBatchModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		m.modal = modalNone
+		b := m.batch
+		m.batch = pendingBatch{}
+		if len(b.jobs) == 0 {
+			return m, nil
+		}
+		if !b.marked {
+			return m, m.lifecycleCmd(b.key, b.jobs[0])
+		}
+		present, _ := b.verb()
+		telemetry.TUIActionExecute("batch_" + strings.ToLower(present))
+		m.marked = nil
+		return m, m.runBatch(b)
+	case "n", "esc", "q":
+		m.modal = modalNone
+		m.batch = pendingBatch{}
+	case "ctrl+c":
+		if m.subClient != nil {
+			m.subClient.Close()
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderBatchModal() string {
+	present, _ := m.batch.verb()
+	title := dialogTitleStyle.Render(fmt.Sprintf("%s %s?", present, jobCount(len(m.batch.jobs))))
+	help := helpDescStyle.Render("y/enter: confirm • n/esc: cancel")
+	if len(m.batch.jobs) == 0 {
+		title = dialogTitleStyle.Render("Nothing to " + strings.ToLower(present))
+		help = helpDescStyle.Render("esc: close")
+	}
+
+	var lines []string
+	for i, job := range m.batch.jobs {
+		if i == maxBatchModalJobs {
+			lines = append(lines, mutedStyle.Render(fmt.Sprintf("… and %d more", len(m.batch.jobs)-i)))
+			break
+		}
+		label := job.Command
+		if job.Alias != "" {
+			label = job.Alias + ": " + job.Command
+		}
+		lines = append(lines, m.truncate(label, 50))
+	}
+	if m.batch.skipped > 0 {
+		reason := "not running"
+		if m.batch.key == "d" {
+			reason = "still running"
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("Skipping %s %s", jobCount(m.batch.skipped), reason)))
+	}
+
+	content := title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + help
+
+	return dialogStyle.Render(content)
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// cleanupKeep is how many stopped runs of each job cleanup keeps, like
+// 'gob disk-usage --prune'
+const cleanupKeep = 1
+
+// cleanupWorkdir is the directory whose jobs cleanup prunes: the current
+// one, or all of them when the TUI shows all directories
+func (m Model) cleanupWorkdir() string {
+	if m.showAll {
+		return ""
+	}
+	return m.cwd
+}
+
+// pruneRuns removes the old stopped runs of the listed jobs and their logs.
+// The daemon reports each removed run as an event.
+func (m Model) pruneRuns() tea.Cmd {
+	workdir := m.cleanupWorkdir()
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			if msg := checkVersionMismatch(err); msg != nil {
+				return msg
+			}
+			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
+		}
+
+		removed, freed, err := client.PruneLogs(workdir, cleanupKeep, false)
+		if err != nil {
+			return actionResultMsg{message: fmt.Sprintf("Failed to clean up: %v", err), isError: true}
+		}
+		if removed == 0 {
+			return actionResultMsg{message: "Nothing to clean up"}
+		}
+		runs := "runs"
+		if removed == 1 {
+			runs = "run"
+		}
+		return actionResultMsg{message: fmt.Sprintf("Removed %d old %s, freed %s", removed, runs, daemon.FormatBytes(freed))}
+	}
+}
+
+// updateCleanupModal handles keys while cleanup waits for confirmation
+func (m Model) updateCleanupModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		m.modal = modalNone
+		telemetry.TUIActionExecute("cleanup_runs")
+		return m, m.pruneRuns()
+	case "n", "esc", "q":
+		m.modal = modalNone
+	case "ctrl+c":
+		if m.subClient != nil {
+			m.subClient.Close()
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) renderCleanupModal() string {
+	title := dialogTitleStyle.Render("Clean up old runs?")
+
+	scope := "jobs in " + m.shortenPath(m.cwd)
+	if m.showAll {
+		scope = "all jobs"
+	}
+	text := fmt.Sprintf("Deletes the stopped runs of %s\nand their logs, keeping the latest run of each.", scope)
+	help := helpDescStyle.Render("y/enter: confirm • n/esc: cancel")
+
+	content := title + "\n\n" + text + "\n\n" + help
+
+	return dialogStyle.Render(content)
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Config holds the TUI settings from $XDG_CONFIG_HOME/gob/tui.toml:
+//
+//	confirm_delete = true # ask before 'd' deletes a job
+//	confirm_kill = true   # ask before 'S' kills a job
+//
+//	[layout]              # written by the TUI when panels are resized
+//	jobs_width = 45
+//	stderr_height = 30
+//
+// Missing settings keep their defaults (no confirmations).
+type Config struct {
+	ConfirmDelete bool         `toml:"confirm_delete"`
+	ConfirmKill   bool         `toml:"confirm_kill"`
+	Layout        LayoutConfig `toml:"layout"`
+}
+
+// LayoutConfig holds the panel sizes, in percent. Zero keeps the default.
+type LayoutConfig struct {
+	JobsWidth    int `toml:"jobs_width,omitempty"`    // left column, share of the screen width
+	StderrHeight int `toml:"stderr_height,omitempty"` // unfocused stderr panel, share of the log column
+}
+
+// configPath returns the path of the TUI config file
+func configPath() string {
+	return filepath.Join(xdg.ConfigHome, "gob", "tui.toml")
+}
+
+// LoadConfig reads the TUI config file. A missing file is the default config.
+func LoadConfig() (Config, error) {
+	var config Config
+
+	path := configPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := toml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// SaveConfig writes the TUI config file
+func SaveConfig(config Config) error {
+	data, err := toml.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	path := configPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// confirms reports whether a lifecycle key asks for confirmation
+func (c Config) confirms(key string) bool {
+	switch key {
+	case "d":
+		return c.ConfirmDelete
+	case "S":
+		return c.ConfirmKill
+	}
+	return false
+}
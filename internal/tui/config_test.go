@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig without a file failed: %v", err)
+	}
+	if config.ConfirmDelete || config.ConfirmKill {
+		t.Errorf("expected no confirmations by default, got %+v", config)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "gob"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "gob", "tui.toml"), []byte("confirm_delete = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !config.ConfirmDelete || config.ConfirmKill {
+		t.Errorf("expected only confirm_delete, got %+v", config)
+	}
+}
+
+func TestSaveConfig_RoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	want := Config{ConfirmKill: true, Layout: LayoutConfig{JobsWidth: 45, StderrHeight: 30}}
+	if err := SaveConfig(want); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	got, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("LoadConfig() = %+v, want %+v", got, want)
+	}
+}
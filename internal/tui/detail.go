@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// maxDetailFailures is how many recent failed runs the job detail lists
+const maxDetailFailures = 5
+
+// maxDetailNotes is how many recent run notes the job detail lists
+const maxDetailNotes = 5
+
+// maxDetailWidth is the widest the job detail gets on large terminals
+const maxDetailWidth = 90
+
+// openJobDetail shows everything known about the selected job
+func (m *Model) openJobDetail() {
+	if len(m.jobs) == 0 {
+		return
+	}
+	m.modal = modalJobDetail
+	telemetry.TUIActionExecute("job_detail")
+}
+
+// updateDetailModal handles keys while the job detail is open
+func (m Model) updateDetailModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "enter", "i":
+		m.modal = modalNone
+	case "ctrl+c":
+		if m.subClient != nil {
+			m.subClient.Close()
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// selectedJobDetail returns the full daemon view of the selected job, which
+// the runs panel keeps for its stats, if it is loaded
+func (m Model) selectedJobDetail() *daemon.JobResponse {
+	if len(m.jobs) == 0 || m.stats == nil || m.stats.ID != m.jobs[m.jobScroll.Cursor].ID {
+		return nil
+	}
+	return m.stats
+}
+
+func (m Model) renderJobDetailModal() string {
+	job := m.jobs[m.jobScroll.Cursor]
+	detail := m.selectedJobDetail()
+
+	width := maxDetailWidth
+	if m.width-4 < width {
+		width = m.width - 4
+	}
+
+	title := dialogTitleStyle.Render("Job " + job.ID)
+
+	var lines []string
+	field := func(name, value string) {
+		if value != "" {
+			lines = append(lines, helpKeyStyle.Render(fmt.Sprintf("%-12s", name))+value)
+		}
+	}
+
+	field("Command", job.Command)
+	field("Alias", job.Alias)
+	field("Description", job.Description)
+	field("Workdir", m.shortenPath(job.Workdir))
+	if detail != nil {
+		if detail.RunDir != "" {
+			field("Runs in", m.shortenPath(detail.RunDir))
+		}
+		if detail.Shell != "" {
+			field("Shell", detail.Shell)
+		}
+		field("Tags", strings.Join(detail.Tags, ", "))
+		if created := parseTime(detail.CreatedAt); !created.IsZero() {
+			field("Created", created.Local().Format("2006-01-02 15:04:05"))
+		}
+		field("Created by", daemon.FormatOrigin(detail.CreatedBy, detail.CreatedVia))
+		field("Started by", daemon.FormatOrigin(detail.StartedBy, detail.StartedVia))
+		if detail.Priority != "" && detail.Priority != daemon.PriorityNormal {
+			field("Priority", string(detail.Priority))
+		}
+		if detail.MemoryLimit > 0 {
+			field("Memory", daemon.FormatBytes(detail.MemoryLimit))
+		}
+		if detail.CPULimit > 0 {
+			field("CPU", fmt.Sprintf("%g cores", detail.CPULimit))
+		}
+		if detail.Stdin != "" && detail.Stdin != "null" {
+			field("Stdin", detail.Stdin)
+		}
+		if detail.Hooks != nil {
+			field("On start", detail.Hooks.OnStart)
+			field("On success", detail.Hooks.OnSuccess)
+			field("On failure", detail.Hooks.OnFailure)
+			field("On slow", detail.Hooks.OnSlow)
+		}
+		field("Slow after", detail.SlowThreshold)
+		if detail.RunCount > 0 {
+			field("Runs", fmt.Sprintf("%d (%.0f%% success)", detail.RunCount, detail.SuccessRate))
+		}
+	}
+	if job.Running {
+		field("Status", fmt.Sprintf("running since %s (PID %d)", m.formatJobTiming(job), job.PID))
+	} else if !job.StartedAt.IsZero() {
+		field("Last run", m.formatJobTiming(job))
+	}
+
+	// Recent failures from the runs panel
+	var failures []string
+	for _, run := range m.runs {
+		if run.JobID != job.ID || run.ExitCode == nil || *run.ExitCode == 0 {
+			continue
+		}
+		if len(failures) == maxDetailFailures {
+			break
+		}
+		failures = append(failures, fmt.Sprintf("  %s  exit %d  %s", run.ID, *run.ExitCode,
+			run.StartedAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	if len(failures) > 0 {
+		lines = append(lines, "", helpKeyStyle.Render("Recent failures"))
+		lines = append(lines, failures...)
+	}
+
+	// Notes attached to runs with gob annotate
+	var notes []string
+	for _, run := range m.runs {
+		if run.JobID != job.ID || run.Note == "" {
+			continue
+		}
+		if len(notes) == maxDetailNotes {
+			break
+		}
+		notes = append(notes, fmt.Sprintf("  %s  %s", run.ID, run.Note))
+	}
+	if len(notes) > 0 {
+		lines = append(lines, "", helpKeyStyle.Render("Run notes"))
+		lines = append(lines, notes...)
+	}
+
+	help := helpDescStyle.Render("esc: close")
+
+	content := title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + help
+
+	return dialogStyle.Width(width).Render(content)
+}
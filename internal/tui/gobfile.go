@@ -1,10 +1,14 @@
 package tui
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/juanibiapina/gob/internal/daemon"
@@ -15,15 +19,43 @@ const gobfilePath = ".config/gobfile.toml"
 
 // GobfileConfig represents the parsed gobfile.toml configuration
 type GobfileConfig struct {
-	Jobs []GobfileJob `toml:"job"`
+	Isolated    bool         `toml:"isolated,omitempty"`      // the project has its own daemon (see daemon.ProjectDir)
+	Portable    bool         `toml:"portable,omitempty"`      // jobs follow the project to other checkouts (see daemon.PortableProject)
+	LogDir      string       `toml:"log_dir,omitempty"`       // default log directory of the jobs, relative to the project
+	StopOnLeave string       `toml:"stop_on_leave,omitempty"` // stop on_enter jobs after no shell is in the project for this long, e.g. "10m"
+	Jobs        []GobfileJob `toml:"job"`
 }
 
 // GobfileJob represents a single job in the gobfile
 type GobfileJob struct {
-	Command     string `toml:"command"`
-	Description string `toml:"description"`
-	Autostart   *bool  `toml:"autostart"` // nil defaults to false
-	Blocked     *bool  `toml:"blocked"`   // nil defaults to false
+	Command       string            `toml:"command"`
+	Description   string            `toml:"description,omitempty"`
+	Workdir       string            `toml:"workdir,omitempty"`        // directory of the job, relative to the project
+	Autostart     *bool             `toml:"autostart,omitempty"`      // nil defaults to false
+	OnEnter       bool              `toml:"on_enter,omitempty"`       // start when a shell with 'gob hook cd' enters the project
+	Blocked       *bool             `toml:"blocked,omitempty"`        // nil defaults to false
+	Priority      string            `toml:"priority,omitempty"`       // low, normal or high (empty keeps current)
+	MemoryLimit   string            `toml:"memory_limit,omitempty"`   // e.g. "2G" (empty keeps current)
+	CPULimit      float64           `toml:"cpu_limit,omitempty"`      // cores, e.g. 1.5 (0 keeps current)
+	OnStart       string            `toml:"on_start,omitempty"`       // hook run after each run starts
+	OnSuccess     string            `toml:"on_success,omitempty"`     // hook run after a successful run
+	OnFailure     string            `toml:"on_failure,omitempty"`     // hook run after a failed run
+	OnSlow        string            `toml:"on_slow,omitempty"`        // hook run when a run passes the job's slow threshold
+	Stdin         string            `toml:"stdin,omitempty"`          // null, open, or a file path relative to the project
+	LogFormat     string            `toml:"log_format,omitempty"`     // text or json (empty keeps current)
+	Timestamps    bool              `toml:"timestamps,omitempty"`     // record when each output line was written
+	CombineOutput bool              `toml:"combine_output,omitempty"` // write stderr into the stdout log
+	KeepHead      string            `toml:"keep_head,omitempty"`      // only keep the start of each log, e.g. "64K"
+	KeepTail      string            `toml:"keep_tail,omitempty"`      // only keep the end of each log, e.g. "1M"
+	LogDir        string            `toml:"log_dir,omitempty"`        // directory of the logs, relative to the project (overrides the default)
+	Container     string            `toml:"container,omitempty"`      // image of the container the command runs in, e.g. "node:20"
+	DevEnv        string            `toml:"dev_env,omitempty"`        // development environment the command runs in: nix or direnv
+	Sandbox       string            `toml:"sandbox,omitempty"`        // where the command can write: project or readonly
+	Env           map[string]string `toml:"env,omitempty"`            // variables set in every run of the job
+	Tags          []string          `toml:"tags,omitempty"`           // replaces the job's tags when set
+	Shell         string            `toml:"shell,omitempty"`          // shell running the command as one script (e.g. "sh")
+	Name          string            `toml:"name,omitempty"`           // name other jobs refer to in needs (defaults to the command)
+	Needs         []string          `toml:"needs,omitempty"`          // jobs that must succeed before this one runs in 'gob ci'
 }
 
 // ShouldAutostart returns whether the job should be auto-started (defaults to false)
@@ -34,6 +66,204 @@ func (j GobfileJob) ShouldAutostart() bool {
 	return *j.Autostart
 }
 
+// CommandArgs returns the job's command as sent to the daemon: the script
+// as is for shell jobs, or split on whitespace
+func (j GobfileJob) CommandArgs() []string {
+	if j.Shell != "" {
+		if script := strings.TrimSpace(j.Command); script != "" {
+			return []string{script}
+		}
+		return nil
+	}
+	return strings.Fields(j.Command)
+}
+
+// JobWorkdir returns the directory the job runs in, for a gobfile in the
+// project directory cwd
+func (j GobfileJob) JobWorkdir(cwd string) string {
+	switch {
+	case j.Workdir == "":
+		return cwd
+	case filepath.IsAbs(j.Workdir):
+		return j.Workdir
+	}
+	return filepath.Join(cwd, j.Workdir)
+}
+
+// Options returns the daemon settings of the job, for a gobfile in the
+// project directory cwd with the default log directory defaultLogDir.
+// Invalid settings are left out of the options and reported in the error.
+func (j GobfileJob) Options(cwd, defaultLogDir string) (daemon.JobOptions, error) {
+	var errs []error
+	opts := daemon.JobOptions{
+		Description: j.Description,
+		Blocked:     j.IsBlocked(),
+		CPULimit:    j.CPULimit,
+		Hooks: daemon.JobHooks{
+			OnStart:   j.OnStart,
+			OnSuccess: j.OnSuccess,
+			OnFailure: j.OnFailure,
+			OnSlow:    j.OnSlow,
+		},
+		LogFormat: j.LogFormat,
+		Container: j.Container,
+		Shell:     j.Shell,
+	}
+	if j.Timestamps {
+		opts.Timestamps = &j.Timestamps
+	}
+	if j.CombineOutput {
+		opts.CombineOutput = &j.CombineOutput
+	}
+
+	var err error
+	if j.Priority != "" {
+		if opts.Priority, err = daemon.ParsePriority(j.Priority); err != nil {
+			errs = append(errs, fmt.Errorf("priority: %w", err))
+		}
+	}
+	if j.MemoryLimit != "" {
+		if opts.MemoryLimit, err = daemon.ParseMemoryLimit(j.MemoryLimit); err != nil {
+			errs = append(errs, fmt.Errorf("memory_limit: %w", err))
+		}
+	}
+	if j.KeepHead != "" {
+		if opts.OutputHead, err = daemon.ParseOutputSize(j.KeepHead); err != nil {
+			errs = append(errs, fmt.Errorf("keep_head: %w", err))
+		}
+	}
+	if j.KeepTail != "" {
+		if opts.OutputTail, err = daemon.ParseOutputSize(j.KeepTail); err != nil {
+			errs = append(errs, fmt.Errorf("keep_tail: %w", err))
+		}
+	}
+	if j.DevEnv != "" {
+		if err = daemon.ValidateDevEnv(j.DevEnv); err != nil {
+			errs = append(errs, fmt.Errorf("dev_env: %w", err))
+		} else {
+			opts.DevEnv = j.DevEnv
+		}
+	}
+	if j.Sandbox != "" {
+		if err = daemon.ValidateSandbox(j.Sandbox); err != nil {
+			errs = append(errs, fmt.Errorf("sandbox: %w", err))
+		} else {
+			opts.Sandbox = j.Sandbox
+		}
+	}
+	if len(j.Env) > 0 {
+		names := slices.Sorted(maps.Keys(j.Env))
+		opts.EnvOverrides = make([]string, len(names))
+		for i, name := range names {
+			opts.EnvOverrides[i] = name + "=" + j.Env[name]
+		}
+		if err = daemon.ValidateEnvOverrides(opts.EnvOverrides); err != nil {
+			errs = append(errs, fmt.Errorf("env: %w", err))
+			opts.EnvOverrides = nil
+		}
+	}
+	if j.Tags != nil {
+		if opts.Tags, err = daemon.NormalizeTags(j.Tags); err != nil {
+			errs = append(errs, fmt.Errorf("tags: %w", err))
+		}
+	}
+
+	opts.Stdin = j.Stdin
+	if opts.Stdin != "" && opts.Stdin != daemon.StdinNull && opts.Stdin != daemon.StdinOpen && !filepath.IsAbs(opts.Stdin) {
+		opts.Stdin = filepath.Join(cwd, opts.Stdin)
+	}
+	opts.LogDir = cmp.Or(j.LogDir, defaultLogDir)
+	if opts.LogDir != "" && !filepath.IsAbs(opts.LogDir) {
+		opts.LogDir = filepath.Join(cwd, opts.LogDir)
+	}
+
+	return opts, errors.Join(errs...)
+}
+
+// Key returns the name other jobs refer to the job by: its name, or its
+// command if it has none
+func (j GobfileJob) Key() string {
+	if j.Name != "" {
+		return j.Name
+	}
+	return j.Command
+}
+
+// Pipeline returns the jobs run by 'gob ci', in gobfile order: the jobs
+// neither autostarted nor blocked, or only those named and the jobs they
+// need if names is not empty. It fails if a job needs one that is unknown,
+// not run by ci, or needs it in turn.
+func (c *GobfileConfig) Pipeline(names []string) ([]GobfileJob, error) {
+	byKey := make(map[string][]int)
+	for i, job := range c.Jobs {
+		if len(job.CommandArgs()) > 0 {
+			byKey[job.Key()] = append(byKey[job.Key()], i)
+		}
+	}
+
+	// Visit the needs depth first, detecting cycles
+	selected := make([]bool, len(c.Jobs))
+	visiting := make([]bool, len(c.Jobs))
+	var visit func(i int) error
+	resolve := func(key, neededBy string) error {
+		indexes := byKey[key]
+		switch {
+		case len(indexes) == 0 && neededBy == "":
+			return fmt.Errorf("unknown job %q", key)
+		case len(indexes) == 0:
+			return fmt.Errorf("job %q needs unknown job %q", neededBy, key)
+		case len(indexes) > 1:
+			return fmt.Errorf("several jobs are %q: give them different names", key)
+		}
+		return visit(indexes[0])
+	}
+	visit = func(i int) error {
+		job := c.Jobs[i]
+		switch {
+		case job.ShouldAutostart() || job.IsBlocked():
+			return fmt.Errorf("job %q is autostarted or blocked, so ci does not run it", job.Key())
+		case visiting[i]:
+			return fmt.Errorf("job %q needs itself through its needs", job.Key())
+		case selected[i]:
+			return nil
+		}
+
+		visiting[i] = true
+		for _, need := range job.Needs {
+			if err := resolve(need, job.Key()); err != nil {
+				return err
+			}
+		}
+		visiting[i] = false
+		selected[i] = true
+		return nil
+	}
+
+	if len(names) == 0 {
+		for i, job := range c.Jobs {
+			if len(job.CommandArgs()) == 0 || job.ShouldAutostart() || job.IsBlocked() {
+				continue
+			}
+			if err := visit(i); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, name := range names {
+		if err := resolve(name, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	var jobs []GobfileJob
+	for i, job := range c.Jobs {
+		if selected[i] {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
 // IsBlocked returns whether the job is blocked (defaults to false)
 func (j GobfileJob) IsBlocked() bool {
 	if j.Blocked == nil {
@@ -97,6 +327,7 @@ func StartGobfileJobs(cwd string, config *GobfileConfig, env []string) error {
 		log.Printf("gobfile: failed to create client: %v", err)
 		return err
 	}
+	client.SetClientType(daemon.ClientTypeTUI)
 	if err := client.Connect(); err != nil {
 		// Silent on version mismatch - TUI will handle it
 		var versionErr *daemon.ErrVersionMismatch
@@ -110,17 +341,22 @@ func StartGobfileJobs(cwd string, config *GobfileConfig, env []string) error {
 	// Process each gobfile job
 	for _, gobJob := range config.Jobs {
 		cmd := gobJob.Command
-		parts := strings.Fields(cmd)
+		parts := gobJob.CommandArgs()
 		if len(parts) == 0 {
 			continue
 		}
 
 		blocked := gobJob.IsBlocked()
+		workdir := gobJob.JobWorkdir(cwd)
+		opts, err := gobJob.Options(cwd, config.LogDir)
+		if err != nil {
+			log.Printf("gobfile: ignoring invalid settings for '%s': %v", cmd, err)
+		}
 
 		if gobJob.ShouldAutostart() && !blocked {
 			// Add is idempotent: creates + starts, or returns already_running
 			// Also updates description and blocked status if different
-			_, err := client.Add(parts, cwd, env, gobJob.Description, blocked)
+			_, err := client.Add(parts, workdir, env, opts)
 			if err != nil {
 				log.Printf("gobfile: failed to add '%s': %v", cmd, err)
 				// Continue on error
@@ -129,7 +365,7 @@ func StartGobfileJobs(cwd string, config *GobfileConfig, env []string) error {
 			// Create is idempotent: creates without starting, or returns existing
 			// Also updates description and blocked status if different
 			// Blocked jobs are created but never started
-			_, err := client.Create(parts, cwd, gobJob.Description, blocked)
+			_, err := client.Create(parts, workdir, opts)
 			if err != nil {
 				log.Printf("gobfile: failed to create '%s': %v", cmd, err)
 				// Continue on error
@@ -153,6 +389,7 @@ func StopGobfileJobs(cwd string, config *GobfileConfig) error {
 		log.Printf("gobfile: failed to create client: %v", err)
 		return err
 	}
+	client.SetClientType(daemon.ClientTypeTUI)
 	if err := client.Connect(); err != nil {
 		// Silent on version mismatch - TUI will handle it
 		var versionErr *daemon.ErrVersionMismatch
@@ -163,19 +400,20 @@ func StopGobfileJobs(cwd string, config *GobfileConfig) error {
 	}
 	defer client.Close()
 
-	// Get existing jobs for this workdir
-	existingJobs, err := client.List(cwd)
+	// Get existing jobs, in the project and the directories of its jobs
+	existingJobs, err := client.List("")
 	if err != nil {
 		log.Printf("gobfile: failed to list jobs: %v", err)
 		return err
 	}
 
-	// Build a set of gobfile commands that should be auto-stopped (only autostart jobs)
+	// Build a set of gobfile jobs that should be auto-stopped (only autostart jobs)
 	// Jobs with autostart=false are meant to be manually controlled and should not be stopped
-	gobfileCommands := make(map[string]bool)
+	type gobfileKey struct{ workdir, command string }
+	gobfileCommands := make(map[gobfileKey]bool)
 	for _, job := range config.Jobs {
 		if job.ShouldAutostart() {
-			gobfileCommands[job.Command] = true
+			gobfileCommands[gobfileKey{job.JobWorkdir(cwd), job.Command}] = true
 		}
 	}
 
@@ -186,7 +424,7 @@ func StopGobfileJobs(cwd string, config *GobfileConfig) error {
 		}
 
 		cmdStr := strings.Join(job.Command, " ")
-		if !gobfileCommands[cmdStr] {
+		if !gobfileCommands[gobfileKey{job.Workdir, cmdStr}] {
 			continue
 		}
 
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+func TestGobfileJob_Options(t *testing.T) {
+	job := GobfileJob{
+		Command:     "npm run dev",
+		Workdir:     "web",
+		Priority:    "high",
+		MemoryLimit: "512M",
+		KeepTail:    "1M",
+		Stdin:       "fixtures/input.txt",
+		Tags:        []string{"web", "web"},
+	}
+
+	if workdir := job.JobWorkdir("/project"); workdir != "/project/web" {
+		t.Errorf("expected the job's directory under the project, got %s", workdir)
+	}
+
+	opts, err := job.Options("/project", "logs")
+	if err != nil {
+		t.Fatalf("Options failed: %v", err)
+	}
+	if opts.Priority != daemon.PriorityHigh || opts.MemoryLimit != 512<<20 || opts.OutputTail != 1<<20 {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+	if opts.Stdin != "/project/fixtures/input.txt" || opts.LogDir != "/project/logs" {
+		t.Errorf("expected paths relative to the project, got stdin %s and log dir %s", opts.Stdin, opts.LogDir)
+	}
+	if len(opts.Tags) != 1 || opts.Tags[0] != "web" {
+		t.Errorf("expected normalized tags, got %v", opts.Tags)
+	}
+
+	// Invalid settings are left out and reported
+	job = GobfileJob{Command: "make", Priority: "urgent", MemoryLimit: "lots", DevEnv: "conda", Description: "build"}
+	opts, err = job.Options("/project", "")
+	if err == nil || !strings.Contains(err.Error(), "priority") || !strings.Contains(err.Error(), "memory_limit") || !strings.Contains(err.Error(), "dev_env") {
+		t.Errorf("expected errors for priority, memory_limit and dev_env, got %v", err)
+	}
+	if opts.Description != "build" || opts.MemoryLimit != 0 {
+		t.Errorf("expected the valid settings kept, got %+v", opts)
+	}
+	if workdir := job.JobWorkdir("/project"); workdir != "/project" {
+		t.Errorf("expected the project directory, got %s", workdir)
+	}
+}
+
+func TestGobfileConfig_Pipeline(t *testing.T) {
+	autostart := true
+	config := &GobfileConfig{Jobs: []GobfileJob{
+		{Command: "npm run dev", Autostart: &autostart},
+		{Command: "make test", Needs: []string{"build"}},
+		{Name: "build", Command: "make build"},
+		{Command: "make lint"},
+	}}
+
+	keys := func(jobs []GobfileJob) string {
+		var k []string
+		for _, job := range jobs {
+			k = append(k, job.Key())
+		}
+		return strings.Join(k, ",")
+	}
+
+	jobs, err := config.Pipeline(nil)
+	if err != nil {
+		t.Fatalf("Pipeline failed: %v", err)
+	}
+	if got := keys(jobs); got != "make test,build,make lint" {
+		t.Errorf("expected the jobs ci runs in gobfile order, got %s", got)
+	}
+
+	// Named jobs bring the jobs they need
+	jobs, _ = config.Pipeline([]string{"make test"})
+	if got := keys(jobs); got != "make test,build" {
+		t.Errorf("expected the named job and its needs, got %s", got)
+	}
+
+	for _, tc := range []struct {
+		needs []string
+		want  string
+	}{
+		{[]string{"missing"}, `needs unknown job "missing"`},
+		{[]string{"npm run dev"}, "autostarted or blocked"},
+		{[]string{"make test"}, "needs itself"},
+	} {
+		config.Jobs[2].Needs = tc.needs
+		if _, err := config.Pipeline(nil); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("needs %v: expected an error containing %q, got %v", tc.needs, tc.want, err)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package tui
 
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
 // leftLayout holds the computed Y boundaries and heights for left-side panels.
 // All values are in terminal rows. The panels stack top-to-bottom:
 // info → jobs → [description] → ports → runs
@@ -60,3 +67,64 @@ func (m Model) leftPanelLayout() leftLayout {
 		runsStart:  runsStart,
 	}
 }
+
+// Panel size limits and steps, in percent (see LayoutConfig)
+const (
+	defaultJobsWidth    = 40
+	minJobsWidth        = 20
+	maxJobsWidth        = 70
+	defaultStderrHeight = 20
+	minStderrHeight     = 10
+	maxStderrHeight     = 50
+	layoutStep          = 5
+)
+
+// minColumnWidth is the narrowest either column gets, in characters
+const minColumnWidth = 30
+
+// resizeJobsPanel widens (delta > 0) or narrows the left column
+func (m *Model) resizeJobsPanel(delta int) tea.Cmd {
+	width := m.config.Layout.JobsWidth
+	if width == 0 && m.width > 0 {
+		// Start from the default size, which is bounded in characters
+		width = m.jobPanelWidth() * 100 / m.width
+	}
+	m.config.Layout.JobsWidth = min(max(width+delta, minJobsWidth), maxJobsWidth)
+	telemetry.TUIActionExecute("resize_panels")
+	return m.applyLayout()
+}
+
+// resizeLogPanels grows (delta > 0) or shrinks the stdout panel, giving
+// the difference to stderr. The focused stderr panel keeps the larger share.
+func (m *Model) resizeLogPanels(delta int) tea.Cmd {
+	height := m.config.Layout.StderrHeight
+	if height == 0 {
+		height = defaultStderrHeight
+	}
+	if m.activePanel == panelStderr {
+		height += delta
+	} else {
+		height -= delta
+	}
+	m.config.Layout.StderrHeight = min(max(height, minStderrHeight), maxStderrHeight)
+	telemetry.TUIActionExecute("resize_panels")
+	return m.applyLayout()
+}
+
+// applyLayout resizes the panels after a layout change and saves the
+// layout to the config file
+func (m *Model) applyLayout() tea.Cmd {
+	m.logPanelWidth = m.width - m.jobPanelWidth() - 4
+	m.jobListView.Width = m.jobPanelWidth() - 4
+	m.stdoutView.SetContent(m.formatStdout())
+	m.stderrView.SetContent(m.formatStderr())
+	m.updateLogViewportSizes()
+
+	config := m.config
+	return func() tea.Msg {
+		if err := SaveConfig(config); err != nil {
+			return actionResultMsg{message: fmt.Sprintf("Failed to save layout: %v", err), isError: true}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+// loglevel.go - level coloring and filtering for jobs with JSON logs
+
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/juanibiapina/gob/internal/daemon"
+)
+
+// levelFilterCycle is the order the level filter key steps through ("" shows all)
+var levelFilterCycle = []string{"", daemon.LevelDebug, daemon.LevelInfo, daemon.LevelWarn, daemon.LevelError}
+
+// nextLevelFilter returns the level filter after the given one
+func nextLevelFilter(current string) string {
+	for i, level := range levelFilterCycle {
+		if level == current {
+			return levelFilterCycle[(i+1)%len(levelFilterCycle)]
+		}
+	}
+	return ""
+}
+
+// levelStyle returns the style used to render a line of the given level
+func levelStyle(level string) (lipgloss.Style, bool) {
+	switch level {
+	case daemon.LevelError, daemon.LevelFatal:
+		return lipgloss.NewStyle().Foreground(dangerColor), true
+	case daemon.LevelWarn:
+		return lipgloss.NewStyle().Foreground(warningColor), true
+	case daemon.LevelDebug, daemon.LevelTrace:
+		return lipgloss.NewStyle().Foreground(mutedColor), true
+	}
+	return lipgloss.Style{}, false
+}
+
+// formatJSONLogs colors JSON log lines by level and drops lines below
+// minLevel (when set). Lines without a level are kept unless filtering.
+// Each line is wrapped to width when width > 0. The first prefixWidth
+// characters of each line (e.g. a timestamp) are not part of the JSON.
+func formatJSONLogs(content string, minLevel string, width int, prefixWidth int) string {
+	var result strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		if line == "" {
+			continue
+		}
+
+		plain := ansi.Strip(line)
+		if len(plain) >= prefixWidth {
+			plain = plain[prefixWidth:]
+		}
+		level := daemon.ParseLogLevel(plain)
+		if minLevel != "" && !daemon.LevelAtLeast(level, minLevel) {
+			continue
+		}
+
+		if width > 0 {
+			line = ansi.Wrap(line, width, " ")
+		}
+
+		style, styled := levelStyle(level)
+		for _, part := range strings.Split(line, "\n") {
+			if styled {
+				part = style.Render(part)
+			}
+			result.WriteString(part + "\n")
+		}
+	}
+	return result.String()
+}
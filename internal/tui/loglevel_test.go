@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestNextLevelFilter(t *testing.T) {
+	expected := []string{"debug", "info", "warn", "error", ""}
+	level := ""
+	for _, want := range expected {
+		level = nextLevelFilter(level)
+		if level != want {
+			t.Fatalf("expected %q, got %q", want, level)
+		}
+	}
+}
+
+func TestFormatJSONLogs(t *testing.T) {
+	content := `{"level":"debug","msg":"a"}
+{"level":"info","msg":"b"}
+plain line
+{"level":"error","msg":"c"}
+`
+
+	// No filter keeps every line
+	all := strings.Split(strings.TrimSuffix(ansi.Strip(formatJSONLogs(content, "", 0, 0)), "\n"), "\n")
+	if len(all) != 4 {
+		t.Errorf("expected 4 lines, got %d: %q", len(all), all)
+	}
+
+	// Filter drops lower levels and lines without a level
+	filtered := ansi.Strip(formatJSONLogs(content, "info", 0, 0))
+	if filtered != "{\"level\":\"info\",\"msg\":\"b\"}\n{\"level\":\"error\",\"msg\":\"c\"}\n" {
+		t.Errorf("unexpected filtered output: %q", filtered)
+	}
+}
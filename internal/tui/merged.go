@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/juanibiapina/gob/internal/logfile"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// maxMergedLines is how many lines the merged output keeps
+const maxMergedLines = 5000
+
+// mergedColors color the prefixes of the merged jobs, in list order. Stderr
+// lines keep the warning color of the stderr panel.
+var mergedColors = []lipgloss.Color{colorGreen, colorBlue, colorMagenta, colorCyan, colorRed}
+
+// mergedLogs follows the output of several jobs in the stdout panel,
+// interleaved in the order it is read, each line prefixed with its job
+type mergedLogs struct {
+	jobIDs  []string
+	offsets map[string]int64 // bytes read of each followed log file
+	lines   []string
+	reading bool // a read is in flight, its offsets not applied yet
+}
+
+func (l mergedLogs) active() bool {
+	return len(l.jobIDs) > 0
+}
+
+// mergedLogMsg carries the lines read since the last offsets
+type mergedLogMsg struct {
+	offsets map[string]int64
+	lines   []string
+}
+
+// toggleMergedLogs starts following the marked jobs together, or goes back
+// to the selected job's logs
+func (m *Model) toggleMergedLogs() tea.Cmd {
+	if m.merged.active() {
+		m.merged = mergedLogs{}
+		m.stdoutView.SetContent(m.formatStdout())
+		m.stderrView.SetContent(m.formatStderr())
+		return nil
+	}
+
+	var ids []string
+	for _, job := range m.jobs {
+		if m.marked[job.ID] {
+			ids = append(ids, job.ID)
+		}
+	}
+	if len(ids) == 0 {
+		m.message = "Mark jobs with space to follow their output together"
+		m.isError = false
+		m.messageTime = time.Now()
+		return nil
+	}
+
+	m.merged = mergedLogs{jobIDs: ids, offsets: make(map[string]int64)}
+	m.followLogs = true
+	if m.activePanel == panelStderr {
+		m.activePanel = panelStdout
+	}
+	telemetry.TUIActionExecute("merge_logs")
+	m.stdoutView.SetContent(m.formatStdout())
+	m.stderrView.SetContent(m.formatStderr())
+	return m.readMergedLogs()
+}
+
+// readMergedLogs reads the complete lines written to the merged jobs' logs
+// since the last read. Offsets of logs no longer followed (e.g. of previous
+// runs) are dropped, and new runs are read from their start.
+func (m *Model) readMergedLogs() tea.Cmd {
+	if !m.merged.active() || m.merged.reading {
+		return nil
+	}
+	m.merged.reading = true
+
+	type source struct {
+		path, prefix string
+	}
+	var sources []source
+	for i, id := range m.merged.jobIDs {
+		for _, job := range m.jobs {
+			if job.ID != id || job.StdoutPath == "" {
+				continue
+			}
+			label := job.ID
+			if job.Alias != "" {
+				label = job.Alias
+			}
+			style := lipgloss.NewStyle().Foreground(mergedColors[i%len(mergedColors)])
+			sources = append(sources, source{job.StdoutPath, style.Render("[" + label + "]")})
+			if !job.Combined {
+				sources = append(sources, source{job.StderrPath, lipgloss.NewStyle().Foreground(warningColor).Render("[" + label + "]")})
+			}
+		}
+	}
+	previous := m.merged.offsets
+
+	return func() tea.Msg {
+		msg := mergedLogMsg{offsets: make(map[string]int64)}
+		for _, s := range sources {
+			offset := previous[s.path]
+			data, err := readFrom(s.path, offset)
+			if err != nil {
+				msg.offsets[s.path] = offset
+				continue
+			}
+			// Leave a partial last line for the next read
+			end := bytes.LastIndexByte(data, '\n') + 1
+			msg.offsets[s.path] = offset + int64(end)
+			if logfile.IsBinary(data[:end]) {
+				msg.lines = append(msg.lines, s.prefix+" "+logfile.BinaryPlaceholder(end))
+				continue
+			}
+			for _, line := range strings.Split(string(data[:end]), "\n") {
+				if line != "" {
+					msg.lines = append(msg.lines, s.prefix+" "+line)
+				}
+			}
+		}
+		return msg
+	}
+}
+
+// readFrom reads a log from an offset to its end
+func readFrom(path string, offset int64) ([]byte, error) {
+	f, err := logfile.OpenSeeker(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// applyMergedLogs adds the lines of a read, keeping the last maxMergedLines
+func (m *Model) applyMergedLogs(msg mergedLogMsg) {
+	if !m.merged.active() {
+		return
+	}
+	m.merged.reading = false
+	m.merged.offsets = msg.offsets
+	m.merged.lines = append(m.merged.lines, msg.lines...)
+	if extra := len(m.merged.lines) - maxMergedLines; extra > 0 {
+		m.merged.lines = m.merged.lines[extra:]
+	}
+}
+
+// formatMergedLogs renders the merged output for the stdout panel
+func (m Model) formatMergedLogs() string {
+	if len(m.merged.lines) == 0 {
+		return mutedStyle.Render("(no output yet)")
+	}
+
+	content := StripCursorSequences(strings.Join(m.merged.lines, "\n"))
+	if m.wrapLines && m.logPanelWidth > 0 {
+		content = ansi.Wrap(content, m.logPanelWidth, " ")
+	}
+	return content + "\n"
+}
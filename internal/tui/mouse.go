@@ -2,12 +2,16 @@ package tui
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/ansi"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
 )
 
 // handleMouseEvent dispatches mouse events to scroll, click, or selection handlers.
@@ -175,6 +179,7 @@ func (m Model) handleLeftPanelClick(x, y int) (tea.Model, tea.Cmd, bool) {
 			itemIndex := m.portScroll.Offset + contentY
 			if itemIndex >= 0 && itemIndex < portCount {
 				m.portScroll.SetCursorTo(itemIndex)
+				m.copySelectedPort()
 			}
 		}
 		return m, nil, true
@@ -347,6 +352,33 @@ func (m Model) highlightedLogView(target panel) string {
 	return strings.Join(lines, "\n")
 }
 
+// portAddress returns the address to reach a listening port: localhost for
+// ports listening on all interfaces or on loopback
+func portAddress(p daemon.PortInfo) string {
+	host := p.Address
+	if ip := net.ParseIP(host); host == "" || host == "*" || (ip != nil && (ip.IsUnspecified() || ip.IsLoopback())) {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(p.Port)))
+}
+
+// copySelectedPort copies the address of the selected port to the clipboard
+func (m *Model) copySelectedPort() {
+	if m.portScroll.Cursor >= m.selectedJobPortCount() {
+		return
+	}
+	addr := portAddress(m.jobs[m.jobScroll.Cursor].Ports[m.portScroll.Cursor])
+	telemetry.TUIActionExecute("copy_port")
+	if err := clipboard.WriteAll(addr); err != nil {
+		m.message = fmt.Sprintf("Failed to copy: %v", err)
+		m.isError = true
+	} else {
+		m.message = fmt.Sprintf("Copied %s to clipboard", addr)
+		m.isError = false
+	}
+	m.messageTime = time.Now()
+}
+
 // selectedJobPortCount returns the number of ports for the currently selected job.
 func (m Model) selectedJobPortCount() int {
 	if len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) && m.jobs[m.jobScroll.Cursor].Running {
@@ -366,5 +398,3 @@ func (m Model) runsHeaderRows() int {
 	}
 	return headerRows
 }
-
-
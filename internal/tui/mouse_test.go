@@ -83,6 +83,16 @@ func TestPanelAtPosition_RightSide(t *testing.T) {
 	}
 }
 
+func TestPanelAtPosition_CombinedOutput(t *testing.T) {
+	m := newTestModelWithJobs(Job{ID: "abc", Combined: true})
+	leftW := m.jobPanelWidth()
+
+	// No stderr panel: the bottom of the right side is still the output panel
+	if got := m.panelAtPosition(leftW+5, 45); got != panelStdout {
+		t.Errorf("panelAtPosition(%d, 45) = %d, want %d", leftW+5, got, panelStdout)
+	}
+}
+
 // --- handleMouseScroll tests ---
 
 func TestHandleMouseScroll_JobsPanel(t *testing.T) {
@@ -677,3 +687,23 @@ func TestRunsHeaderRows_WithProgressBar(t *testing.T) {
 		t.Errorf("runsHeaderRows() = %d, want 3 (with progress bar)", got)
 	}
 }
+
+func TestPortAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"0.0.0.0", "localhost:3000"},
+		{"127.0.0.1", "localhost:3000"},
+		{"::", "localhost:3000"},
+		{"*", "localhost:3000"},
+		{"192.168.1.10", "192.168.1.10:3000"},
+		{"fe80::1", "[fe80::1]:3000"},
+	}
+	for _, tt := range tests {
+		got := portAddress(daemon.PortInfo{Port: 3000, Protocol: "tcp", Address: tt.address})
+		if got != tt.want {
+			t.Errorf("portAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// restartEnvChoices are the environments offered by 'R', by key
+var restartEnvChoices = []struct {
+	key    string
+	source string
+	label  string
+}{
+	{"p", daemon.EnvSourcePrevious, "reuse the environment of the previous run"},
+	{"c", daemon.EnvSourceClient, "use this TUI's environment (as r does)"},
+	{"s", daemon.EnvSourceStored, "use the job's stored --env overrides only"},
+}
+
+// updateRestartEnvModal handles keys while 'R' waits for an environment
+func (m Model) updateRestartEnvModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.modal = modalNone
+		return m, nil
+	case "ctrl+c":
+		if m.subClient != nil {
+			m.subClient.Close()
+		}
+		return m, tea.Quit
+	}
+	for _, choice := range restartEnvChoices {
+		if msg.String() == choice.key {
+			m.modal = modalNone
+			telemetry.TUIActionExecute("restart_job_env")
+			return m, m.restartJob(m.restartEnvJob.ID, choice.source)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) renderRestartEnvModal() string {
+	title := dialogTitleStyle.Render("Restart with which environment?")
+
+	label := m.restartEnvJob.Command
+	if m.restartEnvJob.Alias != "" {
+		label = m.restartEnvJob.Alias + ": " + label
+	}
+	lines := []string{m.truncate(label, 50), ""}
+	for _, choice := range restartEnvChoices {
+		lines = append(lines, m.renderKey(choice.key, choice.label))
+	}
+	help := helpDescStyle.Render("p/c/s: restart • esc: cancel")
+
+	return dialogStyle.Render(title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + help)
+}
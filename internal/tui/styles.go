@@ -129,6 +129,22 @@ var (
 	jobCommandSelectedStyle = lipgloss.NewStyle().
 				Background(selectionBg)
 
+	jobMarkStyle = lipgloss.NewStyle().
+			Foreground(primaryColor)
+
+	jobMarkSelectedStyle = lipgloss.NewStyle().
+				Foreground(primaryColor).
+				Background(selectionBg)
+
+	jobDescriptionStyle = lipgloss.NewStyle().
+				Foreground(mutedColor)
+
+	// The selection background is the muted color, so selected rows dim
+	// the description instead
+	jobDescriptionSelectedStyle = lipgloss.NewStyle().
+					Faint(true).
+					Background(selectionBg)
+
 	// Log styles
 	logPrefixStyle = lipgloss.NewStyle().
 			Foreground(fgColor)
@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"sort"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/logfile"
 	"github.com/juanibiapina/gob/internal/telemetry"
 	"github.com/juanibiapina/gob/internal/version"
 )
@@ -40,49 +42,66 @@ const (
 	modalNone modalMode = iota
 	modalNewJob
 	modalHelp
+	modalConfirmBatch
+	modalConfirmCleanup
+	modalJobDetail
+	modalRestartEnv
+	modalApproval
 )
 
+// tuiTimestampFormat is how line timestamps are shown in the log panels
+const tuiTimestampFormat = "15:04:05.000"
+
 // Job represents a job with its runtime status
 type Job struct {
 	ID          string
+	Alias       string
 	PID         int
 	Command     string
 	Description string
 	Workdir     string
+	LogFormat   string // "json" when stdout is JSON lines with levels
+	Timestamps  bool   // runs record when each output line was written
+	Combined    bool   // stderr is interleaved into the stdout log
 	Running     bool
 	Blocked     bool
+	Pinned      bool // skipped when deleting stopped jobs (see gob pin)
 	ExitCode    *int
 	StartedAt   time.Time
 	StoppedAt   time.Time
 	Ports       []daemon.PortInfo // Listening ports (only for running jobs)
+	StdoutPath  string            // logs of the current or latest run
+	StderrPath  string
+	StartedBy   string // name of the client that started the current or latest run
 }
 
 // Run represents a single execution of a job
 type Run struct {
-	ID         string
-	JobID      string
-	PID        int
-	Status     string
-	ExitCode   *int
-	StdoutPath string
-	StderrPath string
-	StartedAt  time.Time
-	StoppedAt  time.Time
-	DurationMs int64
+	ID          string
+	JobID       string
+	PID         int
+	Status      string
+	ExitCode    *int
+	StdoutPath  string
+	StderrPath  string
+	StartedAt   time.Time
+	StoppedAt   time.Time
+	DurationMs  int64
+	Note        string
+	Markers     []daemon.RunMarker
+	LogsMissing bool                // its log files were deleted outside of gob
+	Output      *daemon.OutputStats // how much it printed, once stopped
 }
 
 // logTickMsg is sent periodically to refresh log content
 type logTickMsg time.Time
 
-// jobsUpdatedMsg is sent when jobs are refreshed
-type jobsUpdatedMsg struct {
-	jobs []Job
-}
-
 // logUpdateMsg is sent when log content is updated
 type logUpdateMsg struct {
-	stdout string
-	stderr string
+	stdout       string
+	stderr       string
+	stdoutStamps []daemon.LineTimestamp // nil unless the run recorded timestamps
+	stderrStamps []daemon.LineTimestamp
 }
 
 // actionResultMsg is sent after an action completes
@@ -95,9 +114,19 @@ type actionResultMsg struct {
 type runsUpdatedMsg struct {
 	jobID string
 	runs  []Run
+	total int // runs of the job, loaded or not
 	stats *daemon.JobResponse
 }
 
+// olderRunsMsg is sent when older runs of a job are fetched, as the run
+// cursor reaches the last loaded one
+type olderRunsMsg struct {
+	jobID  string
+	offset int // loaded runs when fetching
+	runs   []Run
+	total  int
+}
+
 // subscriptionStartedMsg is sent when subscription is established
 type subscriptionStartedMsg struct {
 	client *daemon.Client
@@ -144,6 +173,7 @@ type Model struct {
 	isError     bool
 	cwd         string
 	env         []string
+	client      string // name of this client, see daemon.ClientName
 
 	// Components
 	help        help.Model
@@ -155,14 +185,41 @@ type Model struct {
 	// Log viewer state
 	followLogs    bool
 	wrapLines     bool
+	logLevel      string // minimum level shown for JSON logs ("" for all)
+	showTimes     bool   // prefix lines with their timestamps (jobs that record them)
 	logPanelWidth int
 	stdoutContent string
 	stderrContent string
+	stdoutStamps  []daemon.LineTimestamp
+	stderrStamps  []daemon.LineTimestamp
 
 	// Run history state
 	runs         []Run
 	stats        *daemon.JobResponse
 	runsForJobID string // tracks which job the runs are for
+	olderRuns    int    // runs of the job not loaded yet
+
+	// Job list order, cycled with 'o' (see daemon.JobOrders)
+	jobOrder daemon.JobOrder
+
+	// Jobs marked with space; lifecycle keys apply to all of them
+	marked map[string]bool
+	batch  pendingBatch // waiting for confirmation in modalConfirmBatch
+
+	// Job restarted once an environment is picked in modalRestartEnv
+	restartEnvJob Job
+
+	// Requests waiting for approval, shown in modalApproval oldest first
+	approvals []daemon.Approval
+
+	// Settings from the TUI config file
+	config Config
+
+	// Last deleted jobs, restored with 'u' for undoWindow
+	undo deletedJobs
+
+	// Output of the marked jobs followed together, toggled with 'M'
+	merged mergedLogs
 
 	// Scrollable list states
 	jobScroll  ScrollState
@@ -204,19 +261,50 @@ func New() Model {
 		textInput:   ti,
 		cwd:         cwd,
 		env:         env,
+		client:      daemon.ClientName(),
 		followLogs:  true,
+		jobOrder:    daemon.JobOrderStarted,
 	}
 }
 
-// connectClient creates a new daemon client connection
+// requestTimeout bounds the TUI's requests, so that a hung daemon fails
+// them with daemon.ErrNotResponding instead of freezing the TUI. Generous,
+// since stopping a job waits for its processes to exit.
+const requestTimeout = 30 * time.Second
+
+// runsPageSize is how many runs of a job are loaded at once
+const runsPageSize = 50
+
+// sharedClient is the client of the TUI's requests, connected on first use
+var (
+	sharedClient   *daemon.Client
+	sharedClientMu sync.Mutex
+)
+
+// connectClient returns the TUI's daemon client. Its requests share one
+// persistent connection (see daemon.Client.StartSession), so it is not
+// closed after use.
 func connectClient() (*daemon.Client, error) {
+	sharedClientMu.Lock()
+	defer sharedClientMu.Unlock()
+	if sharedClient != nil {
+		return sharedClient, nil
+	}
+
 	client, err := daemon.NewClient()
 	if err != nil {
 		return nil, err
 	}
+	client.SetClientType(daemon.ClientTypeTUI)
+	client.SetTimeout(requestTimeout)
 	if err := client.Connect(); err != nil {
 		return nil, err
 	}
+	if err := client.StartSession(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	sharedClient = client
 	return client, nil
 }
 
@@ -236,7 +324,6 @@ func checkVersionMismatch(err error) tea.Msg {
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		m.refreshJobs(),
 		m.startSubscription(),
 		logTickCmd(),
 	)
@@ -249,13 +336,15 @@ func logTickCmd() tea.Cmd {
 	})
 }
 
-// startSubscription attempts to connect and subscribe to daemon events
+// startSubscription attempts to connect and subscribe to daemon events. The
+// first event is a snapshot of the jobs.
 func (m Model) startSubscription() tea.Cmd {
 	return func() tea.Msg {
 		client, err := daemon.NewClient()
 		if err != nil {
 			return subscriptionErrorMsg{err: err}
 		}
+		client.SetClientType(daemon.ClientTypeTUI)
 		if err := client.Connect(); err != nil {
 			return subscriptionErrorMsg{err: err}
 		}
@@ -265,7 +354,7 @@ func (m Model) startSubscription() tea.Cmd {
 			workdir = m.cwd
 		}
 
-		events, errs := client.SubscribeChan(workdir)
+		events, errs := client.SubscribeChanWithOptions(workdir, daemon.SubscribeOptions{Snapshot: true, Coalesce: true, Reconnect: true})
 		return subscriptionStartedMsg{
 			client: client,
 			events: events,
@@ -292,51 +381,37 @@ func waitForEvent(events <-chan daemon.Event, errs <-chan error) tea.Cmd {
 	}
 }
 
-// refreshJobs fetches the current job list
-func (m Model) refreshJobs() tea.Cmd {
-	return func() tea.Msg {
-		client, err := connectClient()
-		if err != nil {
-			if msg := checkVersionMismatch(err); msg != nil {
-				return msg
-			}
-			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
-		}
-		defer client.Close()
-
-		workdir := ""
-		if !m.showAll {
-			workdir = m.cwd
-		}
-
-		jobResponses, err := client.List(workdir)
-		if err != nil {
-			return actionResultMsg{message: fmt.Sprintf("Failed to list jobs: %v", err), isError: true}
-		}
-
-		var jobs []Job
-		for _, jr := range jobResponses {
-			// Skip blocked jobs in TUI
-			if jr.Blocked {
-				continue
-			}
-			jobs = append(jobs, Job{
-				ID:          jr.ID,
-				PID:         jr.PID,
-				Command:     strings.Join(jr.Command, " "),
-				Description: jr.Description,
-				Workdir:     jr.Workdir,
-				Running:     jr.Status == "running",
-				Blocked:     jr.Blocked,
-				ExitCode:    jr.ExitCode,
-				StartedAt:   parseTime(jr.StartedAt),
-				StoppedAt:   parseTime(jr.StoppedAt),
-				Ports:       jr.Ports,
-			})
+// jobsFromResponses converts the daemon's jobs to TUI jobs, skipping blocked ones
+func jobsFromResponses(responses []daemon.JobResponse) []Job {
+	var jobs []Job
+	for _, jr := range responses {
+		// Skip blocked jobs in TUI
+		if jr.Blocked {
+			continue
 		}
-
-		return jobsUpdatedMsg{jobs: jobs}
-	}
+		jobs = append(jobs, Job{
+			ID:          jr.ID,
+			Alias:       jr.Alias,
+			PID:         jr.PID,
+			Command:     strings.Join(jr.Command, " "),
+			Description: jr.Description,
+			Workdir:     jr.Workdir,
+			LogFormat:   jr.LogFormat,
+			Timestamps:  jr.Timestamps,
+			Combined:    jr.CombineOutput,
+			Running:     jr.Status == "running",
+			Blocked:     jr.Blocked,
+			Pinned:      jr.Pinned,
+			ExitCode:    jr.ExitCode,
+			StartedAt:   parseTime(jr.StartedAt),
+			StoppedAt:   parseTime(jr.StoppedAt),
+			Ports:       jr.Ports,
+			StdoutPath:  jr.StdoutPath,
+			StderrPath:  jr.StderrPath,
+			StartedBy:   jr.StartedBy,
+		})
+	}
+	return jobs
 }
 
 // readLogs reads the log files for the selected run
@@ -347,13 +422,45 @@ func (m Model) readLogs() tea.Cmd {
 		}
 
 		run := m.runs[m.runScroll.Cursor]
-		stdout, _ := os.ReadFile(run.StdoutPath)
-		stderr, _ := os.ReadFile(run.StderrPath)
+		stdout, _ := logfile.ReadFile(run.StdoutPath)
+		stderr, _ := logfile.ReadFile(run.StderrPath)
+		stdoutStamps, _ := daemon.ReadTimestampIndex(run.StdoutPath)
+		stderrStamps, _ := daemon.ReadTimestampIndex(run.StderrPath)
 
-		return logUpdateMsg{
-			stdout: string(stdout),
-			stderr: string(stderr),
+		msg := logUpdateMsg{
+			stdout:       string(stdout),
+			stderr:       string(stderr),
+			stdoutStamps: stdoutStamps,
+			stderrStamps: stderrStamps,
 		}
+		// Binary output would garble the panels
+		if logfile.IsBinary(stdout) {
+			msg.stdout, msg.stdoutStamps = logfile.BinaryPlaceholder(len(stdout))+"\n", nil
+		}
+		if logfile.IsBinary(stderr) {
+			msg.stderr, msg.stderrStamps = logfile.BinaryPlaceholder(len(stderr))+"\n", nil
+		}
+		return msg
+	}
+}
+
+// runFromResponse converts a run of the daemon to a TUI run
+func runFromResponse(r daemon.RunResponse) Run {
+	return Run{
+		ID:          r.ID,
+		JobID:       r.JobID,
+		PID:         r.PID,
+		Status:      r.Status,
+		ExitCode:    r.ExitCode,
+		StdoutPath:  r.StdoutPath,
+		StderrPath:  r.StderrPath,
+		StartedAt:   parseTime(r.StartedAt),
+		StoppedAt:   parseTime(r.StoppedAt),
+		DurationMs:  r.DurationMs,
+		Note:        r.Note,
+		Markers:     r.Markers,
+		LogsMissing: r.LogsMissing,
+		Output:      r.Output,
 	}
 }
 
@@ -367,34 +474,46 @@ func (m Model) fetchRuns(jobID string) tea.Cmd {
 			}
 			return runsUpdatedMsg{jobID: jobID, runs: nil, stats: nil}
 		}
-		defer client.Close()
 
-		// Fetch runs
-		runsResp, err := client.Runs(jobID)
+		// Fetch the most recent runs, the rest loads as the cursor reaches them
+		runsResp, total, err := client.RunsPage(daemon.RunsPayload{JobID: jobID, Limit: runsPageSize})
+		if errors.Is(err, daemon.ErrNotResponding) {
+			// Keep showing the runs we have
+			return actionResultMsg{message: "Daemon not responding", isError: true}
+		}
 		if err != nil {
 			return runsUpdatedMsg{jobID: jobID, runs: nil, stats: nil}
 		}
 
 		runs := make([]Run, len(runsResp))
 		for i, r := range runsResp {
-			runs[i] = Run{
-				ID:         r.ID,
-				JobID:      r.JobID,
-				PID:        r.PID,
-				Status:     r.Status,
-				ExitCode:   r.ExitCode,
-				StdoutPath: r.StdoutPath,
-				StderrPath: r.StderrPath,
-				StartedAt:  parseTime(r.StartedAt),
-				StoppedAt:  parseTime(r.StoppedAt),
-				DurationMs: r.DurationMs,
-			}
+			runs[i] = runFromResponse(r)
 		}
 
 		// Fetch stats (returns *JobResponse with stats fields)
 		statsJob, _ := client.Stats(jobID)
 
-		return runsUpdatedMsg{jobID: jobID, runs: runs, stats: statsJob}
+		return runsUpdatedMsg{jobID: jobID, runs: runs, total: total, stats: statsJob}
+	}
+}
+
+// fetchOlderRuns fetches the next page of runs of the selected job
+func (m Model) fetchOlderRuns() tea.Cmd {
+	jobID, offset := m.runsForJobID, len(m.runs)
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			return nil
+		}
+		runsResp, total, err := client.RunsPage(daemon.RunsPayload{JobID: jobID, Offset: offset, Limit: runsPageSize})
+		if err != nil {
+			return nil
+		}
+		runs := make([]Run, len(runsResp))
+		for i, r := range runsResp {
+			runs[i] = runFromResponse(r)
+		}
+		return olderRunsMsg{jobID: jobID, offset: offset, runs: runs, total: total}
 	}
 }
 
@@ -448,7 +567,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case logTickMsg:
 		// Update logs only - job status is handled by events
-		cmds = append(cmds, m.readLogs(), logTickCmd())
+		cmds = append(cmds, m.readLogs(), m.readMergedLogs(), logTickCmd())
 
 	case subscriptionStartedMsg:
 		m.subscribed = true
@@ -456,11 +575,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.eventChan = msg.events
 		m.errChan = msg.errs
 		// Start waiting for events
-		cmds = append(cmds, waitForEvent(m.eventChan, m.errChan))
+		cmds = append(cmds, waitForEvent(m.eventChan, m.errChan), m.fetchApprovals())
+
+	case approvalsMsg:
+		m.approvals = msg.approvals
+		m.showApprovals()
 
 	case daemonEventMsg:
 		// Handle the event by updating the job list and runs
 		m.handleDaemonEvent(msg.event)
+		m.sortJobs()
+		m.pruneMarks()
 		// Fetch runs if the selected job changed (e.g., first job added)
 		if len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) {
 			jobID := m.jobs[m.jobScroll.Cursor].ID
@@ -487,33 +612,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Quit
 
-	case jobsUpdatedMsg:
-		m.jobs = msg.jobs
-		m.jobScroll.ClampToCount(len(m.jobs))
-		// Fetch runs for the selected job (ports come from events)
-		if len(m.jobs) > 0 {
-			jobID := m.jobs[m.jobScroll.Cursor].ID
-			if jobID != m.runsForJobID {
-				m.runsForJobID = jobID
-				m.runScroll.Reset()
-				m.portScroll.Reset()
-				cmds = append(cmds, m.fetchRuns(jobID))
-			}
-		}
-
 	case runsUpdatedMsg:
 		// Only update if this is for the currently selected job
 		if msg.jobID == m.runsForJobID {
 			m.runs = msg.runs
+			m.olderRuns = max(0, msg.total-len(msg.runs))
 			m.stats = msg.stats
 			m.runScroll.ClampToCount(len(m.runs))
 			// Read logs now that runs are loaded
 			cmds = append(cmds, m.readLogs())
 		}
 
+	case olderRunsMsg:
+		// Drop pages that no longer follow the loaded runs
+		if msg.jobID == m.runsForJobID && msg.offset == len(m.runs) {
+			m.runs = append(m.runs, msg.runs...)
+			m.olderRuns = max(0, msg.total-len(m.runs))
+		}
+
 	case logUpdateMsg:
 		m.stdoutContent = msg.stdout
 		m.stderrContent = msg.stderr
+		m.stdoutStamps = msg.stdoutStamps
+		m.stderrStamps = msg.stderrStamps
 		m.stdoutView.SetContent(m.formatStdout())
 		m.stderrView.SetContent(m.formatStderr())
 		if m.followLogs {
@@ -521,6 +642,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.stderrView.GotoBottom()
 		}
 
+	case mergedLogMsg:
+		m.applyMergedLogs(msg)
+		m.stdoutView.SetContent(m.formatStdout())
+		if m.followLogs {
+			m.stdoutView.GotoBottom()
+		}
+
+	case jobsDeletedMsg:
+		m.undo = deletedJobs{result: msg.result, jobs: msg.jobs, deadline: time.Now().Add(undoWindow)}
+
 	case actionResultMsg:
 		m.message = msg.message
 		m.isError = msg.isError
@@ -553,22 +684,109 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// sortKey returns the fields of the job the job list orders compare
+func (j Job) sortKey() daemon.JobSortKey {
+	return daemon.JobSortKey{
+		StartedAt: j.StartedAt,
+		StoppedAt: j.StoppedAt,
+		Running:   j.Running,
+		ExitCode:  j.ExitCode,
+		Command:   j.Command,
+	}
+}
+
+// sortJobs puts the job list in the selected order, keeping the cursor on
+// the selected job
+func (m *Model) sortJobs() {
+	if m.jobScroll.Cursor >= len(m.jobs) {
+		return
+	}
+	selected := m.jobs[m.jobScroll.Cursor].ID
+	daemon.SortJobs(m.jobs, m.jobOrder, false, Job.sortKey)
+	for i := range m.jobs {
+		if m.jobs[i].ID == selected {
+			m.jobScroll.SetCursorTo(i)
+			break
+		}
+	}
+}
+
+// cycleJobOrder switches the job list to the next order
+func (m *Model) cycleJobOrder() {
+	next := daemon.JobOrders[0]
+	for i, o := range daemon.JobOrders {
+		if o == m.jobOrder && i+1 < len(daemon.JobOrders) {
+			next = daemon.JobOrders[i+1]
+		}
+	}
+	m.jobOrder = next
+	m.sortJobs()
+	telemetry.TUIActionExecute("cycle_job_order")
+}
+
 // handleDaemonEvent updates the job list based on a daemon event
 func (m *Model) handleDaemonEvent(event daemon.Event) {
 	switch event.Type {
+	case daemon.EventTypeApprovalRequested, daemon.EventTypeApprovalDecided:
+		m.handleApprovalEvent(event)
+
+	case daemon.EventTypeSnapshot:
+		// Replace the job list (sent first when subscribing)
+		m.jobs = jobsFromResponses(event.Jobs)
+		m.jobScroll.ClampToCount(len(m.jobs))
+
+	case daemon.EventTypeResync:
+		// Replace the job list and the runs of the selected job (sent
+		// instead of a burst of events), keeping the selection
+		var selected string
+		if m.jobScroll.Cursor < len(m.jobs) {
+			selected = m.jobs[m.jobScroll.Cursor].ID
+		}
+		m.jobs = jobsFromResponses(event.Jobs)
+		for i := range m.jobs {
+			if m.jobs[i].ID == selected {
+				m.jobScroll.SetCursorTo(i)
+				break
+			}
+		}
+		m.jobScroll.ClampToCount(len(m.jobs))
+
+		var runs []Run
+		for _, r := range event.Runs {
+			if r.JobID == m.runsForJobID {
+				runs = append(runs, runFromResponse(r))
+			}
+		}
+		m.runs = runs
+		m.olderRuns = 0
+		m.runScroll.ClampToCount(len(m.runs))
+		for i := range event.Jobs {
+			if event.Jobs[i].ID == m.runsForJobID {
+				m.stats = &event.Jobs[i]
+			}
+		}
+
 	case daemon.EventTypeJobAdded:
 		// Add job to the beginning of the list (newest first)
 		newJob := Job{
 			ID:          event.Job.ID,
+			Alias:       event.Job.Alias,
 			PID:         event.Job.PID,
 			Command:     strings.Join(event.Job.Command, " "),
 			Description: event.Job.Description,
 			Workdir:     event.Job.Workdir,
+			LogFormat:   event.Job.LogFormat,
+			Timestamps:  event.Job.Timestamps,
+			Combined:    event.Job.CombineOutput,
 			Running:     event.Job.Status == "running",
+			Pinned:      event.Job.Pinned,
 			ExitCode:    event.Job.ExitCode,
 			StartedAt:   parseTime(event.Job.StartedAt),
 			StoppedAt:   parseTime(event.Job.StoppedAt),
 			Ports:       event.Job.Ports,
+			StdoutPath:  event.Job.StdoutPath,
+			StderrPath:  event.Job.StderrPath,
+			StartedBy:   event.Job.StartedBy,
 		}
 		m.jobs = append([]Job{newJob}, m.jobs...)
 		// Select the new job and scroll to top
@@ -584,6 +802,9 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 				m.jobs[i].StartedAt = parseTime(event.Job.StartedAt)
 				m.jobs[i].StoppedAt = time.Time{}
 				m.jobs[i].Description = event.Job.Description
+				m.jobs[i].StdoutPath = event.Job.StdoutPath
+				m.jobs[i].StderrPath = event.Job.StderrPath
+				m.jobs[i].StartedBy = event.Job.StartedBy
 
 				// Move job to the top of the list (most recently run first)
 				if i > 0 {
@@ -628,6 +849,7 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 				// Clear runs if this was the selected job
 				if event.JobID == m.runsForJobID {
 					m.runs = nil
+					m.olderRuns = 0
 					m.stats = nil
 					m.runScroll.Reset()
 					m.runsForJobID = ""
@@ -639,18 +861,7 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 	case daemon.EventTypeRunStarted:
 		// Add new run to the runs list if it's for the selected job
 		if event.Run != nil && event.JobID == m.runsForJobID {
-			newRun := Run{
-				ID:         event.Run.ID,
-				JobID:      event.Run.JobID,
-				PID:        event.Run.PID,
-				Status:     event.Run.Status,
-				ExitCode:   event.Run.ExitCode,
-				StdoutPath: event.Run.StdoutPath,
-				StderrPath: event.Run.StderrPath,
-				StartedAt:  parseTime(event.Run.StartedAt),
-				StoppedAt:  parseTime(event.Run.StoppedAt),
-				DurationMs: event.Run.DurationMs,
-			}
+			newRun := runFromResponse(*event.Run)
 			// Prepend new run to the list (newest first)
 			m.runs = append([]Run{newRun}, m.runs...)
 			// Update stats from event job
@@ -675,6 +886,20 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 			m.stats = &jobResp
 		}
 
+	case daemon.EventTypeRunUpdated:
+		// Update the run's note, markers, missing logs and output if it's for the selected job
+		if event.Run != nil && event.JobID == m.runsForJobID {
+			for i := range m.runs {
+				if m.runs[i].ID == event.Run.ID {
+					m.runs[i].Note = event.Run.Note
+					m.runs[i].Markers = event.Run.Markers
+					m.runs[i].LogsMissing = event.Run.LogsMissing
+					m.runs[i].Output = event.Run.Output
+					break
+				}
+			}
+		}
+
 	case daemon.EventTypeRunRemoved:
 		// Remove run from the runs list if it's for the selected job
 		if event.Run != nil && event.JobID == m.runsForJobID {
@@ -686,6 +911,9 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 					break
 				}
 			}
+			// Update stats from event job
+			jobResp := event.Job
+			m.stats = &jobResp
 		}
 
 	case daemon.EventTypePortsUpdated:
@@ -702,10 +930,15 @@ func (m *Model) handleDaemonEvent(event daemon.Event) {
 		}
 
 	case daemon.EventTypeJobUpdated:
-		// Update job properties (e.g., description change)
+		// Update job properties (e.g., description or alias change)
 		for i := range m.jobs {
 			if m.jobs[i].ID == event.JobID {
 				m.jobs[i].Description = event.Job.Description
+				m.jobs[i].Alias = event.Job.Alias
+				m.jobs[i].LogFormat = event.Job.LogFormat
+				m.jobs[i].Timestamps = event.Job.Timestamps
+				m.jobs[i].Combined = event.Job.CombineOutput
+				m.jobs[i].Pinned = event.Job.Pinned
 				break
 			}
 		}
@@ -736,6 +969,21 @@ func (m Model) updateModal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.textInput, cmd = m.textInput.Update(msg)
 		return m, cmd
 
+	case modalConfirmBatch:
+		return m.updateBatchModal(msg)
+
+	case modalConfirmCleanup:
+		return m.updateCleanupModal(msg)
+
+	case modalJobDetail:
+		return m.updateDetailModal(msg)
+
+	case modalRestartEnv:
+		return m.updateRestartEnvModal(msg)
+
+	case modalApproval:
+		return m.updateApprovalModal(msg)
+
 	case modalHelp:
 		switch msg.String() {
 		case "esc", "?", "q":
@@ -781,6 +1029,9 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case "5":
 		m.activePanel = panelStderr
+		if m.selectedJobCombinesOutput() {
+			m.activePanel = panelStdout
+		}
 		m.updateLogViewportSizes()
 		telemetry.TUIActionExecute("switch_panel")
 
@@ -794,6 +1045,9 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.activePanel = panelStdout
 		case panelStdout:
 			m.activePanel = panelStderr
+			if m.selectedJobCombinesOutput() {
+				m.activePanel = panelJobs
+			}
 		case panelStderr:
 			m.activePanel = panelJobs
 		}
@@ -804,6 +1058,9 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch m.activePanel {
 		case panelJobs:
 			m.activePanel = panelStderr
+			if m.selectedJobCombinesOutput() {
+				m.activePanel = panelStdout
+			}
 		case panelPorts:
 			m.activePanel = panelJobs
 		case panelRuns:
@@ -819,6 +1076,32 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "?":
 		m.modal = modalHelp
 
+	case "C":
+		m.modal = modalConfirmCleanup
+		return m, nil
+
+	case "R":
+		if len(m.jobs) > 0 {
+			m.restartEnvJob = m.jobs[m.jobScroll.Cursor]
+			m.modal = modalRestartEnv
+		}
+		return m, nil
+
+	case "M":
+		return m, m.toggleMergedLogs()
+
+	case "<":
+		return m, m.resizeJobsPanel(-layoutStep)
+
+	case ">":
+		return m, m.resizeJobsPanel(layoutStep)
+
+	case "+", "=":
+		return m, m.resizeLogPanels(layoutStep)
+
+	case "-":
+		return m, m.resizeLogPanels(-layoutStep)
+
 	case "n":
 		m.modal = modalNewJob
 		m.textInput.Reset()
@@ -839,7 +1122,36 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.subscribed = false
 		m.eventChan = nil
 		m.errChan = nil
-		return m, tea.Batch(m.refreshJobs(), m.startSubscription())
+		return m, m.startSubscription()
+	}
+
+	// With marked jobs, lifecycle keys apply to all of them after
+	// confirmation. Otherwise the config may ask to confirm 'd' and 'S'.
+	if key := msg.String(); !(key == "d" && m.activePanel == panelRuns) {
+		switch key {
+		case "s", "S", "r", "d":
+			if b, ok := m.newPendingBatch(key); ok {
+				m.batch = b
+				m.modal = modalConfirmBatch
+				return m, nil
+			}
+			if len(m.jobs) > 0 && m.config.confirms(key) {
+				if job := m.jobs[m.jobScroll.Cursor]; actionApplies(key, job) {
+					m.batch = pendingBatch{key: key, jobs: []Job{job}}
+					m.modal = modalConfirmBatch
+					return m, nil
+				}
+			}
+		}
+	}
+
+	if msg.String() == "u" {
+		if m.canUndo() {
+			jobs := m.undo.jobs
+			m.undo = deletedJobs{}
+			return m, m.restoreJobs(jobs)
+		}
+		return m, nil
 	}
 
 	// Job lifecycle keys work from any panel
@@ -876,32 +1188,34 @@ func (m Model) jobLifecycleCmd(key string) (tea.Cmd, bool) {
 		}
 		return nil, false
 	}
-	job := m.jobs[m.jobScroll.Cursor]
-	id := job.ID
+	switch key {
+	case "s", "S", "r", "d":
+		return m.lifecycleCmd(key, m.jobs[m.jobScroll.Cursor]), true
+	}
+	return nil, false
+}
+
+// lifecycleCmd applies a lifecycle key to a job, if it applies (see
+// actionApplies). Deleted jobs can be restored with 'u'.
+func (m Model) lifecycleCmd(key string, job Job) tea.Cmd {
+	if !actionApplies(key, job) {
+		return nil
+	}
 	switch key {
 	case "s":
-		if job.Running {
-			telemetry.TUIActionExecute("stop_job")
-			return m.stopJob(id, false), true
-		}
-		return nil, true
+		telemetry.TUIActionExecute("stop_job")
+		return m.stopJob(job.ID, false)
 	case "S":
-		if job.Running {
-			telemetry.TUIActionExecute("kill_job")
-			return m.stopJob(id, true), true
-		}
-		return nil, true
+		telemetry.TUIActionExecute("kill_job")
+		return m.stopJob(job.ID, true)
 	case "r":
 		telemetry.TUIActionExecute("restart_job")
-		return m.restartJob(id), true
+		return m.restartJob(job.ID, daemon.EnvSourceClient)
 	case "d":
-		if !job.Running {
-			telemetry.TUIActionExecute("remove_job")
-			return m.removeJob(id), true
-		}
-		return nil, true
+		telemetry.TUIActionExecute("remove_job")
+		return m.runBatch(pendingBatch{key: "d", jobs: []Job{job}})
 	}
-	return nil, false
+	return nil
 }
 
 func (m Model) updateJobsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -974,17 +1288,63 @@ func (m Model) updateJobsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.stderrView.SetContent(m.formatStderr())
 		m.stdoutView.SetXOffset(0)
 		m.stderrView.SetXOffset(0)
+
+	case "v":
+		m.cycleLogLevel()
+
+	case "t":
+		m.toggleTimestamps()
+
+	case "o":
+		m.cycleJobOrder()
+
+	case " ":
+		return m, m.toggleMark()
+
+	case "enter", "i":
+		m.openJobDetail()
+
+	case "esc":
+		if len(m.marked) > 0 {
+			m.marked = nil
+			telemetry.TUIActionExecute("clear_marks")
+		}
 	}
 
 	return m, nil
 }
 
+// cycleLogLevel steps the minimum level shown for jobs with JSON logs
+func (m *Model) cycleLogLevel() {
+	m.logLevel = nextLevelFilter(m.logLevel)
+	telemetry.TUIActionExecute("cycle_log_level")
+	m.stdoutView.SetContent(m.formatStdout())
+	if m.followLogs {
+		m.stdoutView.GotoBottom()
+	}
+}
+
+// toggleTimestamps shows or hides line timestamps in the log panels
+func (m *Model) toggleTimestamps() {
+	m.showTimes = !m.showTimes
+	telemetry.TUIActionExecute("toggle_timestamps")
+	m.stdoutView.SetContent(m.formatStdout())
+	m.stderrView.SetContent(m.formatStderr())
+	m.stdoutView.SetXOffset(0)
+	m.stderrView.SetXOffset(0)
+	if m.followLogs {
+		m.stdoutView.GotoBottom()
+		m.stderrView.GotoBottom()
+	}
+}
+
 // onJobChanged resets dependent state after the job cursor moves.
 // Call this after any operation that changes jobScroll.Cursor.
 func (m *Model) onJobChanged() tea.Cmd {
 	m.followLogs = true
 	m.runScroll.Reset()
 	m.runs = nil
+	m.olderRuns = 0
 	m.stats = nil
 	m.stdoutContent = ""
 	m.stderrContent = ""
@@ -992,6 +1352,10 @@ func (m *Model) onJobChanged() tea.Cmd {
 	if len(m.jobs) > 0 {
 		m.runsForJobID = m.jobs[m.jobScroll.Cursor].ID
 	}
+	if m.activePanel == panelStderr && m.selectedJobCombinesOutput() {
+		m.activePanel = panelStdout
+	}
+	m.updateLogViewportSizes()
 	return m.fetchRunsForSelectedJob()
 }
 
@@ -999,6 +1363,9 @@ func (m *Model) onJobChanged() tea.Cmd {
 // Call this after any operation that changes runScroll.Cursor.
 func (m *Model) onRunChanged() tea.Cmd {
 	m.followLogs = true
+	if m.olderRuns > 0 && m.runScroll.Cursor == len(m.runs)-1 {
+		return tea.Batch(m.readLogs(), m.fetchOlderRuns())
+	}
 	return m.readLogs()
 }
 
@@ -1029,6 +1396,9 @@ func (m Model) updatePortsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "G":
 		m.portScroll.Last(portCount)
 
+	case "c":
+		m.copySelectedPort()
+
 	case "f":
 		m.followLogs = !m.followLogs
 		telemetry.TUIActionExecute("toggle_follow")
@@ -1052,6 +1422,12 @@ func (m Model) updatePortsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.stderrView.SetContent(m.formatStderr())
 		m.stdoutView.SetXOffset(0)
 		m.stderrView.SetXOffset(0)
+
+	case "v":
+		m.cycleLogLevel()
+
+	case "t":
+		m.toggleTimestamps()
 	}
 
 	return m, nil
@@ -1103,6 +1479,12 @@ func (m Model) updateRunsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.stdoutView.SetXOffset(0)
 		m.stderrView.SetXOffset(0)
 
+	case "v":
+		m.cycleLogLevel()
+
+	case "t":
+		m.toggleTimestamps()
+
 	case "d":
 		if len(m.runs) > 0 && m.runs[m.runScroll.Cursor].Status != "running" {
 			telemetry.TUIActionExecute("remove_run")
@@ -1172,6 +1554,12 @@ func (m Model) updateLogsPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Reset horizontal scroll when toggling wrap
 		m.stdoutView.SetXOffset(0)
 		m.stderrView.SetXOffset(0)
+
+	case "v":
+		m.cycleLogLevel()
+
+	case "t":
+		m.toggleTimestamps()
 	}
 
 	var cmd tea.Cmd
@@ -1190,7 +1578,6 @@ func (m Model) stopJob(jobID string, force bool) tea.Cmd {
 			}
 			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
 		}
-		defer client.Close()
 
 		pid, err := client.Stop(jobID, force)
 		if err != nil {
@@ -1211,7 +1598,9 @@ func (m Model) stopJob(jobID string, force bool) tea.Cmd {
 	}
 }
 
-func (m Model) restartJob(jobID string) tea.Cmd {
+// restartJob restarts a job with the environment of the given source (see
+// daemon.EnvSourceClient)
+func (m Model) restartJob(jobID, envSource string) tea.Cmd {
 	return func() tea.Msg {
 		client, err := connectClient()
 		if err != nil {
@@ -1220,9 +1609,8 @@ func (m Model) restartJob(jobID string) tea.Cmd {
 			}
 			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
 		}
-		defer client.Close()
 
-		job, err := client.Restart(jobID, m.env)
+		job, err := client.Restart(jobID, m.env, envSource)
 		if err != nil {
 			return actionResultMsg{message: fmt.Sprintf("Failed to restart: %v", err), isError: true}
 		}
@@ -1234,29 +1622,6 @@ func (m Model) restartJob(jobID string) tea.Cmd {
 	}
 }
 
-func (m Model) removeJob(jobID string) tea.Cmd {
-	return func() tea.Msg {
-		client, err := connectClient()
-		if err != nil {
-			if msg := checkVersionMismatch(err); msg != nil {
-				return msg
-			}
-			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
-		}
-		defer client.Close()
-
-		_, err = client.Remove(jobID)
-		if err != nil {
-			return actionResultMsg{message: fmt.Sprintf("Failed to remove: %v", err), isError: true}
-		}
-
-		return actionResultMsg{
-			message: fmt.Sprintf("Removed %s", jobID),
-			isError: false,
-		}
-	}
-}
-
 func (m Model) removeRun(runID string) tea.Cmd {
 	return func() tea.Msg {
 		client, err := connectClient()
@@ -1266,7 +1631,6 @@ func (m Model) removeRun(runID string) tea.Cmd {
 			}
 			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
 		}
-		defer client.Close()
 
 		err = client.RemoveRun(runID)
 		if err != nil {
@@ -1294,13 +1658,19 @@ func (m Model) addJob(command string) tea.Cmd {
 			}
 			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
 		}
-		defer client.Close()
 
-		result, err := client.Add(parts, m.cwd, m.env, "", false)
+		result, err := client.Run(parts, m.cwd, m.env, daemon.JobOptions{})
 		if err != nil {
 			return actionResultMsg{message: fmt.Sprintf("Failed to add: %v", err), isError: true}
 		}
 
+		if result.Deduplicated {
+			return actionResultMsg{
+				message: fmt.Sprintf("%s was already started by another client", result.Job.ID),
+				isError: false,
+			}
+		}
+
 		return actionResultMsg{
 			message: fmt.Sprintf("Started %s (PID %d)", result.Job.ID, result.Job.PID),
 			isError: false,
@@ -1311,6 +1681,10 @@ func (m Model) addJob(command string) tea.Cmd {
 // Formatting
 
 func (m Model) formatStdout() string {
+	if m.merged.active() {
+		return m.formatMergedLogs()
+	}
+
 	if len(m.jobs) == 0 || m.jobScroll.Cursor >= len(m.jobs) {
 		return mutedStyle.Render("No job selected")
 	}
@@ -1320,7 +1694,27 @@ func (m Model) formatStdout() string {
 	}
 
 	// Strip cursor movement sequences that break TUI rendering
-	content := StripCursorSequences(m.stdoutContent)
+	content := StripCursorSequences(m.withTimestamps(m.stdoutContent, m.stdoutStamps))
+
+	// JSON logs are colored and filtered by level, line by line
+	if m.jobs[m.jobScroll.Cursor].LogFormat == daemon.LogFormatJSON {
+		width := 0
+		if m.wrapLines {
+			width = m.logPanelWidth
+		}
+		prefixWidth := 0
+		if m.showTimes && m.stdoutStamps != nil {
+			prefixWidth = len(tuiTimestampFormat) + 1
+		}
+		return formatJSONLogs(content, m.logLevel, width, prefixWidth)
+	}
+
+	// Dim separator lines where the run was signaled, reloaded, etc.
+	if m.runScroll.Cursor >= 0 && m.runScroll.Cursor < len(m.runs) {
+		content = daemon.InsertMarkers(m.stdoutContent, content, m.runs[m.runScroll.Cursor].Markers, func(marker daemon.RunMarker) string {
+			return mutedStyle.Render("── " + marker.At.Local().Format(tuiTimestampFormat) + " " + marker.String() + " ──")
+		})
+	}
 
 	// Apply line wrapping if enabled
 	if m.wrapLines && m.logPanelWidth > 0 {
@@ -1337,7 +1731,21 @@ func (m Model) formatStdout() string {
 	return result.String()
 }
 
+// withTimestamps prefixes log content with line times when they are shown
+func (m Model) withTimestamps(content string, stamps []daemon.LineTimestamp) string {
+	if !m.showTimes {
+		return content
+	}
+	return daemon.PrefixTimestamps(content, stamps, func(t time.Time) string {
+		return t.Local().Format(tuiTimestampFormat)
+	})
+}
+
 func (m Model) formatStderr() string {
+	if m.merged.active() {
+		return mutedStyle.Render("(merged into the output panel)")
+	}
+
 	if len(m.jobs) == 0 || m.jobScroll.Cursor >= len(m.jobs) {
 		return ""
 	}
@@ -1347,7 +1755,7 @@ func (m Model) formatStderr() string {
 	}
 
 	// Strip cursor movement sequences that break TUI rendering
-	content := StripCursorSequences(m.stderrContent)
+	content := StripCursorSequences(m.withTimestamps(m.stderrContent, m.stderrStamps))
 
 	// Apply line wrapping if enabled
 	if m.wrapLines && m.logPanelWidth > 0 {
@@ -1368,8 +1776,15 @@ func (m Model) formatStderr() string {
 // Layout helpers
 
 func (m Model) jobPanelWidth() int {
+	// A resized layout sets the share of the screen, leaving room for both
+	// columns
+	if pct := m.config.Layout.JobsWidth; pct > 0 {
+		w := m.width * pct / 100
+		return max(min(w, m.width-minColumnWidth), minColumnWidth)
+	}
+
 	// 40% of screen or min 40 chars
-	w := m.width * 40 / 100
+	w := m.width * defaultJobsWidth / 100
 	if w < 40 {
 		w = 40
 	}
@@ -1380,17 +1795,26 @@ func (m Model) jobPanelWidth() int {
 }
 
 // logPanelHeights returns the stdout and stderr panel heights based on the
-// current active panel. Stderr expands to 80% when focused, otherwise 20%.
+// current active panel. Stderr expands to 80% when focused, otherwise 20%
+// (or the other way around from the layout's stderr height).
+// Jobs with combined output have no stderr panel (stderrH is 0).
 func (m Model) logPanelHeights() (stdoutH, stderrH int) {
 	totalH := m.height - 1 // height - status bar
 	if totalH < 8 {
 		totalH = 8
 	}
+	if m.selectedJobCombinesOutput() {
+		return totalH, 0
+	}
 
+	share := m.config.Layout.StderrHeight
+	if share == 0 {
+		share = defaultStderrHeight
+	}
 	if m.activePanel == panelStderr {
-		stderrH = totalH * 80 / 100
+		stderrH = totalH * (100 - share) / 100
 	} else {
-		stderrH = totalH * 20 / 100
+		stderrH = totalH * share / 100
 	}
 	if stderrH < 4 {
 		stderrH = 4
@@ -1410,7 +1834,7 @@ func (m *Model) updateLogViewportSizes() {
 	m.stdoutView.Width = rightPanelW - 4
 	m.stdoutView.Height = stdoutH - 3
 	m.stderrView.Width = rightPanelW - 4
-	m.stderrView.Height = stderrH - 3
+	m.stderrView.Height = max(stderrH-3, 0)
 
 	if m.followLogs {
 		m.stdoutView.GotoBottom()
@@ -1461,7 +1885,14 @@ func (m Model) renderPanels() string {
 
 	// Jobs panel
 	jobContent := m.renderJobList(leftPanelW - 4)
-	jobPanel := m.renderPanel(1, "Jobs", jobContent, leftPanelW, l.jobsH, m.activePanel == panelJobs)
+	jobsTitle := "Jobs"
+	if m.jobOrder != "" && m.jobOrder != daemon.JobOrderStarted {
+		jobsTitle = "Jobs by " + string(m.jobOrder)
+	}
+	if len(m.marked) > 0 {
+		jobsTitle += fmt.Sprintf(" (%d marked)", len(m.marked))
+	}
+	jobPanel := m.renderPanel(1, jobsTitle, jobContent, leftPanelW, l.jobsH, m.activePanel == panelJobs)
 
 	// Description panel (only if selected job has a description)
 	var descPanel string
@@ -1482,12 +1913,19 @@ func (m Model) renderPanels() string {
 	runsTitle := "Runs"
 	if len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) {
 		runsTitle = fmt.Sprintf("Runs: %s", m.jobs[m.jobScroll.Cursor].ID)
+		if m.olderRuns > 0 {
+			runsTitle += fmt.Sprintf(" (%d of %d)", len(m.runs), len(m.runs)+m.olderRuns)
+		}
 	}
 	runsContent := m.renderRunsList(leftPanelW - 4)
 	runsPanel := m.renderPanel(3, runsTitle, runsContent, leftPanelW, l.runsH, m.activePanel == panelRuns)
 
 	// Build titles for log panels
 	var stdoutTitle, stderrTitle string
+	stdoutName := "stdout"
+	if m.selectedJobCombinesOutput() {
+		stdoutName = "output"
+	}
 	if len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) {
 		job := m.jobs[m.jobScroll.Cursor]
 
@@ -1506,6 +1944,9 @@ func (m Model) renderPanels() string {
 				if !run.StartedAt.IsZero() {
 					durationStr = " " + formatDuration(time.Since(run.StartedAt))
 				}
+				if estimate := m.progressEstimate(run.StartedAt); estimate != "" {
+					durationStr += " (" + estimate + ")"
+				}
 			} else if run.ExitCode != nil {
 				if *run.ExitCode == 0 {
 					runStatus = "✓"
@@ -1544,9 +1985,14 @@ func (m Model) renderPanels() string {
 					durationStr = " " + formatDuration(d)
 				}
 			}
+			if job.Running {
+				if estimate := m.progressEstimate(job.StartedAt); estimate != "" {
+					durationStr += " (" + estimate + ")"
+				}
+			}
 		}
 
-		stdoutTitle = fmt.Sprintf("stdout: %s %s%s", showingRunID, runStatus, durationStr)
+		stdoutTitle = fmt.Sprintf("%s: %s %s%s", stdoutName, showingRunID, runStatus, durationStr)
 		stderrTitle = "stderr"
 		if m.followLogs {
 			stdoutTitle += " [following]"
@@ -1557,13 +2003,29 @@ func (m Model) renderPanels() string {
 			stderrTitle += " [wrap]"
 		}
 	} else {
-		stdoutTitle = "stdout"
+		stdoutTitle = stdoutName
 		stderrTitle = "stderr"
 		if m.wrapLines {
 			stdoutTitle += " [wrap]"
 			stderrTitle += " [wrap]"
 		}
 	}
+	if m.showTimes && len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) && m.jobs[m.jobScroll.Cursor].Timestamps {
+		stdoutTitle += " [time]"
+		stderrTitle += " [time]"
+	}
+	if m.logLevel != "" && len(m.jobs) > 0 && m.jobScroll.Cursor < len(m.jobs) && m.jobs[m.jobScroll.Cursor].LogFormat == daemon.LogFormatJSON {
+		stdoutTitle += " [" + m.logLevel + "+]"
+	}
+	if m.merged.active() {
+		stdoutTitle = fmt.Sprintf("output: %s merged", jobCount(len(m.merged.jobIDs)))
+		if m.followLogs {
+			stdoutTitle += " [following]"
+		}
+		if m.wrapLines {
+			stdoutTitle += " [wrap]"
+		}
+	}
 
 	// Stdout panel
 	m.stdoutView.Width = rightPanelW - 4
@@ -1571,11 +2033,15 @@ func (m Model) renderPanels() string {
 	stdoutContent := m.highlightedLogView(panelStdout)
 	stdoutPanel := m.renderPanel(4, stdoutTitle, stdoutContent, rightPanelW, stdoutH, m.activePanel == panelStdout)
 
-	// Stderr panel
-	m.stderrView.Width = rightPanelW - 4
-	m.stderrView.Height = stderrH - 3
-	stderrContent := m.highlightedLogView(panelStderr)
-	stderrPanel := m.renderPanel(5, stderrTitle, stderrContent, rightPanelW, stderrH, m.activePanel == panelStderr)
+	// Stderr panel (none for jobs with combined output)
+	rightPanels := stdoutPanel
+	if stderrH > 0 {
+		m.stderrView.Width = rightPanelW - 4
+		m.stderrView.Height = stderrH - 3
+		stderrContent := m.highlightedLogView(panelStderr)
+		stderrPanel := m.renderPanel(5, stderrTitle, stderrContent, rightPanelW, stderrH, m.activePanel == panelStderr)
+		rightPanels = lipgloss.JoinVertical(lipgloss.Left, stdoutPanel, stderrPanel)
+	}
 
 	// Stack panels
 	var leftPanels string
@@ -1584,7 +2050,6 @@ func (m Model) renderPanels() string {
 	} else {
 		leftPanels = lipgloss.JoinVertical(lipgloss.Left, infoPanel, jobPanel, portsPanel, runsPanel)
 	}
-	rightPanels := lipgloss.JoinVertical(lipgloss.Left, stdoutPanel, stderrPanel)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, leftPanels, rightPanels)
 }
@@ -1646,6 +2111,15 @@ func (m Model) renderDescriptionContent(width int) string {
 }
 
 // selectedJobHasDescription returns true if the selected job has a description
+// selectedJobCombinesOutput reports whether the selected job interleaves
+// stderr into its stdout log, shown in a single output panel
+func (m Model) selectedJobCombinesOutput() bool {
+	if len(m.jobs) == 0 || m.jobScroll.Cursor >= len(m.jobs) {
+		return false
+	}
+	return m.jobs[m.jobScroll.Cursor].Combined
+}
+
 func (m Model) selectedJobHasDescription() bool {
 	if len(m.jobs) == 0 || m.jobScroll.Cursor >= len(m.jobs) {
 		return false
@@ -1727,7 +2201,17 @@ func (m Model) renderRunsList(width int) string {
 		lines = append(lines, progressBar)
 	}
 
-	lines = append(lines, "")
+	// The note of the selected run, if it has one, takes the empty line, or
+	// else that its logs were deleted, or else how much it printed
+	if c := m.runScroll.Cursor; c >= 0 && c < len(m.runs) && m.runs[c].Note != "" {
+		lines = append(lines, mutedStyle.Render(FitCellContent("✎ "+m.runs[c].Note, width)))
+	} else if c >= 0 && c < len(m.runs) && m.runs[c].LogsMissing {
+		lines = append(lines, mutedStyle.Render(FitCellContent("⌀ logs missing (gob cleanup --logs)", width)))
+	} else if c >= 0 && c < len(m.runs) && m.runs[c].Output != nil {
+		lines = append(lines, mutedStyle.Render(FitCellContent(formatRunOutput(m.runs[c].Output), width)))
+	} else {
+		lines = append(lines, "")
+	}
 
 	// Calculate column widths based on panel width
 	// Layout: [space][status 3][space][id 30%][space][time 35%][space][duration 35%]
@@ -1871,11 +2355,21 @@ func (m Model) formatRunListLine(run Run, isSelected bool, width, statusWidth, i
 		duration = formatDuration(time.Duration(run.DurationMs) * time.Millisecond)
 	}
 
+	// Annotated runs, and runs whose logs were deleted, are marked next to
+	// their ID
+	id := run.ID
+	if run.Note != "" {
+		id += " ✎"
+	}
+	if run.LogsMissing {
+		id += " ⌀"
+	}
+
 	// Build the line with fixed-width columns
 	if isSelected {
 		sp := jobSelectedBgStyle.Render(" ")
 		statusStyled := statusSelectedStyle.Render(statusText)
-		idStyled := jobIDSelectedStyle.Render(FitCellContent(run.ID, idWidth))
+		idStyled := jobIDSelectedStyle.Render(FitCellContent(id, idWidth))
 		timeStyled := jobTimeSelectedStyle.Render(FitCellContent(relTime, timeWidth))
 		durationStyled := jobTimeSelectedStyle.Render(FitCellContent(duration, durationWidth))
 		line := sp + statusStyled + sp + idStyled + sp + timeStyled + sp + durationStyled
@@ -1887,12 +2381,19 @@ func (m Model) formatRunListLine(run Run, isSelected bool, width, statusWidth, i
 	}
 
 	statusStyled := statusStyle.Render(statusText)
-	idStyled := jobIDStyle.Render(FitCellContent(run.ID, idWidth))
+	idStyled := jobIDStyle.Render(FitCellContent(id, idWidth))
 	timeStyled := jobTimeStyle.Render(FitCellContent(relTime, timeWidth))
 	durationStyled := jobTimeStyle.Render(FitCellContent(duration, durationWidth))
 	return " " + statusStyled + " " + idStyled + " " + timeStyled + " " + durationStyled
 }
 
+// formatRunOutput formats how much a run printed, e.g.
+// "out 1204 lines 38.2 KB · err 3 lines 120 B"
+func formatRunOutput(o *daemon.OutputStats) string {
+	return fmt.Sprintf("out %d lines %s · err %d lines %s",
+		o.StdoutLines, daemon.FormatBytes(o.StdoutBytes), o.StderrLines, daemon.FormatBytes(o.StderrBytes))
+}
+
 func (m Model) renderPanel(num int, title, content string, width, height int, active bool) string {
 	borderColor := colorBlue
 	titleFg := colorBlue
@@ -2020,7 +2521,18 @@ func (m Model) renderJobList(width int) string {
 		if maxCmdLen < 10 {
 			maxCmdLen = 10
 		}
-		cmd := m.truncate(job.Command, maxCmdLen)
+		label := job.Command
+		if job.Alias != "" {
+			label = job.Alias + ": " + job.Command
+		}
+		if job.Pinned {
+			label = "[pinned] " + label
+		}
+		// Jobs other clients, such as agents, started
+		if job.StartedBy != "" && job.StartedBy != m.client {
+			label = "[" + job.StartedBy + "] " + label
+		}
+		cmd := m.truncate(label, maxCmdLen)
 		var cmdStyled string
 		if isSelected {
 			cmdStyled = jobCommandSelectedStyle.Render(cmd)
@@ -2028,18 +2540,36 @@ func (m Model) renderJobList(width int) string {
 			cmdStyled = cmd
 		}
 
-		// Build line: symbol + command
+		// Description, dimmed, in the space the command leaves
+		if descLen := maxCmdLen - len(cmd) - 2; job.Description != "" && descLen >= 10 {
+			desc := "  " + m.truncate(job.Description, descLen)
+			if isSelected {
+				cmdStyled += jobDescriptionSelectedStyle.Render(desc)
+			} else {
+				cmd += jobDescriptionStyle.Render(desc)
+			}
+		}
+
+		// Build line: mark + symbol + command
 		var line string
 		if isSelected {
 			sp := jobSelectedBgStyle.Render(" ")
-			line = sp + status + sp + exitInfo + cmdStyled
+			mark := sp
+			if m.marked[job.ID] {
+				mark = jobMarkSelectedStyle.Render("▌")
+			}
+			line = mark + status + sp + exitInfo + cmdStyled
 			// Pad with styled spaces to fill width
 			padding := width - lipgloss.Width(line)
 			if padding > 0 {
 				line = line + jobSelectedBgStyle.Render(strings.Repeat(" ", padding))
 			}
 		} else {
-			line = fmt.Sprintf(" %s %s%s", status, exitInfo, cmd)
+			mark := " "
+			if m.marked[job.ID] {
+				mark = jobMarkStyle.Render("▌")
+			}
+			line = fmt.Sprintf("%s%s %s%s", mark, status, exitInfo, cmd)
 		}
 
 		lines = append(lines, line)
@@ -2076,6 +2606,25 @@ func (m Model) formatStatsLine() string {
 		avgDuration)
 }
 
+// minEstimateRuns is the number of successful runs a job needs before the
+// progress of its runs is estimated
+const minEstimateRuns = 3
+
+// progressEstimate describes how far a run started at startedAt is into the
+// typical (average successful) duration of the selected job, e.g.
+// "~75% of typical duration, ETA 12s". Empty without enough history.
+func (m Model) progressEstimate(startedAt time.Time) string {
+	if m.stats == nil || m.stats.SuccessCount < minEstimateRuns || m.stats.AvgDurationMs <= 0 || startedAt.IsZero() {
+		return ""
+	}
+	typical := time.Duration(m.stats.AvgDurationMs) * time.Millisecond
+	elapsed := time.Since(startedAt)
+	if elapsed >= typical {
+		return fmt.Sprintf("over typical duration (%s)", formatDuration(typical))
+	}
+	return fmt.Sprintf("~%d%% of typical duration, ETA %s", int(elapsed*100/typical), formatDuration((typical - elapsed).Round(time.Second)))
+}
+
 // renderProgressBar renders a progress bar for a running job
 // Returns empty string if no progress bar should be shown
 func (m Model) renderProgressBar(width int) string {
@@ -2162,8 +2711,15 @@ func formatRelativeTime(t time.Time) string {
 func (m Model) renderStatusBar() string {
 	var content string
 
-	// Show message instead of shortcuts when there's an active message
-	if m.message != "" && time.Since(m.messageTime) < 3*time.Second {
+	// Offer to undo a deletion, or show a message instead of shortcuts
+	if m.showUndoToast() {
+		toast := m.undoToast()
+		gap := m.width - lipgloss.Width(toast) - 2
+		if gap < 0 {
+			gap = 0
+		}
+		content = " " + toast + strings.Repeat(" ", gap) + " "
+	} else if m.message != "" && time.Since(m.messageTime) < 3*time.Second {
 		var styledMessage string
 		if m.isError {
 			styledMessage = errorStyle.Render(m.message)
@@ -2189,6 +2745,9 @@ func (m Model) renderStatusBar() string {
 				m.renderKey("d", "delete"),
 				m.renderKey("c", "copy"),
 				m.renderKey("n", "new"),
+				m.renderKey("o", "sort"),
+				m.renderKey("space", "mark"),
+				m.renderKey("i", "details"),
 				m.renderKey("H/L", "scroll log"),
 				m.renderKey("w", "wrap"),
 				m.renderKey("a", "all dirs"),
@@ -2197,6 +2756,7 @@ func (m Model) renderStatusBar() string {
 			parts = append(parts,
 				m.renderKey("↑↓", "navigate"),
 				m.renderKey("g/G", "first/last"),
+				m.renderKey("c", "copy address"),
 				m.renderKey("s/S", "stop/kill"),
 				m.renderKey("r", "restart"),
 				m.renderKey("d", "delete"),
@@ -2212,6 +2772,7 @@ func (m Model) renderStatusBar() string {
 				m.renderKey("s/S", "stop/kill"),
 				m.renderKey("r", "restart"),
 				m.renderKey("d", "delete run"),
+				m.renderKey("C", "clean up"),
 				m.renderKey("H/L", "scroll log"),
 				m.renderKey("f", "follow"),
 				m.renderKey("w", "wrap"),
@@ -2227,6 +2788,8 @@ func (m Model) renderStatusBar() string {
 				m.renderKey("d", "delete"),
 				m.renderKey("f", "follow"),
 				m.renderKey("w", "wrap"),
+				m.renderKey("v", "level"),
+				m.renderKey("t", "time"),
 				m.renderKey("1-5", "panels"),
 			)
 		case panelStderr:
@@ -2239,6 +2802,7 @@ func (m Model) renderStatusBar() string {
 				m.renderKey("d", "delete"),
 				m.renderKey("f", "follow"),
 				m.renderKey("w", "wrap"),
+				m.renderKey("t", "time"),
 				m.renderKey("1-5", "panels"),
 			)
 		}
@@ -2268,6 +2832,16 @@ func (m Model) renderModal(background string) string {
 		content = m.renderNewJobModal()
 	case modalHelp:
 		content = m.renderHelpModal()
+	case modalConfirmBatch:
+		content = m.renderBatchModal()
+	case modalConfirmCleanup:
+		content = m.renderCleanupModal()
+	case modalJobDetail:
+		content = m.renderJobDetailModal()
+	case modalRestartEnv:
+		content = m.renderRestartEnvModal()
+	case modalApproval:
+		content = m.renderApprovalModal()
 	}
 
 	// Calculate center position for overlay
@@ -2397,9 +2971,17 @@ func (m Model) renderHelpModal() string {
 		"  " + m.renderKey("s", "stop (SIGTERM)"),
 		"  " + m.renderKey("S", "kill (SIGKILL)"),
 		"  " + m.renderKey("r", "restart"),
+		"  " + m.renderKey("R", "restart, choosing the environment"),
 		"  " + m.renderKey("d", "delete stopped"),
-		"  " + m.renderKey("c", "copy command"),
+		"  " + m.renderKey("u", "undo delete (10s)"),
+		"  " + m.renderKey("C", "clean up old runs"),
+		"  " + m.renderKey("c", "copy command (port address)"),
 		"  " + m.renderKey("n", "new job"),
+		"  " + m.renderKey("o", "sort jobs (jobs panel)"),
+		"  " + m.renderKey("space", "mark for bulk s/S/r/d"),
+		"  " + m.renderKey("M", "follow marked jobs together"),
+		"  " + m.renderKey("enter/i", "job details"),
+		"  " + m.renderKey("esc", "clear marks"),
 		"",
 		helpKeyStyle.Render("Log Viewer"),
 		"  " + m.renderKey("↑/k ↓/j", "scroll vertical"),
@@ -2409,9 +2991,13 @@ func (m Model) renderHelpModal() string {
 		"  " + m.renderKey("g/G", "top/bottom"),
 		"  " + m.renderKey("f", "toggle follow"),
 		"  " + m.renderKey("w", "toggle wrap"),
+		"  " + m.renderKey("v", "level filter (JSON logs)"),
+		"  " + m.renderKey("t", "toggle timestamps"),
 		"",
 		helpKeyStyle.Render("Other"),
 		"  " + m.renderKey("a", "toggle all dirs"),
+		"  " + m.renderKey("< >", "narrow/widen jobs"),
+		"  " + m.renderKey("+ -", "grow/shrink stdout"),
 		"  " + m.renderKey("?", "this help"),
 		"  " + m.renderKey("q", "quit"),
 	}
@@ -2509,8 +3095,15 @@ func Start() error {
 		go StartGobfileJobs(cwd, commands, env)
 	}
 
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	model := New()
+	model.config = config
+
 	// Run TUI
-	p := tea.NewProgram(New(), tea.WithAltScreen(), tea.WithMouseCellMotion())
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
 	finalModel, err := p.Run()
 
 	// Auto-stop gobfile jobs (after TUI exits normally)
@@ -1,7 +1,15 @@
 package tui
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/juanibiapina/gob/internal/daemon"
 )
 
 func TestLogPanelHeights_DefaultPanel(t *testing.T) {
@@ -229,3 +237,432 @@ func TestJobLifecycleCmd_ClaimsKeysWithZeroJobs(t *testing.T) {
 		}
 	}
 }
+
+func TestLogPanelHeights_CombinedOutput(t *testing.T) {
+	// Jobs with combined output have a single output panel
+	m := Model{
+		height:      101, // totalH = 100
+		width:       200,
+		activePanel: panelStderr,
+		jobs:        []Job{{ID: "job1", Combined: true}},
+	}
+
+	stdoutH, stderrH := m.logPanelHeights()
+
+	if stdoutH != 100 || stderrH != 0 {
+		t.Errorf("logPanelHeights() = %d, %d, want 100, 0", stdoutH, stderrH)
+	}
+}
+
+func TestPanelSwitch_CombinedOutputSkipsStderr(t *testing.T) {
+	m := New()
+	m.width = 200
+	m.height = 51
+	m.jobs = []Job{{ID: "abc", Combined: true}}
+
+	m.activePanel = panelStdout
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if got := updated.(Model).activePanel; got != panelJobs {
+		t.Errorf("tab from stdout = %d, want jobs", got)
+	}
+
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("5")})
+	if got := updated.(Model).activePanel; got != panelStdout {
+		t.Errorf("5 = %d, want stdout", got)
+	}
+}
+
+func TestProgressEstimate(t *testing.T) {
+	m := newTestModelWithJobs(Job{ID: "j1", Running: true})
+	startedAt := time.Now().Add(-30 * time.Second)
+
+	m.stats = &daemon.JobResponse{SuccessCount: 2, AvgDurationMs: 40000}
+	if got := m.progressEstimate(startedAt); got != "" {
+		t.Errorf("progressEstimate() = %q, want none with 2 successful runs", got)
+	}
+
+	m.stats = &daemon.JobResponse{SuccessCount: 3, AvgDurationMs: 40000}
+	if got := m.progressEstimate(startedAt); got != "~75% of typical duration, ETA 10s" {
+		t.Errorf("progressEstimate() = %q", got)
+	}
+
+	m.stats = &daemon.JobResponse{SuccessCount: 3, AvgDurationMs: 20000}
+	if got := m.progressEstimate(startedAt); got != "over typical duration (20s)" {
+		t.Errorf("progressEstimate() = %q", got)
+	}
+}
+
+func TestCycleJobOrder_KeepsSelection(t *testing.T) {
+	now := time.Now()
+	m := Model{
+		jobOrder: daemon.JobOrderStarted,
+		jobs: []Job{
+			{ID: "new", Command: "make test", StartedAt: now.Add(-time.Second), StoppedAt: now},
+			{ID: "old", Command: "npm run dev", StartedAt: now.Add(-time.Hour), Running: true},
+		},
+	}
+	m.jobScroll.SetCursorTo(1)
+
+	m.cycleJobOrder()
+
+	if m.jobOrder != daemon.JobOrderDuration {
+		t.Fatalf("jobOrder = %q, want duration", m.jobOrder)
+	}
+	if m.jobs[0].ID != "old" {
+		t.Errorf("first job = %q, want the longest running one", m.jobs[0].ID)
+	}
+	if m.jobs[m.jobScroll.Cursor].ID != "old" {
+		t.Errorf("cursor moved off the selected job to %q", m.jobs[m.jobScroll.Cursor].ID)
+	}
+}
+
+func TestMarkedJobs_LifecycleKeyOpensBatchConfirmation(t *testing.T) {
+	m := Model{
+		activePanel: panelJobs,
+		jobs: []Job{
+			{ID: "job1", Running: true},
+			{ID: "job2"},
+			{ID: "job3", Running: true},
+		},
+	}
+
+	m.toggleMark()
+	m.toggleMark()
+	m.toggleMark()
+	if len(m.marked) != 3 {
+		t.Fatalf("marked = %d jobs, want 3", len(m.marked))
+	}
+
+	updated, cmd := m.updateMain(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("stop with marked jobs ran a command before confirmation")
+	}
+	if m.modal != modalConfirmBatch {
+		t.Fatalf("modal = %d, want modalConfirmBatch", m.modal)
+	}
+	if len(m.batch.jobs) != 2 || m.batch.skipped != 1 {
+		t.Errorf("batch has %d jobs and %d skipped, want 2 running jobs and 1 skipped", len(m.batch.jobs), m.batch.skipped)
+	}
+
+	updated, _ = m.updateModal(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.modal != modalNone || len(m.marked) != 3 {
+		t.Errorf("cancel should close the modal and keep the marks, got modal %d and %d marks", m.modal, len(m.marked))
+	}
+}
+
+func TestMarkedJobs_DeleteSkipsPinnedJobs(t *testing.T) {
+	m := Model{
+		activePanel: panelJobs,
+		jobs: []Job{
+			{ID: "job1"},
+			{ID: "job2", Pinned: true},
+		},
+		marked: map[string]bool{"job1": true, "job2": true},
+	}
+
+	b, ok := m.newPendingBatch("d")
+	if !ok || len(b.jobs) != 1 || b.jobs[0].ID != "job1" || b.skipped != 1 {
+		t.Errorf("batch = %+v, want job1 with the pinned job skipped", b)
+	}
+}
+
+func TestPruneMarks_ForgetsRemovedJobs(t *testing.T) {
+	m := Model{
+		jobs:   []Job{{ID: "job1"}},
+		marked: map[string]bool{"job1": true, "job2": true},
+	}
+
+	m.pruneMarks()
+
+	if !m.marked["job1"] || m.marked["job2"] {
+		t.Errorf("marked = %v, want only job1", m.marked)
+	}
+}
+
+func TestConfirmDelete_AsksBeforeDeletingSelectedJob(t *testing.T) {
+	m := Model{
+		activePanel: panelJobs,
+		jobs:        []Job{{ID: "job1"}, {ID: "job2", Running: true}},
+		config:      Config{ConfirmDelete: true},
+	}
+
+	updated, cmd := m.updateMain(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	m = updated.(Model)
+	if cmd != nil || m.modal != modalConfirmBatch {
+		t.Fatalf("expected a confirmation before deleting, got modal %d", m.modal)
+	}
+	if len(m.batch.jobs) != 1 || m.batch.jobs[0].ID != "job1" || m.batch.marked {
+		t.Errorf("expected the selected job in the confirmation, got %+v", m.batch)
+	}
+
+	// Killing is not confirmed unless configured
+	m.modal = modalNone
+	m.jobScroll.SetCursorTo(1)
+	updated, cmd = m.updateMain(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	m = updated.(Model)
+	if cmd == nil || m.modal != modalNone {
+		t.Errorf("expected S to kill without confirmation, got modal %d", m.modal)
+	}
+}
+
+func TestUndoToast_HiddenByNewerMessage(t *testing.T) {
+	m := Model{
+		undo: deletedJobs{
+			jobs:     []daemon.JobResponse{{ID: "job1"}},
+			deadline: time.Now().Add(undoWindow),
+		},
+	}
+	if !m.showUndoToast() {
+		t.Error("expected the undo toast after a deletion")
+	}
+
+	m.message = "Restarted job2"
+	m.messageTime = time.Now()
+	if m.showUndoToast() {
+		t.Error("expected a newer message to hide the undo toast")
+	}
+
+	m.undo.deadline = time.Now().Add(-time.Second)
+	if m.canUndo() {
+		t.Error("expected undo to expire after the deadline")
+	}
+}
+
+func TestRunRemoved_UpdatesStats(t *testing.T) {
+	m := Model{
+		jobs:         []Job{{ID: "job1"}},
+		runs:         []Run{{ID: "run2", JobID: "job1"}, {ID: "run1", JobID: "job1"}},
+		runsForJobID: "job1",
+		stats:        &daemon.JobResponse{ID: "job1", RunCount: 2},
+	}
+
+	m.handleDaemonEvent(daemon.Event{
+		Type:  daemon.EventTypeRunRemoved,
+		JobID: "job1",
+		Job:   daemon.JobResponse{ID: "job1", RunCount: 1},
+		Run:   &daemon.RunResponse{ID: "run1", JobID: "job1"},
+	})
+
+	if len(m.runs) != 1 || m.runs[0].ID != "run2" {
+		t.Errorf("runs = %v, want only run2", m.runs)
+	}
+	if m.stats == nil || m.stats.RunCount != 1 {
+		t.Errorf("expected the run count to drop to 1, got %+v", m.stats)
+	}
+}
+
+func TestRunUpdated_SetsNote(t *testing.T) {
+	m := Model{
+		jobs:         []Job{{ID: "job1"}},
+		runs:         []Run{{ID: "run2", JobID: "job1"}, {ID: "run1", JobID: "job1"}},
+		runsForJobID: "job1",
+		stats:        &daemon.JobResponse{ID: "job1", RunCount: 2},
+	}
+
+	m.handleDaemonEvent(daemon.Event{
+		Type:  daemon.EventTypeRunUpdated,
+		JobID: "job1",
+		Run:   &daemon.RunResponse{ID: "run1", JobID: "job1", Note: "flaky"},
+	})
+
+	if m.runs[1].Note != "flaky" || m.runs[0].Note != "" {
+		t.Errorf("expected only run1 to get the note, got %+v", m.runs)
+	}
+
+	m.runScroll.SetCursorTo(1)
+	if out := m.renderRunsList(60); !strings.Contains(out, "✎ flaky") {
+		t.Errorf("expected the selected run's note in the runs panel, got:\n%s", out)
+	}
+}
+
+func TestRunUpdated_ShowsOutput(t *testing.T) {
+	m := Model{
+		jobs:         []Job{{ID: "job1"}},
+		runs:         []Run{{ID: "run1", JobID: "job1"}},
+		runsForJobID: "job1",
+		stats:        &daemon.JobResponse{ID: "job1", RunCount: 1},
+	}
+
+	output := &daemon.OutputStats{StdoutBytes: 2048, StdoutLines: 40, StderrBytes: 12, StderrLines: 1}
+	m.handleDaemonEvent(daemon.Event{
+		Type:  daemon.EventTypeRunUpdated,
+		JobID: "job1",
+		Run:   &daemon.RunResponse{ID: "run1", JobID: "job1", Output: output},
+	})
+
+	if out := m.renderRunsList(80); !strings.Contains(out, "out 40 lines 2.0 KB · err 1 lines 12 B") {
+		t.Errorf("expected the selected run's output in the runs panel, got:\n%s", out)
+	}
+}
+
+func TestApprovalEvents_ShowModal(t *testing.T) {
+	m := Model{}
+	remove := daemon.Approval{ID: 1, Client: "claude", Type: daemon.RequestTypeRemove, Target: "abc", Reason: "remove"}
+
+	m.handleDaemonEvent(daemon.Event{Type: daemon.EventTypeApprovalRequested, Approval: &remove})
+	if m.modal != modalApproval || len(m.approvals) != 1 {
+		t.Fatalf("expected the approval modal with one request, got modal %v and %v", m.modal, m.approvals)
+	}
+	if out := m.renderApprovalModal(); !strings.Contains(out, "claude wants to remove") {
+		t.Errorf("expected the request in the modal, got:\n%s", out)
+	}
+
+	// Decided elsewhere, e.g. with 'gob approve'
+	remove.Decision = "approved by zsh"
+	m.handleDaemonEvent(daemon.Event{Type: daemon.EventTypeApprovalDecided, Approval: &remove})
+	if m.modal != modalNone || len(m.approvals) != 0 {
+		t.Errorf("expected the modal closed, got modal %v and %v", m.modal, m.approvals)
+	}
+}
+
+func TestOlderRuns_AppendsNextPage(t *testing.T) {
+	m := Model{
+		jobs:         []Job{{ID: "job1"}},
+		runs:         []Run{{ID: "run4", JobID: "job1"}, {ID: "run3", JobID: "job1"}},
+		runsForJobID: "job1",
+		olderRuns:    2,
+	}
+	older := []Run{{ID: "run2", JobID: "job1"}, {ID: "run1", JobID: "job1"}}
+
+	// A page fetched before a run was removed no longer follows the loaded runs
+	updated, _ := m.Update(olderRunsMsg{jobID: "job1", offset: 3, runs: older, total: 5})
+	if got := updated.(Model).runs; len(got) != 2 {
+		t.Fatalf("expected the stale page to be dropped, got %v", got)
+	}
+
+	updated, _ = m.Update(olderRunsMsg{jobID: "job1", offset: 2, runs: older, total: 4})
+	m = updated.(Model)
+	if len(m.runs) != 4 || m.runs[3].ID != "run1" {
+		t.Errorf("expected the older runs to be appended, got %v", m.runs)
+	}
+	if m.olderRuns != 0 {
+		t.Errorf("olderRuns = %d, want 0", m.olderRuns)
+	}
+}
+
+func TestJobDetail_ShowsFullCommandAndFailures(t *testing.T) {
+	exit1 := 1
+	command := "npm run dev -- --port 3000 --host 0.0.0.0 --open --strictPort --clearScreen false"
+	m := Model{
+		width:        200,
+		activePanel:  panelJobs,
+		jobs:         []Job{{ID: "job1", Command: command, Workdir: "/work"}},
+		runs:         []Run{{ID: "job1-2", JobID: "job1", ExitCode: &exit1}},
+		runsForJobID: "job1",
+		stats:        &daemon.JobResponse{ID: "job1", Tags: []string{"web"}, StartedBy: "claude", StartedVia: daemon.ClientTypeAPI},
+	}
+
+	updated, _ := m.updateJobsPanel(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = updated.(Model)
+	if m.modal != modalJobDetail {
+		t.Fatalf("modal = %d, want modalJobDetail", m.modal)
+	}
+
+	view := m.renderJobDetailModal()
+	for _, want := range []string{"--strictPort", "web", "job1-2  exit 1", "claude via api"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("job detail does not contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestLogPanelHeights_Layout(t *testing.T) {
+	// The layout's stderr height applies unfocused and swaps when focused
+	m := Model{
+		height:      101, // totalH = 100
+		width:       200,
+		activePanel: panelJobs,
+		config:      Config{Layout: LayoutConfig{StderrHeight: 30}},
+	}
+
+	if stdoutH, stderrH := m.logPanelHeights(); stdoutH != 70 || stderrH != 30 {
+		t.Errorf("unfocused heights = %d/%d, want 70/30", stdoutH, stderrH)
+	}
+
+	m.activePanel = panelStderr
+	if stdoutH, stderrH := m.logPanelHeights(); stdoutH != 30 || stderrH != 70 {
+		t.Errorf("focused heights = %d/%d, want 30/70", stdoutH, stderrH)
+	}
+}
+
+func TestResizePanels_ClampsLayout(t *testing.T) {
+	m := Model{height: 101, width: 200, activePanel: panelJobs}
+
+	// Starts from the default width: 60 chars of 200 is 30%
+	m.resizeJobsPanel(layoutStep)
+	if m.config.Layout.JobsWidth != 35 {
+		t.Errorf("JobsWidth = %d, want 35", m.config.Layout.JobsWidth)
+	}
+	if w := m.jobPanelWidth(); w != 70 {
+		t.Errorf("jobPanelWidth() = %d, want 70", w)
+	}
+	for range 20 {
+		m.resizeJobsPanel(layoutStep)
+	}
+	if m.config.Layout.JobsWidth != maxJobsWidth {
+		t.Errorf("JobsWidth = %d, want %d", m.config.Layout.JobsWidth, maxJobsWidth)
+	}
+
+	// Growing stdout shrinks the unfocused stderr panel
+	m.resizeLogPanels(layoutStep)
+	if m.config.Layout.StderrHeight != 15 {
+		t.Errorf("StderrHeight = %d, want 15", m.config.Layout.StderrHeight)
+	}
+	for range 20 {
+		m.resizeLogPanels(layoutStep)
+	}
+	if m.config.Layout.StderrHeight != minStderrHeight {
+		t.Errorf("StderrHeight = %d, want %d", m.config.Layout.StderrHeight, minStderrHeight)
+	}
+
+	// With stderr focused, growing stdout shrinks the focused stderr panel
+	m.activePanel = panelStderr
+	m.resizeLogPanels(layoutStep)
+	if m.config.Layout.StderrHeight != 15 {
+		t.Errorf("StderrHeight = %d, want 15", m.config.Layout.StderrHeight)
+	}
+}
+
+func TestMergedLogs_InterleavesMarkedJobs(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	web := path("web.out", "listening\npart")
+	m := Model{
+		jobs: []Job{
+			{ID: "job1", Alias: "web", StdoutPath: web, StderrPath: path("web.err", "")},
+			{ID: "job2", StdoutPath: path("db.out", "migrated\n"), StderrPath: path("db.err", "slow query\n")},
+		},
+		marked: map[string]bool{"job1": true, "job2": true},
+	}
+
+	m.applyMergedLogs(m.toggleMergedLogs()().(mergedLogMsg))
+	got := ansi.Strip(strings.Join(m.merged.lines, "\n"))
+	want := "[web] listening\n[job2] migrated\n[job2] slow query"
+	if got != want {
+		t.Errorf("merged lines = %q, want %q", got, want)
+	}
+
+	// The partial line is read once it is complete
+	f, err := os.OpenFile(web, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("ial\n")
+	f.Close()
+	m.applyMergedLogs(m.readMergedLogs()().(mergedLogMsg))
+	if last := ansi.Strip(m.merged.lines[len(m.merged.lines)-1]); last != "[web] partial" || len(m.merged.lines) != 4 {
+		t.Errorf("after append, lines = %q", m.merged.lines)
+	}
+
+	if m.toggleMergedLogs(); m.merged.active() {
+		t.Error("M should go back to the selected job's logs")
+	}
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juanibiapina/gob/internal/daemon"
+	"github.com/juanibiapina/gob/internal/telemetry"
+)
+
+// undoWindow is how long 'u' can restore deleted jobs
+const undoWindow = 10 * time.Second
+
+// jobsDeletedMsg is sent after jobs were deleted, with their definitions
+type jobsDeletedMsg struct {
+	result actionResultMsg
+	jobs   []daemon.JobResponse
+}
+
+// deletedJobs are jobs that 'u' can restore until deadline
+type deletedJobs struct {
+	result   actionResultMsg
+	jobs     []daemon.JobResponse
+	deadline time.Time
+}
+
+// canUndo reports whether the last deletion can still be undone
+func (m Model) canUndo() bool {
+	return len(m.undo.jobs) > 0 && time.Now().Before(m.undo.deadline)
+}
+
+// showUndoToast reports whether the status bar offers to undo the last
+// deletion: while it can be undone, unless a newer message is shown
+func (m Model) showUndoToast() bool {
+	newerMessage := m.messageTime.After(m.undo.deadline.Add(-undoWindow)) && time.Since(m.messageTime) < 3*time.Second
+	return m.canUndo() && !newerMessage
+}
+
+// undoToast is the status bar message offering to undo the last deletion
+func (m Model) undoToast() string {
+	remaining := time.Until(m.undo.deadline).Round(time.Second)
+	text := fmt.Sprintf("%s • u: undo (%ds)", m.undo.result.message, int(remaining.Seconds()))
+	if m.undo.result.isError {
+		return errorStyle.Render(text)
+	}
+	return successStyle.Render(text)
+}
+
+// restoreJobs creates deleted jobs again from their definitions, with
+// their aliases. Their runs and logs are gone.
+func (m Model) restoreJobs(jobs []daemon.JobResponse) tea.Cmd {
+	telemetry.TUIActionExecute("undo_remove")
+	return func() tea.Msg {
+		client, err := connectClient()
+		if err != nil {
+			if msg := checkVersionMismatch(err); msg != nil {
+				return msg
+			}
+			return actionResultMsg{message: fmt.Sprintf("Failed to connect: %v", err), isError: true}
+		}
+
+		for _, job := range jobs {
+			created, err := client.Create(job.Command, job.Workdir, job.Options())
+			if err != nil {
+				return actionResultMsg{message: fmt.Sprintf("Failed to restore: %v", err), isError: true}
+			}
+			if job.Alias != "" {
+				if _, err := client.SetAlias(created.ID, job.Alias); err != nil {
+					return actionResultMsg{message: fmt.Sprintf("Restored without alias %s: %v", job.Alias, err), isError: true}
+				}
+			}
+		}
+
+		return actionResultMsg{message: "Restored " + jobCount(len(jobs))}
+	}
+}
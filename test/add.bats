@@ -315,3 +315,110 @@ load 'test_helper'
   local description=$(echo "$output" | jq -r '.[0].description')
   assert_equal "$description" "Keep this description"
 }
+
+@test "add command with --priority stores priority" {
+  run "$JOB_CLI" add --priority low sleep 300
+  assert_success
+
+  run "$JOB_CLI" list --json
+  assert_success
+  local priority=$(echo "$output" | jq -r '.[0].priority')
+  assert_equal "$priority" "low"
+}
+
+@test "add command with --priority low renices the process" {
+  run "$JOB_CLI" add --priority low sleep 300
+  assert_success
+
+  local pid=$(get_job_field pid)
+  local nice=$(ps -o nice= -p "$pid" | tr -d ' ')
+  assert_equal "$nice" "10"
+}
+
+@test "add command rejects invalid priority" {
+  run "$JOB_CLI" add --priority urgent sleep 300
+  assert_failure
+  assert_output --partial "invalid priority"
+}
+
+@test "add command with --stdin open keeps stdin open" {
+  run "$JOB_CLI" add --stdin open cat
+  assert_success
+
+  local pid=$(get_job_field pid)
+  sleep 0.5
+
+  # cat exits on EOF, so it is only still alive if stdin stays open
+  run kill -0 "$pid"
+  assert_success
+}
+
+@test "add command with --log-format json stores the log format" {
+  run "$JOB_CLI" add --log-format json sleep 300
+  assert_success
+
+  local log_format=$(get_job_field log_format)
+  assert_equal "$log_format" "json"
+}
+
+@test "add command rejects invalid log format" {
+  run "$JOB_CLI" add --log-format yaml sleep 300
+  assert_failure
+  assert_output --partial "invalid log format"
+}
+
+@test "add command with --timestamps stores the option" {
+  run "$JOB_CLI" add --timestamps sleep 300
+  assert_success
+
+  local timestamps=$(get_job_field timestamps)
+  assert_equal "$timestamps" "true"
+}
+
+@test "add command with --combine-output stores the option" {
+  run "$JOB_CLI" add --combine-output sleep 300
+  assert_success
+
+  local combine_output=$(get_job_field combine_output)
+  assert_equal "$combine_output" "true"
+}
+
+@test "add command with --no-combine-output turns the option off" {
+  "$JOB_CLI" add --combine-output sleep 300
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" stop "$job_id"
+
+  run "$JOB_CLI" add --no-combine-output sleep 300
+  assert_success
+
+  # Unset fields are left out of the job's JSON
+  local combine_output=$(get_job_field combine_output)
+  assert_equal "$combine_output" "null"
+}
+
+@test "add command with --keep-head and --keep-tail elides the middle of the output" {
+  run "$JOB_CLI" add --keep-head 100 --keep-tail 100 sh -c 'seq 1 1000'
+  assert_success
+  local job_id=$(get_job_field id)
+
+  "$JOB_CLI" await "$job_id"
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_success
+  assert_line --index 0 "1"
+  assert_output --partial "of output elided]"
+  assert_line "1000"
+  refute_output --partial "500"
+}
+
+@test "add command rejects an invalid --keep-tail" {
+  run "$JOB_CLI" add --keep-tail lots sleep 300
+  assert_failure
+  assert_output --partial "invalid output size"
+}
+
+@test "add command rejects --follow-restarts" {
+  run "$JOB_CLI" add --follow-restarts sleep 300
+  assert_failure
+  assert_output --partial "--follow-restarts can only be used with gob run"
+}
@@ -0,0 +1,53 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "alias command assigns an alias usable as job ID" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" alias "$job_id" web-server
+  assert_success
+  assert_output "Job $job_id is now aliased web-server"
+
+  run "$JOB_CLI" stop web-server
+  assert_success
+
+  local alias=$(get_job_field alias)
+  assert_equal "$alias" "web-server"
+}
+
+@test "list shows the alias next to the job ID" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" alias "$job_id" web
+
+  run "$JOB_CLI" list
+  assert_success
+  assert_output --partial "$job_id (web):"
+}
+
+@test "alias command rejects an alias used by another job" {
+  "$JOB_CLI" add sleep 300
+  local job1=$(get_job_field id)
+  "$JOB_CLI" add sleep 301
+  local job2=$(get_job_field id 0)
+
+  "$JOB_CLI" alias "$job1" web
+
+  run "$JOB_CLI" alias "$job2" web
+  assert_failure
+  assert_output --partial "already used"
+}
+
+@test "alias command with --clear removes the alias" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" alias "$job_id" web
+
+  run "$JOB_CLI" alias web --clear
+  assert_success
+
+  run "$JOB_CLI" stop web
+  assert_failure
+}
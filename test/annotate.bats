@@ -0,0 +1,51 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "annotate command sets the note shown by runs" {
+  "$JOB_CLI" add sh -c "exit 1"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" annotate "$job_id-1" broke after upgrading node
+  assert_success
+  assert_output "Run $job_id-1: broke after upgrading node"
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_success
+  assert_output --partial "# broke after upgrading node"
+
+  run "$JOB_CLI" runs --porcelain "$job_id"
+  assert_success
+  assert_output --regexp "	broke after upgrading node$"
+}
+
+@test "annotate command with --clear removes the note" {
+  "$JOB_CLI" add sh -c "exit 1"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+  "$JOB_CLI" annotate "$job_id-1" flaky
+
+  run "$JOB_CLI" annotate "$job_id-1" --clear
+  assert_success
+  assert_output "Removed note from run $job_id-1"
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_success
+  refute_output --partial "flaky"
+}
+
+@test "annotate command requires a note" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" annotate "$job_id-1"
+  assert_failure
+  assert_output --partial "requires a note"
+}
+
+@test "annotate command fails for an unknown run" {
+  run "$JOB_CLI" annotate nope-1 note
+  assert_failure
+  assert_output --partial "run not found: nope-1"
+}
@@ -0,0 +1,85 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+# write_policy makes the approval policy hold the removals of the agent client
+write_policy() {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.xdg-config"
+  mkdir -p "$XDG_CONFIG_HOME/gob"
+  cat > "$XDG_CONFIG_HOME/gob/policy.toml" <<'TOML'
+clients = ["agent"]
+require = ["remove"]
+timeout = "10s"
+TOML
+}
+
+# wait_for_approval waits until a request is waiting for approval
+wait_for_approval() {
+  for _ in $(seq 1 50); do
+    if [ "$("$JOB_CLI" approvals --json | jq length)" -gt 0 ]; then
+      return 0
+    fi
+    sleep 0.1
+  done
+  return 1
+}
+
+@test "approvals is empty without a policy" {
+  run "$JOB_CLI" approvals
+  assert_success
+  assert_output "No requests waiting for approval"
+
+  run "$JOB_CLI" approve 1
+  assert_failure
+  assert_output --partial "no request waiting for approval: 1"
+}
+
+@test "a held removal runs once approved" {
+  write_policy
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  GOB_CLIENT=agent "$JOB_CLI" remove "$job_id" &
+  local remove_pid=$!
+  wait_for_approval
+
+  run "$JOB_CLI" approvals
+  assert_output --partial "#1"
+  assert_output --partial "agent  remove  $job_id  (remove)"
+
+  # The agent can't approve its own request
+  run env GOB_CLIENT=agent "$JOB_CLI" approve 1
+  assert_failure
+
+  run "$JOB_CLI" approve 1
+  assert_success
+  assert_output "Approved #1: agent remove $job_id"
+
+  wait "$remove_pid"
+  assert_equal "$("$JOB_CLI" list --json | jq length)" "0"
+
+  run "$JOB_CLI" audit --client agent
+  assert_output --partial "[approved by"
+}
+
+@test "a denied removal fails and keeps the job" {
+  write_policy
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  GOB_CLIENT=agent "$JOB_CLI" remove "$job_id" 2>"$BATS_TEST_TMPDIR/remove.err" &
+  local remove_pid=$!
+  wait_for_approval
+
+  run "$JOB_CLI" deny 1
+  assert_success
+
+  run wait "$remove_pid"
+  assert_failure
+  run cat "$BATS_TEST_TMPDIR/remove.err"
+  assert_output --partial "request denied by"
+
+  assert_equal "$(get_job_field id)" "$job_id"
+}
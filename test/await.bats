@@ -151,3 +151,16 @@ load 'test_helper'
   assert_success
   assert_output --partial "Awaiting job"
 }
+
+@test "await command with --follow-restarts follows the new run" {
+  "$JOB_CLI" add -- sh -c 'echo "run output"; sleep 1; echo "run finished"'
+  local job_id=$(get_job_field id)
+
+  (sleep 0.5 && "$JOB_CLI" restart "$job_id" >/dev/null) &
+
+  run "$JOB_CLI" await --follow-restarts "$job_id"
+  assert_success
+  assert_output --partial "job $job_id restarted"
+  assert_output --partial "run finished"
+  assert_output --partial "completed"
+}
@@ -0,0 +1,59 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+# Helper to create a gobfile with a pipeline: build, then test, and a
+# server that ci does not run
+create_gobfile() {
+  mkdir -p .config
+  cat > .config/gobfile.toml <<EOF
+[[job]]
+command = "sleep 300"
+autostart = true
+
+[[job]]
+command = "test -f built"
+needs = ["build"]
+
+[[job]]
+name = "build"
+command = "$1"
+EOF
+}
+
+@test "ci runs jobs after the jobs they need" {
+  create_gobfile "touch built"
+
+  run "$JOB_CLI" ci --report report.json
+  assert_success
+  assert_output --partial "2 of 2 commands succeeded"
+  refute_output --partial "sleep 300"
+
+  assert_equal "$(jq -r .status report.json)" "passed"
+  assert_equal "$(jq -r '.jobs | map(.name) | join(",")' report.json)" "test -f built,build"
+}
+
+@test "ci skips the jobs that need a failed job" {
+  create_gobfile "false"
+
+  run "$JOB_CLI" ci -k --report report.json
+  assert_failure
+  assert_output --partial "- test -f built"
+
+  assert_equal "$(jq -r .status report.json)" "failed"
+  assert_equal "$(jq -r '.jobs[0].status' report.json)" "skipped"
+  assert_equal "$(jq -r '.jobs[1].exit_code' report.json)" "1"
+}
+
+@test "ci fails on unknown needs" {
+  mkdir -p .config
+  cat > .config/gobfile.toml <<EOF
+[[job]]
+command = "make test"
+needs = ["build"]
+EOF
+
+  run "$JOB_CLI" ci
+  assert_failure
+  assert_output --partial 'job "make test" needs unknown job "build"'
+}
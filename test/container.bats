@@ -0,0 +1,52 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+# A fake container runtime: records its arguments and runs the command after
+# the image on the host
+setup_fake_runtime() {
+  cat > fake-runtime <<'SH'
+#!/bin/sh
+echo "$*" >> "$(dirname "$0")/runtime.log"
+[ "$1" = run ] || exit 0
+shift
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --rm|--init|--interactive) shift ;;
+    --*) shift 2 ;;
+    *) break ;;
+  esac
+done
+shift # the image
+exec "$@"
+SH
+  chmod +x fake-runtime
+  export GOB_CONTAINER_RUNTIME="$PWD/fake-runtime"
+}
+
+@test "run --in-container runs the command with the container runtime" {
+  setup_fake_runtime
+
+  run "$JOB_CLI" run --in-container node:20 echo hello
+  assert_success
+
+  run cat runtime.log
+  assert_output --regexp "^run --rm --init --name gob-[^ ]+-1 --network host --volume $PWD:$PWD --workdir $PWD .*node:20 echo hello$"
+
+  run "$JOB_CLI" stdout "$(get_job_field id)"
+  assert_output "hello"
+  assert_equal "$(get_job_field container)" "node:20"
+}
+
+@test "stopping a job in a container stops the container" {
+  setup_fake_runtime
+
+  "$JOB_CLI" add --in-container alpine:3 sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" stop "$job_id"
+  assert_success
+
+  run cat runtime.log
+  assert_output --partial "stop --time 10 gob-$job_id-1"
+}
@@ -200,3 +200,114 @@ load test_helper
   # Same daemon
   assert_equal "$pid1" "$pid2"
 }
+
+@test "daemon runs in the foreground for service managers" {
+  "$JOB_CLI" daemon --foreground >/dev/null 2>&1 3>&- &
+  local pid=$!
+
+  local runtime_dir=$(get_runtime_dir)
+  for i in {1..50}; do
+    [ -S "$runtime_dir/daemon.sock" ] && break
+    sleep 0.1
+  done
+
+  run "$JOB_CLI" ping
+  assert_success
+  assert_equal "$(cat "$runtime_dir/daemon.pid")" "$pid"
+
+  kill "$pid"
+  wait "$pid" || true
+}
+
+@test "daemon uses the socket passed by systemd and leaves it in place" {
+  local runtime_dir=$(get_runtime_dir)
+  mkdir -p "$runtime_dir"
+  chmod 700 "$runtime_dir"
+
+  # Listen on the socket and start the daemon with it as fd 3, like systemd
+  python3 - "$runtime_dir/daemon.sock" "$JOB_CLI" <<'PY'
+import os, socket, subprocess, sys
+s = socket.socket(socket.AF_UNIX)
+s.bind(sys.argv[1])
+s.listen()
+os.dup2(s.fileno(), 3)
+subprocess.Popen(["sh", "-c", 'export LISTEN_PID=$$ LISTEN_FDS=1; exec "$0" daemon --foreground', sys.argv[2]],
+                 pass_fds=(3,), stdin=subprocess.DEVNULL, stdout=subprocess.DEVNULL, stderr=subprocess.DEVNULL)
+PY
+
+  run "$JOB_CLI" ping
+  assert_success
+  assert_output "pong"
+
+  run "$JOB_CLI" shutdown
+  assert_success
+  [ -S "$runtime_dir/daemon.sock" ]
+  rm -f "$runtime_dir/daemon.sock"
+}
+
+@test "daemon install --print shows the systemd units" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.config"
+
+  run "$JOB_CLI" daemon install --print
+  assert_success
+  assert_output --partial "ListenStream=$(get_runtime_dir)/daemon.sock"
+  assert_output --partial "daemon --foreground"
+  assert_output --partial "KillMode=process"
+}
+
+@test "daemon status shows the service is not installed" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.config"
+
+  run "$JOB_CLI" daemon status
+  assert_success
+  assert_line --index 0 "Service:  systemd user service, not installed (gob daemon install)"
+  assert_line "Daemon:   not running"
+}
+
+@test "daemon status shows the health of a running daemon" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.config"
+  "$JOB_CLI" add sleep 300
+
+  run "$JOB_CLI" daemon status
+  assert_success
+  assert_line --partial "Daemon:   running, pid $(cat "$(get_runtime_dir)/daemon.pid")"
+  assert_line "Jobs:     1 (1 running), 1 run"
+  assert_line --partial "Database: "
+  assert_line --partial "presence watcher running"
+
+  run "$JOB_CLI" daemon status --json
+  assert_success
+  assert_equal "$(echo "$output" | jq -r '.running')" "true"
+  assert_equal "$(echo "$output" | jq -r '.health.jobs')" "1"
+  assert_equal "$(echo "$output" | jq -r '.health.components | length')" "3"
+}
+
+@test "daemon uninstall fails when not installed" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.config"
+
+  run "$JOB_CLI" daemon uninstall
+  assert_failure
+  assert_output --partial "the daemon is not installed as a systemd user service"
+}
+
+@test "daemon logs shows the daemon's own log" {
+  run "$JOB_CLI" ping
+  assert_success
+
+  run "$JOB_CLI" daemon logs
+  assert_success
+  assert_output --partial "daemon started"
+
+  run "$JOB_CLI" daemon logs --level error
+  assert_success
+  refute_output --partial "daemon started"
+
+  run "$JOB_CLI" daemon logs --path
+  assert_success
+  assert_output "$(get_runtime_dir)/daemon.log"
+}
+
+@test "daemon logs rejects an invalid level" {
+  run "$JOB_CLI" daemon logs --level loud
+  assert_failure
+}
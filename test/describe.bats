@@ -0,0 +1,46 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "describe command sets the description shown by list" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" describe "$job_id" Dev server
+  assert_success
+  assert_output "Job $job_id: Dev server"
+
+  local description=$(get_job_field description)
+  assert_equal "$description" "Dev server"
+
+  run "$JOB_CLI" list
+  assert_success
+  assert_output --partial "Dev server"
+}
+
+@test "describe command with --clear removes the description" {
+  "$JOB_CLI" add --description "Dev server" sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" describe "$job_id" --clear
+  assert_success
+  assert_output "Removed description from job $job_id"
+
+  run "$JOB_CLI" list --compact
+  assert_success
+  refute_output --partial "Dev server"
+}
+
+@test "list --compact includes the description" {
+  "$JOB_CLI" add --description "Dev server" sleep 300
+
+  run "$JOB_CLI" list --compact
+  assert_success
+  assert_output --partial '"description":"Dev server"'
+}
+
+@test "describe command fails for unknown job" {
+  run "$JOB_CLI" describe nonexistent Dev server
+  assert_failure
+  assert_output --partial "job not found"
+}
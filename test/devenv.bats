@@ -0,0 +1,46 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+# A fake direnv: records its arguments and runs the command with a variable
+# the .envrc would set
+setup_fake_direnv() {
+  mkdir bin
+  cat > bin/direnv <<'SH'
+#!/bin/sh
+echo "$*" >> "$(dirname "$0")/direnv.log"
+shift 2
+PROJECT_ENV=loaded exec "$@"
+SH
+  chmod +x bin/direnv
+  export PATH="$PWD/bin:$PATH"
+}
+
+@test "run --dev-env direnv runs the command in the project's environment" {
+  setup_fake_direnv
+  touch .envrc
+  mkdir web
+  cd web
+
+  run "$JOB_CLI" run --dev-env direnv sh -c 'echo "env: $PROJECT_ENV"'
+  assert_success
+
+  run "$JOB_CLI" stdout "$(get_job_field id)"
+  assert_output "env: loaded"
+  assert_equal "$(get_job_field dev_env)" "direnv"
+
+  run cat ../bin/direnv.log
+  assert_output --partial "exec $BATS_TEST_TMPDIR sh -c"
+}
+
+@test "run --dev-env fails without the project file" {
+  run "$JOB_CLI" run --dev-env nix make
+  assert_failure
+  assert_output --partial "no flake.nix found"
+}
+
+@test "add rejects an unknown dev environment" {
+  run "$JOB_CLI" add --dev-env conda make
+  assert_failure
+  assert_output --partial "invalid dev environment \"conda\""
+}
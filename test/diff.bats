@@ -0,0 +1,67 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "diff command requires job ID argument" {
+  run "$JOB_CLI" diff
+  assert_failure
+  assert_output --partial "accepts 1 arg(s)"
+}
+
+@test "diff command shows what a failing run printed differently" {
+  "$JOB_CLI" add --shell 'echo compiling; if [ -f broken ]; then echo "error: missing semicolon"; exit 1; fi; echo done'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  touch broken
+  "$JOB_CLI" start "$job_id"
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" diff "$job_id"
+  assert_success
+  assert_output --partial "--- ${job_id}-1"
+  assert_output --partial "+++ ${job_id}-2"
+  assert_output --partial "@@ -1,2 +1,2 @@"
+  assert_output --partial " compiling"
+  assert_output --partial "-done"
+  assert_output --partial "+error: missing semicolon"
+}
+
+@test "diff command says when the outputs are the same" {
+  "$JOB_CLI" add echo hello
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  "$JOB_CLI" start "$job_id"
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" diff "$job_id"
+  assert_success
+  assert_output "No differences"
+}
+
+@test "diff command fails without a successful run to compare with" {
+  "$JOB_CLI" add --shell 'echo nope; exit 1'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" diff "$job_id"
+  assert_failure
+  assert_output --partial "no successful run before ${job_id}-1"
+}
+
+@test "diff command compares given runs with --run and --against" {
+  "$JOB_CLI" add --shell 'cat count 2>/dev/null; echo x >> count'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+  "$JOB_CLI" start "$job_id"
+  wait_for_job_to_stop "$job_id"
+  "$JOB_CLI" start "$job_id"
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" diff "$job_id" --run "${job_id}-2" --against "${job_id}-3"
+  assert_success
+  assert_output --partial "--- ${job_id}-3"
+  assert_output --partial "+++ ${job_id}-2"
+  assert_output --partial "-x"
+}
@@ -165,3 +165,92 @@ load 'test_helper'
   assert_success
   assert_output --partial "GOB_MULTI_TEST=value_two"
 }
+
+@test "start --env-from previous reuses the previous run's environment" {
+  export GOB_TEST_VAR="first"
+  "$JOB_CLI" add -- sh -c 'echo "GOB_TEST_VAR=$GOB_TEST_VAR"'
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" await "$job_id" || true
+
+  export GOB_TEST_VAR="second"
+  run "$JOB_CLI" start --env-from previous "$job_id"
+  assert_success
+  "$JOB_CLI" await "$job_id" || true
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_output --partial "GOB_TEST_VAR=first"
+
+  run "$JOB_CLI" runs --json "$job_id"
+  local env_source=$(echo "$output" | jq -r ".[] | select(.id == \"${job_id}-2\") | .env_source")
+  assert_equal "$env_source" "previous"
+}
+
+@test "start --env-from stored uses the job's --env overrides only" {
+  export GOB_TEST_VAR="client"
+  "$JOB_CLI" add --env GOB_STORED_VAR=stored -- sh -c 'echo "GOB_TEST_VAR=${GOB_TEST_VAR:-NOTSET} GOB_STORED_VAR=$GOB_STORED_VAR"'
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" await "$job_id" || true
+
+  run "$JOB_CLI" start --env-from stored "$job_id"
+  assert_success
+  "$JOB_CLI" await "$job_id" || true
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_output --partial "GOB_TEST_VAR=NOTSET GOB_STORED_VAR=stored"
+}
+
+@test "start rejects an unknown --env-from" {
+  run "$JOB_CLI" start --env-from elsewhere abc
+  assert_failure
+  assert_output --partial "invalid environment source"
+}
+
+@test "denied variables are not passed to the job" {
+  export GOB_TEST_SECRET="secret_value"
+  export GOB_TEST_KEPT="kept_value"
+  export GOB_ENV_DENY="GOB_TEST_SECRET"
+
+  run "$JOB_CLI" add -- sh -c 'echo "SECRET=${GOB_TEST_SECRET:-NOTSET} KEPT=$GOB_TEST_KEPT"'
+  assert_success
+
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" await "$job_id" || true
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_success
+  assert_output --partial "SECRET=NOTSET KEPT=kept_value"
+}
+
+@test "env shows the dropped variables and why" {
+  export GOB_TEST_BIG="$(printf '%*s' 2000 '' | tr ' ' x)"
+  export GOB_ENV_DENY="GOB_TEST_SECRET*"
+  export GOB_TEST_SECRET_TOKEN="secret"
+  export GOB_ENV_MAX_SIZE="1G"
+
+  run "$JOB_CLI" env
+  assert_success
+  assert_output --regexp "GOB_TEST_SECRET_TOKEN +[0-9]+ B +denied by GOB_TEST_SECRET\*"
+  refute_output --partial "GOB_TEST_BIG"
+
+  GOB_ENV_ALLOW="PATH" GOB_ENV_MAX_SIZE="100" run "$JOB_CLI" env --json
+  assert_success
+  [ "$(echo "$output" | jq -r '.dropped[] | select(.name == "GOB_TEST_BIG") | .reason')" = "not allowed" ]
+  [ "$(echo "$output" | jq '.filter.max_bytes')" = "100" ]
+}
+
+@test "env rejects invalid settings" {
+  GOB_ENV_MAX_SIZE="lots" run "$JOB_CLI" env
+  assert_failure
+  assert_output --partial "invalid GOB_ENV_MAX_SIZE"
+}
+
+@test "runs get their job ID, run ID and log directory" {
+  export GOB_JOB_ID=outer GOB_RUN_ID=outer-1
+  "$JOB_CLI" add sh -c 'echo "$GOB_JOB_ID $GOB_RUN_ID $GOB_LOG_DIR"'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_success
+  assert_output "$job_id $job_id-1 $(dirname "$(get_job_field stdout_path)")"
+}
@@ -4,7 +4,7 @@ load 'test_helper'
 
 @test "events command outputs JSON for job_added event" {
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -30,7 +30,7 @@ load 'test_helper'
 
 @test "events command outputs JSON for job_stopped event when process exits naturally" {
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -61,7 +61,7 @@ load 'test_helper'
   local job_id=$(get_job_field id)
 
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -95,7 +95,7 @@ load 'test_helper'
   assert_success
 
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -128,7 +128,7 @@ load 'test_helper'
   assert_success
 
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -156,7 +156,7 @@ load 'test_helper'
   mkdir -p "$BATS_TEST_TMPDIR/subdir"
 
   # Start events command from main directory (no --all flag)
-  "$JOB_CLI" events > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -183,7 +183,7 @@ load 'test_helper'
   mkdir -p "$BATS_TEST_TMPDIR/subdir"
 
   # Start events command with --all flag
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -208,7 +208,7 @@ load 'test_helper'
 
 @test "events command receives multiple events in sequence" {
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -245,7 +245,7 @@ load 'test_helper'
   local port=$(get_random_port)
 
   # Start events command in background
-  "$JOB_CLI" events --all > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  "$JOB_CLI" events --all --json > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
   events_pid=$!
 
   # Give it time to subscribe
@@ -272,3 +272,85 @@ load 'test_helper'
   assert_output --partial "\"job_id\":\"$job_id\""
   assert_output --partial "\"port\":$port"
 }
+
+@test "events command with --snapshot starts with the current jobs" {
+  run "$JOB_CLI" add sleep 300
+  assert_success
+  local job_id=$(get_job_field id)
+
+  "$JOB_CLI" events --json --snapshot > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  events_pid=$!
+  sleep 0.5
+  kill $events_pid 2>/dev/null || true
+  wait $events_pid 2>/dev/null || true
+
+  run cat "$BATS_TEST_TMPDIR/events_output.txt"
+  assert_line --index 0 --partial '"type":"snapshot"'
+  assert_output --partial "\"id\":\"$job_id\""
+}
+
+@test "events command with --since prints recorded events and exits" {
+  run "$JOB_CLI" add sleep 300
+  assert_success
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" events --since 1h
+  assert_success
+  assert_output --partial "#1  job_added  $job_id  sleep 300"
+
+  run "$JOB_CLI" events --since 1h --json
+  assert_success
+  assert_output --partial '"seq":1,'
+  assert_output --partial '"type":"job_added"'
+}
+
+@test "events command with --after skips older events" {
+  "$JOB_CLI" add sleep 300
+  "$JOB_CLI" add sleep 301
+
+  run "$JOB_CLI" events --after 2
+  assert_success
+  refute_output --partial "sleep 300"
+  assert_output --partial "sleep 301"
+}
+
+@test "events are kept across daemon restarts" {
+  "$JOB_CLI" add true
+  "$JOB_CLI" shutdown
+
+  "$JOB_CLI" add sleep 300
+  run "$JOB_CLI" events --since 1h
+  assert_success
+  assert_output --partial "job_added"
+  assert_output --partial "  true"
+  assert_output --partial "  sleep 300"
+}
+
+@test "events --job and --type only show the matching events" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" add sleep 301
+  local other_id=$(get_job_field id)
+
+  "$JOB_CLI" events --json --job "$job_id" --type job_stopped > "$BATS_TEST_TMPDIR/events_output.txt" 2>&1 &
+  events_pid=$!
+  sleep 0.3
+
+  "$JOB_CLI" stop "$other_id"
+  "$JOB_CLI" stop "$job_id"
+  sleep 0.3
+
+  kill $events_pid 2>/dev/null || true
+  wait $events_pid 2>/dev/null || true
+
+  run cat "$BATS_TEST_TMPDIR/events_output.txt"
+  assert_equal "${#lines[@]}" "1"
+  assert_output --partial '"type":"job_stopped"'
+  assert_output --partial "\"job_id\":\"$job_id\""
+}
+
+@test "events rejects unknown event types" {
+  run "$JOB_CLI" events --type job_exploded
+  assert_failure
+  assert_output --partial "unknown event type: job_exploded"
+}
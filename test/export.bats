@@ -0,0 +1,38 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "export command writes runs as JSON lines" {
+  "$JOB_CLI" add sh -c "exit 3"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" export --job "$job_id"
+  assert_success
+  assert_output --partial "\"run_id\":\"${job_id}-1\""
+  assert_output --partial '"exit_code":3'
+  refute_output --partial "stdout_path"
+}
+
+@test "export command writes runs as CSV with log paths" {
+  "$JOB_CLI" add sh -c "exit 0"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" export --format csv --log-paths
+  assert_success
+  assert_line --index 0 "run_id,job_id,command,workdir,status,exit_code,started_at,stopped_at,duration_ms,log_bytes,note,stdout_path,stderr_path"
+  assert_line --index 1 --regexp "^${job_id}-1,${job_id},sh -c exit 0,.*,stopped,0,.*${job_id}-1\.stderr\.log$"
+}
+
+@test "export command rejects an unknown format" {
+  run "$JOB_CLI" export --format xml
+  assert_failure
+  assert_output --partial "invalid format: xml"
+}
+
+@test "export command fails for an unknown job" {
+  run "$JOB_CLI" export --job nope
+  assert_failure
+  assert_output --partial "job not found: nope"
+}
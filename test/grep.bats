@@ -0,0 +1,54 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "grep command requires a pattern" {
+  run "$JOB_CLI" grep
+  assert_failure
+  assert_output --partial "accepts 1 arg(s)"
+}
+
+@test "grep command finds lines in past runs" {
+  "$JOB_CLI" run sh -c "echo starting; echo 'error: connection refused' >&2"
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" grep "connection refused"
+  assert_success
+  assert_output --regexp "^${job_id}-1  .*  stderr:1: error: connection refused$"
+}
+
+@test "grep command searches oldest run first" {
+  "$JOB_CLI" run sh -c "echo boom"
+  "$JOB_CLI" run sh -c "echo boom"
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" grep boom
+  assert_success
+  assert_line --index 0 --partial "${job_id}-1"
+  assert_line --index 1 --partial "${job_id}-2"
+}
+
+@test "grep command with no matches" {
+  "$JOB_CLI" run echo hello
+
+  run "$JOB_CLI" grep nothing
+  assert_success
+  assert_output "No matches found"
+}
+
+@test "grep command filters by job" {
+  "$JOB_CLI" run echo needle one
+  local first_id=$(get_job_field id)
+  "$JOB_CLI" run echo needle two
+
+  run "$JOB_CLI" grep --job "$first_id" needle
+  assert_success
+  assert_output --partial "needle one"
+  refute_output --partial "needle two"
+}
+
+@test "grep command rejects invalid patterns" {
+  run "$JOB_CLI" grep "("
+  assert_failure
+  assert_output --partial "invalid pattern"
+}
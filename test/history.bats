@@ -0,0 +1,52 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "history command shows no runs" {
+  run "$JOB_CLI" history
+  assert_success
+  assert_output "No runs found"
+}
+
+@test "history command shows runs across jobs" {
+  "$JOB_CLI" run true
+  local first_id=$(get_job_field id)
+  "$JOB_CLI" run sh -c "exit 3"
+  local second_id=$(get_job_field id)
+
+  run "$JOB_CLI" history
+  assert_success
+  assert_line --index 0 --regexp "^${second_id}-1 .*✗ \(3\).*sh -c exit 3"
+  assert_line --index 1 --regexp "^${first_id}-1 .*✓ \(0\).*true"
+}
+
+@test "history command paginates" {
+  "$JOB_CLI" run true
+  "$JOB_CLI" run true
+  "$JOB_CLI" run true
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" history --limit 2
+  assert_success
+  assert_line --index 0 --partial "${job_id}-3"
+  assert_line --index 1 --partial "${job_id}-2"
+  assert_output --partial "Showing 1-2 of 3 runs (use --offset 2 for more)"
+
+  run "$JOB_CLI" history --limit 2 --offset 2
+  assert_success
+  assert_output --partial "${job_id}-1"
+  refute_output --partial "${job_id}-2"
+}
+
+@test "history command only shows the current directory unless --all" {
+  mkdir -p "$BATS_TEST_TMPDIR/subdir"
+  (cd "$BATS_TEST_TMPDIR/subdir" && "$JOB_CLI" run true)
+  local job_id=$("$JOB_CLI" list --all --json | jq -r ".[0].id")
+
+  run "$JOB_CLI" history
+  assert_output "No runs found"
+
+  run "$JOB_CLI" history --all
+  assert_success
+  assert_output --partial "${job_id}-1"
+}
@@ -0,0 +1,85 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+write_gobfile() {
+  mkdir -p project/.config
+  cat > project/.config/gobfile.toml
+}
+
+@test "hook cd starts on_enter jobs when entering a project" {
+  write_gobfile <<'TOML'
+[[job]]
+command = "sleep 300"
+on_enter = true
+
+[[job]]
+command = "sleep 400"
+TOML
+  mkdir -p project/src
+  cd project/src
+
+  run "$JOB_CLI" hook cd --pid $$
+  assert_success
+  assert_output "gob: started sleep 300"
+
+  cd ..
+  run "$JOB_CLI" list
+  assert_output --partial "sleep 300"
+  refute_output --partial "sleep 400"
+}
+
+@test "hook cd does not start jobs again while the shell stays in the project" {
+  write_gobfile <<'TOML'
+[[job]]
+command = "sleep 300"
+on_enter = true
+TOML
+  cd project
+  "$JOB_CLI" hook cd --pid $$
+
+  job_id=$(get_job_field id)
+  "$JOB_CLI" stop "$job_id"
+
+  run "$JOB_CLI" hook cd --pid $$
+  assert_success
+  assert_output ""
+  assert_equal "$(get_job_field status)" "stopped"
+}
+
+@test "hook cd stops started jobs once the project is left" {
+  write_gobfile <<'TOML'
+stop_on_leave = "0s"
+
+[[job]]
+command = "sleep 300"
+on_enter = true
+TOML
+  cd project
+  "$JOB_CLI" hook cd --pid $$
+  job_id=$(get_job_field id)
+
+  cd ..
+  "$JOB_CLI" hook cd --pid $$
+
+  cd project
+  wait_for_job_to_stop "$job_id" 15
+}
+
+@test "hook cd outside a project does not start the daemon" {
+  run "$JOB_CLI" hook cd --pid $$
+  assert_success
+  assert_output ""
+  [ ! -e "$(get_runtime_dir)/daemon.sock" ]
+}
+
+@test "hook cd rejects an invalid stop_on_leave" {
+  write_gobfile <<'TOML'
+stop_on_leave = "soon"
+TOML
+  cd project
+
+  run "$JOB_CLI" hook cd --pid $$
+  assert_failure
+  assert_output --partial "invalid stop_on_leave: soon"
+}
@@ -0,0 +1,52 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "ipc requires --stdio" {
+  run "$JOB_CLI" ipc
+  assert_failure
+  assert_output --partial "--stdio is required"
+}
+
+@test "ipc writes a handshake with the protocol version and capabilities" {
+  run bash -c "'$JOB_CLI' ipc --stdio < /dev/null | head -1"
+  assert_success
+  assert_output --partial '"type":"handshake"'
+  assert_output --partial '"protocol_version":1'
+  assert_output --partial '"subscribe"'
+}
+
+@test "ipc answers requests with their id" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+
+  run bash -c "echo '{\"id\":\"a\",\"type\":\"list\",\"payload\":{\"workdir\":\"$PWD\"}}' | '$JOB_CLI' ipc --stdio"
+  assert_success
+  assert_line --index 1 --partial '"id":"a","type":"response"'
+  assert_line --index 1 --partial "\"id\":\"$job_id\""
+}
+
+@test "ipc answers invalid lines with an error" {
+  run bash -c "printf 'not json\n{\"id\":1,\"type\":\"nope\"}\n' | '$JOB_CLI' ipc --stdio"
+  assert_success
+  assert_output --partial '"error":"invalid request'
+  assert_output --partial '"id":1,"type":"response","response":{"success":false,"error":"unknown request type: nope"}'
+}
+
+@test "ipc streams events of a subscription until unsubscribed" {
+  {
+    echo '{"id":7,"type":"subscribe","payload":{}}'
+    sleep 0.5
+    "$JOB_CLI" add sleep 300 > /dev/null
+    sleep 0.5
+    echo '{"id":8,"type":"unsubscribe","payload":{"id":7}}'
+    sleep 0.5
+  } | "$JOB_CLI" ipc --stdio > "$BATS_TEST_TMPDIR/ipc_output.txt"
+
+  run cat "$BATS_TEST_TMPDIR/ipc_output.txt"
+  assert_output --partial '"id":7,"type":"response","response":{"success":true'
+  assert_output --partial '"id":7,"type":"event","event":{'
+  assert_output --partial '"type":"job_added"'
+  assert_line --index 4 '{"id":7,"type":"end"}'
+  assert_line --index 5 '{"id":8,"type":"response","response":{"success":true}}'
+}
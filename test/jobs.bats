@@ -0,0 +1,50 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "jobs export writes the jobs of the project as a gobfile" {
+  "$JOB_CLI" add --description "Dev server" --tag web --memory-limit 512M sleep 300
+  mkdir web
+  (cd web && "$JOB_CLI" add sh -c "sleep 300")
+
+  run "$JOB_CLI" jobs export
+  assert_success
+  assert_output --partial "command = 'sleep 300'"
+  assert_output --partial "description = 'Dev server'"
+  assert_output --partial "memory_limit = '512M'"
+  assert_output --partial "workdir = 'web'"
+  assert_output --partial "shell = 'sh'"
+}
+
+@test "jobs import creates the exported jobs in another directory" {
+  "$JOB_CLI" add --description "Dev server" --tag web sleep 300
+  "$JOB_CLI" jobs export > jobs.toml
+
+  mkdir clone
+  cd clone
+  run "$JOB_CLI" jobs import ../jobs.toml
+  assert_success
+  assert_output --regexp "^Imported [^:]+: sleep 300$"
+
+  assert_equal "$(get_job_field description)" "Dev server"
+  assert_equal "$(get_job_field status)" "stopped"
+  assert_equal "$(get_job_field 'tags[0]')" "web"
+}
+
+@test "jobs import rejects invalid settings before creating jobs" {
+  cat > jobs.toml <<'TOML'
+[[job]]
+command = "sleep 300"
+
+[[job]]
+command = "make"
+priority = "urgent"
+TOML
+
+  run "$JOB_CLI" jobs import jobs.toml
+  assert_failure
+  assert_output --partial "invalid settings for 'make'"
+
+  run "$JOB_CLI" list --json
+  assert_output "[]"
+}
@@ -0,0 +1,50 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "kill-orphans finds nothing without orphans" {
+  "$JOB_CLI" add sleep 300
+
+  run "$JOB_CLI" kill-orphans
+  assert_success
+  assert_output "No orphaned processes found"
+
+  run "$JOB_CLI" kill-orphans --json
+  assert_success
+  assert_output "[]"
+}
+
+@test "kill-orphans kills processes of runs that are gone" {
+  GOB_RUN_ID=gone-1 sleep 300 >/dev/null 2>&1 3>&- &
+  local pid=$!
+
+  run "$JOB_CLI" kill-orphans
+  assert_success
+  assert_output --partial "Run gone-1 (job removed)"
+  assert_output --partial "$pid  sleep 300"
+  assert_output --partial "Use --kill or --adopt"
+
+  run "$JOB_CLI" kill-orphans --kill
+  assert_success
+  assert_output --partial "Killed run gone-1 (PID $pid)"
+
+  run "$JOB_CLI" kill-orphans
+  assert_output "No orphaned processes found"
+}
+
+@test "kill-orphans adopts the process as a run of its job" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_job_to_stop "$job_id"
+
+  GOB_RUN_ID="$job_id-1" sleep 301 >/dev/null 2>&1 3>&- &
+  local pid=$!
+
+  run "$JOB_CLI" kill-orphans --adopt
+  assert_success
+  assert_output --partial "Adopted PID $pid as job $job_id: sleep 300"
+  assert_equal "$(get_job_field pid)" "$pid"
+
+  "$JOB_CLI" stop "$job_id"
+}
@@ -228,3 +228,39 @@ load 'test_helper'
   # Should NOT have exit code in parentheses for killed process
   assert_output --regexp "stopped: sleep 300"
 }
+
+@test "list command with --porcelain prints tab-separated fields" {
+  "$JOB_CLI" add sh -c "exit 3"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" list --porcelain
+  assert_success
+  assert_output --regexp "^${job_id}	stopped	3	([0-9]+|-)	${PWD}	sh -c exit 3$"
+}
+
+@test "list command with --porcelain prints nothing without jobs" {
+  run "$JOB_CLI" list --porcelain
+  assert_success
+  assert_output ""
+}
+
+@test "list command shows and filters jobs started by other clients" {
+  GOB_CLIENT=agent "$JOB_CLI" add sleep 300
+  "$JOB_CLI" add sleep 301
+
+  run "$JOB_CLI" list
+  assert_success
+  assert_output --partial "started by agent"
+  [ "$(echo "$output" | grep -c "started by")" -eq 1 ]
+
+  run "$JOB_CLI" list --started-by agent --json
+  assert_success
+  assert_equal "$(echo "$output" | jq -r '.[].command | join(" ")')" "sleep 300"
+  assert_equal "$(echo "$output" | jq -r '.[0].created_by')" "agent"
+  assert_equal "$(echo "$output" | jq -r '.[0].started_via')" "cli"
+
+  run "$JOB_CLI" list --started-by 'age*' --columns id,started_by
+  assert_success
+  assert_output --partial "agent"
+}
@@ -119,3 +119,118 @@ load 'test_helper'
   run cat "$BATS_TEST_TMPDIR/logs_output.txt"
   assert_output --partial "[$job_id] Dynamic job output"
 }
+
+# --- Level filter (--level) ---
+
+@test "logs --level shows only lines at or above the level for JSON logs" {
+  "$JOB_CLI" add --log-format json sh -c 'echo "{\"level\":\"info\",\"msg\":\"started\"}"; echo "{\"level\":\"error\",\"msg\":\"boom\"}"; echo "{\"level\":\"debug\",\"msg\":\"details\"}"; sleep 300'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "details"
+
+  run "$JOB_CLI" logs --level warn "$job_id"
+  assert_success
+  assert_output '{"level":"error","msg":"boom"}'
+}
+
+@test "logs --level fails for a job without JSON logs" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" logs --level error "$job_id"
+  assert_failure
+  assert_output --partial "does not have JSON logs"
+}
+
+@test "logs --level rejects unknown levels" {
+  run "$JOB_CLI" logs --level loud
+  assert_failure
+  assert_output --partial "invalid log level"
+}
+
+# --- Timestamps (--timestamps) ---
+
+@test "logs --timestamps prefixes lines of jobs added with --timestamps" {
+  "$JOB_CLI" add --timestamps sh -c 'echo first; echo second; sleep 300'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "second"
+
+  run "$JOB_CLI" logs --timestamps "$job_id"
+  assert_success
+  assert_line --index 0 --regexp '^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9:.]+([+-][0-9:]+|Z) first$'
+  assert_line --index 1 --regexp ' second$'
+
+  # The raw log file is unchanged
+  run cat "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log"
+  assert_output "first
+second"
+}
+
+@test "logs --timestamps cannot be combined with --follow" {
+  run "$JOB_CLI" logs --follow --timestamps
+  assert_failure
+  assert_output --partial "cannot be used with --follow"
+}
+
+# --- Markers (--markers) ---
+
+@test "logs --markers inserts the run's markers between its lines" {
+  "$JOB_CLI" add --shell 'trap "echo reloaded" HUP; echo ready; while true; do sleep 0.1; done'
+  local job_id=$(get_job_field id)
+  local log="$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log"
+  wait_for_log_content "$log" "ready"
+
+  "$JOB_CLI" reload "$job_id"
+  wait_for_log_content "$log" "reloaded"
+
+  run "$JOB_CLI" logs --markers "$job_id"
+  assert_success
+  assert_line --index 0 --regexp '^\[gob\] .* started$'
+  assert_line --index 1 "ready"
+  assert_line --index 2 --regexp '^\[gob\] .* reloaded \(hangup\)$'
+  assert_line --index 3 "reloaded"
+
+  # Without --markers the log is unchanged
+  run "$JOB_CLI" logs "$job_id"
+  assert_output "ready
+reloaded"
+}
+
+# --- Combined output (--combine-output) ---
+
+@test "logs of a job with --combine-output keep stdout and stderr in order" {
+  "$JOB_CLI" add --combine-output sh -c 'echo out1; echo err1 >&2; echo out2; sleep 300'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "out2"
+
+  run "$JOB_CLI" logs "$job_id"
+  assert_success
+  assert_line --index 0 "out1"
+  assert_line --index 1 "err1"
+  assert_line --index 2 "out2"
+}
+
+# --- Several jobs (--ids) ---
+
+@test "logs --ids dumps the listed jobs in order" {
+  "$JOB_CLI" add echo first
+  local first_id=$(get_job_field id)
+  "$JOB_CLI" add echo second
+  local second_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${first_id}-1.stdout.log" "first"
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${second_id}-1.stdout.log" "second"
+
+  run "$JOB_CLI" logs --ids "$second_id,$first_id"
+  assert_success
+  assert_line --index 0 "second"
+  assert_line --index 1 "first"
+}
+
+@test "logs --ids cannot be combined with a job ID argument" {
+  run "$JOB_CLI" logs --ids abc def
+  assert_failure
+  assert_output --partial "--ids cannot be used with a job ID argument"
+}
@@ -0,0 +1,70 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "pin marks the job in list" {
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" pin "$job_id"
+  assert_success
+  assert_output "Pinned job $job_id"
+
+  run "$JOB_CLI" list
+  assert_output --partial "$job_id (pinned):"
+
+  assert_equal "$(get_job_field pinned)" "true"
+}
+
+@test "remove refuses a pinned job without --force" {
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+  "$JOB_CLI" pin "$job_id"
+
+  run "$JOB_CLI" remove "$job_id"
+  assert_failure
+  assert_output --partial "cannot remove pinned job: $job_id"
+
+  run "$JOB_CLI" remove --force "$job_id"
+  assert_success
+}
+
+@test "remove --match skips pinned jobs" {
+  "$JOB_CLI" add sh -c 'exit 0'
+  local pinned_id=$(get_job_field id)
+  "$JOB_CLI" add sh -c 'exit 1'
+  local other_id=$(get_job_field id)
+  wait_for_job_to_stop "$pinned_id"
+  wait_for_job_to_stop "$other_id"
+  "$JOB_CLI" pin "$pinned_id"
+
+  run "$JOB_CLI" remove --match "sh *"
+  assert_success
+  assert_output --partial "Removed job $other_id"
+  refute_output --partial "$pinned_id"
+}
+
+@test "remove --match --force includes pinned jobs" {
+  "$JOB_CLI" add sh -c 'exit 0'
+  local pinned_id=$(get_job_field id)
+  wait_for_job_to_stop "$pinned_id"
+  "$JOB_CLI" pin "$pinned_id"
+
+  run "$JOB_CLI" remove --match "sh *" --force
+  assert_success
+  assert_output --partial "Removed job $pinned_id"
+}
+
+@test "unpin removes the pin" {
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  "$JOB_CLI" pin "$job_id"
+
+  run "$JOB_CLI" unpin "$job_id"
+  assert_success
+  assert_output "Unpinned job $job_id"
+
+  run "$JOB_CLI" list
+  refute_output --partial "(pinned)"
+}
@@ -162,3 +162,36 @@ load 'test_helper'
     assert_failure
     assert_output --partial "job not found"
 }
+
+@test "await-port waits until the job listens on the port" {
+    local port=$(get_random_port)
+
+    "$JOB_CLI" add -- python3 "$BATS_TEST_DIRNAME/fixtures/port_listener.py" "$port"
+    local job_id=$(get_job_field id)
+
+    run "$JOB_CLI" await-port "$job_id" --port "$port" --timeout 10
+    assert_success
+    assert_output --partial "listening on port $port"
+}
+
+@test "await-port fails when the job stops" {
+    "$JOB_CLI" add sleep 300
+    local job_id=$(get_job_field id)
+    local pid=$(get_job_field pid)
+
+    "$JOB_CLI" stop "$job_id"
+    wait_for_process_death "$pid"
+
+    run "$JOB_CLI" await-port "$job_id" --timeout 1
+    assert_failure
+    assert_output --partial "is not running"
+}
+
+@test "await-port times out when no port is opened" {
+    "$JOB_CLI" add sleep 300
+    local job_id=$(get_job_field id)
+
+    run "$JOB_CLI" await-port "$job_id" --timeout 1
+    assert_failure
+    assert_output --partial "timed out"
+}
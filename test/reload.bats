@@ -0,0 +1,44 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "reload sends HUP and keeps the run" {
+  "$JOB_CLI" add --shell 'trap "echo reloaded" HUP; echo ready; while true; do sleep 0.1; done'
+  local job_id=$(get_job_field id)
+  local log="$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log"
+  wait_for_log_content "$log" "ready"
+
+  run "$JOB_CLI" reload "$job_id"
+  assert_success
+  assert_output --regexp "^Reloaded job $job_id \(sent HUP to PID [0-9]+\)$"
+
+  wait_for_log_content "$log" "reloaded"
+  assert_equal "$(get_job_field status)" "running"
+
+  run "$JOB_CLI" runs "$job_id" --json
+  assert_equal "$(echo "$output" | jq 'length')" "1"
+  assert_equal "$(echo "$output" | jq -r '.[0].markers[1].kind')" "reloaded"
+}
+
+@test "reload sends the job's reload signal" {
+  "$JOB_CLI" add --reload-signal USR1 --shell 'trap "echo usr1" USR1; echo ready; while true; do sleep 0.1; done'
+  local job_id=$(get_job_field id)
+  local log="$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log"
+  wait_for_log_content "$log" "ready"
+
+  run "$JOB_CLI" reload "$job_id"
+  assert_success
+  assert_output --partial "sent USR1"
+
+  wait_for_log_content "$log" "usr1"
+}
+
+@test "reload fails for a stopped job" {
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" reload "$job_id"
+  assert_failure
+  assert_output --partial "is not running"
+}
@@ -5,7 +5,7 @@ load 'test_helper'
 @test "remove command requires job ID argument" {
   run "$JOB_CLI" remove
   assert_failure
-  assert_output --partial "accepts 1 arg(s)"
+  assert_output --partial "requires at least 1 arg(s)"
 }
 
 @test "remove command fails if job is running" {
@@ -139,3 +139,63 @@ load 'test_helper'
   assert_success
   assert_output "[]"
 }
+
+@test "remove command removes multiple stopped jobs" {
+  "$JOB_CLI" add sleep 300
+  local first_id=$(get_job_field id)
+  local first_pid=$(get_job_field pid)
+  "$JOB_CLI" add sleep 301
+  local second_id=$(get_job_field id)
+  local second_pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$first_id" "$second_id"
+  wait_for_process_death "$first_pid"
+  wait_for_process_death "$second_pid"
+
+  run "$JOB_CLI" remove "$first_id" "$second_id"
+  assert_success
+  assert_output --partial "Removed job $first_id"
+  assert_output --partial "Removed job $second_id"
+
+  run "$JOB_CLI" list
+  assert_output "No jobs found"
+}
+
+@test "remove command with --dry-run lists the jobs and logs without removing them" {
+  "$JOB_CLI" add sh -c 'echo output; exit 1'
+  local failed_id=$(get_job_field id)
+  "$JOB_CLI" add sh -c 'exit 0'
+  local passed_id=$(get_job_field id)
+  wait_for_job_to_stop "$failed_id"
+  wait_for_job_to_stop "$passed_id"
+
+  run "$JOB_CLI" remove --failed-only --dry-run
+  assert_success
+  assert_output --partial "Would remove job $failed_id"
+  assert_output --partial "$failed_id-1.stdout.log"
+  assert_output --partial "Would free 7 B"
+  refute_output --partial "$passed_id"
+
+  run "$JOB_CLI" list --json
+  assert_output --partial "$failed_id"
+}
+
+@test "remove command with --older-than only removes jobs inactive that long" {
+  "$JOB_CLI" add sh -c 'exit 0'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" remove --older-than 1d
+  assert_failure
+  assert_output --partial "no jobs matched"
+
+  run "$JOB_CLI" remove --older-than 0s
+  assert_success
+  assert_output --partial "Removed job $job_id"
+  assert_output --partial "Freed"
+}
+
+@test "remove command rejects filters with job IDs" {
+  run "$JOB_CLI" remove abc --failed-only
+  assert_failure
+  assert_output --partial "cannot be used with job IDs"
+}
@@ -5,7 +5,7 @@ load 'test_helper'
 @test "restart command requires job ID argument" {
   run "$JOB_CLI" restart
   assert_failure
-  assert_output --partial "accepts 1 arg(s)"
+  assert_output --partial "requires at least 1 arg(s)"
 }
 
 @test "restart command restarts a running job" {
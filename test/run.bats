@@ -291,3 +291,84 @@ load 'test_helper'
   local description=$(echo "$output" | jq -r '.[0].description')
   assert_equal "$description" "Keep this description"
 }
+
+@test "run command with --stdin-from feeds the file to stdin" {
+  echo "from file" > input.txt
+
+  run "$JOB_CLI" run --stdin-from input.txt cat
+  assert_success
+
+  local job_id=$(get_job_field id)
+  run "$JOB_CLI" stdout "$job_id"
+  assert_success
+  assert_output "from file"
+}
+
+@test "run command with --silent prints nothing on success" {
+  run "$JOB_CLI" run --silent echo hello
+  assert_success
+  assert_output ""
+}
+
+@test "run command with --silent reports a failure in one line" {
+  run "$JOB_CLI" run --silent sh -c "echo boom; exit 3"
+  assert_failure 3
+  assert_line --index 0 --regexp "^gob: job [^ ]+ failed with exit code 3: sh -c echo boom; exit 3$"
+  refute_output --partial "Job completed"
+}
+
+@test "add command rejects --silent" {
+  run "$JOB_CLI" add --silent echo hello
+  assert_failure
+  assert_output --partial "--silent can only be used with gob run"
+}
+
+@test "run command with --workdir runs the command in another directory" {
+  mkdir -p "$BATS_TEST_TMPDIR/repo/pkg"
+  cd "$BATS_TEST_TMPDIR/repo/pkg"
+
+  run "$JOB_CLI" run --workdir .. pwd
+  assert_success
+  assert_output --partial "  in $BATS_TEST_TMPDIR/repo"
+
+  local job_id=$(get_job_field id)
+  run "$JOB_CLI" stdout "$job_id"
+  assert_output "$BATS_TEST_TMPDIR/repo"
+
+  # The job stays with the directory it was run from
+  run "$JOB_CLI" list --json
+  assert_equal "$(echo "$output" | jq -r '.[0].workdir')" "$BATS_TEST_TMPDIR/repo/pkg"
+  assert_equal "$(echo "$output" | jq -r '.[0].run_dir')" "$BATS_TEST_TMPDIR/repo"
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_output --partial "(in $BATS_TEST_TMPDIR/repo)"
+}
+
+@test "run command rejects a --workdir that is not a directory" {
+  run "$JOB_CLI" run --workdir "$BATS_TEST_TMPDIR/missing" pwd
+  assert_failure
+  assert_output --partial "workdir is not a directory"
+}
+
+@test "run command with GOB_PORTABLE moves the job to another checkout" {
+  export GOB_PORTABLE=1
+  for checkout in old new; do
+    mkdir -p "$BATS_TEST_TMPDIR/$checkout/.git" "$BATS_TEST_TMPDIR/$checkout/pkg"
+    printf '[remote "origin"]\n\turl = git@example.com:me/app.git\n' > "$BATS_TEST_TMPDIR/$checkout/.git/config"
+  done
+
+  cd "$BATS_TEST_TMPDIR/old/pkg"
+  run "$JOB_CLI" run true
+  assert_success
+  local job_id=$(get_job_field id)
+
+  cd "$BATS_TEST_TMPDIR/new/pkg"
+  run "$JOB_CLI" run true
+  assert_success
+  assert_output --partial "Previous runs: 1"
+  assert_equal "$(get_job_field id)" "$job_id"
+
+  cd "$BATS_TEST_TMPDIR/old/pkg"
+  run "$JOB_CLI" list
+  assert_output "No jobs found"
+}
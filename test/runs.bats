@@ -57,6 +57,36 @@ load 'test_helper'
   assert_output --regexp "${job_id}-1"
 }
 
+@test "runs command with --limit and --offset pages through runs" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  local pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  "$JOB_CLI" start "$job_id"
+  pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  "$JOB_CLI" start "$job_id"
+  pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  run "$JOB_CLI" runs "$job_id" --limit 1 --offset 1
+  assert_success
+  assert_output --partial "${job_id}-2"
+  refute_output --partial "${job_id}-3"
+  refute_output --partial "${job_id}-1 "
+  assert_output --partial "Showing 2-2 of 3 runs (use --offset 2 for more)"
+
+  run "$JOB_CLI" runs "$job_id" --offset 2
+  assert_success
+  assert_output --partial "${job_id}-1"
+  refute_output --partial "Showing"
+}
+
 @test "runs command shows running status for active run" {
   # Start a job
   "$JOB_CLI" add sleep 300
@@ -121,3 +151,91 @@ load 'test_helper'
   assert_success
   assert_output --regexp "${job_id}-1"
 }
+
+@test "runs command with --porcelain prints tab-separated fields" {
+  "$JOB_CLI" add sh -c "exit 2"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" runs --porcelain "$job_id"
+  assert_success
+  assert_output --regexp "^${job_id}-1	stopped	2	[0-9T:Z+-]+	[0-9]+	-$"
+}
+
+@test "runs archive archives old runs, listed with --include-archived" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  local pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  "$JOB_CLI" start "$job_id"
+  pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  run "$JOB_CLI" runs archive --older-than 0s
+  assert_success
+  assert_output --partial "Archived 1 runs"
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_success
+  assert_output --partial "${job_id}-2"
+  refute_output --partial "${job_id}-1"
+
+  run "$JOB_CLI" runs "$job_id" --include-archived
+  assert_success
+  assert_output --regexp "${job_id}-1 .*\(archived\)"
+}
+
+@test "cleanup --logs removes runs whose log files were deleted" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  local pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  "$JOB_CLI" start "$job_id"
+  pid=$(get_job_field pid)
+  "$JOB_CLI" stop "$job_id"
+  wait_for_process_death "$pid"
+
+  rm "$("$JOB_CLI" runs "$job_id" --json | jq -r ".[] | select(.id == \"${job_id}-1\") | .stdout_path")"
+
+  run "$JOB_CLI" cleanup --logs
+  assert_success
+  assert_output "Removed 1 run with missing logs"
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_success
+  assert_output --partial "${job_id}-2"
+  refute_output --partial "${job_id}-1"
+
+  run "$JOB_CLI" cleanup --logs
+  assert_success
+  assert_output "No runs with missing logs"
+}
+
+@test "cleanup requires a mode" {
+  run "$JOB_CLI" cleanup
+  assert_failure
+  assert_output --partial "pass --logs"
+}
+
+@test "runs command shows how much a stopped run printed" {
+  "$JOB_CLI" run sh -c "echo one; echo two; echo oops >&2"
+  local job_id=$(get_job_field id)
+
+  for i in $(seq 1 50); do
+    [ "$("$JOB_CLI" runs --json "$job_id" | jq '.[0].output.stdout_lines')" = "2" ] && break
+    sleep 0.1
+  done
+
+  run bash -c "'$JOB_CLI' runs --json '$job_id' | jq -c '.[0].output'"
+  assert_success
+  assert_output '{"stdout_bytes":8,"stdout_lines":2,"stderr_bytes":5,"stderr_lines":1}'
+
+  run "$JOB_CLI" runs "$job_id"
+  assert_success
+  assert_output --partial "3 lines, 13 B"
+}
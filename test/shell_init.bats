@@ -0,0 +1,56 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "shell-init prints the integration for each shell" {
+  run "$JOB_CLI" shell-init zsh
+  assert_success
+  assert_output --partial "zle -N accept-line __gob_accept_line"
+  assert_output --partial "add-zsh-hook precmd __gob_precmd"
+
+  run "$JOB_CLI" shell-init fish
+  assert_success
+  assert_output --partial "function __gob_prompt --on-event fish_prompt"
+}
+
+@test "shell-init bash output is valid bash" {
+  "$JOB_CLI" shell-init bash --alias gj > init.bash
+  run bash -n init.bash
+  assert_success
+}
+
+@test "shell-init uses the given suffix and alias" {
+  run "$JOB_CLI" shell-init bash --suffix '&bg' --alias gj --prompt=false
+  assert_success
+  assert_output --partial "local suffix='&bg'"
+  assert_output --partial "gj() {"
+  refute_output --partial "PROMPT_COMMAND"
+}
+
+@test "shell-init rejects unsupported shells" {
+  run "$JOB_CLI" shell-init tcsh
+  assert_failure
+  assert_output --partial "unsupported shell: tcsh"
+}
+
+@test "shell-init rejects a suffix with quotes" {
+  run "$JOB_CLI" shell-init zsh --suffix "'g"
+  assert_failure
+  assert_output --partial "invalid suffix"
+}
+
+@test "prompt prints running and failed job counts" {
+  "$JOB_CLI" add sleep 300
+  "$JOB_CLI" run false || true
+
+  run "$JOB_CLI" prompt
+  assert_success
+  assert_output "1 1"
+}
+
+@test "prompt prints nothing when the daemon is not running" {
+  run "$JOB_CLI" prompt
+  assert_success
+  assert_output ""
+  [ ! -e "$(get_runtime_dir)/daemon.sock" ]
+}
@@ -91,3 +91,16 @@ load 'test_helper'
   assert_output --partial "Stopped 0 running job(s)"
   assert_output --partial "Daemon shut down"
 }
+
+@test "shutdown command with --dry-run stops nothing" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  local pid=$(get_job_field pid)
+
+  run "$JOB_CLI" shutdown --dry-run
+  assert_success
+  assert_output --partial "Would stop job $job_id (PID $pid): sleep 300"
+  assert_output --partial "Would stop 1 running job(s)"
+
+  assert kill -0 "$pid"
+}
@@ -5,13 +5,13 @@ load 'test_helper'
 @test "signal command requires job ID and signal arguments" {
   run "$JOB_CLI" signal
   assert_failure
-  assert_output --partial "accepts 2 arg(s)"
+  assert_output --partial "requires at least 2 arg(s)"
 }
 
 @test "signal command requires signal argument" {
   run "$JOB_CLI" signal 123456
   assert_failure
-  assert_output --partial "accepts 2 arg(s)"
+  assert_output --partial "requires at least 2 arg(s)"
 }
 
 @test "signal command sends TERM signal by name" {
@@ -134,3 +134,64 @@ load 'test_helper'
   # Should not show success duration since there are no successes
   refute_output --partial "Avg success duration:"
 }
+
+@test "stats command shows the range of failed runs apart from successes" {
+  "$JOB_CLI" add sh -c "sleep 0.2; exit 1"
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" stats "$job_id"
+  assert_success
+  assert_output --regexp "Avg failure duration: .* \(fastest .*, slowest .*\)"
+
+  run "$JOB_CLI" stats "$job_id" --json
+  assert_success
+  local failure_max=$(echo "$output" | jq -r '.failure_max_duration_ms')
+  local success_max=$(echo "$output" | jq -r '.success_max_duration_ms')
+  [ "$failure_max" -ge 200 ]
+  assert_equal "$success_max" "null"
+}
+
+@test "stats --all shows where the time goes across jobs" {
+  "$JOB_CLI" add sh -c "exit 1"
+  local failing_id=$(get_job_field id)
+  wait_for_job_to_stop "$failing_id"
+
+  "$JOB_CLI" add sh -c "sleep 0.2"
+  local slow_id=$(get_job_field id 0)
+  wait_for_job_to_stop "$slow_id"
+
+  run "$JOB_CLI" stats --all
+  assert_success
+  assert_output --partial "Time spent in the last 7d:"
+  assert_output --partial "across 2 runs"
+  assert_output --regexp "Slowest:
+  $slow_id"
+  assert_output --regexp "Most failing:
+  $failing_id  1 failure \(0% success\)"
+
+  run "$JOB_CLI" stats --all --json
+  assert_success
+  assert_equal "$(echo "$output" | jq '.jobs | length')" "2"
+  [ "$(echo "$output" | jq '.total_duration_ms')" -ge 200 ]
+}
+
+@test "stats --all does not take a job ID" {
+  run "$JOB_CLI" stats --all abc
+  assert_failure
+}
+
+@test "stats --when only counts runs started in the conditions" {
+  "$JOB_CLI" add true
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" stats "$job_id" --when busy,idle
+  assert_success
+  assert_output --partial "Runs started: busy, idle"
+  assert_output --partial "No completed runs yet"
+
+  run "$JOB_CLI" stats "$job_id" --when sunny
+  assert_failure
+  assert_output --partial 'invalid condition "sunny"'
+}
@@ -5,13 +5,13 @@ load 'test_helper'
 @test "stdout command requires job ID argument" {
   run "$JOB_CLI" stdout
   assert_failure
-  assert_output --partial "accepts 1 arg(s)"
+  assert_output --partial "give either a job ID or --run"
 }
 
 @test "stderr command requires job ID argument" {
   run "$JOB_CLI" stderr
   assert_failure
-  assert_output --partial "accepts 1 arg(s)"
+  assert_output --partial "give either a job ID or --run"
 }
 
 @test "stdout command fails for non-existent job" {
@@ -161,3 +161,92 @@ load 'test_helper'
   assert_success
   assert_output ""
 }
+
+@test "stdout --tail shows the last lines" {
+  "$JOB_CLI" add -- sh -c 'echo one; echo two; echo three'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "three"
+
+  run "$JOB_CLI" stdout --tail 2 "$job_id"
+  assert_success
+  assert_output "two
+three"
+}
+
+@test "stdout --run shows the output of an earlier run" {
+  "$JOB_CLI" add -- sh -c 'echo "run-$(date +%s%N)"'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "run-"
+  wait_for_process_death "$(get_job_field pid)" || sleep 0.2
+  local first_output=$(cat "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log")
+
+  "$JOB_CLI" start "$job_id"
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-2.stdout.log" "run-"
+
+  run "$JOB_CLI" stdout --run "${job_id}-1"
+  assert_success
+  assert_output "$first_output"
+
+  # A finished run is printed, not followed
+  run "$JOB_CLI" stderr --follow --run "${job_id}-1"
+  assert_success
+  assert_output ""
+}
+
+@test "stdout --run fails for a non-existent run" {
+  "$JOB_CLI" add echo hello
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" stdout --run "${job_id}-9"
+  assert_failure
+  assert_output --partial "run not found"
+}
+
+@test "stdout --clean keeps the last state of progress bars" {
+  "$JOB_CLI" add -- sh -c 'printf "10%%\r50%%\r100%%\n\033[2Kdone\n"'
+  local job_id=$(get_job_field id)
+
+  wait_for_log_content "$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log" "done"
+
+  run "$JOB_CLI" stdout --clean "$job_id"
+  assert_success
+  assert_output "100%
+done"
+}
+
+@test "stdout reads logs compressed once the run stopped" {
+  export GOB_COMPRESS_MIN_SIZE=1K
+  "$JOB_CLI" run seq 1 2000
+  local job_id=$(get_job_field id)
+
+  local log="$XDG_STATE_HOME/gob/logs/${job_id}-1.stdout.log"
+  for i in $(seq 1 50); do
+    [ -f "$log.zst" ] && break
+    sleep 0.1
+  done
+  [ -f "$log.zst" ]
+  [ ! -f "$log" ]
+
+  run "$JOB_CLI" stdout "$job_id" --tail 1
+  assert_success
+  assert_output "2000"
+
+  run "$JOB_CLI" grep "^1999$"
+  assert_success
+  assert_output --partial "stdout:1999: 1999"
+}
+
+@test "stdout shows the size of binary output unless --raw" {
+  "$JOB_CLI" run sh -c "printf 'ustar\000\000\001\002'"
+  local job_id=$(get_job_field id)
+
+  run "$JOB_CLI" stdout "$job_id"
+  assert_success
+  assert_output "[binary output: 9 bytes] (use --raw to print it)"
+
+  run bash -c "'$JOB_CLI' stdout --raw '$job_id' | wc -c"
+  assert_success
+  assert_output --partial "9"
+}
@@ -5,7 +5,7 @@ load 'test_helper'
 @test "stop command requires job ID argument" {
   run "$JOB_CLI" stop
   assert_failure
-  assert_output --partial "accepts 1 arg(s)"
+  assert_output --partial "requires at least 1 arg(s)"
 }
 
 @test "stop command stops a running job" {
@@ -165,3 +165,73 @@ load 'test_helper'
   assert_success
   assert_output "Force stopped job $job_id (PID $pid)"
 }
+
+@test "stop command stops children that left the process tree" {
+  # The subshell exits, re-parenting a child that started its own session
+  "$JOB_CLI" add sh -c '(setsid sleep 300 & echo $! > child.pid); exec sleep 300'
+  local job_id=$(get_job_field id)
+
+  # Wait for the child PID to be written
+  for i in $(seq 1 50); do
+    [ -s child.pid ] && break
+    sleep 0.1
+  done
+  local child_pid=$(cat child.pid)
+  assert kill -0 "$child_pid"
+
+  run "$JOB_CLI" stop "$job_id"
+  assert_success
+
+  wait_for_process_death "$child_pid"
+  run kill -0 "$child_pid"
+  assert_failure
+}
+
+@test "stop command stops multiple jobs" {
+  "$JOB_CLI" add sleep 300
+  local first_id=$(get_job_field id)
+  local first_pid=$(get_job_field pid)
+  "$JOB_CLI" add sleep 301
+  local second_id=$(get_job_field id)
+  local second_pid=$(get_job_field pid)
+
+  run "$JOB_CLI" stop "$first_id" "$second_id"
+  assert_success
+  assert_output --partial "Stopped job $first_id (PID $first_pid)"
+  assert_output --partial "Stopped job $second_id (PID $second_pid)"
+
+  wait_for_process_death "$first_pid"
+  wait_for_process_death "$second_pid"
+}
+
+@test "stop command with --match stops matching jobs" {
+  "$JOB_CLI" add sleep 300
+  local sleep_pid=$(get_job_field pid)
+  "$JOB_CLI" add sleep 301
+  local other_pid=$(get_job_field pid)
+  "$JOB_CLI" add tail -f /dev/null
+  local tail_pid=$(get_job_field pid)
+
+  run "$JOB_CLI" stop --match "sleep *"
+  assert_success
+
+  wait_for_process_death "$sleep_pid"
+  wait_for_process_death "$other_pid"
+
+  # Non-matching job keeps running
+  assert kill -0 "$tail_pid"
+}
+
+@test "stop command stops no job of a batch with unknown jobs" {
+  "$JOB_CLI" add sleep 300
+  local job_id=$(get_job_field id)
+  local pid=$(get_job_field pid)
+
+  run "$JOB_CLI" stop "$job_id" nonexistent
+  assert_failure
+  assert_output --partial "job not found: nonexistent"
+  assert_output --partial "stop failed for 2 of 2 jobs"
+
+  # The valid job keeps running
+  assert kill -0 "$pid"
+}
@@ -0,0 +1,46 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "summary reports the verdict of labelled runs" {
+  "$JOB_CLI" run --label pr-1 true
+  "$JOB_CLI" run --label pr-1 sh -c 'exit 3' || true
+  "$JOB_CLI" run true
+
+  run "$JOB_CLI" summary --label pr-1
+  assert_failure 1
+  assert_output --partial "sh -c exit 3 (exit 3)"
+  assert_output --partial "pr-1: failed (1 passed, 1 failed, 0 running)"
+}
+
+@test "summary counts only the latest labelled run of each job" {
+  "$JOB_CLI" add --label pr-2 sh -c 'test -f marker'
+  local job_id=$(get_job_field id)
+  wait_for_job_to_stop "$job_id"
+
+  touch marker
+  "$JOB_CLI" add --label pr-2 sh -c 'test -f marker'
+  wait_for_job_to_stop "$job_id"
+
+  run "$JOB_CLI" summary --label pr-2
+  assert_success
+  assert_output --partial "pr-2: passed (1 passed, 0 failed, 0 running)"
+
+  run "$JOB_CLI" history --label pr-2
+  assert_success
+  assert_equal "$(echo "$output" | grep -c 'test -f marker')" "2"
+}
+
+@test "summary exits 2 while a labelled run is running" {
+  "$JOB_CLI" add --label pr-3 sleep 300
+
+  run "$JOB_CLI" summary --label pr-3 --json
+  assert_failure 2
+  assert_output --partial '"verdict": "running"'
+}
+
+@test "summary fails without runs for the label" {
+  run "$JOB_CLI" summary --label missing
+  assert_failure
+  assert_output --partial "no runs with label missing"
+}
@@ -0,0 +1,58 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "add command with --tag stores tags" {
+  run "$JOB_CLI" add --tag slow --tag backend sleep 300
+  assert_success
+
+  run "$JOB_CLI" list --json
+  assert_success
+  local tags=$(echo "$output" | jq -r '.[0].tags | join(",")')
+  assert_equal "$tags" "backend,slow"
+}
+
+@test "list command with --tag only shows tagged jobs" {
+  "$JOB_CLI" add --tag backend sleep 300
+  "$JOB_CLI" add --tag frontend sleep 301
+
+  run "$JOB_CLI" list --tag backend
+  assert_success
+  assert_output --partial "sleep 300"
+  refute_output --partial "sleep 301"
+}
+
+@test "stop command with --tag stops all tagged jobs" {
+  "$JOB_CLI" add --tag backend sleep 300
+  local pid1=$(get_job_field pid)
+  "$JOB_CLI" add --tag backend sleep 301
+  local pid2=$(get_job_field pid)
+  "$JOB_CLI" add --tag frontend sleep 302
+  local pid3=$(get_job_field pid)
+
+  run "$JOB_CLI" stop --tag backend
+  assert_success
+
+  wait_for_process_death "$pid1"
+  wait_for_process_death "$pid2"
+  run kill -0 "$pid3"
+  assert_success
+}
+
+@test "restart command with --tag restarts all tagged jobs" {
+  "$JOB_CLI" add --tag backend sleep 300
+  local pid=$(get_job_field pid)
+
+  run "$JOB_CLI" restart --tag backend
+  assert_success
+  assert_output --partial "Restarted job"
+
+  local new_pid=$(get_job_field pid)
+  [ "$pid" != "$new_pid" ]
+}
+
+@test "stop command with unknown tag fails" {
+  run "$JOB_CLI" stop --tag nothing
+  assert_failure
+  assert_output --partial "no jobs matched"
+}
@@ -0,0 +1,44 @@
+#!/usr/bin/env bats
+
+load 'test_helper'
+
+@test "telemetry status shows the environment override" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.xdg-config"
+  run "$JOB_CLI" telemetry status
+  assert_success
+  assert_output "Telemetry: off (from environment)"
+}
+
+@test "telemetry local saves the mode and writes events to a file" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.xdg-config"
+  unset GOB_TELEMETRY_DISABLED
+
+  run "$JOB_CLI" telemetry status
+  assert_success
+  assert_output "Telemetry: off (not chosen yet)"
+
+  run "$JOB_CLI" telemetry local
+  assert_success
+  assert_output "Telemetry: local"
+  grep -q 'mode = .local.' "$XDG_CONFIG_HOME/gob/telemetry.toml"
+
+  run "$JOB_CLI" telemetry status
+  assert_success
+  assert_line --index 0 "Telemetry: local (from config)"
+  assert_line --index 1 "Events are written to $XDG_STATE_HOME/gob/telemetry.jsonl"
+
+  run "$JOB_CLI" list
+  assert_success
+  run jq -r .event "$XDG_STATE_HOME/gob/telemetry.jsonl"
+  assert_output --partial "cli:command_run"
+}
+
+@test "GOB_TELEMETRY overrides the saved mode" {
+  export XDG_CONFIG_HOME="$BATS_TEST_TMPDIR/.xdg-config"
+  unset GOB_TELEMETRY_DISABLED
+  "$JOB_CLI" telemetry on
+
+  GOB_TELEMETRY=off run "$JOB_CLI" telemetry status
+  assert_success
+  assert_output "Telemetry: off (from environment)"
+}